@@ -30,7 +30,7 @@ export default function UserCrud() {
       console.log("fetchUsers response", res.status, data);
       // Use the generated type for list response
       const usersList: UsersListResponse = data;
-      setUsers(usersList.users || []);
+      setUsers(usersList.users);
       console.log("setUsers called with", usersList.users);
     } catch {
       alert("Error fetching users");
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// benchOps are the requests `bench` can mix, by name.
+var benchOps = []string{"create", "get", "list", "update", "delete"}
+
+// runBench implements `api bench`: it sends a fixed rate of CRUD requests to
+// a running API for a while and prints latency percentiles per operation. It
+// returns the process exit code.
+//
+//	go run ./backend/api bench --target http://localhost:8080 --rps 200 --duration 30s --mix create=1,get=6,list=2,update=1
+func runBench(args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	target := fs.String("target", "http://localhost:8080", "base URL of the API")
+	rps := fs.Int("rps", 50, "requests per second to send")
+	duration := fs.Duration("duration", 10*time.Second, "how long to send requests")
+	concurrency := fs.Int("concurrency", 64, "maximum requests in flight; ticks beyond it are counted as dropped")
+	mix := fs.String("mix", "create=1,get=5,list=3,update=1", "relative weights of "+strings.Join(benchOps, ", "))
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	weights, err := parseMix(*mix)
+	if err != nil || *rps <= 0 || *concurrency <= 0 {
+		fmt.Fprintf(os.Stderr, "bench: invalid flags: %v\n", err)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *duration)
+	defer cancel()
+
+	b := &bencher{
+		target:  strings.TrimSuffix(*target, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+		results: map[string]*benchResult{},
+	}
+	fmt.Printf("Sending %d req/s to %s for %s (mix %s)\n", *rps, b.target, *duration, *mix)
+
+	sem := make(chan struct{}, *concurrency)
+	tick := time.NewTicker(time.Second / time.Duration(*rps))
+	defer tick.Stop()
+	var wg sync.WaitGroup
+	dropped := 0
+	start := time.Now()
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-tick.C:
+		}
+		select {
+		case sem <- struct{}{}:
+		default:
+			dropped++
+			continue
+		}
+		op := pickOp(weights)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			b.run(op)
+		}()
+	}
+	wg.Wait()
+	b.report(os.Stdout, time.Since(start), dropped)
+	return 0
+}
+
+// parseMix reads weights like "create=1,get=5".
+func parseMix(s string) (map[string]int, error) {
+	weights := map[string]int{}
+	for _, part := range strings.Split(s, ",") {
+		name, w, ok := strings.Cut(strings.TrimSpace(part), "=")
+		n, err := strconv.Atoi(w)
+		if !ok || err != nil || n < 0 {
+			return nil, fmt.Errorf("mix entry %q is not name=weight", part)
+		}
+		if !slices.Contains(benchOps, name) {
+			return nil, fmt.Errorf("unknown operation %q in mix", name)
+		}
+		weights[name] = n
+	}
+	total := 0
+	for _, n := range weights {
+		total += n
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("mix has no weight")
+	}
+	return weights, nil
+}
+
+func pickOp(weights map[string]int) string {
+	total := 0
+	for _, op := range benchOps {
+		total += weights[op]
+	}
+	n := rand.IntN(total)
+	for _, op := range benchOps {
+		if n < weights[op] {
+			return op
+		}
+		n -= weights[op]
+	}
+	return benchOps[0]
+}
+
+type bencher struct {
+	target string
+	client *http.Client
+
+	mu      sync.Mutex
+	ids     []string // users created during the run
+	results map[string]*benchResult
+}
+
+type benchResult struct {
+	latencies []time.Duration
+	statuses  map[int]int // 0 counts transport errors
+}
+
+// run sends one request of kind op. Operations that need a user pick one
+// created earlier in the run, and fall back to creating one.
+func (b *bencher) run(op string) {
+	id := b.randomID(op == "delete")
+	if id == "" && op != "list" {
+		op = "create"
+	}
+	var method, path string
+	var body any
+	switch op {
+	case "create":
+		n := rand.Int64()
+		method, path = http.MethodPost, "/v1/users"
+		body = map[string]string{"name": fmt.Sprintf("Bench %d", n), "email": fmt.Sprintf("bench%d@example.com", n)}
+	case "get":
+		method, path = http.MethodGet, "/v1/users/"+id
+	case "list":
+		method, path = http.MethodGet, "/v1/users?per_page=20"
+	case "update":
+		method, path = http.MethodPut, "/v1/users/"+id
+		body = map[string]string{"name": fmt.Sprintf("Bench %d", rand.Int64())}
+	case "delete":
+		method, path = http.MethodDelete, "/v1/users/"+id
+	}
+
+	status, created, elapsed := b.send(method, path, body)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if created != "" {
+		b.ids = append(b.ids, created)
+	}
+	r := b.results[op]
+	if r == nil {
+		r = &benchResult{statuses: map[int]int{}}
+		b.results[op] = r
+	}
+	r.latencies = append(r.latencies, elapsed)
+	r.statuses[status]++
+}
+
+// randomID returns a user created during the run, removing it from the pool
+// if take is set, or "" if there is none yet.
+func (b *bencher) randomID(take bool) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.ids) == 0 {
+		return ""
+	}
+	i := rand.IntN(len(b.ids))
+	id := b.ids[i]
+	if take {
+		b.ids[i] = b.ids[len(b.ids)-1]
+		b.ids = b.ids[:len(b.ids)-1]
+	}
+	return id
+}
+
+// send returns the status (0 on transport errors), the ID of a user created by
+// the request, and how long it took until the body was read.
+func (b *bencher) send(method, path string, body any) (int, string, time.Duration) {
+	var payload io.Reader
+	if body != nil {
+		buf, _ := json.Marshal(body)
+		payload = bytes.NewReader(buf)
+	}
+	req, err := http.NewRequest(method, b.target+path, payload)
+	if err != nil {
+		return 0, "", 0
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	start := time.Now()
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return 0, "", time.Since(start)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	elapsed := time.Since(start)
+	var created struct {
+		ID string `json:"id"`
+	}
+	if resp.StatusCode == http.StatusCreated {
+		json.Unmarshal(data, &created)
+	}
+	return resp.StatusCode, created.ID, elapsed
+}
+
+func (b *bencher) report(w io.Writer, elapsed time.Duration, dropped int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	all := &benchResult{statuses: map[int]int{}}
+	fmt.Fprintf(w, "\n%-8s %7s %9s %9s %9s %9s  %s\n", "op", "count", "p50", "p90", "p99", "max", "statuses")
+	for _, op := range append(append([]string{}, benchOps...), "total") {
+		r := b.results[op]
+		if op == "total" {
+			r = all
+		}
+		if r == nil || len(r.latencies) == 0 {
+			continue
+		}
+		if op != "total" {
+			all.latencies = append(all.latencies, r.latencies...)
+			for status, n := range r.statuses {
+				all.statuses[status] += n
+			}
+		}
+		sort.Slice(r.latencies, func(i, j int) bool { return r.latencies[i] < r.latencies[j] })
+		fmt.Fprintf(w, "%-8s %7d %9s %9s %9s %9s  %s\n", op, len(r.latencies),
+			percentile(r.latencies, 50), percentile(r.latencies, 90), percentile(r.latencies, 99),
+			r.latencies[len(r.latencies)-1].Round(time.Microsecond), formatStatuses(r.statuses))
+	}
+	fmt.Fprintf(w, "\n%d requests in %s (%.1f req/s), %d dropped at the concurrency limit\n",
+		len(all.latencies), elapsed.Round(time.Millisecond), float64(len(all.latencies))/elapsed.Seconds(), dropped)
+}
+
+// percentile expects sorted latencies.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	i := (len(sorted)*p+99)/100 - 1
+	return sorted[max(i, 0)].Round(time.Microsecond)
+}
+
+func formatStatuses(statuses map[int]int) string {
+	codes := make([]int, 0, len(statuses))
+	for code := range statuses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	parts := make([]string, len(codes))
+	for i, code := range codes {
+		label := strconv.Itoa(code)
+		if code == 0 {
+			label = "error"
+		}
+		parts[i] = fmt.Sprintf("%s×%d", label, statuses[code])
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/server"
+)
+
+// runCheck implements `api check`: it loads the configuration as the server
+// would, connects to every dependency it configures and validates the store
+// and the OpenAPI spec, then prints a report, without serving anything. It
+// returns the process exit code, 1 if any check failed, so it can gate a
+// deploy or run as an init container.
+//
+//	go run ./backend/api check -timeout 10s
+func runCheck(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", 30*time.Second, "how long all the checks may take")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	start := time.Now()
+	cfg, err := server.ConfigFromEnv()
+	results := []server.CheckResult{{Name: "config", Status: server.CheckOK, Duration: time.Since(start)}}
+	if err != nil {
+		results[0].Status, results[0].Detail = server.CheckFailed, err.Error()
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		defer cancel()
+		results = append(results, server.Check(ctx, cfg)...)
+	}
+	ok := true
+	for _, r := range results {
+		ok = ok && r.Status != server.CheckFailed
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(struct {
+			OK     bool                 `json:"ok"`
+			Checks []server.CheckResult `json:"checks"`
+		}{ok, results})
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, r := range results {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Status, r.Name, r.Duration.Round(time.Millisecond), r.Detail)
+		}
+		w.Flush()
+	}
+	if !ok {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/server"
+)
+
+// parseSpecFlags reads the `gen:openapi` flags, e.g.
+// `gen:openapi -bundled -yaml=false -sign-key contracts.pem`.
+func parseSpecFlags(args []string) (server.SpecOptions, error) {
+	opts := server.SpecOptions{}
+	fs := flag.NewFlagSet("gen:openapi", flag.ContinueOnError)
+	fs.BoolVar(&opts.YAML, "yaml", true, "also write the spec as YAML")
+	fs.BoolVar(&opts.Bundled, "bundled", false, "also write a variant with all schema $refs inlined, for tools that can't follow them")
+	signKey := fs.String("sign-key", "", "sign the checksums with the Ed25519 private key in this PEM file")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if *signKey != "" {
+		b, err := os.ReadFile(*signKey)
+		if err == nil {
+			opts.SigningKey, err = server.ParseSigningKey(b)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "gen:openapi: -sign-key: %v\n", err)
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+// serveFlags are the `serve` flags.
+type serveFlags struct {
+	Dev  bool
+	Mock bool
+}
+
+// parseServeFlags reads the `serve` flags, e.g. `serve --dev`. Running the
+// binary without a subcommand serves with the defaults.
+func parseServeFlags(args []string) (serveFlags, error) {
+	var f serveFlags
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.BoolVar(&f.Dev, "dev", false, "log request and response bodies, pretty-print JSON, allow any CORS origin and return stack traces for panics")
+	fs.BoolVar(&f.Mock, "mock", false, "answer every operation with the example response generated from the OpenAPI spec instead of running its logic")
+	err := fs.Parse(args)
+	return f, err
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/mock"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/server"
+)
+
+// runGenMock implements `api gen:mock`: it writes the fixtures of
+// packages/api-mock, every operation's canned responses as `serve --mock`
+// answers them, generated from the same spec as gen:openapi, so the fake
+// the web app's tests run against can't drift from the contract. It returns
+// the process exit code.
+//
+//	go run ./backend/api gen:mock -out packages/api-mock/src/fixtures.ts
+func runGenMock(args []string) int {
+	fs := flag.NewFlagSet("gen:mock", flag.ContinueOnError)
+	out := fs.String("out", "packages/api-mock/src/fixtures.ts", "where to write the fixtures module")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	cfg, err := server.ConfigFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gen:mock: invalid configuration: %v\n", err)
+		return 1
+	}
+	srv := server.NewServer(server.Config{MetadataSchema: cfg.MetadataSchema}, server.NewMemoryStore())
+	b, err := mock.FixturesModule(srv.OpenAPI())
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(*out), 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(*out, b, 0644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gen:mock: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Mock fixtures written to %s\n", *out)
+	return 0
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/buildinfo"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/server"
+)
+
+// runGenSpec implements `api gen:openapi`: it writes the OpenAPI spec to
+// OPENAPI_PATH, stamped with the build, and the variants the flags select.
+// It is the only command that writes the spec; a running server serves it
+// from memory, so it needs no writable filesystem. The server is built on
+// an empty store, as the spec doesn't depend on what the store holds. It
+// returns the process exit code.
+//
+//	go run ./backend/api gen:openapi -bundled
+func runGenSpec(args []string) int {
+	opts, err := parseSpecFlags(args)
+	if err != nil {
+		return 2
+	}
+	cfg, err := server.ConfigFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gen:openapi: invalid configuration: %v\n", err)
+		return 1
+	}
+	info := buildinfo.Get()
+	opts.Build = server.SpecBuild{Version: info.Version, Commit: cmp.Or(info.Commit, gitCommit())}
+	srv := server.NewServer(server.Config{MetadataSchema: cfg.MetadataSchema}, server.NewMemoryStore())
+	written, err := srv.WriteSpec(cfg.OpenAPIPath, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gen:openapi: %v\n", err)
+		return 1
+	}
+	fmt.Printf("OpenAPI spec written to %s\n", strings.Join(written, ", "))
+	return 0
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// runHealthcheck implements `api healthcheck`: it asks a running server's
+// readiness probe, or the one named by -probe, how it is and prints the
+// answer in the plain format. It returns the process exit code, 0 if the
+// probe passed and 1 if not or if the server didn't answer, as Docker's
+// HEALTHCHECK and exec probes expect; the image has no curl.
+//
+//	./backend-api healthcheck -probe livez -timeout 2s
+func runHealthcheck(args []string) int {
+	fs := flag.NewFlagSet("healthcheck", flag.ContinueOnError)
+	target := fs.String("target", "http://localhost:"+cmp.Or(os.Getenv("API_PORT"), "8080"), "base URL of the API")
+	probe := fs.String("probe", "readyz", "which probe to ask: readyz, livez or health")
+	timeout := fs.Duration("timeout", 3*time.Second, "how long to wait for the answer")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	switch *probe {
+	case "readyz", "livez", "health":
+	default:
+		fmt.Fprintf(os.Stderr, "healthcheck: -probe: want readyz, livez or health, got %q\n", *probe)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	u, err := url.JoinPath(*target, *probe)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "healthcheck: -target: %v\n", err)
+		return 2
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u+"?format=plain", nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "healthcheck: %v\n", err)
+		return 2
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "healthcheck: %v\n", err)
+		return 1
+	}
+	defer resp.Body.Close()
+	io.Copy(os.Stdout, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,47 @@
+package apitest
+
+import "github.com/rohanchauhan02/monorepo-demo/backend/api/internal/server"
+
+// IDs of the users returned by Users.
+const (
+	AdaID   = "fixture-ada"
+	GraceID = "fixture-grace"
+	LinusID = "fixture-linus"
+)
+
+// AdminToken is the admin bearer token New configures unless WithConfig sets
+// another one.
+const AdminToken = "fixture-admin-token"
+
+// Users returns fresh copies of the standard fixture users: Ada and Grace are
+// active, Grace is tagged "beta", and Linus is suspended.
+func Users() []*server.User {
+	return []*server.User{
+		{
+			ID:       AdaID,
+			Username: "ada",
+			Name:     "Ada Lovelace",
+			Email:    "ada@example.com",
+			Status:   server.UserStatusActive,
+			Active:   true,
+			Metadata: map[string]any{"plan": "pro"},
+		},
+		{
+			ID:       GraceID,
+			Username: "grace",
+			Name:     "Grace Hopper",
+			Email:    "grace@example.com",
+			Phone:    "+436601234567",
+			Status:   server.UserStatusActive,
+			Active:   true,
+			Tags:     []string{"beta"},
+		},
+		{
+			ID:       LinusID,
+			Username: "linus",
+			Name:     "Linus Torvalds",
+			Email:    "linus@example.com",
+			Status:   server.UserStatusSuspended,
+		},
+	}
+}
@@ -0,0 +1,240 @@
+package apitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/server"
+)
+
+// Request is a request being built. Finish it with Do.
+type Request struct {
+	s      *Server
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   any
+}
+
+// Request starts a request to path, which may include a query string.
+func (s *Server) Request(method, path string) *Request {
+	return &Request{s: s, method: method, path: path, query: url.Values{}, header: http.Header{}}
+}
+
+// Get starts a GET request.
+func (s *Server) Get(path string) *Request { return s.Request(http.MethodGet, path) }
+
+// Post starts a POST request. See Request.Body for what body may be.
+func (s *Server) Post(path string, body any) *Request {
+	return s.Request(http.MethodPost, path).Body(body)
+}
+
+// Put starts a PUT request. See Request.Body for what body may be.
+func (s *Server) Put(path string, body any) *Request {
+	return s.Request(http.MethodPut, path).Body(body)
+}
+
+// Delete starts a DELETE request.
+func (s *Server) Delete(path string) *Request { return s.Request(http.MethodDelete, path) }
+
+// Query adds a query parameter.
+func (r *Request) Query(key, value string) *Request {
+	r.query.Add(key, value)
+	return r
+}
+
+// Header sets a request header.
+func (r *Request) Header(key, value string) *Request {
+	r.header.Set(key, value)
+	return r
+}
+
+// AsAdmin sends the request with AdminToken.
+func (r *Request) AsAdmin() *Request {
+	return r.Header("Authorization", "Bearer "+AdminToken)
+}
+
+// Body sets the request body: a string or []byte is sent as is, anything else
+// is encoded as JSON. Either way it is sent as application/json unless a
+// Content-Type header is set.
+func (r *Request) Body(body any) *Request {
+	r.body = body
+	return r
+}
+
+// Do sends the request, failing the test if it can't be sent.
+func (r *Request) Do() *Response {
+	t := r.s.t
+	t.Helper()
+	var payload io.Reader
+	if r.body != nil {
+		var b []byte
+		switch body := r.body.(type) {
+		case string:
+			b = []byte(body)
+		case []byte:
+			b = body
+		default:
+			var err error
+			if b, err = json.Marshal(body); err != nil {
+				t.Fatalf("apitest: encode %s %s body: %v", r.method, r.path, err)
+			}
+		}
+		payload = bytes.NewReader(b)
+		if r.header.Get("Content-Type") == "" {
+			r.header.Set("Content-Type", "application/json")
+		}
+	}
+	u := r.s.URL + r.path
+	if len(r.query) > 0 {
+		sep := "?"
+		if strings.Contains(r.path, "?") {
+			sep = "&"
+		}
+		u += sep + r.query.Encode()
+	}
+	req, err := http.NewRequest(r.method, u, payload)
+	if err != nil {
+		t.Fatalf("apitest: %v", err)
+	}
+	req.Header = r.header
+	resp, err := r.s.Client().Do(req)
+	if err != nil {
+		t.Fatalf("apitest: %s %s: %v", r.method, r.path, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("apitest: read %s %s response: %v", r.method, r.path, err)
+	}
+	return &Response{Response: resp, Body: body, t: t, name: r.method + " " + r.path, tracer: r.s.tracer}
+}
+
+// Response is a completed response. Its assertion methods report failures
+// with t.Errorf and return the response, so they can be chained.
+type Response struct {
+	*http.Response
+	Body   []byte
+	t      testing.TB
+	name   string
+	tracer *server.CallTracer
+}
+
+// Status asserts the status code.
+func (r *Response) Status(want int) *Response {
+	r.t.Helper()
+	if r.StatusCode != want {
+		r.t.Errorf("%s: got status %d, want %d; body: %s", r.name, r.StatusCode, want, r.Body)
+	}
+	return r
+}
+
+// HasHeader asserts a response header value.
+func (r *Response) HasHeader(key, want string) *Response {
+	r.t.Helper()
+	if got := r.Header.Get(key); got != want {
+		r.t.Errorf("%s: got header %s %q, want %q", r.name, key, got, want)
+	}
+	return r
+}
+
+// Decode decodes the JSON body into v, failing the test if it can't.
+func (r *Response) Decode(v any) *Response {
+	r.t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		r.t.Fatalf("%s: decode body: %v; body: %s", r.name, err, r.Body)
+	}
+	return r
+}
+
+// Field asserts the value at a dot-separated path in the JSON body, such as
+// "users.0.name". want is compared after a JSON round trip, so 3 matches 3.0
+// and a []string matches a JSON array of strings.
+func (r *Response) Field(path string, want any) *Response {
+	r.t.Helper()
+	got, err := r.lookup(path)
+	if err != nil {
+		r.t.Errorf("%s: %v; body: %s", r.name, err, r.Body)
+		return r
+	}
+	if w := normalize(want); !reflect.DeepEqual(got, w) {
+		r.t.Errorf("%s: %s is %#v, want %#v", r.name, path, got, w)
+	}
+	return r
+}
+
+// Calls returns the calls serving the request went through, in order, as
+// "middleware:authenticate", "handler:get-v1-users" or "store:GetUser". It
+// fails the test unless the server was started WithCallTracing.
+func (r *Response) Calls() []string {
+	r.t.Helper()
+	if r.tracer == nil {
+		r.t.Fatalf("%s: calls aren't traced; start the server WithCallTracing", r.name)
+	}
+	var calls []string
+	for _, c := range r.tracer.Calls(r.Header.Get("X-Request-ID")) {
+		calls = append(calls, c.String())
+	}
+	return calls
+}
+
+// CalledInOrder asserts that serving the request went through the calls,
+// in that order, with any others before, between or after them.
+func (r *Response) CalledInOrder(want ...string) *Response {
+	r.t.Helper()
+	calls := r.Calls()
+	i := 0
+	for _, c := range calls {
+		if i < len(want) && c == want[i] {
+			i++
+		}
+	}
+	if i < len(want) {
+		r.t.Errorf("%s: %s isn't called after %v; calls: %v", r.name, want[i], want[:i], calls)
+	}
+	return r
+}
+
+func (r *Response) lookup(path string) (any, error) {
+	var v any
+	if err := json.Unmarshal(r.Body, &v); err != nil {
+		return nil, fmt.Errorf("body is not JSON: %w", err)
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			child, ok := node[key]
+			if !ok {
+				return nil, fmt.Errorf("%s: no %q", path, key)
+			}
+			v = child
+		case []any:
+			var i int
+			if _, err := fmt.Sscan(key, &i); err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("%s: no index %q in array of %d", path, key, len(node))
+			}
+			v = node[i]
+		default:
+			return nil, fmt.Errorf("%s: %q is not an object or array", path, key)
+		}
+	}
+	return v, nil
+}
+
+func normalize(v any) any {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out any
+	json.Unmarshal(b, &out)
+	return out
+}
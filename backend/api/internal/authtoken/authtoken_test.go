@@ -0,0 +1,55 @@
+package authtoken
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSigner(t *testing.T, b byte) *Signer {
+	t.Helper()
+	s, err := NewSigner(bytes.Repeat([]byte{b}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestIssueVerify(t *testing.T) {
+	s := testSigner(t, 1)
+	token, issued, err := s.Issue(Claims{Subject: "ada", ImpersonatedBy: "sam@support"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !LooksLikeToken(token) {
+		t.Fatalf("token %q doesn't look like one", token)
+	}
+	got, err := s.Verify(token, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *issued || got.Subject != "ada" || got.ImpersonatedBy != "sam@support" || got.ID == "" {
+		t.Fatalf("Verify = %+v, issued %+v", got, issued)
+	}
+
+	if _, err := s.Verify(token, time.Now().Add(time.Hour)); !errors.Is(err, ErrExpired) {
+		t.Errorf("Verify after expiry = %v, want ErrExpired", err)
+	}
+	if _, err := testSigner(t, 2).Verify(token, time.Now()); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Verify under another key = %v, want ErrInvalid", err)
+	}
+	head, rest, _ := strings.Cut(token, ".")
+	_, sig, _ := strings.Cut(rest, ".")
+	forged := head + "." + b64.EncodeToString([]byte(`{"sub":"grace","exp":9999999999}`)) + "." + sig
+	if _, err := s.Verify(forged, time.Now()); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Verify of a changed payload = %v, want ErrInvalid", err)
+	}
+}
+
+func TestNewSignerRejectsShortKey(t *testing.T) {
+	if _, err := NewSigner([]byte("short")); err == nil {
+		t.Fatal("NewSigner accepted a 5 byte key")
+	}
+}
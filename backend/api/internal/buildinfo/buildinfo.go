@@ -0,0 +1,46 @@
+// Package buildinfo describes the running binary. Release builds set the
+// variables with -ldflags, e.g.
+//
+//	go build -ldflags "-X github.com/rohanchauhan02/monorepo-demo/backend/api/internal/buildinfo.Version=v1.2.0 \
+//	  -X github.com/rohanchauhan02/monorepo-demo/backend/api/internal/buildinfo.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/rohanchauhan02/monorepo-demo/backend/api/internal/buildinfo.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Builds without them fall back to the VCS details the Go toolchain embeds.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set with -ldflags -X at build time.
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+// Info is the build metadata of the running binary.
+type Info struct {
+	Version   string
+	Commit    string
+	Date      string
+	GoVersion string
+}
+
+// Get returns the build metadata, filling Commit and Date from the embedded
+// VCS stamp when -ldflags didn't set them.
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, Date: Date, GoVersion: runtime.Version()}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.Date == "":
+				info.Date = s.Value
+			}
+		}
+	}
+	return info
+}
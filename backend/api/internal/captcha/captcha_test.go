@@ -0,0 +1,49 @@
+package captcha
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch {
+		case r.Form.Get("secret") != "s3cret":
+			fmt.Fprint(w, `{"success":false,"error-codes":["invalid-input-secret"]}`)
+		case r.Form.Get("response") == "human" && r.Form.Get("remoteip") == "192.0.2.1":
+			fmt.Fprint(w, `{"success":true}`)
+		case r.Form.Get("response") == "borderline":
+			fmt.Fprint(w, `{"success":true,"score":0.3}`)
+		default:
+			fmt.Fprint(w, `{"success":false,"error-codes":["invalid-input-response"]}`)
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	v, err := New(Turnstile, "s3cret", WithEndpoint(srv.URL), WithMinScore(0.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Verify(ctx, "human", "192.0.2.1"); err != nil {
+		t.Errorf("Verify(human) = %v", err)
+	}
+	for _, token := range []string{"bot", "borderline", ""} {
+		if err := v.Verify(ctx, token, "192.0.2.1"); !errors.Is(err, ErrRejected) {
+			t.Errorf("Verify(%q) = %v, want ErrRejected", token, err)
+		}
+	}
+
+	wrong, _ := New(HCaptcha, "wrong", WithEndpoint(srv.URL))
+	if err := wrong.Verify(ctx, "human", "192.0.2.1"); err == nil || errors.Is(err, ErrRejected) {
+		t.Errorf("Verify with a bad secret = %v, want a configuration error", err)
+	}
+	if _, err := New("captchaco", "s3cret"); err == nil {
+		t.Error("New accepted an unknown provider")
+	}
+}
@@ -0,0 +1,226 @@
+// Package email renders the emails the API sends from templates embedded in
+// the binary.
+//
+// Each email has a text and an HTML template per language, under
+// templates/<lang>/<name>.txt and .html. The text template also defines the
+// subject, as {{define "subject"}}; the HTML one defines "content", which
+// templates/layout.html wraps. English is the fallback: it must have every
+// email, while other languages may leave some out.
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Fallback is the language used when an email has no variant for the one
+// asked for.
+const Fallback = "en"
+
+// The emails the API sends.
+const (
+	Verification = "verification"
+	Reset        = "reset"
+	Digest       = "digest"
+	Invitation   = "invitation"
+)
+
+//go:embed templates
+var templates embed.FS
+
+// Message is a rendered email.
+type Message struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// VerificationData fills the verification email, which asks a user to
+// confirm their email address.
+type VerificationData struct {
+	Name       string
+	Link       string
+	ValidHours int
+}
+
+// ResetData fills the password reset email.
+type ResetData struct {
+	Name       string
+	Link       string
+	ValidHours int
+}
+
+// DigestData fills the activity digest email.
+type DigestData struct {
+	Name string
+	// Period is "daily" or "weekly", as in the user's preferences.
+	Period string
+	// Since is when the activity summarized starts, in the recipient's
+	// time zone.
+	Since        time.Time
+	NewUsers     int
+	UpdatedUsers int
+	// PreferencesLink is where the user can change or turn off the digest;
+	// empty leaves the link out.
+	PreferencesLink string
+}
+
+// InvitationData fills the invitation email, which invites someone to
+// create an account.
+type InvitationData struct {
+	// Name is what the invitation calls them; empty greets them without one.
+	Name      string
+	Link      string
+	ValidDays int
+}
+
+// Samples holds made-up data for every email, for previews.
+var Samples = map[string]any{
+	Verification: VerificationData{Name: "Ada Lovelace", Link: "https://example.com/verify?token=sample", ValidHours: 24},
+	Reset:        ResetData{Name: "Ada Lovelace", Link: "https://example.com/reset?token=sample", ValidHours: 1},
+	Invitation:   InvitationData{Name: "Ada Lovelace", Link: "https://example.com/invitations/accept?token=sample", ValidDays: 7},
+	Digest: DigestData{
+		Name: "Ada Lovelace", Period: "weekly", Since: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		NewUsers: 12, UpdatedUsers: 30, PreferencesLink: "https://example.com/settings/notifications",
+	},
+}
+
+type variant struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Renderer renders emails from a set of templates.
+type Renderer struct {
+	// variants maps lang/name to the templates.
+	variants map[string]variant
+	names    []string
+}
+
+// Default is the renderer for the templates embedded in the binary.
+var Default = mustLoad(templates, "templates")
+
+// Load parses the templates under dir in fsys, laid out as described in the
+// package documentation.
+func Load(fsys fs.FS, dir string) (*Renderer, error) {
+	layout, err := fs.ReadFile(fsys, path.Join(dir, "layout.html"))
+	if err != nil {
+		return nil, err
+	}
+	langs, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	r := &Renderer{variants: map[string]variant{}}
+	for _, l := range langs {
+		if !l.IsDir() {
+			continue
+		}
+		lang := strings.ToLower(l.Name())
+		files, err := fs.Glob(fsys, path.Join(dir, l.Name(), "*.txt"))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			name := strings.TrimSuffix(path.Base(file), ".txt")
+			v, err := parse(fsys, layout, file, strings.TrimSuffix(file, ".txt")+".html")
+			if err != nil {
+				return nil, fmt.Errorf("email: %s/%s: %w", lang, name, err)
+			}
+			r.variants[lang+"/"+name] = v
+			if lang == Fallback {
+				r.names = append(r.names, name)
+			}
+		}
+	}
+	if len(r.names) == 0 {
+		return nil, fmt.Errorf("email: no %s templates in %s", Fallback, dir)
+	}
+	for key := range r.variants {
+		lang, name, _ := strings.Cut(key, "/")
+		if _, ok := r.variants[Fallback+"/"+name]; !ok {
+			return nil, fmt.Errorf("email: %s/%s has no %s variant", lang, name, Fallback)
+		}
+	}
+	sort.Strings(r.names)
+	return r, nil
+}
+
+func mustLoad(fsys fs.FS, dir string) *Renderer {
+	r, err := Load(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func parse(fsys fs.FS, layout []byte, textFile, htmlFile string) (variant, error) {
+	text, err := texttemplate.ParseFS(fsys, textFile)
+	if err != nil {
+		return variant{}, err
+	}
+	if text.Lookup("subject") == nil {
+		return variant{}, fmt.Errorf("%s doesn't define a subject", textFile)
+	}
+	html, err := htmltemplate.New("layout").Parse(string(layout))
+	if err == nil {
+		html, err = html.ParseFS(fsys, htmlFile)
+	}
+	if err != nil {
+		return variant{}, err
+	}
+	return variant{text: text, html: html}, nil
+}
+
+// Names returns the emails the renderer has, sorted.
+func (r *Renderer) Names() []string {
+	return r.names
+}
+
+// Render renders the email name in lang with data, falling back from a
+// regional tag (de-AT) to its base language (de), and then to Fallback.
+func (r *Renderer) Render(name, lang string, data any) (*Message, error) {
+	v, ok := r.variant(name, lang)
+	if !ok {
+		return nil, fmt.Errorf("email: no template %q", name)
+	}
+	var subject, text, html bytes.Buffer
+	if err := v.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := v.text.Execute(&text, data); err != nil {
+		return nil, err
+	}
+	if err := v.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return nil, err
+	}
+	return &Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
+
+func (r *Renderer) variant(name, lang string) (variant, bool) {
+	lang = strings.ToLower(lang)
+	base, _, _ := strings.Cut(lang, "-")
+	for _, l := range []string{lang, base, Fallback} {
+		if v, ok := r.variants[l+"/"+name]; ok {
+			return v, true
+		}
+	}
+	return variant{}, false
+}
+
+// Render renders an email with the Default renderer.
+func Render(name, lang string, data any) (*Message, error) {
+	return Default.Render(name, lang, data)
+}
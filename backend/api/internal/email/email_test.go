@@ -0,0 +1,91 @@
+package email
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestRenderEveryVariant(t *testing.T) {
+	for _, name := range Default.Names() {
+		for _, lang := range []string{"en", "de", "es", "fr"} {
+			msg, err := Render(name, lang, Samples[name])
+			if err != nil {
+				t.Errorf("%s in %s: %v", name, lang, err)
+				continue
+			}
+			if msg.Subject == "" || !strings.Contains(msg.Text, "Ada Lovelace") || !strings.Contains(msg.HTML, "Ada Lovelace") {
+				t.Errorf("%s in %s rendered as %+v", name, lang, msg)
+			}
+		}
+	}
+}
+
+func TestRenderFallsBack(t *testing.T) {
+	msg, err := Render(Reset, "de-AT", Samples[Reset])
+	if err != nil || msg.Subject != "Setze dein Passwort zurück" {
+		t.Errorf("de-AT: %+v, %v; want the German variant", msg, err)
+	}
+	msg, err = Render(Reset, "ja", Samples[Reset])
+	if err != nil || msg.Subject != "Reset your password" {
+		t.Errorf("ja: %+v, %v; want the English one", msg, err)
+	}
+	if _, err := Render("missing", "en", nil); err == nil {
+		t.Error("rendering an unknown email should fail")
+	}
+}
+
+func TestHTMLIsEscaped(t *testing.T) {
+	data := Samples[Verification].(VerificationData)
+	data.Name = "<script>alert(1)</script>"
+	msg, err := Render(Verification, "en", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(msg.HTML, "<script>") {
+		t.Errorf("HTML carries the name unescaped: %s", msg.HTML)
+	}
+	if !strings.Contains(msg.Text, data.Name) {
+		t.Errorf("text should carry the name as is: %s", msg.Text)
+	}
+}
+
+func TestLoadNeedsFallback(t *testing.T) {
+	fsys := fstest.MapFS{
+		"t/layout.html":   {Data: []byte(`{{template "content" .}}`)},
+		"t/en/hello.txt":  {Data: []byte(`{{define "subject"}}Hi{{end}}Hi`)},
+		"t/en/hello.html": {Data: []byte(`{{define "content"}}Hi{{end}}`)},
+		"t/de/bye.txt":    {Data: []byte(`{{define "subject"}}Tschüss{{end}}Tschüss`)},
+		"t/de/bye.html":   {Data: []byte(`{{define "content"}}Tschüss{{end}}`)},
+	}
+	if _, err := Load(fsys, "t"); err == nil {
+		t.Error("a German email without an English variant should be refused")
+	}
+}
+
+func TestCompose(t *testing.T) {
+	msg, err := Render(Reset, "fr", Samples[Reset])
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSMTP("localhost:25", "Monorepo <no-reply@example.com>", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := compose(s.from, "ada@example.com", msg, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"From: \"Monorepo\" <no-reply@example.com>\r\n",
+		"To: <ada@example.com>\r\n",
+		"Subject: =?utf-8?q?R=C3=A9initialisez_votre_mot_de_passe?=\r\n",
+		"Content-Type: text/plain; charset=utf-8\r\n",
+		"Content-Type: text/html; charset=utf-8\r\n",
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("message lacks %q:\n%s", want, b)
+		}
+	}
+}
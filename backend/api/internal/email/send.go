@@ -0,0 +1,126 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+// Sender delivers rendered emails. to is a bare address.
+type Sender interface {
+	Send(ctx context.Context, to string, msg *Message) error
+}
+
+// SMTP sends emails through an SMTP relay. It upgrades to TLS when the
+// server offers STARTTLS, and authenticates with PLAIN if it has a username.
+type SMTP struct {
+	addr     string
+	from     mail.Address
+	username string
+	password string
+}
+
+// NewSMTP returns a sender relaying through addr, a host:port, with from as
+// the sender, e.g. "Monorepo <no-reply@example.com>".
+func NewSMTP(addr, from, username, password string) (*SMTP, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, fmt.Errorf("email: SMTP address: %w", err)
+	}
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("email: sender: %w", err)
+	}
+	return &SMTP{addr: addr, from: *sender, username: username, password: password}, nil
+}
+
+// Send delivers msg as a multipart/alternative email with its text and HTML
+// parts. net/smtp takes no context, so ctx only stops a send that hasn't
+// started.
+func (s *SMTP) Send(ctx context.Context, to string, msg *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if s.username != "" {
+		host, _, _ := net.SplitHostPort(s.addr)
+		auth = smtp.PlainAuth("", s.username, s.password, host)
+	}
+	body, err := compose(s.from, to, msg, time.Now())
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(s.addr, auth, s.from.Address, []string{to}, body)
+}
+
+// Ping connects to the relay and logs in, as Send would, without sending
+// anything.
+func (s *SMTP) Ping(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	host, _, _ := net.SplitHostPort(s.addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if err := c.Hello("localhost"); err != nil {
+		return err
+	}
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.username, s.password, host)); err != nil {
+			return err
+		}
+	}
+	return c.Quit()
+}
+
+// compose builds the RFC 5322 message for msg.
+func compose(from mail.Address, to string, msg *Message, now time.Time) ([]byte, error) {
+	b := make([]byte, 12)
+	rand.Read(b)
+	boundary := hex.EncodeToString(b)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", (&mail.Address{Address: to}).String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	} {
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		fmt.Fprintf(&buf, "Content-Type: %s; charset=utf-8\r\n", part.contentType)
+		fmt.Fprintf(&buf, "Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		w := quotedprintable.NewWriter(&buf)
+		if _, err := w.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\r\n")
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+	return buf.Bytes(), nil
+}
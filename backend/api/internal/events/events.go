@@ -0,0 +1,122 @@
+// Package events is the in-process event bus. Handlers publish domain events
+// (a user was activated, deleted, ...) and any number of subscribers react to
+// them without the handlers knowing who is listening.
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Event is something that happened to a resource.
+type Event struct {
+	// ID identifies the event, for events it causes to point back to.
+	ID string `json:"id"`
+	// Type names what happened, e.g. "user.activated".
+	Type string `json:"type"`
+	// Subject is the ID of the resource the event is about.
+	Subject string `json:"subject"`
+	// Time is when the event was published.
+	Time time.Time `json:"time"`
+	// Data holds event-specific details.
+	Data any `json:"data,omitempty"`
+	// CorrelationID ties together everything done for one action, like a
+	// click in the frontend, across requests and the events they cause.
+	CorrelationID string `json:"correlation_id,omitempty"`
+	// CausationID is the ID of what directly caused the event: the request
+	// that published it, or another event.
+	CausationID string `json:"causation_id,omitempty"`
+}
+
+// Trace is the correlation and causation an event published with a
+// context gets.
+type Trace struct {
+	CorrelationID string
+	CausationID   string
+}
+
+type traceKey struct{}
+
+// WithTrace returns a copy of ctx carrying t.
+func WithTrace(ctx context.Context, t Trace) context.Context {
+	return context.WithValue(ctx, traceKey{}, t)
+}
+
+// TraceFrom returns the trace ctx carries, if any.
+func TraceFrom(ctx context.Context) Trace {
+	t, _ := ctx.Value(traceKey{}).(Trace)
+	return t
+}
+
+// Context returns a copy of ctx for publishing the events e causes: they
+// share its correlation and name it as their cause.
+func (e Event) Context(ctx context.Context) context.Context {
+	return WithTrace(ctx, Trace{CorrelationID: e.CorrelationID, CausationID: e.ID})
+}
+
+// Handler receives published events. Handlers run synchronously on the
+// publishing goroutine, so anything slow should hand off to its own goroutine.
+type Handler func(Event)
+
+// Bus fans events out to subscribers. The zero value is ready to use.
+type Bus struct {
+	mu       sync.RWMutex
+	nextID   int
+	handlers map[int]Handler
+}
+
+// New returns an empty bus.
+func New() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers h for every event published after this call and returns
+// a function that removes it again.
+func (b *Bus) Subscribe(h Handler) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.handlers == nil {
+		b.handlers = map[int]Handler{}
+	}
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = h
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers, id)
+	}
+}
+
+// Publish delivers e to every subscriber, stamping ID and Time if they are
+// unset, and the correlation and causation IDs from ctx's trace.
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	if e.ID == "" {
+		e.ID = newID()
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	if e.CorrelationID == "" && e.CausationID == "" {
+		t := TraceFrom(ctx)
+		e.CorrelationID, e.CausationID = t.CorrelationID, t.CausationID
+	}
+	b.mu.RLock()
+	handlers := make([]Handler, 0, len(b.handlers))
+	for _, h := range b.handlers {
+		handlers = append(handlers, h)
+	}
+	b.mu.RUnlock()
+	for _, h := range handlers {
+		h(e)
+	}
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "evt_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,180 @@
+// Package fieldcrypt encrypts individual string fields with envelope
+// encryption: values are sealed with AES-256-GCM under a data key, and the
+// data key is itself encrypted ("wrapped") by a key-encryption key that is
+// either a local key or an AWS KMS key. Every encrypted value carries its
+// wrapped data key and the ID of the key that wrapped it, so values written
+// under older keys stay readable after a rotation.
+package fieldcrypt
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// prefix marks encrypted values; anything without it is plaintext.
+const prefix = "enc:v1:"
+
+var b64 = base64.RawURLEncoding
+
+// KEK is a key-encryption key, which wraps and unwraps data keys.
+type KEK interface {
+	// ID names the key in encrypted values. It is unique within a Keyring
+	// and contains no ':'.
+	ID() string
+	WrapKey(ctx context.Context, dataKey []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// Keyring encrypts under its primary KEK and decrypts under any of its KEKs.
+// It is safe for concurrent use.
+type Keyring struct {
+	primary KEK
+	keks    map[string]KEK
+
+	mu      sync.Mutex
+	dataKey cipher.AEAD            // current data key, wrapped by primary
+	wrapped string                 // dataKey as wrapped by primary, base64
+	keys    map[string]cipher.AEAD // unwrapped data keys by KEK ID and wrapped key
+}
+
+// NewKeyring returns a Keyring that encrypts under primary and can still
+// decrypt values encrypted under older.
+func NewKeyring(primary KEK, older ...KEK) (*Keyring, error) {
+	k := &Keyring{primary: primary, keks: map[string]KEK{}, keys: map[string]cipher.AEAD{}}
+	for _, kek := range append([]KEK{primary}, older...) {
+		id := kek.ID()
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid key ID %q", id)
+		}
+		if _, dup := k.keks[id]; dup {
+			return nil, fmt.Errorf("duplicate key ID %q", id)
+		}
+		k.keks[id] = kek
+	}
+	return k, nil
+}
+
+// PrimaryID is the ID of the KEK new values are encrypted under.
+func (k *Keyring) PrimaryID() string {
+	return k.primary.ID()
+}
+
+// Encrypt seals plaintext. The empty string stays empty, so optional fields
+// remain recognizably unset.
+func (k *Keyring) Encrypt(ctx context.Context, plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	aead, wrapped, err := k.currentKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return prefix + k.primary.ID() + ":" + wrapped + ":" + b64.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value from Encrypt. Plaintext values, written before
+// encryption was turned on, are returned as they are.
+func (k *Keyring) Decrypt(ctx context.Context, value string) (string, error) {
+	rest, ok := strings.CutPrefix(value, prefix)
+	if !ok {
+		return value, nil
+	}
+	parts := strings.Split(rest, ":")
+	if len(parts) != 3 {
+		return "", errors.New("fieldcrypt: malformed encrypted value")
+	}
+	aead, err := k.key(ctx, parts[0], parts[1])
+	if err != nil {
+		return "", err
+	}
+	sealed, err := b64.DecodeString(parts[2])
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("fieldcrypt: malformed encrypted value")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("fieldcrypt: decrypt under key %s: %w", parts[0], err)
+	}
+	return string(plaintext), nil
+}
+
+// Current reports whether value needs no re-encryption: it is empty or
+// encrypted under the primary KEK.
+func (k *Keyring) Current(value string) bool {
+	return value == "" || strings.HasPrefix(value, prefix+k.primary.ID()+":")
+}
+
+// currentKey returns the data key new values use, generating and wrapping
+// one on first use. One data key per process keeps KMS calls to one per
+// start rather than one per write.
+func (k *Keyring) currentKey(ctx context.Context) (cipher.AEAD, string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.dataKey != nil {
+		return k.dataKey, k.wrapped, nil
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", err
+	}
+	wrapped, err := k.primary.WrapKey(ctx, raw)
+	if err != nil {
+		return nil, "", fmt.Errorf("fieldcrypt: wrap data key under %s: %w", k.primary.ID(), err)
+	}
+	aead, err := newAEAD(raw)
+	if err != nil {
+		return nil, "", err
+	}
+	k.dataKey, k.wrapped = aead, b64.EncodeToString(wrapped)
+	k.keys[k.primary.ID()+":"+k.wrapped] = aead
+	return k.dataKey, k.wrapped, nil
+}
+
+// key returns the data key wrapped as wrapped by KEK id, unwrapping it once
+// and caching it after that.
+func (k *Keyring) key(ctx context.Context, id, wrapped string) (cipher.AEAD, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if aead, ok := k.keys[id+":"+wrapped]; ok {
+		return aead, nil
+	}
+	kek, ok := k.keks[id]
+	if !ok {
+		return nil, fmt.Errorf("fieldcrypt: value is encrypted under unknown key %q", id)
+	}
+	blob, err := b64.DecodeString(wrapped)
+	if err != nil {
+		return nil, errors.New("fieldcrypt: malformed encrypted value")
+	}
+	raw, err := kek.UnwrapKey(ctx, blob)
+	if err != nil {
+		return nil, fmt.Errorf("fieldcrypt: unwrap data key under %s: %w", id, err)
+	}
+	aead, err := newAEAD(raw)
+	if err != nil {
+		return nil, err
+	}
+	k.keys[id+":"+wrapped] = aead
+	return aead, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
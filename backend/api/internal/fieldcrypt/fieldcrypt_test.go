@@ -0,0 +1,70 @@
+package fieldcrypt
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func testKey(t *testing.T, id string, b byte) KEK {
+	t.Helper()
+	kek, err := LocalKey(id, bytes.Repeat([]byte{b}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kek
+}
+
+func TestRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	k, err := NewKeyring(testKey(t, "k1", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := k.Encrypt(ctx, "ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(enc, "ada") || !strings.HasPrefix(enc, "enc:v1:k1:") {
+		t.Fatalf("Encrypt = %q", enc)
+	}
+	if got, err := k.Decrypt(ctx, enc); err != nil || got != "ada@example.com" {
+		t.Fatalf("Decrypt = %q, %v", got, err)
+	}
+	if got, _ := k.Decrypt(ctx, "plain@example.com"); got != "plain@example.com" {
+		t.Errorf("plaintext passed through as %q", got)
+	}
+	if enc, _ := k.Encrypt(ctx, ""); enc != "" {
+		t.Errorf("Encrypt(\"\") = %q, want empty", enc)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	ctx := context.Background()
+	old, _ := NewKeyring(testKey(t, "k1", 1))
+	enc, err := old.Encrypt(ctx, "+436601234567")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := NewKeyring(testKey(t, "k2", 2), testKey(t, "k1", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.Current(enc) {
+		t.Error("value under the old key reported current")
+	}
+	if got, err := rotated.Decrypt(ctx, enc); err != nil || got != "+436601234567" {
+		t.Fatalf("Decrypt after rotation = %q, %v", got, err)
+	}
+	again, _ := rotated.Encrypt(ctx, "+436601234567")
+	if !rotated.Current(again) {
+		t.Errorf("re-encrypted value %q not current", again)
+	}
+
+	dropped, _ := NewKeyring(testKey(t, "k2", 2))
+	if _, err := dropped.Decrypt(ctx, enc); err == nil {
+		t.Error("decrypted a value under a key no longer in the keyring")
+	}
+}
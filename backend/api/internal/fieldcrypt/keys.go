@@ -0,0 +1,112 @@
+package fieldcrypt
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+)
+
+// localKey is a KEK held in process memory, e.g. read from the environment.
+type localKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// LocalKey returns a KEK that wraps data keys with the 32-byte AES key key.
+func LocalKey(id string, key []byte) (KEK, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key %s: want 32 bytes, got %d", id, len(key))
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &localKey{id: id, aead: aead}, nil
+}
+
+func (k *localKey) ID() string { return k.id }
+
+func (k *localKey) WrapKey(ctx context.Context, dataKey []byte) ([]byte, error) {
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return k.aead.Seal(nonce, nonce, dataKey, nil), nil
+}
+
+func (k *localKey) UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < k.aead.NonceSize() {
+		return nil, errors.New("wrapped key too short")
+	}
+	n := k.aead.NonceSize()
+	return k.aead.Open(nil, wrapped[:n], wrapped[n:], nil)
+}
+
+// ParseLocalKeys parses a comma-separated list of id:key pairs, keys being
+// 32 bytes in standard base64 (`openssl rand -base64 32`), e.g.
+// "2024-06:...,2024-01:...". Order is kept, so the first can be the primary.
+func ParseLocalKeys(s string) ([]KEK, error) {
+	var keks []KEK
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, enc, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("key %q: want id:base64key", entry)
+		}
+		key, err := base64.StdEncoding.DecodeString(enc)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		kek, err := LocalKey(id, key)
+		if err != nil {
+			return nil, err
+		}
+		keks = append(keks, kek)
+	}
+	return keks, nil
+}
+
+// kmsKey is a KEK in AWS KMS. Data keys are wrapped with kms:Encrypt and
+// unwrapped with kms:Decrypt, so the key material never leaves KMS.
+type kmsKey struct {
+	client *kms.Client
+	keyID  string
+	id     string
+}
+
+// KMSKey returns a KEK backed by the KMS key keyID (an ID, ARN or alias).
+// Its ID in encrypted values is derived from keyID, since ARNs contain ':'.
+func KMSKey(client *kms.Client, keyID string) KEK {
+	sum := sha256.Sum256([]byte(keyID))
+	return &kmsKey{client: client, keyID: keyID, id: "kms-" + hex.EncodeToString(sum[:4])}
+}
+
+func (k *kmsKey) ID() string { return k.id }
+
+func (k *kmsKey) WrapKey(ctx context.Context, dataKey []byte) ([]byte, error) {
+	out, err := k.client.Encrypt(ctx, &kms.EncryptInput{KeyId: aws.String(k.keyID), Plaintext: dataKey})
+	if err != nil {
+		return nil, err
+	}
+	return out.CiphertextBlob, nil
+}
+
+func (k *kmsKey) UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	out, err := k.client.Decrypt(ctx, &kms.DecryptInput{KeyId: aws.String(k.keyID), CiphertextBlob: wrapped})
+	if err != nil {
+		return nil, err
+	}
+	return out.Plaintext, nil
+}
@@ -0,0 +1,47 @@
+package i18n
+
+import "github.com/danielgtaylor/huma/v2"
+
+// Translatable is an error body of another shape than huma.ErrorModel that
+// LocalizeErrors can translate. Translate returns a copy with every
+// user-facing message passed through translate.
+type Translatable interface {
+	Translate(translate func(msg string) string) any
+}
+
+// LocalizeErrors is a huma transformer that rewrites error responses into the
+// language negotiated from the request's Accept-Language header. Running as a
+// transformer means it sees both the errors returned by handlers and the ones
+// huma produces itself during validation.
+func LocalizeErrors(ctx huma.Context, status string, v any) (any, error) {
+	em, ok := v.(*huma.ErrorModel)
+	t, translatable := v.(Translatable)
+	if !ok && !translatable {
+		return v, nil
+	}
+	lang := Match(ctx.Header("Accept-Language"))
+	ctx.AppendHeader("Vary", "Accept-Language")
+	ctx.SetHeader("Content-Language", lang)
+	if lang == Fallback {
+		return v, nil
+	}
+	if translatable {
+		return t.Translate(func(msg string) string { return Translate(lang, msg) }), nil
+	}
+
+	out := *em
+	out.Title = Translate(lang, em.Title)
+	out.Detail = Translate(lang, em.Detail)
+	if em.Errors != nil {
+		out.Errors = make([]*huma.ErrorDetail, len(em.Errors))
+		for i, d := range em.Errors {
+			if d == nil {
+				continue
+			}
+			translated := *d
+			translated.Message = Translate(lang, d.Message)
+			out.Errors[i] = &translated
+		}
+	}
+	return &out, nil
+}
@@ -0,0 +1,185 @@
+// Package i18n translates user-facing API messages into the language a client
+// asks for via Accept-Language.
+//
+// Catalogs are keyed by the English source text (gettext style), so English
+// never needs a catalog of its own and any message without a translation
+// falls back to the original string. Keys may contain fmt verbs (%s, %v, %d,
+// %q); these match any text in a formatted message and are substituted back
+// into the translation in order, which lets huma's built-in validation
+// messages be translated after they have been formatted.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Fallback is the language used when nothing in Accept-Language matches.
+const Fallback = "en"
+
+//go:embed locales/*.json
+var locales embed.FS
+
+var verbRe = regexp.MustCompile(`%[svdq]`)
+
+type template struct {
+	match       *regexp.Regexp
+	translation string
+}
+
+type catalog struct {
+	exact     map[string]string
+	templates []template
+}
+
+// Bundle holds the catalogs for every supported language.
+type Bundle struct {
+	catalogs map[string]*catalog
+}
+
+// Default is the bundle built from the catalogs embedded in the binary.
+var Default = mustLoad(locales, "locales")
+
+// Load builds a bundle from `<lang>.json` files in dir, each holding a flat
+// object of English message to translated message.
+func Load(fsys fs.FS, dir string) (*Bundle, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{catalogs: map[string]*catalog{}}
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".json" {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("i18n: %s: %w", e.Name(), err)
+		}
+		lang := strings.ToLower(strings.TrimSuffix(e.Name(), ".json"))
+		b.catalogs[lang] = newCatalog(messages)
+	}
+	return b, nil
+}
+
+func mustLoad(fsys fs.FS, dir string) *Bundle {
+	b, err := Load(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func newCatalog(messages map[string]string) *catalog {
+	c := &catalog{exact: map[string]string{}}
+	keys := make([]string, 0, len(messages))
+	for k := range messages {
+		keys = append(keys, k)
+	}
+	// Longest keys first so the most specific template wins.
+	sort.Slice(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
+	for _, k := range keys {
+		if !verbRe.MatchString(k) {
+			c.exact[k] = messages[k]
+			continue
+		}
+		parts := verbRe.Split(k, -1)
+		for i := range parts {
+			parts[i] = regexp.QuoteMeta(parts[i])
+		}
+		c.templates = append(c.templates, template{
+			match:       regexp.MustCompile("^" + strings.Join(parts, "(.+?)") + "$"),
+			translation: messages[k],
+		})
+	}
+	return c
+}
+
+// Match picks the best supported language for an Accept-Language header
+// value, honoring q-values and falling back from regional tags (`de-AT`) to
+// their base language (`de`). It returns Fallback when nothing matches.
+func (b *Bundle) Match(acceptLanguage string) string {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{strings.ToLower(tag), q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	for _, c := range candidates {
+		if c.tag == Fallback || strings.HasPrefix(c.tag, Fallback+"-") {
+			return Fallback
+		}
+		if _, ok := b.catalogs[c.tag]; ok {
+			return c.tag
+		}
+		base, _, _ := strings.Cut(c.tag, "-")
+		if _, ok := b.catalogs[base]; ok {
+			return base
+		}
+	}
+	return Fallback
+}
+
+// Translate returns msg in lang, or msg unchanged when there is no
+// translation for it.
+func (b *Bundle) Translate(lang, msg string) string {
+	c, ok := b.catalogs[lang]
+	if !ok || msg == "" {
+		return msg
+	}
+	if t, ok := c.exact[msg]; ok {
+		return t
+	}
+	for _, t := range c.templates {
+		m := t.match.FindStringSubmatch(msg)
+		if m == nil {
+			continue
+		}
+		args := m[1:]
+		return verbRe.ReplaceAllStringFunc(t.translation, func(string) string {
+			if len(args) == 0 {
+				return ""
+			}
+			v := args[0]
+			args = args[1:]
+			return v
+		})
+	}
+	return msg
+}
+
+// Match picks a language from the Default bundle.
+func Match(acceptLanguage string) string {
+	return Default.Match(acceptLanguage)
+}
+
+// Translate translates msg using the Default bundle.
+func Translate(lang, msg string) string {
+	return Default.Translate(lang, msg)
+}
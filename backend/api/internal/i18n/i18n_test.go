@@ -0,0 +1,106 @@
+package i18n
+
+import (
+	"encoding/json"
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	for _, c := range []struct{ header, want string }{
+		{"", "en"},
+		{"de", "de"},
+		{"DE", "de"},
+		{"de-AT", "de"},
+		{"fr-CA,fr;q=0.9", "fr"},
+		{"es-419", "es"},
+		{"en-GB,de;q=0.9", "en"},
+		// The highest q-value wins, whatever the order.
+		{"de;q=0.5, fr;q=0.8", "fr"},
+		{"de;q=0.5, fr", "fr"},
+		// q=0 rules a language out.
+		{"de;q=0, es;q=0.1", "es"},
+		{"de;q=0", "en"},
+		// A q-value that doesn't parse counts as 1.
+		{"de;q=high, fr;q=0.9", "de"},
+		// Nothing is translated into every language, so * gets English,
+		// and is skipped in favour of a language that is supported.
+		{"*", "en"},
+		{"*, de;q=0.5", "de"},
+		{"ja", "en"},
+		{"ja, pt-BR;q=0.8", "en"},
+		{"ja, es;q=0.1", "es"},
+		{" , ;q=1, fr", "fr"},
+	} {
+		if got := Match(c.header); got != c.want {
+			t.Errorf("Match(%q) = %q, want %q", c.header, got, c.want)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	for _, c := range []struct{ lang, msg, want string }{
+		{"de", "Not Found", "Nicht gefunden"},
+		{"de", "cannot change status from active to invited", "Status kann nicht von active zu invited geändert werden"},
+		{"de", "expected metadata of at most 16384 bytes", "Metadaten mit höchstens 16384 Bytes erwartet"},
+		// Without a translation, the message stays as it is.
+		{"de", "no translation for this", "no translation for this"},
+		{"en", "Not Found", "Not Found"},
+		{"ja", "Not Found", "Not Found"},
+		{"de", "", ""},
+	} {
+		if got := Translate(c.lang, c.msg); got != c.want {
+			t.Errorf("Translate(%q, %q) = %q, want %q", c.lang, c.msg, got, c.want)
+		}
+	}
+}
+
+// TestCatalogs checks that every embedded language translates the same
+// messages, keeping the verbs of each.
+func TestCatalogs(t *testing.T) {
+	entries, err := fs.ReadDir(locales, "locales")
+	if err != nil {
+		t.Fatal(err)
+	}
+	catalogs := map[string]map[string]string{}
+	for _, e := range entries {
+		data, err := fs.ReadFile(locales, path.Join("locales", e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			t.Fatalf("%s: %v", e.Name(), err)
+		}
+		catalogs[strings.TrimSuffix(e.Name(), ".json")] = messages
+	}
+	if len(catalogs) < 3 {
+		t.Fatalf("embedded catalogs %v, want de, es and fr at least", slices.Sorted(maps.Keys(catalogs)))
+	}
+
+	keys := map[string]bool{}
+	for _, messages := range catalogs {
+		for k := range messages {
+			keys[k] = true
+		}
+	}
+	for lang, messages := range catalogs {
+		if match := Match(lang); match != lang {
+			t.Errorf("Match(%q) = %q, want the embedded catalog", lang, match)
+		}
+		for k := range keys {
+			translation, ok := messages[k]
+			if !ok {
+				t.Errorf("%s: no translation of %q", lang, k)
+				continue
+			}
+			if want, got := verbRe.FindAllString(k, -1), verbRe.FindAllString(translation, -1); !slices.Equal(got, want) {
+				t.Errorf("%s: %q has verbs %v, want %v", lang, translation, got, want)
+			}
+		}
+	}
+}
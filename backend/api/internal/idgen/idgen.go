@@ -0,0 +1,264 @@
+// Package idgen generates the IDs of the API's users, posts, comments and
+// notifications. Generator is the strategy a deployment picks: the
+// timestamps to the second IDs have always been, random UUIDv4s, or one of
+// the time-sortable kinds, UUIDv7, ULID and Snowflake, whose IDs sort in
+// the order they were made. Every kind but UUIDv4 sorts as a string too,
+// which is how lists ordered by ID come out oldest first.
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Names of the strategies, as ID_GENERATOR takes them.
+const (
+	StrategyTimestamp = "timestamp"
+	StrategyUUIDv4    = "uuidv4"
+	StrategyUUIDv7    = "uuidv7"
+	StrategyULID      = "ulid"
+	StrategySnowflake = "snowflake"
+)
+
+// Generator makes the ID of a resource created at now. IDs need only be
+// unique among those of the same kind of resource; callers check for the
+// rare clash, as Timestamp clashes within a second.
+type Generator interface {
+	New(now time.Time) string
+	// Time is when the ID was made, as far as it tells: to the second for
+	// Timestamp and the millisecond for the rest, ignoring anything after
+	// the ID proper, like the -2 callers add on a clash. ok is false for
+	// UUIDv4s and IDs the generator couldn't have made.
+	Time(id string) (t time.Time, ok bool)
+}
+
+// New returns the generator of strategy. node is the node ID Snowflake
+// needs, and ignored by the others.
+func New(strategy string, node int64) (Generator, error) {
+	switch strategy {
+	case StrategyTimestamp:
+		return Timestamp{}, nil
+	case StrategyUUIDv4:
+		return UUIDv4{}, nil
+	case StrategyUUIDv7:
+		return UUIDv7{}, nil
+	case StrategyULID:
+		return ULID{}, nil
+	case StrategySnowflake:
+		return NewSnowflake(node)
+	}
+	return nil, fmt.Errorf("want timestamp, uuidv4, uuidv7, ulid or snowflake, got %q", strategy)
+}
+
+// Timestamp makes IDs of now to the second in its time zone, the server's,
+// like 20240101120000.
+type Timestamp struct{}
+
+const timestampLayout = "20060102150405"
+
+func (Timestamp) New(now time.Time) string {
+	return now.Format(timestampLayout)
+}
+
+func (Timestamp) Time(id string) (time.Time, bool) {
+	if len(id) < len(timestampLayout) {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(timestampLayout, id[:len(timestampLayout)], time.Local)
+	return t, err == nil
+}
+
+// UUIDv4 makes random UUIDs, like 9b2f6c1e-7a4d-4f0b-8c3e-2d5a6b7c8d9e.
+type UUIDv4 struct{}
+
+func (UUIDv4) New(time.Time) string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return formatUUID(b)
+}
+
+func (UUIDv4) Time(string) (time.Time, bool) {
+	return time.Time{}, false
+}
+
+// UUIDv7 makes UUIDs starting with now in Unix milliseconds, as RFC 9562
+// lays out, like 018cc251-f400-7a3c-9d2e-5b6a7c8d9e0f. The 12 bits after
+// the version hold the fraction of the millisecond, so IDs made within one
+// still mostly sort in order; the rest is random.
+type UUIDv7 struct{}
+
+func (UUIDv7) New(now time.Time) string {
+	var b [16]byte
+	rand.Read(b[8:])
+	ms := now.UnixMilli()
+	binary.BigEndian.PutUint64(b[:8], uint64(ms)<<16)
+	frac := uint16((now.UnixNano() - ms*1e6) * 4096 / 1e6)
+	binary.BigEndian.PutUint16(b[6:8], 0x7000|frac)
+	b[8] = b[8]&0x3f | 0x80
+	return formatUUID(b)
+}
+
+func (UUIDv7) Time(id string) (time.Time, bool) {
+	// The first 48 bits: the 8 hex digits before the first dash and the 4
+	// after it.
+	if len(id) < 36 || id[8] != '-' || id[14] != '7' {
+		return time.Time{}, false
+	}
+	ms, err := strconv.ParseInt(id[:8]+id[9:13], 16, 64)
+	return time.UnixMilli(ms), err == nil
+}
+
+func formatUUID(b [16]byte) string {
+	h := hex.EncodeToString(b[:])
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// crockfordAlphabet is the base 32 alphabet of ULIDs.
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var crockford = base32.NewEncoding(crockfordAlphabet).WithPadding(base32.NoPadding)
+
+// ULID makes ULIDs: now in Unix milliseconds and 80 random bits, in 26
+// characters of Crockford's base 32, like 01HK153X00Q8ZJ4V6N2B7C9D0E.
+type ULID struct{}
+
+func (ULID) New(now time.Time) string {
+	// 130 bits encode the 128, with the 2 leading bits zero.
+	var b [17]byte
+	binary.BigEndian.PutUint64(b[1:9], uint64(now.UnixMilli())<<16)
+	rand.Read(b[7:])
+	// Shift the 128 bits 2 right, so the first character holds the 2 zero
+	// bits and 3 of the timestamp rather than 5 of it.
+	var out [17]byte
+	for i := range 16 {
+		out[i] = b[i]<<6 | b[i+1]>>2
+	}
+	out[16] = b[16] << 6
+	return crockford.EncodeToString(out[:])[:26]
+}
+
+func (ULID) Time(id string) (time.Time, bool) {
+	// The first 10 characters are the 48 bits of the timestamp, after the 2
+	// zero bits.
+	if len(id) < 26 {
+		return time.Time{}, false
+	}
+	var ms int64
+	for _, c := range id[:10] {
+		i := strings.IndexRune(crockfordAlphabet, c)
+		if i < 0 {
+			return time.Time{}, false
+		}
+		ms = ms<<5 | int64(i)
+	}
+	return time.UnixMilli(ms), true
+}
+
+// Snowflake bit layout: 41 bits of milliseconds since snowflakeEpoch, 10
+// of node ID and 12 of sequence.
+const (
+	snowflakeNodeBits = 10
+	snowflakeSeqBits  = 12
+	// MaxSnowflakeNode is the highest node ID.
+	MaxSnowflakeNode = 1<<snowflakeNodeBits - 1
+)
+
+// maxSnowflakeWait bounds how long New waits for the clock to pass a
+// millisecond whose sequence numbers ran out. Past it, as when the clock
+// went back further, New takes the next millisecond ahead of the clock
+// rather than wait for the clock to catch up.
+const maxSnowflakeWait = 10 * time.Millisecond
+
+// snowflakeEpoch is when Snowflake's clock starts; 41 bits of milliseconds
+// last 69 years from it.
+var snowflakeEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+
+// Snowflake makes Twitter-style Snowflake IDs, 63-bit integers unique as
+// long as every replica has a node ID of its own. Up to 4096 are made per
+// millisecond, after which New waits for the next, up to
+// maxSnowflakeWait. They are written in
+// decimal, zero-padded to 19 digits so they sort as strings too, like
+// 0000000123456789012.
+type Snowflake struct {
+	node  int64
+	clock func() time.Time
+	sleep func(time.Duration)
+
+	mu   sync.Mutex
+	last int64 // milliseconds since snowflakeEpoch of the last ID
+	seq  int64
+}
+
+// ErrSnowflakeNode is what NewSnowflake fails with for a node ID out of
+// range.
+var ErrSnowflakeNode = errors.New("idgen: Snowflake node IDs go from 0 to 1023")
+
+// NewSnowflake returns a Snowflake for the node.
+func NewSnowflake(node int64) (*Snowflake, error) {
+	if node < 0 || node > MaxSnowflakeNode {
+		return nil, ErrSnowflakeNode
+	}
+	return &Snowflake{node: node, clock: time.Now, sleep: time.Sleep}, nil
+}
+
+// Node is the node ID of s.
+func (s *Snowflake) Node() int64 {
+	return s.node
+}
+
+func (s *Snowflake) New(now time.Time) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// A clock that went back keeps the time of the last ID, so IDs never
+	// repeat.
+	ms := max(now.UnixMilli()-snowflakeEpoch, s.last)
+	if ms == s.last {
+		s.seq = (s.seq + 1) & (1<<snowflakeSeqBits - 1)
+		if s.seq == 0 {
+			ms = s.next()
+		}
+	} else {
+		s.seq = 0
+	}
+	s.last = ms
+	return fmt.Sprintf("%019d", ms<<(snowflakeNodeBits+snowflakeSeqBits)|s.node<<snowflakeSeqBits|s.seq)
+}
+
+// next returns the millisecond after s.last to make IDs in once the
+// sequence numbers of s.last ran out: the clock's once it passes s.last, or
+// s.last+1 if it doesn't within maxSnowflakeWait. s.mu must be held.
+func (s *Snowflake) next() int64 {
+	for waited := time.Duration(0); waited < maxSnowflakeWait; waited += time.Millisecond {
+		ms := s.clock().UnixMilli() - snowflakeEpoch
+		if ms > s.last {
+			return ms
+		}
+		if s.last-ms >= maxSnowflakeWait.Milliseconds() {
+			// Too far behind to catch up in time.
+			break
+		}
+		s.sleep(time.Millisecond)
+	}
+	return s.last + 1
+}
+
+func (s *Snowflake) Time(id string) (time.Time, bool) {
+	if len(id) < 19 {
+		return time.Time{}, false
+	}
+	n, err := strconv.ParseInt(id[:19], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(n>>(snowflakeNodeBits+snowflakeSeqBits) + snowflakeEpoch), true
+}
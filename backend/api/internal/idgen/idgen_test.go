@@ -0,0 +1,141 @@
+package idgen
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestFormats(t *testing.T) {
+	// The timestamps of the examples in RFC 9562 and the ULID spec.
+	rfc := time.UnixMilli(1645557742000)
+	ulid := time.UnixMilli(1469918176385)
+	for _, c := range []struct {
+		gen  Generator
+		now  time.Time
+		want string
+	}{
+		{Timestamp{}, time.Date(2024, 1, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600)), `^20240101120000$`},
+		{UUIDv4{}, rfc, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{UUIDv7{}, rfc, `^017f22e2-79b0-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{ULID{}, ulid, `^01ARYZ6S41[0-9A-HJKMNP-TV-Z]{16}$`},
+	} {
+		if got := c.gen.New(c.now); !regexp.MustCompile(c.want).MatchString(got) {
+			t.Errorf("%T: %s, want %s", c.gen, got, c.want)
+		}
+	}
+}
+
+func TestSortable(t *testing.T) {
+	sf, _ := NewSnowflake(7)
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	for _, gen := range []Generator{Timestamp{}, UUIDv7{}, ULID{}, sf} {
+		prev := ""
+		for i := range 5 {
+			id := gen.New(start.Add(time.Duration(i) * 1500 * time.Millisecond))
+			if id <= prev {
+				t.Errorf("%T: %s after %s", gen, id, prev)
+			}
+			prev = id
+		}
+	}
+}
+
+func TestTime(t *testing.T) {
+	sf, _ := NewSnowflake(MaxSnowflakeNode)
+	now := time.Date(2024, 6, 1, 12, 30, 15, 123456789, time.Local)
+	for _, c := range []struct {
+		gen  Generator
+		want time.Time
+	}{
+		{Timestamp{}, now.Truncate(time.Second)},
+		{UUIDv7{}, now.Truncate(time.Millisecond)},
+		{ULID{}, now.Truncate(time.Millisecond)},
+		{sf, now.Truncate(time.Millisecond)},
+	} {
+		id := c.gen.New(now)
+		if got, ok := c.gen.Time(id + "-2"); !ok || !got.Equal(c.want) {
+			t.Errorf("%T: Time(%s-2) = %v, %t; want %v", c.gen, id, got, ok, c.want)
+		}
+		if _, ok := c.gen.Time("abc"); ok {
+			t.Errorf("%T: Time(abc) ok", c.gen)
+		}
+	}
+	if _, ok := (UUIDv4{}).Time(UUIDv4{}.New(now)); ok {
+		t.Error("UUIDv4: Time ok")
+	}
+}
+
+func TestSnowflake(t *testing.T) {
+	if _, err := NewSnowflake(MaxSnowflakeNode + 1); err != ErrSnowflakeNode {
+		t.Errorf("node 1024: err = %v", err)
+	}
+	a, _ := NewSnowflake(1)
+	b, _ := NewSnowflake(2)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	seen := map[string]bool{}
+	// More than fit in a millisecond, and a clock going back.
+	for i := range 5000 {
+		for _, gen := range []*Snowflake{a, b} {
+			at := now
+			if i%100 == 99 {
+				at = now.Add(-time.Second)
+			}
+			id := gen.New(at)
+			if len(id) != 19 || seen[id] {
+				t.Fatalf("ID %d %q: repeated or not 19 digits", i, id)
+			}
+			seen[id] = true
+		}
+	}
+}
+
+func TestSnowflakeClock(t *testing.T) {
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := start
+	var slept time.Duration
+	sf, _ := NewSnowflake(1)
+	sf.clock = func() time.Time { return clock }
+	sf.sleep = func(d time.Duration) { slept += d; clock = clock.Add(d) }
+	ms := func(id string) int64 {
+		at, _ := sf.Time(id)
+		return at.Sub(start).Milliseconds()
+	}
+
+	// Out of sequence numbers, New waits for the next millisecond.
+	var id string
+	for range 4097 {
+		id = sf.New(clock)
+	}
+	if ms(id) != 1 || slept != time.Millisecond {
+		t.Errorf("after 4097 IDs: made at +%dms after sleeping %v, want +1ms after 1ms", ms(id), slept)
+	}
+
+	// With the clock gone back a minute, it runs ahead of it instead of
+	// sleeping until it catches up.
+	clock, slept = clock.Add(-time.Minute), 0
+	last := sf.New(clock)
+	for range 3 * 4096 {
+		if id = sf.New(clock); id <= last {
+			t.Fatalf("ID %s after %s", id, last)
+		}
+		last = id
+	}
+	if slept != 0 || ms(id) != 4 {
+		t.Errorf("clock behind: made at +%dms after sleeping %v, want +4ms without sleeping", ms(id), slept)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, strategy := range []string{StrategyTimestamp, StrategyUUIDv4, StrategyUUIDv7, StrategyULID, StrategySnowflake} {
+		if _, err := New(strategy, 0); err != nil {
+			t.Errorf("%s: %v", strategy, err)
+		}
+	}
+	if _, err := New("uuidv1", 0); err == nil {
+		t.Error("uuidv1: no error")
+	}
+	if _, err := New(StrategySnowflake, -1); err == nil {
+		t.Error("snowflake node -1: no error")
+	}
+}
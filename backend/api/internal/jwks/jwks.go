@@ -0,0 +1,410 @@
+// Package jwks verifies JWTs issued by identity providers outside the API,
+// such as Auth0 or an internal one, against the keys each publishes as a
+// JSON Web Key Set. Key sets are cached and refreshed in the background,
+// and fetched again early when a token names a key the cache doesn't have,
+// so a provider can rotate its keys without a restart.
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var b64 = base64.RawURLEncoding
+
+var (
+	// ErrInvalid is returned for tokens that are malformed, from an issuer
+	// the Verifier doesn't trust, for another audience, or whose signature
+	// doesn't match a key of their issuer.
+	ErrInvalid = errors.New("invalid token")
+	// ErrExpired is returned for correctly signed tokens past their expiry.
+	ErrExpired = errors.New("token expired")
+)
+
+// leeway is how far the clocks of the API and an issuer may disagree when
+// checking a token's expiry and start.
+const leeway = 30 * time.Second
+
+// refetchAfter is how long after fetching an issuer's keys a token naming
+// a key that isn't among them makes Verify fetch them again, so tokens with
+// made-up key IDs can't make it hammer the issuer.
+const refetchAfter = time.Minute
+
+// Issuer is an identity provider whose tokens are trusted.
+type Issuer struct {
+	// URL is the iss claim of its tokens.
+	URL string
+	// JWKSURL is where it publishes its keys, URL's
+	// /.well-known/jwks.json if empty.
+	JWKSURL string
+	// Audience, if set, must be one of a token's aud claims.
+	Audience string
+}
+
+func (i Issuer) jwksURL() string {
+	if i.JWKSURL != "" {
+		return i.JWKSURL
+	}
+	return strings.TrimSuffix(i.URL, "/") + "/.well-known/jwks.json"
+}
+
+// Claims are what a token asserts.
+type Claims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	Audience  audience `json:"aud"`
+	ID        string   `json:"jti"`
+	IssuedAt  int64    `json:"iat"`
+	NotBefore int64    `json:"nbf"`
+	ExpiresAt int64    `json:"exp"`
+}
+
+// Expiry returns ExpiresAt as a time.
+func (c *Claims) Expiry() time.Time {
+	return time.Unix(c.ExpiresAt, 0).UTC()
+}
+
+// audience is the aud claim, which is a string or an array of them.
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*a = audience{one}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(a))
+}
+
+// Option configures New.
+type Option func(*Verifier)
+
+// WithHTTPClient fetches key sets with c instead of a client with a
+// 5 second timeout.
+func WithHTTPClient(c *http.Client) Option {
+	return func(v *Verifier) { v.client = c }
+}
+
+// Verifier verifies the tokens of a set of issuers. It is safe for
+// concurrent use.
+type Verifier struct {
+	client  *http.Client
+	issuers map[string]*keySet
+}
+
+// keySet is an issuer's keys, by key ID, as last fetched.
+type keySet struct {
+	issuer Issuer
+
+	mu      sync.Mutex
+	keys    map[string]*key
+	fetched time.Time // of the last attempt, successful or not
+	err     error     // of the last attempt
+}
+
+type key struct {
+	alg string // the algorithm the key set limits it to, if any
+	pub crypto.PublicKey
+}
+
+// New returns a Verifier for the tokens of issuers. It fetches no keys
+// until Refresh or the first token it verifies.
+func New(issuers []Issuer, opts ...Option) *Verifier {
+	v := &Verifier{client: &http.Client{Timeout: 5 * time.Second}, issuers: map[string]*keySet{}}
+	for _, i := range issuers {
+		v.issuers[i.URL] = &keySet{issuer: i}
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Issues reports whether token, as yet unverified, claims to be from one of
+// the Verifier's issuers.
+func (v *Verifier) Issues(token string) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	var c struct {
+		Issuer string `json:"iss"`
+	}
+	raw, err := b64.DecodeString(parts[1])
+	if err != nil || json.Unmarshal(raw, &c) != nil {
+		return false
+	}
+	_, ok := v.issuers[c.Issuer]
+	return ok
+}
+
+// Verify checks token's signature, issuer, audience and validity at now and
+// returns its claims. Errors other than ErrInvalid and ErrExpired mean the
+// issuer's keys couldn't be fetched.
+func (v *Verifier) Verify(ctx context.Context, token string, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalid
+	}
+	var head struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	raw, err := b64.DecodeString(parts[0])
+	if err != nil || json.Unmarshal(raw, &head) != nil {
+		return nil, ErrInvalid
+	}
+	var c Claims
+	raw, err = b64.DecodeString(parts[1])
+	if err != nil || json.Unmarshal(raw, &c) != nil || c.Subject == "" {
+		return nil, ErrInvalid
+	}
+	ks := v.issuers[c.Issuer]
+	if ks == nil {
+		return nil, ErrInvalid
+	}
+	sig, err := b64.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	k, err := v.key(ctx, ks, head.Kid, now)
+	if err != nil {
+		return nil, err
+	}
+	if k.alg != "" && k.alg != head.Alg || !verifySignature(head.Alg, k.pub, parts[0]+"."+parts[1], sig) {
+		return nil, ErrInvalid
+	}
+	if want := ks.issuer.Audience; want != "" && !contains(c.Audience, want) {
+		return nil, ErrInvalid
+	}
+	if c.NotBefore != 0 && now.Add(leeway).Unix() < c.NotBefore {
+		return nil, ErrInvalid
+	}
+	if c.ExpiresAt == 0 || now.Add(-leeway).Unix() >= c.ExpiresAt {
+		return nil, ErrExpired
+	}
+	return &c, nil
+}
+
+func contains(aud audience, want string) bool {
+	for _, a := range aud {
+		if a == want {
+			return true
+		}
+	}
+	return false
+}
+
+// key returns ks's key kid, fetching the key set first if kid isn't in it
+// and it wasn't fetched in the last refetchAfter. An issuer with a single
+// key may leave kid out of its tokens.
+func (v *Verifier) key(ctx context.Context, ks *keySet, kid string, now time.Time) (*key, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if k := ks.lookup(kid); k != nil {
+		return k, nil
+	}
+	if ks.fetched.IsZero() || now.Sub(ks.fetched) >= refetchAfter {
+		keys, err := v.fetch(ctx, ks.issuer)
+		ks.store(keys, err, now)
+	}
+	if k := ks.lookup(kid); k != nil {
+		return k, nil
+	}
+	// The key may well be one the issuer published since.
+	if ks.err != nil {
+		return nil, ks.err
+	}
+	return nil, ErrInvalid
+}
+
+// store records the outcome of a fetch at now, keeping the keys fetched
+// before if it failed. ks.mu must be held.
+func (ks *keySet) store(keys map[string]*key, err error, now time.Time) {
+	ks.fetched, ks.err = now, err
+	if err == nil {
+		ks.keys = keys
+	}
+}
+
+func (ks *keySet) lookup(kid string) *key {
+	if kid == "" && len(ks.keys) == 1 {
+		for _, k := range ks.keys {
+			return k
+		}
+	}
+	return ks.keys[kid]
+}
+
+// Refresh fetches every issuer's keys. An issuer whose keys can't be
+// fetched keeps the ones it had.
+func (v *Verifier) Refresh(ctx context.Context) error {
+	var errs []error
+	for _, ks := range v.issuers {
+		// Tokens are verified with the old keys while the new ones are
+		// fetched.
+		keys, err := v.fetch(ctx, ks.issuer)
+		ks.mu.Lock()
+		ks.store(keys, err, time.Now())
+		ks.mu.Unlock()
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// fetch returns the keys issuer publishes now.
+func (v *Verifier) fetch(ctx context.Context, issuer Issuer) (map[string]*key, error) {
+	url := issuer.jwksURL()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks: fetch %s: %s", url, resp.Status)
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("jwks: decode %s: %w", url, err)
+	}
+	keys := map[string]*key{}
+	for _, j := range set.Keys {
+		// Keys for encryption, and of types this can't verify with, are
+		// skipped rather than failing the whole set.
+		if j.Use != "" && j.Use != "sig" {
+			continue
+		}
+		if pub, err := j.publicKey(); err == nil {
+			keys[j.Kid] = &key{alg: j.Alg, pub: pub}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("jwks: %s has no signing keys", url)
+	}
+	return keys, nil
+}
+
+// jwk is a JSON Web Key (RFC 7517), with the fields of RSA, EC and OKP
+// public keys.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (j jwk) publicKey() (crypto.PublicKey, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := b64.DecodeString(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := b64.DecodeString(j.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("bad RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := b64.DecodeString(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := b64.DecodeString(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("point not on curve")
+		}
+		return pub, nil
+	case "OKP":
+		x, err := b64.DecodeString(j.X)
+		if err != nil || j.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("unsupported OKP key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", j.Kty)
+}
+
+// verifySignature checks sig over signed with pub under alg, which must
+// suit pub's type, so a token can't make a key be used with another
+// algorithm than its own.
+func verifySignature(alg string, pub crypto.PublicKey, signed string, sig []byte) bool {
+	switch alg {
+	case "RS256", "RS384", "RS512":
+		k, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return false
+		}
+		h, digest := hashFor(alg, signed)
+		return rsa.VerifyPKCS1v15(k, h, digest, sig) == nil
+	case "ES256", "ES384":
+		k, ok := pub.(*ecdsa.PublicKey)
+		if !ok || alg == "ES256" && k.Curve != elliptic.P256() || alg == "ES384" && k.Curve != elliptic.P384() {
+			return false
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return false
+		}
+		_, digest := hashFor(alg, signed)
+		r, s := new(big.Int).SetBytes(sig[:size]), new(big.Int).SetBytes(sig[size:])
+		return ecdsa.Verify(k, digest, r, s)
+	case "EdDSA":
+		k, ok := pub.(ed25519.PublicKey)
+		return ok && ed25519.Verify(k, []byte(signed), sig)
+	}
+	return false
+}
+
+// hashFor hashes signed with the hash of alg, by its bit size.
+func hashFor(alg, signed string) (crypto.Hash, []byte) {
+	switch alg[2:] {
+	case "384":
+		sum := sha512.Sum384([]byte(signed))
+		return crypto.SHA384, sum[:]
+	case "512":
+		sum := sha512.Sum512([]byte(signed))
+		return crypto.SHA512, sum[:]
+	}
+	sum := sha256.Sum256([]byte(signed))
+	return crypto.SHA256, sum[:]
+}
@@ -0,0 +1,147 @@
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testIssuer serves a key set of its current keys.
+type testIssuer struct {
+	mu      sync.Mutex
+	keys    []map[string]string
+	fetches int
+}
+
+func (i *testIssuer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.fetches++
+	json.NewEncoder(w).Encode(map[string]any{"keys": i.keys})
+}
+
+func rsaJWK(kid string, k *rsa.PrivateKey) map[string]string {
+	return map[string]string{"kty": "RSA", "kid": kid, "use": "sig", "n": b64.EncodeToString(k.N.Bytes()), "e": b64.EncodeToString(big.NewInt(int64(k.E)).Bytes())}
+}
+
+func ecJWK(kid string, k *ecdsa.PrivateKey) map[string]string {
+	return map[string]string{"kty": "EC", "kid": kid, "crv": "P-256", "x": b64.EncodeToString(k.X.FillBytes(make([]byte, 32))), "y": b64.EncodeToString(k.Y.FillBytes(make([]byte, 32)))}
+}
+
+func sign(t *testing.T, alg, kid string, k crypto.Signer, claims map[string]any) string {
+	t.Helper()
+	head, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64.EncodeToString(head) + "." + b64.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	var sig []byte
+	switch k := k.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + b64.EncodeToString(sig)
+}
+
+func TestVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth0 := &testIssuer{keys: []map[string]string{rsaJWK("rsa-1", rsaKey)}}
+	internal := &testIssuer{keys: []map[string]string{ecJWK("ec-1", ecKey)}}
+	auth0Srv, internalSrv := httptest.NewServer(auth0), httptest.NewServer(internal)
+	defer auth0Srv.Close()
+	defer internalSrv.Close()
+
+	v := New([]Issuer{
+		{URL: auth0Srv.URL + "/", Audience: "https://api.example.com"},
+		{URL: "https://idp.internal", JWKSURL: internalSrv.URL + "/keys"},
+	})
+	now := time.Now()
+	claims := func(iss string, extra map[string]any) map[string]any {
+		c := map[string]any{"iss": iss, "sub": "20240101120000", "exp": now.Add(time.Hour).Unix(), "aud": []string{"https://api.example.com", "other"}}
+		for k, val := range extra {
+			c[k] = val
+		}
+		return c
+	}
+
+	for _, tc := range []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"auth0", sign(t, "RS256", "rsa-1", rsaKey, claims(auth0Srv.URL+"/", nil)), nil},
+		{"internal", sign(t, "ES256", "", ecKey, claims("https://idp.internal", map[string]any{"aud": "anything"})), nil},
+		{"another audience", sign(t, "RS256", "rsa-1", rsaKey, claims(auth0Srv.URL+"/", map[string]any{"aud": "other"})), ErrInvalid},
+		{"expired", sign(t, "RS256", "rsa-1", rsaKey, claims(auth0Srv.URL+"/", map[string]any{"exp": now.Add(-time.Minute).Unix()})), ErrExpired},
+		{"not yet valid", sign(t, "RS256", "rsa-1", rsaKey, claims(auth0Srv.URL+"/", map[string]any{"nbf": now.Add(time.Hour).Unix()})), ErrInvalid},
+		{"other issuer's key", sign(t, "ES256", "ec-1", ecKey, claims(auth0Srv.URL+"/", nil)), ErrInvalid},
+		{"untrusted issuer", sign(t, "RS256", "rsa-1", rsaKey, claims("https://evil.example", nil)), ErrInvalid},
+		{"algorithm mismatch", sign(t, "ES256", "rsa-1", ecKey, claims(auth0Srv.URL+"/", nil)), ErrInvalid},
+	} {
+		c, err := v.Verify(context.Background(), tc.token, now)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		} else if err == nil && c.Subject != "20240101120000" {
+			t.Errorf("%s: subject %q", tc.name, c.Subject)
+		}
+	}
+	if !v.Issues(sign(t, "RS256", "rsa-1", rsaKey, claims("https://idp.internal", nil))) || v.Issues("a.b.c") {
+		t.Error("Issues doesn't tell the issuers' tokens apart")
+	}
+
+	// A rotated key is fetched when a token first names it, but a made-up
+	// one doesn't fetch the keys again straight away.
+	rotated, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth0.mu.Lock()
+	auth0.keys = append(auth0.keys, rsaJWK("rsa-2", rotated))
+	auth0.mu.Unlock()
+	later := now.Add(2 * refetchAfter)
+	if _, err := v.Verify(context.Background(), sign(t, "RS256", "rsa-2", rotated, claims(auth0Srv.URL+"/", nil)), later); err != nil {
+		t.Errorf("rotated key: %v", err)
+	}
+	if _, err := v.Verify(context.Background(), sign(t, "RS256", "rsa-3", rotated, claims(auth0Srv.URL+"/", nil)), later); !errors.Is(err, ErrInvalid) {
+		t.Errorf("unknown key: err = %v, want ErrInvalid", err)
+	}
+	if auth0.fetches != 2 {
+		t.Errorf("fetched the key set %d times, want 2", auth0.fetches)
+	}
+
+	// Keys survive a refresh that fails.
+	auth0Srv.Close()
+	if err := v.Refresh(context.Background()); err == nil {
+		t.Error("Refresh with the issuer down succeeded")
+	}
+	if _, err := v.Verify(context.Background(), sign(t, "RS256", "rsa-1", rsaKey, claims(auth0Srv.URL+"/", nil)), now); err != nil {
+		t.Errorf("after a failed refresh: %v", err)
+	}
+}
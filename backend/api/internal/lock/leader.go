@@ -0,0 +1,72 @@
+package lock
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Elector elects one leader among the processes campaigning for the same
+// name: the one holding the lock of that name. The leader renews its lock
+// every third of the ttl; if it can't, because it died or lost touch with
+// the Locker, another campaigner takes over within the ttl.
+type Elector struct {
+	locker Locker
+	name   string
+	ttl    time.Duration
+
+	// until is when the leadership runs out unless renewed, in Unix
+	// nanoseconds; zero while not leading.
+	until atomic.Int64
+}
+
+// NewElector returns an Elector for the lock name on locker.
+func NewElector(locker Locker, name string, ttl time.Duration) *Elector {
+	return &Elector{locker: locker, name: name, ttl: ttl}
+}
+
+// IsLeader reports whether this process leads. It stops doing so before
+// the lock could expire, so two processes never both think they lead.
+func (e *Elector) IsLeader() bool {
+	return time.Now().UnixNano() < e.until.Load()
+}
+
+// Leader returns the holder that leads, or "" if no one does.
+func (e *Elector) Leader(ctx context.Context) (string, error) {
+	return e.locker.Holder(ctx, e.name)
+}
+
+// Run campaigns until ctx is done, then steps down, releasing the lock so
+// another process can take over straight away.
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+	var held Lock
+	for {
+		start := time.Now()
+		var err error
+		if held == nil {
+			held, err = e.locker.TryLock(ctx, e.name, e.ttl)
+		} else if err = held.Extend(ctx, e.ttl); err != nil {
+			// Give up what may be left of the lock, so the next leader
+			// needn't wait for it to expire.
+			held.Unlock(context.WithoutCancel(ctx))
+			held = nil
+		}
+		if err == nil {
+			e.until.Store(start.Add(e.ttl - drift(e.ttl)).UnixNano())
+		} else {
+			e.until.Store(0)
+		}
+
+		select {
+		case <-ctx.Done():
+			e.until.Store(0)
+			if held != nil {
+				held.Unlock(context.WithoutCancel(ctx))
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
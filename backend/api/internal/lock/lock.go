@@ -0,0 +1,180 @@
+// Package lock hands out named locks that expire, so a job that must not
+// run twice at once can claim its name first. Memory's locks only exclude
+// the goroutines of one process; Redis's are shared by every replica using
+// the same Redis servers.
+//
+// Locks expire after the ttl they were taken for, so one held by a process
+// that died is freed on its own. Work that can outlast the ttl has to
+// Extend the lock before it runs out. Each Locker takes its locks in the
+// name of a holder, like the replica's ID, which Holder reports.
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrLocked is returned by TryLock when someone else holds the lock.
+var ErrLocked = errors.New("lock: held by someone else")
+
+// ErrNotHeld is returned by Extend when the lock expired, and may since have
+// been taken by someone else.
+var ErrNotHeld = errors.New("lock: no longer held")
+
+// Locker hands out locks.
+type Locker interface {
+	// TryLock takes the lock name for ttl, or fails with ErrLocked
+	// straight away if it is taken.
+	TryLock(ctx context.Context, name string, ttl time.Duration) (Lock, error)
+	// Holder returns the holder of the lock name, or "" if it is free.
+	Holder(ctx context.Context, name string) (string, error)
+}
+
+// Lock is a lock taken from a Locker.
+type Lock interface {
+	// Extend makes the lock expire ttl from now.
+	Extend(ctx context.Context, ttl time.Duration) error
+	// Unlock releases the lock. Unlocking one that expired is no error.
+	Unlock(ctx context.Context) error
+}
+
+// drift is how much the servers' clocks may run ahead of ours over ttl.
+func drift(ttl time.Duration) time.Duration {
+	return ttl/100 + 2*time.Millisecond
+}
+
+// token tells apart the holders of a lock, even two with the same name, so
+// one whose lock expired can't release or extend its next holder's. It is
+// holder/random.
+func token(holder string) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return holder + "/" + hex.EncodeToString(b)
+}
+
+func holderOf(token string) string {
+	i := strings.LastIndexByte(token, '/')
+	if i < 0 {
+		return ""
+	}
+	return token[:i]
+}
+
+// Memory is a Locker for the goroutines of one process. The zero value is
+// ready to use, with an empty holder.
+type Memory struct {
+	holder string
+	mu     sync.Mutex
+	held   map[string]memoryLock
+	clock  func() time.Time
+}
+
+type memoryLock struct {
+	token   string
+	expires time.Time
+}
+
+// NewMemory returns an in-process Locker taking locks in the name of
+// holder.
+func NewMemory(holder string) *Memory {
+	return &Memory{holder: holder}
+}
+
+func (m *Memory) now() time.Time {
+	if m.clock != nil {
+		return m.clock()
+	}
+	return time.Now()
+}
+
+func (m *Memory) TryLock(_ context.Context, name string, ttl time.Duration) (Lock, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	if l, ok := m.held[name]; ok && now.Before(l.expires) {
+		return nil, ErrLocked
+	}
+	if m.held == nil {
+		m.held = map[string]memoryLock{}
+	}
+	l := memoryLock{token: token(m.holder), expires: now.Add(ttl)}
+	m.held[name] = l
+	return &heldMemory{m: m, name: name, token: l.token}, nil
+}
+
+func (m *Memory) Holder(_ context.Context, name string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if l, ok := m.held[name]; ok && m.now().Before(l.expires) {
+		return holderOf(l.token), nil
+	}
+	return "", nil
+}
+
+type heldMemory struct {
+	m     *Memory
+	name  string
+	token string
+}
+
+func (h *heldMemory) Extend(_ context.Context, ttl time.Duration) error {
+	h.m.mu.Lock()
+	defer h.m.mu.Unlock()
+	now := h.m.now()
+	l, ok := h.m.held[h.name]
+	if !ok || l.token != h.token || !now.Before(l.expires) {
+		return ErrNotHeld
+	}
+	l.expires = now.Add(ttl)
+	h.m.held[h.name] = l
+	return nil
+}
+
+func (h *heldMemory) Unlock(context.Context) error {
+	h.m.mu.Lock()
+	defer h.m.mu.Unlock()
+	if l, ok := h.m.held[h.name]; ok && l.token == h.token {
+		delete(h.m.held, h.name)
+	}
+	return nil
+}
+
+// Do runs fn holding the lock name, extending it every third of ttl while
+// fn runs, and releases it once fn returns. If the lock is taken it returns
+// ErrLocked without running fn; if an extension fails, fn's context is
+// canceled, as someone else may take over.
+func Do(ctx context.Context, l Locker, name string, ttl time.Duration, fn func(ctx context.Context) error) error {
+	held, err := l.TryLock(ctx, name, ttl)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	done := make(chan struct{})
+	extended := make(chan struct{})
+	go func() {
+		defer close(extended)
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := held.Extend(ctx, ttl); err != nil {
+					cancel(err)
+					return
+				}
+			}
+		}
+	}()
+	err = fn(ctx)
+	close(done)
+	<-extended
+	return errors.Join(err, held.Unlock(context.WithoutCancel(ctx)))
+}
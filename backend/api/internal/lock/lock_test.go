@@ -0,0 +1,90 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := &Memory{holder: "api-0", clock: func() time.Time { return now }}
+
+	first, err := m.TryLock(ctx, "job", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := m.Holder(ctx, "job"); got != "api-0" {
+		t.Errorf("Holder = %q, want api-0", got)
+	}
+	if _, err := m.TryLock(ctx, "job", time.Minute); !errors.Is(err, ErrLocked) {
+		t.Fatalf("second TryLock: err = %v, want ErrLocked", err)
+	}
+	if _, err := m.TryLock(ctx, "other", time.Minute); err != nil {
+		t.Errorf("another name: %v", err)
+	}
+	if err := first.Extend(ctx, 2*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(90 * time.Second)
+	if _, err := m.TryLock(ctx, "job", time.Minute); !errors.Is(err, ErrLocked) {
+		t.Fatalf("TryLock before the extended expiry: err = %v, want ErrLocked", err)
+	}
+
+	now = now.Add(time.Minute)
+	second, err := m.TryLock(ctx, "job", time.Minute)
+	if err != nil {
+		t.Fatalf("TryLock after the expiry: %v", err)
+	}
+	// The first holder's lock ran out; it can't touch the second's.
+	if err := first.Extend(ctx, time.Minute); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("Extend of an expired lock: err = %v, want ErrNotHeld", err)
+	}
+	if err := first.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.TryLock(ctx, "job", time.Minute); !errors.Is(err, ErrLocked) {
+		t.Errorf("stale Unlock released the new holder's lock")
+	}
+	if err := second.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := m.Holder(ctx, "job"); got != "" {
+		t.Errorf("Holder after Unlock = %q, want none", got)
+	}
+}
+
+func TestDo(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory("api-0")
+	err := Do(ctx, m, "job", 30*time.Millisecond, func(ctx context.Context) error {
+		if err := Do(ctx, m, "job", time.Second, func(context.Context) error { return nil }); !errors.Is(err, ErrLocked) {
+			t.Errorf("nested Do: err = %v, want ErrLocked", err)
+		}
+		// Outlive the ttl: the lock is extended meanwhile.
+		time.Sleep(50 * time.Millisecond)
+		if _, err := m.TryLock(ctx, "job", time.Second); !errors.Is(err, ErrLocked) {
+			t.Errorf("lock expired while held: err = %v", err)
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.TryLock(ctx, "job", time.Second); err != nil {
+		t.Errorf("TryLock after Do: %v", err)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for range 100 {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("timed out")
+}
@@ -0,0 +1,199 @@
+//go:build !noredis && !minimal
+
+package lock
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis is a Locker shared through Redis. With one server it is a plain
+// SET NX lock; with several, independent ones, it uses the Redlock
+// algorithm: a lock is taken if a majority of the servers grant it within
+// its ttl, so it survives a minority of them failing.
+type Redis struct {
+	holder  string
+	clients []*redis.Client
+	prefix  string
+}
+
+// NewRedis returns a Locker over the Redis servers at urls, like
+// redis://:password@localhost:6379/0, taking locks in the name of holder.
+// Lock names are prefixed with "lock:".
+func NewRedis(holder string, urls ...string) (*Redis, error) {
+	return NewRedisPool(holder, RedisPool{}, urls...)
+}
+
+// NewRedisPool is NewRedis with the connection pools sized by pool.
+func NewRedisPool(holder string, pool RedisPool, urls ...string) (*Redis, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("lock: no Redis servers")
+	}
+	r := &Redis{holder: holder, prefix: "lock:"}
+	for _, u := range urls {
+		opts, err := redis.ParseURL(u)
+		if err != nil {
+			return nil, fmt.Errorf("lock: %w", err)
+		}
+		if pool.MaxOpen > 0 {
+			// PoolSize is what go-redis keeps open; MaxActiveConns caps it.
+			opts.PoolSize, opts.MaxActiveConns = pool.MaxOpen, pool.MaxOpen
+		}
+		opts.MaxIdleConns = cmp.Or(pool.MaxIdle, opts.MaxIdleConns)
+		opts.ConnMaxLifetime = cmp.Or(pool.MaxLifetime, opts.ConnMaxLifetime)
+		opts.ConnMaxIdleTime = cmp.Or(pool.MaxIdleTime, opts.ConnMaxIdleTime)
+		opts.PoolTimeout = cmp.Or(pool.Timeout, opts.PoolTimeout)
+		r.clients = append(r.clients, redis.NewClient(opts))
+	}
+	return r, nil
+}
+
+// PoolStats reports the pool of each server, in the order of the URLs.
+func (r *Redis) PoolStats() []PoolStats {
+	out := make([]PoolStats, len(r.clients))
+	for i, c := range r.clients {
+		st := c.PoolStats()
+		out[i] = PoolStats{
+			Server:   c.Options().Addr,
+			InUse:    int(st.TotalConns) - int(st.IdleConns),
+			Idle:     int(st.IdleConns),
+			Waits:    uint64(st.WaitCount),
+			Timeouts: uint64(st.Timeouts),
+			Waited:   time.Duration(st.WaitDurationNs),
+		}
+	}
+	return out
+}
+
+// Close closes the connections to the servers.
+func (r *Redis) Close() error {
+	var errs []error
+	for _, c := range r.clients {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Ping checks that a majority of the servers answer, as locks need.
+func (r *Redis) Ping(ctx context.Context) error {
+	var errs []error
+	for _, c := range r.clients {
+		if err := c.Ping(ctx).Err(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(r.clients)-len(errs) < r.quorum() {
+		return fmt.Errorf("lock: %d of %d Redis servers answer, want %d: %w", len(r.clients)-len(errs), len(r.clients), r.quorum(), errors.Join(errs...))
+	}
+	return nil
+}
+
+// Only the holder's token may extend or delete a key.
+var (
+	extendScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) end return 0`)
+	unlockScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`)
+)
+
+func (r *Redis) quorum() int {
+	return len(r.clients)/2 + 1
+}
+
+func (r *Redis) TryLock(ctx context.Context, name string, ttl time.Duration) (Lock, error) {
+	h := &heldRedis{r: r, key: r.prefix + name, token: token(r.holder)}
+	start := time.Now()
+	granted, taken, errs := 0, 0, []error(nil)
+	for _, c := range r.clients {
+		// Bound each server by the ttl, so a slow one can't use it all up.
+		cctx, cancel := context.WithTimeout(ctx, ttl/time.Duration(2*len(r.clients)))
+		ok, err := c.SetNX(cctx, h.key, h.token, ttl).Result()
+		cancel()
+		switch {
+		case err != nil:
+			errs = append(errs, err)
+		case ok:
+			granted++
+		default:
+			taken++
+		}
+	}
+	if granted >= r.quorum() && time.Since(start)+drift(ttl) < ttl {
+		return h, nil
+	}
+	// Give back what was granted, lest it block the next try until it
+	// expires.
+	h.Unlock(context.WithoutCancel(ctx))
+	if taken == 0 && len(errs) > 0 {
+		return nil, fmt.Errorf("lock: %w", errors.Join(errs...))
+	}
+	return nil, ErrLocked
+}
+
+// Holder returns the holder a majority of the servers agree on.
+func (r *Redis) Holder(ctx context.Context, name string) (string, error) {
+	votes := map[string]int{}
+	var errs []error
+	for _, c := range r.clients {
+		v, err := c.Get(ctx, r.prefix+name).Result()
+		switch {
+		case errors.Is(err, redis.Nil):
+		case err != nil:
+			errs = append(errs, err)
+		default:
+			votes[v]++
+		}
+	}
+	for v, n := range votes {
+		if n >= r.quorum() {
+			return holderOf(v), nil
+		}
+	}
+	if len(errs) >= r.quorum() {
+		return "", fmt.Errorf("lock: %w", errors.Join(errs...))
+	}
+	return "", nil
+}
+
+type heldRedis struct {
+	r     *Redis
+	key   string
+	token string
+}
+
+func (h *heldRedis) Extend(ctx context.Context, ttl time.Duration) error {
+	start := time.Now()
+	extended := 0
+	var errs []error
+	for _, c := range h.r.clients {
+		n, err := extendScript.Run(ctx, c, []string{h.key}, h.token, ttl.Milliseconds()).Int()
+		if err != nil {
+			errs = append(errs, err)
+		} else if n == 1 {
+			extended++
+		}
+	}
+	if extended >= h.r.quorum() && time.Since(start)+drift(ttl) < ttl {
+		return nil
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrNotHeld, errors.Join(errs...))
+	}
+	return ErrNotHeld
+}
+
+func (h *heldRedis) Unlock(ctx context.Context) error {
+	var errs []error
+	for _, c := range h.r.clients {
+		if err := unlockScript.Run(ctx, c, []string{h.key}, h.token).Err(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("lock: %w", err)
+	}
+	return nil
+}
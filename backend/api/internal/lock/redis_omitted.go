@@ -0,0 +1,42 @@
+//go:build noredis || minimal
+
+package lock
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// errRedisOmitted is what NewRedis fails with in a binary built without
+// Redis.
+var errRedisOmitted = errors.New("lock: Redis support is not in this binary, which was built with the noredis or minimal tag")
+
+// Redis stands in for the Redis Locker in a binary built with the noredis
+// or minimal tag, which leaves go-redis out. NewRedis always fails, so
+// locks are held within the replica.
+type Redis struct{}
+
+// NewRedis fails: this binary was built without Redis.
+func NewRedis(holder string, urls ...string) (*Redis, error) {
+	return nil, errRedisOmitted
+}
+
+// NewRedisPool fails: this binary was built without Redis.
+func NewRedisPool(holder string, pool RedisPool, urls ...string) (*Redis, error) {
+	return nil, errRedisOmitted
+}
+
+func (r *Redis) PoolStats() []PoolStats { return nil }
+
+func (r *Redis) Close() error { return nil }
+
+func (r *Redis) Ping(ctx context.Context) error { return errRedisOmitted }
+
+func (r *Redis) TryLock(ctx context.Context, name string, ttl time.Duration) (Lock, error) {
+	return nil, errRedisOmitted
+}
+
+func (r *Redis) Holder(ctx context.Context, name string) (string, error) {
+	return "", errRedisOmitted
+}
@@ -0,0 +1,171 @@
+//go:build !noredis && !minimal
+
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newRedis(t *testing.T, holder string, servers ...*miniredis.Miniredis) *Redis {
+	t.Helper()
+	urls := make([]string, len(servers))
+	for i, s := range servers {
+		urls[i] = "redis://" + s.Addr()
+	}
+	r, err := NewRedis(holder, urls...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	a, b := newRedis(t, "api-0", srv), newRedis(t, "api-1", srv)
+
+	held, err := a.TryLock(ctx, "job", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := srv.TTL("lock:job"); got != time.Minute {
+		t.Errorf("TTL = %v, want 1m", got)
+	}
+	if _, err := b.TryLock(ctx, "job", time.Minute); !errors.Is(err, ErrLocked) {
+		t.Fatalf("TryLock from another replica: err = %v, want ErrLocked", err)
+	}
+	if got, _ := b.Holder(ctx, "job"); got != "api-0" {
+		t.Errorf("Holder = %q, want api-0", got)
+	}
+	if err := held.Extend(ctx, 5*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if got := srv.TTL("lock:job"); got != 5*time.Minute {
+		t.Errorf("TTL after Extend = %v, want 5m", got)
+	}
+
+	srv.FastForward(6 * time.Minute)
+	if _, err := b.TryLock(ctx, "job", time.Minute); err != nil {
+		t.Fatalf("TryLock after the expiry: %v", err)
+	}
+	if err := held.Extend(ctx, time.Minute); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("Extend of an expired lock: err = %v, want ErrNotHeld", err)
+	}
+	if err := held.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !srv.Exists("lock:job") {
+		t.Error("stale Unlock released the new holder's lock")
+	}
+}
+
+func TestRedisPool(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	r, err := NewRedisPool("api-0", RedisPool{MaxOpen: 1, Timeout: 50 * time.Millisecond}, "redis://"+srv.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if _, err := r.TryLock(ctx, "job", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	st := r.PoolStats()
+	if len(st) != 1 || st[0].Server != srv.Addr() || st[0].InUse != 0 || st[0].Idle != 1 {
+		t.Fatalf("PoolStats = %+v, want the one connection idle", st)
+	}
+
+	// With the one connection taken, the next call waits for it in vain.
+	conn := r.clients[0].Conn()
+	if err := conn.Ping(ctx).Err(); err != nil {
+		t.Fatal(err)
+	}
+	r.Holder(ctx, "job")
+	conn.Close()
+	if st := r.PoolStats(); st[0].Timeouts == 0 {
+		t.Errorf("PoolStats = %+v, want a wait that timed out", st)
+	}
+}
+
+func TestRedlock(t *testing.T) {
+	ctx := context.Background()
+	s1, s2, s3 := miniredis.RunT(t), miniredis.RunT(t), miniredis.RunT(t)
+	r := newRedis(t, "api-0", s1, s2, s3)
+	if err := r.Ping(ctx); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+
+	// One server already holds the name for someone else: two of three is
+	// still a majority.
+	s1.Set("lock:job", "api-1/0123")
+	held, err := r.TryLock(ctx, "job", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := r.Holder(ctx, "job"); got != "api-0" {
+		t.Errorf("Holder = %q, want api-0, whom the majority agree on", got)
+	}
+	if _, err := r.TryLock(ctx, "job", time.Minute); !errors.Is(err, ErrLocked) {
+		t.Fatalf("second TryLock: err = %v, want ErrLocked", err)
+	}
+	if err := held.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s1.Get("lock:job"); got != "api-1/0123" {
+		t.Errorf("Unlock deleted another holder's key; it is now %q", got)
+	}
+
+	// With two of three down there is no majority, and nothing is left
+	// locked on the one that answered.
+	s2.Close()
+	s3.Close()
+	s1.Del("lock:job")
+	if _, err := r.TryLock(ctx, "job", time.Minute); err == nil || errors.Is(err, ErrLocked) {
+		t.Errorf("TryLock without a majority: err = %v, want the servers' errors", err)
+	}
+	if s1.Exists("lock:job") {
+		t.Error("a failed TryLock left its key behind")
+	}
+	if err := r.Ping(ctx); err == nil {
+		t.Error("Ping without a majority succeeded")
+	}
+}
+
+func TestElector(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := miniredis.RunT(t)
+	a := NewElector(newRedis(t, "api-0", srv), "leader", 30*time.Millisecond)
+	b := NewElector(newRedis(t, "api-1", srv), "leader", 30*time.Millisecond)
+
+	done := make(chan struct{})
+	go func() { a.Run(ctx); close(done) }()
+	waitFor(t, a.IsLeader)
+	bctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go b.Run(bctx)
+	// a renews its lock, so b doesn't take over once it expires.
+	time.Sleep(50 * time.Millisecond)
+	if b.IsLeader() {
+		t.Fatal("both lead")
+	}
+	if got, _ := b.Leader(ctx); got != "api-0" {
+		t.Errorf("Leader = %q, want api-0", got)
+	}
+
+	// a steps down, and b takes over.
+	cancel()
+	<-done
+	if a.IsLeader() {
+		t.Error("a leads after stepping down")
+	}
+	waitFor(t, b.IsLeader)
+	if got, _ := a.Leader(context.Background()); got != "api-1" {
+		t.Errorf("Leader = %q, want api-1", got)
+	}
+}
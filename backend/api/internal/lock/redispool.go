@@ -0,0 +1,24 @@
+package lock
+
+import "time"
+
+// RedisPool sizes the connection pool kept to each server. Zero fields keep
+// what the server's URL sets, like ?pool_size=20, or go-redis's default.
+type RedisPool struct {
+	MaxOpen     int           // connections open at once; more wait for one
+	MaxIdle     int           // idle connections kept open
+	MaxLifetime time.Duration // how long a connection is reused before it is replaced
+	MaxIdleTime time.Duration // how long an idle connection is kept open
+	Timeout     time.Duration // how long to wait for a connection when MaxOpen are in use
+}
+
+// PoolStats is how the connection pool to one server is doing. The counts
+// of waits and timeouts, and the time waited, add up since NewRedis.
+type PoolStats struct {
+	Server   string // host:port
+	InUse    int    // connections handed out
+	Idle     int    // connections open and waiting to be used
+	Waits    uint64 // times a connection had to be waited for
+	Timeouts uint64 // times none freed up within the pool timeout
+	Waited   time.Duration
+}
@@ -0,0 +1,279 @@
+// Package mock serves an OpenAPI spec's operations with canned responses
+// generated from their schemas and examples, so clients can be built
+// against the contract before the logic behind it exists.
+//
+// Responses are deterministic: every call to an operation gets the same
+// body, whatever the path, query or request body.
+package mock
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/go-chi/chi/v5"
+)
+
+// Fixed values for the string formats the specs use, so examples pass the
+// clients' own validation.
+var formats = map[string]string{
+	"date-time": "2024-01-01T00:00:00Z",
+	"date":      "2024-01-01",
+	"time":      "00:00:00Z",
+	"email":     "user@example.com",
+	"idn-email": "user@example.com",
+	"uri":       "https://example.com",
+	"url":       "https://example.com",
+	"uuid":      "00000000-0000-4000-8000-000000000000",
+	"ipv4":      "192.0.2.1",
+	"ipv6":      "2001:db8::1",
+	"hostname":  "example.com",
+}
+
+// Example returns an example value for s: the first of its examples, its
+// default or its first enum value if it has one, or else a placeholder of
+// its type and format, built up field by field for objects.
+func Example(spec *huma.OpenAPI, s *huma.Schema) any {
+	return example(spec.Components.Schemas, s, map[string]bool{})
+}
+
+// example builds the example of s. seen holds the $refs being expanded,
+// so a recursive schema ends in null rather than looping.
+func example(registry huma.Registry, s *huma.Schema, seen map[string]bool) any {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		if seen[s.Ref] {
+			return nil
+		}
+		seen[s.Ref] = true
+		defer delete(seen, s.Ref)
+		return example(registry, registry.SchemaFromRef(s.Ref), seen)
+	}
+	switch {
+	case len(s.Examples) > 0:
+		return s.Examples[0]
+	case s.Default != nil:
+		return s.Default
+	case len(s.Enum) > 0:
+		return s.Enum[0]
+	case len(s.OneOf) > 0:
+		return example(registry, s.OneOf[0], seen)
+	case len(s.AnyOf) > 0:
+		return example(registry, s.AnyOf[0], seen)
+	case len(s.AllOf) > 0:
+		return example(registry, s.AllOf[0], seen)
+	}
+	switch s.Type {
+	case huma.TypeObject:
+		obj := map[string]any{}
+		for name, prop := range s.Properties {
+			if prop.WriteOnly {
+				continue
+			}
+			obj[name] = example(registry, prop, seen)
+		}
+		return obj
+	case huma.TypeArray:
+		return []any{example(registry, s.Items, seen)}
+	case huma.TypeString:
+		if v, ok := formats[s.Format]; ok {
+			return v
+		}
+		if s.MinLength != nil && *s.MinLength > len("string") {
+			return strings.Repeat("x", *s.MinLength)
+		}
+		return "string"
+	case huma.TypeInteger:
+		if s.Minimum != nil {
+			return int64(*s.Minimum)
+		}
+		return 1
+	case huma.TypeNumber:
+		if s.Minimum != nil {
+			return *s.Minimum
+		}
+		return 1.5
+	case huma.TypeBoolean:
+		return true
+	}
+	return nil
+}
+
+// response is what an operation answers with one status.
+type response struct {
+	status      int
+	contentType string
+	body        []byte // nil for a response without content
+}
+
+// responses returns every response op documents, by status code, and its
+// lowest 2xx one, its default.
+func responses(spec *huma.OpenAPI, op *huma.Operation) (map[int]response, int, error) {
+	byStatus := map[int]response{}
+	success := 0
+	for code, r := range op.Responses {
+		status, err := strconv.Atoi(code)
+		if err != nil {
+			continue // "default" or a range like "4XX"
+		}
+		resp := response{status: status}
+		types := make([]string, 0, len(r.Content))
+		for t := range r.Content {
+			types = append(types, t)
+		}
+		slices.Sort(types)
+		if i := slices.Index(types, "application/json"); i > 0 {
+			types[0], types[i] = types[i], types[0]
+		}
+		if len(types) > 0 {
+			media := r.Content[types[0]]
+			v := media.Example
+			if v == nil {
+				v = Example(spec, media.Schema)
+			}
+			if resp.body, err = json.Marshal(v); err != nil {
+				return nil, 0, err
+			}
+			resp.contentType = types[0]
+		}
+		byStatus[status] = resp
+		if status/100 == 2 && (success == 0 || status < success) {
+			success = status
+		}
+	}
+	return byStatus, cmp.Or(success, http.StatusOK), nil
+}
+
+// Handler serves every operation in spec with its example response.
+// Requests pick another documented response with a Prefer: code=404
+// header, to try the clients' error handling.
+func Handler(spec *huma.OpenAPI) (http.Handler, error) {
+	router := chi.NewRouter()
+	router.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "mock: no operation matches the path", http.StatusNotFound)
+	})
+	for path, item := range spec.Paths {
+		for method, op := range map[string]*huma.Operation{
+			http.MethodGet:    item.Get,
+			http.MethodPut:    item.Put,
+			http.MethodPost:   item.Post,
+			http.MethodDelete: item.Delete,
+			http.MethodPatch:  item.Patch,
+			http.MethodHead:   item.Head,
+		} {
+			if op == nil {
+				continue
+			}
+			byStatus, success, err := responses(spec, op)
+			if err != nil {
+				return nil, err
+			}
+			router.MethodFunc(method, path, func(w http.ResponseWriter, r *http.Request) {
+				status := success
+				if code, ok := preferredCode(r); ok {
+					status = code
+				}
+				resp, ok := byStatus[status]
+				if !ok {
+					// The operation doesn't document that response; answer
+					// with just the status.
+					resp = response{status: status}
+				}
+				w.Header().Set("X-Mock", "true")
+				if resp.body == nil {
+					w.WriteHeader(resp.status)
+					return
+				}
+				w.Header().Set("Content-Type", resp.contentType)
+				w.WriteHeader(resp.status)
+				w.Write(resp.body)
+			})
+		}
+	}
+	return router, nil
+}
+
+// preferredCode parses a Prefer: code=NNN header.
+func preferredCode(r *http.Request) (int, bool) {
+	for _, pref := range strings.Split(r.Header.Get("Prefer"), ",") {
+		v, ok := strings.CutPrefix(strings.TrimSpace(pref), "code=")
+		if !ok {
+			continue
+		}
+		if code, err := strconv.Atoi(v); err == nil && code >= 100 && code <= 599 {
+			return code, true
+		}
+	}
+	return 0, false
+}
+
+// Fixture is an operation's canned responses, for mocks of the API outside
+// Go, like packages/api-mock, to answer as Handler does.
+type Fixture struct {
+	OperationID string `json:"operationId"`
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	// Status is the response answered unless the request prefers another.
+	Status    int                        `json:"status"`
+	Responses map[string]FixtureResponse `json:"responses"` // by status
+}
+
+// FixtureResponse is the response of a Fixture with one status. Body, if
+// set, is sent as it is, encoded as JSON.
+type FixtureResponse struct {
+	ContentType string          `json:"contentType,omitempty"`
+	Body        json.RawMessage `json:"body,omitempty"`
+}
+
+// Fixtures returns the fixtures of every operation in spec, sorted by path
+// and method so they diff well.
+func Fixtures(spec *huma.OpenAPI) ([]Fixture, error) {
+	var out []Fixture
+	for path, item := range spec.Paths {
+		for _, op := range []*huma.Operation{item.Get, item.Put, item.Post, item.Delete, item.Patch, item.Head} {
+			if op == nil {
+				continue
+			}
+			byStatus, success, err := responses(spec, op)
+			if err != nil {
+				return nil, err
+			}
+			f := Fixture{OperationID: op.OperationID, Method: op.Method, Path: path, Status: success, Responses: map[string]FixtureResponse{}}
+			for status, resp := range byStatus {
+				f.Responses[strconv.Itoa(status)] = FixtureResponse{ContentType: resp.contentType, Body: resp.body}
+			}
+			out = append(out, f)
+		}
+	}
+	slices.SortFunc(out, func(a, b Fixture) int {
+		return cmp.Or(strings.Compare(a.Path, b.Path), strings.Compare(a.Method, b.Method))
+	})
+	return out, nil
+}
+
+// FixturesModule is the TypeScript module of packages/api-mock holding the
+// fixtures of spec, as `api gen:mock` writes it.
+func FixturesModule(spec *huma.OpenAPI) ([]byte, error) {
+	fixtures, err := Fixtures(spec)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.MarshalIndent(fixtures, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by `api gen:mock` from the OpenAPI spec. DO NOT EDIT.\n\n")
+	buf.WriteString("import type { Fixture } from \"./types\";\n\n")
+	buf.WriteString("export const fixtures: Fixture[] = ")
+	buf.Write(b)
+	buf.WriteString(";\n")
+	return buf.Bytes(), nil
+}
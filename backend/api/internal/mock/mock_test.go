@@ -0,0 +1,148 @@
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humachi"
+	"github.com/go-chi/chi/v5"
+)
+
+type Node struct {
+	Name     string    `json:"name" example:"root"`
+	Kind     string    `json:"kind" enum:"file,dir"`
+	Size     int       `json:"size" minimum:"10"`
+	Created  string    `json:"created" format:"date-time"`
+	Password string    `json:"password,omitempty" writeOnly:"true"`
+	Children []*Node   `json:"children"`
+	Tags     []string  `json:"tags"`
+	Score    float64   `json:"score"`
+	Owner    NodeOwner `json:"owner"`
+}
+
+type NodeOwner struct {
+	Email string `json:"email" format:"email"`
+}
+
+func spec(t *testing.T) *huma.OpenAPI {
+	t.Helper()
+	api := humachi.New(chi.NewRouter(), huma.DefaultConfig("Test", "1.0.0"))
+	huma.Register(api, huma.Operation{
+		OperationID: "get-node",
+		Method:      http.MethodGet,
+		Path:        "/nodes/{id}",
+		Errors:      []int{http.StatusNotFound},
+	}, func(ctx context.Context, input *struct {
+		ID string `path:"id"`
+	}) (*struct{ Body Node }, error) {
+		panic("the mock must not run the handler")
+	})
+	huma.Register(api, huma.Operation{
+		OperationID:   "delete-node",
+		Method:        http.MethodDelete,
+		Path:          "/nodes/{id}",
+		DefaultStatus: http.StatusNoContent,
+	}, func(ctx context.Context, input *struct {
+		ID string `path:"id"`
+	}) (*struct{}, error) {
+		panic("the mock must not run the handler")
+	})
+	return api.OpenAPI()
+}
+
+func TestHandler(t *testing.T) {
+	h, err := Handler(spec(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(prefer string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/nodes/anything", nil)
+		if prefer != "" {
+			req.Header.Set("Prefer", prefer)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("")
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Mock") != "true" {
+		t.Fatalf("status %d, X-Mock %q", rec.Code, rec.Header().Get("X-Mock"))
+	}
+	var node map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &node); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"name":    "root",
+		"kind":    "file",
+		"size":    float64(10),
+		"created": "2024-01-01T00:00:00Z",
+		"score":   1.5,
+	}
+	for k, v := range want {
+		if node[k] != v {
+			t.Errorf("%s = %v, want %v", k, node[k], v)
+		}
+	}
+	if _, ok := node["password"]; ok {
+		t.Error("the write-only password is in the response")
+	}
+	// The recursive children end in null rather than looping.
+	if children, _ := node["children"].([]any); len(children) != 1 || children[0] != nil {
+		t.Errorf("children = %v, want [null]", node["children"])
+	}
+	if owner, _ := node["owner"].(map[string]any); owner["email"] != "user@example.com" {
+		t.Errorf("owner = %v", node["owner"])
+	}
+	if again := get(""); again.Body.String() != rec.Body.String() {
+		t.Errorf("responses differ:\n%s\n%s", rec.Body, again.Body)
+	}
+
+	rec = get("respond-async, code=404")
+	if rec.Code != http.StatusNotFound || rec.Header().Get("Content-Type") != "application/problem+json" {
+		t.Errorf("Prefer code=404: status %d, Content-Type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/nodes/1", nil))
+	if rec.Code != http.StatusNoContent || rec.Body.Len() != 0 {
+		t.Errorf("delete: status %d, body %q", rec.Code, rec.Body)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown path: status %d", rec.Code)
+	}
+}
+
+func TestFixtures(t *testing.T) {
+	fixtures, err := Fixtures(spec(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) != 2 || fixtures[0].OperationID != "delete-node" || fixtures[1].OperationID != "get-node" {
+		t.Fatalf("fixtures %+v, want delete-node and get-node, sorted by method", fixtures)
+	}
+	del, get := fixtures[0], fixtures[1]
+	if del.Status != http.StatusNoContent || del.Responses["204"].Body != nil {
+		t.Errorf("delete-node answers %d %s, want 204 without a body", del.Status, del.Responses["204"].Body)
+	}
+	if get.Path != "/nodes/{id}" || get.Status != http.StatusOK || get.Responses["404"].ContentType != "application/problem+json" {
+		t.Errorf("get-node fixture %+v", get)
+	}
+	// The bodies are those Handler serves.
+	h, err := Handler(spec(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/nodes/1", nil))
+	if got := string(get.Responses["200"].Body); got != rec.Body.String() {
+		t.Errorf("fixture body %s, Handler serves %s", got, rec.Body)
+	}
+}
@@ -0,0 +1,100 @@
+// Package objstore reads and writes whole objects at a location given as a
+// URL: s3://bucket/key for S3 or an S3-compatible service, or a local path.
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Put writes data to dest, replacing any object already there.
+func Put(ctx context.Context, dest string, data []byte) error {
+	bucket, key, ok, err := parseS3(dest)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(dest, data, 0600)
+	}
+	client, err := newS3Client(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	})
+	if err != nil {
+		return fmt.Errorf("upload %s: %w", dest, err)
+	}
+	return nil
+}
+
+// Get reads the object at src.
+func Get(ctx context.Context, src string) ([]byte, error) {
+	bucket, key, ok, err := parseS3(src)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return os.ReadFile(src)
+	}
+	client, err := newS3Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", src, err)
+	}
+	defer out.Body.Close()
+	return io.ReadAll(out.Body)
+}
+
+// parseS3 splits an s3:// URL into bucket and key. ok is false for anything
+// else, which is a local path.
+func parseS3(loc string) (bucket, key string, ok bool, err error) {
+	if !strings.HasPrefix(loc, "s3://") {
+		return "", "", false, nil
+	}
+	u, err := url.Parse(loc)
+	if err != nil {
+		return "", "", false, err
+	}
+	bucket, key = u.Host, strings.TrimPrefix(u.Path, "/")
+	if bucket == "" || key == "" {
+		return "", "", false, fmt.Errorf("%s: want s3://bucket/key", loc)
+	}
+	return bucket, key, true, nil
+}
+
+// newS3Client configures S3 the standard AWS way: credentials and region
+// from the environment, shared config files or the instance role, and
+// AWS_ENDPOINT_URL_S3 for an S3-compatible service. Such services are
+// addressed path-style, which MinIO and most others expect.
+func newS3Client(ctx context.Context) (*s3.Client, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load AWS config: %w", err)
+	}
+	return s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.UsePathStyle = o.BaseEndpoint != nil
+	}), nil
+}
@@ -0,0 +1,168 @@
+// Package profiling runs the optional continuous profilers: pushing CPU,
+// heap and goroutine profiles to Pyroscope, and serving net/http/pprof on a
+// private address for Parca or ad hoc `go tool pprof` sessions to pull. It
+// also runs the watchdog, which warns when goroutines, the heap or
+// scheduling lag grow past their thresholds.
+package profiling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/grafana/pyroscope-go"
+)
+
+// Options selects the profilers to run. Both are off when empty.
+type Options struct {
+	// PyroscopeAddr is the Pyroscope server profiles are pushed to.
+	PyroscopeAddr string
+	// PyroscopeUser and PyroscopePassword authenticate to hosted Pyroscope.
+	PyroscopeUser     string
+	PyroscopePassword string
+	// AppName names the application in Pyroscope.
+	AppName string
+	// Version tags every profile, so growth can be pinned to a release.
+	Version string
+
+	// PprofAddr, e.g. "127.0.0.1:6060", serves /debug/pprof/. Keep it off
+	// the public interface: profiles reveal internals and are expensive.
+	PprofAddr string
+
+	// MaxGoroutines, MaxHeapBytes and MaxSchedulingLag are the thresholds
+	// past which the watchdog logs a warning. Zero leaves one unwatched;
+	// the watchdog is off when all are.
+	MaxGoroutines    int
+	MaxHeapBytes     uint64
+	MaxSchedulingLag time.Duration
+	// WatchdogInterval is how often the watchdog checks; 10 seconds if
+	// zero.
+	WatchdogInterval time.Duration
+	// HeapProfileDir, if set, is where the watchdog writes a heap profile
+	// when the heap goes past MaxHeapBytes, at most once an hour.
+	HeapProfileDir string
+}
+
+// OptionsFromEnv reads PYROSCOPE_SERVER_ADDRESS, PYROSCOPE_BASIC_AUTH_USER,
+// PYROSCOPE_BASIC_AUTH_PASSWORD, PYROSCOPE_APPLICATION_NAME, PPROF_ADDR,
+// WATCHDOG_MAX_GOROUTINES, WATCHDOG_MAX_HEAP_MB, WATCHDOG_MAX_LAG,
+// WATCHDOG_INTERVAL and WATCHDOG_HEAP_PROFILE_DIR.
+func OptionsFromEnv() (Options, error) {
+	appName := os.Getenv("PYROSCOPE_APPLICATION_NAME")
+	if appName == "" {
+		appName = "monorepo-demo.api"
+	}
+	opts := Options{
+		PyroscopeAddr:     os.Getenv("PYROSCOPE_SERVER_ADDRESS"),
+		PyroscopeUser:     os.Getenv("PYROSCOPE_BASIC_AUTH_USER"),
+		PyroscopePassword: os.Getenv("PYROSCOPE_BASIC_AUTH_PASSWORD"),
+		AppName:           appName,
+		PprofAddr:         os.Getenv("PPROF_ADDR"),
+		HeapProfileDir:    os.Getenv("WATCHDOG_HEAP_PROFILE_DIR"),
+	}
+	if v := os.Getenv("WATCHDOG_MAX_GOROUTINES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return opts, fmt.Errorf("WATCHDOG_MAX_GOROUTINES: want a count, got %q", v)
+		}
+		opts.MaxGoroutines = n
+	}
+	if v := os.Getenv("WATCHDOG_MAX_HEAP_MB"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return opts, fmt.Errorf("WATCHDOG_MAX_HEAP_MB: want a number of MiB, got %q", v)
+		}
+		opts.MaxHeapBytes = n << 20
+	}
+	for key, dst := range map[string]*time.Duration{
+		"WATCHDOG_MAX_LAG":  &opts.MaxSchedulingLag,
+		"WATCHDOG_INTERVAL": &opts.WatchdogInterval,
+	} {
+		if v := os.Getenv(key); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return opts, fmt.Errorf("%s: %w", key, err)
+			}
+			*dst = d
+		}
+	}
+	return opts, nil
+}
+
+// Start starts the profilers and watchdog opts enables. The returned stop
+// flushes the last Pyroscope upload, closes the pprof listener and stops the
+// watchdog.
+func Start(opts Options) (stop func(), err error) {
+	var stops []func()
+	stop = func() {
+		for _, s := range stops {
+			s()
+		}
+	}
+
+	if opts.PyroscopeAddr != "" {
+		profiler, err := pyroscope.Start(pyroscope.Config{
+			ApplicationName:   opts.AppName,
+			ServerAddress:     opts.PyroscopeAddr,
+			BasicAuthUser:     opts.PyroscopeUser,
+			BasicAuthPassword: opts.PyroscopePassword,
+			Tags:              map[string]string{"version": opts.Version},
+			ProfileTypes: []pyroscope.ProfileType{
+				pyroscope.ProfileCPU,
+				pyroscope.ProfileAllocObjects,
+				pyroscope.ProfileAllocSpace,
+				pyroscope.ProfileInuseObjects,
+				pyroscope.ProfileInuseSpace,
+				pyroscope.ProfileGoroutines,
+			},
+		})
+		if err != nil {
+			return stop, fmt.Errorf("start Pyroscope: %w", err)
+		}
+		log.Printf("Pushing profiles to Pyroscope at %s\n", opts.PyroscopeAddr)
+		stops = append(stops, func() { _ = profiler.Stop() })
+	}
+
+	if opts.PprofAddr != "" {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		srv := &http.Server{Addr: opts.PprofAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			log.Printf("Serving pprof on http://%s/debug/pprof/\n", opts.PprofAddr)
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("pprof server failed: %v", err)
+			}
+		}()
+		stops = append(stops, func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			_ = srv.Shutdown(ctx)
+		})
+	}
+
+	if opts.watching() {
+		if opts.WatchdogInterval <= 0 {
+			opts.WatchdogInterval = 10 * time.Second
+		}
+		if opts.HeapProfileDir != "" {
+			if err := os.MkdirAll(opts.HeapProfileDir, 0o700); err != nil {
+				return stop, fmt.Errorf("create heap profile dir: %w", err)
+			}
+		}
+		done := make(chan struct{})
+		go newWatchdog(opts).run(done)
+		log.Printf("Watchdog checking goroutines, heap and scheduling lag every %s\n", opts.WatchdogInterval)
+		stops = append(stops, func() { close(done) })
+	}
+	return stop, nil
+}
@@ -0,0 +1,124 @@
+package profiling
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/metrics"
+	"runtime/pprof"
+	"time"
+)
+
+// heapDumpCooldown is the least time between two heap profiles the watchdog
+// writes, so a heap that stays big doesn't fill the disk.
+const heapDumpCooldown = time.Hour
+
+// heapMetric is the memory taken by live and not yet swept heap objects,
+// readable without stopping the world as runtime.ReadMemStats does.
+const heapMetric = "/memory/classes/heap/objects:bytes"
+
+// watchdog checks the goroutine count, heap size and scheduling lag every
+// interval, and logs a warning when one goes past its threshold and again
+// once it is back under.
+type watchdog struct {
+	opts Options
+	// over tracks which thresholds were exceeded at the last check, so each
+	// crossing is logged once.
+	over     map[string]bool
+	lastDump time.Time
+	sample   []metrics.Sample
+}
+
+func newWatchdog(opts Options) *watchdog {
+	return &watchdog{opts: opts, over: map[string]bool{}, sample: []metrics.Sample{{Name: heapMetric}}}
+}
+
+// watching reports whether opts sets any threshold.
+func (opts Options) watching() bool {
+	return opts.MaxGoroutines > 0 || opts.MaxHeapBytes > 0 || opts.MaxSchedulingLag > 0
+}
+
+// run checks on every tick until done is closed. The lag is how late the
+// watchdog gets to run after its ticker fired: with the scheduler
+// overloaded, or the world stopped for long GC pauses, every goroutine
+// waits that long for a CPU.
+func (w *watchdog) run(done <-chan struct{}) {
+	ticker := time.NewTicker(w.opts.WatchdogInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case tick := <-ticker.C:
+			w.check(runtime.NumGoroutine(), w.heap(), time.Since(tick))
+		}
+	}
+}
+
+func (w *watchdog) heap() uint64 {
+	metrics.Read(w.sample)
+	if w.sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return w.sample[0].Value.Uint64()
+}
+
+func (w *watchdog) check(goroutines int, heap uint64, lag time.Duration) {
+	w.threshold("goroutines", w.opts.MaxGoroutines > 0 && goroutines > w.opts.MaxGoroutines,
+		fmt.Sprintf("%d goroutines, over the %d of WATCHDOG_MAX_GOROUTINES", goroutines, w.opts.MaxGoroutines))
+	heapOver := w.opts.MaxHeapBytes > 0 && heap > w.opts.MaxHeapBytes
+	if w.threshold("heap", heapOver,
+		fmt.Sprintf("heap of %d MiB, over the %d MiB of WATCHDOG_MAX_HEAP_MB", heap>>20, w.opts.MaxHeapBytes>>20)) {
+		w.dumpHeap()
+	}
+	w.threshold("scheduling lag", w.opts.MaxSchedulingLag > 0 && lag > w.opts.MaxSchedulingLag,
+		fmt.Sprintf("scheduling lag of %s, over the %s of WATCHDOG_MAX_LAG", lag.Round(time.Millisecond), w.opts.MaxSchedulingLag))
+}
+
+// threshold logs msg if name just went over its threshold, or that it is
+// back under if it just did that, and reports whether it just went over.
+func (w *watchdog) threshold(name string, over bool, msg string) (crossed bool) {
+	was := w.over[name]
+	w.over[name] = over
+	switch {
+	case over && !was:
+		log.Printf("Watchdog: %s", msg)
+		return true
+	case !over && was:
+		log.Printf("Watchdog: %s is back under its threshold", name)
+	}
+	return false
+}
+
+// dumpHeap writes a heap profile to opts.HeapProfileDir, if set, for a
+// postmortem with `go tool pprof`.
+func (w *watchdog) dumpHeap() {
+	if w.opts.HeapProfileDir == "" {
+		return
+	}
+	now := time.Now()
+	if !w.lastDump.IsZero() && now.Sub(w.lastDump) < heapDumpCooldown {
+		return
+	}
+	w.lastDump = now
+	path := filepath.Join(w.opts.HeapProfileDir, "heap-"+now.UTC().Format("20060102T150405Z")+".pb.gz")
+	if err := writeHeapProfile(path); err != nil {
+		log.Printf("Watchdog: writing heap profile failed: %v", err)
+		return
+	}
+	log.Printf("Watchdog: wrote heap profile to %s", path)
+}
+
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := pprof.Lookup("heap").WriteTo(f, 0); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,50 @@
+package profiling
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchdog(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	dir := t.TempDir()
+	w := newWatchdog(Options{MaxGoroutines: 100, MaxHeapBytes: 64 << 20, MaxSchedulingLag: 50 * time.Millisecond, HeapProfileDir: dir})
+	w.check(10, 1<<20, time.Millisecond)
+	if logs.Len() != 0 {
+		t.Fatalf("logged under every threshold: %s", logs.String())
+	}
+
+	w.check(500, 128<<20, time.Second)
+	for _, want := range []string{"500 goroutines", "heap of 128 MiB", "scheduling lag of 1s", "wrote heap profile"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("logs lack %q: %s", want, logs.String())
+		}
+	}
+	// Staying over is only logged once, and the cooldown holds off another
+	// profile.
+	logs.Reset()
+	w.check(500, 128<<20, time.Second)
+	if logs.Len() != 0 {
+		t.Errorf("logged again while still over: %s", logs.String())
+	}
+	w.check(10, 1<<20, time.Millisecond)
+	w.check(10, 256<<20, time.Millisecond)
+	if got := strings.Count(logs.String(), "back under"); got != 3 {
+		t.Errorf("logged %d recoveries, want 3: %s", got, logs.String())
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || !strings.HasPrefix(files[0].Name(), "heap-") {
+		t.Errorf("heap profiles = %v, want one", files)
+	}
+}
@@ -0,0 +1,238 @@
+// Package redact keeps personal data and secrets out of logs and error
+// reports. Struct fields tagged `redact:"true"` are replaced with Redacted
+// wherever a value of their type is logged, and their JSON names become
+// sensitive keys, redacted in JSON bodies, maps and log attributes too:
+//
+//	type User struct {
+//		Email string `json:"email" redact:"true"`
+//	}
+//
+// Types are registered with Register, typically from an init function next
+// to their declarations.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces every sensitive value.
+const Redacted = "REDACTED"
+
+var (
+	mu sync.RWMutex
+	// keys are the sensitive keys, normalized. Credentials are always
+	// sensitive; Register adds the JSON names of tagged fields.
+	keys = map[string]bool{
+		"authorization": true,
+		"cookie":        true,
+		"password":      true,
+		"secret":        true,
+		"token":         true,
+		"api_key":       true,
+	}
+	sensitiveTypes sync.Map // reflect.Type → bool
+)
+
+// Register adds the JSON names of the tagged fields of each struct in vs,
+// given as values or pointers, to the sensitive keys.
+func Register(vs ...any) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, v := range vs {
+		t := reflect.TypeOf(v)
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			panic(fmt.Sprintf("redact: Register(%s): not a struct", t))
+		}
+		for _, f := range reflect.VisibleFields(t) {
+			if tagged(f) {
+				if name := jsonName(f); name != "" {
+					keys[normalize(name)] = true
+				}
+			}
+		}
+	}
+}
+
+// Key reports whether values under key must be redacted. Matching ignores
+// case and treats '-' like '_', so "Authorization" and "api-key" match.
+func Key(key string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return keys[normalize(key)]
+}
+
+// Value returns v with its sensitive parts replaced: tagged struct fields
+// and map entries under sensitive keys. Structs that need redacting come
+// back as maps keyed by their JSON names. Values with nothing sensitive in
+// them are returned as they are.
+func Value(v any) any {
+	if v == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if !sensitive(rv.Type()) {
+		return v
+	}
+	return value(rv)
+}
+
+// JSON returns the JSON document b with the values under sensitive keys
+// replaced, at any depth. Anything that doesn't parse as JSON, which
+// includes truncated documents, is replaced as a whole, since there is no
+// telling what is in it.
+func JSON(b []byte) []byte {
+	if len(bytes.TrimSpace(b)) == 0 {
+		return b
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil || dec.More() {
+		return []byte(fmt.Sprintf("%s (%d bytes)", Redacted, len(b)))
+	}
+	out, err := json.Marshal(Value(doc))
+	if err != nil {
+		return []byte(Redacted)
+	}
+	return out
+}
+
+// Attr redacts a log attribute: its whole value if its key is sensitive,
+// otherwise whatever is sensitive inside it. It has the signature of
+// slog.HandlerOptions.ReplaceAttr.
+func Attr(groups []string, a slog.Attr) slog.Attr {
+	if Key(a.Key) {
+		if a.Value.Kind() == slog.KindString && a.Value.String() == "" {
+			return a
+		}
+		return slog.String(a.Key, Redacted)
+	}
+	if a.Value.Kind() == slog.KindAny {
+		v := a.Value.Any()
+		if _, ok := v.(error); !ok {
+			a.Value = slog.AnyValue(Value(v))
+		}
+	}
+	return a
+}
+
+func value(rv reflect.Value) any {
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return value(rv.Elem())
+	case reflect.Struct:
+		if !sensitive(rv.Type()) {
+			return rv.Interface()
+		}
+		m := map[string]any{}
+		for _, f := range reflect.VisibleFields(rv.Type()) {
+			if !f.IsExported() || f.Anonymous {
+				continue
+			}
+			name := jsonName(f)
+			if name == "" {
+				continue
+			}
+			fv := rv.FieldByIndex(f.Index)
+			switch {
+			case tagged(f) && !fv.IsZero():
+				m[name] = Redacted
+			case tagged(f):
+				m[name] = fv.Interface()
+			default:
+				m[name] = value(fv)
+			}
+		}
+		return m
+	case reflect.Map:
+		if rv.IsNil() || !sensitive(rv.Type()) {
+			return rv.Interface()
+		}
+		m := make(map[string]any, rv.Len())
+		for iter := rv.MapRange(); iter.Next(); {
+			k := fmt.Sprint(iter.Key().Interface())
+			if Key(k) {
+				m[k] = Redacted
+			} else {
+				m[k] = value(iter.Value())
+			}
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		if (rv.Kind() == reflect.Slice && rv.IsNil()) || !sensitive(rv.Type()) {
+			return rv.Interface()
+		}
+		s := make([]any, rv.Len())
+		for i := range s {
+			s[i] = value(rv.Index(i))
+		}
+		return s
+	}
+	return rv.Interface()
+}
+
+// sensitive reports whether values of t can hold anything to redact.
+func sensitive(t reflect.Type) bool {
+	if v, ok := sensitiveTypes.Load(t); ok {
+		return v.(bool)
+	}
+	s := sensitiveType(t, map[reflect.Type]bool{})
+	sensitiveTypes.Store(t, s)
+	return s
+}
+
+// sensitiveType does the work of sensitive. seen breaks cycles through
+// recursive types: a type being looked at contributes nothing of its own.
+func sensitiveType(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return sensitiveType(t.Elem(), seen)
+	case reflect.Interface:
+		return true // decided per value
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String || sensitiveType(t.Elem(), seen)
+	case reflect.Struct:
+		for _, f := range reflect.VisibleFields(t) {
+			if f.IsExported() && (tagged(f) || sensitiveType(f.Type, seen)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func tagged(f reflect.StructField) bool {
+	return f.Tag.Get("redact") == "true"
+}
+
+// jsonName is the name encoding/json uses for f, "" if it skips f.
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	}
+	return name
+}
+
+func normalize(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "-", "_")
+}
@@ -0,0 +1,65 @@
+package redact
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+type account struct {
+	ID       string            `json:"id"`
+	Email    string            `json:"email" redact:"true"`
+	Nickname string            `json:"nickname,omitempty" redact:"true"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Friends  []*account        `json:"friends,omitempty"`
+}
+
+func init() {
+	Register(account{})
+}
+
+func TestValue(t *testing.T) {
+	got := Value(&account{
+		ID:      "a1",
+		Email:   "ada@example.com",
+		Labels:  map[string]string{"email": "backup@example.com", "plan": "pro"},
+		Friends: []*account{{ID: "a2", Email: "bob@example.com"}},
+	}).(map[string]any)
+	if got["id"] != "a1" || got["email"] != Redacted || got["nickname"] != "" {
+		t.Errorf("fields: %v", got)
+	}
+	if labels := got["labels"].(map[string]any); labels["email"] != Redacted || labels["plan"] != "pro" {
+		t.Errorf("labels: %v", labels)
+	}
+	if friend := got["friends"].([]any)[0].(map[string]any); friend["email"] != Redacted {
+		t.Errorf("friend: %v", friend)
+	}
+	if v := Value(42); v != 42 {
+		t.Errorf("Value(42) = %v", v)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	got := string(JSON([]byte(`{"name":"x","email":"ada@example.com","meta":{"token":"abc"},"n":12345678901234567890}`)))
+	for _, leak := range []string{"ada@example.com", "abc"} {
+		if strings.Contains(got, leak) {
+			t.Errorf("JSON leaked %q: %s", leak, got)
+		}
+	}
+	if !strings.Contains(got, "12345678901234567890") {
+		t.Errorf("JSON mangled a number: %s", got)
+	}
+	if got := string(JSON([]byte(`{"email":"ada@exam`))); strings.Contains(got, "ada") {
+		t.Errorf("truncated JSON leaked: %s", got)
+	}
+}
+
+func TestAttr(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{ReplaceAttr: Attr}))
+	logger.Info("hi", "email", "ada@example.com", "Authorization", "Bearer x", "user", account{ID: "a1", Email: "ada@example.com"})
+	if out := buf.String(); strings.Contains(out, "ada@") || strings.Contains(out, "Bearer") || !strings.Contains(out, "a1") {
+		t.Errorf("log line: %s", out)
+	}
+}
@@ -0,0 +1,255 @@
+// Package replay records the requests an API serves and their responses to
+// a fixture file, and replays them later, so a client's end-to-end tests
+// can run without the live API behind them.
+//
+// A fixture file holds one Exchange per line, as JSON. Requests are
+// matched on their method, path, query and body; credentials and other
+// request headers are neither stored nor matched.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxBody bounds the request and response bodies recorded.
+const maxBody = 10 << 20
+
+// Exchange is one recorded request and the response it got.
+type Exchange struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Query  string `json:"query,omitempty"` // encoded with its keys sorted
+	Body   Body   `json:"body,omitempty"`
+}
+
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+// Body is a body, stored as text if it is UTF-8 and base64 otherwise, so
+// fixtures of JSON APIs stay readable and diffable.
+type Body []byte
+
+func (b Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(map[string][]byte{"base64": b})
+}
+
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*b = Body(s)
+		return nil
+	}
+	var enc struct {
+		Base64 []byte `json:"base64"`
+	}
+	if err := json.Unmarshal(data, &enc); err != nil {
+		return err
+	}
+	*b = enc.Base64
+	return nil
+}
+
+// skippedHeaders aren't recorded from responses: the server that replays
+// them sets its own.
+var skippedHeaders = []string{"Content-Length", "Date", "Connection", "Transfer-Encoding"}
+
+// normalize returns the request as it is recorded and matched. JSON bodies
+// are compacted, so whitespace doesn't matter.
+func normalize(r *http.Request, body []byte) Request {
+	var buf bytes.Buffer
+	if json.Compact(&buf, body) == nil {
+		body = buf.Bytes()
+	}
+	return Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query().Encode(),
+		Body:   body,
+	}
+}
+
+// Recorder is a reverse proxy to a live API that appends every exchange it
+// forwards to a fixture file. Responses are only passed on once complete,
+// so it doesn't suit streams.
+type Recorder struct {
+	proxy *httputil.ReverseProxy
+	mu    sync.Mutex
+	out   *os.File
+	enc   *json.Encoder
+}
+
+// NewRecorder forwards to target and records to the file at path, which it
+// truncates.
+func NewRecorder(target *url.URL, path string) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	rec := &Recorder{out: f, enc: json.NewEncoder(f)}
+	rec.proxy = &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.Out.Host = pr.In.Host
+			// The transport asks for gzip itself and decompresses, so the
+			// fixtures stay readable.
+			pr.Out.Header.Del("Accept-Encoding")
+		},
+		ModifyResponse: rec.record,
+	}
+	return rec, nil
+}
+
+// requestKey carries the normalized request to record in its context.
+type requestKey struct{}
+
+func (rec *Recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBody))
+	if err != nil {
+		http.Error(w, "replay: reading the request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	req := normalize(r, body)
+	rec.proxy.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestKey{}, req)))
+}
+
+func (rec *Recorder) record(resp *http.Response) error {
+	req, _ := resp.Request.Context().Value(requestKey{}).(Request)
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	header := resp.Header.Clone()
+	for _, h := range skippedHeaders {
+		header.Del(h)
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.enc.Encode(Exchange{
+		Request:  req,
+		Response: Response{Status: resp.StatusCode, Header: header, Body: body},
+	})
+}
+
+// Close closes the fixture file.
+func (rec *Recorder) Close() error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.out.Close()
+}
+
+// Replayer answers requests with the recorded responses. A request
+// recorded several times gets its responses in the order they were
+// recorded, then the last one again, so a list fetched before and after a
+// write shows the write. A request nothing matches exactly is matched on
+// its method, path and query alone, as bodies often hold values that
+// differ between runs, like timestamps.
+type Replayer struct {
+	mu        sync.Mutex
+	responses map[string][]Response // by key, with and without the body
+	served    map[string]int
+}
+
+// Load reads the fixture file at path.
+func Load(path string) (*Replayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rp := &Replayer{responses: map[string][]Response{}, served: map[string]int{}}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 4*maxBody)
+	for line := 1; sc.Scan(); line++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var ex Exchange
+		if err := json.Unmarshal(sc.Bytes(), &ex); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		rp.add(ex)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return rp, nil
+}
+
+func (rp *Replayer) add(ex Exchange) {
+	for _, key := range []string{ex.Request.key(true), ex.Request.key(false)} {
+		rp.responses[key] = append(rp.responses[key], ex.Response)
+	}
+}
+
+// key identifies r to match it, with its body or without.
+func (r Request) key(withBody bool) string {
+	key := r.Method + " " + r.Path + "?" + r.Query
+	if withBody {
+		key += "\x00" + string(r.Body)
+	}
+	return key
+}
+
+func (rp *Replayer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBody))
+	if err != nil {
+		http.Error(w, "replay: reading the request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := normalize(r, body)
+	resp, ok := rp.next(req)
+	if !ok {
+		w.Header().Set("X-Replay", "miss")
+		http.Error(w, "replay: nothing recorded for "+r.Method+" "+r.URL.RequestURI(), http.StatusNotFound)
+		return
+	}
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set("X-Replay", "hit")
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
+
+// next returns the response to serve req, and counts it served.
+func (rp *Replayer) next(req Request) (Response, bool) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	key := req.key(true)
+	responses := rp.responses[key]
+	if len(responses) == 0 {
+		key = req.key(false)
+		responses = rp.responses[key]
+	}
+	if len(responses) == 0 {
+		return Response{}, false
+	}
+	n := rp.served[key]
+	rp.served[key] = n + 1
+	return responses[min(n, len(responses)-1)], true
+}
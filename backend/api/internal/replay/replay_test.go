@@ -0,0 +1,102 @@
+package replay
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	var created atomic.Int64
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			created.Add(1)
+			w.WriteHeader(http.StatusCreated)
+			w.Write(body)
+		case r.URL.Path == "/v1/users":
+			if created.Load() == 0 {
+				io.WriteString(w, `[]`)
+			} else {
+				io.WriteString(w, `[{"name":"Ada"}]`)
+			}
+		case r.URL.Path == "/logo":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte{0x89, 'P', 'N', 'G', 0xff})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer api.Close()
+
+	path := filepath.Join(t.TempDir(), "fixtures.ndjson")
+	target, _ := url.Parse(api.URL)
+	rec, err := NewRecorder(target, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := httptest.NewServer(rec)
+	call := func(base, method, path, body string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(method, base+path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(b)
+	}
+	call(proxy.URL, http.MethodGet, "/v1/users?b=2&a=1", "")
+	call(proxy.URL, http.MethodPost, "/v1/users", `{"name": "Ada"}`)
+	call(proxy.URL, http.MethodGet, "/v1/users?b=2&a=1", "")
+	call(proxy.URL, http.MethodGet, "/logo", "")
+	proxy.Close()
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if fixtures, _ := os.ReadFile(path); bytes.Contains(fixtures, []byte("secret")) {
+		t.Error("the fixtures hold the credentials")
+	}
+
+	rp, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := httptest.NewServer(rp)
+	defer replayed.Close()
+	steps := []struct {
+		method, path, body string
+		status             int
+		want               string
+	}{
+		// Query parameters match in any order.
+		{http.MethodGet, "/v1/users?a=1&b=2", "", http.StatusOK, `[]`},
+		// JSON bodies match whatever their whitespace.
+		{http.MethodPost, "/v1/users", `{"name":"Ada"}`, http.StatusCreated, `{"name": "Ada"}`},
+		{http.MethodGet, "/v1/users?a=1&b=2", "", http.StatusOK, `[{"name":"Ada"}]`},
+		// Then the last response again.
+		{http.MethodGet, "/v1/users?a=1&b=2", "", http.StatusOK, `[{"name":"Ada"}]`},
+		// A body that differs falls back to the method and path.
+		{http.MethodPost, "/v1/users", `{"name":"Grace"}`, http.StatusCreated, `{"name": "Ada"}`},
+		{http.MethodGet, "/logo", "", http.StatusOK, "\x89PNG\xff"},
+		{http.MethodGet, "/v1/posts", "", http.StatusNotFound, "replay: nothing recorded for GET /v1/posts\n"},
+	}
+	for _, s := range steps {
+		status, body := call(replayed.URL, s.method, s.path, s.body)
+		if status != s.status || body != s.want {
+			t.Errorf("%s %s: %d %q, want %d %q", s.method, s.path, status, body, s.status, s.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+//go:build !nosearch && !minimal
+
+package search
+
+import (
+	"context"
+	"errors"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// BleveBuilt reports whether this binary has Bleve, which the nosearch
+// and minimal tags leave out.
+const BleveBuilt = true
+
+// Bleve is an Index embedded in the process, using the Bleve library.
+type Bleve struct {
+	index bleve.Index
+}
+
+// OpenBleve opens the index in the directory path, creating it if it
+// doesn't exist yet, or with an empty path returns one that lives in memory
+// and starts empty every time. Only one process can have a directory open.
+func OpenBleve(path string) (*Bleve, error) {
+	if path == "" {
+		index, err := bleve.NewMemOnly(indexMapping())
+		if err != nil {
+			return nil, err
+		}
+		return &Bleve{index: index}, nil
+	}
+	index, err := bleve.Open(path)
+	if errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
+		index, err = bleve.New(path, indexMapping())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Bleve{index: index}, nil
+}
+
+// indexMapping keeps the type and owner exact and out of the text searched;
+// the fields are mapped dynamically, as text analyzed by the standard
+// analyzer.
+func indexMapping() mapping.IndexMapping {
+	keyword := bleve.NewKeywordFieldMapping()
+	keyword.IncludeInAll = false
+	doc := bleve.NewDocumentMapping()
+	doc.AddFieldMappingsAt(typeField, keyword)
+	doc.AddFieldMappingsAt(ownerField, keyword)
+	m := bleve.NewIndexMapping()
+	m.DefaultMapping = doc
+	return m
+}
+
+func (b *Bleve) Index(ctx context.Context, docs ...Document) error {
+	batch := b.index.NewBatch()
+	for _, d := range docs {
+		if err := batch.Index(docID(d.Type, d.ID), d.source()); err != nil {
+			return err
+		}
+	}
+	return b.index.Batch(batch)
+}
+
+func (b *Bleve) Delete(ctx context.Context, typ, id string) error {
+	return b.index.Delete(docID(typ, id))
+}
+
+// DeleteOwner deletes the owner's documents a page at a time.
+func (b *Bleve) DeleteOwner(ctx context.Context, owner string) error {
+	q := bleve.NewTermQuery(owner)
+	q.SetField(ownerField)
+	for {
+		res, err := b.index.SearchInContext(ctx, bleve.NewSearchRequestOptions(q, 500, 0, false))
+		if err != nil {
+			return err
+		}
+		if len(res.Hits) == 0 {
+			return nil
+		}
+		batch := b.index.NewBatch()
+		for _, hit := range res.Hits {
+			batch.Delete(hit.ID)
+		}
+		if err := b.index.Batch(batch); err != nil {
+			return err
+		}
+	}
+}
+
+func (b *Bleve) Search(ctx context.Context, q Query) ([]Hit, error) {
+	if q.Text == "" {
+		return nil, ErrEmptyQuery
+	}
+	var bq query.Query = bleve.NewMatchQuery(q.Text)
+	if len(q.Types) > 0 {
+		types := make([]query.Query, len(q.Types))
+		for i, typ := range q.Types {
+			t := bleve.NewTermQuery(typ)
+			t.SetField(typeField)
+			types[i] = t
+		}
+		bq = bleve.NewConjunctionQuery(bq, bleve.NewDisjunctionQuery(types...))
+	}
+	req := bleve.NewSearchRequestOptions(bq, q.Limit, 0, false)
+	req.Highlight = bleve.NewHighlightWithStyle("html")
+	res, err := b.index.SearchInContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]Hit, len(res.Hits))
+	for i, h := range res.Hits {
+		typ, id := splitDocID(h.ID)
+		hits[i] = Hit{Type: typ, ID: id, Score: h.Score, Highlights: highlights(h.Fragments)}
+	}
+	return hits, nil
+}
+
+func (b *Bleve) Close() error {
+	return b.index.Close()
+}
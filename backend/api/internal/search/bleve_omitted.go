@@ -0,0 +1,46 @@
+//go:build nosearch || minimal
+
+package search
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrBleveOmitted is what Search fails with in a binary built without
+// Bleve, and OpenBleve with a path.
+var ErrBleveOmitted = errors.New("search: Bleve is not in this binary, which was built with the nosearch or minimal tag")
+
+// BleveBuilt reports whether this binary has Bleve, which the nosearch
+// and minimal tags leave out.
+const BleveBuilt = false
+
+// Bleve stands in for the embedded index in a binary built with the
+// nosearch or minimal tag, which leaves the Bleve library out. It keeps
+// nothing, and every search fails; an Elasticsearch or OpenSearch cluster
+// still works.
+type Bleve struct{}
+
+// OpenBleve returns an index that keeps nothing, or fails with a path, as
+// nothing can be kept there.
+func OpenBleve(path string) (*Bleve, error) {
+	if path != "" {
+		return nil, ErrBleveOmitted
+	}
+	return &Bleve{}, nil
+}
+
+func (b *Bleve) Index(ctx context.Context, docs ...Document) error { return nil }
+
+func (b *Bleve) Delete(ctx context.Context, typ, id string) error { return nil }
+
+func (b *Bleve) DeleteOwner(ctx context.Context, owner string) error { return nil }
+
+func (b *Bleve) Search(ctx context.Context, q Query) ([]Hit, error) {
+	if q.Text == "" {
+		return nil, ErrEmptyQuery
+	}
+	return nil, ErrBleveOmitted
+}
+
+func (b *Bleve) Close() error { return nil }
@@ -0,0 +1,92 @@
+//go:build !nosearch && !minimal
+
+package search
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBleve(t *testing.T) {
+	ctx := context.Background()
+	idx, err := OpenBleve("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+	if err := idx.Index(ctx, docs...); err != nil {
+		t.Fatal(err)
+	}
+
+	hits, err := idx.Search(ctx, Query{Text: "grace", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(hits); len(got) != 2 || got[0] != "user/2" {
+		t.Fatalf("grace hits = %v, want user/2 first and post/10", got)
+	}
+	if got := hits[0].Highlights["name"]; len(got) != 1 || got[0] != "<mark>Grace</mark> Hopper" {
+		t.Errorf("name highlights = %q", got)
+	}
+	if got := strings.Join(hits[1].Highlights["body"], ""); !strings.Contains(got, "&amp;") || !strings.Contains(got, "&lt;compiler&gt;") {
+		t.Errorf("body highlight %q isn't HTML-escaped", got)
+	}
+
+	hits, err = idx.Search(ctx, Query{Text: "grace", Types: []string{"post"}, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(hits); len(got) != 1 || got[0] != "post/10" {
+		t.Errorf("post hits = %v, want post/10", got)
+	}
+	// The type itself isn't searched.
+	if hits, _ := idx.Search(ctx, Query{Text: "user", Limit: 10}); len(hits) != 0 {
+		t.Errorf("user hits = %v, want none", ids(hits))
+	}
+
+	if err := idx.DeleteOwner(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if hits, _ := idx.Search(ctx, Query{Text: "engine lovelace", Limit: 10}); len(hits) != 0 {
+		t.Errorf("hits after DeleteOwner = %v, want none", ids(hits))
+	}
+	if err := idx.Delete(ctx, "user", "2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Delete(ctx, "user", "2"); err != nil {
+		t.Errorf("deleting again: %v", err)
+	}
+	if _, err := idx.Search(ctx, Query{Limit: 10}); err != ErrEmptyQuery {
+		t.Errorf("empty query: err = %v, want ErrEmptyQuery", err)
+	}
+}
+
+func TestBleveOnDisk(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "index")
+	idx, err := OpenBleve(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Index(ctx, docs...); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err = OpenBleve(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+	hits, err := idx.Search(ctx, Query{Text: "hopper", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(hits); len(got) != 1 || got[0] != "user/2" {
+		t.Errorf("hits after reopening = %v, want user/2", got)
+	}
+}
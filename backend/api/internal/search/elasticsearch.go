@@ -0,0 +1,229 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Elasticsearch is an Index kept in an Elasticsearch or OpenSearch cluster.
+// Writes are seen by searches once the cluster refreshes the index, within
+// a second by default.
+type Elasticsearch struct {
+	url    string
+	index  string
+	client *http.Client
+
+	mu      sync.Mutex
+	created bool
+}
+
+// NewElasticsearch returns an Index stored as index in the cluster at
+// baseURL. Credentials for basic auth can go in the URL's user info. The
+// index is created, with its mapping, on the first write.
+func NewElasticsearch(baseURL, index string) (*Elasticsearch, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("search: want an absolute URL, got %q", baseURL)
+	}
+	if index == "" {
+		return nil, errors.New("search: index name is required")
+	}
+	return &Elasticsearch{
+		url:    strings.TrimSuffix(baseURL, "/"),
+		index:  index,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}, nil
+}
+
+// esMapping keeps the type and owner exact; the fields are mapped
+// dynamically, as text.
+var esMapping = map[string]any{
+	"mappings": map[string]any{
+		"properties": map[string]any{
+			typeField:  map[string]string{"type": "keyword"},
+			ownerField: map[string]string{"type": "keyword"},
+			"fields":   map[string]string{"type": "object"},
+		},
+	},
+}
+
+// errorResponse is the body of the cluster's errors.
+type errorResponse struct {
+	Error struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error"`
+}
+
+// do sends body, JSON-encoded unless it is already bytes, and decodes the
+// response into out. A 404 is only an error if ok404 is false.
+func (e *Elasticsearch) do(ctx context.Context, method, path string, body, out any, ok404 bool) error {
+	var r io.Reader
+	contentType := "application/json"
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		r, contentType = bytes.NewReader(b), "application/x-ndjson"
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, e.url+path, r)
+	if err != nil {
+		return err
+	}
+	if r != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("search: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound && ok404 {
+		return nil
+	}
+	if resp.StatusCode >= 300 {
+		var er errorResponse
+		json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&er)
+		if er.Error.Type != "" {
+			return fmt.Errorf("search: %s %s: %s: %s", method, path, er.Error.Type, er.Error.Reason)
+		}
+		return fmt.Errorf("search: %s %s: %s", method, path, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// ensureIndex creates the index unless it exists already.
+func (e *Elasticsearch) ensureIndex(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.created {
+		return nil
+	}
+	err := e.do(ctx, http.MethodPut, "/"+e.index, esMapping, nil, false)
+	if err != nil && !strings.Contains(err.Error(), "resource_already_exists_exception") {
+		return err
+	}
+	e.created = true
+	return nil
+}
+
+// Ping checks that the cluster answers, and that its credentials work.
+func (e *Elasticsearch) Ping(ctx context.Context) error {
+	return e.do(ctx, http.MethodGet, "/", nil, nil, false)
+}
+
+func (e *Elasticsearch) Index(ctx context.Context, docs ...Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	if err := e.ensureIndex(ctx); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, d := range docs {
+		action := map[string]any{"index": map[string]string{"_index": e.index, "_id": docID(d.Type, d.ID)}}
+		if err := enc.Encode(action); err != nil {
+			return err
+		}
+		if err := enc.Encode(d.source()); err != nil {
+			return err
+		}
+	}
+	var res struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID    string `json:"_id"`
+			Error *struct {
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := e.do(ctx, http.MethodPost, "/_bulk", buf.Bytes(), &res, false); err != nil {
+		return err
+	}
+	if !res.Errors {
+		return nil
+	}
+	for _, item := range res.Items {
+		for _, result := range item {
+			if result.Error != nil {
+				return fmt.Errorf("search: index %s: %s", result.ID, result.Error.Reason)
+			}
+		}
+	}
+	return errors.New("search: bulk request failed")
+}
+
+func (e *Elasticsearch) Delete(ctx context.Context, typ, id string) error {
+	return e.do(ctx, http.MethodDelete, "/"+e.index+"/_doc/"+url.PathEscape(docID(typ, id)), nil, nil, true)
+}
+
+func (e *Elasticsearch) DeleteOwner(ctx context.Context, owner string) error {
+	body := map[string]any{"query": map[string]any{"term": map[string]string{ownerField: owner}}}
+	return e.do(ctx, http.MethodPost, "/"+e.index+"/_delete_by_query?conflicts=proceed", body, nil, true)
+}
+
+func (e *Elasticsearch) Search(ctx context.Context, q Query) ([]Hit, error) {
+	if q.Text == "" {
+		return nil, ErrEmptyQuery
+	}
+	boolQuery := map[string]any{
+		"must": map[string]any{"multi_match": map[string]any{"query": q.Text, "fields": []string{fieldsPrefix + "*"}}},
+	}
+	if len(q.Types) > 0 {
+		boolQuery["filter"] = map[string]any{"terms": map[string][]string{typeField: q.Types}}
+	}
+	body := map[string]any{
+		"size":    q.Limit,
+		"_source": false,
+		"query":   map[string]any{"bool": boolQuery},
+		"highlight": map[string]any{
+			"pre_tags":  []string{HighlightStart},
+			"post_tags": []string{HighlightEnd},
+			"encoder":   "html",
+			"fields":    map[string]any{fieldsPrefix + "*": map[string]any{}},
+		},
+	}
+	var res struct {
+		Hits struct {
+			Hits []struct {
+				ID        string              `json:"_id"`
+				Score     float64             `json:"_score"`
+				Highlight map[string][]string `json:"highlight"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	// Nothing was indexed yet if the index doesn't exist.
+	if err := e.do(ctx, http.MethodPost, "/"+e.index+"/_search", body, &res, true); err != nil {
+		return nil, err
+	}
+	hits := make([]Hit, len(res.Hits.Hits))
+	for i, h := range res.Hits.Hits {
+		typ, id := splitDocID(h.ID)
+		hits[i] = Hit{Type: typ, ID: id, Score: h.Score, Highlights: highlights(h.Highlight)}
+	}
+	return hits, nil
+}
+
+// Close does nothing; the cluster keeps the index.
+func (e *Elasticsearch) Close() error {
+	return nil
+}
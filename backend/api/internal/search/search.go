@@ -0,0 +1,105 @@
+// Package search keeps a full-text index of the API's resources. Index is
+// the abstraction the server writes to and queries; Bleve implements it with
+// an index embedded in the process, in memory or on disk, and Elasticsearch
+// with an Elasticsearch or OpenSearch cluster, which speak the same REST API
+// for everything it needs.
+//
+// Every document has a type, like "user" or "post", an ID unique within
+// its type, an owner, the user it belongs to, and text fields. Searches match
+// the text of every field and highlight the matches.
+package search
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Names of the backends.
+const (
+	BackendBleve         = "bleve"
+	BackendElasticsearch = "elasticsearch"
+	BackendOpenSearch    = "opensearch"
+)
+
+// Highlights wrap matched terms in these tags; everything else in a
+// fragment is HTML-escaped.
+const (
+	HighlightStart = "<mark>"
+	HighlightEnd   = "</mark>"
+)
+
+// Document is a resource as the index sees it.
+type Document struct {
+	Type  string
+	ID    string
+	Owner string
+	// Fields holds the text to search, by field name.
+	Fields map[string]string
+}
+
+// Query asks for the documents best matching Text.
+type Query struct {
+	Text string
+	// Types limits the search to documents of these types; empty searches
+	// all of them.
+	Types []string
+	Limit int
+}
+
+// Hit is a document matching a query.
+type Hit struct {
+	Type  string
+	ID    string
+	Score float64
+	// Highlights holds, by field, the fragments of the field's text that
+	// matched.
+	Highlights map[string][]string
+}
+
+// Index is a full-text index. Index adds documents, replacing any with the
+// same type and ID, and Delete and DeleteOwner remove them; documents that
+// aren't there are no error. Search returns the best matches first.
+type Index interface {
+	Index(ctx context.Context, docs ...Document) error
+	Delete(ctx context.Context, typ, id string) error
+	DeleteOwner(ctx context.Context, owner string) error
+	Search(ctx context.Context, q Query) ([]Hit, error)
+	Close() error
+}
+
+// ErrEmptyQuery is returned by Search for a query without any text.
+var ErrEmptyQuery = errors.New("search: empty query")
+
+// The document layout both backends store: the text fields under
+// fieldsPrefix, so they can be searched without the type and owner.
+const (
+	typeField    = "type"
+	ownerField   = "owner"
+	fieldsPrefix = "fields."
+)
+
+func docID(typ, id string) string {
+	return typ + "/" + id
+}
+
+func splitDocID(s string) (typ, id string) {
+	typ, id, _ = strings.Cut(s, "/")
+	return typ, id
+}
+
+func (d Document) source() map[string]any {
+	return map[string]any{typeField: d.Type, ownerField: d.Owner, "fields": d.Fields}
+}
+
+// highlights turns the highlights by stored field into highlights by
+// Document field.
+func highlights(byField map[string][]string) map[string][]string {
+	out := make(map[string][]string, len(byField))
+	for field, fragments := range byField {
+		if name, ok := strings.CutPrefix(field, fieldsPrefix); ok {
+			out[name] = fragments
+		}
+	}
+	return out
+}
@@ -0,0 +1,98 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var docs = []Document{
+	{Type: "user", ID: "1", Owner: "1", Fields: map[string]string{"name": "Ada Lovelace", "email": "ada@example.com"}},
+	{Type: "user", ID: "2", Owner: "2", Fields: map[string]string{"name": "Grace Hopper", "email": "grace@example.com"}},
+	{Type: "post", ID: "10", Owner: "1", Fields: map[string]string{"title": "Notes on the engine", "body": "The analytical engine & <compiler>, by Grace."}},
+}
+
+func ids(hits []Hit) []string {
+	out := make([]string, len(hits))
+	for i, h := range hits {
+		out[i] = h.Type + "/" + h.ID
+	}
+	return out
+}
+
+func TestElasticsearch(t *testing.T) {
+	var requests []string
+	var search map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.Method == http.MethodPut:
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"error":{"type":"resource_already_exists_exception","reason":"index exists"}}`)
+		case r.URL.Path == "/_bulk":
+			if r.Header.Get("Content-Type") != "application/x-ndjson" {
+				t.Errorf("bulk Content-Type = %q", r.Header.Get("Content-Type"))
+			}
+			if lines := strings.Count(string(body), "\n"); lines != 2*len(docs) {
+				t.Errorf("bulk body has %d lines, want %d", lines, 2*len(docs))
+			}
+			io.WriteString(w, `{"errors":false,"items":[]}`)
+		case strings.HasSuffix(r.URL.Path, "/_search"):
+			json.Unmarshal(body, &search)
+			io.WriteString(w, `{"hits":{"hits":[{"_id":"user/2","_score":1.5,"highlight":{"fields.name":["<mark>Grace</mark> Hopper"]}}]}}`)
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"result":"not_found"}`)
+		default:
+			io.WriteString(w, `{}`)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	idx, err := NewElasticsearch(srv.URL+"/", "monorepo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Index(ctx, docs...); err != nil {
+		t.Fatal(err)
+	}
+	hits, err := idx.Search(ctx, Query{Text: "grace", Types: []string{"user"}, Limit: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].Type != "user" || hits[0].ID != "2" || hits[0].Highlights["name"][0] != "<mark>Grace</mark> Hopper" {
+		t.Errorf("hits = %+v", hits)
+	}
+	if search["size"] != float64(5) {
+		t.Errorf("search size = %v, want 5", search["size"])
+	}
+	filter, _ := json.Marshal(search["query"].(map[string]any)["bool"].(map[string]any)["filter"])
+	if string(filter) != `{"terms":{"type":["user"]}}` {
+		t.Errorf("search filter = %s", filter)
+	}
+	if err := idx.Delete(ctx, "user", "2"); err != nil {
+		t.Errorf("deleting a missing document: %v", err)
+	}
+	if err := idx.DeleteOwner(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"PUT /monorepo", "POST /_bulk", "POST /monorepo/_search", "DELETE /monorepo/_doc/user/2", "POST /monorepo/_delete_by_query"}
+	if strings.Join(requests, "\n") != strings.Join(want, "\n") {
+		t.Errorf("requests:\n%s\nwant:\n%s", strings.Join(requests, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestNewElasticsearchNeedsURL(t *testing.T) {
+	if _, err := NewElasticsearch("localhost:9200", "monorepo"); err == nil {
+		t.Error("a URL without a scheme was accepted")
+	}
+	if _, err := NewElasticsearch("http://localhost:9200", ""); err == nil {
+		t.Error("an empty index name was accepted")
+	}
+}
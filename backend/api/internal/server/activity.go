@@ -0,0 +1,192 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// The auth layer publishes these when it authenticates a request, keeping it
+// decoupled from the user model. A login also counts as being seen.
+const (
+	EventUserLoggedIn = "user.logged_in"
+	EventUserSeen     = "user.seen"
+)
+
+const (
+	// lastSeenGranularity is how stale last_seen_at may get: a user seen
+	// again within it isn't saved again. Logins are always saved.
+	lastSeenGranularity = time.Minute
+	// activityQueueSize bounds the activity waiting to be saved; past it,
+	// the newest is dropped.
+	activityQueueSize = 1000
+)
+
+// activityRecorder keeps last_login_at and last_seen_at current from the
+// auth layer's events. It saves them in the background, so being seen
+// costs a request no write, and at most once per lastSeenGranularity for
+// each user besides logins. The writes are transactions, so a request
+// changing the user meanwhile keeps its changes. Nothing is recorded during
+// maintenance, or on a raft follower, whose writes would go to the leader:
+// there only the requests the leader serves count.
+type activityRecorder struct {
+	users     *UserService
+	following func() bool
+	queue     chan events.Event
+	dropped   atomic.Int64
+	// every is how often each user's last_seen_at is saved at most,
+	// lastSeenGranularity.
+	every time.Duration
+
+	mu    sync.Mutex
+	saved map[string]time.Time // when each user was last queued to be saved
+
+	ctx    context.Context // ends when close is called
+	cancel context.CancelFunc
+	done   chan struct{} // closed when run returns
+}
+
+// newActivityRecorder records the activity published on bus. following
+// reports whether this replica is a raft follower.
+func newActivityRecorder(users *UserService, bus *events.Bus, following func() bool) *activityRecorder {
+	ctx, cancel := context.WithCancel(context.Background())
+	a := &activityRecorder{users: users, following: following, queue: make(chan events.Event, activityQueueSize), every: lastSeenGranularity, saved: map[string]time.Time{}, ctx: ctx, cancel: cancel, done: make(chan struct{})}
+	bus.Subscribe(func(e events.Event) {
+		if e.Type != EventUserLoggedIn && e.Type != EventUserSeen || users.readOnly.Load() || a.following() {
+			return
+		}
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		if at, ok := a.saved[e.Subject]; ok && e.Type == EventUserSeen && e.Time.Sub(at) < a.every {
+			return
+		}
+		select {
+		case a.queue <- e:
+			a.saved[e.Subject] = e.Time
+		default:
+			a.dropped.Add(1)
+		}
+	})
+	return a
+}
+
+// run saves the queued activity until close is called.
+func (a *activityRecorder) run() {
+	defer close(a.done)
+	ticker := time.NewTicker(lastSeenGranularity)
+	defer ticker.Stop()
+	for {
+		select {
+		case e := <-a.queue:
+			a.save(a.ctx, e)
+		case now := <-ticker.C:
+			a.forget(now)
+		case <-a.ctx.Done():
+			return
+		}
+	}
+}
+
+// forget drops the users queued at least a.every before now, whom the next
+// sighting queues again anyway, so saved doesn't grow with every user ever
+// seen.
+func (a *activityRecorder) forget(now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for id, at := range a.saved {
+		if now.Sub(at) >= a.every {
+			delete(a.saved, id)
+		}
+	}
+}
+
+func (a *activityRecorder) save(ctx context.Context, e events.Event) {
+	if n := a.dropped.Swap(0); n > 0 {
+		a.users.logger.Warn("activity recording fell behind, activity dropped", "dropped", n)
+	}
+	if a.users.readOnly.Load() || a.following() {
+		return
+	}
+	err := WithTx(ctx, a.users.store, func(tx Store) error {
+		user, err := tx.GetUser(ctx, e.Subject)
+		if err != nil {
+			return err
+		}
+		// Another replica may have saved them since.
+		if e.Type == EventUserSeen && user.LastSeenAt != nil && e.Time.Sub(user.LastSeenAt.Time) < a.every {
+			return nil
+		}
+		at := timestamp.From(e.Time)
+		user.LastSeenAt = &at
+		if e.Type == EventUserLoggedIn {
+			user.LastLoginAt = &at
+		}
+		return tx.PutUser(ctx, user)
+	})
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		a.users.logger.Error("failed to record user activity", "user", e.Subject, "err", err)
+	}
+}
+
+// close stops run, once the requests are done publishing, and saves the
+// activity still queued, for as long as ctx allows.
+func (a *activityRecorder) close(ctx context.Context) {
+	a.cancel()
+	<-a.done
+	for {
+		select {
+		case e := <-a.queue:
+			if ctx.Err() != nil {
+				return
+			}
+			a.save(ctx, e)
+		default:
+			return
+		}
+	}
+}
+
+// inactiveSince reports whether user has not been seen since cutoff. Users
+// that have never been seen are always inactive.
+func inactiveSince(user *User, cutoff time.Time) bool {
+	return user.LastSeenAt == nil || user.LastSeenAt.Before(cutoff)
+}
+
+// parseSince turns a relative age like "30d", "2w" or "12h", or a timestamp
+// in any form timestamp.Parse takes, into an absolute point in time.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if age, err := parseAge(s); err == nil {
+		return now.Add(-age), nil
+	}
+	t, err := timestamp.Parse(s)
+	if err != nil {
+		return time.Time{}, errors.New("expected a duration like 30d, 2w or 12h, or an RFC 3339 timestamp")
+	}
+	return t, nil
+}
+
+// parseAge parses a non-negative duration, accepting days ("30d") and weeks
+// ("2w") besides what time.ParseDuration does.
+func parseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil || count < 0 {
+				return 0, errors.New("expected a non-negative number of days or weeks")
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, errors.New("expected a duration like 30d, 2w or 12h")
+	}
+	return d, nil
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+)
+
+func TestActivityRecorder(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer(Config{}, NewMemoryStore())
+	a := s.activity
+	s.store.PutUser(ctx, &User{ID: "a", Name: "Ada", Status: UserStatusActive, Active: true})
+
+	// A user seen again within the minute isn't queued again; a login is.
+	t0 := time.Now().Truncate(time.Second)
+	for _, e := range []events.Event{
+		{Type: EventUserSeen, Time: t0},
+		{Type: EventUserSeen, Time: t0.Add(30 * time.Second)},
+		{Type: EventUserSeen, Time: t0.Add(lastSeenGranularity)},
+		{Type: EventUserLoggedIn, Time: t0.Add(lastSeenGranularity + time.Second)},
+	} {
+		e.Subject = "a"
+		s.bus.Publish(ctx, e)
+	}
+	if n := len(a.queue); n != 3 {
+		t.Fatalf("%d queued, want 3", n)
+	}
+	go a.run()
+	a.close(ctx)
+	u, _ := s.store.GetUser(ctx, "a")
+	if want := t0.Add(lastSeenGranularity + time.Second); u.LastSeenAt == nil || !u.LastSeenAt.Equal(want) || u.LastLoginAt == nil || !u.LastLoginAt.Equal(want) {
+		t.Fatalf("last seen %v, last login %v; want both %v", u.LastSeenAt, u.LastLoginAt, want)
+	}
+
+	// Nothing is recorded during maintenance.
+	s.users.SetReadOnly(true)
+	s.bus.Publish(ctx, events.Event{Type: EventUserLoggedIn, Subject: "a"})
+	s.users.SetReadOnly(false)
+	if n := len(a.queue); n != 0 {
+		t.Errorf("%d queued during maintenance, want none", n)
+	}
+	// Nor on a raft follower.
+	a.following = func() bool { return true }
+	s.bus.Publish(ctx, events.Event{Type: EventUserLoggedIn, Subject: "a"})
+	if n := len(a.queue); n != 0 {
+		t.Errorf("%d queued on a follower, want none", n)
+	}
+
+	// Recording activity doesn't undo a change made meanwhile: tags set
+	// while an authenticated request's sighting is being saved stay set.
+	m := &pausingStore{MemoryStore: NewMemoryStore()}
+	s = NewServer(Config{}, m)
+	a = s.activity
+	m.PutUser(ctx, &User{ID: "a", Name: "Ada", Status: UserStatusActive, Active: true})
+	key, err := s.users.CreateAPIKey(ctx, "a", CreateAPIKeyRequest{Name: "test", Scopes: []string{"users:read"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	m.onGetUser = func(ctx context.Context) {
+		if ctx == a.ctx {
+			once.Do(func() { close(read); <-release })
+		}
+	}
+	go a.run()
+	r := httptest.NewRequest(http.MethodGet, "/v1/users/a", nil)
+	r.Header.Set("Authorization", "Bearer "+key.Key)
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /v1/users/a answered %d", w.Code)
+	}
+	<-read
+	if _, err := s.users.SetTags(ctx, "a", []string{"vip"}); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	a.close(ctx)
+	u, _ = m.GetUser(ctx, "a")
+	if !slices.Equal(u.Tags, []string{"vip"}) || u.LastSeenAt == nil {
+		t.Errorf("tags %v, last seen %v; want [vip] and a time", u.Tags, u.LastSeenAt)
+	}
+
+	// Nor does an update undo recorded activity: a sighting saved while
+	// the user's name is being changed stays saved.
+	m = &pausingStore{MemoryStore: NewMemoryStore()}
+	s = NewServer(Config{}, m)
+	a = s.activity
+	m.PutUser(ctx, &User{ID: "a", Name: "Ada", Status: UserStatusActive, Active: true})
+	updating, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var seen sync.Once
+	m.onGetUser = func(ctx context.Context) {
+		if ctx == updating {
+			seen.Do(func() {
+				a.save(context.Background(), events.Event{Type: EventUserSeen, Subject: "a", Time: time.Now()})
+			})
+		}
+	}
+	name := "Ada Lovelace"
+	if _, err := s.users.Update(updating, "a", UpdateUserRequest{Name: &name}); err != nil {
+		t.Fatal(err)
+	}
+	u, _ = m.GetUser(ctx, "a")
+	if u.Name != name || u.LastSeenAt == nil {
+		t.Errorf("name %q, last seen %v; want %q and a time", u.Name, u.LastSeenAt, name)
+	}
+}
+
+// pausingStore calls onGetUser, if set, after each GetUser.
+type pausingStore struct {
+	*MemoryStore
+	onGetUser func(ctx context.Context)
+}
+
+func (p *pausingStore) GetUser(ctx context.Context, id string) (*User, error) {
+	u, err := p.MemoryStore.GetUser(ctx, id)
+	if p.onGetUser != nil {
+		p.onGetUser(ctx)
+	}
+	return u, err
+}
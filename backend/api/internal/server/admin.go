@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// adminSecurity marks an operation as needing the ADMIN_TOKEN bearer token,
+// or a client certificate of one of the ADMIN_SERVICES.
+var adminSecurity = []map[string][]string{{"adminToken": {}}, {"mutualTLS": {}}}
+
+// AdminInput carries the credentials every admin operation checks.
+type AdminInput struct {
+	Authorization string `header:"Authorization" redact:"true" doc:"Bearer ADMIN_TOKEN"`
+	remoteIP      string
+	operation     string
+}
+
+// Resolve records where the call came from, for the security event of a
+// wrong admin token.
+func (i *AdminInput) Resolve(ctx huma.Context) []error {
+	i.remoteIP = remoteHost(ctx.RemoteAddr())
+	i.operation = ctx.Operation().OperationID
+	return nil
+}
+
+type LogLevelRequest struct {
+	Level string `json:"level" enum:"debug,info,warn,error" doc:"Minimum level to log"`
+	// RevertAfterMinutes puts the configured level back after the given
+	// time, so a forgotten debug session doesn't flood the logs.
+	RevertAfterMinutes int `json:"revert_after_minutes,omitempty" minimum:"1" maximum:"1440" doc:"Go back to the configured level after this many minutes"`
+}
+
+type LogLevelResponse struct {
+	Level    string          `json:"level" doc:"Level now in effect"`
+	Previous string          `json:"previous" doc:"Level before this change"`
+	RevertAt *timestamp.Time `json:"revert_at,omitempty" doc:"When the configured level comes back, if a revert was requested"`
+}
+
+type LogLevelInput struct {
+	AdminInput
+	Body LogLevelRequest
+}
+
+type LogLevelOutput struct {
+	Body *LogLevelResponse
+}
+
+// authorizeAdmin checks the bearer token against cfg.AdminToken, unless
+// the request came from one of cfg.AdminServices. Without a configured
+// token or service the admin API is off and every call is refused. A token
+// that is sent but wrong is published as EventAuthFailed.
+func (s *Server) authorizeAdmin(ctx context.Context, in AdminInput) error {
+	if s.adminService(ctx) {
+		return nil
+	}
+	token, ok := strings.CutPrefix(in.Authorization, "Bearer ")
+	if s.cfg.AdminToken == "" || !ok ||
+		subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.AdminToken)) != 1 {
+		if in.Authorization != "" {
+			s.bus.Publish(ctx, events.Event{Type: EventAuthFailed, Data: map[string]any{
+				"ip": in.remoteIP, "operation": in.operation, "reason": "invalid_admin_token",
+			}})
+		}
+		return huma.Error401Unauthorized("admin token required")
+	}
+	return nil
+}
+
+// setLogLevel overrides the log level until the next call, a config reload
+// or, if revertAfter is positive, until revertAfter has passed.
+func (s *Server) setLogLevel(level slog.Level, revertAfter time.Duration) *LogLevelResponse {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	res := &LogLevelResponse{Previous: levelName(s.logLevel.Level()), Level: levelName(level)}
+	if s.levelRevert != nil {
+		s.levelRevert.Stop()
+		s.levelRevert = nil
+	}
+	s.logLevel.Set(level)
+	if revertAfter > 0 {
+		at := timestamp.From(time.Now().Add(revertAfter))
+		res.RevertAt = &at
+		var timer *time.Timer
+		timer = time.AfterFunc(revertAfter, func() {
+			s.reloadMu.Lock()
+			defer s.reloadMu.Unlock()
+			if s.levelRevert != timer {
+				return // superseded by a later change
+			}
+			s.levelRevert = nil
+			s.logLevel.Set(s.cfg.LogLevel)
+			s.logger.Info("log level reverted", "level", levelName(s.cfg.LogLevel))
+		})
+		s.levelRevert = timer
+	}
+	s.logger.Info("log level changed", "from", res.Previous, "to", res.Level, "revert_at", res.RevertAt)
+	return res
+}
+
+// levelName spells level the way LogLevelRequest accepts it.
+func levelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
@@ -0,0 +1,318 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// Security events about API keys. Their subject is the user who owns the
+// key.
+const (
+	EventAPIKeyCreated = "security.api_key_created"
+	EventAPIKeyRotated = "security.api_key_rotated"
+	EventAPIKeyRevoked = "security.api_key_revoked"
+)
+
+const (
+	// apiKeyPrefix starts every API key, so they are told apart from user
+	// tokens and found by secret scanners.
+	apiKeyPrefix = "mk_"
+	// maxAPIKeys is how many keys one user can have.
+	maxAPIKeys = 20
+	// apiKeyUseGranularity is how stale last_used_at may get: a key used
+	// all the time is written back once a minute rather than on every
+	// request.
+	apiKeyUseGranularity = time.Minute
+)
+
+// StoredAPIKey is an API key as kept with its user's credentials: only a
+// hash of its secret.
+type StoredAPIKey struct {
+	APIKey
+	SecretHash string `json:"secret_hash"`
+}
+
+// APIKey is an API key as its owner sees it, without its secret.
+type APIKey struct {
+	ID         string          `json:"id" example:"key_3f9a1c2b7d4e" doc:"API key ID"`
+	Name       string          `json:"name" example:"CI deploys" doc:"What the key is for"`
+	Prefix     string          `json:"prefix" example:"mk_66697874757265_3f9a1c2b7d4e" doc:"The start of the key, which stays the same when it is rotated, to tell it apart from the others"`
+	Scopes     []string        `json:"scopes" doc:"What the key may do"`
+	CreatedAt  timestamp.Time  `json:"created_at" doc:"When the key was created"`
+	RotatedAt  *timestamp.Time `json:"rotated_at,omitempty" doc:"When the key was last rotated, if it was"`
+	LastUsedAt *timestamp.Time `json:"last_used_at,omitempty" doc:"When the key was last used, to the minute; absent if it never was"`
+	ExpiresAt  *timestamp.Time `json:"expires_at,omitempty" doc:"When the key stops working; absent if it doesn't"`
+}
+
+// NewAPIKey is an API key with its secret, which is only ever shown once.
+type NewAPIKey struct {
+	APIKey
+	Key string `json:"key" redact:"true" example:"mk_66697874757265_3f9a1c2b7d4e_9c1f…" doc:"The key, to send as a bearer token; it can't be shown again"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string          `json:"name" minLength:"1" maxLength:"100" example:"CI deploys" doc:"What the key is for"`
+	Scopes    []string        `json:"scopes" minItems:"1" uniqueItems:"true" enum:"users:read,notifications:read,notifications:write" doc:"What the key may do"` // every one of apiKeyScopes
+	ExpiresAt *timestamp.Time `json:"expires_at,omitempty" doc:"When the key stops working; never if omitted"`
+}
+
+// Resolve refuses an expiry that has passed.
+func (r *CreateAPIKeyRequest) Resolve(ctx huma.Context, prefix *huma.PathBuffer) []error {
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(time.Now()) {
+		return []error{&ErrorDetail{
+			Location: prefix.With("expires_at"),
+			Code:     "invalid",
+			Message:  "expected a time in the future",
+			Value:    r.ExpiresAt,
+		}}
+	}
+	return nil
+}
+
+type CreateAPIKeyInput struct {
+	UserInput
+	Body CreateAPIKeyRequest
+}
+
+type APIKeyIDInput struct {
+	UserInput
+	ID string `path:"id" doc:"API key ID"`
+}
+
+type APIKeysResponse struct {
+	APIKeys []APIKey `json:"api_keys" doc:"Your API keys, oldest first"`
+}
+
+type APIKeysOutput struct {
+	Body *APIKeysResponse
+}
+
+type NewAPIKeyOutput struct {
+	Body *NewAPIKey
+}
+
+var errAPIKeyNotFound = apiError(http.StatusNotFound, CodeAPIKeyNotFound, "API key not found")
+
+// newAPIKeySecret returns the key for the user's key id, and the hash of
+// it that is stored. The key carries the user's ID, hex-encoded, to find
+// the key to check it against without a lookup table.
+func newAPIKeySecret(userID, id string) (key, hash string) {
+	b := make([]byte, 24)
+	rand.Read(b)
+	key = apiKeyPrefixFor(userID, id) + "_" + hex.EncodeToString(b)
+	return key, hashAPIKey(key)
+}
+
+func apiKeyPrefixFor(userID, id string) string {
+	return apiKeyPrefix + hex.EncodeToString([]byte(userID)) + "_" + strings.TrimPrefix(id, "key_")
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// parseAPIKey returns the user and key ID key claims to be, if it is shaped
+// like an API key.
+func parseAPIKey(key string) (userID, id string, ok bool) {
+	rest, ok := strings.CutPrefix(key, apiKeyPrefix)
+	if !ok {
+		return "", "", false
+	}
+	parts := strings.Split(rest, "_")
+	if len(parts) != 3 {
+		return "", "", false
+	}
+	user, err := hex.DecodeString(parts[0])
+	if err != nil || len(user) == 0 {
+		return "", "", false
+	}
+	return string(user), "key_" + parts[1], true
+}
+
+// credentials returns the user's stored credentials, empty ones if they
+// have none.
+func (u *UserService) credentials(ctx context.Context, userID string) (*Credentials, error) {
+	creds, err := u.store.GetCredentials(ctx, userID)
+	if errors.Is(err, ErrNotFound) {
+		return &Credentials{}, nil
+	}
+	return creds, err
+}
+
+// APIKeys returns the user's API keys, oldest first.
+func (u *UserService) APIKeys(ctx context.Context, userID string) ([]APIKey, error) {
+	creds, err := u.credentials(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]APIKey, 0, len(creds.APIKeys))
+	for _, k := range creds.APIKeys {
+		keys = append(keys, k.APIKey)
+	}
+	return keys, nil
+}
+
+// CreateAPIKey gives the user a new API key.
+func (u *UserService) CreateAPIKey(ctx context.Context, userID string, req CreateAPIKeyRequest) (*NewAPIKey, error) {
+	creds, err := u.credentials(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(creds.APIKeys) >= maxAPIKeys {
+		return nil, apiError(http.StatusConflict, CodeConflict, "too many API keys; revoke one first")
+	}
+	b := make([]byte, 6)
+	rand.Read(b)
+	id := "key_" + hex.EncodeToString(b)
+	key, hash := newAPIKeySecret(userID, id)
+	stored := StoredAPIKey{
+		APIKey: APIKey{
+			ID:        id,
+			Name:      req.Name,
+			Prefix:    apiKeyPrefixFor(userID, id),
+			Scopes:    req.Scopes,
+			CreatedAt: timestamp.Now(),
+			ExpiresAt: req.ExpiresAt,
+		},
+		SecretHash: hash,
+	}
+	// The stored slice may be shared with the store; never change it in
+	// place.
+	creds.APIKeys = append(slices.Clone(creds.APIKeys), stored)
+	if err := u.store.PutCredentials(ctx, userID, creds); err != nil {
+		return nil, err
+	}
+	u.bus.Publish(ctx, events.Event{Type: EventAPIKeyCreated, Subject: userID, Data: map[string]any{"key_id": id, "scopes": req.Scopes}})
+	return &NewAPIKey{APIKey: stored.APIKey, Key: key}, nil
+}
+
+// RotateAPIKey gives the user's key a new secret, keeping its ID, name and
+// scopes. The old secret stops working at once.
+func (u *UserService) RotateAPIKey(ctx context.Context, userID, id string) (*NewAPIKey, error) {
+	creds, err := u.credentials(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(creds.APIKeys, func(k StoredAPIKey) bool { return k.ID == id })
+	if i < 0 {
+		return nil, errAPIKeyNotFound
+	}
+	key, hash := newAPIKeySecret(userID, id)
+	now := timestamp.Now()
+	creds.APIKeys = slices.Clone(creds.APIKeys)
+	creds.APIKeys[i].SecretHash = hash
+	creds.APIKeys[i].RotatedAt = &now
+	if err := u.store.PutCredentials(ctx, userID, creds); err != nil {
+		return nil, err
+	}
+	u.bus.Publish(ctx, events.Event{Type: EventAPIKeyRotated, Subject: userID, Data: map[string]any{"key_id": id}})
+	return &NewAPIKey{APIKey: creds.APIKeys[i].APIKey, Key: key}, nil
+}
+
+// RevokeAPIKey deletes the user's key.
+func (u *UserService) RevokeAPIKey(ctx context.Context, userID, id string) error {
+	creds, err := u.credentials(ctx, userID)
+	if err != nil {
+		return err
+	}
+	keys := slices.DeleteFunc(slices.Clone(creds.APIKeys), func(k StoredAPIKey) bool { return k.ID == id })
+	if len(keys) == len(creds.APIKeys) {
+		return errAPIKeyNotFound
+	}
+	creds.APIKeys = keys
+	if err := u.store.PutCredentials(ctx, userID, creds); err != nil {
+		return err
+	}
+	u.bus.Publish(ctx, events.Event{Type: EventAPIKeyRevoked, Subject: userID, Data: map[string]any{"key_id": id}})
+	return nil
+}
+
+// errBadAPIKey is the one answer to an API key that doesn't work, however
+// it doesn't.
+var errBadAPIKey = errors.New("invalid API key")
+
+// authenticateAPIKey returns the principal key acts as. Keys of users who
+// are no longer active stop working, as their passwords do.
+func (u *UserService) authenticateAPIKey(ctx context.Context, key string, now time.Time) (*Principal, error) {
+	userID, id, ok := parseAPIKey(key)
+	if !ok {
+		return nil, errBadAPIKey
+	}
+	creds, err := u.store.GetCredentials(ctx, userID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, errBadAPIKey
+	} else if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(creds.APIKeys, func(k StoredAPIKey) bool { return k.ID == id })
+	if i < 0 || subtle.ConstantTimeCompare([]byte(creds.APIKeys[i].SecretHash), []byte(hashAPIKey(key))) != 1 {
+		return nil, errBadAPIKey
+	}
+	stored := creds.APIKeys[i]
+	if stored.ExpiresAt != nil && !now.Before(stored.ExpiresAt.Time) {
+		return nil, errBadAPIKey
+	}
+	user, err := u.store.GetUser(ctx, userID)
+	if err != nil || user.Status != UserStatusActive {
+		return nil, errBadAPIKey
+	}
+	if !u.readOnly.Load() && (stored.LastUsedAt == nil || now.Sub(stored.LastUsedAt.Time) >= apiKeyUseGranularity) {
+		at := timestamp.From(now.Truncate(apiKeyUseGranularity))
+		creds.APIKeys = slices.Clone(creds.APIKeys)
+		creds.APIKeys[i].LastUsedAt = &at
+		if err := u.store.PutCredentials(ctx, userID, creds); err != nil {
+			u.logger.ErrorContext(ctx, "failed to record API key use", "key_id", id, "err", err)
+		}
+	}
+	p := &Principal{UserID: userID, APIKeyID: id, Scopes: stored.Scopes}
+	if stored.ExpiresAt != nil {
+		p.ExpiresAt = stored.ExpiresAt.Time
+	}
+	return p, nil
+}
+
+// listAPIKeys is the get-v1-api-keys handler.
+func (s *Server) listAPIKeys(ctx context.Context, _ *UserInput) (*APIKeysOutput, error) {
+	keys, err := s.users.APIKeys(ctx, principalFrom(ctx).UserID)
+	if err != nil {
+		return nil, err
+	}
+	return &APIKeysOutput{Body: &APIKeysResponse{APIKeys: keys}}, nil
+}
+
+// createAPIKey is the post-v1-api-keys handler.
+func (s *Server) createAPIKey(ctx context.Context, input *CreateAPIKeyInput) (*NewAPIKeyOutput, error) {
+	key, err := s.users.CreateAPIKey(ctx, principalFrom(ctx).UserID, input.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &NewAPIKeyOutput{Body: key}, nil
+}
+
+// rotateAPIKey is the post-v1-api-keys-by-id-rotate handler.
+func (s *Server) rotateAPIKey(ctx context.Context, input *APIKeyIDInput) (*NewAPIKeyOutput, error) {
+	key, err := s.users.RotateAPIKey(ctx, principalFrom(ctx).UserID, input.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &NewAPIKeyOutput{Body: key}, nil
+}
+
+// revokeAPIKey is the delete-v1-api-keys-by-id handler.
+func (s *Server) revokeAPIKey(ctx context.Context, input *APIKeyIDInput) (*struct{}, error) {
+	return nil, s.users.RevokeAPIKey(ctx, principalFrom(ctx).UserID, input.ID)
+}
@@ -0,0 +1,300 @@
+package server
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+type AuditEntry struct {
+	ID      int64          `json:"id" doc:"Sequence number of the entry"`
+	Time    timestamp.Time `json:"time" doc:"When it happened"`
+	Type    string         `json:"type" example:"user.activated" doc:"What happened"`
+	Subject string         `json:"subject" doc:"ID of the user it happened to, or an erased-… placeholder once the user is erased"`
+	Data    any            `json:"data,omitempty" doc:"Event-specific details"`
+	EventID string         `json:"event_id" doc:"ID of the event recorded"`
+	// The IDs that trace the event back to the action behind it.
+	CorrelationID string `json:"correlation_id,omitempty" doc:"Correlation ID of the request that caused it, the X-Correlation-ID it was sent with or else its request ID"`
+	CausationID   string `json:"causation_id,omitempty" doc:"ID of what caused it: the X-Request-ID of a request, or the ID of another event"`
+}
+
+// AuditLog records every event published on the bus, oldest first, under
+// sequence numbers counting up from 1. It is kept in memory, so it starts
+// empty on every restart, unless OpenFile keeps it in a file too.
+type AuditLog struct {
+	mu      sync.RWMutex
+	nextID  int64
+	entries []AuditEntry
+	// changed is closed, and replaced, whenever an entry is recorded, to
+	// wake the change feed's long polls.
+	changed chan struct{}
+
+	// file, once OpenFile has been called, gets every entry recorded.
+	file   *os.File
+	path   string
+	logger *slog.Logger
+}
+
+// NewAuditLog returns an audit log recording bus's events.
+func NewAuditLog(bus *events.Bus) *AuditLog {
+	a := &AuditLog{nextID: 1, changed: make(chan struct{})}
+	bus.Subscribe(a.record)
+	return a
+}
+
+func (a *AuditLog) record(e events.Event) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	entry := AuditEntry{
+		ID:            a.nextID,
+		Time:          timestamp.From(e.Time),
+		Type:          e.Type,
+		Subject:       e.Subject,
+		Data:          e.Data,
+		EventID:       e.ID,
+		CorrelationID: e.CorrelationID,
+		CausationID:   e.CausationID,
+	}
+	a.entries = append(a.entries, entry)
+	a.nextID++
+	a.append(entry)
+	close(a.changed)
+	a.changed = make(chan struct{})
+}
+
+// ForSubject returns the entries about subject, oldest first.
+func (a *AuditLog) ForSubject(subject string) []AuditEntry {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	out := []AuditEntry{}
+	for _, e := range a.entries {
+		if e.Subject == subject {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Anonymize replaces subject in every entry about it with placeholder and
+// drops the entries' details, leaving the fact that things happened, and
+// when, but not to whom. It returns how many entries it changed.
+func (a *AuditLog) Anonymize(subject, placeholder string) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	n := 0
+	for i := range a.entries {
+		if a.entries[i].Subject == subject {
+			a.entries[i].Subject = placeholder
+			a.entries[i].Data = nil
+			n++
+		}
+	}
+	if n > 0 {
+		a.rewrite()
+	}
+	return n
+}
+
+// Prune drops the entries from before cutoff and returns how many there
+// were. With dryRun it only counts them.
+func (a *AuditLog) Prune(cutoff time.Time, dryRun bool) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	n := 0
+	for _, e := range a.entries {
+		if e.Time.Before(cutoff) {
+			n++
+		}
+	}
+	if !dryRun && n > 0 {
+		a.entries = slices.DeleteFunc(a.entries, func(e AuditEntry) bool { return e.Time.Before(cutoff) })
+		a.rewrite()
+	}
+	return n
+}
+
+// Entries returns a copy of the whole log.
+func (a *AuditLog) Entries() []AuditEntry {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return slices.Clone(a.entries)
+}
+
+// event returns the event e recorded, as it was published but for what
+// Anonymize removed.
+func (e AuditEntry) event() events.Event {
+	return events.Event{
+		ID:            e.EventID,
+		Type:          e.Type,
+		Subject:       e.Subject,
+		Time:          e.Time.Time,
+		Data:          e.Data,
+		CorrelationID: e.CorrelationID,
+		CausationID:   e.CausationID,
+	}
+}
+
+// OpenFile loads the entries kept in the file at path, if it exists, and
+// then keeps every entry recorded there too, one line of JSON each, so the
+// log and its sequence numbers carry on across restarts. Lines are written
+// through to the OS but not synced, so a crash of the machine, though not
+// of the process, may lose the last few. Anonymize and Prune rewrite the
+// file, so what they remove doesn't linger on disk; a failure to write it
+// is logged to logger, and the entries stay in memory regardless. A torn
+// last line, from a crash mid-append, is dropped.
+func (a *AuditLog) OpenFile(path string, logger *slog.Logger) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file != nil {
+		return errors.New("audit log file is already open")
+	}
+	entries, err := readAuditFile(path)
+	if err != nil {
+		return err
+	}
+	// Entries recorded before the file was opened come after those in it.
+	next := int64(1)
+	if len(entries) > 0 {
+		next = entries[len(entries)-1].ID + 1
+	}
+	for _, e := range a.entries {
+		e.ID = next
+		next++
+		entries = append(entries, e)
+	}
+	a.entries, a.nextID = entries, next
+	a.path, a.logger = path, logger
+	// Writing the file afresh drops a torn line, which would otherwise
+	// end up in the middle of it.
+	return a.writeFile()
+}
+
+// Close closes the audit log's file, if one is open.
+func (a *AuditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		return nil
+	}
+	err := a.file.Close()
+	a.file = nil
+	return err
+}
+
+func readAuditFile(path string) ([]AuditEntry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []AuditEntry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64<<10), walMaxRecord)
+	var pending error
+	for line := 1; sc.Scan(); line++ {
+		if pending != nil {
+			return nil, pending // a bad line that wasn't the last one
+		}
+		var e AuditEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			pending = fmt.Errorf("%s line %d: %w", path, line, err)
+			continue
+		}
+		if len(entries) > 0 && e.ID <= entries[len(entries)-1].ID {
+			return nil, fmt.Errorf("%s line %d: entry %d out of order", path, line, e.ID)
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+// append adds e to the file, if one is open. The caller holds the write
+// lock.
+func (a *AuditLog) append(e AuditEntry) {
+	if a.file == nil {
+		return
+	}
+	b, err := json.Marshal(e)
+	if err == nil {
+		_, err = a.file.Write(append(b, '\n'))
+	}
+	if err != nil {
+		a.logger.Warn("failed to append to the audit log file", "path", a.path, "id", e.ID, "err", err)
+	}
+}
+
+// rewrite replaces the file, if one is open, with the entries now in the
+// log. The caller holds the write lock.
+func (a *AuditLog) rewrite() {
+	if a.file == nil {
+		return
+	}
+	if err := a.writeFile(); err != nil {
+		a.logger.Warn("failed to rewrite the audit log file", "path", a.path, "err", err)
+	}
+}
+
+// writeFile writes the entries to a temporary file next to the log's and
+// renames it into place, then appends to it from then on. The caller holds
+// the write lock.
+func (a *AuditLog) writeFile() error {
+	if err := os.MkdirAll(filepath.Dir(a.path), 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(a.path), filepath.Base(a.path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // fails harmlessly once renamed
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, e := range a.entries {
+		if err := enc.Encode(e); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := os.Rename(f.Name(), a.path); err != nil {
+		f.Close()
+		return err
+	}
+	if a.file != nil {
+		a.file.Close()
+	}
+	// f now is the file at path, opened for writing at its end.
+	a.file = f
+	return nil
+}
+
+// erasedSubject returns a fresh placeholder for an erased user. It is random
+// rather than derived from the ID, so it can't be linked back to them.
+func erasedSubject() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "erased-" + hex.EncodeToString(b)
+}
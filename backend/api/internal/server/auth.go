@@ -0,0 +1,201 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/authtoken"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/jwks"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// EventImpersonatedRequest is published for every request made with an
+// impersonation token, so the audit log shows what support staff did while
+// acting as a user.
+const EventImpersonatedRequest = "user.impersonated_request"
+
+// Principal is the user a request authenticated as.
+type Principal struct {
+	UserID string
+	// ImpersonatedBy is set when staff act as the user; see
+	// post-admin-impersonate-by-user-id.
+	ImpersonatedBy string
+	TokenID        string
+	// Issuer is set when the token is from one of Config.JWTIssuers rather
+	// than the API's own.
+	Issuer string
+	// APIKeyID is set when the request authenticated with one of the user's
+	// API keys rather than a user token.
+	APIKeyID string
+	// Scopes are what an API key may do; nil, for a user token, is
+	// anything.
+	Scopes    []string
+	ExpiresAt time.Time // zero for an API key that doesn't expire
+}
+
+type principalKey struct{}
+
+// principalFrom returns the request's principal, or nil if it carried no
+// user token.
+func principalFrom(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// UserInput carries the credentials operations secured by scoped or
+// userTokenSecurity check. The authenticate and authorize middlewares have
+// verified them by the time the handler runs; see principalFrom.
+type UserInput struct {
+	Authorization string `header:"Authorization" redact:"true" doc:"Bearer user token"`
+}
+
+// authenticate resolves a user token or API key in the Authorization
+// header into a Principal on the request's context. Requests without one
+// pass through unchanged, as do those carrying the admin token, which the
+// admin operations check themselves. A user token that is forged, expired
+// or revoked, or an API key that doesn't work, is refused outright rather
+// than treated as absent.
+//
+// An authenticated request counts as the user being seen, unless staff are
+// impersonating them; those requests are audited instead.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if ok && strings.HasPrefix(token, apiKeyPrefix) {
+			p, err := s.users.authenticateAPIKey(r.Context(), token, time.Now())
+			if errors.Is(err, errBadAPIKey) {
+				s.publishDenied(r, EventAuthFailed, "", "invalid_api_key", nil)
+				writeError(w, r, http.StatusUnauthorized, CodeInvalidToken, "invalid API key")
+				return
+			} else if err != nil {
+				s.logger.ErrorContext(r.Context(), "failed to check API key", "err", err)
+				writeError(w, r, http.StatusInternalServerError, CodeInternal, "unexpected error occurred")
+				return
+			}
+			ctx := context.WithValue(r.Context(), principalKey{}, p)
+			ctx = context.WithValue(ctx, apiKeyIDKey{}, p.APIKeyID)
+			r = r.WithContext(ctx)
+			s.bus.Publish(r.Context(), events.Event{Type: EventUserSeen, Subject: p.UserID})
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !ok || !authtoken.LooksLikeToken(token) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		p, err := s.verifyToken(r.Context(), token)
+		switch {
+		case errors.Is(err, authtoken.ErrExpired), errors.Is(err, jwks.ErrExpired):
+			s.publishDenied(r, EventAuthFailed, "", "expired_token", nil)
+			writeError(w, r, http.StatusUnauthorized, CodeInvalidToken, "user token expired")
+			return
+		case errors.Is(err, authtoken.ErrInvalid), errors.Is(err, jwks.ErrInvalid):
+			s.publishDenied(r, EventAuthFailed, "", "invalid_token", nil)
+			writeError(w, r, http.StatusUnauthorized, CodeInvalidToken, "invalid user token")
+			return
+		case err != nil:
+			s.logger.ErrorContext(r.Context(), "failed to fetch JWT issuer keys", "err", err)
+			writeError(w, r, http.StatusServiceUnavailable, CodeIssuerUnavailable, "the token's issuer is unavailable")
+			return
+		}
+		if revoked, err := s.users.tokenRevoked(r.Context(), p.UserID, token); err != nil {
+			s.logger.ErrorContext(r.Context(), "failed to check token revocation", "err", err)
+			writeError(w, r, http.StatusInternalServerError, CodeInternal, "unexpected error occurred")
+			return
+		} else if revoked {
+			s.publishDenied(r, EventAuthFailed, p.UserID, "revoked_token", nil)
+			writeError(w, r, http.StatusUnauthorized, CodeInvalidToken, "user token revoked")
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), principalKey{}, p))
+		if p.ImpersonatedBy == "" {
+			s.bus.Publish(r.Context(), events.Event{Type: EventUserSeen, Subject: p.UserID})
+			next.ServeHTTP(w, r)
+			return
+		}
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		s.bus.Publish(r.Context(), events.Event{
+			Type:    EventImpersonatedRequest,
+			Subject: p.UserID,
+			Data: map[string]any{
+				"impersonated_by": p.ImpersonatedBy,
+				"token_id":        p.TokenID,
+				"method":          r.Method,
+				"route":           routePattern(r),
+				"status":          sw.status,
+			},
+		})
+	})
+}
+
+// verifyToken verifies a user token, the API's own or one from the JWT
+// issuers, and returns who it acts as.
+func (s *Server) verifyToken(ctx context.Context, token string) (*Principal, error) {
+	if s.jwks != nil && s.jwks.Issues(token) {
+		claims, err := s.jwks.Verify(ctx, token, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		return &Principal{UserID: claims.Subject, Issuer: claims.Issuer, TokenID: claims.ID, ExpiresAt: claims.Expiry()}, nil
+	}
+	claims, err := s.tokens.Verify(token, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{
+		UserID:         claims.Subject,
+		ImpersonatedBy: claims.ImpersonatedBy,
+		TokenID:        claims.ID,
+		ExpiresAt:      claims.Expiry(),
+	}, nil
+}
+
+// CurrentUser is who a user token or API key acts as.
+type CurrentUser struct {
+	User           *User           `json:"user" doc:"The user the token acts as"`
+	ImpersonatedBy string          `json:"impersonated_by,omitempty" redact:"true" doc:"Staff member acting as the user, if this is an impersonation token"`
+	Issuer         string          `json:"issuer,omitempty" example:"https://example.us.auth0.com/" doc:"The identity provider that issued the token, if it isn't the API's own"`
+	APIKeyID       string          `json:"api_key_id,omitempty" doc:"The API key used, if it was one rather than a user token"`
+	Scopes         []string        `json:"scopes,omitempty" doc:"What the API key may do; absent for a user token, which may do anything"`
+	TokenExpiresAt *timestamp.Time `json:"token_expires_at,omitempty" doc:"When the token stops working; absent for an API key that doesn't expire"`
+}
+
+type CurrentUserOutput struct {
+	Body *CurrentUser
+}
+
+// currentUser is the get-v1-me handler.
+func (s *Server) currentUser(ctx context.Context, _ *UserInput) (*CurrentUserOutput, error) {
+	p := principalFrom(ctx)
+	user, err := s.users.Get(ctx, p.UserID)
+	if err != nil {
+		return nil, err
+	}
+	out := &CurrentUser{User: user, ImpersonatedBy: p.ImpersonatedBy, Issuer: p.Issuer, APIKeyID: p.APIKeyID, Scopes: p.Scopes}
+	if !p.ExpiresAt.IsZero() {
+		at := timestamp.From(p.ExpiresAt)
+		out.TokenExpiresAt = &at
+	}
+	return &CurrentUserOutput{Body: out}, nil
+}
+
+// newTokenSigner returns a signer for key, or for a random key if key is
+// empty.
+func newTokenSigner(key []byte) *authtoken.Signer {
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		rand.Read(key)
+	}
+	signer, err := authtoken.NewSigner(key)
+	if err != nil {
+		// ConfigFromEnv already rejects short keys.
+		panic(err)
+	}
+	return signer
+}
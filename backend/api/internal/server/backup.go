@@ -0,0 +1,282 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// backupContentType is the media type of a backup archive: a gzipped
+// snapshot.
+const backupContentType = "application/gzip"
+
+// maxBackupBytes bounds the archive /admin/restore accepts.
+const maxBackupBytes = 256 << 20
+
+// maxInflatedBackupBytes bounds what an archive may inflate to, well above
+// what JSON compresses by, so a small archive can't exhaust memory.
+const maxInflatedBackupBytes = 16 * maxBackupBytes
+
+type BackupInput struct {
+	AdminInput
+}
+
+type BackupOutput struct {
+	ContentType        string `header:"Content-Type"`
+	ContentDisposition string `header:"Content-Disposition"`
+	Body               []byte
+}
+
+type RestoreInput struct {
+	AdminInput
+	RawBody []byte `contentType:"application/gzip"`
+}
+
+type RestoreResponse struct {
+	Users int `json:"users" doc:"Number of users restored"`
+}
+
+type RestoreOutput struct {
+	Body *RestoreResponse
+}
+
+// ExportStore writes a backup of store to w: every user, their preferences,
+// credentials, posts and comments, and the invitations, in the snapshot
+// format, gzipped. It only uses the Store interface, so it works the same for
+// every backend.
+func ExportStore(ctx context.Context, store Store, w io.Writer) error {
+	users, err := store.ListUsers(ctx)
+	if err != nil {
+		return err
+	}
+	snap := snapshot{
+		Version:     snapshotVersion,
+		TakenAt:     timestamp.Now(),
+		Users:       users,
+		Preferences: map[string]*UserPreferences{},
+		Credentials: map[string]*Credentials{},
+	}
+	if snap.Posts, err = store.ListPosts(ctx); err != nil {
+		return err
+	}
+	for _, p := range snap.Posts {
+		comments, err := store.ListComments(ctx, p.ID)
+		if err != nil {
+			return err
+		}
+		snap.Comments = append(snap.Comments, comments...)
+	}
+	if snap.Invitations, err = store.ListInvitations(ctx); err != nil {
+		return err
+	}
+	for _, u := range users {
+		prefs, err := store.GetPreferences(ctx, u.ID)
+		switch {
+		case err == nil:
+			snap.Preferences[u.ID] = prefs
+		case !errors.Is(err, ErrNotFound):
+			return err
+		}
+		creds, err := store.GetCredentials(ctx, u.ID)
+		switch {
+		case err == nil:
+			snap.Credentials[u.ID] = creds
+		case !errors.Is(err, ErrNotFound):
+			return err
+		}
+	}
+	zw := gzip.NewWriter(w)
+	if err := json.NewEncoder(zw).Encode(snap); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ImportStore replaces the contents of store with the backup read from r and
+// returns how many users it restored. Users and invitations missing from the
+// backup are deleted. It isn't atomic: if it fails midway, store holds a mix of old and
+// restored users, and running it again finishes the job.
+func ImportStore(ctx context.Context, store Store, r io.Reader) (int, error) {
+	snap, err := readBackup(r)
+	if err != nil {
+		return 0, err
+	}
+	return restoreSnapshot(ctx, store, snap)
+}
+
+func readBackup(r io.Reader) (snapshot, error) {
+	var snap snapshot
+	zr, err := gunzip(r, maxInflatedBackupBytes)
+	if err != nil {
+		return snap, fmt.Errorf("read backup: %w", err)
+	}
+	if err := json.NewDecoder(zr).Decode(&snap); err != nil {
+		return snap, fmt.Errorf("decode backup: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return snap, fmt.Errorf("backup version %d is not supported, want %d", snap.Version, snapshotVersion)
+	}
+	return snap, nil
+}
+
+func restoreSnapshot(ctx context.Context, store Store, snap snapshot) (int, error) {
+	keep := make(map[string]bool, len(snap.Users))
+	for _, u := range snap.Users {
+		keep[u.ID] = true
+	}
+	existing, err := store.ListUsers(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, u := range existing {
+		if keep[u.ID] {
+			continue
+		}
+		if err := store.DeleteUser(ctx, u.ID); err != nil && !errors.Is(err, ErrNotFound) {
+			return 0, err
+		}
+	}
+	keepPosts := make(map[string]bool, len(snap.Posts))
+	for _, p := range snap.Posts {
+		keepPosts[p.ID] = true
+	}
+	posts, err := store.ListPosts(ctx)
+	if err != nil {
+		return 0, err
+	}
+	keepComments := make(map[string]bool, len(snap.Comments))
+	for _, c := range snap.Comments {
+		keepComments[c.ID] = true
+	}
+	for _, p := range posts {
+		if !keep[p.AuthorID] {
+			continue // gone with their author already
+		}
+		if !keepPosts[p.ID] {
+			if err := store.DeletePost(ctx, p.ID); err != nil && !errors.Is(err, ErrNotFound) {
+				return 0, err
+			}
+			continue
+		}
+		comments, err := store.ListComments(ctx, p.ID)
+		if err != nil {
+			return 0, err
+		}
+		for _, c := range comments {
+			if keepComments[c.ID] {
+				continue
+			}
+			// Replies the backup has go with it and are put back below.
+			if err := store.DeleteComment(ctx, c.ID); err != nil && !errors.Is(err, ErrNotFound) {
+				return 0, err
+			}
+		}
+	}
+	keepInvitations := make(map[string]bool, len(snap.Invitations))
+	for _, inv := range snap.Invitations {
+		keepInvitations[inv.ID] = true
+	}
+	invitations, err := store.ListInvitations(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, inv := range invitations {
+		if keepInvitations[inv.ID] {
+			continue
+		}
+		if err := store.DeleteInvitation(ctx, inv.ID); err != nil && !errors.Is(err, ErrNotFound) {
+			return 0, err
+		}
+	}
+	for _, u := range snap.Users {
+		if err := store.PutUser(ctx, u); err != nil {
+			return 0, err
+		}
+		if prefs, ok := snap.Preferences[u.ID]; ok {
+			if err := store.PutPreferences(ctx, u.ID, prefs); err != nil {
+				return 0, err
+			}
+		}
+		if creds, ok := snap.Credentials[u.ID]; ok {
+			if err := store.PutCredentials(ctx, u.ID, creds); err != nil {
+				return 0, err
+			}
+		}
+	}
+	for _, p := range snap.Posts {
+		err := store.PutPost(ctx, p)
+		if errors.Is(err, ErrNotFound) {
+			continue // a post whose author isn't in the backup
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	for _, c := range snap.Comments {
+		err := store.PutComment(ctx, c)
+		if errors.Is(err, ErrNotFound) {
+			continue // a comment whose post or author isn't in the backup
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	for _, inv := range snap.Invitations {
+		if err := store.PutInvitation(ctx, inv); err != nil {
+			return 0, err
+		}
+	}
+	return len(snap.Users), nil
+}
+
+// backup is the get-admin-backup handler.
+func (s *Server) backup(ctx context.Context, input *BackupInput) (*BackupOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := ExportStore(ctx, s.store, &buf); err != nil {
+		return nil, err
+	}
+	name := "backup-" + time.Now().UTC().Format("20060102T150405Z") + ".json.gz"
+	return &BackupOutput{
+		ContentType:        backupContentType,
+		ContentDisposition: `attachment; filename="` + name + `"`,
+		Body:               buf.Bytes(),
+	}, nil
+}
+
+// restore is the post-admin-restore handler.
+func (s *Server) restore(ctx context.Context, input *RestoreInput) (*RestoreOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	snap, err := readBackup(bytes.NewReader(input.RawBody))
+	if err != nil {
+		return nil, apiError(http.StatusUnprocessableEntity, CodeInvalidBackup, err.Error())
+	}
+	var n int
+	err = s.exclusive(ctx, lockRestore, func(ctx context.Context) error {
+		n, err = restoreSnapshot(ctx, s.store, snap)
+		if err != nil {
+			return err
+		}
+		s.logger.InfoContext(ctx, "store restored from backup", "users", n)
+		if err := s.search.Rebuild(ctx); err != nil {
+			s.logger.ErrorContext(ctx, "failed to rebuild the search index", "err", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &RestoreOutput{Body: &RestoreResponse{Users: n}}, nil
+}
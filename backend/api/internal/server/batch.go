@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// BatchUpdate is one entry of a batch update.
+type BatchUpdate struct {
+	ID      string         `json:"id" minLength:"1" example:"20240101120000" doc:"ID of the user to update"`
+	Changes map[string]any `json:"changes" example:"{\"name\":\"Rohan Chauhan\"}" doc:"The fields to change, as in the body of put-v1-users-by-id (UpdateUserRequest). They are validated per entry, so an invalid entry fails on its own"`
+}
+
+type BatchUpdateRequest struct {
+	Updates []BatchUpdate `json:"updates" minItems:"1" maxItems:"100" doc:"Updates to apply, in order"`
+}
+
+type BatchUpdateUsersInput struct {
+	Body BatchUpdateRequest
+}
+
+// BatchUpdateResult is the outcome of one entry of a batch update.
+type BatchUpdateResult struct {
+	ID     string      `json:"id" example:"20240101120000" doc:"ID of the user the entry was for"`
+	Status int         `json:"status" example:"200" doc:"What updating the user on its own would have answered: 200, or the status of Error"`
+	User   *User       `json:"user,omitempty" doc:"The updated user, if the entry succeeded"`
+	Error  *ErrorModel `json:"error,omitempty" doc:"Why the entry failed, as put-v1-users-by-id would have said"`
+}
+
+type BatchUpdateResponse struct {
+	Results []BatchUpdateResult `json:"results" doc:"One per entry, in request order"`
+	Updated int                 `json:"updated" example:"2" doc:"How many entries succeeded"`
+	Failed  int                 `json:"failed" example:"1" doc:"How many entries failed"`
+}
+
+// Translate lets i18n.LocalizeErrors translate the entries' errors.
+func (r *BatchUpdateResponse) Translate(translate func(string) string) any {
+	out := *r
+	out.Results = make([]BatchUpdateResult, len(r.Results))
+	for i, res := range r.Results {
+		if res.Error != nil {
+			res.Error = res.Error.Translate(translate).(*ErrorModel)
+		}
+		out.Results[i] = res
+	}
+	return &out
+}
+
+type BatchUpdateUsersOutput struct {
+	Body *BatchUpdateResponse
+}
+
+// batchUpdate applies updates in order, each on its own: one that fails
+// validation or conflicts doesn't stop the others.
+func (s *Server) batchUpdate(ctx context.Context, updates []BatchUpdate) *BatchUpdateResponse {
+	out := &BatchUpdateResponse{Results: make([]BatchUpdateResult, len(updates))}
+	for i, update := range updates {
+		user, err := s.applyBatchUpdate(ctx, i, update)
+		res := BatchUpdateResult{ID: update.ID, Status: http.StatusOK, User: user}
+		if err != nil {
+			em := s.errorModel(ctx, err)
+			res.Status, res.Error = em.Status, em
+			out.Failed++
+		} else {
+			out.Updated++
+		}
+		out.Results[i] = res
+	}
+	return out
+}
+
+// applyBatchUpdate validates and applies the i'th update as put-v1-users-by-id
+// would, with errors located in the batch's body.
+func (s *Server) applyBatchUpdate(ctx context.Context, i int, update BatchUpdate) (*User, error) {
+	var req UpdateUserRequest
+	if err := s.decodeBody(ctx, bodyPath("updates", i, "changes"), update.Changes, &req); err != nil {
+		return nil, err
+	}
+	return s.users.Update(ctx, update.ID, req)
+}
+
+// requestBody is a request body decodeBody can normalize after validating
+// it, as huma would have run its Resolve.
+type requestBody interface {
+	normalize(ctx context.Context, prefix *huma.PathBuffer) []error
+}
+
+// decodeBody does for body, part of a larger request that huma decoded
+// without checking, what huma does for a whole request body: it validates
+// body against dst's schema, decodes it into dst and normalizes it. This
+// lets a batch fail one entry rather than the whole request. Problems are
+// located under prefix and come back as a 422.
+func (s *Server) decodeBody(ctx context.Context, prefix *huma.PathBuffer, body map[string]any, dst requestBody) error {
+	registry := s.api.OpenAPI().Components.Schemas
+	res := &huma.ValidateResult{}
+	huma.Validate(registry, registry.Schema(reflect.TypeOf(dst).Elem(), true, ""), prefix, huma.ModeWriteToServer, body, res)
+	if len(res.Errors) > 0 {
+		return newError(http.StatusUnprocessableEntity, "validation failed", res.Errors...)
+	}
+	encoded, err := json.Marshal(body)
+	if err == nil {
+		err = json.Unmarshal(encoded, dst)
+	}
+	if err != nil {
+		return err
+	}
+	if errs := dst.normalize(ctx, prefix); len(errs) > 0 {
+		return newError(http.StatusUnprocessableEntity, "validation failed", errs...)
+	}
+	return nil
+}
+
+// bodyPath is the location body.<list>[i].<field>.
+func bodyPath(list string, i int, field string) *huma.PathBuffer {
+	prefix := huma.NewPathBuffer(make([]byte, 0, 64), 0)
+	prefix.Push("body")
+	prefix.Push(list)
+	prefix.PushIndex(i)
+	prefix.Push(field)
+	return prefix
+}
+
+// errorModel is err as the ErrorModel huma would answer it with. Errors
+// that aren't the client's are logged and hidden behind a 500, as huma
+// does.
+func (s *Server) errorModel(ctx context.Context, err error) *ErrorModel {
+	var em *ErrorModel
+	if !errors.As(err, &em) {
+		s.logger.ErrorContext(ctx, "operation failed", "err", err)
+		em = newError(http.StatusInternalServerError, "unexpected error occurred").(*ErrorModel)
+	}
+	return em
+}
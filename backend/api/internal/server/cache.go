@@ -0,0 +1,108 @@
+package server
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// CachePolicy is how long clients and caches may keep an operation's
+// successful responses, declared on the operation with cached. Operations
+// that declare none, and every error, are sent with no-store: most
+// responses carry user data, so caching is something an operation opts
+// into.
+type CachePolicy struct {
+	// MaxAge is how long a response stays fresh; zero has caches check
+	// back every time (no-cache).
+	MaxAge time.Duration
+	// Public lets shared caches, like a CDN, keep it, not just the client.
+	Public bool
+	// Tagged responses carry the Surrogate-Key and Last-Modified of what
+	// they hold, which must be a body implementing surrogated, and are
+	// purged from shared caches when that changes.
+	Tagged bool
+}
+
+// cachePolicyKey is the operation metadata cached puts the policy under.
+const cachePolicyKey = "cachePolicy"
+
+// noStore is the Cache-Control of everything that declares no policy.
+const noStore = "no-store"
+
+// cached is the Metadata of an operation whose responses may be kept per
+// policy. Operations taking credentials should only be public if their
+// responses are the same for everyone.
+func cached(policy CachePolicy) map[string]any {
+	return map[string]any{cachePolicyKey: policy}
+}
+
+// cacheControl returns the Cache-Control header of policy.
+func (p CachePolicy) cacheControl() string {
+	scope := "private"
+	if p.Public {
+		scope = "public"
+	}
+	if p.MaxAge <= 0 {
+		return scope + ", no-cache"
+	}
+	return scope + ", max-age=" + strconv.Itoa(int(p.MaxAge/time.Second))
+}
+
+// cacheControl is a huma middleware setting the Cache-Control of each
+// operation's responses from its CachePolicy. Responses that may be cached
+// and depend on the credentials an operation takes vary by Authorization;
+// the HAL transformer already has them all vary by Accept.
+func (s *Server) cacheControl(ctx huma.Context, next func(huma.Context)) {
+	op := ctx.Operation()
+	policy, ok := op.Metadata[cachePolicyKey].(CachePolicy)
+	if !ok {
+		ctx.SetHeader("Cache-Control", noStore)
+		next(ctx)
+		return
+	}
+	ctx.SetHeader("Cache-Control", policy.cacheControl())
+	if len(op.Security) > 0 {
+		ctx.AppendHeader("Vary", "Authorization")
+	}
+	next(ctx)
+}
+
+// uncacheErrors is a huma transformer keeping errors out of caches, even
+// those of operations whose responses may be cached, so a 404 or 503
+// doesn't outlive its cause.
+func uncacheErrors(ctx huma.Context, status string, v any) (any, error) {
+	if code, _ := strconv.Atoi(status); code >= 400 {
+		ctx.SetHeader("Cache-Control", noStore)
+	}
+	return v, nil
+}
+
+// documentCaching adds the Cache-Control of the operations declaring a
+// CachePolicy to the spec of their successful responses.
+func documentCaching(spec *huma.OpenAPI) {
+	for _, item := range spec.Paths {
+		for _, op := range []*huma.Operation{item.Get, item.Put, item.Post, item.Delete, item.Patch} {
+			if op == nil {
+				continue
+			}
+			policy, ok := op.Metadata[cachePolicyKey].(CachePolicy)
+			if !ok {
+				continue
+			}
+			for key, resp := range op.Responses {
+				if len(key) != 3 || key[0] != '2' {
+					continue
+				}
+				if resp.Headers == nil {
+					resp.Headers = map[string]*huma.Param{}
+				}
+				resp.Headers["Cache-Control"] = &huma.Param{
+					Description: "How long the response may be cached, and by whom",
+					Schema:      &huma.Schema{Type: "string"},
+					Example:     policy.cacheControl(),
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// Kinds of Call.
+const (
+	CallMiddleware = "middleware"
+	CallHandler    = "handler"
+	CallStore      = "store"
+)
+
+// Call is a step in serving a request: a router or huma middleware being
+// entered, the operation's handler, or a store method.
+type Call struct {
+	Kind string // CallMiddleware, CallHandler or CallStore
+	// Name is the middleware's function, as in "authenticate" or
+	// "instrument", the operation ID, or the store method.
+	Name string
+}
+
+func (c Call) String() string { return c.Kind + ":" + c.Name }
+
+// CallTracer records the calls each request goes through, in the order it
+// goes through them, so tests can check the order of the middleware stack
+// holds as it grows: that a request is authenticated before its quota is
+// counted, and both before its handler runs. It is for tests only, through
+// Config.CallTracer; every middleware it wraps costs a call more.
+type CallTracer struct {
+	mu     sync.Mutex
+	traces map[string][]Call // by request ID
+}
+
+// NewCallTracer returns an empty CallTracer.
+func NewCallTracer() *CallTracer {
+	return &CallTracer{traces: map[string][]Call{}}
+}
+
+// Calls returns the calls of the request with the X-Request-ID id, once it
+// has been served.
+func (t *CallTracer) Calls(id string) []Call {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.traces[id]
+}
+
+// callTrace collects the calls of one request.
+type callTrace struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+type callTraceKey struct{}
+
+// recordCall adds a call to the trace of the request ctx belongs to, if it
+// is traced.
+func recordCall(ctx context.Context, kind, name string) {
+	trace, ok := ctx.Value(callTraceKey{}).(*callTrace)
+	if !ok {
+		return
+	}
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	trace.calls = append(trace.calls, Call{Kind: kind, Name: name})
+}
+
+// begin is the outermost router middleware when tracing: it starts each
+// request's trace and keeps it under the request ID traceRequests gave it.
+func (t *CallTracer) begin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trace := &callTrace{}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), callTraceKey{}, trace)))
+		trace.mu.Lock()
+		defer trace.mu.Unlock()
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		// traceRequests sets the ID on the request's header, which the
+		// copies of r share.
+		t.traces[r.Header.Get(headerRequestID)] = trace.calls
+	})
+}
+
+// traceMiddlewares wraps router middlewares to record entering them.
+func traceMiddlewares(mws []func(http.Handler) http.Handler) []func(http.Handler) http.Handler {
+	traced := make([]func(http.Handler) http.Handler, len(mws))
+	for i, mw := range mws {
+		name := funcName(mw)
+		traced[i] = func(next http.Handler) http.Handler {
+			h := mw(next)
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				recordCall(r.Context(), CallMiddleware, name)
+				h.ServeHTTP(w, r)
+			})
+		}
+	}
+	return traced
+}
+
+// traceHumaMiddlewares wraps huma middlewares to record entering them, and
+// adds one recording the handler after them.
+func traceHumaMiddlewares(mws []func(huma.Context, func(huma.Context))) []func(huma.Context, func(huma.Context)) {
+	traced := make([]func(huma.Context, func(huma.Context)), 0, len(mws)+1)
+	for _, mw := range mws {
+		name := funcName(mw)
+		traced = append(traced, func(ctx huma.Context, next func(huma.Context)) {
+			recordCall(ctx.Context(), CallMiddleware, name)
+			mw(ctx, next)
+		})
+	}
+	return append(traced, func(ctx huma.Context, next func(huma.Context)) {
+		recordCall(ctx.Context(), CallHandler, ctx.Operation().OperationID)
+		next(ctx)
+	})
+}
+
+// closureSuffix is what the runtime appends to the names of closures and
+// method values.
+var closureSuffix = regexp.MustCompile(`(\.func\d+)+$|-fm$`)
+
+// funcName names fn after the function or method it is, or the function
+// that returned it: "authenticate" for s.authenticate, "instrument" for
+// instrument(s.metrics), and "sentryhttp.Handle" for a method of another
+// package's.
+func funcName(fn any) string {
+	name := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	name = closureSuffix.ReplaceAllString(name, "")
+	name = name[strings.LastIndex(name, "/")+1:]
+	pkg, name, _ := strings.Cut(name, ".")
+	if i := strings.LastIndex(name, ")."); i >= 0 {
+		name = name[i+2:]
+	}
+	if pkg != "server" {
+		name = pkg + "." + name
+	}
+	return name
+}
+
+// tracedStore records its calls in the request's trace.
+type tracedStore struct {
+	Store
+}
+
+// withTx records the transaction's calls like any others.
+func (t tracedStore) withTx(ctx context.Context, fn func(tx Store) error) error {
+	return WithTx(ctx, t.Store, func(tx Store) error { return fn(tracedStore{tx}) })
+}
+
+func (t tracedStore) GetUser(ctx context.Context, id string) (*User, error) {
+	recordCall(ctx, CallStore, "GetUser")
+	return t.Store.GetUser(ctx, id)
+}
+
+func (t tracedStore) ListUsers(ctx context.Context) ([]*User, error) {
+	recordCall(ctx, CallStore, "ListUsers")
+	return t.Store.ListUsers(ctx)
+}
+
+func (t tracedStore) PutUser(ctx context.Context, user *User) error {
+	recordCall(ctx, CallStore, "PutUser")
+	return t.Store.PutUser(ctx, user)
+}
+
+func (t tracedStore) DeleteUser(ctx context.Context, id string) error {
+	recordCall(ctx, CallStore, "DeleteUser")
+	return t.Store.DeleteUser(ctx, id)
+}
+
+func (t tracedStore) GetPreferences(ctx context.Context, userID string) (*UserPreferences, error) {
+	recordCall(ctx, CallStore, "GetPreferences")
+	return t.Store.GetPreferences(ctx, userID)
+}
+
+func (t tracedStore) PutPreferences(ctx context.Context, userID string, prefs *UserPreferences) error {
+	recordCall(ctx, CallStore, "PutPreferences")
+	return t.Store.PutPreferences(ctx, userID, prefs)
+}
+
+func (t tracedStore) GetCredentials(ctx context.Context, userID string) (*Credentials, error) {
+	recordCall(ctx, CallStore, "GetCredentials")
+	return t.Store.GetCredentials(ctx, userID)
+}
+
+func (t tracedStore) PutCredentials(ctx context.Context, userID string, creds *Credentials) error {
+	recordCall(ctx, CallStore, "PutCredentials")
+	return t.Store.PutCredentials(ctx, userID, creds)
+}
+
+func (t tracedStore) GetPost(ctx context.Context, id string) (*Post, error) {
+	recordCall(ctx, CallStore, "GetPost")
+	return t.Store.GetPost(ctx, id)
+}
+
+func (t tracedStore) ListPosts(ctx context.Context) ([]*Post, error) {
+	recordCall(ctx, CallStore, "ListPosts")
+	return t.Store.ListPosts(ctx)
+}
+
+func (t tracedStore) PutPost(ctx context.Context, post *Post) error {
+	recordCall(ctx, CallStore, "PutPost")
+	return t.Store.PutPost(ctx, post)
+}
+
+func (t tracedStore) DeletePost(ctx context.Context, id string) error {
+	recordCall(ctx, CallStore, "DeletePost")
+	return t.Store.DeletePost(ctx, id)
+}
+
+func (t tracedStore) GetComment(ctx context.Context, id string) (*Comment, error) {
+	recordCall(ctx, CallStore, "GetComment")
+	return t.Store.GetComment(ctx, id)
+}
+
+func (t tracedStore) ListComments(ctx context.Context, postIDs ...string) ([]*Comment, error) {
+	recordCall(ctx, CallStore, "ListComments")
+	return t.Store.ListComments(ctx, postIDs...)
+}
+
+func (t tracedStore) PutComment(ctx context.Context, comment *Comment) error {
+	recordCall(ctx, CallStore, "PutComment")
+	return t.Store.PutComment(ctx, comment)
+}
+
+func (t tracedStore) DeleteComment(ctx context.Context, id string) error {
+	recordCall(ctx, CallStore, "DeleteComment")
+	return t.Store.DeleteComment(ctx, id)
+}
+
+func (t tracedStore) GetInvitation(ctx context.Context, id string) (*StoredInvitation, error) {
+	recordCall(ctx, CallStore, "GetInvitation")
+	return t.Store.GetInvitation(ctx, id)
+}
+
+func (t tracedStore) ListInvitations(ctx context.Context) ([]*StoredInvitation, error) {
+	recordCall(ctx, CallStore, "ListInvitations")
+	return t.Store.ListInvitations(ctx)
+}
+
+func (t tracedStore) PutInvitation(ctx context.Context, inv *StoredInvitation) error {
+	recordCall(ctx, CallStore, "PutInvitation")
+	return t.Store.PutInvitation(ctx, inv)
+}
+
+func (t tracedStore) DeleteInvitation(ctx context.Context, id string) error {
+	recordCall(ctx, CallStore, "DeleteInvitation")
+	return t.Store.DeleteInvitation(ctx, id)
+}
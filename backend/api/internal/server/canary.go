@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+)
+
+// headerCanary, set to true on a request, sends it to Config.CanaryStore,
+// and set to false keeps it off it. Responses served from the canary store
+// carry it too.
+const headerCanary = "X-Canary"
+
+type canaryKey struct{}
+
+// isCanary reports whether the request ctx belongs to is served from the
+// canary store.
+func isCanary(ctx context.Context) bool {
+	canary, _ := ctx.Value(canaryKey{}).(bool)
+	return canary
+}
+
+// routeCanary picks, with Config.CanaryStore set, which requests the canary
+// store serves: those with X-Canary: true, and Config.CanaryPercent of those
+// without the header, at random. The admin API, /metrics and the probes
+// always go to the store. It runs before instrument, which counts canary
+// requests again in metrics of their own, to compare with the rest.
+func (s *Server) routeCanary(next http.Handler) http.Handler {
+	if s.cfg.CanaryStore == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if adminPath(r.URL.Path) || probePath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		var canary bool
+		switch r.Header.Get(headerCanary) {
+		case "true":
+			canary = true
+		case "false":
+		default:
+			canary = rand.Float64()*100 < s.cfg.CanaryPercent
+		}
+		if !canary {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set(headerCanary, "true")
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), canaryKey{}, true)))
+	})
+}
+
+// canaryStore sends each call to canary if its request is a canary one, and
+// to primary otherwise. The two are expected to hold the same data, the
+// canary being, say, a new backend the store is migrated to that the
+// migration keeps in sync; canary requests write to it alone.
+type canaryStore struct {
+	primary Store
+	canary  Store
+}
+
+func (c canaryStore) pick(ctx context.Context) Store {
+	if isCanary(ctx) {
+		return c.canary
+	}
+	return c.primary
+}
+
+// withTx runs the transaction on the store the request goes to.
+func (c canaryStore) withTx(ctx context.Context, fn func(tx Store) error) error {
+	return WithTx(ctx, c.pick(ctx), fn)
+}
+
+func (c canaryStore) GetUser(ctx context.Context, id string) (*User, error) {
+	return c.pick(ctx).GetUser(ctx, id)
+}
+
+func (c canaryStore) ListUsers(ctx context.Context) ([]*User, error) {
+	return c.pick(ctx).ListUsers(ctx)
+}
+
+func (c canaryStore) PutUser(ctx context.Context, user *User) error {
+	return c.pick(ctx).PutUser(ctx, user)
+}
+
+func (c canaryStore) DeleteUser(ctx context.Context, id string) error {
+	return c.pick(ctx).DeleteUser(ctx, id)
+}
+
+func (c canaryStore) GetPreferences(ctx context.Context, userID string) (*UserPreferences, error) {
+	return c.pick(ctx).GetPreferences(ctx, userID)
+}
+
+func (c canaryStore) PutPreferences(ctx context.Context, userID string, prefs *UserPreferences) error {
+	return c.pick(ctx).PutPreferences(ctx, userID, prefs)
+}
+
+func (c canaryStore) GetCredentials(ctx context.Context, userID string) (*Credentials, error) {
+	return c.pick(ctx).GetCredentials(ctx, userID)
+}
+
+func (c canaryStore) PutCredentials(ctx context.Context, userID string, creds *Credentials) error {
+	return c.pick(ctx).PutCredentials(ctx, userID, creds)
+}
+
+func (c canaryStore) GetPost(ctx context.Context, id string) (*Post, error) {
+	return c.pick(ctx).GetPost(ctx, id)
+}
+
+func (c canaryStore) ListPosts(ctx context.Context) ([]*Post, error) {
+	return c.pick(ctx).ListPosts(ctx)
+}
+
+func (c canaryStore) PutPost(ctx context.Context, post *Post) error {
+	return c.pick(ctx).PutPost(ctx, post)
+}
+
+func (c canaryStore) DeletePost(ctx context.Context, id string) error {
+	return c.pick(ctx).DeletePost(ctx, id)
+}
+
+func (c canaryStore) GetComment(ctx context.Context, id string) (*Comment, error) {
+	return c.pick(ctx).GetComment(ctx, id)
+}
+
+func (c canaryStore) ListComments(ctx context.Context, postIDs ...string) ([]*Comment, error) {
+	return c.pick(ctx).ListComments(ctx, postIDs...)
+}
+
+func (c canaryStore) PutComment(ctx context.Context, comment *Comment) error {
+	return c.pick(ctx).PutComment(ctx, comment)
+}
+
+func (c canaryStore) DeleteComment(ctx context.Context, id string) error {
+	return c.pick(ctx).DeleteComment(ctx, id)
+}
+
+func (c canaryStore) GetInvitation(ctx context.Context, id string) (*StoredInvitation, error) {
+	return c.pick(ctx).GetInvitation(ctx, id)
+}
+
+func (c canaryStore) ListInvitations(ctx context.Context) ([]*StoredInvitation, error) {
+	return c.pick(ctx).ListInvitations(ctx)
+}
+
+func (c canaryStore) PutInvitation(ctx context.Context, inv *StoredInvitation) error {
+	return c.pick(ctx).PutInvitation(ctx, inv)
+}
+
+func (c canaryStore) DeleteInvitation(ctx context.Context, id string) error {
+	return c.pick(ctx).DeleteInvitation(ctx, id)
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/captcha"
+)
+
+// CaptchaInput carries the CAPTCHA token of operations bots shouldn't be
+// able to call, checked with verifyCaptcha.
+type CaptchaInput struct {
+	CaptchaToken string `header:"X-Captcha-Token" redact:"true" doc:"Token from solving the site's CAPTCHA; required when the server has CAPTCHA_PROVIDER set"`
+	remoteIP     string
+}
+
+// Resolve records the client's address, which the provider also weighs.
+func (i *CaptchaInput) Resolve(ctx huma.Context) []error {
+	i.remoteIP = remoteHost(ctx.RemoteAddr())
+	return nil
+}
+
+// verifyCaptcha checks input's token with cfg.Captcha, if set. A missing or
+// rejected token is a 403; a provider that can't be asked is a 503, as
+// letting the request through would let bots in whenever it is down.
+func (s *Server) verifyCaptcha(ctx context.Context, input CaptchaInput) error {
+	if s.cfg.Captcha == nil {
+		return nil
+	}
+	err := s.cfg.Captcha.Verify(ctx, input.CaptchaToken, input.remoteIP)
+	switch {
+	case err == nil:
+		return nil
+	case input.CaptchaToken == "":
+		return apiError(http.StatusForbidden, CodeCaptchaFailed, "captcha token required")
+	case errors.Is(err, captcha.ErrRejected):
+		return apiError(http.StatusForbidden, CodeCaptchaFailed, "captcha verification failed")
+	default:
+		s.logger.ErrorContext(ctx, "captcha verification failed", "provider", s.cfg.CaptchaProvider, "err", err)
+		return apiError(http.StatusServiceUnavailable, CodeCaptchaUnavailable, "captcha verification is unavailable")
+	}
+}
@@ -0,0 +1,208 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/redact"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// defaultDevCaptureRequests is how many requests dev mode captures when
+// CAPTURE_REQUESTS isn't set.
+const defaultDevCaptureRequests = 100
+
+// CapturedRequest is a request and its response as captureRequests kept
+// them, redacted like the logs.
+type CapturedRequest struct {
+	ID              int64             `json:"id" doc:"Sequence number of the request since the server started"`
+	Time            timestamp.Time    `json:"time" doc:"When the request arrived"`
+	Method          string            `json:"method" example:"PATCH" doc:"HTTP method"`
+	Path            string            `json:"path" example:"/v1/users/20240101120000" doc:"Request path, without the query"`
+	Route           string            `json:"route,omitempty" example:"/v1/users/{userID}" doc:"The route that matched, if any"`
+	Params          string            `json:"params,omitempty" doc:"Route and query parameters, redacted"`
+	ClientIP        string            `json:"client_ip" example:"203.0.113.9" doc:"The client's address, as resolved through TRUSTED_PROXIES"`
+	Status          int               `json:"status" example:"422" doc:"Response status"`
+	DurationMS      float64           `json:"duration_ms" example:"3.25" doc:"How long the request took to serve, in milliseconds"`
+	RequestHeaders  map[string]string `json:"request_headers,omitempty" doc:"Request headers, with credentials redacted"`
+	RequestBody     string            `json:"request_body,omitempty" doc:"Request body, redacted and truncated to 4 KiB"`
+	ResponseHeaders map[string]string `json:"response_headers,omitempty" doc:"Response headers, with credentials redacted"`
+	ResponseBody    string            `json:"response_body,omitempty" doc:"Response body, redacted and truncated to 4 KiB"`
+}
+
+// requestCapture keeps the last requests in a ring buffer.
+type requestCapture struct {
+	size int
+
+	mu      sync.Mutex
+	entries []CapturedRequest
+	next    int // where the next entry goes
+	nextID  int64
+}
+
+func newRequestCapture(size int) *requestCapture {
+	return &requestCapture{size: size, entries: make([]CapturedRequest, 0, size), nextID: 1}
+}
+
+func (c *requestCapture) add(e CapturedRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e.ID = c.nextID
+	c.nextID++
+	if len(c.entries) < c.size {
+		c.entries = append(c.entries, e)
+	} else {
+		c.entries[c.next] = e
+	}
+	c.next = (c.next + 1) % c.size
+}
+
+// recent returns up to limit entries, newest first.
+func (c *requestCapture) recent(limit int) []CapturedRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := []CapturedRequest{}
+	for i := range min(limit, len(c.entries)) {
+		j := (c.next - 1 - i + 2*c.size) % c.size
+		out = append(out, c.entries[j])
+	}
+	return out
+}
+
+func (c *requestCapture) clear() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := len(c.entries)
+	c.entries, c.next = c.entries[:0], 0
+	return n
+}
+
+// captureRequests records every request in s.captured, but for the ones
+// that read it back and for health checks and metrics scrapes, which would
+// crowd out the rest.
+func (s *Server) captureRequests(next http.Handler) http.Handler {
+	if s.captured == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/admin/requests" || r.URL.Path == "/metrics" || probePath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+		var reqBody []byte
+		if r.Body != nil && r.Body != http.NoBody {
+			// Only what is kept is read ahead; the handler gets the rest
+			// as it comes.
+			reqBody, _ = io.ReadAll(io.LimitReader(r.Body, devBodyLogLimit+1))
+			r.Body = readCloser{io.MultiReader(bytes.NewReader(reqBody), r.Body), r.Body}
+		}
+		rec := &bodyRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		s.captured.add(CapturedRequest{
+			Time:            timestamp.From(start),
+			Method:          r.Method,
+			Path:            r.URL.Path,
+			Route:           routePattern(r),
+			Params:          requestParams(r),
+			ClientIP:        remoteHost(r.RemoteAddr),
+			Status:          rec.status,
+			DurationMS:      float64(time.Since(start).Microseconds()) / 1000,
+			RequestHeaders:  capturedHeaders(r.Header),
+			RequestBody:     truncateBody(redact.JSON(reqBody)),
+			ResponseHeaders: capturedHeaders(rec.Header()),
+			ResponseBody:    truncateBody(redact.JSON(rec.body.Bytes())),
+		})
+	})
+}
+
+// readCloser reads from Reader and closes Closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// capturedHeaders flattens h, redacting credentials and anything named like
+// a token.
+func capturedHeaders(h http.Header) map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(h))
+	for name, values := range h {
+		if redact.Key(name) || strings.Contains(strings.ToLower(name), "token") {
+			out[name] = redact.Redacted
+			continue
+		}
+		out[name] = strings.Join(values, ", ")
+	}
+	return out
+}
+
+type ListCapturedInput struct {
+	AdminInput
+	Limit  int    `query:"limit" minimum:"1" maximum:"1000" default:"50" doc:"How many requests to return, newest first"`
+	Status string `query:"status" enum:"all,errors" default:"all" doc:"errors keeps only 4xx and 5xx responses"`
+}
+
+type CapturedList struct {
+	Capacity int               `json:"capacity" doc:"How many requests the buffer keeps, CAPTURE_REQUESTS"`
+	Requests []CapturedRequest `json:"requests" doc:"The captured requests, newest first"`
+}
+
+type ListCapturedOutput struct {
+	Body *CapturedList
+}
+
+type ClearCapturedInput struct {
+	AdminInput
+}
+
+type ClearCapturedOutput struct {
+	Body struct {
+		Cleared int `json:"cleared" doc:"How many captured requests were dropped"`
+	}
+}
+
+// errCaptureOff is what /admin/requests answers when nothing is captured.
+var errCaptureOff = apiError(http.StatusNotFound, CodeNotFound, "request capture is off; set CAPTURE_REQUESTS")
+
+// listCaptured is the get-admin-requests handler.
+func (s *Server) listCaptured(ctx context.Context, input *ListCapturedInput) (*ListCapturedOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	if s.captured == nil {
+		return nil, errCaptureOff
+	}
+	all := s.captured.recent(s.captured.size)
+	out := &CapturedList{Capacity: s.captured.size, Requests: []CapturedRequest{}}
+	for _, e := range all {
+		if len(out.Requests) == input.Limit {
+			break
+		}
+		if input.Status == "errors" && e.Status < 400 {
+			continue
+		}
+		out.Requests = append(out.Requests, e)
+	}
+	return &ListCapturedOutput{Body: out}, nil
+}
+
+// clearCaptured is the delete-admin-requests handler.
+func (s *Server) clearCaptured(ctx context.Context, input *ClearCapturedInput) (*ClearCapturedOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	if s.captured == nil {
+		return nil, errCaptureOff
+	}
+	out := &ClearCapturedOutput{}
+	out.Body.Cleared = s.captured.clear()
+	return out, nil
+}
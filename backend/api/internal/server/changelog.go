@@ -0,0 +1,163 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// changelogJSON is the hand-written changelog. Each release lists the
+// operations the spec had when it was cut, so the operations added and
+// removed since the one before are found by diffing those lists, and the
+// unreleased ones by diffing the last list against the spec served now.
+//
+//go:embed changelog.json
+var changelogJSON []byte
+
+type changelogFile struct {
+	Unreleased []ChangelogChange `json:"unreleased"`
+	Releases   []struct {
+		ChangelogRelease
+		Operations []string `json:"operations"`
+	} `json:"releases"` // newest first
+}
+
+var changelogData = mustParseChangelog(changelogJSON)
+
+func mustParseChangelog(data []byte) changelogFile {
+	var f changelogFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		panic(fmt.Sprintf("server: parse changelog.json: %v", err))
+	}
+	return f
+}
+
+// unreleased is the version of the changes not released yet.
+const unreleased = "unreleased"
+
+// ChangelogChange is one change to the API.
+type ChangelogChange struct {
+	Kind        string `json:"kind" enum:"added,changed,deprecated,removed,fixed,security" doc:"What kind of change it is"`
+	Operation   string `json:"operation,omitempty" example:"get-v1-users" doc:"ID of the operation it changed, if it is about one"`
+	Description string `json:"description" doc:"What changed"`
+}
+
+// ChangelogRelease is the changes one release made.
+type ChangelogRelease struct {
+	Version string            `json:"version" example:"1.0.0" doc:"The release, or unreleased for the changes since the last one"`
+	Date    string            `json:"date,omitempty" format:"date" doc:"When it was released; omitted for the unreleased changes"`
+	Changes []ChangelogChange `json:"changes" doc:"Its changes: the changelog's, plus the operations added and removed that it doesn't mention"`
+}
+
+type ChangelogInput struct {
+	Since string `query:"since" example:"1.0.0" doc:"Return only the releases after this one, and the unreleased changes"`
+}
+
+type Changelog struct {
+	Releases []ChangelogRelease `json:"releases" doc:"Newest first; the unreleased changes come first, if there are any"`
+}
+
+type ChangelogOutput struct {
+	Body *Changelog
+}
+
+// buildChangelog merges f with the changes to the operations, current
+// mapping the IDs of those served now to their summaries.
+func buildChangelog(f changelogFile, current map[string]string) []ChangelogRelease {
+	// operations returns release i's operations, with their summaries if
+	// they are still served; nil past the first release.
+	operations := func(i int) map[string]string {
+		if i >= len(f.Releases) {
+			return nil
+		}
+		m := map[string]string{}
+		for _, op := range f.Releases[i].Operations {
+			m[op] = current[op]
+		}
+		return m
+	}
+	releases := []ChangelogRelease{}
+	if changes := withDiff(f.Unreleased, operations(0), current); len(changes) > 0 {
+		releases = append(releases, ChangelogRelease{Version: unreleased, Changes: changes})
+	}
+	for i, r := range f.Releases {
+		r.Changes = withDiff(r.Changes, operations(i+1), operations(i))
+		releases = append(releases, r.ChangelogRelease)
+	}
+	return releases
+}
+
+// withDiff returns logged followed by the changes between the operations
+// older and newer that it leaves out. With older nil, for the first
+// release, there is nothing to compare against.
+func withDiff(logged []ChangelogChange, older, newer map[string]string) []ChangelogChange {
+	changes := slices.Clone(logged)
+	if changes == nil {
+		changes = []ChangelogChange{}
+	}
+	if older == nil {
+		return changes
+	}
+	return append(changes, diffOperations(logged, older, newer)...)
+}
+
+// diffOperations returns the operations in newer but not older as added,
+// and the reverse as removed, leaving out the ones logged already.
+func diffOperations(logged []ChangelogChange, older, newer map[string]string) []ChangelogChange {
+	mentioned := func(kind, op string) bool {
+		return slices.ContainsFunc(logged, func(c ChangelogChange) bool { return c.Kind == kind && c.Operation == op })
+	}
+	var out []ChangelogChange
+	for _, op := range sortedKeys(newer) {
+		if _, ok := older[op]; !ok && !mentioned("added", op) {
+			out = append(out, ChangelogChange{Kind: "added", Operation: op, Description: cmp.Or(newer[op], "Added "+op+".")})
+		}
+	}
+	for _, op := range sortedKeys(older) {
+		if _, ok := newer[op]; !ok && !mentioned("removed", op) {
+			out = append(out, ChangelogChange{Kind: "removed", Operation: op, Description: "Removed " + op + "."})
+		}
+	}
+	return out
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// operationSummaries maps the ID of every operation in spec to its summary.
+func operationSummaries(spec *huma.OpenAPI) map[string]string {
+	out := map[string]string{}
+	for _, item := range spec.Paths {
+		for _, op := range []*huma.Operation{item.Get, item.Put, item.Post, item.Delete, item.Patch, item.Head} {
+			if op != nil {
+				out[op.OperationID] = op.Summary
+			}
+		}
+	}
+	return out
+}
+
+// getChangelog is the get-v1-changelog handler.
+func (s *Server) getChangelog(ctx context.Context, input *ChangelogInput) (*ChangelogOutput, error) {
+	releases := buildChangelog(changelogData, operationSummaries(s.api.OpenAPI()))
+	if input.Since != "" {
+		i := slices.IndexFunc(releases, func(r ChangelogRelease) bool { return r.Version == input.Since && r.Version != unreleased })
+		if i < 0 {
+			return nil, apiError(http.StatusNotFound, CodeNotFound, "no release "+input.Since)
+		}
+		releases = releases[:i]
+	}
+	return &ChangelogOutput{Body: &Changelog{Releases: releases}}, nil
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildChangelogDiffsOperations(t *testing.T) {
+	f := mustParseChangelog([]byte(`{
+		"unreleased": [{"kind": "added", "operation": "get-c", "description": "Get a C."}],
+		"releases": [
+			{"version": "1.1.0", "date": "2024-02-01", "changes": [], "operations": ["get-a", "get-b"]},
+			{"version": "1.0.0", "date": "2024-01-01", "changes": [{"kind": "added", "description": "First release."}], "operations": ["get-a", "get-old"]}
+		]
+	}`))
+	got := buildChangelog(f, map[string]string{"get-a": "Get an A", "get-c": "Get a C", "get-d": "Get a D"})
+
+	want := []ChangelogRelease{
+		{Version: unreleased, Changes: []ChangelogChange{
+			{Kind: "added", Operation: "get-c", Description: "Get a C."},
+			{Kind: "added", Operation: "get-d", Description: "Get a D"},
+			{Kind: "removed", Operation: "get-b", Description: "Removed get-b."},
+		}},
+		{Version: "1.1.0", Date: "2024-02-01", Changes: []ChangelogChange{
+			{Kind: "added", Operation: "get-b", Description: "Added get-b."},
+			{Kind: "removed", Operation: "get-old", Description: "Removed get-old."},
+		}},
+		{Version: "1.0.0", Date: "2024-01-01", Changes: []ChangelogChange{
+			{Kind: "added", Description: "First release."},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildChangelog() =\n%+v\nwant\n%+v", got, want)
+	}
+}
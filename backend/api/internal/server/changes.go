@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humachi"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// maxChangesWait bounds how long get-v1-users-changes holds a request open.
+const maxChangesWait = 30 * time.Second
+
+// changeEvents are the audit entries the change feed reports: the events
+// that change what the users endpoints return. Activity, impersonation and
+// data exports don't.
+var changeEvents = map[string]bool{
+	"user.created":     true,
+	"user.updated":     true,
+	"user.invited":     true,
+	"user.activated":   true,
+	"user.deactivated": true,
+	"user.deleted":     true,
+	"user.purged":      true,
+	"user.erased":      true,
+}
+
+// UserChange is one entry of the change feed. It says which user changed
+// and how, not what they look like now; clients fetch the user for that.
+type UserChange struct {
+	Seq    int64          `json:"seq" example:"42" doc:"Sequence number of the change; the same as the audit entry's ID"`
+	Time   timestamp.Time `json:"time" doc:"When the change happened"`
+	Type   string         `json:"type" example:"user.updated" doc:"What happened"`
+	UserID string         `json:"user_id" example:"20240101120000" doc:"ID of the user, or an erased-… placeholder once the user is erased"`
+	// CorrelationID lets a client tell its own changes from others'.
+	CorrelationID string `json:"correlation_id,omitempty" doc:"Correlation ID of the request that made the change, the X-Correlation-ID it was sent with or else its request ID"`
+}
+
+type UserChangesInput struct {
+	Since int64  `query:"since" minimum:"-1" default:"-1" doc:"Sequence number of the last change seen; only later ones are returned. -1, the default, starts from the latest change without returning it"`
+	Wait  string `query:"wait" example:"30s" doc:"How long to wait, up to 30s, for a change when there is none yet. Without it the request returns at once"`
+	Limit int    `query:"limit" minimum:"1" maximum:"1000" default:"100" doc:"Maximum number of changes to return"`
+
+	wait time.Duration
+}
+
+// Resolve parses wait, which the schema can only take as a string.
+func (i *UserChangesInput) Resolve(ctx huma.Context) []error {
+	if i.Wait == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(i.Wait)
+	if err != nil || d < 0 || d > maxChangesWait {
+		return []error{&ErrorDetail{
+			Location: "query.wait",
+			Code:     "format",
+			Message:  "expected a duration up to 30s, like 20s",
+			Value:    i.Wait,
+		}}
+	}
+	i.wait = d
+	return nil
+}
+
+type UserChanges struct {
+	Changes   []UserChange `json:"changes" doc:"The changes after since, oldest first; empty if the wait ran out first"`
+	NextSince int64        `json:"next_since" example:"42" doc:"What to pass as since to get the changes after these"`
+}
+
+type UserChangesOutput struct {
+	Body *UserChanges
+}
+
+// errChangesExpired is what the change feed answers when it can't return
+// every change after since.
+var errChangesExpired = apiError(http.StatusGone, CodeChangesExpired, "the changes after since are no longer kept; list the users again and resume with since=-1")
+
+// changesAfter returns up to limit changes after seq, oldest first, and the
+// sequence number to resume from. oldest is the ID of the first entry still
+// kept and latest that of the last one recorded; changed is closed on the
+// next entry, so a caller that finds nothing can wait on it without missing
+// one.
+func (a *AuditLog) changesAfter(seq int64, limit int) (out []UserChange, next, oldest, latest int64, changed <-chan struct{}) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	latest, oldest = a.nextID-1, a.nextID
+	if len(a.entries) > 0 {
+		oldest = a.entries[0].ID
+	}
+	out, next = []UserChange{}, latest
+	for _, e := range a.entries {
+		if e.ID <= seq || !changeEvents[e.Type] {
+			continue
+		}
+		if len(out) == limit {
+			next = out[len(out)-1].Seq
+			break
+		}
+		out = append(out, UserChange{Seq: e.ID, Time: e.Time, Type: e.Type, UserID: e.Subject, CorrelationID: e.CorrelationID})
+	}
+	return out, next, oldest, latest, a.changed
+}
+
+// userChanges is the get-v1-users-changes handler. It answers as soon as
+// there are changes after since, or once the wait runs out or the server
+// begins shutting down, whichever comes first.
+func (s *Server) userChanges(ctx context.Context, input *UserChangesInput) (*UserChangesOutput, error) {
+	start := time.Now()
+	timer := time.NewTimer(input.wait)
+	defer timer.Stop()
+	defer func() {
+		if t := timingFrom(ctx); t != nil {
+			t.waited.Add(int64(time.Since(start)))
+		}
+	}()
+	since := input.Since
+	for {
+		changes, next, oldest, latest, changed := s.audit.changesAfter(since, input.Limit)
+		if since == -1 {
+			since, changes, next = latest, changes[:0], latest
+		}
+		if since > latest || since+1 < oldest {
+			// Entries were pruned, or since is from before a restart.
+			return nil, errChangesExpired
+		}
+		if len(changes) > 0 {
+			return &UserChangesOutput{Body: &UserChanges{Changes: changes, NextSince: next}}, nil
+		}
+		select {
+		case <-changed:
+			continue
+		case <-timer.C:
+		case <-s.stopping:
+		case <-ctx.Done():
+		}
+		return &UserChangesOutput{Body: &UserChanges{Changes: changes, NextSince: next}}, nil
+	}
+}
+
+// holdOpen extends the write deadline of a long poll past the servers'
+// WriteTimeout, which is shorter than the longest wait. Writers that can't
+// have it extended, like httptest's, don't need it either. It also gives up
+// the request's load shedding slot, which waiting doesn't need.
+func holdOpen(ctx huma.Context, next func(huma.Context)) {
+	releaseLoadSlot(ctx.Context())
+	_, w := humachi.Unwrap(ctx)
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(maxChangesWait + 10*time.Second))
+	next(ctx)
+}
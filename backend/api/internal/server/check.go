@@ -0,0 +1,310 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/jwks"
+)
+
+// CheckStatus is the outcome of one of Check's checks.
+type CheckStatus string
+
+const (
+	CheckOK      CheckStatus = "ok"
+	CheckWarning CheckStatus = "warning" // works for now, but needs attention
+	CheckFailed  CheckStatus = "failed"
+	CheckSkipped CheckStatus = "skipped" // not configured
+)
+
+// certExpiryWarning is how close to expiry the TLS certificate gets a
+// warning.
+const certExpiryWarning = 14 * 24 * time.Hour
+
+// CheckResult is what one of Check's checks found.
+type CheckResult struct {
+	Name     string        `json:"name"`
+	Status   CheckStatus   `json:"status"`
+	Detail   string        `json:"detail,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// pinger is implemented by the clients of dependencies that can tell
+// whether they are reachable without doing anything: lock.Redis,
+// email.SMTP and search.Elasticsearch.
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// check is one of Check's checks. It returns CheckSkipped, with why, when
+// what it checks isn't configured.
+type check struct {
+	name string
+	run  func(ctx context.Context, cfg Config) (CheckStatus, string, error)
+}
+
+var checks = []check{
+	{"tls", checkTLS},
+	{"store", checkStore},
+	{"raft", checkRaft},
+	{"search", checkSearch},
+	{"locks", checkLocks},
+	{"email", checkEmail},
+	{"encryption", checkEncryption},
+	{"jwks", checkJWKS},
+	{"security_events", checkSecurityEvents},
+	{"openapi", checkSpec},
+}
+
+// Check connects to every dependency cfg configures and validates what the
+// server would load at startup, without starting it or changing anything:
+// the TLS certificate, the store's snapshot and write-ahead log, the raft
+// peers, the search index, Redis, the SMTP relay, the PII keys, the JWKS of
+// the JWT issuers, the security event sink and the committed OpenAPI spec.
+// The checks run concurrently until ctx is done; the results are in a fixed
+// order.
+func Check(ctx context.Context, cfg Config) []CheckResult {
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			status, detail, err := c.run(ctx, cfg)
+			if err != nil {
+				status, detail = CheckFailed, err.Error()
+			}
+			results[i] = CheckResult{Name: c.name, Status: status, Detail: detail, Duration: time.Since(start)}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// checkTLS loads the certificate https listeners serve.
+func checkTLS(_ context.Context, cfg Config) (CheckStatus, string, error) {
+	if cfg.TLSCertFile == "" || cfg.TLSKeyFile == "" {
+		return CheckSkipped, "TLS_CERT_FILE and TLS_KEY_FILE are not set", nil
+	}
+	pair, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return "", "", err
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return "", "", err
+	}
+	left := time.Until(leaf.NotAfter)
+	switch {
+	case left <= 0:
+		return "", "", fmt.Errorf("the certificate expired on %s", leaf.NotAfter.Format(time.DateOnly))
+	case left < certExpiryWarning:
+		return CheckWarning, fmt.Sprintf("the certificate expires on %s", leaf.NotAfter.Format(time.DateOnly)), nil
+	}
+	return CheckOK, fmt.Sprintf("valid until %s", leaf.NotAfter.Format(time.DateOnly)), nil
+}
+
+// checkStore restores the snapshot and replays the write-ahead log into a
+// store of its own, as New would, so a snapshot of an unsupported version or
+// a corrupt log fails here rather than on startup, and checks their
+// directories can be written to.
+func checkStore(_ context.Context, cfg Config) (CheckStatus, string, error) {
+	if cfg.RaftNodeID != "" {
+		return CheckSkipped, "the raft replicas hold the store", nil
+	}
+	if cfg.StoreSnapshotPath == "" {
+		return CheckSkipped, "STORE_SNAPSHOT_PATH is not set; the store is in memory", nil
+	}
+	m := NewMemoryStore()
+	if err := m.LoadSnapshot(cfg.StoreSnapshotPath); err != nil {
+		return "", "", fmt.Errorf("load snapshot: %w", err)
+	}
+	dirs := []string{filepath.Dir(cfg.StoreSnapshotPath)}
+	if cfg.StoreWALPath != "" {
+		m.mu.Lock()
+		for _, p := range []string{compactingPath(cfg.StoreWALPath), cfg.StoreWALPath} {
+			if _, err := m.replayWAL(p); err != nil {
+				m.mu.Unlock()
+				return "", "", fmt.Errorf("replay %s: %w", p, err)
+			}
+		}
+		m.mu.Unlock()
+		dirs = append(dirs, filepath.Dir(cfg.StoreWALPath))
+	}
+	for _, dir := range dirs {
+		if err := checkWritable(dir); err != nil {
+			return "", "", err
+		}
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return CheckOK, fmt.Sprintf("%d users, %d posts, %d comments", len(m.users), len(m.posts), len(m.comments)), nil
+}
+
+// checkWritable reports whether files can be created in dir.
+func checkWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".check-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", dir, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// checkRaft dials the other replicas' raft transports. Those that don't
+// answer are only a warning: a cluster forms as its replicas come up.
+func checkRaft(ctx context.Context, cfg Config) (CheckStatus, string, error) {
+	if cfg.RaftNodeID == "" {
+		return CheckSkipped, "RAFT_NODE_ID is not set", nil
+	}
+	var down []error
+	for _, p := range cfg.RaftPeers {
+		if p.ID == cfg.RaftNodeID {
+			continue
+		}
+		if err := dial(ctx, p.Addr); err != nil {
+			down = append(down, fmt.Errorf("%s: %w", p.ID, err))
+		}
+	}
+	if len(down) > 0 {
+		return CheckWarning, errors.Join(down...).Error(), nil
+	}
+	return CheckOK, fmt.Sprintf("%d peers answer", len(cfg.RaftPeers)-1), nil
+}
+
+// dial reports whether addr accepts TCP connections.
+func dial(ctx context.Context, addr string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func checkSearch(ctx context.Context, cfg Config) (CheckStatus, string, error) {
+	switch {
+	case cfg.Search != nil:
+		return ping(ctx, cfg.Search)
+	case cfg.SearchIndexPath != "":
+		// Bleve creates it on first start.
+		dir := cfg.SearchIndexPath
+		if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+			dir = filepath.Dir(dir)
+		}
+		if err := checkWritable(dir); err != nil {
+			return "", "", err
+		}
+		return CheckOK, "Bleve index in " + cfg.SearchIndexPath, nil
+	}
+	return CheckSkipped, "the index is in memory", nil
+}
+
+func checkLocks(ctx context.Context, cfg Config) (CheckStatus, string, error) {
+	if cfg.Locker == nil {
+		return CheckSkipped, "REDIS_URLS is not set; locks are held within the replica", nil
+	}
+	return ping(ctx, cfg.Locker)
+}
+
+func checkEmail(ctx context.Context, cfg Config) (CheckStatus, string, error) {
+	if cfg.Mailer == nil {
+		return CheckSkipped, "SMTP_ADDR is not set; emails are only logged", nil
+	}
+	return ping(ctx, cfg.Mailer)
+}
+
+// ping pings dep, if it can be.
+func ping(ctx context.Context, dep any) (CheckStatus, string, error) {
+	p, ok := dep.(pinger)
+	if !ok {
+		return CheckOK, "nothing to connect to", nil
+	}
+	if err := p.Ping(ctx); err != nil {
+		return "", "", err
+	}
+	return CheckOK, "", nil
+}
+
+// checkEncryption encrypts and decrypts a value with the PII keys, which
+// with KMS keys has KMS wrap and unwrap a data key.
+func checkEncryption(ctx context.Context, cfg Config) (CheckStatus, string, error) {
+	if cfg.PIIKeys == nil {
+		return CheckSkipped, "PII_ENCRYPTION_KEYS and PII_KMS_KEY_IDS are not set", nil
+	}
+	const probe = "check@example.com"
+	enc, err := cfg.PIIKeys.Encrypt(ctx, probe)
+	if err != nil {
+		return "", "", err
+	}
+	dec, err := cfg.PIIKeys.Decrypt(ctx, enc)
+	if err != nil {
+		return "", "", err
+	}
+	if dec != probe {
+		return "", "", errors.New("decrypting doesn't give back what was encrypted")
+	}
+	return CheckOK, "primary key " + cfg.PIIKeys.PrimaryID(), nil
+}
+
+func checkJWKS(ctx context.Context, cfg Config) (CheckStatus, string, error) {
+	if len(cfg.JWTIssuers) == 0 {
+		return CheckSkipped, "JWT_ISSUERS is not set", nil
+	}
+	if err := jwks.New(cfg.JWTIssuers).Refresh(ctx); err != nil {
+		return "", "", err
+	}
+	return CheckOK, fmt.Sprintf("fetched the keys of %d issuers", len(cfg.JWTIssuers)), nil
+}
+
+// checkSecurityEvents dials the webhook the security events go to. Sending
+// an event would be the only real test, and not one to run on every deploy.
+func checkSecurityEvents(ctx context.Context, cfg Config) (CheckStatus, string, error) {
+	if cfg.SecurityEvents == nil {
+		return CheckSkipped, "SECURITY_EVENTS is not set", nil
+	}
+	sink, ok := cfg.SecurityEvents.(*webhookSink)
+	if !ok {
+		return CheckOK, cfg.SecurityEventsTarget, nil
+	}
+	u, err := url.Parse(sink.url)
+	if err != nil {
+		return "", "", err
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+	if err := dial(ctx, net.JoinHostPort(u.Hostname(), port)); err != nil {
+		return "", "", err
+	}
+	return CheckOK, u.Host + " answers", nil
+}
+
+// checkSpec verifies the committed spec at cfg.OpenAPIPath, as `api
+// verify:openapi` does, if there is one: deployed images usually don't
+// carry it.
+func checkSpec(_ context.Context, cfg Config) (CheckStatus, string, error) {
+	if _, err := os.Stat(checksumsPath(cfg.OpenAPIPath)); errors.Is(err, fs.ErrNotExist) {
+		return CheckSkipped, "no contract at " + cfg.OpenAPIPath, nil
+	}
+	srv := NewServer(Config{}, NewMemoryStore())
+	if err := srv.VerifySpec(cfg.OpenAPIPath, nil); err != nil {
+		return "", "", err
+	}
+	return CheckOK, cfg.OpenAPIPath + " is up to date", nil
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/fieldcrypt"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/jwks"
+)
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	snapshotPath := filepath.Join(dir, "store.json")
+	good := NewMemoryStore()
+	good.PutUser(ctx, &User{ID: "u1", Email: "ada@example.com"})
+	if err := good.SaveSnapshot(snapshotPath); err != nil {
+		t.Fatal(err)
+	}
+	signer, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	jwk := fmt.Sprintf(`{"keys":[{"kty":"EC","crv":"P-256","kid":"k1","x":%q,"y":%q}]}`,
+		base64.RawURLEncoding.EncodeToString(signer.X.FillBytes(make([]byte, 32))),
+		base64.RawURLEncoding.EncodeToString(signer.Y.FillBytes(make([]byte, 32))))
+	jwksSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(jwk))
+	}))
+	defer jwksSrv.Close()
+	kek, _ := fieldcrypt.LocalKey("k1", bytes.Repeat([]byte{1}, 32))
+	keys, _ := fieldcrypt.NewKeyring(kek)
+
+	status := func(results []CheckResult) map[string]CheckStatus {
+		got := map[string]CheckStatus{}
+		for _, r := range results {
+			got[r.Name] = r.Status
+		}
+		return got
+	}
+	cfg := Config{
+		StoreSnapshotPath: snapshotPath,
+		StoreWALPath:      filepath.Join(dir, "store.wal"),
+		PIIKeys:           keys,
+		JWTIssuers:        []jwks.Issuer{{URL: "https://issuer.example.com", JWKSURL: jwksSrv.URL}},
+		OpenAPIPath:       filepath.Join(dir, "v1.json"),
+	}
+	results := Check(ctx, cfg)
+	if len(results) != len(checks) {
+		t.Fatalf("got %d results, want %d", len(results), len(checks))
+	}
+	want := map[string]CheckStatus{
+		"tls": CheckSkipped, "store": CheckOK, "raft": CheckSkipped, "search": CheckSkipped,
+		"locks": CheckSkipped, "email": CheckSkipped, "encryption": CheckOK, "jwks": CheckOK,
+		"security_events": CheckSkipped, "openapi": CheckSkipped,
+	}
+	if got := status(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("statuses = %v, want %v\n%+v", got, want, results)
+	}
+	if got := results[1].Detail; got != "1 users, 0 posts, 0 comments" {
+		t.Errorf("store detail = %q", got)
+	}
+
+	os.WriteFile(snapshotPath, []byte(`{"version":99}`), 0o600)
+	jwksSrv.Close()
+	got := status(Check(ctx, cfg))
+	if got["store"] != CheckFailed || got["jwks"] != CheckFailed {
+		t.Errorf("with a future snapshot and no JWKS: %v", got)
+	}
+}
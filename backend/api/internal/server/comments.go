@@ -0,0 +1,327 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/idgen"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// CommentStatus is where a comment stands in moderation.
+type CommentStatus string
+
+const (
+	CommentPublished CommentStatus = "published"
+	CommentHidden    CommentStatus = "hidden"
+)
+
+// Comment is a user's comment on a post, or with ParentID a reply to
+// another comment on the same post. Deleting a comment deletes the replies
+// to it, and theirs; deleting the post or the author for good deletes it.
+type Comment struct {
+	ID        string         `json:"id" example:"20240101121000" doc:"Comment ID"`
+	PostID    string         `json:"post_id" example:"20240101120500" doc:"ID of the post the comment is on"`
+	AuthorID  string         `json:"author_id" example:"20240101120000" doc:"ID of the user who wrote the comment"`
+	ParentID  string         `json:"parent_id,omitempty" example:"20240101120900" doc:"ID of the comment this one replies to; absent for a top-level comment"`
+	Body      string         `json:"body" example:"Nice post!" doc:"Text of the comment"`
+	Status    CommentStatus  `json:"status" enum:"published,hidden" doc:"Moderation status; hidden comments are left out of listings unless asked for"`
+	CreatedAt timestamp.Time `json:"created_at" readOnly:"true" doc:"When the comment was written"`
+	UpdatedAt timestamp.Time `json:"updated_at" readOnly:"true" doc:"When the comment was last changed"`
+
+	ReplyCount *int `json:"reply_count,omitempty" readOnly:"true" doc:"Number of direct replies, as the listing would show them"`
+}
+
+type CreateCommentRequest struct {
+	AuthorID string `json:"author_id" minLength:"1" example:"20240101120000" doc:"ID of the user writing the comment"`
+	ParentID string `json:"parent_id,omitempty" example:"20240101120900" doc:"ID of the comment on the same post to reply to"`
+	Body     string `json:"body" minLength:"1" maxLength:"5000" example:"Nice post!" doc:"Text of the comment"`
+}
+
+type UpdateCommentRequest struct {
+	Body string `json:"body" minLength:"1" maxLength:"5000" example:"Nice post, thanks!" doc:"Text of the comment"`
+}
+
+type CommentStatusRequest struct {
+	Status CommentStatus `json:"status" enum:"published,hidden" doc:"Status to move the comment to"`
+}
+
+type ListCommentsInput struct {
+	KeysetPageInput
+	PostID        string `path:"id" example:"20240101120500" doc:"Post ID"`
+	ParentID      string `query:"parent_id" example:"20240101120900" doc:"List the replies to this comment instead of the top-level comments"`
+	IncludeHidden bool   `query:"include_hidden" doc:"Also list, and count as replies, comments hidden by moderation"`
+}
+
+type CreateCommentInput struct {
+	PostID string `path:"id" example:"20240101120500" doc:"Post ID"`
+	Body   CreateCommentRequest
+}
+
+type CommentIDInput struct {
+	PostID string `path:"id" example:"20240101120500" doc:"Post ID"`
+	ID     string `path:"commentID" example:"20240101121000" doc:"Comment ID"`
+}
+
+type UpdateCommentInput struct {
+	PostID string `path:"id" example:"20240101120500" doc:"Post ID"`
+	ID     string `path:"commentID" example:"20240101121000" doc:"Comment ID"`
+	Body   UpdateCommentRequest
+}
+
+type CommentStatusInput struct {
+	AdminInput
+	PostID string `path:"id" example:"20240101120500" doc:"Post ID"`
+	ID     string `path:"commentID" example:"20240101121000" doc:"Comment ID"`
+	Body   CommentStatusRequest
+}
+
+type CommentOutput struct {
+	Body *Comment
+}
+
+type CommentsListResponse struct {
+	Comments []*Comment `json:"comments" doc:"This page of comments, oldest first"`
+	Pagination
+}
+
+type CommentsListOutput struct {
+	TotalCount int    `header:"X-Total-Count" doc:"Number of comments matching the filters, across all pages"`
+	Link       string `header:"Link" doc:"RFC 8288 links to the first, prev, next and last pages"`
+	Body       *CommentsListResponse
+}
+
+// CommentService holds the rules for comments: a comment is on an existing
+// post by an existing user, and a reply is to a comment on the same post.
+// Like posts', changes publish events with the comment's author as the
+// subject: comment.created, comment.updated, comment.moderated and
+// comment.deleted.
+type CommentService struct {
+	store Store
+	bus   *events.Bus
+	idGen idgen.Generator
+}
+
+func NewCommentService(store Store, bus *events.Bus, idGen idgen.Generator) *CommentService {
+	return &CommentService{store: store, bus: bus, idGen: idGen}
+}
+
+var errCommentNotFound = apiError(http.StatusNotFound, CodeCommentNotFound, "Comment not found")
+
+// comments returns every comment on the post, turning a missing post into a
+// 404.
+func (c *CommentService) comments(ctx context.Context, postID string) ([]*Comment, error) {
+	_, err := c.store.GetPost(ctx, postID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, errPostNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.store.ListComments(ctx, postID)
+}
+
+// findComment returns the comment with id among comments.
+func findComment(comments []*Comment, id string) (*Comment, error) {
+	for _, comment := range comments {
+		if comment.ID == id {
+			return comment, nil
+		}
+	}
+	return nil, errCommentNotFound
+}
+
+// countReplies sets the ReplyCount of each of out from all, the comments on
+// their post, counting hidden replies only with includeHidden.
+func countReplies(out, all []*Comment, includeHidden bool) {
+	counts := map[string]int{}
+	for _, comment := range all {
+		if comment.ParentID != "" && (includeHidden || comment.Status == CommentPublished) {
+			counts[comment.ParentID]++
+		}
+	}
+	for _, comment := range out {
+		n := counts[comment.ID]
+		comment.ReplyCount = &n
+	}
+}
+
+// Get loads a comment on the post, with its published replies counted.
+func (c *CommentService) Get(ctx context.Context, postID, id string) (*Comment, error) {
+	all, err := c.comments(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	comment, err := findComment(all, id)
+	if err != nil {
+		return nil, err
+	}
+	countReplies([]*Comment{comment}, all, false)
+	return comment, nil
+}
+
+// List returns the top-level comments on the post, or with parentID the
+// replies to that comment, oldest first.
+func (c *CommentService) List(ctx context.Context, postID, parentID string, includeHidden bool) ([]*Comment, error) {
+	all, err := c.comments(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if parentID != "" {
+		if _, err := findComment(all, parentID); err != nil {
+			return nil, err
+		}
+	}
+	out := []*Comment{}
+	for _, comment := range all {
+		if comment.ParentID == parentID && (includeHidden || comment.Status == CommentPublished) {
+			out = append(out, comment)
+		}
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].ID < out[b].ID })
+	countReplies(out, all, includeHidden)
+	return out, nil
+}
+
+// Create adds a published comment on the post.
+func (c *CommentService) Create(ctx context.Context, postID string, req CreateCommentRequest) (*Comment, error) {
+	all, err := c.comments(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorExists(ctx, c.store, req.AuthorID); err != nil {
+		return nil, err
+	}
+	if req.ParentID != "" {
+		if _, err := findComment(all, req.ParentID); err != nil {
+			return nil, newError(http.StatusUnprocessableEntity, "validation failed", &ErrorDetail{
+				Location: "body.parent_id",
+				Code:     "invalid",
+				Message:  "expected the ID of a comment on this post",
+				Value:    req.ParentID,
+			})
+		}
+	}
+	// Comment IDs are unique across posts, so every comment counts.
+	taken, err := c.commentIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	comment := &Comment{
+		ID:        newID(c.idGen, taken, now),
+		PostID:    postID,
+		AuthorID:  req.AuthorID,
+		ParentID:  req.ParentID,
+		Body:      req.Body,
+		Status:    CommentPublished,
+		CreatedAt: timestamp.From(now),
+		UpdatedAt: timestamp.From(now),
+	}
+	if err := c.put(ctx, comment); err != nil {
+		return nil, err
+	}
+	c.publish(ctx, "comment.created", comment, nil)
+	zero := 0
+	comment.ReplyCount = &zero
+	return comment, nil
+}
+
+func (c *CommentService) commentIDs(ctx context.Context) (map[string]bool, error) {
+	posts, err := c.store.ListPosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := c.store.ListComments(ctx, postIDs(posts)...)
+	if err != nil {
+		return nil, err
+	}
+	taken := map[string]bool{}
+	for _, comment := range comments {
+		taken[comment.ID] = true
+	}
+	return taken, nil
+}
+
+// Update replaces the comment's body.
+func (c *CommentService) Update(ctx context.Context, postID, id string, req UpdateCommentRequest) (*Comment, error) {
+	return c.change(ctx, postID, id, "comment.updated", func(comment *Comment) map[string]any {
+		comment.Body = req.Body
+		return nil
+	})
+}
+
+// SetStatus moves the comment to status. Moving it to the status it already
+// has is a no-op.
+func (c *CommentService) SetStatus(ctx context.Context, postID, id string, status CommentStatus) (*Comment, error) {
+	comment, err := c.Get(ctx, postID, id)
+	if err != nil || comment.Status == status {
+		return comment, err
+	}
+	return c.change(ctx, postID, id, "comment.moderated", func(comment *Comment) map[string]any {
+		from := comment.Status
+		comment.Status = status
+		return map[string]any{"from": from, "to": status}
+	})
+}
+
+// change applies fn to the comment, saves it and publishes typ with what fn
+// returns.
+func (c *CommentService) change(ctx context.Context, postID, id, typ string, fn func(*Comment) map[string]any) (*Comment, error) {
+	comment, err := c.Get(ctx, postID, id)
+	if err != nil {
+		return nil, err
+	}
+	replies := comment.ReplyCount
+	comment.ReplyCount = nil
+	data := fn(comment)
+	comment.UpdatedAt = timestamp.Now()
+	if err := c.put(ctx, comment); err != nil {
+		return nil, err
+	}
+	c.publish(ctx, typ, comment, data)
+	comment.ReplyCount = replies
+	return comment, nil
+}
+
+// put saves comment, turning a post or author deleted in the meantime into
+// a 404.
+func (c *CommentService) put(ctx context.Context, comment *Comment) error {
+	err := c.store.PutComment(ctx, comment)
+	if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if _, err := c.store.GetPost(ctx, comment.PostID); err != nil {
+		return errPostNotFound
+	}
+	return apiError(http.StatusNotFound, CodeUserNotFound, "User not found")
+}
+
+// Delete removes the comment and the replies under it.
+func (c *CommentService) Delete(ctx context.Context, postID, id string) error {
+	comment, err := c.Get(ctx, postID, id)
+	if err != nil {
+		return err
+	}
+	err = c.store.DeleteComment(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return errCommentNotFound
+	}
+	if err != nil {
+		return err
+	}
+	c.publish(ctx, "comment.deleted", comment, nil)
+	return nil
+}
+
+func (c *CommentService) publish(ctx context.Context, typ string, comment *Comment, data map[string]any) {
+	if data == nil {
+		data = map[string]any{}
+	}
+	data["post_id"], data["comment_id"] = comment.PostID, comment.ID
+	c.bus.Publish(ctx, events.Event{Type: typ, Subject: comment.AuthorID, Data: data})
+}
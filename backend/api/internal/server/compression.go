@@ -0,0 +1,95 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// gzipBodiesKey is the Metadata key marking operations that take gzipped
+// bodies.
+const gzipBodiesKey = "gzipBodies"
+
+// gzipBodies is the Metadata of an operation that accepts its body sent
+// with Content-Encoding: gzip, for the imports and bulk operations whose
+// bodies are big. MaxBodyBytes bounds the body once inflated.
+func gzipBodies() map[string]any {
+	return map[string]any{gzipBodiesKey: true}
+}
+
+// errInflatedTooLarge is what a gunzip reader fails with past its limit.
+var errInflatedTooLarge = errors.New("decompressed data is over the limit")
+
+// gunzip returns a reader inflating the gzip stream r that fails with
+// errInflatedTooLarge rather than yield more than limit bytes, so a small
+// upload can't inflate into gigabytes.
+func gunzip(r io.Reader, limit int64) (io.ReadCloser, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return &cappedReader{Reader: zr, left: limit}, nil
+}
+
+type cappedReader struct {
+	*gzip.Reader
+	left int64 // how many more bytes it may yield
+}
+
+func (c *cappedReader) Read(p []byte) (int, error) {
+	// Reading one byte past the limit tells data of exactly the limit from
+	// longer data.
+	if int64(len(p)) > c.left {
+		p = p[:c.left+1]
+	}
+	n, err := c.Reader.Read(p)
+	if int64(n) > c.left {
+		n, c.left = int(c.left), 0
+		return n, errInflatedTooLarge
+	}
+	c.left -= int64(n)
+	return n, err
+}
+
+// inflate is a huma middleware decompressing the gzipped bodies of
+// operations with gzipBodies before huma reads them. The body is inflated
+// as it arrives, and given up on as soon as it would pass the operation's
+// MaxBodyBytes, which huma enforces on the bodies of the others as sent.
+// Other operations get their bodies as sent, whatever their encoding.
+func (s *Server) inflate(ctx huma.Context, next func(huma.Context)) {
+	op := ctx.Operation()
+	encoding := strings.TrimSpace(ctx.Header("Content-Encoding"))
+	if op.Metadata[gzipBodiesKey] != true || encoding == "" || strings.EqualFold(encoding, "identity") {
+		next(ctx)
+		return
+	}
+	if !strings.EqualFold(encoding, "gzip") {
+		s.writeErr(ctx, apiError(http.StatusUnsupportedMediaType, CodeUnsupportedMediaType, fmt.Sprintf("Content-Encoding %q is not supported; send the body gzipped or as is", encoding)))
+		return
+	}
+	tooLarge := apiError(http.StatusRequestEntityTooLarge, CodeRequestTooLarge, fmt.Sprintf("request body is too large limit=%d bytes", op.MaxBodyBytes))
+	sent := &io.LimitedReader{R: ctx.BodyReader(), N: op.MaxBodyBytes + 1}
+	zr, err := gunzip(sent, op.MaxBodyBytes)
+	if err != nil {
+		s.writeErr(ctx, apiError(http.StatusBadRequest, CodeBadRequest, "body is not gzipped: "+err.Error()))
+		return
+	}
+	defer zr.Close()
+	var body bytes.Buffer
+	_, err = body.ReadFrom(zr)
+	switch {
+	case errors.Is(err, errInflatedTooLarge), sent.N == 0:
+		s.writeErr(ctx, tooLarge)
+		return
+	case err != nil:
+		s.writeErr(ctx, apiError(http.StatusBadRequest, CodeBadRequest, "body is not valid gzip: "+err.Error()))
+		return
+	}
+	next(&recordingContext{humaContext: ctx, body: &body})
+}
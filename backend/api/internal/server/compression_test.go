@@ -0,0 +1,32 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestGunzipLimit(t *testing.T) {
+	var archive bytes.Buffer
+	zw := gzip.NewWriter(&archive)
+	zw.Write(bytes.Repeat([]byte("a"), 1000))
+	zw.Close()
+	for _, tc := range []struct {
+		limit int64
+		err   error
+	}{{1000, nil}, {2000, nil}, {999, errInflatedTooLarge}, {0, errInflatedTooLarge}} {
+		zr, err := gunzip(bytes.NewReader(archive.Bytes()), tc.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(zr)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("limit %d: err = %v, want %v", tc.limit, err, tc.err)
+		}
+		if int64(len(data)) > tc.limit {
+			t.Errorf("limit %d: read %d bytes", tc.limit, len(data))
+		}
+	}
+}
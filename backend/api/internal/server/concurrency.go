@@ -0,0 +1,130 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// Route groups, which Config.ConcurrencyLimits caps each on its own.
+// Operations declare with concurrencyGroup the group they are in, if they
+// are expensive enough to have one; the others are reads if they are GETs
+// and writes if not. The admin API and probes are in none: the load
+// shedder's lanes look after them.
+const (
+	GroupReads   = "reads"   // GETs not in another group
+	GroupWrites  = "writes"  // other methods not in another group
+	GroupBulk    = "bulk"    // batch updates and transactions, up to 100 writes each
+	GroupSearch  = "search"  // full-text and typeahead search
+	GroupExports = "exports" // GDPR exports and downloads of background exports
+)
+
+// concurrencyGroups lists the groups, in the order errors name them.
+var concurrencyGroups = []string{GroupReads, GroupWrites, GroupBulk, GroupSearch, GroupExports}
+
+// concurrencyGroupKey is the operation metadata concurrencyGroup puts the
+// group under.
+const concurrencyGroupKey = "concurrencyGroup"
+
+// concurrencyGroup is the Metadata of an operation in group.
+func concurrencyGroup(group string) map[string]any {
+	return map[string]any{concurrencyGroupKey: group}
+}
+
+// groupOf returns the route group of op, or "" for one in none.
+func groupOf(op *huma.Operation) string {
+	if laneOf(op.Path) != laneAPI {
+		return ""
+	}
+	if group, ok := op.Metadata[concurrencyGroupKey].(string); ok {
+		return group
+	}
+	if op.Method == http.MethodGet {
+		return GroupReads
+	}
+	return GroupWrites
+}
+
+// ConcurrencyLimit caps the requests to a route group served at once at
+// Max. Queued more wait up to Config.QueueTimeout for a slot; the rest are
+// refused with 429 CONCURRENCY_LIMITED, so expensive routes can't take
+// every slot of MAX_IN_FLIGHT from cheap ones.
+type ConcurrencyLimit struct {
+	Max    int
+	Queued int
+}
+
+// parseConcurrencyLimits parses CONCURRENCY_LIMITS: comma-separated
+// group=max entries, or group=max:queued, queued defaulting to max as
+// MAX_QUEUED does.
+func parseConcurrencyLimits(v string) (map[string]ConcurrencyLimit, error) {
+	limits := map[string]ConcurrencyLimit{}
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		group, caps, _ := strings.Cut(entry, "=")
+		if !slices.Contains(concurrencyGroups, group) {
+			return nil, fmt.Errorf("want groups of %s, got %q", strings.Join(concurrencyGroups, ", "), group)
+		}
+		maxS, queuedS, queue := strings.Cut(caps, ":")
+		limit := ConcurrencyLimit{}
+		var err error
+		if limit.Max, err = strconv.Atoi(maxS); err != nil || limit.Max <= 0 {
+			return nil, fmt.Errorf("%s: want a positive count, got %q", group, maxS)
+		}
+		limit.Queued = limit.Max
+		if queue {
+			if limit.Queued, err = strconv.Atoi(queuedS); err != nil || limit.Queued < 0 {
+				return nil, fmt.Errorf("%s: want a queue of 0 or more, got %q", group, queuedS)
+			}
+		}
+		limits[group] = limit
+	}
+	return limits, nil
+}
+
+// newGroupLimiters returns a loadShedder for each limited group.
+func newGroupLimiters(cfg Config) map[string]*loadShedder {
+	if len(cfg.ConcurrencyLimits) == 0 {
+		return nil
+	}
+	limiters := make(map[string]*loadShedder, len(cfg.ConcurrencyLimits))
+	for group, limit := range cfg.ConcurrencyLimits {
+		limiters[group] = newLoadShedder(limit.Max, limit.Queued, cfg.QueueTimeout)
+	}
+	return limiters
+}
+
+type groupSlotKey struct{}
+
+// limitConcurrency is a huma middleware holding each request to a limited
+// route group to its group's ConcurrencyLimit, answering those it turns
+// away with 429 CONCURRENCY_LIMITED and a Retry-After. Like the load
+// shedder's, the slot is given up by releaseLoadSlot.
+func (s *Server) limitConcurrency(ctx huma.Context, next func(huma.Context)) {
+	group := groupOf(ctx.Operation())
+	limiter := s.groupLimiters[group]
+	if limiter == nil {
+		next(ctx)
+		return
+	}
+	release, reason := limiter.acquire(ctx.Context())
+	if release == nil {
+		if reason == "" {
+			return // the client is gone
+		}
+		s.metrics.concurrencyLimited(group, reason)
+		ctx.SetHeader("Retry-After", shedRetryAfter)
+		s.writeErr(ctx, apiError(http.StatusTooManyRequests, CodeConcurrencyLimited, "too many requests like this at once; retry after Retry-After"))
+		return
+	}
+	slot := &loadSlot{release: release}
+	defer slot.once.Do(slot.release)
+	next(huma.WithValue(ctx, groupSlotKey{}, slot))
+}
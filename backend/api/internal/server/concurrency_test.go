@@ -0,0 +1,44 @@
+package server
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConcurrencyLimits(t *testing.T) {
+	if _, err := parseConcurrencyLimits("reads=50,imports=2"); err == nil {
+		t.Error("imports: no error")
+	}
+	limits, err := parseConcurrencyLimits("bulk=2, reads=50:0")
+	if err != nil || !maps.Equal(limits, map[string]ConcurrencyLimit{GroupBulk: {2, 2}, GroupReads: {50, 0}}) {
+		t.Fatalf("limits %v, %v", limits, err)
+	}
+
+	s := NewServer(Config{ConcurrencyLimits: map[string]ConcurrencyLimit{GroupReads: {1, 0}}}, NewMemoryStore())
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+	release, _ := s.groupLimiters[GroupReads].acquire(context.Background())
+	if rec := get("/v1/users"); rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" || !strings.Contains(rec.Body.String(), string(CodeConcurrencyLimited)) {
+		t.Errorf("read with the reads slot taken: %d %s", rec.Code, rec.Body)
+	}
+	// Other groups, and the admin API, aren't held up.
+	for _, path := range []string{"/v1/users/search?q=ad", "/admin/maintenance", "/livez"} {
+		if rec := get(path); rec.Code == http.StatusTooManyRequests {
+			t.Errorf("%s with the reads slot taken: %d", path, rec.Code)
+		}
+	}
+	release()
+	if rec := get("/v1/users"); rec.Code != http.StatusOK {
+		t.Errorf("read with the slot free: %d %s", rec.Code, rec.Body)
+	}
+	if rec := get("/metrics"); !strings.Contains(rec.Body.String(), `http_concurrency_limited_requests_total{group="reads",reason="queue_full"} 1`) {
+		t.Error("limited request not counted")
+	}
+}
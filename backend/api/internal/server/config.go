@@ -0,0 +1,969 @@
+package server
+
+import (
+	"cmp"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/captcha"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/email"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/fieldcrypt"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/idgen"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/jwks"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/lock"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/search"
+)
+
+// Config holds the settings NewServer needs from its environment. Fields
+// tagged reload:"live" are applied by Reload; a change to any other field
+// takes a restart, except for those tagged reload:"-", which hold what is
+// built from other settings, or set only in code, and aren't compared.
+type Config struct {
+	// Addr is the address Run listens on, ":8080" if empty, unless
+	// Listeners is set. Raft replicas forward writes to its port.
+	Addr string
+	// TrustedProxies are the proxies whose forwarding headers are believed
+	// about which client a request came from.
+	TrustedProxies TrustedProxies
+	// IPAccess is which client addresses may call the API.
+	IPAccess IPAccess `reload:"live"`
+	// Listeners, if set, are the addresses Run listens on instead of Addr.
+	Listeners []Listener
+	// TLSCertFile and TLSKeyFile are the certificate and key https
+	// listeners serve.
+	TLSCertFile string
+	TLSKeyFile  string
+	// TLSClientCAFile is the PEM bundle ClientCAs was read from. With
+	// ClientCAs, https listeners ask clients for a certificate issued by one
+	// of them, for mutual TLS between services, and refuse the handshake
+	// without one if RequireClientCerts is set.
+	TLSClientCAFile    string
+	ClientCAs          *x509.CertPool `reload:"-"`
+	RequireClientCerts bool
+	// ServiceIdentities maps the names client certificates carry, URI SANs
+	// such as SPIFFE IDs, DNS SANs or common names, to the services they
+	// identify; see serviceFrom. Certificates naming none of them identify
+	// no service.
+	ServiceIdentities map[string]string
+	// AdminServices may call the admin API without the admin token.
+	AdminServices []string
+	// OpenAPIPath is where `api gen:openapi` writes the JSON spec, relative
+	// to the working directory, and where Check looks for it. The server
+	// itself only serves the spec from memory.
+	OpenAPIPath string
+	// CORS is who may call the API from a browser, and AdminCORS who may
+	// call the admin API, the same as CORS if nil.
+	CORS      CORSPolicy  `reload:"live"`
+	AdminCORS *CORSPolicy `reload:"live"`
+	// MetadataSchema, if set, is a JSON Schema user metadata must match.
+	MetadataSchema *huma.Schema
+	// UniquePhones makes a phone number belong to at most one user.
+	UniquePhones bool `reload:"live"`
+	// EmailFolding is how users' emails are folded into their canonical
+	// form.
+	EmailFolding EmailFolding
+	// IDGenerator makes the IDs of users, posts, comments and notifications;
+	// timestamps to the second, as they have always been, if nil.
+	IDGenerator idgen.Generator
+	// BlockDisposableEmails rejects emails at disposable domains: the
+	// built-in ones and those listed at DisposableDomainsURL, if set, which
+	// is fetched again every DisposableDomainsRefresh, a day if zero.
+	BlockDisposableEmails    bool
+	DisposableDomainsURL     string
+	DisposableDomainsRefresh time.Duration
+	// LogLevel is the minimum level logged, info by default.
+	LogLevel slog.Level `reload:"live"`
+	// AdminToken is the bearer token the /admin operations require. They
+	// refuse every call while it is empty.
+	AdminToken string
+	// SlowRequestThreshold is how long a request may take before it is
+	// logged as slow. Zero or less turns slow request logging off.
+	SlowRequestThreshold time.Duration `reload:"live"`
+	// SentryDSN, if set, reports panics and 500s to Sentry.
+	SentryDSN string
+	// SentrySampleRate is the share of errors sent to Sentry, 0 to 1.
+	SentrySampleRate float64
+	// MetricsExporter is MetricsPrometheus (the default, served on
+	// /metrics), MetricsStatsD or MetricsNone.
+	MetricsExporter string
+	// StatsDAddr is the DogStatsD agent's host:port. Empty uses
+	// DD_AGENT_HOST and DD_DOGSTATSD_PORT, falling back to localhost:8125.
+	StatsDAddr string
+	// StoreMaxUsers caps the in-memory store, evicting the least recently
+	// used user when full. Zero means no cap.
+	StoreMaxUsers int
+	// StoreUserTTL evicts users not read or written for this long. Zero
+	// means they never expire.
+	StoreUserTTL time.Duration
+	// StoreSnapshotPath, if set, is the file the in-memory store is
+	// restored from at startup and saved to while running.
+	StoreSnapshotPath string
+	// StoreSnapshotInterval is how often the snapshot is saved, besides on
+	// shutdown. Zero saves only on shutdown.
+	StoreSnapshotInterval time.Duration
+	// StoreWALPath, if set, is a write-ahead log every store mutation is
+	// synced to before it is applied, replayed at startup and compacted by
+	// each snapshot. It needs StoreSnapshotPath.
+	StoreWALPath string
+	// CanaryStore, if set, is a second store, such as a new backend being
+	// migrated to, that serves the requests with X-Canary: true and
+	// CanaryPercent (0 to 100) of the others, picked at random, so it can
+	// be tried on a share of the traffic before it takes over; see
+	// routeCanary.
+	CanaryStore   Store `reload:"-"`
+	CanaryPercent float64
+	// AuditLogPath, if set, is a file the audit log is kept in as well as
+	// in memory, so it, the change feed and the event replay outlive
+	// restarts; see AuditLog.OpenFile.
+	AuditLogPath string
+	// RaftNodeID, if set, runs the experimental clustered mode: the store is
+	// replicated to RaftPeers with raft, and this replica is the peer with
+	// this ID. It can't be combined with snapshots or the write-ahead log.
+	RaftNodeID string
+	// RaftPeers lists every replica, this one included. All of them serve
+	// the API on the same port.
+	RaftPeers []RaftPeer
+	// RaftBindAddr is where the raft transport listens, this replica's peer
+	// address if empty.
+	RaftBindAddr string
+	// PIIKeys, if set, encrypts users' email and phone at rest: in the
+	// store, and so in its snapshots, write-ahead log and backups.
+	PIIKeys *fieldcrypt.Keyring `reload:"-"`
+	// JWTIssuers are identity providers, such as Auth0, whose JWTs are
+	// accepted as user tokens, their subject being the user's ID. Their
+	// keys are fetched from their JWKS and refreshed every
+	// JWKSRefreshInterval, 15 minutes if zero.
+	JWTIssuers          []jwks.Issuer
+	JWKSRefreshInterval time.Duration
+	// TokenSigningKey signs user tokens. If empty, a random key is used, so
+	// tokens stop working on restart and only work on the replica that
+	// issued them.
+	TokenSigningKey []byte
+	// URLSigningKey signs download links. If empty, a random key is used,
+	// so links stop working on restart and only work on the replica that
+	// signed them.
+	URLSigningKey []byte
+	// LoginMaxFailures, LoginMaxFailuresPerIP and LoginLockout throttle
+	// failed logins; see LoginPolicy. Zero gets its default.
+	LoginMaxFailures      int
+	LoginMaxFailuresPerIP int
+	LoginLockout          time.Duration
+	// TrafficAnalyzer, if set, watches each client's requests and bans or
+	// challenges the abusive ones; see analyzeTraffic. Without one, a
+	// RateAnalyzer is used if TrafficPolicy sets a limit.
+	TrafficAnalyzer TrafficAnalyzer `reload:"-"`
+	TrafficPolicy   TrafficPolicy
+	// SecurityEvents, if set, receives the security event stream: failed
+	// authentication, denied permissions, lockouts, impersonation and API
+	// key changes; see SecurityStream. SecurityEventsTarget names it, for
+	// logs.
+	SecurityEvents       SecuritySink `reload:"-"`
+	SecurityEventsTarget string
+	// CachePurger, if set, purges the responses a CDN or reverse proxy in
+	// front of the API keeps when what they hold changes; see
+	// surrogateKeys.
+	CachePurger CachePurger `reload:"-"`
+	// Mailer sends emails; without one they are only logged. Those it
+	// refuses are queued and retried; see mailQueue.
+	Mailer email.Sender `reload:"-"`
+	// MailQueuePath, if set, is a file the emails queued to be retried are
+	// kept in as well as in memory, so they outlive restarts.
+	MailQueuePath string
+	// DigestSchedule sends the daily activity digests every day at
+	// DigestAt, a time of day in UTC as the time since midnight, and the
+	// weekly ones on DigestWeekday too. Without it digests are only sent
+	// through post-admin-digest.
+	DigestSchedule bool
+	DigestAt       time.Duration
+	DigestWeekday  time.Weekday
+	// AppURL is the base URL of the dashboard, for links in emails.
+	AppURL string
+	// Search is the full-text index behind get-v1-search. If nil, New
+	// opens a Bleve index in the directory SearchIndexPath, or in memory if
+	// that is empty, which is rebuilt from the store on every start.
+	Search          search.Index `reload:"-"`
+	SearchIndexPath string
+	// Locker holds the locks that keep restores, retention runs and digests
+	// from running twice at once, and elects the leader that runs the
+	// scheduled ones, unless the store is a RaftStore. If nil they are only
+	// held within this replica, which then always leads; share one, like
+	// lock.Redis, to hold them across replicas.
+	Locker lock.Locker `reload:"-"`
+	// RedisPool sizes the connection pools of a Locker ConfigFromEnv builds
+	// from REDIS_URLS.
+	RedisPool lock.RedisPool
+	// ReplicaID names this replica as the leader and holder of locks. It
+	// defaults to RaftNodeID, or else the hostname.
+	ReplicaID string
+	// Captcha, if set, checks the X-Captcha-Token of signups and logins.
+	// CaptchaProvider names it, for logs.
+	Captcha         captcha.Verifier `reload:"-"`
+	CaptchaProvider string
+	// RetentionDeletedUsers is how long soft-deleted users are kept before
+	// the retention policy purges them. Zero keeps them forever.
+	RetentionDeletedUsers time.Duration
+	// RetentionAudit is how long audit entries are kept. Zero keeps them
+	// until the restart.
+	RetentionAudit time.Duration
+	// RetentionInterval is how often the retention policy runs, if any
+	// retention is set.
+	RetentionInterval time.Duration
+	// HealthProbeInterval is how often the server probes its own
+	// readiness for GET /admin/health/history, 10 seconds if zero.
+	HealthProbeInterval time.Duration
+	// RetentionDryRun makes the scheduled runs only report what they would
+	// purge.
+	RetentionDryRun bool
+	// Maintenance starts the server in maintenance mode; see
+	// refuseDuringMaintenance. Empty means off.
+	Maintenance MaintenanceMode `reload:"live"`
+	// MaintenanceRetryAfter is the Retry-After of 503s during maintenance,
+	// unless one is given when it is switched on; 5 minutes if zero.
+	MaintenanceRetryAfter time.Duration `reload:"live"`
+	// ShutdownTimeout bounds how long a graceful shutdown waits for
+	// in-flight requests and background jobs; 10 seconds if zero.
+	ShutdownTimeout time.Duration
+	// ShutdownDelay is how long shutdown keeps serving, with /health and
+	// /readyz failing, before it stops accepting connections, so a load
+	// balancer has time to take the server out of rotation.
+	// ConfigFromEnv defaults it to kubernetesShutdownDelay in Kubernetes.
+	ShutdownDelay time.Duration
+	// WarmUp has /readyz fail after startup until the server has primed
+	// its caches and served itself a few of the requests clients send
+	// first, or WarmUpTimeout, 30 seconds if zero, has passed; see warmUp.
+	WarmUp        bool
+	WarmUpTimeout time.Duration
+	// FaultInjection enables the fault rules of PUT /admin/faults, for
+	// testing clients in staging. Dev mode enables them too.
+	FaultInjection bool
+	// CaptureRequests is how many of the latest requests and responses to
+	// keep for GET /admin/requests; 100 in dev mode if zero, and none
+	// otherwise.
+	CaptureRequests int
+	// ValidateResponses checks every response an operation serves against
+	// what the spec documents for it, and logs those that don't match, to
+	// catch drift in dev and CI; it keeps a copy of each body to do so. Dev
+	// mode enables it too.
+	ValidateResponses bool
+	// OnSchemaViolation, if set, is called with each response
+	// ValidateResponses finds not matching, for tests to fail on.
+	OnSchemaViolation func(SchemaViolation) `reload:"-"`
+	// ShadowURL, if set, is the base URL of a second deployment, such as
+	// a new implementation being load-tested, that ShadowPercent (0 to
+	// 100, all if zero) of the requests are mirrored to, with their bodies
+	// as ShadowBodies says, ShadowBodiesRedacted if empty; see
+	// shadowTraffic.
+	ShadowURL     *url.URL
+	ShadowPercent float64
+	ShadowBodies  string
+	// MaxInFlight caps the requests served at once; see loadShedder. Zero
+	// means no cap. MaxQueued more wait up to QueueTimeout, 1 second if
+	// zero, for one of them to finish before they are shed with a 503.
+	MaxInFlight  int
+	MaxQueued    int
+	QueueTimeout time.Duration
+	// ConcurrencyLimits caps the requests served at once in each route
+	// group it has, by group; see concurrencyGroups. Queued requests wait
+	// up to QueueTimeout too.
+	ConcurrencyLimits map[string]ConcurrencyLimit
+	// DeadLetterAlert is how many dead letters the dead-letter queue grows
+	// by between the errors logged about it, 100 if zero; see DeadLetters.
+	DeadLetterAlert int
+	// StatsCacheTTL is how long GET /v1/stats reuses the counts it took.
+	// Zero counts on every request.
+	StatsCacheTTL time.Duration
+	// Telemetry, off unless set, sends anonymous usage reports, the
+	// requests to each operation, the version and the store backend, to
+	// TelemetryURL every TelemetryInterval, a day if zero; see
+	// TelemetryReport. GET /admin/telemetry shows what they hold either way.
+	Telemetry         bool
+	TelemetryURL      string
+	TelemetryInterval time.Duration
+	// Dev logs request and response bodies, pretty-prints JSON, allows any
+	// CORS origin, puts stack traces in the body of panics' 500s and serves
+	// email previews under /dev/emails. Never set it in production.
+	Dev bool
+	// CallTracer, which only tests set, records the middlewares, handler
+	// and store calls each request goes through.
+	CallTracer *CallTracer `reload:"-"`
+
+	// resourceEnv holds the variables of resourceEnvKeys ConfigFromEnv
+	// read, for Reload to tell whether what it built from them changed.
+	resourceEnv map[string]string
+}
+
+// resourceEnvKeys are the variables ConfigFromEnv builds resources from,
+// rather than only settings: the captcha verifier, security event sink,
+// cache purger, mailer, PII keyring, Redis locker and search index.
+var resourceEnvKeys = []string{
+	"CAPTCHA_SECRET", "CAPTCHA_MIN_SCORE", "SECURITY_EVENTS_SECRET",
+	"CACHE_PURGE_URL", "CACHE_PURGE_TOKEN", "SMTP_ADDR", "MAIL_FROM",
+	"SMTP_USERNAME", "SMTP_PASSWORD", "PII_ENCRYPTION_KEYS", "PII_KMS_KEY_IDS",
+	"REDIS_URLS", "SEARCH_BACKEND", "SEARCH_URL", "SEARCH_INDEX",
+}
+
+// ConfigFromEnv reads API_PORT, LISTEN, TLS_CERT_FILE, TLS_KEY_FILE,
+// TLS_CLIENT_CA_FILE, TLS_CLIENT_CERT_REQUIRED, SERVICE_IDENTITIES,
+// ADMIN_SERVICES, TRUSTED_PROXIES, IP_ALLOW, IP_DENY, ADMIN_IP_ALLOW, ADMIN_IP_DENY,
+// OPENAPI_PATH, CORS_ORIGIN, CORS_MAX_AGE, CORS_PRIVATE_NETWORK,
+// ADMIN_CORS_ORIGIN, ADMIN_CORS_MAX_AGE, ADMIN_CORS_PRIVATE_NETWORK,
+// USER_METADATA_SCHEMA, USER_PHONE_UNIQUE, EMAIL_FOLDING, ID_GENERATOR,
+// SNOWFLAKE_NODE_ID,
+// BLOCK_DISPOSABLE_EMAILS, DISPOSABLE_DOMAINS_URL,
+// DISPOSABLE_DOMAINS_REFRESH, LOG_LEVEL, ADMIN_TOKEN,
+// SLOW_REQUEST_THRESHOLD, SENTRY_DSN, SENTRY_SAMPLE_RATE, METRICS_EXPORTER,
+// STATSD_ADDR, STORE_MAX_USERS, STORE_USER_TTL, STORE_SNAPSHOT_PATH,
+// STORE_SNAPSHOT_INTERVAL, STORE_WAL_PATH, AUDIT_LOG_PATH, RAFT_NODE_ID,
+// RAFT_PEERS, RAFT_BIND_ADDR, PII_ENCRYPTION_KEYS, PII_KMS_KEY_IDS, TOKEN_SIGNING_KEY,
+// URL_SIGNING_KEY,
+// JWT_ISSUERS, JWT_AUDIENCE, JWKS_REFRESH_INTERVAL,
+// LOGIN_MAX_FAILURES, LOGIN_MAX_FAILURES_PER_IP, LOGIN_LOCKOUT,
+// TRAFFIC_MAX_PER_MINUTE, TRAFFIC_MAX_ERROR_RATE, TRAFFIC_ACTION,
+// TRAFFIC_BAN_DURATION,
+// CAPTCHA_PROVIDER, CAPTCHA_SECRET, CAPTCHA_MIN_SCORE, SECURITY_EVENTS,
+// SECURITY_EVENTS_SECRET, CACHE_PURGE_URL, CACHE_PURGE_TOKEN, MAINTENANCE_MODE,
+// MAINTENANCE_RETRY_AFTER, SHUTDOWN_TIMEOUT, SHUTDOWN_DELAY, WARM_UP,
+// WARM_UP_TIMEOUT,
+// RETENTION_DELETED_USERS, RETENTION_AUDIT, RETENTION_INTERVAL,
+// RETENTION_DRY_RUN, CANARY_PERCENT, CAPTURE_REQUESTS, SHADOW_URL,
+// SHADOW_PERCENT, SHADOW_BODIES, MAX_IN_FLIGHT, MAX_QUEUED,
+// QUEUE_TIMEOUT, CONCURRENCY_LIMITS, HEALTH_PROBE_INTERVAL, DEAD_LETTER_ALERT, STATS_CACHE_TTL, SMTP_ADDR,
+// SMTP_USERNAME, SMTP_PASSWORD, MAIL_FROM, MAIL_QUEUE_PATH, DIGEST_AT,
+// DIGEST_WEEKDAY, APP_URL,
+// SEARCH_BACKEND, SEARCH_INDEX_PATH, SEARCH_URL, SEARCH_INDEX, REDIS_URLS,
+// REDIS_POOL_MAX_OPEN, REDIS_POOL_MAX_IDLE, REDIS_POOL_MAX_LIFETIME,
+// REDIS_POOL_MAX_IDLE_TIME, REDIS_POOL_TIMEOUT, REPLICA_ID, TELEMETRY,
+// TELEMETRY_URL, TELEMETRY_INTERVAL, VALIDATE_RESPONSES and FAULT_INJECTION. If
+// CONFIG_FILE names a file of KEY=VALUE lines, in the .env format, its values
+// take precedence over the environment.
+func ConfigFromEnv() (Config, error) {
+	return configFromEnv(true)
+}
+
+// SettingsFromEnv reads the same variables as ConfigFromEnv, but leaves out
+// the resources it builds from them, which open files, connection pools and
+// KMS clients. Reload keeps the resources the server started with, so
+// editing CONFIG_FILE and calling SettingsFromEnv is how settings are
+// reloaded, without leaking a set of resources on each reload.
+func SettingsFromEnv() (Config, error) {
+	return configFromEnv(false)
+}
+
+// configFromEnv is ConfigFromEnv, which builds the fields tagged reload:"-"
+// only if open is set.
+func configFromEnv(open bool) (Config, error) {
+	getenv := os.Getenv
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		file, err := readEnvFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("read CONFIG_FILE: %w", err)
+		}
+		getenv = func(key string) string {
+			if v, ok := file[key]; ok {
+				return v
+			}
+			return os.Getenv(key)
+		}
+	}
+
+	cfg := Config{
+		Addr:         ":" + cmp.Or(getenv("API_PORT"), "8080"),
+		OpenAPIPath:  cmp.Or(getenv("OPENAPI_PATH"), "packages/api/src/contracts/v1.json"),
+		UniquePhones: getenv("USER_PHONE_UNIQUE") == "true",
+		AdminToken:   getenv("ADMIN_TOKEN"),
+		SentryDSN:    getenv("SENTRY_DSN"),
+		StatsDAddr:   getenv("STATSD_ADDR"),
+		TLSCertFile:  getenv("TLS_CERT_FILE"),
+		TLSKeyFile:   getenv("TLS_KEY_FILE"),
+
+		TLSClientCAFile:    getenv("TLS_CLIENT_CA_FILE"),
+		RequireClientCerts: getenv("TLS_CLIENT_CERT_REQUIRED") == "true",
+
+		StoreSnapshotPath: getenv("STORE_SNAPSHOT_PATH"),
+		StoreWALPath:      getenv("STORE_WAL_PATH"),
+		AuditLogPath:      getenv("AUDIT_LOG_PATH"),
+		MailQueuePath:     getenv("MAIL_QUEUE_PATH"),
+
+		RaftNodeID:   getenv("RAFT_NODE_ID"),
+		RaftBindAddr: getenv("RAFT_BIND_ADDR"),
+		ReplicaID:    getenv("REPLICA_ID"),
+
+		RetentionDryRun: getenv("RETENTION_DRY_RUN") == "true",
+		WarmUp:          getenv("WARM_UP") == "true",
+		FaultInjection:  getenv("FAULT_INJECTION") == "true",
+
+		ValidateResponses: getenv("VALIDATE_RESPONSES") == "true",
+	}
+	api, err := corsPolicyFromEnv(getenv, "")
+	if err != nil {
+		return cfg, err
+	}
+	cfg.CORS = api
+	if getenv("ADMIN_CORS_ORIGIN") != "" || getenv("ADMIN_CORS_MAX_AGE") != "" || getenv("ADMIN_CORS_PRIVATE_NETWORK") != "" {
+		admin, err := corsPolicyFromEnv(getenv, "ADMIN_")
+		if err != nil {
+			return cfg, err
+		}
+		cfg.AdminCORS = &admin
+	}
+	if proxies := getenv("TRUSTED_PROXIES"); proxies != "" {
+		var err error
+		if cfg.TrustedProxies, err = ParseTrustedProxies(proxies); err != nil {
+			return cfg, fmt.Errorf("TRUSTED_PROXIES: %w", err)
+		}
+	}
+	for name, prefixes := range map[string]*[]netip.Prefix{
+		"IP_ALLOW":       &cfg.IPAccess.All.Allow,
+		"IP_DENY":        &cfg.IPAccess.All.Deny,
+		"ADMIN_IP_ALLOW": &cfg.IPAccess.Admin.Allow,
+		"ADMIN_IP_DENY":  &cfg.IPAccess.Admin.Deny,
+	} {
+		var err error
+		if *prefixes, err = ParsePrefixes(getenv(name)); err != nil {
+			return cfg, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	if listen := getenv("LISTEN"); listen != "" {
+		listeners, err := ParseListeners(listen)
+		if err != nil {
+			return cfg, fmt.Errorf("LISTEN: %w", err)
+		}
+		if slices.ContainsFunc(listeners, func(l Listener) bool { return l.Kind == ListenHTTPS }) &&
+			(cfg.TLSCertFile == "" || cfg.TLSKeyFile == "") {
+			return cfg, errors.New("LISTEN: https listeners need TLS_CERT_FILE and TLS_KEY_FILE")
+		}
+		cfg.Listeners = listeners
+	}
+	if cfg.TLSClientCAFile != "" {
+		pool, err := loadClientCAs(cfg.TLSClientCAFile)
+		if err != nil {
+			return cfg, fmt.Errorf("TLS_CLIENT_CA_FILE: %w", err)
+		}
+		cfg.ClientCAs = pool
+	} else if cfg.RequireClientCerts {
+		return cfg, errors.New("TLS_CLIENT_CERT_REQUIRED needs TLS_CLIENT_CA_FILE")
+	}
+	if identities := getenv("SERVICE_IDENTITIES"); identities != "" {
+		var err error
+		if cfg.ServiceIdentities, err = ParseServiceIdentities(identities); err != nil {
+			return cfg, fmt.Errorf("SERVICE_IDENTITIES: %w", err)
+		}
+	}
+	for _, service := range strings.Split(getenv("ADMIN_SERVICES"), ",") {
+		if service = strings.TrimSpace(service); service != "" {
+			cfg.AdminServices = append(cfg.AdminServices, service)
+		}
+	}
+	if level := getenv("LOG_LEVEL"); level != "" {
+		if err := cfg.LogLevel.UnmarshalText([]byte(level)); err != nil {
+			return cfg, fmt.Errorf("LOG_LEVEL: %w", err)
+		}
+	}
+	cfg.SlowRequestThreshold = time.Second
+	if threshold := getenv("SLOW_REQUEST_THRESHOLD"); threshold != "" {
+		d, err := time.ParseDuration(threshold)
+		if err != nil {
+			return cfg, fmt.Errorf("SLOW_REQUEST_THRESHOLD: %w", err)
+		}
+		cfg.SlowRequestThreshold = d
+	}
+	switch cfg.MetricsExporter = cmp.Or(getenv("METRICS_EXPORTER"), MetricsPrometheus); cfg.MetricsExporter {
+	case MetricsPrometheus, MetricsStatsD, MetricsNone:
+	default:
+		return cfg, fmt.Errorf("METRICS_EXPORTER: want prometheus, statsd or none, got %q", cfg.MetricsExporter)
+	}
+	cfg.SentrySampleRate = 1
+	if rate := getenv("SENTRY_SAMPLE_RATE"); rate != "" {
+		r, err := strconv.ParseFloat(rate, 64)
+		if err != nil || r < 0 || r > 1 {
+			return cfg, fmt.Errorf("SENTRY_SAMPLE_RATE: want a number from 0 to 1, got %q", rate)
+		}
+		cfg.SentrySampleRate = r
+	}
+	if percent := getenv("CANARY_PERCENT"); percent != "" {
+		p, err := strconv.ParseFloat(percent, 64)
+		if err != nil || p < 0 || p > 100 {
+			return cfg, fmt.Errorf("CANARY_PERCENT: want a number from 0 to 100, got %q", percent)
+		}
+		cfg.CanaryPercent = p
+	}
+	if limit := getenv("STORE_MAX_USERS"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("STORE_MAX_USERS: want a non-negative integer, got %q", limit)
+		}
+		cfg.StoreMaxUsers = n
+	}
+	if ttl := getenv("STORE_USER_TTL"); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil {
+			return cfg, fmt.Errorf("STORE_USER_TTL: %w", err)
+		}
+		cfg.StoreUserTTL = d
+	}
+	for key, dst := range map[string]*int{
+		"MAX_IN_FLIGHT":     &cfg.MaxInFlight,
+		"MAX_QUEUED":        &cfg.MaxQueued,
+		"DEAD_LETTER_ALERT": &cfg.DeadLetterAlert,
+	} {
+		if v := getenv(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return cfg, fmt.Errorf("%s: want a count, got %q", key, v)
+			}
+			*dst = n
+		}
+	}
+	if getenv("MAX_QUEUED") == "" {
+		cfg.MaxQueued = cfg.MaxInFlight
+	}
+	if timeout := getenv("QUEUE_TIMEOUT"); timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			return cfg, fmt.Errorf("QUEUE_TIMEOUT: %w", err)
+		}
+		cfg.QueueTimeout = d
+	}
+	if v := getenv("CONCURRENCY_LIMITS"); v != "" {
+		limits, err := parseConcurrencyLimits(v)
+		if err != nil {
+			return cfg, fmt.Errorf("CONCURRENCY_LIMITS: %w", err)
+		}
+		cfg.ConcurrencyLimits = limits
+	}
+	if ttl := getenv("STATS_CACHE_TTL"); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil {
+			return cfg, fmt.Errorf("STATS_CACHE_TTL: %w", err)
+		}
+		cfg.StatsCacheTTL = d
+	}
+	cfg.StoreSnapshotInterval = time.Minute
+	if interval := getenv("STORE_SNAPSHOT_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			return cfg, fmt.Errorf("STORE_SNAPSHOT_INTERVAL: %w", err)
+		}
+		cfg.StoreSnapshotInterval = d
+	}
+	if cfg.StoreWALPath != "" && cfg.StoreSnapshotPath == "" {
+		return cfg, errors.New("STORE_WAL_PATH needs STORE_SNAPSHOT_PATH, which it is compacted into")
+	}
+	if cfg.RaftNodeID != "" {
+		peers, err := ParseRaftPeers(getenv("RAFT_PEERS"))
+		if err != nil {
+			return cfg, fmt.Errorf("RAFT_PEERS: %w", err)
+		}
+		if !slices.ContainsFunc(peers, func(p RaftPeer) bool { return p.ID == cfg.RaftNodeID }) {
+			return cfg, fmt.Errorf("RAFT_PEERS has no entry for RAFT_NODE_ID %q", cfg.RaftNodeID)
+		}
+		if cfg.StoreSnapshotPath != "" || cfg.StoreWALPath != "" {
+			return cfg, errors.New("RAFT_NODE_ID can't be combined with STORE_SNAPSHOT_PATH or STORE_WAL_PATH; the replicas are each other's backup")
+		}
+		cfg.RaftPeers = peers
+	}
+	for key, dst := range map[string]*time.Duration{
+		"RETENTION_DELETED_USERS": &cfg.RetentionDeletedUsers,
+		"RETENTION_AUDIT":         &cfg.RetentionAudit,
+	} {
+		if v := getenv(key); v != "" {
+			d, err := parseAge(v)
+			if err != nil {
+				return cfg, fmt.Errorf("%s: %w", key, err)
+			}
+			*dst = d
+		}
+	}
+	// Kubernetes sends SIGTERM while it removes the pod from the service's
+	// endpoints, and the proxies keep routing to it until they catch up.
+	if getenv("SHUTDOWN_DELAY") == "" && getenv("KUBERNETES_SERVICE_HOST") != "" {
+		cfg.ShutdownDelay = kubernetesShutdownDelay
+	}
+	if cfg.Telemetry = getenv("TELEMETRY") == "true"; cfg.Telemetry {
+		target := getenv("TELEMETRY_URL")
+		u, err := url.Parse(target)
+		if err != nil || u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+			return cfg, fmt.Errorf("TELEMETRY_URL: TELEMETRY=true needs an http(s) URL, got %q", target)
+		}
+		cfg.TelemetryURL = target
+	}
+	if interval := getenv("TELEMETRY_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d < time.Minute {
+			return cfg, fmt.Errorf("TELEMETRY_INTERVAL: want a duration of a minute or more, got %q", interval)
+		}
+		cfg.TelemetryInterval = d
+	}
+	cfg.RetentionInterval = time.Hour
+	if interval := getenv("RETENTION_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("RETENTION_INTERVAL: want a positive duration, got %q", interval)
+		}
+		cfg.RetentionInterval = d
+	}
+	cfg.resourceEnv = make(map[string]string, len(resourceEnvKeys))
+	for _, key := range resourceEnvKeys {
+		cfg.resourceEnv[key] = getenv(key)
+	}
+	if open {
+		keys, err := loadPIIKeys(getenv("PII_ENCRYPTION_KEYS"), getenv("PII_KMS_KEY_IDS"))
+		if err != nil {
+			return cfg, err
+		}
+		cfg.PIIKeys = keys
+	}
+	if key := getenv("TOKEN_SIGNING_KEY"); key != "" {
+		b, err := base64.StdEncoding.DecodeString(key)
+		if err != nil || len(b) < 32 {
+			return cfg, errors.New("TOKEN_SIGNING_KEY: want at least 32 bytes in base64")
+		}
+		cfg.TokenSigningKey = b
+	}
+	if key := getenv("URL_SIGNING_KEY"); key != "" {
+		b, err := base64.StdEncoding.DecodeString(key)
+		if err != nil || len(b) < 32 {
+			return cfg, errors.New("URL_SIGNING_KEY: want at least 32 bytes in base64")
+		}
+		cfg.URLSigningKey = b
+	}
+	if issuers := getenv("JWT_ISSUERS"); issuers != "" {
+		var err error
+		if cfg.JWTIssuers, err = ParseJWTIssuers(issuers, getenv("JWT_AUDIENCE")); err != nil {
+			return cfg, fmt.Errorf("JWT_ISSUERS: %w", err)
+		}
+	}
+	cfg.BlockDisposableEmails = getenv("BLOCK_DISPOSABLE_EMAILS") == "true"
+	if target := getenv("DISPOSABLE_DOMAINS_URL"); target != "" {
+		if u, err := url.Parse(target); err != nil || u.Scheme != "https" && u.Scheme != "http" || u.Host == "" {
+			return cfg, fmt.Errorf("DISPOSABLE_DOMAINS_URL: want an http or https URL, got %q", target)
+		}
+		cfg.DisposableDomainsURL = target
+	}
+	if interval := getenv("DISPOSABLE_DOMAINS_REFRESH"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("DISPOSABLE_DOMAINS_REFRESH: want a positive duration, got %q", interval)
+		}
+		cfg.DisposableDomainsRefresh = d
+	}
+	if interval := getenv("HEALTH_PROBE_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("HEALTH_PROBE_INTERVAL: want a positive duration, got %q", interval)
+		}
+		cfg.HealthProbeInterval = d
+	}
+	if interval := getenv("JWKS_REFRESH_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("JWKS_REFRESH_INTERVAL: want a positive duration, got %q", interval)
+		}
+		cfg.JWKSRefreshInterval = d
+	}
+	for key, dst := range map[string]*int{
+		"LOGIN_MAX_FAILURES":        &cfg.LoginMaxFailures,
+		"LOGIN_MAX_FAILURES_PER_IP": &cfg.LoginMaxFailuresPerIP,
+		"TRAFFIC_MAX_PER_MINUTE":    &cfg.TrafficPolicy.MaxPerMinute,
+		"CAPTURE_REQUESTS":          &cfg.CaptureRequests,
+	} {
+		if v := getenv(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				return cfg, fmt.Errorf("%s: want a positive integer, got %q", key, v)
+			}
+			*dst = n
+		}
+	}
+	if target := getenv("SHADOW_URL"); target != "" {
+		u, err := url.Parse(target)
+		if err != nil || u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+			return cfg, fmt.Errorf("SHADOW_URL: want an http(s) URL, got %q", target)
+		}
+		cfg.ShadowURL = u
+	}
+	if percent := getenv("SHADOW_PERCENT"); percent != "" {
+		p, err := strconv.ParseFloat(percent, 64)
+		if err != nil || p <= 0 || p > 100 {
+			return cfg, fmt.Errorf("SHADOW_PERCENT: want a number above 0 and up to 100, got %q", percent)
+		}
+		cfg.ShadowPercent = p
+	}
+	switch cfg.ShadowBodies = cmp.Or(getenv("SHADOW_BODIES"), ShadowBodiesRedacted); cfg.ShadowBodies {
+	case ShadowBodiesRedacted, ShadowBodiesFull, ShadowBodiesNone:
+	default:
+		return cfg, fmt.Errorf("SHADOW_BODIES: want redacted, full or none, got %q", cfg.ShadowBodies)
+	}
+	if lockout := getenv("LOGIN_LOCKOUT"); lockout != "" {
+		d, err := time.ParseDuration(lockout)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("LOGIN_LOCKOUT: want a positive duration, got %q", lockout)
+		}
+		cfg.LoginLockout = d
+	}
+	if rate := getenv("TRAFFIC_MAX_ERROR_RATE"); rate != "" {
+		f, err := strconv.ParseFloat(rate, 64)
+		if err != nil || f <= 0 || f > 1 {
+			return cfg, fmt.Errorf("TRAFFIC_MAX_ERROR_RATE: want a number above 0, up to 1, got %q", rate)
+		}
+		cfg.TrafficPolicy.MaxErrorRate = f
+	}
+	switch action := getenv("TRAFFIC_ACTION"); action {
+	case "", TrafficBan, TrafficCaptcha:
+		cfg.TrafficPolicy.Action = action
+	default:
+		return cfg, fmt.Errorf("TRAFFIC_ACTION: want ban or captcha, got %q", action)
+	}
+	if ban := getenv("TRAFFIC_BAN_DURATION"); ban != "" {
+		d, err := time.ParseDuration(ban)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("TRAFFIC_BAN_DURATION: want a positive duration, got %q", ban)
+		}
+		cfg.TrafficPolicy.For = d
+	}
+	for key, dst := range map[string]*time.Duration{
+		"SHUTDOWN_TIMEOUT": &cfg.ShutdownTimeout,
+		"SHUTDOWN_DELAY":   &cfg.ShutdownDelay,
+		"WARM_UP_TIMEOUT":  &cfg.WarmUpTimeout,
+	} {
+		if v := getenv(key); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < 0 {
+				return cfg, fmt.Errorf("%s: want a non-negative duration, got %q", key, v)
+			}
+			*dst = d
+		}
+	}
+	mode, err := parseMaintenanceMode(getenv("MAINTENANCE_MODE"))
+	if err != nil {
+		return cfg, fmt.Errorf("MAINTENANCE_MODE: %w", err)
+	}
+	cfg.Maintenance = mode
+	if retryAfter := getenv("MAINTENANCE_RETRY_AFTER"); retryAfter != "" {
+		d, err := time.ParseDuration(retryAfter)
+		if err != nil || d < time.Second {
+			return cfg, fmt.Errorf("MAINTENANCE_RETRY_AFTER: want a duration of at least 1s, got %q", retryAfter)
+		}
+		cfg.MaintenanceRetryAfter = d
+	}
+	if provider := getenv("CAPTCHA_PROVIDER"); provider != "" {
+		minScore := 0.5
+		if score := getenv("CAPTCHA_MIN_SCORE"); score != "" {
+			f, err := strconv.ParseFloat(score, 64)
+			if err != nil || f < 0 || f > 1 {
+				return cfg, fmt.Errorf("CAPTCHA_MIN_SCORE: want a number from 0 to 1, got %q", score)
+			}
+			minScore = f
+		}
+		cfg.CaptchaProvider = provider
+		if open {
+			v, err := captcha.New(provider, getenv("CAPTCHA_SECRET"), captcha.WithMinScore(minScore))
+			if err != nil {
+				return cfg, fmt.Errorf("CAPTCHA_PROVIDER: %w", err)
+			}
+			cfg.Captcha = v
+		}
+	}
+	if target := getenv("SECURITY_EVENTS"); target != "" {
+		cfg.SecurityEventsTarget = target
+		if open {
+			sink, err := NewSecuritySink(target, getenv("SECURITY_EVENTS_SECRET"))
+			if err != nil {
+				return cfg, fmt.Errorf("SECURITY_EVENTS: %w", err)
+			}
+			cfg.SecurityEvents = sink
+		}
+	}
+	if target := getenv("CACHE_PURGE_URL"); target != "" && open {
+		purger, err := NewCachePurger(target, getenv("CACHE_PURGE_TOKEN"))
+		if err != nil {
+			return cfg, fmt.Errorf("CACHE_PURGE_URL: %w", err)
+		}
+		cfg.CachePurger = purger
+	}
+	if addr := getenv("SMTP_ADDR"); addr != "" && open {
+		sender, err := email.NewSMTP(addr, cmp.Or(getenv("MAIL_FROM"), "no-reply@localhost"), getenv("SMTP_USERNAME"), getenv("SMTP_PASSWORD"))
+		if err != nil {
+			return cfg, fmt.Errorf("SMTP_ADDR: %w", err)
+		}
+		cfg.Mailer = sender
+	}
+	if at := getenv("DIGEST_AT"); at != "" {
+		d, err := parseTimeOfDay(at)
+		if err != nil {
+			return cfg, fmt.Errorf("DIGEST_AT: %w", err)
+		}
+		cfg.DigestSchedule, cfg.DigestAt = true, d
+	}
+	cfg.DigestWeekday = time.Monday
+	if day := getenv("DIGEST_WEEKDAY"); day != "" {
+		d, err := parseWeekday(day)
+		if err != nil {
+			return cfg, fmt.Errorf("DIGEST_WEEKDAY: %w", err)
+		}
+		cfg.DigestWeekday = d
+	}
+	if app := getenv("APP_URL"); app != "" {
+		u, err := parseAppURL(app)
+		if err != nil {
+			return cfg, fmt.Errorf("APP_URL: %w", err)
+		}
+		cfg.AppURL = u
+	}
+	for key, dst := range map[string]*int{
+		"REDIS_POOL_MAX_OPEN": &cfg.RedisPool.MaxOpen,
+		"REDIS_POOL_MAX_IDLE": &cfg.RedisPool.MaxIdle,
+	} {
+		if v := getenv(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return cfg, fmt.Errorf("%s: want a count, got %q", key, v)
+			}
+			*dst = n
+		}
+	}
+	for key, dst := range map[string]*time.Duration{
+		"REDIS_POOL_MAX_LIFETIME":  &cfg.RedisPool.MaxLifetime,
+		"REDIS_POOL_MAX_IDLE_TIME": &cfg.RedisPool.MaxIdleTime,
+		"REDIS_POOL_TIMEOUT":       &cfg.RedisPool.Timeout,
+	} {
+		if v := getenv(key); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < 0 {
+				return cfg, fmt.Errorf("%s: want a non-negative duration, got %q", key, v)
+			}
+			*dst = d
+		}
+	}
+	if urls := getenv("REDIS_URLS"); urls != "" && open {
+		replica := cmp.Or(cfg.ReplicaID, defaultReplicaID(cfg.RaftNodeID))
+		locker, err := lock.NewRedisPool(replica, cfg.RedisPool, strings.Split(urls, ",")...)
+		if err != nil {
+			return cfg, fmt.Errorf("REDIS_URLS: %w", err)
+		}
+		cfg.Locker = locker
+	}
+	switch backend := cmp.Or(getenv("SEARCH_BACKEND"), search.BackendBleve); backend {
+	case search.BackendBleve:
+		cfg.SearchIndexPath = getenv("SEARCH_INDEX_PATH")
+	case search.BackendElasticsearch, search.BackendOpenSearch:
+		if !open {
+			break
+		}
+		index, err := search.NewElasticsearch(getenv("SEARCH_URL"), cmp.Or(getenv("SEARCH_INDEX"), "monorepo"))
+		if err != nil {
+			return cfg, fmt.Errorf("SEARCH_URL: %w", err)
+		}
+		cfg.Search = index
+	default:
+		return cfg, fmt.Errorf("SEARCH_BACKEND: want bleve, elasticsearch or opensearch, got %q", backend)
+	}
+	if cfg.EmailFolding, err = parseEmailFolding(getenv("EMAIL_FOLDING")); err != nil {
+		return cfg, fmt.Errorf("EMAIL_FOLDING: %w", err)
+	}
+	if cfg.IDGenerator, err = idGeneratorFromEnv(getenv); err != nil {
+		return cfg, err
+	}
+	if path := getenv("USER_METADATA_SCHEMA"); path != "" {
+		schema, err := loadMetadataSchema(path)
+		if err != nil {
+			return cfg, fmt.Errorf("load user metadata schema: %w", err)
+		}
+		cfg.MetadataSchema = schema
+	}
+	return cfg, nil
+}
+
+// corsPolicyFromEnv reads the CORS policy of the variables starting with
+// prefix: CORS_ORIGIN, a comma-separated list, CORS_MAX_AGE and
+// CORS_PRIVATE_NETWORK.
+func corsPolicyFromEnv(getenv func(string) string, prefix string) (CORSPolicy, error) {
+	var p CORSPolicy
+	for _, origin := range strings.Split(getenv(prefix+"CORS_ORIGIN"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			p.Origins = append(p.Origins, origin)
+		}
+	}
+	if v := getenv(prefix + "CORS_MAX_AGE"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < time.Second {
+			return p, fmt.Errorf("%sCORS_MAX_AGE: want a duration of a second or more, got %q", prefix, v)
+		}
+		p.MaxAge = d
+	}
+	p.PrivateNetwork = getenv(prefix+"CORS_PRIVATE_NETWORK") == "true"
+	return p, nil
+}
+
+// idGeneratorFromEnv returns the generator ID_GENERATOR names, timestamp if
+// unset. Snowflake needs SNOWFLAKE_NODE_ID, different on every replica.
+func idGeneratorFromEnv(getenv func(string) string) (idgen.Generator, error) {
+	strategy := cmp.Or(getenv("ID_GENERATOR"), idgen.StrategyTimestamp)
+	var node int64
+	if strategy == idgen.StrategySnowflake {
+		v := getenv("SNOWFLAKE_NODE_ID")
+		if v == "" {
+			return nil, errors.New("ID_GENERATOR=snowflake needs SNOWFLAKE_NODE_ID, unique to the replica")
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 || n > idgen.MaxSnowflakeNode {
+			return nil, fmt.Errorf("SNOWFLAKE_NODE_ID: want an integer from 0 to %d, got %q", idgen.MaxSnowflakeNode, v)
+		}
+		node = n
+	}
+	gen, err := idgen.New(strategy, node)
+	if err != nil {
+		return nil, fmt.Errorf("ID_GENERATOR: %w", err)
+	}
+	return gen, nil
+}
+
+// sameIDGenerator reports whether a and b make the same kind of IDs, as the
+// Snowflake read from the environment again on reload is a new one.
+func sameIDGenerator(a, b idgen.Generator) bool {
+	sa, okA := a.(*idgen.Snowflake)
+	sb, okB := b.(*idgen.Snowflake)
+	if okA && okB {
+		return sa.Node() == sb.Node()
+	}
+	return a == b
+}
+
+// readEnvFile parses a .env style file: KEY=VALUE lines, optionally prefixed
+// with "export" and with the value in quotes. Blank lines and lines starting
+// with # are skipped.
+func readEnvFile(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	vars := map[string]string{}
+	for i, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, i+1)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		vars[strings.TrimSpace(key)] = value
+	}
+	return vars, nil
+}
@@ -0,0 +1,508 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/apitest"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/mock"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/search"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/server"
+)
+
+// contractCase is one request against a server seeded with the apitest
+// fixtures. Cases run in order and share state.
+type contractCase struct {
+	op     string // operation ID from the spec
+	method string
+	path   string // may contain {id} and {grace}, replaced with apitest.AdaID and apitest.GraceID, {post}, the last post created, {comment}, the last comment created, and {notification}, the newest one listed, {invitation} and {invitetoken}, the last invitation created, and {export}, the last export created
+	body   string // may contain {id} and {grace}; "{backup}" sends the last backup archive
+	status int
+}
+
+var contractCases = []contractCase{
+	{"get-hello", http.MethodGet, "/hello", "", 200},
+	{"get-health", http.MethodGet, "/health", "", 200},
+	{"get-livez", http.MethodGet, "/livez", "", 200},
+	{"get-readyz", http.MethodGet, "/readyz", "", 200},
+	{"get-readyz", http.MethodGet, "/readyz?format=plain", "", 200},
+	{"get-readyz", http.MethodGet, "/readyz?format=prometheus", "", 200},
+	{"get-readyz", http.MethodGet, "/readyz?format=xml", "", 422},
+	{"get-version", http.MethodGet, "/version", "", 200},
+	{"get-v1-changelog", http.MethodGet, "/v1/changelog", "", 200},
+	{"get-v1-changelog", http.MethodGet, "/v1/changelog?since=0.1.0", "", 404},
+	{"post-v1-users", http.MethodPost, "/v1/users", `{"name":"Ro","email":"ro@example.com","username":"ro_c","phone":"+43 660 7654321"}`, 201},
+	{"post-v1-users", http.MethodPost, "/v1/users", `{"name":"Ro"}`, 422},
+	{"post-v1-users", http.MethodPost, "/v1/users", `{"name":"Lin","email":"lin@example.com","username":"lin_p","password":"correct horse"}`, 201},
+	{"post-v1-invitations", http.MethodPost, "/v1/invitations", `{"email":"kim@example.com","name":"Kim"}`, 401},
+	{"post-v1-invitations", http.MethodPost, "/v1/invitations", `{"email":"kim@example.com","name":"Kim","locale":"de"}`, 201},
+	{"post-v1-invitations", http.MethodPost, "/v1/invitations", `{"email":"kim@example.com"}`, 409},
+	{"post-v1-invitations", http.MethodPost, "/v1/invitations", `{"email":"kim"}`, 422},
+	{"get-v1-invitations", http.MethodGet, "/v1/invitations", "", 401},
+	{"get-v1-invitations", http.MethodGet, "/v1/invitations?status=pending", "", 200},
+	{"post-v1-invitations-by-token-accept", http.MethodPost, "/v1/invitations/nope/accept", `{"password":"correct horse"}`, 404},
+	{"post-v1-invitations-by-token-accept", http.MethodPost, "/v1/invitations/{invitetoken}/accept", `{"password":"short"}`, 422},
+	{"post-v1-invitations-by-token-accept", http.MethodPost, "/v1/invitations/{invitetoken}/accept", `{"username":"kim_l","password":"correct horse"}`, 201},
+	{"post-v1-invitations-by-token-accept", http.MethodPost, "/v1/invitations/{invitetoken}/accept", `{"password":"correct horse"}`, 409},
+	{"delete-v1-invitations-by-id", http.MethodDelete, "/v1/invitations/{invitation}", "", 401},
+	{"delete-v1-invitations-by-id", http.MethodDelete, "/v1/invitations/{invitation}", "", 409},
+	{"post-v1-invitations", http.MethodPost, "/v1/invitations", `{"email":"lee@example.com"}`, 201},
+	{"delete-v1-invitations-by-id", http.MethodDelete, "/v1/invitations/{invitation}", "", 204},
+	{"delete-v1-invitations-by-id", http.MethodDelete, "/v1/invitations/inv_missing", "", 404},
+	{"post-v1-exports", http.MethodPost, "/v1/exports", `{"format":"csv"}`, 401},
+	{"post-v1-exports", http.MethodPost, "/v1/exports", `{"format":"csv","filters":{"include_inactive":true,"tag":["beta"]}}`, 202},
+	{"post-v1-exports", http.MethodPost, "/v1/exports", `{"format":"xml"}`, 422},
+	{"post-v1-exports", http.MethodPost, "/v1/exports", `{"filters":{"inactive_since":"soon"}}`, 422},
+	{"get-v1-exports-by-id", http.MethodGet, "/v1/exports/{export}", "", 401},
+	{"get-v1-exports-by-id", http.MethodGet, "/v1/exports/{export}", "", 200},
+	{"get-v1-exports-by-id", http.MethodGet, "/v1/exports/exp_missing", "", 404},
+	{"get-v1-downloads-by-kind-by-id", http.MethodGet, "/v1/downloads/exports/{export}?expires=4102444800&signature=AA", "", 403},
+	{"get-v1-downloads-by-kind-by-id", http.MethodGet, "/v1/downloads/avatars/{export}?expires=4102444800&signature=AA", "", 422},
+	{"get-v1-stats", http.MethodGet, "/v1/stats", "", 401},
+	{"get-v1-stats", http.MethodGet, "/v1/stats", "", 200},
+	{"post-v1-auth-login", http.MethodPost, "/v1/auth/login", `{"login":"lin_p","password":"wrong horse"}`, 401},
+	{"post-v1-auth-login", http.MethodPost, "/v1/auth/login", `{"login":"lin@example.com","password":"correct horse"}`, 200},
+	{"post-v1-auth-introspect", http.MethodPost, "/v1/auth/introspect", `{"token":"not.a.token"}`, 200},
+	{"post-v1-auth-introspect", http.MethodPost, "/v1/auth/introspect", `{"token":""}`, 422},
+	{"post-v1-auth-revoke", http.MethodPost, "/v1/auth/revoke", `{"token":"not.a.token"}`, 200},
+	{"get-v1-users", http.MethodGet, "/v1/users?per_page=1", "", 200},
+	{"get-v1-users-search", http.MethodGet, "/v1/users/search?q=ro", "", 200},
+	{"get-v1-search", http.MethodGet, "/v1/search?q=rohan&type=user,post", "", 200},
+	{"get-v1-search", http.MethodGet, "/v1/search?q=rohan&type=comment", "", 422},
+	{"post-v1-users-lookup", http.MethodPost, "/v1/users/lookup", `{"ids":["{id}","missing"]}`, 200},
+	{"patch-v1-users-batch", http.MethodPatch, "/v1/users/batch", `{"updates":[{"id":"{id}","changes":{"name":"Rohan C."}},{"id":"missing","changes":{}}]}`, 200},
+	{"patch-v1-users-batch", http.MethodPatch, "/v1/users/batch", `{"updates":[]}`, 422},
+	{"post-v1-batch", http.MethodPost, "/v1/batch", `{"operations":[{"op":"create","resource":"user","body":{"name":"Lin","email":"lin@example.com"}},{"op":"update","resource":"user","id":"$0","body":{"phone":"+43 660 1234567"}}]}`, 200},
+	{"post-v1-batch", http.MethodPost, "/v1/batch", `{"operations":[{"op":"remove","resource":"user","id":"{id}"}]}`, 422},
+	{"get-v1-users-changes", http.MethodGet, "/v1/users/changes?since=0", "", 200},
+	{"get-v1-users-changes", http.MethodGet, "/v1/users/changes?since=100000", "", 410},
+	{"get-v1-users-stream", http.MethodGet, "/v1/users/stream?include_inactive=true", "", 200},
+	{"get-v1-users-stream", http.MethodGet, "/v1/users/stream?inactive_since=soon", "", 422},
+	{"get-v1-users-by-id", http.MethodGet, "/v1/users/{id}", "", 200},
+	{"get-v1-users-by-id", http.MethodGet, "/v1/users/missing", "", 404},
+	{"post-v1-users-by-id-posts", http.MethodPost, "/v1/users/{id}/posts", `{"title":"Hello, world","body":"My first post."}`, 201},
+	{"post-v1-users-by-id-posts", http.MethodPost, "/v1/users/missing/posts", `{"title":"Hello, world","body":""}`, 404},
+	{"get-v1-users-by-id-posts", http.MethodGet, "/v1/users/{id}/posts", "", 200},
+	{"get-v1-users-by-id-posts", http.MethodGet, "/v1/users/missing/posts", "", 404},
+	{"post-v1-posts", http.MethodPost, "/v1/posts", `{"author_id":"{grace}","title":"","body":""}`, 422},
+	{"post-v1-posts", http.MethodPost, "/v1/posts", `{"author_id":"{grace}","title":"Notes","body":"On compilers."}`, 201},
+	{"get-v1-posts", http.MethodGet, "/v1/posts?author_id={grace}&per_page=1", "", 200},
+	{"get-v1-posts-by-id", http.MethodGet, "/v1/posts/{post}", "", 200},
+	{"get-v1-posts-by-id", http.MethodGet, "/v1/posts/missing", "", 404},
+	{"put-v1-posts-by-id", http.MethodPut, "/v1/posts/{post}", `{"title":"Notes, edited"}`, 200},
+	{"post-v1-posts-by-id-comments", http.MethodPost, "/v1/posts/{post}/comments", `{"author_id":"{id}","body":"Nice notes."}`, 201},
+	{"post-v1-posts-by-id-comments", http.MethodPost, "/v1/posts/{post}/comments", `{"author_id":"{id}","parent_id":"missing","body":"Agreed."}`, 422},
+	{"post-v1-posts-by-id-comments", http.MethodPost, "/v1/posts/missing/comments", `{"author_id":"{id}","body":"Nice notes."}`, 404},
+	{"get-v1-posts-by-id-comments", http.MethodGet, "/v1/posts/{post}/comments?per_page=1", "", 200},
+	{"get-v1-posts-by-id-comments", http.MethodGet, "/v1/posts/{post}/comments?parent_id=missing", "", 404},
+	{"get-v1-posts-by-id-comments-by-comment-id", http.MethodGet, "/v1/posts/{post}/comments/{comment}", "", 200},
+	{"get-v1-posts-by-id-comments-by-comment-id", http.MethodGet, "/v1/posts/{post}/comments/missing", "", 404},
+	{"put-v1-posts-by-id-comments-by-comment-id", http.MethodPut, "/v1/posts/{post}/comments/{comment}", `{"body":"Nice notes, thanks."}`, 200},
+	{"post-v1-posts-by-id-comments-by-comment-id-status", http.MethodPost, "/v1/posts/{post}/comments/{comment}/status", `{"status":"hidden"}`, 401},
+	{"post-v1-posts-by-id-comments-by-comment-id-status", http.MethodPost, "/v1/posts/{post}/comments/{comment}/status", `{"status":"hidden"}`, 200},
+	{"delete-v1-posts-by-id-comments-by-comment-id", http.MethodDelete, "/v1/posts/{post}/comments/{comment}", "", 204},
+	{"delete-v1-posts-by-id-comments-by-comment-id", http.MethodDelete, "/v1/posts/{post}/comments/{comment}", "", 404},
+	{"get-v1-users-by-id", http.MethodGet, "/v1/users/{grace}?include=post_count", "", 200},
+	{"get-v1-users", http.MethodGet, "/v1/users?include=post_count", "", 200},
+	{"get-v1-users", http.MethodGet, "/v1/users?include=posts.comments", "", 200},
+	{"get-v1-users", http.MethodGet, "/v1/users?include=posts.comments.author", "", 422},
+	{"delete-v1-posts-by-id", http.MethodDelete, "/v1/posts/{post}", "", 204},
+	{"delete-v1-posts-by-id", http.MethodDelete, "/v1/posts/{post}", "", 404},
+	{"put-v1-users-by-id", http.MethodPut, "/v1/users/{id}", `{"name":"Rohan","metadata":{"plan":"pro"}}`, 200},
+	{"put-v1-users-by-id-tags", http.MethodPut, "/v1/users/{id}/tags", `{"tags":["beta"]}`, 200},
+	{"get-v1-usernames-by-name-available", http.MethodGet, "/v1/usernames/ro_c/available", "", 200},
+	{"get-v1-users-by-id-preferences", http.MethodGet, "/v1/users/{id}/preferences", "", 200},
+	{"put-v1-users-by-id-preferences", http.MethodPut, "/v1/users/{id}/preferences", `{"timezone":"Europe/Vienna"}`, 200},
+	{"post-v1-users-by-id-deactivate", http.MethodPost, "/v1/users/{id}/deactivate", "", 200},
+	{"post-v1-users-by-id-activate", http.MethodPost, "/v1/users/{id}/activate", "", 200},
+	{"post-v1-users-by-id-status", http.MethodPost, "/v1/users/{id}/status", `{"status":"invited"}`, 409},
+	{"get-v1-users-by-id-data-export", http.MethodGet, "/v1/users/{id}/data-export", "", 200},
+	{"get-v1-users-by-id-data-export", http.MethodGet, "/v1/users/missing/data-export", "", 404},
+	{"delete-v1-users-by-id", http.MethodDelete, "/v1/users/{id}?mode=erase", "", 200},
+	{"delete-v1-users-by-id", http.MethodDelete, "/v1/users/{id}", "", 404},
+	{"put-admin-loglevel", http.MethodPut, "/admin/loglevel", `{"level":"debug"}`, 401},
+	{"put-admin-loglevel", http.MethodPut, "/admin/loglevel", `{"level":"debug","revert_after_minutes":5}`, 200},
+	{"get-admin-backup", http.MethodGet, "/admin/backup", "", 401},
+	{"get-admin-backup", http.MethodGet, "/admin/backup", "", 200},
+	{"post-admin-restore", http.MethodPost, "/admin/restore", "not an archive", 422},
+	{"post-admin-restore", http.MethodPost, "/admin/restore", "{backup}", 200},
+	{"post-admin-reencrypt", http.MethodPost, "/admin/reencrypt", "", 401},
+	{"post-admin-reencrypt", http.MethodPost, "/admin/reencrypt", "", 409},
+	{"post-admin-retention", http.MethodPost, "/admin/retention?dry_run=true", "", 401},
+	{"post-admin-retention", http.MethodPost, "/admin/retention", "", 200},
+	{"post-admin-impersonate-by-user-id", http.MethodPost, "/admin/impersonate/{grace}", `{"actor":"sam@support.example.com","reason":"contract test"}`, 401},
+	{"post-admin-impersonate-by-user-id", http.MethodPost, "/admin/impersonate/missing", `{"actor":"sam@support.example.com","reason":"contract test"}`, 404},
+	{"post-admin-impersonate-by-user-id", http.MethodPost, "/admin/impersonate/{grace}", `{"actor":"sam@support.example.com","reason":"contract test","ttl_minutes":5}`, 200},
+	{"get-v1-me", http.MethodGet, "/v1/me", "", 401},
+	{"get-v1-me", http.MethodGet, "/v1/me", "", 200},
+	{"get-v1-notifications", http.MethodGet, "/v1/notifications", "", 401},
+	{"get-v1-notifications", http.MethodGet, "/v1/notifications?per_page=1", "", 200},
+	{"post-v1-notifications-by-id-read", http.MethodPost, "/v1/notifications/{notification}/read", "", 200},
+	{"post-v1-notifications-by-id-read", http.MethodPost, "/v1/notifications/missing/read", "", 404},
+	{"post-v1-api-keys", http.MethodPost, "/v1/api-keys", `{"name":"ci","scopes":["users:read"]}`, 401},
+	{"post-v1-api-keys", http.MethodPost, "/v1/api-keys", `{"name":"ci","scopes":["users:read"]}`, 201},
+	{"post-v1-api-keys", http.MethodPost, "/v1/api-keys", `{"name":"ci","scopes":["everything"]}`, 422},
+	{"post-v1-api-keys", http.MethodPost, "/v1/api-keys", `{"name":"ci","scopes":["users:read"]}`, 403},
+	{"get-v1-notifications", http.MethodGet, "/v1/notifications", "", 403},
+	{"get-v1-api-keys", http.MethodGet, "/v1/api-keys", "", 401},
+	{"get-v1-api-keys", http.MethodGet, "/v1/api-keys", "", 200},
+	{"get-v1-api-keys", http.MethodGet, "/v1/api-keys", "", 403},
+	{"post-v1-api-keys-by-id-rotate", http.MethodPost, "/v1/api-keys/{apikey}/rotate", "", 401},
+	{"post-v1-api-keys-by-id-rotate", http.MethodPost, "/v1/api-keys/{apikey}/rotate", "", 200},
+	{"post-v1-api-keys-by-id-rotate", http.MethodPost, "/v1/api-keys/key_missing/rotate", "", 404},
+	{"post-v1-api-keys-by-id-rotate", http.MethodPost, "/v1/api-keys/{apikey}/rotate", "", 403},
+	{"delete-v1-api-keys-by-id", http.MethodDelete, "/v1/api-keys/{apikey}", "", 401},
+	{"delete-v1-api-keys-by-id", http.MethodDelete, "/v1/api-keys/{apikey}", "", 403},
+	{"delete-v1-api-keys-by-id", http.MethodDelete, "/v1/api-keys/{apikey}", "", 204},
+	{"delete-v1-api-keys-by-id", http.MethodDelete, "/v1/api-keys/{apikey}", "", 404},
+	{"post-admin-unlock-by-user-id", http.MethodPost, "/admin/unlock/{grace}", "", 401},
+	{"post-admin-unlock-by-user-id", http.MethodPost, "/admin/unlock/missing", "", 404},
+	{"post-admin-unlock-by-user-id", http.MethodPost, "/admin/unlock/{grace}", "", 200},
+	{"get-admin-maintenance", http.MethodGet, "/admin/maintenance", "", 401},
+	{"get-admin-maintenance", http.MethodGet, "/admin/maintenance", "", 200},
+	{"get-admin-leader", http.MethodGet, "/admin/leader", "", 401},
+	{"get-admin-leader", http.MethodGet, "/admin/leader", "", 200},
+	{"get-admin-health-history", http.MethodGet, "/admin/health/history", "", 401},
+	{"get-admin-health-history", http.MethodGet, "/admin/health/history", "", 200},
+	{"put-admin-maintenance", http.MethodPut, "/admin/maintenance", `{"mode":"on"}`, 401},
+	{"put-admin-maintenance", http.MethodPut, "/admin/maintenance", `{"mode":"off"}`, 200},
+	{"get-admin-faults", http.MethodGet, "/admin/faults", "", 401},
+	{"get-admin-faults", http.MethodGet, "/admin/faults", "", 200},
+	{"put-admin-faults", http.MethodPut, "/admin/faults", `{"rules":[]}`, 401},
+	{"put-admin-faults", http.MethodPut, "/admin/faults", `{"rules":[{"route":"/v1/nothing","rate":1,"status":503}]}`, 200},
+	{"put-admin-faults", http.MethodPut, "/admin/faults", `{"rules":[{"route":"*","rate":1,"status":503,"drop":true}]}`, 422},
+	{"get-admin-ratelimits", http.MethodGet, "/admin/ratelimits", "", 401},
+	{"put-admin-ratelimits-by-key-id", http.MethodPut, "/admin/ratelimits/key_1", `{"daily":100,"monthly":1000}`, 401},
+	{"put-admin-ratelimits-by-key-id", http.MethodPut, "/admin/ratelimits/key_1", `{"daily":100,"monthly":1000}`, 200},
+	{"put-admin-ratelimits-by-key-id", http.MethodPut, "/admin/ratelimits/key_1", `{"daily":-1,"monthly":1000}`, 422},
+	{"get-admin-ratelimits", http.MethodGet, "/admin/ratelimits", "", 200},
+	{"get-admin-ratelimits-by-key-id", http.MethodGet, "/admin/ratelimits/key_1", "", 401},
+	{"get-admin-ratelimits-by-key-id", http.MethodGet, "/admin/ratelimits/key_1", "", 200},
+	{"delete-admin-ratelimits-by-key-id", http.MethodDelete, "/admin/ratelimits/key_1", "", 401},
+	{"delete-admin-ratelimits-by-key-id", http.MethodDelete, "/admin/ratelimits/key_1", "", 204},
+	{"delete-admin-ratelimits-by-key-id", http.MethodDelete, "/admin/ratelimits/key_1", "", 404},
+	{"get-admin-traffic-blocks", http.MethodGet, "/admin/traffic/blocks", "", 401},
+	{"get-admin-traffic-blocks", http.MethodGet, "/admin/traffic/blocks", "", 200},
+	{"delete-admin-traffic-blocks-by-ip", http.MethodDelete, "/admin/traffic/blocks/203.0.113.9", "", 401},
+	{"delete-admin-traffic-blocks-by-ip", http.MethodDelete, "/admin/traffic/blocks/203.0.113.9", "", 404},
+	{"get-admin-requests", http.MethodGet, "/admin/requests", "", 401},
+	{"get-admin-requests", http.MethodGet, "/admin/requests?status=errors&limit=5", "", 200},
+	{"delete-admin-requests", http.MethodDelete, "/admin/requests", "", 401},
+	{"delete-admin-requests", http.MethodDelete, "/admin/requests", "", 200},
+	{"get-admin-inflight", http.MethodGet, "/admin/inflight", "", 401},
+	{"get-admin-inflight", http.MethodGet, "/admin/inflight", "", 200},
+	{"post-admin-inflight-by-id-cancel", http.MethodPost, "/admin/inflight/req-missing/cancel", "", 401},
+	{"post-admin-inflight-by-id-cancel", http.MethodPost, "/admin/inflight/req-missing/cancel", "", 404},
+	{"get-admin-telemetry", http.MethodGet, "/admin/telemetry", "", 401},
+	{"get-admin-telemetry", http.MethodGet, "/admin/telemetry", "", 200},
+	{"get-dev-emails-by-name", http.MethodGet, "/dev/emails/verification", "", 404},
+	{"post-admin-digest", http.MethodPost, "/admin/digest?period=weekly&dry_run=true", "", 401},
+	{"post-admin-digest", http.MethodPost, "/admin/digest?period=weekly&dry_run=true", "", 200},
+	{"get-v1-events", http.MethodGet, "/v1/events", "", 401},
+	{"get-v1-events", http.MethodGet, "/v1/events?from_seq=2&limit=5", "", 200},
+	{"get-v1-events", http.MethodGet, "/v1/events?from_seq=0", "", 422},
+	{"post-admin-events-replay", http.MethodPost, "/admin/events/replay", `{"from_seq":1}`, 401},
+	{"post-admin-events-replay", http.MethodPost, "/admin/events/replay", `{"from_seq":1}`, 409},
+	{"post-admin-events-replay", http.MethodPost, "/admin/events/replay", `{"from_seq":1,"targets":["cache_purge"]}`, 422},
+	{"get-admin-dead-letters", http.MethodGet, "/admin/dead-letters", "", 401},
+	{"get-admin-dead-letters", http.MethodGet, "/admin/dead-letters?kind=security_event&status=dead", "", 200},
+	{"get-admin-dead-letters", http.MethodGet, "/admin/dead-letters?kind=webhook", "", 422},
+	{"get-admin-dead-letters-by-id", http.MethodGet, "/admin/dead-letters/dlq_missing", "", 401},
+	{"get-admin-dead-letters-by-id", http.MethodGet, "/admin/dead-letters/dlq_missing", "", 404},
+	{"post-admin-dead-letters-by-id-requeue", http.MethodPost, "/admin/dead-letters/dlq_missing/requeue", "", 401},
+	{"post-admin-dead-letters-by-id-requeue", http.MethodPost, "/admin/dead-letters/dlq_missing/requeue", "", 404},
+	{"delete-admin-dead-letters-by-id", http.MethodDelete, "/admin/dead-letters/dlq_missing", "", 401},
+	{"delete-admin-dead-letters-by-id", http.MethodDelete, "/admin/dead-letters/dlq_missing", "", 404},
+}
+
+// TestContract calls every documented operation and validates each response
+// body against the schema the spec declares for its status code.
+func TestContract(t *testing.T) {
+	s := apitest.New(t, apitest.WithConfig(server.Config{CaptureRequests: 20, FaultInjection: true}), apitest.WithUsers(apitest.Users()...))
+	spec := s.API.OpenAPI()
+
+	covered := map[string]bool{}
+	var backup []byte
+	var userToken string               // from the last impersonation
+	var post string                    // ID of the last post created
+	var comment string                 // ID of the last comment created
+	var notification string            // ID of the newest notification listed
+	var apiKeyID, apiKey string        // the last API key created or rotated
+	var invitation, inviteToken string // the last invitation created
+	var export string                  // ID of the last export created
+	for _, c := range contractCases {
+		covered[c.op] = true
+		if c.op == "get-v1-search" && c.status == http.StatusOK && !search.BleveBuilt {
+			continue // every search fails without Bleve
+		}
+		name := fmt.Sprintf("%s %d", c.op, c.status)
+		path := strings.NewReplacer("{id}", apitest.AdaID, "{grace}", apitest.GraceID, "{post}", post, "{comment}", comment, "{notification}", notification, "{apikey}", apiKeyID, "{invitation}", invitation, "{invitetoken}", inviteToken, "{export}", export).Replace(c.path)
+		body := strings.NewReplacer("{id}", apitest.AdaID, "{grace}", apitest.GraceID).Replace(c.body)
+
+		r := s.Request(c.method, path)
+		switch {
+		case body == "{backup}":
+			r.Body(backup).Header("Content-Type", "application/gzip")
+		case c.op == "post-admin-restore":
+			r.Body(body).Header("Content-Type", "application/gzip")
+		case body != "":
+			r.Body(body)
+		}
+		op := findOperation(spec, c.op)
+		if op == nil {
+			t.Errorf("%s: operation is not in the spec", name)
+			continue
+		}
+		// Secured operations get credentials unless the case is about
+		// their absence. Cases about a 403 from an operation taking a user
+		// token use the API key, which has only the users:read scope.
+		if len(op.Security) > 0 && c.status != http.StatusUnauthorized {
+			if _, ok := op.Security[0]["userToken"]; ok && c.status == http.StatusForbidden {
+				r.Header("Authorization", "Bearer "+apiKey)
+			} else if ok {
+				r.Header("Authorization", "Bearer "+userToken)
+			} else {
+				r.AsAdmin()
+			}
+		}
+		resp := r.Do()
+		if resp.StatusCode != c.status {
+			t.Errorf("%s: got status %d, body %s", name, resp.StatusCode, resp.Body)
+			continue
+		}
+		raw := resp.Body
+		if c.op == "get-admin-backup" && resp.StatusCode == http.StatusOK {
+			backup = raw
+		}
+		if c.op == "post-admin-impersonate-by-user-id" && resp.StatusCode == http.StatusOK {
+			var tok struct{ Token string }
+			json.Unmarshal(raw, &tok)
+			userToken = tok.Token
+		}
+		if strings.HasSuffix(c.op, "posts") && resp.StatusCode == http.StatusCreated {
+			var created struct{ ID string }
+			json.Unmarshal(raw, &created)
+			post = created.ID
+		}
+		if strings.HasSuffix(c.op, "comments") && resp.StatusCode == http.StatusCreated {
+			var created struct{ ID string }
+			json.Unmarshal(raw, &created)
+			comment = created.ID
+		}
+		if strings.Contains(c.op, "api-keys") && resp.StatusCode < 300 && len(raw) > 0 {
+			var created struct{ ID, Key string }
+			json.Unmarshal(raw, &created)
+			if created.Key != "" {
+				apiKeyID, apiKey = created.ID, created.Key
+			}
+		}
+		if c.op == "post-v1-invitations" && resp.StatusCode == http.StatusCreated {
+			var created struct{ ID, Token string }
+			json.Unmarshal(raw, &created)
+			invitation, inviteToken = created.ID, created.Token
+		}
+		if c.op == "post-v1-exports" && resp.StatusCode == http.StatusAccepted {
+			var created struct{ ID string }
+			json.Unmarshal(raw, &created)
+			export = created.ID
+		}
+		if c.op == "get-v1-notifications" && resp.StatusCode == http.StatusOK {
+			var list struct{ Notifications []struct{ ID string } }
+			json.Unmarshal(raw, &list)
+			if len(list.Notifications) > 0 {
+				notification = list.Notifications[0].ID
+			}
+		}
+
+		if op.Method != c.method {
+			t.Errorf("%s: spec documents %s, not %s", name, op.Method, c.method)
+		}
+		if op.Responses[strconv.Itoa(resp.StatusCode)] == nil && resp.StatusCode < 400 {
+			t.Errorf("%s: spec does not document status %d", name, resp.StatusCode)
+			continue
+		}
+		// Newline-delimited JSON isn't one JSON document to validate.
+		if ct := resp.Header.Get("Content-Type"); ct != "" && (!strings.Contains(ct, "json") || strings.Contains(ct, "ndjson")) {
+			if op.Responses[strconv.Itoa(resp.StatusCode)].Content[ct] == nil {
+				t.Errorf("%s: spec does not document a %s body", name, ct)
+			}
+			continue
+		}
+		schema := responseSchema(op, resp.StatusCode)
+		if schema == nil {
+			if len(bytes.TrimSpace(raw)) > 0 {
+				t.Errorf("%s: spec documents no body but got %s", name, raw)
+			}
+			continue
+		}
+		var v any
+		if err := json.Unmarshal(raw, &v); err != nil {
+			t.Errorf("%s: response is not JSON: %v", name, err)
+			continue
+		}
+		for _, path := range nulls(v, "body") {
+			t.Errorf("%s: %s is null; lists should be [] and missing objects left out", name, path)
+		}
+		res := &huma.ValidateResult{}
+		huma.Validate(spec.Components.Schemas, schema, huma.NewPathBuffer([]byte{}, 0), huma.ModeReadFromServer, v, res)
+		for _, e := range res.Errors {
+			t.Errorf("%s: response does not match the spec: %v", name, e)
+		}
+	}
+
+	for _, op := range operations(spec) {
+		if !covered[op.OperationID] {
+			t.Errorf("operation %s is documented but not exercised; add it to contractCases", op.OperationID)
+		}
+	}
+}
+
+// TestContractExamples validates the named examples of every operation
+// against the schemas they illustrate, so mock servers built from the spec
+// answer with what the server would. The requests of examples answered with
+// 400 or 422 are invalid on purpose.
+func TestContractExamples(t *testing.T) {
+	s := apitest.New(t)
+	spec := s.API.OpenAPI()
+	validate := func(name string, mt *huma.MediaType, mode huma.ValidateMode, invalid map[string]bool) {
+		for _, key := range slices.Sorted(maps.Keys(mt.Examples)) {
+			if invalid[key] {
+				continue
+			}
+			raw, err := json.Marshal(mt.Examples[key].Value)
+			if err != nil {
+				t.Errorf("%s %s: %v", name, key, err)
+				continue
+			}
+			var v any
+			json.Unmarshal(raw, &v)
+			res := &huma.ValidateResult{}
+			huma.Validate(spec.Components.Schemas, mt.Schema, huma.NewPathBuffer([]byte{}, 0), mode, v, res)
+			for _, e := range res.Errors {
+				t.Errorf("%s example %s does not match the spec: %v", name, key, e)
+			}
+		}
+	}
+	examples := 0
+	for _, op := range operations(spec) {
+		invalid := map[string]bool{}
+		for _, status := range []string{"400", "422"} {
+			if resp := op.Responses[status]; resp != nil {
+				for _, mt := range resp.Content {
+					for key := range mt.Examples {
+						invalid[key] = true
+					}
+				}
+			}
+		}
+		if op.RequestBody != nil {
+			for ct, mt := range op.RequestBody.Content {
+				validate(op.OperationID+" request "+ct, mt, huma.ModeWriteToServer, invalid)
+				examples += len(mt.Examples)
+			}
+		}
+		for status, resp := range op.Responses {
+			for ct, mt := range resp.Content {
+				validate(op.OperationID+" "+status+" "+ct, mt, huma.ModeReadFromServer, nil)
+				examples += len(mt.Examples)
+			}
+		}
+	}
+	if examples == 0 {
+		t.Error("the spec has no named examples")
+	}
+}
+
+// TestContractUpToDate fails when the committed contract no longer matches
+// the spec the server generates, i.e. someone forgot `task gen:contracts`,
+// or its files no longer match their checksums.
+func TestContractUpToDate(t *testing.T) {
+	srv := server.NewServer(server.Config{}, server.NewMemoryStore())
+	if err := srv.VerifySpec("../../../../packages/api/src/contracts/v1.json", nil); err != nil {
+		t.Errorf("%v; run `task gen:contracts`", err)
+	}
+}
+
+// TestMockFixturesUpToDate checks packages/api-mock answers with what the
+// spec documents now.
+func TestMockFixturesUpToDate(t *testing.T) {
+	srv := server.NewServer(server.Config{}, server.NewMemoryStore())
+	want, err := mock.FixturesModule(srv.OpenAPI())
+	if err != nil {
+		t.Fatal(err)
+	}
+	const path = "../../../../packages/api-mock/src/fixtures.ts"
+	if got, err := os.ReadFile(path); err != nil || !bytes.Equal(got, want) {
+		t.Errorf("%s is out of date; run `task gen:contracts`", path)
+	}
+}
+
+func operations(spec *huma.OpenAPI) []*huma.Operation {
+	var ops []*huma.Operation
+	for _, item := range spec.Paths {
+		for _, op := range []*huma.Operation{item.Get, item.Put, item.Post, item.Patch, item.Delete} {
+			if op != nil {
+				ops = append(ops, op)
+			}
+		}
+	}
+	return ops
+}
+
+// nulls returns the paths of the null values in v, a decoded JSON document.
+func nulls(v any, path string) []string {
+	switch v := v.(type) {
+	case nil:
+		return []string{path}
+	case map[string]any:
+		var out []string
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			out = append(out, nulls(v[k], path+"."+k)...)
+		}
+		return out
+	case []any:
+		var out []string
+		for i, item := range v {
+			out = append(out, nulls(item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return out
+	}
+	return nil
+}
+
+func findOperation(spec *huma.OpenAPI, id string) *huma.Operation {
+	for _, op := range operations(spec) {
+		if op.OperationID == id {
+			return op
+		}
+	}
+	return nil
+}
+
+// responseSchema returns the JSON schema documented for status, falling back
+// to the default (error) response, or nil if no body is documented.
+func responseSchema(op *huma.Operation, status int) *huma.Schema {
+	resp := op.Responses[strconv.Itoa(status)]
+	if resp == nil {
+		resp = op.Responses["default"]
+	}
+	if resp == nil {
+		return nil
+	}
+	for _, ct := range []string{"application/json", "application/problem+json"} {
+		if mt := resp.Content[ct]; mt != nil {
+			return mt.Schema
+		}
+	}
+	return nil
+}
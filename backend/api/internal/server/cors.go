@@ -0,0 +1,138 @@
+package server
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/go-chi/cors"
+)
+
+// defaultCORSMaxAge is how long browsers cache a preflight unless the
+// policy says otherwise.
+const defaultCORSMaxAge = 5 * time.Minute
+
+// dashboardOrigins are the dashboard dev servers, which every policy
+// allows.
+var dashboardOrigins = []string{"http://localhost:5173", "http://localhost:5175"}
+
+// CORSPolicy is which browser origins may call a group of routes, and how.
+type CORSPolicy struct {
+	// Origins are allowed in addition to dashboardOrigins.
+	Origins []string
+	// MaxAge is how long browsers may cache the answer to a preflight,
+	// defaultCORSMaxAge if zero.
+	MaxAge time.Duration
+	// PrivateNetwork answers the Private Network Access preflights of
+	// allowed origins, which browsers send before a public page calls a
+	// server on a private network or localhost, as internal tools do.
+	PrivateNetwork bool
+}
+
+// equal reports whether p and q allow the same.
+func (p CORSPolicy) equal(q CORSPolicy) bool {
+	return slices.Equal(p.Origins, q.Origins) && p.MaxAge == q.MaxAge && p.PrivateNetwork == q.PrivateNetwork
+}
+
+// corsOptions allows the dashboard dev servers plus the policy's origins,
+// or any origin in dev mode.
+func corsOptions(policy CORSPolicy, dev bool) cors.Options {
+	var allowOrigin func(*http.Request, string) bool
+	if dev {
+		allowOrigin = func(*http.Request, string) bool { return true }
+	}
+	allowedOrigins := slices.Clone(dashboardOrigins)
+	for _, origin := range policy.Origins {
+		if origin != "" && !slices.Contains(allowedOrigins, origin) {
+			allowedOrigins = append(allowedOrigins, origin)
+		}
+	}
+	return cors.Options{
+		AllowedOrigins:   allowedOrigins,
+		AllowOriginFunc:  allowOrigin,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Captcha-Token", headerRequestID, headerCorrelationID, headerCanary},
+		ExposedHeaders:   []string{"Link", "X-Total-Count", "Retry-After", headerRequestID, headerCorrelationID, headerCanary},
+		AllowCredentials: true,
+		MaxAge:           int(cmp.Or(policy.MaxAge, defaultCORSMaxAge) / time.Second),
+		// Preflights go on to corsHandler.handler, to answer Private
+		// Network Access, which the cors package doesn't know.
+		OptionsPassthrough: true,
+	}
+}
+
+// corsHandler applies one CORSPolicy.
+type corsHandler struct {
+	cors           *cors.Cors
+	privateNetwork bool
+}
+
+func newCORSHandler(policy CORSPolicy, dev bool) *corsHandler {
+	return &corsHandler{cors: cors.New(corsOptions(policy, dev)), privateNetwork: policy.PrivateNetwork}
+}
+
+// handler serves r, answering it as the cors package does and, for
+// preflights, allowing private network access as the policy says.
+func (c *corsHandler) handler(next http.Handler) http.Handler {
+	return c.cors.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, origin := r.Header["Origin"]; r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" || !origin {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h := w.Header()
+		h.Add("Vary", "Access-Control-Request-Private-Network")
+		// Only an allowed origin got Access-Control-Allow-Origin.
+		if c.privateNetwork && r.Header.Get("Access-Control-Request-Private-Network") == "true" && h.Get("Access-Control-Allow-Origin") != "" {
+			h.Set("Access-Control-Allow-Private-Network", "true")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+// corsPolicies are the handlers of the public API's policy and the admin
+// API's, which is the same one unless Config.AdminCORS is set.
+type corsPolicies struct {
+	api, admin *corsHandler
+}
+
+func newCORSPolicies(cfg Config) *corsPolicies {
+	p := &corsPolicies{api: newCORSHandler(cfg.CORS, cfg.Dev)}
+	p.admin = p.api
+	if cfg.AdminCORS != nil {
+		p.admin = newCORSHandler(*cfg.AdminCORS, cfg.Dev)
+	}
+	return p
+}
+
+// sameCORS reports whether a and b, Config.AdminCORS values, allow the
+// same.
+func sameCORS(a, b *CORSPolicy) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.equal(*b)
+}
+
+// adminCORSString describes p, a Config.AdminCORS, for the reload log.
+func adminCORSString(p *CORSPolicy) string {
+	if p == nil {
+		return "(the API's)"
+	}
+	return fmt.Sprintf("%+v", *p)
+}
+
+// handleCORS applies the current CORS policy of each request's route
+// group: the admin API's to /admin/ and /metrics, the public API's to the
+// rest. It reads them on every request so Reload can swap them.
+func (s *Server) handleCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := s.cors.Load()
+		c := p.api
+		if adminPath(r.URL.Path) {
+			c = p.admin
+		}
+		c.handler(next).ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,46 @@
+package server_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/apitest"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/server"
+)
+
+func TestCORS(t *testing.T) {
+	s := apitest.New(t, apitest.WithConfig(server.Config{
+		CORS:      server.CORSPolicy{Origins: []string{"https://app.example.com"}, MaxAge: 10 * time.Minute},
+		AdminCORS: &server.CORSPolicy{Origins: []string{"https://tools.example.com"}, PrivateNetwork: true},
+	}))
+	preflight := func(path, origin string) *apitest.Response {
+		return s.Request(http.MethodOptions, path).
+			Header("Origin", origin).
+			Header("Access-Control-Request-Method", http.MethodGet).
+			Header("Access-Control-Request-Private-Network", "true").
+			Do().Status(http.StatusOK)
+	}
+	check := func(resp *apitest.Response, origin, maxAge, privateNetwork string) {
+		t.Helper()
+		for key, want := range map[string]string{
+			"Access-Control-Allow-Origin":          origin,
+			"Access-Control-Max-Age":               maxAge,
+			"Access-Control-Allow-Private-Network": privateNetwork,
+		} {
+			if got := resp.Header.Get(key); got != want {
+				t.Errorf("%s: %q, want %q", key, got, want)
+			}
+		}
+	}
+
+	check(preflight("/v1/users", "https://app.example.com"), "https://app.example.com", "600", "")
+	check(preflight("/v1/users", "https://tools.example.com"), "", "", "")
+	check(preflight("/admin/maintenance", "https://tools.example.com"), "https://tools.example.com", "300", "true")
+	check(preflight("/admin/maintenance", "https://app.example.com"), "", "", "")
+	// The dashboard dev servers may call both.
+	check(preflight("/admin/maintenance", "http://localhost:5173"), "http://localhost:5173", "300", "true")
+
+	resp := s.Get("/v1/users").Header("Origin", "https://app.example.com").Do().Status(http.StatusOK)
+	check(resp, "https://app.example.com", "", "")
+}
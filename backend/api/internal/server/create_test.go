@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/idgen"
+)
+
+// slowListStore takes a while to list users, so that creates running at
+// once all list them before any of them writes.
+type slowListStore struct {
+	*MemoryStore
+}
+
+func (s slowListStore) ListUsers(ctx context.Context) ([]*User, error) {
+	users, err := s.MemoryStore.ListUsers(ctx)
+	time.Sleep(5 * time.Millisecond)
+	return users, err
+}
+
+// createConcurrently runs the creates of reqs on users at once and returns
+// their errors.
+func createConcurrently(users *UserService, reqs []CreateUserRequest) []error {
+	errs := make([]error, len(reqs))
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = users.Create(context.Background(), req)
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+func TestConcurrentCreatesGetTheirOwnIDs(t *testing.T) {
+	store := NewMemoryStore()
+	// Timestamp IDs are to the second, so these all want the same one.
+	users := NewUserService(slowListStore{store}, events.New(), nil, slog.Default(), false, EmailFolding{}, idgen.Timestamp{})
+	var reqs []CreateUserRequest
+	for i := range 50 {
+		reqs = append(reqs, CreateUserRequest{Name: fmt.Sprint("User ", i), Email: fmt.Sprintf("user%d@example.com", i)})
+	}
+	created := 0
+	for _, err := range createConcurrently(users, reqs) {
+		if err == nil {
+			created++
+		}
+	}
+	stored, _ := store.ListUsers(context.Background())
+	if created != len(reqs) || len(stored) != created {
+		t.Errorf("%d creates succeeded and %d users are stored, want all %d, each its own", created, len(stored), len(reqs))
+	}
+}
+
+func TestConcurrentCreatesKeepFieldsUnique(t *testing.T) {
+	for _, c := range []struct {
+		field string
+		req   func(i int) CreateUserRequest
+	}{
+		{"username", func(i int) CreateUserRequest {
+			return CreateUserRequest{Name: "Kim", Username: "kim", Email: fmt.Sprintf("kim%d@example.com", i)}
+		}},
+		{"email", func(i int) CreateUserRequest {
+			return CreateUserRequest{Name: "Kim", Email: "kim@example.com"}
+		}},
+		{"phone", func(i int) CreateUserRequest {
+			return CreateUserRequest{Name: "Kim", Email: fmt.Sprintf("kim%d@example.com", i), Phone: "+436601234567"}
+		}},
+	} {
+		store := NewMemoryStore()
+		users := NewUserService(slowListStore{store}, events.New(), nil, slog.Default(), true, EmailFolding{}, idgen.UUIDv4{})
+		var reqs []CreateUserRequest
+		for i := range 50 {
+			reqs = append(reqs, c.req(i))
+		}
+		var created, conflicts int
+		for _, err := range createConcurrently(users, reqs) {
+			var se huma.StatusError
+			switch {
+			case err == nil:
+				created++
+			case errors.As(err, &se) && se.GetStatus() == http.StatusConflict:
+				conflicts++
+			default:
+				t.Errorf("%s: %v", c.field, err)
+			}
+		}
+		stored, _ := store.ListUsers(context.Background())
+		if created != 1 || conflicts != len(reqs)-1 || len(stored) != 1 {
+			t.Errorf("%s: %d created, %d conflicts and %d stored; want the %s taken once", c.field, created, conflicts, len(stored), c.field)
+		}
+	}
+}
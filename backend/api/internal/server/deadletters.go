@@ -0,0 +1,292 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// Kinds of dead letters: the background deliveries that end up in the
+// dead-letter queue once they run out of attempts.
+const (
+	DeadLetterSecurityEvent = "security_event" // a security event the sink didn't take; see SecurityStream
+	DeadLetterCachePurge    = "cache_purge"    // surrogate keys the CDN didn't purge; see cachePurges
+	DeadLetterEmail         = "email"          // an email the mail server kept refusing; see mailQueue
+)
+
+// Where a dead letter is.
+const (
+	DeadLetterDead     = "dead"     // waiting to be requeued or discarded
+	DeadLetterRequeued = "requeued" // back in its queue; it leaves the queue once delivered, or is dead again
+)
+
+// maxDeadLetters bounds the dead-letter queue; beyond it the oldest letters
+// are dropped.
+const maxDeadLetters = 10000
+
+// defaultDeadLetterAlert is Config.DeadLetterAlert if that is zero.
+const defaultDeadLetterAlert = 100
+
+// DeadLetterAttempt is one failed attempt at a delivery.
+type DeadLetterAttempt struct {
+	Time  timestamp.Time `json:"time" doc:"When the attempt failed"`
+	Error string         `json:"error" example:"webhook returned 503 Service Unavailable" doc:"Why it failed"`
+}
+
+// DeadLetter is a delivery that failed every attempt, kept with what it
+// was to deliver so an operator can look into it and requeue it.
+type DeadLetter struct {
+	ID        string              `json:"id" example:"dlq_3f9a1c2b7e4d" doc:"Dead letter ID"`
+	Kind      string              `json:"kind" enum:"security_event,cache_purge,email" doc:"What failed to be delivered"`
+	Status    string              `json:"status" enum:"dead,requeued" doc:"dead until requeued; requeued ones leave the queue once delivered, or are dead again"`
+	Payload   any                 `json:"payload" doc:"What was to be delivered: the security event, the surrogate keys to purge, or the email"`
+	Attempts  []DeadLetterAttempt `json:"attempts" doc:"Every failed attempt, oldest first, including those after requeueing"`
+	CreatedAt timestamp.Time      `json:"created_at" doc:"When the delivery first ran out of attempts"`
+	UpdatedAt timestamp.Time      `json:"updated_at" doc:"When the status last changed"`
+}
+
+// DeadLetters is the dead-letter queue of the background deliveries. Like
+// exports, it is kept in memory, per replica, and lost on restart. Every
+// DeadLetterAlert letters it grows by are logged as an error, and the
+// dead_letters gauge tracks its size, for alerting.
+type DeadLetters struct {
+	logger  *slog.Logger
+	metrics recorder
+	alertAt int
+
+	mu      sync.Mutex
+	letters []*DeadLetter // oldest first
+	// requeue puts the payload of a dead letter of each kind back on its
+	// queue, waiting for room until ctx ends.
+	requeue map[string]func(ctx context.Context, id string, payload any) error
+}
+
+func newDeadLetters(logger *slog.Logger, metrics recorder, alertAt int) *DeadLetters {
+	return &DeadLetters{logger: logger, metrics: metrics, alertAt: cmp.Or(alertAt, defaultDeadLetterAlert), requeue: map[string]func(context.Context, string, any) error{}}
+}
+
+// handle has dead letters of kind requeued with requeue.
+func (d *DeadLetters) handle(kind string, requeue func(ctx context.Context, id string, payload any) error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.requeue[kind] = requeue
+}
+
+// fail records that a delivery of kind ran out of attempts, returning its
+// dead letter's ID. id is the dead letter it was requeued from, if any,
+// which is dead again with attempts added to its history.
+func (d *DeadLetters) fail(kind, id string, payload any, attempts []DeadLetterAttempt) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := timestamp.Now()
+	if i := d.index(id); i >= 0 {
+		l := d.letters[i]
+		l.Status, l.UpdatedAt = DeadLetterDead, now
+		l.Attempts = append(l.Attempts, attempts...)
+		return l.ID
+	}
+	b := make([]byte, 6)
+	rand.Read(b)
+	l := &DeadLetter{ID: "dlq_" + hex.EncodeToString(b), Kind: kind, Status: DeadLetterDead, Payload: payload, Attempts: attempts, CreatedAt: now, UpdatedAt: now}
+	if len(d.letters) == maxDeadLetters {
+		oldest := d.letters[0]
+		d.logger.Warn("dead-letter queue is full, oldest dead letter dropped", "id", oldest.ID, "kind", oldest.Kind)
+		d.letters = slices.Delete(d.letters, 0, 1)
+		d.count(oldest.Kind)
+	}
+	d.letters = append(d.letters, l)
+	if n := len(d.letters); n%d.alertAt == 0 {
+		d.logger.Error("dead-letter queue is growing; list GET /admin/dead-letters", "dead_letters", n)
+	}
+	d.count(kind)
+	return l.ID
+}
+
+// delivered removes the dead letter id, if any, whose requeued delivery
+// went through.
+func (d *DeadLetters) delivered(id string) {
+	if id == "" {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if i := d.index(id); i >= 0 {
+		kind := d.letters[i].Kind
+		d.letters = slices.Delete(d.letters, i, i+1)
+		d.count(kind)
+	}
+}
+
+// index returns where the dead letter id is in d.letters, or -1. d.mu must
+// be held.
+func (d *DeadLetters) index(id string) int {
+	if id == "" {
+		return -1
+	}
+	return slices.IndexFunc(d.letters, func(l *DeadLetter) bool { return l.ID == id })
+}
+
+// count updates the gauge of kind. d.mu must be held.
+func (d *DeadLetters) count(kind string) {
+	n := 0
+	for _, l := range d.letters {
+		if l.Kind == kind {
+			n++
+		}
+	}
+	d.metrics.deadLetters(kind, n)
+}
+
+// List returns copies of the dead letters of kind and status, or of every
+// kind or status if empty, newest first.
+func (d *DeadLetters) List(kind, status string) []*DeadLetter {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := []*DeadLetter{}
+	for _, l := range slices.Backward(d.letters) {
+		if (kind == "" || l.Kind == kind) && (status == "" || l.Status == status) {
+			out = append(out, l.copy())
+		}
+	}
+	return out
+}
+
+// Get returns a copy of the dead letter id.
+func (d *DeadLetters) Get(id string) (*DeadLetter, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	i := d.index(id)
+	if i < 0 {
+		return nil, errDeadLetterNotFound
+	}
+	return d.letters[i].copy(), nil
+}
+
+// Requeue puts the dead letter id back on its queue. It stays listed, as
+// requeued, until it is delivered.
+func (d *DeadLetters) Requeue(ctx context.Context, id string) (*DeadLetter, error) {
+	d.mu.Lock()
+	i := d.index(id)
+	if i < 0 {
+		d.mu.Unlock()
+		return nil, errDeadLetterNotFound
+	}
+	l := d.letters[i]
+	if l.Status == DeadLetterRequeued {
+		d.mu.Unlock()
+		return nil, apiError(http.StatusConflict, CodeConflict, "the dead letter is already requeued")
+	}
+	requeue := d.requeue[l.Kind]
+	l.Status, l.UpdatedAt = DeadLetterRequeued, timestamp.Now()
+	requeued := l.copy()
+	d.mu.Unlock()
+
+	// Outside the lock: the delivery may fail, and land here, before
+	// requeue returns.
+	if err := requeue(ctx, id, requeued.Payload); err != nil {
+		d.mu.Lock()
+		if i := d.index(id); i >= 0 && d.letters[i].Status == DeadLetterRequeued {
+			d.letters[i].Status = DeadLetterDead
+		}
+		d.mu.Unlock()
+		return nil, err
+	}
+	return requeued, nil
+}
+
+// Delete discards the dead letter id.
+func (d *DeadLetters) Delete(id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	i := d.index(id)
+	if i < 0 {
+		return errDeadLetterNotFound
+	}
+	kind := d.letters[i].Kind
+	d.letters = slices.Delete(d.letters, i, i+1)
+	d.count(kind)
+	return nil
+}
+
+func (l *DeadLetter) copy() *DeadLetter {
+	c := *l
+	c.Attempts = slices.Clone(l.Attempts)
+	return &c
+}
+
+var errDeadLetterNotFound = apiError(http.StatusNotFound, CodeDeadLetterNotFound, "dead letter not found")
+
+type DeadLettersInput struct {
+	AdminInput
+	Kind   string `query:"kind" enum:"security_event,cache_purge,email" doc:"Only list dead letters of this kind"`
+	Status string `query:"status" enum:"dead,requeued" doc:"Only list dead letters with this status"`
+}
+
+type DeadLetterList struct {
+	DeadLetters []*DeadLetter `json:"dead_letters" doc:"The dead letters, newest first"`
+}
+
+type DeadLettersOutput struct {
+	Body *DeadLetterList
+}
+
+type DeadLetterIDInput struct {
+	AdminInput
+	ID string `path:"id" example:"dlq_3f9a1c2b7e4d" doc:"Dead letter ID"`
+}
+
+type DeadLetterOutput struct {
+	Body *DeadLetter
+}
+
+// listDeadLetters is the get-admin-dead-letters handler.
+func (s *Server) listDeadLetters(ctx context.Context, input *DeadLettersInput) (*DeadLettersOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	return &DeadLettersOutput{Body: &DeadLetterList{DeadLetters: s.deadLetters.List(input.Kind, input.Status)}}, nil
+}
+
+// getDeadLetter is the get-admin-dead-letters-by-id handler.
+func (s *Server) getDeadLetter(ctx context.Context, input *DeadLetterIDInput) (*DeadLetterOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	l, err := s.deadLetters.Get(input.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &DeadLetterOutput{Body: l}, nil
+}
+
+// requeueDeadLetter is the post-admin-dead-letters-by-id-requeue handler.
+func (s *Server) requeueDeadLetter(ctx context.Context, input *DeadLetterIDInput) (*DeadLetterOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	l, err := s.deadLetters.Requeue(ctx, input.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "dead letter requeued", "id", l.ID, "kind", l.Kind)
+	return &DeadLetterOutput{Body: l}, nil
+}
+
+// deleteDeadLetter is the delete-admin-dead-letters-by-id handler.
+func (s *Server) deleteDeadLetter(ctx context.Context, input *DeadLetterIDInput) (*struct{}, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	if err := s.deadLetters.Delete(input.ID); err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "dead letter discarded", "id", input.ID)
+	return nil, nil
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+)
+
+// failingSink fails every send while failing is set, and keeps the events
+// it takes otherwise.
+type failingSink struct {
+	recordingSink
+	failing atomic.Bool
+}
+
+func (f *failingSink) Send(ctx context.Context, e SecurityEvent) error {
+	if f.failing.Load() {
+		return errors.New("sink is down")
+	}
+	return f.recordingSink.Send(ctx, e)
+}
+
+func TestDeadLetters(t *testing.T) {
+	sink := &failingSink{}
+	sink.failing.Store(true)
+	s := NewServer(Config{SecurityEvents: sink}, NewMemoryStore())
+	s.security.retryDelay = time.Millisecond
+	ctx := context.Background()
+	deadLetters := func() []*DeadLetter {
+		t.Helper()
+		for range 100 {
+			if l := s.deadLetters.List("", DeadLetterDead); len(l) > 0 {
+				return l
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return nil
+	}
+	go s.security.run()
+	s.bus.Publish(ctx, events.Event{Type: EventAuthFailed})
+	dead := deadLetters()
+	if len(dead) != 1 || dead[0].Kind != DeadLetterSecurityEvent || len(dead[0].Attempts) != securitySendAttempts || dead[0].Attempts[0].Error != "sink is down" {
+		t.Fatalf("dead letters %+v, want the event after %d failed attempts", dead, securitySendAttempts)
+	}
+	id := dead[0].ID
+
+	// Failing again adds to its history rather than making another.
+	if _, err := s.deadLetters.Requeue(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if dead = deadLetters(); len(dead) != 1 || dead[0].ID != id || len(dead[0].Attempts) < 2*securitySendAttempts {
+		t.Fatalf("after failing again: %+v, want one dead letter with both histories", dead)
+	}
+
+	sink.failing.Store(false)
+	for _, l := range s.deadLetters.List("", DeadLetterDead) {
+		if _, err := s.deadLetters.Requeue(ctx, l.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.security.close(ctx)
+	if left := s.deadLetters.List("", ""); len(left) != 0 || len(sink.events) == 0 || sink.events[0].Type != EventAuthFailed {
+		t.Errorf("after delivering: %d dead letters, delivered %+v", len(left), sink.events)
+	}
+	if _, err := s.deadLetters.Get(id); !errors.Is(err, errDeadLetterNotFound) {
+		t.Errorf("delivered dead letter: %v, want not found", err)
+	}
+}
@@ -0,0 +1,266 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// DedupePolicy keeps an operation from running twice for the same request
+// in quick succession, declared on the operation with deduplicated. It is
+// for forms submitted twice by a double click: within Window of the first
+// request, an identical one, from the same client, gets the first one's
+// successful response instead of creating a second user or post. A
+// duplicate that arrives while the first is still being served waits for
+// it.
+type DedupePolicy struct {
+	Window time.Duration
+}
+
+// dedupePolicyKey is the operation metadata deduplicated puts the policy
+// under.
+const dedupePolicyKey = "dedupePolicy"
+
+// doubleSubmitWindow is the window of the operations creating something
+// from a form.
+const doubleSubmitWindow = 5 * time.Second
+
+// maxDedupedResponses bounds how many responses are remembered; requests
+// beyond it aren't deduplicated.
+const maxDedupedResponses = 10_000
+
+// deduplicatedHeader marks a response replayed for a duplicate request.
+const deduplicatedHeader = "X-Deduplicated"
+
+// deduplicated is the Metadata of an operation whose duplicate requests
+// within window get the first one's response.
+func deduplicated(window time.Duration) map[string]any {
+	return map[string]any{dedupePolicyKey: DedupePolicy{Window: window}}
+}
+
+// dedupedResponse is the response to a request, once served, that
+// duplicates get.
+type dedupedResponse struct {
+	done    chan struct{} // closed once the response is known
+	expires time.Time
+	// replay is whether duplicates get the response. Only successful
+	// ones are replayed: a request that failed created nothing, and its
+	// duplicate may carry what it lacked, like a solved CAPTCHA.
+	replay bool
+	status int
+	header http.Header
+	body   []byte
+}
+
+// requestDedupe remembers the responses of deduplicated operations, by a
+// hash of the request. Like notifications, they are kept in memory, per
+// replica, which is where the duplicates of a double click land as long as
+// the load balancer keeps connections to one replica.
+type requestDedupe struct {
+	mu        sync.Mutex
+	responses map[string]*dedupedResponse
+}
+
+func newRequestDedupe() *requestDedupe {
+	return &requestDedupe{responses: map[string]*dedupedResponse{}}
+}
+
+// claim returns the response remembered for key, or, if there is none,
+// starts one that the caller must finish. It returns nil for both if
+// there's no room to remember another.
+func (d *requestDedupe) claim(key string, now time.Time) (first, started *dedupedResponse) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for k, r := range d.responses {
+		if !r.expires.IsZero() && !now.Before(r.expires) {
+			delete(d.responses, k)
+		}
+	}
+	if r, ok := d.responses[key]; ok {
+		return r, nil
+	}
+	if len(d.responses) >= maxDedupedResponses {
+		return nil, nil
+	}
+	r := &dedupedResponse{done: make(chan struct{})}
+	d.responses[key] = r
+	return nil, r
+}
+
+// finish records the response started for key, kept for window, or forgets
+// it if it isn't to be replayed.
+func (d *requestDedupe) finish(key string, r *dedupedResponse, window time.Duration) {
+	d.mu.Lock()
+	if r.replay {
+		r.expires = time.Now().Add(window)
+	} else {
+		delete(d.responses, key)
+	}
+	d.mu.Unlock()
+	close(r.done)
+}
+
+// dedupeKey hashes what makes two requests the same one: the method, URL,
+// client, requested format, language and time zone, and body. The client is
+// who the credentials name, or without any, its address.
+func dedupeKey(ctx huma.Context, body []byte) string {
+	client := ctx.Header("Authorization")
+	if client == "" {
+		client = remoteHost(ctx.RemoteAddr())
+	}
+	u := ctx.URL()
+	h := sha256.New()
+	for _, part := range []string{ctx.Method(), u.RequestURI(), client, ctx.Header("Accept"), ctx.Header("Accept-Language"), ctx.Header(timeZoneHeader)} {
+		io.WriteString(h, part)
+		h.Write([]byte{0})
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// dedupe is a huma middleware giving duplicates of requests to operations
+// with a DedupePolicy the first request's response, marked with
+// X-Deduplicated.
+func (s *Server) dedupe(ctx huma.Context, next func(huma.Context)) {
+	op := ctx.Operation()
+	policy, ok := op.Metadata[dedupePolicyKey].(DedupePolicy)
+	if !ok {
+		next(ctx)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(ctx.BodyReader(), op.MaxBodyBytes+1))
+	rest := io.MultiReader(bytes.NewReader(body), ctx.BodyReader())
+	if err != nil || int64(len(body)) > op.MaxBodyBytes {
+		// Let huma report the body it can't read.
+		next(&recordingContext{humaContext: ctx, body: rest})
+		return
+	}
+	key := dedupeKey(ctx, body)
+	for {
+		first, started := s.dedupes.claim(key, time.Now())
+		if first == nil && started == nil {
+			next(&recordingContext{humaContext: ctx, body: rest})
+			return
+		}
+		if started != nil {
+			s.serveFirst(ctx, next, key, started, body, policy)
+			return
+		}
+		select {
+		case <-first.done:
+		case <-ctx.Context().Done():
+			return
+		}
+		if first.replay {
+			s.metrics.deduplicated(op.OperationID)
+			s.logger.DebugContext(ctx.Context(), "replayed the response of a duplicate request", "operation", op.OperationID)
+			for name, values := range first.header {
+				ctx.SetHeader(name, values[0])
+				for _, v := range values[1:] {
+					ctx.AppendHeader(name, v)
+				}
+			}
+			ctx.SetHeader(deduplicatedHeader, "true")
+			ctx.SetStatus(first.status)
+			ctx.BodyWriter().Write(first.body)
+			return
+		}
+		// The first one failed, so this one is tried in its place, unless
+		// another duplicate got there first.
+	}
+}
+
+// serveFirst serves the first of possibly duplicate requests, recording its
+// response in r.
+func (s *Server) serveFirst(ctx huma.Context, next func(huma.Context), key string, r *dedupedResponse, body []byte, policy DedupePolicy) {
+	rc := &recordingContext{humaContext: ctx, body: bytes.NewReader(body), header: http.Header{}, status: http.StatusOK}
+	defer func() {
+		r.status, r.header, r.body = rc.status, rc.header, rc.out.Bytes()
+		r.replay = rc.status < http.StatusMultipleChoices
+		if p := recover(); p != nil {
+			r.replay = false
+			s.dedupes.finish(key, r, policy.Window)
+			panic(p)
+		}
+		s.dedupes.finish(key, r, policy.Window)
+	}()
+	next(rc)
+}
+
+// humaContext lets recordingContext embed a huma.Context without the field
+// hiding its Context method.
+type humaContext = huma.Context
+
+// recordingContext replays the request body the dedupe middleware read and,
+// if it has a header to record into, records the response as it is written.
+type recordingContext struct {
+	humaContext
+	body   io.Reader
+	header http.Header // nil unless recording
+	status int
+	out    bytes.Buffer
+}
+
+// Unwrap lets humachi.Unwrap reach the request and response.
+func (c *recordingContext) Unwrap() huma.Context { return c.humaContext }
+
+func (c *recordingContext) BodyReader() io.Reader { return c.body }
+
+func (c *recordingContext) SetStatus(code int) {
+	c.status = code
+	c.humaContext.SetStatus(code)
+}
+
+func (c *recordingContext) SetHeader(name, value string) {
+	if c.header != nil {
+		c.header.Set(name, value)
+	}
+	c.humaContext.SetHeader(name, value)
+}
+
+func (c *recordingContext) AppendHeader(name, value string) {
+	if c.header != nil {
+		c.header.Add(name, value)
+	}
+	c.humaContext.AppendHeader(name, value)
+}
+
+func (c *recordingContext) BodyWriter() io.Writer {
+	if c.header == nil {
+		return c.humaContext.BodyWriter()
+	}
+	return io.MultiWriter(c.humaContext.BodyWriter(), &c.out)
+}
+
+// documentDeduplication adds X-Deduplicated to the spec of the successful
+// responses of the operations declaring a DedupePolicy.
+func documentDeduplication(spec *huma.OpenAPI) {
+	for _, item := range spec.Paths {
+		for _, op := range []*huma.Operation{item.Get, item.Put, item.Post, item.Delete, item.Patch} {
+			if op == nil {
+				continue
+			}
+			if _, ok := op.Metadata[dedupePolicyKey].(DedupePolicy); !ok {
+				continue
+			}
+			for key, resp := range op.Responses {
+				if len(key) != 3 || key[0] != '2' {
+					continue
+				}
+				if resp.Headers == nil {
+					resp.Headers = map[string]*huma.Param{}
+				}
+				resp.Headers[deduplicatedHeader] = &huma.Param{
+					Description: "true if the response is that of an identical request made just before, which this one duplicated",
+					Schema:      &huma.Schema{Type: "string", Enum: []any{"true"}},
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/email"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/redact"
+)
+
+// devBodyLogLimit caps how much of each request and response body dev mode
+// logs.
+const devBodyLogLimit = 4 << 10
+
+// prettyJSONFormat is huma's JSON format indented for reading in a terminal.
+var prettyJSONFormat = huma.Format{
+	Marshal: func(w io.Writer, v any) error {
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	},
+	Unmarshal: json.Unmarshal,
+}
+
+// devPanicError is the 500 body dev mode sends for a panicking handler.
+type devPanicError struct {
+	ErrorModel
+	Stack []string `json:"stack"`
+}
+
+// recoverWithStack turns a panic into a 500 whose body carries the panic
+// value and the stack trace, so it shows up next to the failing request in
+// the browser. Only dev mode uses it; the traces leak internals.
+func recoverWithStack(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+				stack := string(debug.Stack())
+				logger.Error("handler panicked", "method", r.Method, "route", routePattern(r), "params", requestParams(r), "panic", rec, "stack", stack)
+				body := devPanicError{
+					ErrorModel: ErrorModel{
+						Code:   CodeInternal,
+						Title:  http.StatusText(http.StatusInternalServerError),
+						Status: http.StatusInternalServerError,
+						Detail: fmt.Sprint(rec),
+					},
+					Stack: strings.Split(strings.TrimSpace(stack), "\n"),
+				}
+				w.Header().Set("Content-Type", "application/problem+json")
+				w.WriteHeader(http.StatusInternalServerError)
+				prettyJSONFormat.Marshal(w, body)
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// logBodies logs every request with its request and response bodies, each
+// truncated to devBodyLogLimit. Like the bodies, the parameters are
+// redacted, so the log is safe to share.
+func logBodies(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var reqBody []byte
+			if r.Body != nil {
+				reqBody, _ = io.ReadAll(r.Body)
+				r.Body = io.NopCloser(bytes.NewReader(reqBody))
+			}
+			rec := &bodyRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			logger.Info("request",
+				"method", r.Method,
+				"route", routePattern(r),
+				"ip", remoteHost(r.RemoteAddr),
+				"params", requestParams(r),
+				"status", rec.status,
+				"request_body", truncateBody(redact.JSON(reqBody)),
+				"response_body", truncateBody(redact.JSON(rec.body.Bytes())),
+			)
+		})
+	}
+}
+
+// bodyRecorder passes a response through while keeping a copy of its status
+// and the first devBodyLogLimit bytes of its body.
+type bodyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bodyRecorder) WriteHeader(status int) {
+	b.status = status
+	b.ResponseWriter.WriteHeader(status)
+}
+
+func (b *bodyRecorder) Write(p []byte) (int, error) {
+	if room := devBodyLogLimit + 1 - b.body.Len(); room > 0 {
+		b.body.Write(p[:min(len(p), room)])
+	}
+	return b.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (b *bodyRecorder) Unwrap() http.ResponseWriter {
+	return b.ResponseWriter
+}
+
+func truncateBody(b []byte) string {
+	if len(b) > devBodyLogLimit {
+		return string(b[:devBodyLogLimit]) + "…"
+	}
+	return string(b)
+}
+
+// devRepeatLimit is how often dev mode lets one request make an identical
+// store read before it warns about it.
+const devRepeatLimit = 2
+
+// storeReads counts the store reads of one request, by method and
+// arguments.
+type storeReads struct {
+	mu       sync.Mutex
+	counts   map[string]int
+	repeated []string          // the reads that went over devRepeatLimit, in order
+	stacks   map[string]string // of the read that did
+}
+
+type storeReadsKey struct{}
+
+// read counts call, noting the stack the first time it goes over
+// devRepeatLimit.
+func (r *storeReads) read(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counts[call]++
+	if r.counts[call] == devRepeatLimit+1 {
+		r.repeated = append(r.repeated, call)
+		r.stacks[call] = string(debug.Stack())
+	}
+}
+
+// detectRepeatedReads warns about every store read a request made more than
+// devRepeatLimit times with the same arguments, with the route and the stack
+// of the read that went over. That is the sign of an N+1: a read per item of
+// a list, like per user for ?include=, that should be one read for all of
+// them. Only dev mode uses it, through countedStore.
+func detectRepeatedReads(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reads := &storeReads{counts: map[string]int{}, stacks: map[string]string{}}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), storeReadsKey{}, reads)))
+			reads.mu.Lock()
+			defer reads.mu.Unlock()
+			for _, call := range reads.repeated {
+				logger.Warn("repeated store read, likely an N+1",
+					"method", r.Method,
+					"route", routePattern(r),
+					"call", call,
+					"count", reads.counts[call],
+					"stack", reads.stacks[call],
+				)
+			}
+		})
+	}
+}
+
+// countedStore counts the reads of each request for detectRepeatedReads.
+// Its arguments are IDs, which the warnings show as is, like slow request
+// logs do.
+type countedStore struct {
+	Store
+}
+
+func (c countedStore) count(ctx context.Context, method string, args ...string) {
+	if reads, ok := ctx.Value(storeReadsKey{}).(*storeReads); ok {
+		reads.read(method + "(" + strings.Join(args, ", ") + ")")
+	}
+}
+
+// withTx counts the transaction's reads like any others.
+func (c countedStore) withTx(ctx context.Context, fn func(tx Store) error) error {
+	return WithTx(ctx, c.Store, func(tx Store) error { return fn(countedStore{tx}) })
+}
+
+func (c countedStore) GetUser(ctx context.Context, id string) (*User, error) {
+	c.count(ctx, "GetUser", id)
+	return c.Store.GetUser(ctx, id)
+}
+
+func (c countedStore) ListUsers(ctx context.Context) ([]*User, error) {
+	c.count(ctx, "ListUsers")
+	return c.Store.ListUsers(ctx)
+}
+
+func (c countedStore) GetPreferences(ctx context.Context, userID string) (*UserPreferences, error) {
+	c.count(ctx, "GetPreferences", userID)
+	return c.Store.GetPreferences(ctx, userID)
+}
+
+func (c countedStore) GetCredentials(ctx context.Context, userID string) (*Credentials, error) {
+	c.count(ctx, "GetCredentials", userID)
+	return c.Store.GetCredentials(ctx, userID)
+}
+
+func (c countedStore) GetPost(ctx context.Context, id string) (*Post, error) {
+	c.count(ctx, "GetPost", id)
+	return c.Store.GetPost(ctx, id)
+}
+
+func (c countedStore) ListPosts(ctx context.Context) ([]*Post, error) {
+	c.count(ctx, "ListPosts")
+	return c.Store.ListPosts(ctx)
+}
+
+func (c countedStore) GetComment(ctx context.Context, id string) (*Comment, error) {
+	c.count(ctx, "GetComment", id)
+	return c.Store.GetComment(ctx, id)
+}
+
+func (c countedStore) ListComments(ctx context.Context, postIDs ...string) ([]*Comment, error) {
+	c.count(ctx, "ListComments", postIDs...)
+	return c.Store.ListComments(ctx, postIDs...)
+}
+
+func (c countedStore) GetInvitation(ctx context.Context, id string) (*StoredInvitation, error) {
+	c.count(ctx, "GetInvitation", id)
+	return c.Store.GetInvitation(ctx, id)
+}
+
+func (c countedStore) ListInvitations(ctx context.Context) ([]*StoredInvitation, error) {
+	c.count(ctx, "ListInvitations")
+	return c.Store.ListInvitations(ctx)
+}
+
+type EmailPreviewInput struct {
+	Name   string `path:"name" enum:"verification,reset,digest,invitation" doc:"Email to render"`
+	Lang   string `query:"lang" default:"en" example:"de" doc:"Language to render it in; regional tags fall back to their base language, and missing variants to English"`
+	Format string `query:"format" enum:"html,text" default:"html" doc:"Which part of the email to show"`
+}
+
+type EmailPreviewOutput struct {
+	ContentType string `header:"Content-Type"`
+	Subject     string `header:"X-Email-Subject" doc:"The rendered subject line"`
+	Body        []byte
+}
+
+// errNotDev is what dev-only operations answer outside dev mode.
+var errNotDev = apiError(http.StatusNotFound, CodeNotFound, "only available in dev mode")
+
+// previewEmail is the get-dev-emails-by-name handler. It renders an email
+// with the sample data from email.Samples.
+func (s *Server) previewEmail(ctx context.Context, input *EmailPreviewInput) (*EmailPreviewOutput, error) {
+	if !s.cfg.Dev {
+		return nil, errNotDev
+	}
+	msg, err := email.Render(input.Name, input.Lang, email.Samples[input.Name])
+	if err != nil {
+		return nil, err
+	}
+	out := &EmailPreviewOutput{ContentType: "text/html; charset=utf-8", Subject: mime.QEncoding.Encode("utf-8", msg.Subject), Body: []byte(msg.HTML)}
+	if input.Format == "text" {
+		out.ContentType, out.Body = "text/plain; charset=utf-8", []byte(msg.Text)
+	}
+	return out, nil
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestDetectRepeatedReads(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+	m.PutUser(ctx, &User{ID: "a", Name: "Ada"})
+	m.PutPost(ctx, &Post{ID: "p1", AuthorID: "a"})
+	m.PutPost(ctx, &Post{ID: "p2", AuthorID: "a"})
+	store := countedStore{m}
+	var logs bytes.Buffer
+	router := chi.NewRouter()
+	router.Use(detectRepeatedReads(slog.New(slog.NewTextHandler(&logs, nil))))
+	router.Get("/v1/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		// A ListComments per post, as an N+1 would, and the user read
+		// twice, which is within the limit.
+		for range 2 {
+			store.GetUser(r.Context(), "a")
+		}
+		for range 3 {
+			store.ListComments(r.Context(), "p1", "p2")
+		}
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/users/a", nil))
+
+	out := logs.String()
+	if n := strings.Count(out, "repeated store read"); n != 1 {
+		t.Fatalf("%d warnings, want one:\n%s", n, out)
+	}
+	for _, want := range []string{`route=/v1/users/{id}`, `call="ListComments(p1, p2)"`, "count=3", "TestDetectRepeatedReads"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("warning lacks %s:\n%s", want, out)
+		}
+	}
+}
+
+func TestDevMode(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	// Bodies are logged, redacted.
+	echo := logBodies(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"active","password":"hunter22"}`))
+	}))
+	r := httptest.NewRequest(http.MethodPost, "/v1/users?token=secret", strings.NewReader(`{"status":"active","password":"hunter22"}`))
+	echo.ServeHTTP(httptest.NewRecorder(), r)
+	if got := logs.String(); !strings.Contains(got, `active`) || strings.Contains(got, "hunter22") || strings.Contains(got, "secret") {
+		t.Errorf("logged %s, want the bodies with the password and token redacted", got)
+	}
+
+	// A panic's 500 carries the panic value and stack.
+	panicky := recoverWithStack(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	w := httptest.NewRecorder()
+	panicky.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/users", nil))
+	var body devPanicError
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Code != http.StatusInternalServerError || body.Detail != "boom" || len(body.Stack) == 0 {
+		t.Errorf("panic answered %d %s, want a 500 with the panic and a stack", w.Code, w.Body)
+	}
+
+	// JSON is indented, and pages on any origin may call the API.
+	s := NewServer(Config{Dev: true}, NewMemoryStore())
+	r = httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+	r.Header.Set("Origin", "https://anywhere.example.com")
+	w = httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, r)
+	if !strings.Contains(w.Body.String(), "\n  ") {
+		t.Errorf("body %s, want it indented", w.Body)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got == "" {
+		t.Error("an unlisted origin wasn't allowed")
+	}
+	w = httptest.NewRecorder()
+	NewServer(Config{}, NewMemoryStore()).Handler().ServeHTTP(w, r)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" || strings.Contains(w.Body.String(), "\n  ") {
+		t.Errorf("outside dev mode: allowed origin %q, body %s", got, w.Body)
+	}
+}
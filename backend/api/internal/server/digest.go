@@ -0,0 +1,260 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/email"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/lock"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// Digest periods, as in NotificationPreferences.Digest.
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// DigestReport is the outcome of sending one period's digests.
+type DigestReport struct {
+	Period       string         `json:"period" enum:"daily,weekly" doc:"Which users' digests were sent"`
+	DryRun       bool           `json:"dry_run" doc:"Whether the run only counted who would get one"`
+	Since        timestamp.Time `json:"since" doc:"Start of the activity summarized: a day or a week ago"`
+	NewUsers     int            `json:"new_users" doc:"Users created since then"`
+	UpdatedUsers int            `json:"updated_users" doc:"Other users changed since then"`
+	Sent         int            `json:"sent" doc:"Digests sent, or that would be on a dry run"`
+	Queued       int            `json:"queued" doc:"Of those sent, the ones queued to be retried, as the mail server refused them or is refusing others"`
+	Failed       int            `json:"failed" doc:"Digests that couldn't be sent, or queued"`
+}
+
+type DigestInput struct {
+	AdminInput
+	Period string `query:"period" enum:"daily,weekly" default:"daily" doc:"Send the digests of the users who get them this often"`
+	DryRun bool   `query:"dry_run" doc:"Only count who would get one"`
+}
+
+type DigestOutput struct {
+	Body *DigestReport
+}
+
+// digestActivity counts, from the audit log, the users created and the
+// other users changed since since.
+func (s *Server) digestActivity(since time.Time) (created, updated int) {
+	changes := map[string]bool{"user.updated": true}
+	for _, typ := range userStatusEvents {
+		changes[typ] = true
+	}
+	createdIDs, updatedIDs := map[string]bool{}, map[string]bool{}
+	for _, e := range s.audit.Entries() {
+		switch {
+		case e.Time.Before(since):
+		case e.Type == "user.created":
+			createdIDs[e.Subject] = true
+		case changes[e.Type]:
+			updatedIDs[e.Subject] = true
+		}
+	}
+	for id := range updatedIDs {
+		if !createdIDs[id] {
+			updated++
+		}
+	}
+	return len(createdIDs), updated
+}
+
+// sendDigests emails the digest of the last day or week to every active
+// user whose preferences ask for period, in their locale and time zone. A user the digest
+// can't be sent to is counted and logged, and doesn't stop the others. Only
+// one run sends a period's digests at a time; others fail with
+// errAlreadyRunning. Dry runs send nothing, so they don't wait their turn.
+func (s *Server) sendDigests(ctx context.Context, period string, dryRun bool) (report *DigestReport, err error) {
+	if dryRun {
+		return s.digestRun(ctx, period, true)
+	}
+	err = s.exclusive(ctx, lockDigests+":"+period, func(ctx context.Context) error {
+		report, err = s.digestRun(ctx, period, dryRun)
+		return err
+	})
+	return report, err
+}
+
+func (s *Server) digestRun(ctx context.Context, period string, dryRun bool) (*DigestReport, error) {
+	since := time.Now().UTC().AddDate(0, 0, -1)
+	if period == DigestWeekly {
+		since = time.Now().UTC().AddDate(0, 0, -7)
+	}
+	report := &DigestReport{Period: period, DryRun: dryRun, Since: timestamp.From(since)}
+	report.NewUsers, report.UpdatedUsers = s.digestActivity(since)
+
+	users, err := s.users.store.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		if user.Status != UserStatusActive || user.Email == "" {
+			continue
+		}
+		prefs, err := s.users.store.GetPreferences(ctx, user.ID)
+		if errors.Is(err, ErrNotFound) {
+			prefs, err = defaultPreferences(), nil
+		}
+		if err != nil {
+			return report, err
+		}
+		if prefs.Notifications.Digest != period {
+			continue
+		}
+		if dryRun {
+			report.Sent++
+			continue
+		}
+		queued, err := s.sendDigest(ctx, user, localeOf(prefs), report)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "failed to send digest", "user", user.ID, "err", err)
+			report.Failed++
+			continue
+		}
+		report.Sent++
+		if queued {
+			report.Queued++
+		}
+	}
+	return report, nil
+}
+
+// sendDigest sends user the digest of report, or queues it to be retried.
+func (s *Server) sendDigest(ctx context.Context, user *User, locale requestLocale, report *DigestReport) (queued bool, err error) {
+	data := email.DigestData{
+		Name:         user.Name,
+		Period:       report.Period,
+		Since:        report.Since.In(locale.Location),
+		NewUsers:     report.NewUsers,
+		UpdatedUsers: report.UpdatedUsers,
+	}
+	if s.cfg.AppURL != "" {
+		data.PreferencesLink = s.cfg.AppURL + "/settings/notifications"
+	}
+	msg, err := email.Render(email.Digest, locale.Lang, data)
+	if err != nil {
+		return false, err
+	}
+	return s.mail.send(ctx, user.Email, msg)
+}
+
+// mailer is cfg.Mailer, or one that logs what it would have sent.
+func (s *Server) mailer() email.Sender {
+	if s.cfg.Mailer != nil {
+		return s.cfg.Mailer
+	}
+	return logMailer{s}
+}
+
+// logMailer stands in for a mail server in development: it logs the
+// subject of each email instead of sending it.
+type logMailer struct{ s *Server }
+
+func (l logMailer) Send(ctx context.Context, _ string, msg *email.Message) error {
+	l.s.logger.InfoContext(ctx, "email not sent; set SMTP_ADDR to send it", "subject", msg.Subject)
+	return nil
+}
+
+// nextDigestRun is the next time after now that the clock reads at, a
+// time of day in UTC.
+func nextDigestRun(now time.Time, at time.Duration) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).Add(at)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// digestLoop sends the daily digests every day at cfg.DigestAt, and the
+// weekly ones too on cfg.DigestWeekday, until ctx is done.
+func (s *Server) digestLoop(ctx context.Context) {
+	for {
+		next := nextDigestRun(time.Now(), s.cfg.DigestAt)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if m := s.maintenance.Load(); m != nil && m.Mode != MaintenanceOff {
+			s.logger.InfoContext(ctx, "skipped digests during maintenance")
+			continue
+		}
+		if !s.leader.IsLeader() {
+			continue
+		}
+		// The leader claims the day before sending, and never releases the
+		// claim, so one that takes over later that day, or whose timer
+		// fires late, doesn't send them again.
+		if _, err := s.locks.TryLock(ctx, lockDigests+"@"+next.Format(time.DateOnly), 12*time.Hour); err != nil {
+			if errors.Is(err, lock.ErrLocked) {
+				s.logger.InfoContext(ctx, "skipped digests, another replica sends them")
+			} else {
+				s.logger.ErrorContext(ctx, "skipped digests, couldn't claim them", "err", err)
+			}
+			continue
+		}
+		periods := []string{DigestDaily}
+		if next.Weekday() == s.cfg.DigestWeekday {
+			periods = append(periods, DigestWeekly)
+		}
+		for _, period := range periods {
+			report, err := s.sendDigests(ctx, period, false)
+			if err != nil {
+				s.logger.ErrorContext(ctx, "failed to send digests", "period", period, "err", err)
+				continue
+			}
+			s.logger.InfoContext(ctx, "sent digests", "period", period, "sent", report.Sent, "failed", report.Failed)
+		}
+	}
+}
+
+// parseTimeOfDay parses HH:MM into the time since midnight.
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("want a time of day like 07:30, got %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// parseWeekday parses an English weekday name, like monday.
+func parseWeekday(s string) (time.Weekday, error) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(d.String(), s) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("want a weekday like monday, got %q", s)
+}
+
+// parseAppURL checks that s is an absolute URL and drops any trailing
+// slash, so paths can be appended to it.
+func parseAppURL(s string) (string, error) {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("want an absolute URL, got %q", s)
+	}
+	return strings.TrimSuffix(s, "/"), nil
+}
+
+// applyDigest is the post-admin-digest handler.
+func (s *Server) applyDigest(ctx context.Context, input *DigestInput) (*DigestOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	report, err := s.sendDigests(ctx, input.Period, input.DryRun)
+	if err != nil {
+		return nil, err
+	}
+	return &DigestOutput{Body: report}, nil
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	_ "embed"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// disposableDomainsTxt is the built-in list of disposable email domains.
+//
+//go:embed disposable_domains.txt
+var disposableDomainsTxt string
+
+// defaultDisposableRefresh is how often DISPOSABLE_DOMAINS_URL is fetched
+// again without DISPOSABLE_DOMAINS_REFRESH.
+const defaultDisposableRefresh = 24 * time.Hour
+
+// maxDisposableListBytes caps the list fetched from DISPOSABLE_DOMAINS_URL;
+// the lists in use are a few hundred KiB.
+const maxDisposableListBytes = 8 << 20
+
+// disposableDomains is the blocklist of Config.BlockDisposableEmails: the
+// built-in domains and those last fetched from Config.DisposableDomainsURL.
+type disposableDomains struct {
+	builtin map[string]bool
+	fetched atomic.Pointer[map[string]bool]
+	url     string
+	client  *http.Client
+}
+
+func newDisposableDomains(url string) *disposableDomains {
+	builtin, _ := parseDomainList(strings.NewReader(disposableDomainsTxt))
+	return &disposableDomains{builtin: builtin, url: url, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// parseDomainList reads a list of domains, one per line, skipping blank
+// lines and # comments.
+func parseDomainList(r io.Reader) (map[string]bool, error) {
+	domains := map[string]bool{}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		if d := strings.ToLower(strings.TrimSpace(line)); d != "" {
+			domains[d] = true
+		}
+	}
+	return domains, sc.Err()
+}
+
+// blocked reports whether email is at a disposable domain or a subdomain of
+// one.
+func (d *disposableDomains) blocked(email string) bool {
+	i := strings.LastIndexByte(email, '@')
+	if i < 0 {
+		return false
+	}
+	domain := strings.ToLower(email[i+1:])
+	fetched := d.fetched.Load()
+	for {
+		if d.builtin[domain] || fetched != nil && (*fetched)[domain] {
+			return true
+		}
+		_, parent, ok := strings.Cut(domain, ".")
+		if !ok || !strings.Contains(parent, ".") {
+			return false
+		}
+		domain = parent
+	}
+}
+
+// refresh fetches the list at d.url, replacing the one fetched before.
+func (d *disposableDomains) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", d.url, resp.Status)
+	}
+	domains, err := parseDomainList(io.LimitReader(resp.Body, maxDisposableListBytes))
+	if err != nil {
+		return err
+	}
+	d.fetched.Store(&domains)
+	return nil
+}
+
+// disposableLoop fetches DISPOSABLE_DOMAINS_URL at startup and every
+// interval after. A failed fetch keeps the domains fetched before, and the
+// built-in ones are blocked throughout.
+func (s *Server) disposableLoop(ctx context.Context) {
+	refresh := func() {
+		if err := s.disposable.refresh(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Warn("failed to refresh disposable email domains", "err", err)
+		}
+	}
+	refresh()
+	ticker := time.NewTicker(cmp.Or(s.cfg.DisposableDomainsRefresh, defaultDisposableRefresh))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			refresh()
+		}
+	}
+}
+
+// disposableDomainsKey carries the blocklist in the request context, for
+// Resolve methods to check emails against, when Config.BlockDisposableEmails
+// is set.
+type disposableDomainsKey struct{}
+
+// withDisposableDomains makes d available to validateEmailDomain for every
+// request.
+func withDisposableDomains(d *disposableDomains) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), disposableDomainsKey{}, d)))
+		})
+	}
+}
+
+// validateEmailDomain refuses an email at a disposable domain, reporting it
+// under prefix.email, if the blocklist is on.
+func validateEmailDomain(ctx context.Context, prefix *huma.PathBuffer, email string) []error {
+	d, _ := ctx.Value(disposableDomainsKey{}).(*disposableDomains)
+	if d == nil || !d.blocked(email) {
+		return nil
+	}
+	return []error{&ErrorDetail{Location: prefix.With("email"), Code: "disposable", Message: "expected an email at a domain that isn't disposable", Value: email}}
+}
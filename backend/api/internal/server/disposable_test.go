@@ -0,0 +1,39 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDisposableDomainsRefresh(t *testing.T) {
+	list := "# fetched\nFresh.example\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if list == "" {
+			http.Error(w, "gone", http.StatusInternalServerError)
+			return
+		}
+		io.WriteString(w, list)
+	}))
+	defer srv.Close()
+
+	d := newDisposableDomains(srv.URL)
+	if d.blocked("kim@fresh.example") || !d.blocked("kim@mailinator.com") {
+		t.Fatal("before fetching, want only the built-in domains blocked")
+	}
+	if err := d.refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !d.blocked("kim@fresh.example") || !d.blocked("kim@mail.fresh.example") || !d.blocked("kim@mailinator.com") {
+		t.Error("after fetching, want the fetched and built-in domains blocked")
+	}
+	list = ""
+	if err := d.refresh(context.Background()); err == nil {
+		t.Error("refresh from a failing server succeeded")
+	}
+	if !d.blocked("kim@fresh.example") {
+		t.Error("a failed refresh dropped the domains fetched before")
+	}
+}
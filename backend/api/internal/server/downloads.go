@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/signedurl"
+)
+
+// downloadsPath is where get-v1-downloads-by-kind-by-id serves the files
+// signed links point to.
+const downloadsPath = "/v1/downloads/"
+
+// Download is a file a signed link hands out.
+type Download struct {
+	Name        string // what to save it as
+	ContentType string
+	Data        []byte
+}
+
+// downloadSource fetches the file id of a kind of download, once its link
+// has been verified.
+type downloadSource func(ctx context.Context, id string) (*Download, error)
+
+// DownloadLinks signs the links to downloads, which work without
+// credentials until they expire, and verifies them. A link names the kind of
+// download and its ID, so one can't be turned into another.
+type DownloadLinks struct {
+	signer *signedurl.Signer
+	now    func() time.Time
+}
+
+// NewDownloadLinks returns links signed with key, or with a random key if it
+// is empty.
+func NewDownloadLinks(key []byte) *DownloadLinks {
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		rand.Read(key)
+	}
+	signer, err := signedurl.New(key)
+	if err != nil {
+		// ConfigFromEnv already rejects short keys.
+		panic(err)
+	}
+	return &DownloadLinks{signer: signer, now: time.Now}
+}
+
+// Sign returns a link to the download id of kind that works for ttl, and
+// when it stops working.
+func (l *DownloadLinks) Sign(kind, id string, ttl time.Duration) (string, time.Time) {
+	expires := l.now().Add(ttl).Truncate(time.Second)
+	return l.signer.Sign(downloadPath(kind, id), expires), expires
+}
+
+// Verify checks the expires and signature of a link to the download id of
+// kind.
+func (l *DownloadLinks) Verify(kind, id string, expires int64, signature string) error {
+	err := l.signer.Verify(downloadPath(kind, id), expires, signature, l.now())
+	switch {
+	case errors.Is(err, signedurl.ErrExpired):
+		return apiError(http.StatusForbidden, CodeInvalidSignature, "the download link has expired; request a new one")
+	case err != nil:
+		return apiError(http.StatusForbidden, CodeInvalidSignature, "the download link is not valid")
+	}
+	return nil
+}
+
+func downloadPath(kind, id string) string {
+	return downloadsPath + url.PathEscape(kind) + "/" + url.PathEscape(id)
+}
+
+type DownloadInput struct {
+	Kind      string `path:"kind" enum:"exports" doc:"Kind of download"`
+	ID        string `path:"id" example:"exp_5b0e2a9c1d7f" doc:"ID of the download within its kind"`
+	Expires   int64  `query:"expires" required:"true" doc:"When the link stops working, in Unix seconds"`
+	Signature string `query:"signature" required:"true" redact:"true" doc:"Signature of the link"`
+}
+
+type DownloadOutput struct {
+	ContentType        string `header:"Content-Type"`
+	ContentDisposition string `header:"Content-Disposition"`
+	Body               []byte
+}
+
+// download is the get-v1-downloads-by-kind-by-id handler. The link's
+// signature stands in for credentials; only once it is verified is the
+// download's source asked for the file.
+func (s *Server) download(ctx context.Context, input *DownloadInput) (*DownloadOutput, error) {
+	if err := s.links.Verify(input.Kind, input.ID, input.Expires, input.Signature); err != nil {
+		return nil, err
+	}
+	source, ok := s.downloads[input.Kind]
+	if !ok {
+		return nil, apiError(http.StatusNotFound, CodeNotFound, "download not found")
+	}
+	d, err := source(ctx, input.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &DownloadOutput{
+		ContentType:        d.ContentType,
+		ContentDisposition: `attachment; filename="` + d.Name + `"`,
+		Body:               d.Data,
+	}, nil
+}
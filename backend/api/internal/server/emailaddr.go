@@ -0,0 +1,87 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EmailFolding is how emails are folded into the canonical form users are
+// stored, found and compared by, on top of the lowercasing of the domain
+// every email gets. The email as given is kept too, as User.EmailRaw.
+type EmailFolding struct {
+	// Plus drops the +tag of plus addressing (ada+news@example.com), at
+	// every domain.
+	Plus bool
+	// Gmail folds Gmail addresses as Gmail does: without dots or a +tag,
+	// ignoring case, with googlemail.com as gmail.com.
+	Gmail bool
+}
+
+// parseEmailFolding parses EMAIL_FOLDING, a comma-separated list of the
+// foldings: plus and gmail.
+func parseEmailFolding(s string) (EmailFolding, error) {
+	var f EmailFolding
+	for _, name := range strings.Split(s, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "plus":
+			f.Plus = true
+		case "gmail":
+			f.Gmail = true
+		default:
+			return f, fmt.Errorf("unknown folding %q, want plus or gmail", name)
+		}
+	}
+	return f, nil
+}
+
+// canonical returns the canonical form of email.
+func (f EmailFolding) canonical(email string) string {
+	i := strings.LastIndexByte(email, '@')
+	if i < 0 {
+		return email
+	}
+	local, domain := email[:i], strings.ToLower(email[i+1:])
+	gmail := f.Gmail && (domain == "gmail.com" || domain == "googlemail.com")
+	if f.Plus || gmail {
+		if tag := strings.IndexByte(local, '+'); tag > 0 {
+			local = local[:tag]
+		}
+	}
+	if gmail {
+		local = strings.ToLower(strings.ReplaceAll(local, ".", ""))
+		domain = "gmail.com"
+	}
+	return local + "@" + domain
+}
+
+// same reports whether a and b are the same email once folded, ignoring
+// case as email lookups always have. Either may have been stored before
+// folding was configured.
+func (f EmailFolding) same(a, b string) bool {
+	return strings.EqualFold(f.canonical(a), f.canonical(b))
+}
+
+// taken reports whether a user other than exceptID already has email, once
+// folded.
+func (f EmailFolding) taken(users []*User, email, exceptID string) bool {
+	if email == "" {
+		return false
+	}
+	for _, u := range users {
+		if u.ID != exceptID && f.same(u.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// setEmail gives user the canonical form of raw, keeping raw as EmailRaw if
+// it differs.
+func (u *UserService) setEmail(user *User, raw string) {
+	user.Email = u.emailFolding.canonical(raw)
+	user.EmailRaw = ""
+	if user.Email != raw {
+		user.EmailRaw = raw
+	}
+}
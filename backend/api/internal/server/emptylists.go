@@ -0,0 +1,136 @@
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// Responses never hold null: an empty list is [], and an object that isn't
+// there is left out, which its field's omitempty takes care of. The
+// emptyLists transformer makes the lists so, which lets the spec declare
+// them not nullable and spares clients checking for null.
+func init() {
+	huma.DefaultArrayNullable = false
+}
+
+var marshalerType = reflect.TypeFor[json.Marshaler]()
+
+// emptyLists is a huma transformer replacing the nil slices and maps in a
+// response body, which JSON writes as null, with empty ones. Those in
+// omitempty fields are left out anyway and stay as they are. It copies what
+// it changes rather than change v, which may be shared, like a cached
+// response or the store's users.
+func emptyLists(ctx huma.Context, status string, v any) (any, error) {
+	if v == nil {
+		return v, nil
+	}
+	if out, changed := withEmptyLists(reflect.ValueOf(v)); changed {
+		return out.Interface(), nil
+	}
+	return v, nil
+}
+
+// withEmptyLists returns v with its nil slices and maps made empty, and
+// whether that changed anything. Values that marshal themselves, like
+// json.RawMessage, are left alone, but for structs, whose MarshalJSON
+// encodes their fields.
+func withEmptyLists(v reflect.Value) (reflect.Value, bool) {
+	t := v.Type()
+	if t.Kind() != reflect.Struct && t.Implements(marshalerType) {
+		return v, false
+	}
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return v, false
+		}
+		elem, changed := withEmptyLists(v.Elem())
+		if !changed {
+			return v, false
+		}
+		if t.Kind() == reflect.Interface {
+			out := reflect.New(t).Elem()
+			out.Set(elem)
+			return out, true
+		}
+		out := reflect.New(elem.Type())
+		out.Elem().Set(elem)
+		return out, true
+	case reflect.Struct:
+		var out reflect.Value
+		for i := range t.NumField() {
+			f := t.Field(i)
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if !f.IsExported() || name == "-" {
+				continue
+			}
+			field := v.Field(i)
+			if kind := field.Kind(); (kind == reflect.Slice || kind == reflect.Map) && field.IsNil() &&
+				strings.Contains(","+opts+",", ",omitempty,") {
+				continue // left out either way
+			}
+			field, changed := withEmptyLists(field)
+			if !changed {
+				continue
+			}
+			if !out.IsValid() {
+				out = reflect.New(t).Elem()
+				out.Set(v)
+			}
+			out.Field(i).Set(field)
+		}
+		if !out.IsValid() {
+			return v, false
+		}
+		return out, true
+	case reflect.Slice:
+		if v.IsNil() {
+			if t.Elem().Kind() == reflect.Uint8 {
+				return v, false // bytes, written as a base64 string
+			}
+			return reflect.MakeSlice(t, 0, 0), true
+		}
+		var out reflect.Value
+		for i := range v.Len() {
+			item, changed := withEmptyLists(v.Index(i))
+			if !changed {
+				continue
+			}
+			if !out.IsValid() {
+				out = reflect.MakeSlice(t, v.Len(), v.Len())
+				reflect.Copy(out, v)
+			}
+			out.Index(i).Set(item)
+		}
+		if !out.IsValid() {
+			return v, false
+		}
+		return out, true
+	case reflect.Map:
+		if v.IsNil() {
+			return reflect.MakeMap(t), true
+		}
+		var out reflect.Value
+		for iter := v.MapRange(); iter.Next(); {
+			item, changed := withEmptyLists(iter.Value())
+			if !changed {
+				continue
+			}
+			if !out.IsValid() {
+				out = reflect.MakeMapWithSize(t, v.Len())
+				for it := v.MapRange(); it.Next(); {
+					out.SetMapIndex(it.Key(), it.Value())
+				}
+			}
+			out.SetMapIndex(iter.Key(), item)
+		}
+		if !out.IsValid() {
+			return v, false
+		}
+		return out, true
+	}
+	return v, false
+}
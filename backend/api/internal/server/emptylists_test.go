@@ -0,0 +1,41 @@
+package server
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEmptyLists(t *testing.T) {
+	user := &User{ID: "a", Name: "Ada"}
+	in := &UsersListResponse{Users: []*User{user}, Status: 200}
+	out, err := emptyLists(nil, "200", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := json.Marshal(out)
+	if want := `{"users":[{"id":"a","name":"Ada","email":"","status":"","active":false}],"status":200,"tag_counts":{},"has_more":false}`; string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if in.TagCounts != nil {
+		t.Error("the body given was changed")
+	}
+
+	// Lists in lists and maps, and what's behind an interface, are filled
+	// too; bytes and values marshaling themselves aren't.
+	out, _ = emptyLists(nil, "200", map[string]any{
+		"nested":  [][]string{nil},
+		"letters": []DeadLetter{{ID: "dlq_1"}},
+		"raw":     json.RawMessage(nil),
+		"bytes":   []byte(nil),
+	})
+	got, _ = json.Marshal(out)
+	for _, want := range []string{`"nested":[[]]`, `"attempts":[]`, `"raw":null`, `"bytes":null`} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("got %s, want it to contain %s", got, want)
+		}
+	}
+	if out, _ := emptyLists(nil, "200", user); out != any(user) {
+		t.Error("a body without nil lists was copied")
+	}
+}
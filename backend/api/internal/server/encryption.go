@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/fieldcrypt"
+)
+
+// loadPIIKeys builds the keyring from PII_ENCRYPTION_KEYS, local id:key
+// pairs, and PII_KMS_KEY_IDS, KMS key IDs or ARNs, both comma-separated.
+// The first KMS key, or without one the first local key, encrypts; the rest
+// are kept to decrypt what older keys wrote. No keys means no encryption.
+func loadPIIKeys(localKeys, kmsKeyIDs string) (*fieldcrypt.Keyring, error) {
+	local, err := fieldcrypt.ParseLocalKeys(localKeys)
+	if err != nil {
+		return nil, fmt.Errorf("PII_ENCRYPTION_KEYS: %w", err)
+	}
+	var keks []fieldcrypt.KEK
+	if ids := strings.TrimSpace(kmsKeyIDs); ids != "" {
+		awsCfg, err := config.LoadDefaultConfig(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("PII_KMS_KEY_IDS: load AWS config: %w", err)
+		}
+		client := kms.NewFromConfig(awsCfg)
+		for _, id := range strings.Split(ids, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				keks = append(keks, fieldcrypt.KMSKey(client, id))
+			}
+		}
+	}
+	keks = append(keks, local...)
+	if len(keks) == 0 {
+		return nil, nil
+	}
+	keys, err := fieldcrypt.NewKeyring(keks[0], keks[1:]...)
+	if err != nil {
+		return nil, fmt.Errorf("PII encryption keys: %w", err)
+	}
+	return keys, nil
+}
+
+// encryptedStore is a Store decorator that encrypts users' emails and phone,
+// and invitations' emails, on the way in and decrypts them on the way out, so
+// they are ciphertext in the underlying store, its snapshots, write-ahead log
+// and backups, while everything above it sees plaintext.
+type encryptedStore struct {
+	Store
+	keys *fieldcrypt.Keyring
+}
+
+func (e encryptedStore) GetUser(ctx context.Context, id string) (*User, error) {
+	user, err := e.Store.GetUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return e.decrypt(ctx, user)
+}
+
+func (e encryptedStore) ListUsers(ctx context.Context) ([]*User, error) {
+	users, err := e.Store.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, u := range users {
+		if users[i], err = e.decrypt(ctx, u); err != nil {
+			return nil, err
+		}
+	}
+	return users, nil
+}
+
+func (e encryptedStore) PutUser(ctx context.Context, user *User) error {
+	enc, err := encryptUser(ctx, e.keys, user)
+	if err != nil {
+		return err
+	}
+	return e.Store.PutUser(ctx, enc)
+}
+
+func (e encryptedStore) GetInvitation(ctx context.Context, id string) (*StoredInvitation, error) {
+	inv, err := e.Store.GetInvitation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return e.decryptInvitation(ctx, inv)
+}
+
+func (e encryptedStore) ListInvitations(ctx context.Context) ([]*StoredInvitation, error) {
+	invitations, err := e.Store.ListInvitations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, inv := range invitations {
+		if invitations[i], err = e.decryptInvitation(ctx, inv); err != nil {
+			return nil, err
+		}
+	}
+	return invitations, nil
+}
+
+func (e encryptedStore) PutInvitation(ctx context.Context, inv *StoredInvitation) error {
+	c := inv.clone()
+	var err error
+	if c.Email, err = e.keys.Encrypt(ctx, inv.Email); err != nil {
+		return err
+	}
+	return e.Store.PutInvitation(ctx, c)
+}
+
+// withTx encrypts what the transaction writes like any other write.
+func (e encryptedStore) withTx(ctx context.Context, fn func(tx Store) error) error {
+	return WithTx(ctx, e.Store, func(tx Store) error { return fn(encryptedStore{tx, e.keys}) })
+}
+
+// decrypt returns a copy of user with plaintext emails and phone.
+func (e encryptedStore) decrypt(ctx context.Context, user *User) (*User, error) {
+	c := user.clone()
+	var err error
+	if c.Email, err = e.keys.Decrypt(ctx, user.Email); err != nil {
+		return nil, fmt.Errorf("user %s email: %w", user.ID, err)
+	}
+	if c.EmailRaw, err = e.keys.Decrypt(ctx, user.EmailRaw); err != nil {
+		return nil, fmt.Errorf("user %s raw email: %w", user.ID, err)
+	}
+	if c.Phone, err = e.keys.Decrypt(ctx, user.Phone); err != nil {
+		return nil, fmt.Errorf("user %s phone: %w", user.ID, err)
+	}
+	return c, nil
+}
+
+// decryptInvitation returns a copy of inv with a plaintext email.
+func (e encryptedStore) decryptInvitation(ctx context.Context, inv *StoredInvitation) (*StoredInvitation, error) {
+	c := inv.clone()
+	var err error
+	if c.Email, err = e.keys.Decrypt(ctx, inv.Email); err != nil {
+		return nil, fmt.Errorf("invitation %s email: %w", inv.ID, err)
+	}
+	return c, nil
+}
+
+// encryptUser returns a copy of user with emails and phone encrypted under
+// the primary key.
+func encryptUser(ctx context.Context, keys *fieldcrypt.Keyring, user *User) (*User, error) {
+	c := user.clone()
+	var err error
+	if c.Email, err = keys.Encrypt(ctx, user.Email); err != nil {
+		return nil, err
+	}
+	if c.EmailRaw, err = keys.Encrypt(ctx, user.EmailRaw); err != nil {
+		return nil, err
+	}
+	if c.Phone, err = keys.Encrypt(ctx, user.Phone); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// reencryptUsers is the post-admin-reencrypt handler.
+func (s *Server) reencryptUsers(ctx context.Context, input *AdminInput) (*ReencryptOutput, error) {
+	if err := s.authorizeAdmin(ctx, *input); err != nil {
+		return nil, err
+	}
+	if s.cfg.PIIKeys == nil {
+		return nil, apiError(http.StatusConflict, CodeEncryptionNotConfigured, "field encryption is not configured")
+	}
+	res, err := reencrypt(ctx, s.store, s.cfg.PIIKeys)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "re-encrypted users", "checked", res.Checked, "reencrypted", res.Reencrypted, "key", res.KeyID)
+	return &ReencryptOutput{Body: res}, nil
+}
+
+// ReencryptResponse reports a re-encryption pass.
+type ReencryptResponse struct {
+	Checked     int    `json:"checked" doc:"Users looked at"`
+	Reencrypted int    `json:"reencrypted" doc:"Users whose fields were rewritten under the primary key"`
+	KeyID       string `json:"key_id" doc:"ID of the primary key"`
+}
+
+type ReencryptOutput struct {
+	Body *ReencryptResponse
+}
+
+// reencrypt rewrites every user whose emails or phone are plaintext or
+// encrypted under an older key, so that the older key can be retired. store
+// is the underlying store, holding ciphertext.
+func reencrypt(ctx context.Context, store Store, keys *fieldcrypt.Keyring) (*ReencryptResponse, error) {
+	users, err := store.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := &ReencryptResponse{Checked: len(users), KeyID: keys.PrimaryID()}
+	plain := encryptedStore{store, keys}
+	for _, u := range users {
+		if keys.Current(u.Email) && keys.Current(u.EmailRaw) && keys.Current(u.Phone) {
+			continue
+		}
+		dec, err := plain.decrypt(ctx, u)
+		if err != nil {
+			return res, err
+		}
+		if err := plain.PutUser(ctx, dec); err != nil {
+			return res, err
+		}
+		res.Reencrypted++
+	}
+	return res, nil
+}
@@ -0,0 +1,445 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/validation"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/i18n"
+)
+
+// ErrorCode identifies the kind of problem an error response reports.
+// Unlike titles and details, which may be reworded or translated, codes are
+// stable, so clients can branch on them.
+type ErrorCode string
+
+const (
+	CodeBadRequest              ErrorCode = "BAD_REQUEST"
+	CodeValidationFailed        ErrorCode = "VALIDATION_FAILED"
+	CodeUnauthorized            ErrorCode = "UNAUTHORIZED"
+	CodeInvalidToken            ErrorCode = "INVALID_TOKEN"
+	CodeInvalidCredentials      ErrorCode = "INVALID_CREDENTIALS"
+	CodeAccountLocked           ErrorCode = "ACCOUNT_LOCKED"
+	CodeLoginThrottled          ErrorCode = "LOGIN_THROTTLED"
+	CodeCaptchaFailed           ErrorCode = "CAPTCHA_FAILED"
+	CodeCaptchaUnavailable      ErrorCode = "CAPTCHA_UNAVAILABLE"
+	CodeNotFound                ErrorCode = "NOT_FOUND"
+	CodeUserNotFound            ErrorCode = "USER_NOT_FOUND"
+	CodePostNotFound            ErrorCode = "POST_NOT_FOUND"
+	CodeCommentNotFound         ErrorCode = "COMMENT_NOT_FOUND"
+	CodeNotificationNotFound    ErrorCode = "NOTIFICATION_NOT_FOUND"
+	CodeMethodNotAllowed        ErrorCode = "METHOD_NOT_ALLOWED"
+	CodeNotAcceptable           ErrorCode = "NOT_ACCEPTABLE"
+	CodeConflict                ErrorCode = "CONFLICT"
+	CodeUsernameTaken           ErrorCode = "USERNAME_TAKEN"
+	CodePhoneTaken              ErrorCode = "PHONE_TAKEN"
+	CodeEmailTaken              ErrorCode = "EMAIL_TAKEN"
+	CodeInvalidStatusChange     ErrorCode = "INVALID_STATUS_TRANSITION"
+	CodeEncryptionNotConfigured ErrorCode = "ENCRYPTION_NOT_CONFIGURED"
+	CodeInvalidBackup           ErrorCode = "INVALID_BACKUP"
+	CodeInvalidLogLevel         ErrorCode = "INVALID_LOG_LEVEL"
+	CodePreconditionFailed      ErrorCode = "PRECONDITION_FAILED"
+	CodeRequestTooLarge         ErrorCode = "REQUEST_TOO_LARGE"
+	CodeUnsupportedMediaType    ErrorCode = "UNSUPPORTED_MEDIA_TYPE"
+	CodeInternal                ErrorCode = "INTERNAL_ERROR"
+	CodeNoLeader                ErrorCode = "NO_LEADER"
+	CodeMaintenance             ErrorCode = "MAINTENANCE"
+	CodeOverloaded              ErrorCode = "OVERLOADED"
+	CodeIPNotAllowed            ErrorCode = "IP_NOT_ALLOWED"
+	CodeClientBanned            ErrorCode = "CLIENT_BANNED"
+	CodeChangesExpired          ErrorCode = "CHANGES_EXPIRED"
+	CodeSearchUnavailable       ErrorCode = "SEARCH_UNAVAILABLE"
+	CodeLeaderUnknown           ErrorCode = "LEADER_UNKNOWN"
+	CodeInjectedFault           ErrorCode = "INJECTED_FAULT"
+	CodeQuotaExceeded           ErrorCode = "QUOTA_EXCEEDED"
+	CodeInsufficientScope       ErrorCode = "INSUFFICIENT_SCOPE"
+	CodeAPIKeyNotFound          ErrorCode = "API_KEY_NOT_FOUND"
+	CodeIssuerUnavailable       ErrorCode = "ISSUER_UNAVAILABLE"
+	CodeInvitationNotFound      ErrorCode = "INVITATION_NOT_FOUND"
+	CodeInvitationExpired       ErrorCode = "INVITATION_EXPIRED"
+	CodeExportNotFound          ErrorCode = "EXPORT_NOT_FOUND"
+	CodeInvalidSignature        ErrorCode = "INVALID_SIGNATURE"
+	CodeReplayNotConfigured     ErrorCode = "REPLAY_NOT_CONFIGURED"
+	CodeDeadLetterNotFound      ErrorCode = "DEAD_LETTER_NOT_FOUND"
+	CodeConcurrencyLimited      ErrorCode = "CONCURRENCY_LIMITED"
+	CodeRequestCanceled         ErrorCode = "REQUEST_CANCELED"
+)
+
+// errorCodes documents every code, in the order the spec lists them. Add new
+// codes here as well as above.
+var errorCodes = []struct {
+	code ErrorCode
+	doc  string
+}{
+	{CodeBadRequest, "The request could not be read, e.g. its body is not valid JSON."},
+	{CodeValidationFailed, "Parameters or body failed validation; `errors` lists every problem."},
+	{CodeUnauthorized, "The operation needs credentials that were missing or wrong."},
+	{CodeInvalidToken, "The user token is forged, malformed or expired."},
+	{CodeInvalidCredentials, "The login or password is wrong, or the user can't log in."},
+	{CodeAccountLocked, "Too many failed logins locked the account; retry after Retry-After or ask an admin to unlock it."},
+	{CodeLoginThrottled, "Too many failed logins from the account or address; retry after Retry-After."},
+	{CodeRequestCanceled, "An operator canceled the request through the admin API before it was answered."},
+	{CodeCaptchaFailed, "The X-Captcha-Token header is missing, or the CAPTCHA provider rejected it. Clients the traffic analyzer challenges get it on every request until they send a solved CAPTCHA."},
+	{CodeCaptchaUnavailable, "The CAPTCHA provider couldn't be reached to check the token."},
+	{CodeNotFound, "No route or resource matches the request."},
+	{CodeUserNotFound, "The user does not exist."},
+	{CodePostNotFound, "The post does not exist."},
+	{CodeCommentNotFound, "The comment does not exist, or is on another post."},
+	{CodeNotificationNotFound, "The notification does not exist, or is another user's."},
+	{CodeMethodNotAllowed, "The route does not support the method."},
+	{CodeNotAcceptable, "No response format matches the Accept header."},
+	{CodeConflict, "The request conflicts with the current state."},
+	{CodeUsernameTaken, "Another user has the username."},
+	{CodePhoneTaken, "Another user has the phone number, and USER_PHONE_UNIQUE is on."},
+	{CodeEmailTaken, "Another user has the email, once folded as EMAIL_FOLDING says."},
+	{CodeInvalidStatusChange, "The user's status can't move to the requested one."},
+	{CodeEncryptionNotConfigured, "The operation needs field encryption, which is off."},
+	{CodeInvalidBackup, "The uploaded archive is not a backup this server can restore."},
+	{CodeInvalidLogLevel, "The log level is not one the server knows."},
+	{CodePreconditionFailed, "An If-Match or If-Unmodified-Since precondition failed."},
+	{CodeRequestTooLarge, "The request body is over the limit."},
+	{CodeUnsupportedMediaType, "The body's Content-Type is not supported."},
+	{CodeInternal, "Something went wrong on the server."},
+	{CodeNoLeader, "The clustered store has no leader to take the write; retry after Retry-After."},
+	{CodeMaintenance, "The API is down or read-only for maintenance; retry after Retry-After."},
+	{CodeOverloaded, "The server is serving as many requests as it can and shed this one; retry after Retry-After."},
+	{CodeIPNotAllowed, "The client's address is not allowed to call the endpoint, per the IP allow and deny lists."},
+	{CodeClientBanned, "The traffic analyzer banned the client's address for abusive traffic; retry after Retry-After."},
+	{CodeChangesExpired, "The change feed no longer has every change after since; list the users again and resume with since=-1."},
+	{CodeSearchUnavailable, "The search index couldn't be queried; retry later."},
+	{CodeLeaderUnknown, "The locks that elect the leader couldn't be reached; retry later."},
+	{CodeInjectedFault, "A fault rule of PUT /admin/faults failed the request on purpose, to test the client."},
+	{CodeQuotaExceeded, "The API key used up its daily or monthly quota; retry after Retry-After."},
+	{CodeInsufficientScope, "The API key doesn't have the scope the operation needs, or the operation needs a user token."},
+	{CodeAPIKeyNotFound, "The API key does not exist, or is another user's."},
+	{CodeIssuerUnavailable, "The keys of the identity provider that issued the token couldn't be fetched to check it."},
+	{CodeInvitationNotFound, "No invitation has the ID, or no pending one the token."},
+	{CodeInvitationExpired, "The invitation is past its expiry; ask for a new one."},
+	{CodeExportNotFound, "The export does not exist, or was deleted after a day."},
+	{CodeInvalidSignature, "The signed link was tampered with or has expired; get a new one."},
+	{CodeReplayNotConfigured, "There is nowhere to replay events to: neither the security event stream nor cache purging is configured."},
+	{CodeDeadLetterNotFound, "No dead letter has the ID on this replica; it was delivered, discarded or lost on restart."},
+	{CodeConcurrencyLimited, "The route group of the endpoint is serving as many requests at once as CONCURRENCY_LIMITS lets it; retry after Retry-After."},
+	{CodeRequestCanceled, "An operator canceled the request through the admin API before it was answered."},
+}
+
+// statusCodes are the codes errors without one of their own get.
+var statusCodes = map[int]ErrorCode{
+	http.StatusBadRequest:            CodeBadRequest,
+	http.StatusUnauthorized:          CodeUnauthorized,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusMethodNotAllowed:      CodeMethodNotAllowed,
+	http.StatusNotAcceptable:         CodeNotAcceptable,
+	http.StatusConflict:              CodeConflict,
+	http.StatusPreconditionFailed:    CodePreconditionFailed,
+	http.StatusRequestEntityTooLarge: CodeRequestTooLarge,
+	http.StatusUnsupportedMediaType:  CodeUnsupportedMediaType,
+	http.StatusUnprocessableEntity:   CodeValidationFailed,
+}
+
+// Schema documents ErrorCode as the ErrorCode component, with every code and
+// what it means.
+func (ErrorCode) Schema(r huma.Registry) *huma.Schema {
+	if _, ok := r.Map()["ErrorCode"]; !ok {
+		s := &huma.Schema{Type: huma.TypeString, Description: "Machine-readable error code. Branch on it rather than on the messages:\n"}
+		for _, c := range errorCodes {
+			s.Enum = append(s.Enum, string(c.code))
+			s.Description += "\n- `" + string(c.code) + "`: " + c.doc
+		}
+		r.Map()["ErrorCode"] = s
+	}
+	return &huma.Schema{Ref: "#/components/schemas/ErrorCode"}
+}
+
+// ErrorModel is the RFC 9457 problem document every error response carries.
+// It is huma's plus a Code, and each entry of Errors names the invalid field
+// and a machine-readable code for what is wrong with it.
+type ErrorModel struct {
+	Code     ErrorCode      `json:"code"`
+	Type     string         `json:"type,omitempty" format:"uri" default:"about:blank" example:"https://example.com/errors/example" doc:"A URI reference to human-readable documentation for the error."`
+	Title    string         `json:"title,omitempty" example:"Bad Request" doc:"A short, human-readable summary of the problem type. This value should not change between occurrences of the error."`
+	Status   int            `json:"status,omitempty" example:"400" doc:"HTTP status code"`
+	Detail   string         `json:"detail,omitempty" example:"Property foo is required but is missing." doc:"A human-readable explanation specific to this occurrence of the problem."`
+	Instance string         `json:"instance,omitempty" format:"uri" example:"https://example.com/error-log/abc123" doc:"A URI reference that identifies the specific occurrence of the problem."`
+	Errors   []*ErrorDetail `json:"errors,omitempty" doc:"Every problem found with the request, at most one per field"`
+}
+
+// ErrorDetail is one problem with a request, typically a field that failed
+// validation.
+type ErrorDetail struct {
+	Field    string `json:"field" example:"tags[0]" doc:"Path of the invalid field within its location, empty for the body as a whole"`
+	Code     string `json:"code" enum:"required,unexpected_property,type,format,enum,pattern,minimum,maximum,multiple_of,min_length,max_length,min_items,max_items,unique_items,min_properties,max_properties,schema,max_size,max_depth,reserved,disposable,malformed,unsupported_media_type,invalid" doc:"What is wrong with the field"`
+	Message  string `json:"message" doc:"Error message text"`
+	Location string `json:"location" example:"body.tags[0]" doc:"Where the error occurred, e.g. 'body.items[3].tags' or 'path.thing-id'"`
+	Value    any    `json:"value,omitempty" doc:"The value at the given location"`
+}
+
+func (e *ErrorModel) Error() string { return e.Detail }
+
+func (e *ErrorModel) GetStatus() int { return e.Status }
+
+// ContentType sends errors as application/problem+json, as huma's own do.
+func (e *ErrorModel) ContentType(ct string) string {
+	if ct == "application/json" {
+		return "application/problem+json"
+	}
+	return ct
+}
+
+// Translate lets i18n.LocalizeErrors translate the messages.
+func (e *ErrorModel) Translate(translate func(string) string) any {
+	out := *e
+	out.Title = translate(e.Title)
+	out.Detail = translate(e.Detail)
+	if e.Errors != nil {
+		out.Errors = make([]*ErrorDetail, len(e.Errors))
+		for i, d := range e.Errors {
+			translated := *d
+			translated.Message = translate(d.Message)
+			out.Errors[i] = &translated
+		}
+	}
+	return &out
+}
+
+func (e *ErrorDetail) Error() string {
+	return fmt.Sprintf("%s (%s: %v)", e.Message, e.Location, e.Value)
+}
+
+// ErrorDetail returns the detail in huma's form, for code that only knows it.
+func (e *ErrorDetail) ErrorDetail() *huma.ErrorDetail {
+	return &huma.ErrorDetail{Message: e.Message, Location: e.Location, Value: e.Value}
+}
+
+// apiError is an error response with a code more specific than its status'.
+func apiError(status int, code ErrorCode, msg string) huma.StatusError {
+	e := newError(status, msg).(*ErrorModel)
+	e.Code = code
+	return e
+}
+
+// writeError writes an error response from outside huma, such as a
+// middleware or the router's fallbacks, translated like huma's.
+func writeError(w http.ResponseWriter, r *http.Request, status int, code ErrorCode, msg string) {
+	lang := i18n.Match(r.Header.Get("Accept-Language"))
+	e := apiError(status, code, msg).(*ErrorModel).Translate(func(msg string) string { return i18n.Translate(lang, msg) })
+	w.Header().Add("Vary", "Accept-Language")
+	w.Header().Set("Content-Language", lang)
+	w.Header().Set("Cache-Control", noStore)
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(e)
+}
+
+func init() {
+	huma.NewError = newError
+}
+
+// newError replaces huma.NewError, so huma's validation failures and the
+// errors handlers return both come out as an ErrorModel. Huma validates the
+// path, query and body and runs the resolvers before it fails a request, so
+// errs holds every problem found. When a resolver trips over a value the
+// schema already rejected, only the first problem with the field is kept.
+func newError(status int, msg string, errs ...error) huma.StatusError {
+	e := &ErrorModel{Code: statusCodes[status], Status: status, Title: http.StatusText(status), Detail: msg}
+	if e.Code == "" {
+		e.Code = CodeBadRequest
+		if status >= 500 {
+			e.Code = CodeInternal
+		}
+	}
+	seen := map[string]bool{}
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		d := toErrorDetail(err)
+		if d.Location != "" && d.Location != "body" {
+			if seen[d.Location] {
+				continue
+			}
+			seen[d.Location] = true
+		}
+		e.Errors = append(e.Errors, d)
+	}
+	return e
+}
+
+func toErrorDetail(err error) *ErrorDetail {
+	var d *ErrorDetail
+	switch err := err.(type) {
+	case *ErrorDetail:
+		c := *err
+		d = &c
+	case huma.ErrorDetailer:
+		hd := err.ErrorDetail()
+		d = &ErrorDetail{Message: hd.Message, Location: hd.Location, Value: hd.Value}
+	default:
+		d = &ErrorDetail{Message: err.Error()}
+	}
+	if d.Code == "" {
+		var property string
+		d.Code, property = violationCode(d.Message)
+		if d.Code == "invalid" && d.Location == "body" {
+			d.Code = "malformed"
+		}
+		if property != "" {
+			// Huma reports a missing property on the object holding it,
+			// with the object as the value.
+			d.Location = strings.TrimPrefix(d.Location+"."+property, ".")
+			d.Value = nil
+		}
+	}
+	d.Field = fieldName(d.Location)
+	return d
+}
+
+// fieldName strips the location's first segment, which says whether the
+// field is in the path, query, headers or body.
+func fieldName(location string) string {
+	_, field, _ := strings.Cut(location, ".")
+	return field
+}
+
+type violationRule struct {
+	match *regexp.Regexp
+	code  string
+}
+
+var fmtVerb = regexp.MustCompile(`%[vsdq]`)
+
+// violationRules map huma's validation messages to codes. The messages are
+// formatted by the time they get here, so each is matched with its verbs as
+// wildcards.
+var violationRules = func() []violationRule {
+	var rules []violationRule
+	add := func(code string, messages ...string) {
+		for _, msg := range messages {
+			parts := fmtVerb.Split(msg, -1)
+			for i := range parts {
+				parts[i] = regexp.QuoteMeta(parts[i])
+			}
+			rules = append(rules, violationRule{regexp.MustCompile("^" + strings.Join(parts, "(.+?)") + "$"), code})
+		}
+	}
+	add("required", validation.MsgExpectedRequiredProperty, validation.MsgExpectedDependentRequiredProperty,
+		"required %s parameter is missing")
+	add("unexpected_property", validation.MsgUnexpectedProperty)
+	add("type", validation.MsgExpectedBoolean, validation.MsgExpectedNumber, validation.MsgExpectedInteger,
+		validation.MsgExpectedString, validation.MsgExpectedArray, validation.MsgExpectedObject,
+		"invalid integer", "invalid float", "invalid floating value", "invalid boolean")
+	add("format", validation.MsgExpectedRFC3339DateTime, validation.MsgExpectedRFC1123DateTime,
+		validation.MsgExpectedRFC3339Date, validation.MsgExpectedRFC3339Time, validation.MsgExpectedRFC5322Email,
+		validation.MsgExpectedRFC5890Hostname, validation.MsgExpectedRFC2673IPv4, validation.MsgExpectedRFC2373IPv6,
+		validation.MsgExpectedRFC3986URI, validation.MsgExpectedRFC4122UUID, validation.MsgExpectedRFC6570URITemplate,
+		validation.MsgExpectedRFC6901JSONPointer, validation.MsgExpectedRFC6901RelativeJSONPointer,
+		validation.MsgExpectedRegexp, validation.MsgExpectedBase64String, validation.MsgExpectedBePattern,
+		"invalid date/time for format %s")
+	add("enum", validation.MsgExpectedOneOf)
+	add("pattern", validation.MsgExpectedMatchPattern)
+	add("minimum", validation.MsgExpectedMinimumNumber, validation.MsgExpectedExclusiveMinimumNumber)
+	add("maximum", validation.MsgExpectedMaximumNumber, validation.MsgExpectedExclusiveMaximumNumber)
+	add("multiple_of", validation.MsgExpectedNumberBeMultipleOf)
+	add("min_length", validation.MsgExpectedMinLength)
+	add("max_length", validation.MsgExpectedMaxLength)
+	add("min_items", validation.MsgExpectedMinItems)
+	add("max_items", validation.MsgExpectedMaxItems,
+		"expected at most one value, but received multiple values")
+	add("unique_items", validation.MsgExpectedArrayItemsUnique)
+	add("min_properties", validation.MsgExpectedMinProperties)
+	add("max_properties", validation.MsgExpectedMaxProperties)
+	add("schema", validation.MsgExpectedMatchAtLeastOneSchema, validation.MsgExpectedMatchExactlyOneSchema,
+		validation.MsgExpectedNotMatchSchema, validation.MsgExpectedPropertyNameInObject)
+	add("unsupported_media_type", "unknown content type: %s")
+	return rules
+}()
+
+// violationCode returns the code for one of huma's validation messages,
+// and for a missing property its name. Messages it doesn't know, such as
+// JSON syntax errors, are "invalid".
+func violationCode(msg string) (code, property string) {
+	for _, r := range violationRules {
+		m := r.match.FindStringSubmatch(msg)
+		if m == nil {
+			continue
+		}
+		if r.code == "required" && strings.HasPrefix(msg, "expected") && len(m) > 1 {
+			return r.code, m[1]
+		}
+		return r.code, ""
+	}
+	return "invalid", ""
+}
+
+// errorExamples are the example bodies the spec shows for each error status,
+// with the code operations most often answer it with.
+var errorExamples = map[int]*ErrorModel{
+	http.StatusBadRequest: {Code: CodeBadRequest, Detail: "validation failed", Errors: []*ErrorDetail{{
+		Code:     "malformed",
+		Message:  "invalid character '}' looking for beginning of object key string",
+		Location: "body",
+		Value:    `{"name":"Rohan",}`,
+	}}},
+	http.StatusUnauthorized:          {Code: CodeUnauthorized, Detail: "admin token required"},
+	http.StatusForbidden:             {Code: CodeCaptchaFailed, Detail: "captcha verification failed"},
+	http.StatusNotFound:              {Code: CodeUserNotFound, Detail: "User not found"},
+	http.StatusConflict:              {Code: CodeUsernameTaken, Detail: "username is already taken"},
+	http.StatusRequestEntityTooLarge: {Code: CodeRequestTooLarge, Detail: "request body is too large limit=1048576 bytes"},
+	http.StatusUnprocessableEntity: {Code: CodeValidationFailed, Detail: "validation failed", Errors: []*ErrorDetail{{
+		Field:    "email",
+		Code:     "format",
+		Message:  "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+		Location: "body.email",
+		Value:    "rohan.example.com",
+	}}},
+	http.StatusGone:                {Code: CodeChangesExpired, Detail: "the changes after since are no longer kept; list the users again and resume with since=-1"},
+	http.StatusLocked:              {Code: CodeAccountLocked, Detail: "account is locked after too many failed logins"},
+	http.StatusTooManyRequests:     {Code: CodeLoginThrottled, Detail: "too many failed logins, try again later"},
+	http.StatusInternalServerError: {Code: CodeInternal, Detail: "unexpected error occurred"},
+	http.StatusServiceUnavailable:  {Code: CodeMaintenance, Detail: "the API is down for maintenance"},
+}
+
+// documentErrors gives every error response in spec an example body, and
+// every operation a default response for the errors middleware can answer
+// any of them with, such as 503 during maintenance or 403 from the IP lists.
+func documentErrors(spec *huma.OpenAPI) {
+	example := func(status int) *ErrorModel {
+		e := *errorExamples[status]
+		e.Status, e.Title = status, http.StatusText(status)
+		return &e
+	}
+	for _, item := range spec.Paths {
+		for _, op := range []*huma.Operation{item.Get, item.Put, item.Post, item.Delete, item.Patch} {
+			if op == nil {
+				continue
+			}
+			if op.Responses["default"] == nil {
+				op.Responses["default"] = &huma.Response{
+					Description: "Error",
+					Content: map[string]*huma.MediaType{
+						"application/problem+json": {Schema: &huma.Schema{Ref: "#/components/schemas/ErrorModel"}},
+					},
+				}
+			}
+			for key, resp := range op.Responses {
+				mt := resp.Content["application/problem+json"]
+				if mt == nil {
+					continue
+				}
+				status, _ := strconv.Atoi(key)
+				if key == "default" {
+					status = http.StatusServiceUnavailable
+				}
+				if errorExamples[status] != nil {
+					mt.Example = example(status)
+				}
+			}
+		}
+	}
+}
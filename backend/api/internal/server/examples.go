@@ -0,0 +1,206 @@
+package server
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// OperationExample is a named request to an operation and the response it
+// gets, declared on the operation with exampled. The spec shows the request
+// under the operation's request body and the response under its status, so
+// mock servers can answer with it and the docs can show a whole exchange.
+type OperationExample struct {
+	Summary string
+	// Request is the request body, if the operation takes one.
+	Request any
+	// Status is the response's; zero is the operation's default status.
+	Status int
+	// Response is the response body. An *ErrorModel without a Status or
+	// Title gets those of Status.
+	Response any
+}
+
+// examplesKey is the operation metadata exampled puts the examples under.
+const examplesKey = "examples"
+
+// exampled is the Metadata of an operation with examples, keyed by name,
+// like success, validation_failed or username_taken.
+func exampled(examples map[string]OperationExample) map[string]any {
+	return map[string]any{examplesKey: examples}
+}
+
+// metadata merges the Metadata of an operation declaring more than one
+// policy, like deduplicated and exampled.
+func metadata(ms ...map[string]any) map[string]any {
+	out := map[string]any{}
+	for _, m := range ms {
+		maps.Copy(out, m)
+	}
+	return out
+}
+
+// documentExamples adds the examples of the operations declaring them to
+// the spec of their request bodies and responses. Named examples replace
+// the single example documentErrors gave their status, as OpenAPI allows
+// only one of the two. An example for a status or request body the
+// operation doesn't document is a bug, and panics like a conflicting route.
+func documentExamples(spec *huma.OpenAPI) {
+	for _, item := range spec.Paths {
+		for _, op := range []*huma.Operation{item.Get, item.Put, item.Post, item.Delete, item.Patch} {
+			if op == nil {
+				continue
+			}
+			examples, ok := op.Metadata[examplesKey].(map[string]OperationExample)
+			if !ok {
+				continue
+			}
+			for _, name := range slices.Sorted(maps.Keys(examples)) {
+				ex := examples[name]
+				if ex.Request != nil {
+					var mt *huma.MediaType
+					if op.RequestBody != nil {
+						mt = jsonMediaType(op.RequestBody.Content)
+					}
+					if mt == nil {
+						panic(fmt.Errorf("server: example %s of %s has a request body, but the operation takes none", name, op.OperationID))
+					}
+					addExample(mt, name, ex.Summary, ex.Request)
+				}
+				status := ex.Status
+				if status == 0 {
+					status = op.DefaultStatus
+				}
+				if status == 0 {
+					status = http.StatusOK
+				}
+				var mt *huma.MediaType
+				if resp := op.Responses[strconv.Itoa(status)]; resp != nil {
+					mt = jsonMediaType(resp.Content)
+				}
+				if mt == nil {
+					panic(fmt.Errorf("server: example %s of %s answers %d, which the operation doesn't document with a body", name, op.OperationID, status))
+				}
+				body := ex.Response
+				if e, ok := body.(*ErrorModel); ok && e.Status == 0 {
+					c := *e
+					c.Status, c.Title = status, http.StatusText(status)
+					body = &c
+				}
+				addExample(mt, name, ex.Summary, body)
+			}
+		}
+	}
+}
+
+// jsonMediaType returns the JSON media type of content, or nil if it has
+// none.
+func jsonMediaType(content map[string]*huma.MediaType) *huma.MediaType {
+	for _, ct := range []string{"application/json", "application/problem+json"} {
+		if mt := content[ct]; mt != nil {
+			return mt
+		}
+	}
+	return nil
+}
+
+func addExample(mt *huma.MediaType, name, summary string, value any) {
+	if mt.Examples == nil {
+		mt.Examples = map[string]*huma.Example{}
+	}
+	mt.Example = nil
+	mt.Examples[name] = &huma.Example{Summary: summary, Value: value}
+}
+
+// exampleTime is when the users and posts of the examples were created.
+var exampleTime = timestamp.From(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+
+var createUserExamples = map[string]OperationExample{
+	"success": {
+		Summary: "The user is created",
+		Request: CreateUserRequest{Username: "ro_chauhan", Name: "Rohan Chauhan", Email: "rohan@example.com", Password: "correct horse battery"},
+		Response: &User{
+			ID: "20240101120000", Username: "ro_chauhan", Name: "Rohan Chauhan", Email: "rohan@example.com",
+			Status: UserStatusActive, Active: true,
+		},
+	},
+	"validation_failed": {
+		Summary: "The email is not an email address",
+		Request: CreateUserRequest{Name: "Rohan Chauhan", Email: "rohan.example.com"},
+		Status:  http.StatusUnprocessableEntity,
+		Response: &ErrorModel{Code: CodeValidationFailed, Detail: "validation failed", Errors: []*ErrorDetail{{
+			Field:    "email",
+			Code:     "format",
+			Message:  "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+			Location: "body.email",
+			Value:    "rohan.example.com",
+		}}},
+	},
+	"username_taken": {
+		Summary:  "Another user has the username",
+		Request:  CreateUserRequest{Username: "ro_chauhan", Name: "Rohan Chauhan", Email: "rohan.c@example.com"},
+		Status:   http.StatusConflict,
+		Response: &ErrorModel{Code: CodeUsernameTaken, Detail: "username is already taken"},
+	},
+}
+
+var updateUserExamples = map[string]OperationExample{
+	"success": {
+		Summary: "The user is renamed",
+		Request: map[string]any{"name": "Rohan K. Chauhan"},
+		Response: &User{
+			ID: "20240101120000", Username: "ro_chauhan", Name: "Rohan K. Chauhan", Email: "rohan@example.com",
+			Status: UserStatusActive, Active: true,
+		},
+	},
+	"validation_failed": {
+		Summary: "The name is empty",
+		Request: map[string]any{"name": ""},
+		Status:  http.StatusUnprocessableEntity,
+		Response: &ErrorModel{Code: CodeValidationFailed, Detail: "validation failed", Errors: []*ErrorDetail{{
+			Field:    "name",
+			Code:     "min_length",
+			Message:  "expected length >= 1",
+			Location: "body.name",
+			Value:    "",
+		}}},
+	},
+	"username_taken": {
+		Summary:  "Another user has the username",
+		Request:  map[string]any{"username": "grace"},
+		Status:   http.StatusConflict,
+		Response: &ErrorModel{Code: CodeUsernameTaken, Detail: "username is already taken"},
+	},
+}
+
+var createPostExamples = map[string]OperationExample{
+	"success": {
+		Summary:  "The post is created",
+		Request:  CreatePostRequest{AuthorID: "20240101120000", Title: "Hello, world", Body: "My first post."},
+		Response: &Post{ID: "20240101120500", AuthorID: "20240101120000", Title: "Hello, world", Body: "My first post.", CreatedAt: exampleTime, UpdatedAt: exampleTime},
+	},
+	"validation_failed": {
+		Summary: "The title is missing",
+		Request: map[string]any{"author_id": "20240101120000", "body": "My first post."},
+		Status:  http.StatusUnprocessableEntity,
+		Response: &ErrorModel{Code: CodeValidationFailed, Detail: "validation failed", Errors: []*ErrorDetail{{
+			Field:    "title",
+			Code:     "required",
+			Message:  "expected required property title to be present",
+			Location: "body.title",
+		}}},
+	},
+	"author_not_found": {
+		Summary:  "No user has the author_id",
+		Request:  CreatePostRequest{AuthorID: "20991231235959", Title: "Hello, world", Body: "My first post."},
+		Status:   http.StatusNotFound,
+		Response: &ErrorModel{Code: CodeUserNotFound, Detail: "User not found"},
+	},
+}
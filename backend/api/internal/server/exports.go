@@ -0,0 +1,348 @@
+package server
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// Events about exports. None has a subject.
+const (
+	EventExportCreated    = "export.created"
+	EventExportCompleted  = "export.completed"
+	EventExportFailed     = "export.failed"
+	EventExportDownloaded = "export.downloaded"
+)
+
+const (
+	// exportRetention is how long a finished export is kept.
+	exportRetention = 24 * time.Hour
+	// exportLinkTTL is how long a download link works.
+	exportLinkTTL = time.Hour
+)
+
+// ExportFormat is the file format of an export.
+type ExportFormat string
+
+const (
+	ExportNDJSON ExportFormat = "ndjson" // one user per line, as the API returns them
+	ExportCSV    ExportFormat = "csv"
+)
+
+// ExportStatus is where an export is in its life.
+type ExportStatus string
+
+const (
+	ExportPending   ExportStatus = "pending"
+	ExportRunning   ExportStatus = "running"
+	ExportCompleted ExportStatus = "completed"
+	ExportFailed    ExportStatus = "failed"
+)
+
+// exportCSVHeader are the columns of a CSV export.
+var exportCSVHeader = []string{"id", "username", "name", "email", "phone", "status", "tags", "last_login_at", "last_seen_at", "metadata"}
+
+// ExportFilters select the users an export holds, as the filters of
+// get-v1-users select the users it lists.
+type ExportFilters struct {
+	IncludeInactive bool              `json:"include_inactive,omitempty" doc:"Also export users that are not active"`
+	Tag             []string          `json:"tag,omitempty" example:"[\"beta\"]" doc:"Only export users carrying every one of these tags"`
+	InactiveSince   string            `json:"inactive_since,omitempty" example:"30d" doc:"Only export users not seen since this long ago (e.g. 30d, 2w, 12h) or since an RFC 3339 timestamp"`
+	Metadata        map[string]string `json:"metadata,omitempty" example:"{\"plan\":\"pro\"}" doc:"Only export users whose metadata has these values"`
+}
+
+type CreateExportRequest struct {
+	Format  ExportFormat  `json:"format,omitempty" enum:"ndjson,csv" doc:"File format: ndjson, a user per line as get-v1-users-by-id returns them, or csv; ndjson if omitted"`
+	Filters ExportFilters `json:"filters,omitempty" doc:"Which users to export; every active user if omitted"`
+
+	inactiveCutoff time.Time
+}
+
+// Resolve validates inactive_since, which the schema can't express.
+func (r *CreateExportRequest) Resolve(ctx huma.Context, prefix *huma.PathBuffer) []error {
+	if r.Filters.InactiveSince == "" {
+		return nil
+	}
+	cutoff, err := parseSince(r.Filters.InactiveSince, time.Now())
+	if err != nil {
+		return []error{&ErrorDetail{
+			Location: prefix.With("filters.inactive_since"),
+			Code:     "format",
+			Message:  err.Error(),
+			Value:    r.Filters.InactiveSince,
+		}}
+	}
+	r.inactiveCutoff = cutoff
+	return nil
+}
+
+// Export is a background export of users.
+type Export struct {
+	ID          string          `json:"id" example:"exp_5b0e2a9c1d7f" doc:"Export ID"`
+	Format      ExportFormat    `json:"format" enum:"ndjson,csv" doc:"File format"`
+	Filters     ExportFilters   `json:"filters" doc:"Which users the export holds"`
+	Status      ExportStatus    `json:"status" enum:"pending,running,completed,failed" doc:"pending until it starts, then running until it completed or failed"`
+	Error       string          `json:"error,omitempty" doc:"Why the export failed"`
+	Rows        int             `json:"rows" doc:"Number of users exported, once completed"`
+	Size        int             `json:"size" doc:"Size of the file in bytes, once completed"`
+	CreatedAt   timestamp.Time  `json:"created_at" doc:"When the export was requested"`
+	CompletedAt *timestamp.Time `json:"completed_at,omitempty" doc:"When the export completed or failed"`
+	ExpiresAt   *timestamp.Time `json:"expires_at,omitempty" doc:"When the finished export is deleted"`
+	Timezone    string          `json:"timezone" example:"Europe/Vienna" doc:"IANA time zone the timestamps of a CSV export are in: that of the tz query parameter, the user's preferences or the Time-Zone header when it was requested, else UTC"`
+
+	DownloadURL       string          `json:"download_url,omitempty" example:"/v1/downloads/exports/exp_5b0e2a9c1d7f?expires=1704114000&signature=n2Xc…" doc:"Signed link to the file, relative to the API; it needs no credentials, so treat it as a secret. Only once completed"`
+	DownloadExpiresAt *timestamp.Time `json:"download_expires_at,omitempty" doc:"When download_url stops working; get the export again for a new one"`
+}
+
+type CreateExportInput struct {
+	AdminInput
+	Body CreateExportRequest
+}
+
+type ExportIDInput struct {
+	AdminInput
+	ID string `path:"id" example:"exp_5b0e2a9c1d7f" doc:"Export ID"`
+}
+
+type ExportOutput struct {
+	Location string `header:"Location" doc:"Where to poll the export's status; only when it is created"`
+	Body     *Export
+}
+
+var errExportNotFound = apiError(http.StatusNotFound, CodeExportNotFound, "export not found")
+
+// storedExport is an export with its file, once it has one.
+type storedExport struct {
+	Export
+	filters  *ListUsersInput
+	location *time.Location
+	data     []byte
+}
+
+// ExportService builds exports of users in the background, so large ones
+// don't run into the write timeout, and hands them out as downloads.
+// Like invitations, exports are kept in memory, per replica: they must be
+// polled and downloaded on the replica that built them, and are lost on
+// restart.
+type ExportService struct {
+	users *UserService
+	bus   *events.Bus
+	links *DownloadLinks
+	now   func() time.Time
+
+	mu   sync.Mutex
+	byID map[string]*storedExport
+}
+
+// NewExportService returns a service exporting the users of users, linking
+// to the files with links.
+func NewExportService(users *UserService, bus *events.Bus, links *DownloadLinks) *ExportService {
+	return &ExportService{users: users, bus: bus, links: links, now: time.Now, byID: map[string]*storedExport{}}
+}
+
+// Create records a pending export of the users req selects, formatted in
+// the locale of ctx's request. The caller runs it with Run.
+func (x *ExportService) Create(ctx context.Context, req CreateExportRequest) *Export {
+	id := make([]byte, 6)
+	rand.Read(id)
+	location := localeFrom(ctx).Location
+	exp := &storedExport{
+		Export: Export{
+			ID:        "exp_" + hex.EncodeToString(id),
+			Format:    req.Format,
+			Filters:   req.Filters,
+			Status:    ExportPending,
+			CreatedAt: timestamp.From(x.now()),
+			Timezone:  location.String(),
+		},
+		filters: &ListUsersInput{
+			IncludeInactive: req.Filters.IncludeInactive,
+			Tag:             req.Filters.Tag,
+			inactiveCutoff:  req.inactiveCutoff,
+			metadataFilters: req.Filters.Metadata,
+		},
+		location: location,
+	}
+	x.mu.Lock()
+	x.prune()
+	x.byID[exp.ID] = exp
+	out := x.view(exp)
+	x.mu.Unlock()
+
+	x.bus.Publish(ctx, events.Event{Type: EventExportCreated, Data: map[string]any{"export_id": exp.ID, "format": string(req.Format)}})
+	return out
+}
+
+// Run builds the file of the pending export id.
+func (x *ExportService) Run(ctx context.Context, id string) {
+	x.mu.Lock()
+	exp, ok := x.byID[id]
+	if !ok || exp.Status != ExportPending {
+		x.mu.Unlock()
+		return
+	}
+	exp.Status = ExportRunning
+	x.mu.Unlock()
+
+	data, rows, err := x.build(ctx, exp)
+
+	x.mu.Lock()
+	now := x.now()
+	done, expires := timestamp.From(now), timestamp.From(now.Add(exportRetention))
+	exp.CompletedAt, exp.ExpiresAt = &done, &expires
+	if err != nil {
+		exp.Status, exp.Error = ExportFailed, err.Error()
+	} else {
+		exp.Status, exp.Rows, exp.Size, exp.data = ExportCompleted, rows, len(data), data
+	}
+	x.mu.Unlock()
+
+	if err != nil {
+		x.bus.Publish(ctx, events.Event{Type: EventExportFailed, Data: map[string]any{"export_id": id, "error": err.Error()}})
+		return
+	}
+	x.bus.Publish(ctx, events.Event{Type: EventExportCompleted, Data: map[string]any{"export_id": id, "rows": rows}})
+}
+
+// build writes the users exp selects in its format, in ID order.
+func (x *ExportService) build(ctx context.Context, exp *storedExport) ([]byte, int, error) {
+	users, err := x.users.List(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	users = slices.DeleteFunc(users, func(u *User) bool { return !exp.filters.matches(u) })
+	slices.SortFunc(users, func(a, b *User) int { return strings.Compare(a.ID, b.ID) })
+
+	var buf bytes.Buffer
+	switch exp.Format {
+	case ExportCSV:
+		w := csv.NewWriter(&buf)
+		w.Write(exportCSVHeader)
+		for _, u := range users {
+			if err := w.Write(exportCSVRow(u, exp.location)); err != nil {
+				return nil, 0, err
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, 0, err
+		}
+	default:
+		enc := json.NewEncoder(&buf)
+		for _, u := range users {
+			if err := enc.Encode(u); err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+	return buf.Bytes(), len(users), nil
+}
+
+// exportCSVRow is u's row under exportCSVHeader, its timestamps in loc. Tags
+// are joined with semicolons and metadata is written as JSON.
+func exportCSVRow(u *User, loc *time.Location) []string {
+	at := func(t *timestamp.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.In(loc).Format(time.RFC3339)
+	}
+	var metadata string
+	if len(u.Metadata) > 0 {
+		b, _ := json.Marshal(u.Metadata)
+		metadata = string(b)
+	}
+	return []string{u.ID, u.Username, u.Name, u.Email, u.Phone, string(u.Status), strings.Join(u.Tags, ";"), at(u.LastLoginAt), at(u.LastSeenAt), metadata}
+}
+
+// Get returns the export id, with a fresh download link if it completed.
+func (x *ExportService) Get(id string) (*Export, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.prune()
+	exp, ok := x.byID[id]
+	if !ok {
+		return nil, errExportNotFound
+	}
+	return x.view(exp), nil
+}
+
+// File returns the file of the completed export id, for its download link.
+func (x *ExportService) File(ctx context.Context, id string) (*Download, error) {
+	x.mu.Lock()
+	x.prune()
+	exp, ok := x.byID[id]
+	if !ok || exp.Status != ExportCompleted {
+		x.mu.Unlock()
+		return nil, errExportNotFound
+	}
+	d := &Download{Name: id + "." + string(exp.Format), ContentType: "application/x-ndjson", Data: exp.data}
+	if exp.Format == ExportCSV {
+		d.ContentType = "text/csv"
+	}
+	x.mu.Unlock()
+
+	x.bus.Publish(ctx, events.Event{Type: EventExportDownloaded, Data: map[string]any{"export_id": id}})
+	return d, nil
+}
+
+// view is a copy of exp, with a download link valid for exportLinkTTL if it
+// completed. x.mu must be held.
+func (x *ExportService) view(exp *storedExport) *Export {
+	out := exp.Export
+	if exp.Status == ExportCompleted {
+		link, expires := x.links.Sign("exports", exp.ID, exportLinkTTL)
+		at := timestamp.From(expires)
+		out.DownloadURL, out.DownloadExpiresAt = link, &at
+	}
+	return &out
+}
+
+// prune drops the finished exports past their expiry. x.mu must be held.
+func (x *ExportService) prune() {
+	now := x.now()
+	for id, exp := range x.byID {
+		if exp.ExpiresAt != nil && !now.Before(exp.ExpiresAt.Time) {
+			delete(x.byID, id)
+		}
+	}
+}
+
+// createExport is the post-v1-exports handler. The export runs as a
+// background job, which shutdown waits for, after the request ends.
+func (s *Server) createExport(ctx context.Context, input *CreateExportInput) (*ExportOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	input.Body.Format = cmp.Or(input.Body.Format, ExportNDJSON)
+	exp := s.exports.Create(ctx, input.Body)
+	job := context.WithoutCancel(ctx)
+	s.goJob(func() { s.exports.Run(job, exp.ID) })
+	return &ExportOutput{Location: "/v1/exports/" + exp.ID, Body: exp}, nil
+}
+
+// getExport is the get-v1-exports-by-id handler.
+func (s *Server) getExport(ctx context.Context, input *ExportIDInput) (*ExportOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	exp, err := s.exports.Get(input.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &ExportOutput{Body: exp}, nil
+}
@@ -0,0 +1,177 @@
+package server
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// FaultRule makes a share of a route's requests slow, fail or drop, to
+// exercise clients' timeouts and retries.
+type FaultRule struct {
+	Route     string  `json:"route" minLength:"1" example:"/v1/users/{id}" doc:"Route the rule applies to, with {param} for any one segment and a trailing /* for any rest, or * for every route; the admin API and probes are never hit"`
+	Method    string  `json:"method,omitempty" enum:"GET,HEAD,POST,PUT,PATCH,DELETE" doc:"Only requests with this method; any if omitted"`
+	Rate      float64 `json:"rate" minimum:"0" maximum:"1" example:"0.25" doc:"Share of the matching requests the fault hits"`
+	LatencyMS int     `json:"latency_ms,omitempty" minimum:"0" maximum:"60000" doc:"Delay before the request is handled, failed or dropped"`
+	Status    int     `json:"status,omitempty" minimum:"500" maximum:"599" doc:"Answer with this status, and code INJECTED_FAULT, instead of handling the request"`
+	Drop      bool    `json:"drop,omitempty" doc:"Close the connection without answering"`
+}
+
+// Resolve refuses a rule that both fails and drops requests.
+func (f *FaultRule) Resolve(ctx huma.Context, prefix *huma.PathBuffer) []error {
+	if f.Status != 0 && f.Drop {
+		return []error{&ErrorDetail{
+			Location: prefix.With("drop"),
+			Code:     "invalid",
+			Message:  "a rule either answers with status or drops the connection",
+			Value:    f.Drop,
+		}}
+	}
+	return nil
+}
+
+// matches reports whether the rule applies to r.
+func (f *FaultRule) matches(r *http.Request) bool {
+	if f.Method != "" && f.Method != r.Method {
+		return false
+	}
+	return matchRoute(f.Route, r.URL.Path)
+}
+
+// matchRoute matches path against a chi-style route pattern. It runs
+// before routing, so matching the chi pattern itself isn't possible yet.
+func matchRoute(pattern, path string) bool {
+	if pattern == "*" {
+		return true
+	}
+	want := strings.Split(strings.Trim(pattern, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	for i, seg := range want {
+		if seg == "*" && i == len(want)-1 {
+			return true
+		}
+		if i >= len(got) {
+			return false
+		}
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			if got[i] == "" {
+				return false
+			}
+		} else if seg != got[i] {
+			return false
+		}
+	}
+	return len(got) == len(want)
+}
+
+// faultInjector holds the rules in effect. Only the first rule matching a
+// request applies.
+type faultInjector struct {
+	rules atomic.Pointer[[]FaultRule]
+}
+
+func newFaultInjector() *faultInjector {
+	f := &faultInjector{}
+	f.rules.Store(&[]FaultRule{})
+	return f
+}
+
+// pick returns the rule to apply to r, if the dice say so.
+func (f *faultInjector) pick(r *http.Request) (FaultRule, bool) {
+	for _, rule := range *f.rules.Load() {
+		if rule.matches(r) {
+			return rule, rand.Float64() < rule.Rate
+		}
+	}
+	return FaultRule{}, false
+}
+
+// injectFaults applies the fault rules, if fault injection is on.
+func (s *Server) injectFaults(next http.Handler) http.Handler {
+	if s.faults == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if adminPath(r.URL.Path) || probePath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		rule, ok := s.faults.pick(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if rule.LatencyMS > 0 {
+			timer := time.NewTimer(time.Duration(rule.LatencyMS) * time.Millisecond)
+			select {
+			case <-timer.C:
+			case <-r.Context().Done():
+				timer.Stop()
+				return
+			}
+		}
+		switch {
+		case rule.Drop:
+			// net/http closes the connection, or resets the HTTP/2
+			// stream, without logging.
+			panic(http.ErrAbortHandler)
+		case rule.Status != 0:
+			writeError(w, r, rule.Status, CodeInjectedFault, "fault injected for testing")
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+type FaultRules struct {
+	Rules []FaultRule `json:"rules" maxItems:"100" doc:"The rules, tried in order; the first matching a request applies"`
+}
+
+type GetFaultsInput struct {
+	AdminInput
+}
+
+type PutFaultsInput struct {
+	AdminInput
+	Body FaultRules
+}
+
+type FaultsOutput struct {
+	Body FaultRules
+}
+
+// errFaultsOff is what /admin/faults answers when fault injection is off.
+var errFaultsOff = apiError(http.StatusNotFound, CodeNotFound, "fault injection is off; set FAULT_INJECTION")
+
+// getFaults is the get-admin-faults handler.
+func (s *Server) getFaults(ctx context.Context, input *GetFaultsInput) (*FaultsOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	if s.faults == nil {
+		return nil, errFaultsOff
+	}
+	return &FaultsOutput{Body: FaultRules{Rules: *s.faults.rules.Load()}}, nil
+}
+
+// putFaults is the put-admin-faults handler.
+func (s *Server) putFaults(ctx context.Context, input *PutFaultsInput) (*FaultsOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	if s.faults == nil {
+		return nil, errFaultsOff
+	}
+	rules := input.Body.Rules
+	if rules == nil {
+		rules = []FaultRule{}
+	}
+	s.faults.rules.Store(&rules)
+	s.logger.WarnContext(ctx, "fault rules changed", "rules", len(rules))
+	return &FaultsOutput{Body: FaultRules{Rules: rules}}, nil
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+	humayaml "github.com/danielgtaylor/huma/v2/yaml"
+	"gopkg.in/yaml.v3"
+)
+
+// yamlFormat serves and accepts `application/yaml`. Values go through JSON on
+// the way in and out so the `json` struct tags stay the single source of truth
+// for field names, exactly as they are for the JSON and CBOR formats.
+var yamlFormat = huma.Format{
+	Marshal: func(w io.Writer, v any) error {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		return humayaml.Convert(w, bytes.NewReader(b))
+	},
+	Unmarshal: func(data []byte, v any) error {
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		b, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(b, v)
+	},
+}
+
+// refuseUnacceptable returns a huma middleware answering 406 NOT_ACCEPTABLE
+// to a request whose Accept takes none of formats, those huma negotiates,
+// nor a content type its operation documents, rather than the JSON huma
+// would fall back to. A request without Accept takes anything.
+func (s *Server) refuseUnacceptable(formats map[string]huma.Format) func(huma.Context, func(huma.Context)) {
+	var negotiated []string
+	for ct := range formats {
+		if strings.Contains(ct, "/") {
+			negotiated = append(negotiated, ct)
+		}
+	}
+	return func(ctx huma.Context, next func(huma.Context)) {
+		accept := ctx.Header("Accept")
+		if accept == "" {
+			next(ctx)
+			return
+		}
+		offered := slices.Clone(negotiated)
+		for _, resp := range ctx.Operation().Responses {
+			for ct := range resp.Content {
+				offered = append(offered, ct)
+			}
+		}
+		if acceptable(accept, offered) {
+			next(ctx)
+			return
+		}
+		huma.WriteErr(s.api, ctx, http.StatusNotAcceptable, "no response format matches the Accept header")
+	}
+}
+
+// acceptable reports whether the Accept header accept takes one of the
+// content types offered: one of them, a range like text/* one is in, or
+// */*. Types with a +json suffix are taken as JSON, as huma marshals them.
+func acceptable(accept string, offered []string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		ct, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q <= 0 {
+			continue
+		}
+		if strings.HasSuffix(ct, "+json") {
+			ct = "application/json"
+		}
+		for _, o := range offered {
+			o, _, _ = mime.ParseMediaType(o)
+			if ct == "*/*" || ct == o || strings.HasSuffix(ct, "/*") && strings.HasPrefix(o, strings.TrimSuffix(ct, "*")) {
+				return true
+			}
+		}
+	}
+	return false
+}
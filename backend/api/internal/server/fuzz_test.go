@@ -0,0 +1,87 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/apitest"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/server"
+)
+
+var fuzzContentTypes = []string{"application/json", "application/cbor", "application/yaml"}
+
+var (
+	e164Pattern     = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+	usernamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{2,31}$`)
+)
+
+// fuzzUserBody sends body to method path in each supported format and checks
+// that the server neither fails nor stores anything validation should have
+// rejected.
+func fuzzUserBody(f *testing.F, method, path string, seeds ...string) {
+	for _, s := range seeds {
+		for ct := range fuzzContentTypes {
+			f.Add([]byte(s), uint8(ct))
+		}
+	}
+	store := server.NewMemoryStore()
+	for _, u := range apitest.Users() {
+		store.PutUser(context.Background(), u)
+	}
+	srv := server.NewServer(server.Config{UniquePhones: true}, store)
+
+	f.Fuzz(func(t *testing.T, body []byte, ct uint8) {
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", fuzzContentTypes[int(ct)%len(fuzzContentTypes)])
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, req)
+
+		if rec.Code >= 500 {
+			t.Fatalf("status %d for body %q: %s", rec.Code, body, rec.Body)
+		}
+		if rec.Code != http.StatusOK && rec.Code != http.StatusCreated {
+			return
+		}
+		var user server.User
+		if err := json.Unmarshal(rec.Body.Bytes(), &user); err != nil {
+			t.Fatalf("response is not a user: %v: %s", err, rec.Body)
+		}
+		if user.Phone != "" && !e164Pattern.MatchString(user.Phone) {
+			t.Errorf("stored phone %q is not E.164", user.Phone)
+		}
+		if user.Username != "" && !usernamePattern.MatchString(user.Username) {
+			t.Errorf("stored username %q is invalid", user.Username)
+		}
+		if b, _ := json.Marshal(user.Metadata); len(b) > 8<<10 {
+			t.Errorf("stored %d bytes of metadata", len(b))
+		}
+	})
+}
+
+func FuzzCreateUserRequest(f *testing.F) {
+	fuzzUserBody(f, http.MethodPost, "/v1/users",
+		`{"name":"Ro","email":"ro@example.com"}`,
+		`{"name":"Ro","email":"ro@example.com","username":"Ro_C","phone":"0043 (660) 123-4567"}`,
+		`{"name":"Ro","email":"ro@example.com","metadata":{"a":{"b":{"c":[1,2,{"d":null}]}}}}`,
+		`{"name":1,"emial":"x"}`,
+		`name: Ro
+email: ro@example.com
+phone: "+1 555 0100"`,
+		`[]`,
+	)
+}
+
+func FuzzUpdateUserRequest(f *testing.F) {
+	fuzzUserBody(f, http.MethodPut, "/v1/users/"+apitest.AdaID,
+		`{"name":"Ada"}`,
+		`{"id":"x","status":"deleted","active":false,"phone":""}`,
+		`{"username":"ADA_L","phone":"+43 660 1234567"}`,
+		`{"metadata":{"plan":"free","seats":3.5}}`,
+		`{"username":null,"name":null}`,
+	)
+}
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humachi"
+)
+
+// halContentType asks for responses in HAL (draft-kelly-json-hal): the
+// same fields, plus _links to the resources they relate to and the other
+// pages of a list, and lists' entries under _embedded, so a generic API
+// browser can walk the API.
+const halContentType = "application/hal+json"
+
+// halLink is a HAL link object.
+type halLink struct {
+	Href string `json:"href"`
+}
+
+// halLinks maps link relations to their links.
+type halLinks map[string]halLink
+
+// halResource turns v, a JSON object, into a HAL resource with links and
+// the embedded resources; an embedded field moves out of v's own.
+func halResource(v any, links halLinks, embedded map[string]any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	delete(fields, "$schema")
+	fields["_links"] = links
+	for name := range embedded {
+		delete(fields, name)
+	}
+	if len(embedded) > 0 {
+		fields["_embedded"] = embedded
+	}
+	return fields, nil
+}
+
+func halUser(u *User) (map[string]any, error) {
+	self := "/v1/users/" + url.PathEscape(u.ID)
+	embedded := map[string]any{}
+	if len(u.Posts) > 0 {
+		posts, err := halEach(u.Posts, halPost)
+		if err != nil {
+			return nil, err
+		}
+		embedded["posts"] = posts
+	}
+	return halResource(u, halLinks{
+		"self":        {self},
+		"posts":       {self + "/posts"},
+		"preferences": {self + "/preferences"},
+	}, embedded)
+}
+
+func halPost(p *Post) (map[string]any, error) {
+	self := "/v1/posts/" + url.PathEscape(p.ID)
+	embedded := map[string]any{}
+	if len(p.Comments) > 0 {
+		comments, err := halEach(p.Comments, halComment)
+		if err != nil {
+			return nil, err
+		}
+		embedded["comments"] = comments
+	}
+	return halResource(p, halLinks{
+		"self":     {self},
+		"author":   {"/v1/users/" + url.PathEscape(p.AuthorID)},
+		"comments": {self + "/comments"},
+	}, embedded)
+}
+
+func halComment(c *Comment) (map[string]any, error) {
+	post := "/v1/posts/" + url.PathEscape(c.PostID)
+	links := halLinks{
+		"self":   {post + "/comments/" + url.PathEscape(c.ID)},
+		"post":   {post},
+		"author": {"/v1/users/" + url.PathEscape(c.AuthorID)},
+	}
+	if c.ParentID != "" {
+		links["parent"] = halLink{post + "/comments/" + url.PathEscape(c.ParentID)}
+	}
+	return halResource(c, links, nil)
+}
+
+// halEach converts every one of items with convert.
+func halEach[T any](items []T, convert func(T) (map[string]any, error)) ([]map[string]any, error) {
+	out := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		r, err := convert(item)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// halList turns a page of a list into a HAL resource, its entries embedded
+// under name. Its links are the request's own and those of the Link header
+// the handler set, the first, previous, next and last pages.
+func halList[T any](ctx huma.Context, body any, name string, items []T, convert func(T) (map[string]any, error)) (any, error) {
+	embedded, err := halEach(items, convert)
+	if err != nil {
+		return nil, err
+	}
+	_, w := humachi.Unwrap(ctx)
+	links := parseLinkHeader(w.Header().Get("Link"))
+	self := ctx.URL()
+	links["self"] = halLink{self.RequestURI()}
+	return halResource(body, links, map[string]any{name: embedded})
+}
+
+// parseLinkHeader parses a Link header as pageLinks writes it into links
+// by relation.
+func parseLinkHeader(header string) halLinks {
+	links := halLinks{}
+	for _, part := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(part), ";")
+		if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			if rel, ok := strings.CutPrefix(strings.TrimSpace(param), "rel="); ok {
+				links[strings.Trim(rel, `"`)] = halLink{strings.Trim(target, "<>")}
+			}
+		}
+	}
+	return links
+}
+
+// halTransformer is a huma transformer that answers in HAL the requests
+// that ask for it. Users, posts and comments, and lists of them, get their
+// links; other bodies, errors among them, are left as they are.
+func (s *Server) halTransformer(ctx huma.Context, status string, v any) (any, error) {
+	// Which format is used depends on Accept, so caches must keep them
+	// apart.
+	ctx.AppendHeader("Vary", "Accept")
+	if ct, err := s.api.Negotiate(ctx.Header("Accept")); err != nil || ct != halContentType {
+		return v, nil
+	}
+	switch body := v.(type) {
+	case *User:
+		return halUser(body)
+	case *Post:
+		return halPost(body)
+	case *Comment:
+		return halComment(body)
+	case *UsersListResponse:
+		return halList(ctx, body, "users", body.Users, halUser)
+	case *PostsListResponse:
+		return halList(ctx, body, "posts", body.Posts, halPost)
+	case *CommentsListResponse:
+		return halList(ctx, body, "comments", body.Comments, halComment)
+	}
+	return v, nil
+}
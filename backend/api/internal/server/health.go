@@ -0,0 +1,135 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// The health endpoints answer in JSON by default, and with ?format= in the
+// other forms orchestrators and people read. The media types carry
+// parameters, which content negotiation ignores, so only ?format= picks
+// them and no other operation does.
+const (
+	healthPlainType      = "text/plain; charset=utf-8"
+	healthPrometheusType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// healthFormats are the huma formats of the health endpoints' other forms.
+var healthFormats = map[string]huma.Format{
+	healthPlainType:      {Marshal: marshalHealth(writePlainHealth)},
+	healthPrometheusType: {Marshal: marshalHealth(writePrometheusHealth)},
+}
+
+// healthContentTypes maps ?format= values to media types.
+var healthContentTypes = map[string]string{
+	"plain":      healthPlainType,
+	"prometheus": healthPrometheusType,
+}
+
+type HealthInput struct {
+	Format string `query:"format" enum:"json,plain,prometheus" default:"json" doc:"json, plain for a line per component that people and shell scripts can read, or prometheus for gauges in the Prometheus text format"`
+}
+
+// contentType is the Content-Type of the format asked for, or "" to
+// negotiate it.
+func (in *HealthInput) contentType() string {
+	return healthContentTypes[in.Format]
+}
+
+// healthResponses documents a health endpoint's body, of type body, in
+// each format.
+func healthResponses(api huma.API, body any) map[string]*huma.Response {
+	text := &huma.MediaType{Schema: &huma.Schema{Type: "string"}}
+	return map[string]*huma.Response{
+		"200": {
+			Description: "OK",
+			Content: map[string]*huma.MediaType{
+				"application/json":   {Schema: api.OpenAPI().Components.Schemas.Schema(reflect.TypeOf(body), true, "")},
+				healthPlainType:      text,
+				healthPrometheusType: text,
+			},
+		},
+	}
+}
+
+// healthReport is what every health body has, or may have, in common.
+// Bodies are read through JSON, as the $schema transformer swaps them for
+// copies of another type.
+type healthReport struct {
+	Status     int               `json:"status"`
+	Reason     string            `json:"reason"`
+	Components []ComponentStatus `json:"components"`
+}
+
+func marshalHealth(write func(io.Writer, healthReport) error) func(io.Writer, any) error {
+	return func(w io.Writer, v any) error {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		var r healthReport
+		if err := json.Unmarshal(b, &r); err != nil {
+			return err
+		}
+		return write(w, r)
+	}
+}
+
+// writePlainHealth writes "ok", or "unavailable" and why, then a line per
+// component.
+func writePlainHealth(w io.Writer, r healthReport) error {
+	var b strings.Builder
+	if r.Status == http.StatusOK {
+		b.WriteString("ok\n")
+	} else {
+		b.WriteString("unavailable")
+		if r.Reason != "" {
+			b.WriteString(": " + r.Reason)
+		}
+		b.WriteString("\n")
+	}
+	for _, c := range r.Components {
+		ready := "ready"
+		if !c.Ready {
+			ready = "not ready"
+		}
+		fmt.Fprintf(&b, "%s: %s, %s", c.Name, c.Status, ready)
+		if c.Error != "" {
+			b.WriteString(": " + c.Error)
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writePrometheusHealth writes health_up and, for each component,
+// health_component_ready.
+func writePrometheusHealth(w io.Writer, r healthReport) error {
+	var b strings.Builder
+	up := 0
+	if r.Status == http.StatusOK {
+		up = 1
+	}
+	b.WriteString("# HELP health_up Whether the probe passes.\n# TYPE health_up gauge\n")
+	fmt.Fprintf(&b, "health_up %d\n", up)
+	if len(r.Components) > 0 {
+		b.WriteString("# HELP health_component_ready Whether the component is running and ready.\n# TYPE health_component_ready gauge\n")
+	}
+	for _, c := range r.Components {
+		ready := 0
+		if c.Ready {
+			ready = 1
+		}
+		fmt.Fprintf(&b, "health_component_ready{component=%s,status=%s} %d\n", strconv.Quote(c.Name), strconv.Quote(c.Status), ready)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
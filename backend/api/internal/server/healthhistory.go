@@ -0,0 +1,169 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// defaultHealthProbeInterval is Config.HealthProbeInterval if that is zero.
+const defaultHealthProbeInterval = 10 * time.Second
+
+// Bounds of the health history: a day of probes at the default interval,
+// and the latest transitions.
+const (
+	healthHistorySize    = 8640
+	maxHealthTransitions = 100
+)
+
+// What a health probe found.
+const (
+	HealthUp   = "up"   // the server took traffic
+	HealthDown = "down" // it answered /readyz with 503
+)
+
+// HealthTransition is a change in what the health probes found.
+type HealthTransition struct {
+	Time       timestamp.Time `json:"time" doc:"When the probe that saw the change ran"`
+	Status     string         `json:"status" enum:"up,down" doc:"What the server was from then on"`
+	Reason     string         `json:"reason,omitempty" enum:"draining,not_ready" doc:"Why it was down, as /readyz gives it"`
+	Components []string       `json:"components,omitempty" example:"store" doc:"The components that weren't ready, if any"`
+}
+
+// HealthHistory is what the latest health probes of this replica found.
+type HealthHistory struct {
+	Status          string             `json:"status,omitempty" enum:"up,down" doc:"What the latest probe found; left out before the first"`
+	UptimePercent   *float64           `json:"uptime_percent,omitempty" example:"99.95" doc:"The share of the probes kept that found the server up, in percent; left out before the first"`
+	Probes          int                `json:"probes" example:"8640" doc:"How many probes are kept: the latest, up to 8640"`
+	Since           *timestamp.Time    `json:"since,omitempty" doc:"When the oldest probe kept ran"`
+	IntervalSeconds int                `json:"interval_seconds" example:"10" doc:"Seconds between probes"`
+	Transitions     []HealthTransition `json:"transitions" doc:"The latest changes in status, newest first, up to 100; the first probe is one"`
+}
+
+type HealthHistoryOutput struct {
+	Body *HealthHistory
+}
+
+// healthProbe is the outcome of one health probe.
+type healthProbe struct {
+	time time.Time
+	up   bool
+}
+
+// healthHistory keeps the outcomes of the health probes, in a ring buffer
+// of the latest healthHistorySize, and the transitions between up and
+// down. Like the dead letters, it is kept in memory, per replica, and
+// starts over on restart.
+type healthHistory struct {
+	mu          sync.Mutex
+	probes      []healthProbe // a ring once healthHistorySize long
+	next        int           // where the next probe goes once probes is full
+	up          int           // how many of probes found the server up
+	transitions []HealthTransition
+}
+
+// record adds a probe done at now that got r from readiness, returning the
+// transition it makes, if any.
+func (h *healthHistory) record(now time.Time, r *ReadinessResponse) *HealthTransition {
+	p := healthProbe{time: now, up: r.Status < 300}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var last *healthProbe
+	if n := len(h.probes); n > 0 {
+		last = &h.probes[(h.next+n-1)%n]
+	}
+	changed := last == nil || last.up != p.up
+	if len(h.probes) < healthHistorySize {
+		h.probes = append(h.probes, p)
+	} else {
+		if h.probes[h.next].up {
+			h.up--
+		}
+		h.probes[h.next] = p
+		h.next = (h.next + 1) % healthHistorySize
+	}
+	if p.up {
+		h.up++
+	}
+	if !changed {
+		return nil
+	}
+	t := HealthTransition{Time: timestamp.From(now), Status: HealthUp}
+	if !p.up {
+		t.Status, t.Reason = HealthDown, r.Reason
+		for _, c := range r.Components {
+			if !c.Ready {
+				t.Components = append(t.Components, c.Name)
+			}
+		}
+	}
+	if len(h.transitions) == maxHealthTransitions {
+		h.transitions = slices.Delete(h.transitions, 0, 1)
+	}
+	h.transitions = append(h.transitions, t)
+	return &t
+}
+
+// history returns what the probes kept found, taken every interval.
+func (h *healthHistory) history(interval time.Duration) *HealthHistory {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := &HealthHistory{Probes: len(h.probes), IntervalSeconds: int(interval / time.Second), Transitions: []HealthTransition{}}
+	for _, t := range slices.Backward(h.transitions) {
+		t.Components = slices.Clone(t.Components)
+		out.Transitions = append(out.Transitions, t)
+	}
+	if n := len(h.probes); n > 0 {
+		out.Status = HealthDown
+		if h.probes[(h.next+n-1)%n].up {
+			out.Status = HealthUp
+		}
+		uptime := 100 * float64(h.up) / float64(n)
+		out.UptimePercent = &uptime
+		since := timestamp.From(h.probes[h.next%n].time)
+		out.Since = &since
+	}
+	return out
+}
+
+func (s *Server) healthProbeInterval() time.Duration {
+	return cmp.Or(s.cfg.HealthProbeInterval, defaultHealthProbeInterval)
+}
+
+// healthLoop probes the server's readiness at startup and every
+// HealthProbeInterval after, as the orchestrator would, into s.health.
+func (s *Server) healthLoop(ctx context.Context) {
+	probe := func() {
+		t := s.health.record(time.Now(), s.readiness())
+		switch {
+		case t == nil:
+		case t.Status == HealthDown:
+			s.logger.Warn("health probe failed", "reason", t.Reason, "components", t.Components)
+		default:
+			s.logger.Info("health probe passes")
+		}
+	}
+	probe()
+	ticker := time.NewTicker(s.healthProbeInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			probe()
+		}
+	}
+}
+
+// getHealthHistory is the get-admin-health-history handler.
+func (s *Server) getHealthHistory(ctx context.Context, input *AdminInput) (*HealthHistoryOutput, error) {
+	if err := s.authorizeAdmin(ctx, *input); err != nil {
+		return nil, err
+	}
+	return &HealthHistoryOutput{Body: s.health.history(s.healthProbeInterval())}, nil
+}
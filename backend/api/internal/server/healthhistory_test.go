@@ -0,0 +1,44 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHealthHistory(t *testing.T) {
+	var h healthHistory
+	if got := h.history(time.Second); got.Status != "" || got.UptimePercent != nil || len(got.Transitions) != 0 {
+		t.Fatalf("before any probe: %+v", got)
+	}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	up := &ReadinessResponse{Status: http.StatusOK}
+	down := &ReadinessResponse{Status: http.StatusServiceUnavailable, Reason: "not_ready", Components: []ComponentStatus{{Name: "metrics", Ready: true}, {Name: "store", Error: "no raft leader"}}}
+	for i, r := range []*ReadinessResponse{up, up, down, up} {
+		h.record(start.Add(time.Duration(i)*10*time.Second), r)
+	}
+	got := h.history(10 * time.Second)
+	if got.Status != HealthUp || *got.UptimePercent != 75 || got.Probes != 4 || !got.Since.Equal(start) || got.IntervalSeconds != 10 {
+		t.Errorf("history %+v", got)
+	}
+	var statuses []string
+	for _, tr := range got.Transitions {
+		statuses = append(statuses, tr.Status)
+	}
+	if !slices.Equal(statuses, []string{HealthUp, HealthDown, HealthUp}) {
+		t.Errorf("transitions %v, want up, down, up newest first", statuses)
+	}
+	if tr := got.Transitions[1]; tr.Reason != "not_ready" || !slices.Equal(tr.Components, []string{"store"}) || !tr.Time.Equal(start.Add(20*time.Second)) {
+		t.Errorf("down transition %+v", tr)
+	}
+
+	// Once full, the oldest probes make way for new ones.
+	for i := range healthHistorySize {
+		h.record(start.Add(time.Duration(4+i)*10*time.Second), down)
+	}
+	got = h.history(10 * time.Second)
+	if got.Status != HealthDown || *got.UptimePercent != 0 || got.Probes != healthHistorySize || !got.Since.Equal(start.Add(40*time.Second)) {
+		t.Errorf("full history: status %s, uptime %v, %d probes since %v", got.Status, *got.UptimePercent, got.Probes, got.Since)
+	}
+}
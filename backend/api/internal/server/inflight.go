@@ -0,0 +1,223 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// InFlightRequest is a request the server is serving.
+type InFlightRequest struct {
+	ID       string         `json:"id" example:"req_4bf92f3577b34da6a3ce929d0e0e4736" doc:"The request's X-Request-ID"`
+	Method   string         `json:"method" example:"GET" doc:"HTTP method"`
+	Path     string         `json:"path" example:"/v1/users/20240101120000/data-export" doc:"Request path, without the query"`
+	Route    string         `json:"route,omitempty" example:"/v1/users/{userID}/data-export" doc:"The operation's route, once one matched"`
+	ClientIP string         `json:"client_ip" example:"203.0.113.9" doc:"The client's address, as resolved through TRUSTED_PROXIES"`
+	Started  timestamp.Time `json:"started" doc:"When the request arrived"`
+	AgeMS    float64        `json:"age_ms" example:"48210.5" doc:"How long the request has been running, in milliseconds"`
+	Canceled bool           `json:"canceled,omitempty" doc:"true if it was canceled and its handler hasn't returned yet"`
+}
+
+// errRequestCanceled is the cause of the context of a request canceled
+// through POST /admin/inflight/{id}/cancel.
+var errRequestCanceled = errors.New("canceled through the admin API")
+
+// inFlightRequests is the registry of the requests being served. The zero
+// value is empty and ready to use.
+type inFlightRequests struct {
+	mu       sync.Mutex
+	requests map[*inFlightEntry]struct{}
+}
+
+type inFlightEntry struct {
+	InFlightRequest // guarded by inFlightRequests.mu
+	cancel          context.CancelCauseFunc
+}
+
+type inFlightKey struct{}
+
+func (f *inFlightRequests) add(e *inFlightEntry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.requests == nil {
+		f.requests = map[*inFlightEntry]struct{}{}
+	}
+	f.requests[e] = struct{}{}
+}
+
+func (f *inFlightRequests) remove(e *inFlightEntry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.requests, e)
+}
+
+// setRoute records the route of the request ctx belongs to.
+func (f *inFlightRequests) setRoute(ctx context.Context, route string) {
+	e, ok := ctx.Value(inFlightKey{}).(*inFlightEntry)
+	if !ok {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	e.Route = route
+}
+
+// list returns the requests, oldest first.
+func (f *inFlightRequests) list(now time.Time) []InFlightRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]InFlightRequest, 0, len(f.requests))
+	for e := range f.requests {
+		r := e.InFlightRequest
+		r.AgeMS = float64(now.Sub(r.Started.Time).Microseconds()) / 1000
+		out = append(out, r)
+	}
+	slices.SortFunc(out, func(a, b InFlightRequest) int {
+		if c := a.Started.Compare(b.Started.Time); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return out
+}
+
+// cancel cancels the requests with the ID id, which can be several as
+// clients pick their own, and returns them.
+func (f *inFlightRequests) cancel(id string, now time.Time) []InFlightRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []InFlightRequest
+	for e := range f.requests {
+		if e.ID != id {
+			continue
+		}
+		e.cancel(errRequestCanceled)
+		e.Canceled = true
+		r := e.InFlightRequest
+		r.AgeMS = float64(now.Sub(r.Started.Time).Microseconds()) / 1000
+		out = append(out, r)
+	}
+	return out
+}
+
+// trackInFlight registers every request in s.inflight while it is served,
+// with a context POST /admin/inflight/{id}/cancel can cancel. A request
+// canceled before it started its response gets a 503 REQUEST_CANCELED in
+// place of whatever its handler makes of the canceled context; one
+// canceled midway, such as a stream, is cut short.
+func (s *Server) trackInFlight(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithCancelCause(r.Context())
+		defer cancel(nil)
+		e := &inFlightEntry{
+			InFlightRequest: InFlightRequest{
+				ID:       requestID(ctx),
+				Method:   r.Method,
+				Path:     r.URL.Path,
+				ClientIP: remoteHost(r.RemoteAddr),
+				Started:  timestamp.From(time.Now()),
+			},
+			cancel: cancel,
+		}
+		s.inflight.add(e)
+		defer s.inflight.remove(e)
+		cw := &cancelableWriter{ResponseWriter: w, ctx: ctx}
+		r = r.WithContext(context.WithValue(ctx, inFlightKey{}, e))
+		next.ServeHTTP(cw, r)
+		if context.Cause(ctx) == errRequestCanceled && cw.drop() {
+			w.Header().Del("Content-Length")
+			writeError(w, r, http.StatusServiceUnavailable, CodeRequestCanceled, "the request was canceled by an operator")
+		}
+	})
+}
+
+// nameInFlight is a huma middleware recording the operation's route in the
+// request's entry in s.inflight.
+func (s *Server) nameInFlight(ctx huma.Context, next func(huma.Context)) {
+	s.inflight.setRoute(ctx.Context(), ctx.Operation().Path)
+	next(ctx)
+}
+
+// cancelableWriter drops the response of a request canceled through the
+// admin API before it was started, so trackInFlight can answer instead,
+// as it does for one whose handler gave up without answering.
+type cancelableWriter struct {
+	http.ResponseWriter
+	ctx     context.Context
+	started bool
+	dropped bool
+}
+
+// drop reports whether to drop a write to the response.
+func (c *cancelableWriter) drop() bool {
+	if !c.started {
+		c.started = true
+		c.dropped = context.Cause(c.ctx) == errRequestCanceled
+	}
+	return c.dropped
+}
+
+func (c *cancelableWriter) WriteHeader(status int) {
+	if !c.drop() {
+		c.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (c *cancelableWriter) Write(p []byte) (int, error) {
+	if c.drop() {
+		return len(p), nil
+	}
+	return c.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (c *cancelableWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+type ListInFlightInput struct {
+	AdminInput
+}
+
+type InFlightList struct {
+	Requests []InFlightRequest `json:"requests" doc:"The requests, oldest first"`
+}
+
+type InFlightListOutput struct {
+	Body *InFlightList
+}
+
+type CancelInFlightInput struct {
+	AdminInput
+	ID string `path:"id" maxLength:"128" example:"req_4bf92f3577b34da6a3ce929d0e0e4736" doc:"X-Request-ID of the request to cancel"`
+}
+
+// listInFlight is the get-admin-inflight handler.
+func (s *Server) listInFlight(ctx context.Context, input *ListInFlightInput) (*InFlightListOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	return &InFlightListOutput{Body: &InFlightList{Requests: s.inflight.list(time.Now())}}, nil
+}
+
+// cancelInFlight is the post-admin-inflight-by-id-cancel handler.
+func (s *Server) cancelInFlight(ctx context.Context, input *CancelInFlightInput) (*InFlightListOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	canceled := s.inflight.cancel(input.ID, time.Now())
+	if len(canceled) == 0 {
+		return nil, apiError(http.StatusNotFound, CodeNotFound, "no request with that ID is in flight")
+	}
+	for _, r := range canceled {
+		s.logger.WarnContext(ctx, "canceled an in-flight request", "canceled_request_id", r.ID, "route", r.Route, "age_ms", r.AgeMS)
+	}
+	return &InFlightListOutput{Body: &InFlightList{Requests: canceled}}, nil
+}
@@ -0,0 +1,427 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/email"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// Events about invitations. An accepted one's subject is the user it became;
+// the others have none.
+const (
+	EventInvitationCreated  = "invitation.created"
+	EventInvitationAccepted = "invitation.accepted"
+	EventInvitationRevoked  = "invitation.revoked"
+)
+
+// defaultInvitationTTL is how long an invitation is valid unless it says.
+const defaultInvitationTTL = 7 * 24 * time.Hour
+
+// InvitationStatus is where an invitation is in its life.
+type InvitationStatus string
+
+const (
+	InvitationPending  InvitationStatus = "pending"
+	InvitationAccepted InvitationStatus = "accepted"
+	InvitationRevoked  InvitationStatus = "revoked"
+	InvitationExpired  InvitationStatus = "expired"
+)
+
+// Invitation invites someone to create an account with their email.
+type Invitation struct {
+	ID         string           `json:"id" example:"inv_3f9a1c2b7d4e" doc:"Invitation ID"`
+	Email      string           `json:"email" redact:"true" example:"kim@example.com" doc:"Who is invited; the user's email once they accept"`
+	Name       string           `json:"name,omitempty" redact:"true" example:"Kim Lee" doc:"The name they are greeted with and the user gets, unless they give another on accepting"`
+	Locale     string           `json:"locale" example:"de" doc:"Language of the invitation email"`
+	Status     InvitationStatus `json:"status" enum:"pending,accepted,revoked,expired" doc:"pending until accepted, revoked or past expires_at"`
+	CreatedAt  timestamp.Time   `json:"created_at" doc:"When the invitation was created"`
+	ExpiresAt  timestamp.Time   `json:"expires_at" doc:"When the invitation stops working if not accepted"`
+	AcceptedAt *timestamp.Time  `json:"accepted_at,omitempty" doc:"When the invitation was accepted"`
+	UserID     string           `json:"user_id,omitempty" example:"20240101120000" doc:"The user the invitation became"`
+	RevokedAt  *timestamp.Time  `json:"revoked_at,omitempty" doc:"When the invitation was revoked"`
+}
+
+// NewInvitation is an invitation with its token, which is only ever shown
+// once.
+type NewInvitation struct {
+	Invitation
+	Token   string `json:"token" redact:"true" example:"9c1f0d3e…" doc:"The token post-v1-invitations-by-token-accept takes; it can't be shown again"`
+	Emailed bool   `json:"emailed" doc:"Whether the invitation was emailed, which needs APP_URL for the link, or queued to be if the mail server is refusing emails"`
+}
+
+type CreateInvitationRequest struct {
+	Email     string          `json:"email" format:"email" maxLength:"254" example:"kim@example.com" redact:"true" doc:"Who to invite"`
+	Name      string          `json:"name,omitempty" maxLength:"200" example:"Kim Lee" redact:"true" doc:"What to call them"`
+	Locale    string          `json:"locale,omitempty" pattern:"^[a-z]{2}(-[A-Z]{2})?$" example:"de" doc:"BCP 47 language tag of the invitation email; en if omitted"`
+	ExpiresAt *timestamp.Time `json:"expires_at,omitempty" doc:"When the invitation stops working; in 7 days if omitted"`
+}
+
+// Resolve refuses an expiry that has passed and, with
+// BLOCK_DISPOSABLE_EMAILS, a disposable email.
+func (r *CreateInvitationRequest) Resolve(ctx huma.Context, prefix *huma.PathBuffer) []error {
+	errs := validateEmailDomain(ctx.Context(), prefix, r.Email)
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(time.Now()) {
+		errs = append(errs, &ErrorDetail{
+			Location: prefix.With("expires_at"),
+			Code:     "invalid",
+			Message:  "expected a time in the future",
+			Value:    r.ExpiresAt,
+		})
+	}
+	return errs
+}
+
+type AcceptInvitationRequest struct {
+	Username string `json:"username,omitempty" example:"kim_l" redact:"true" doc:"Unique handle: 3-32 lowercase letters, digits or underscores, starting with a letter"`
+	Name     string `json:"name,omitempty" maxLength:"200" example:"Kim Lee" redact:"true" doc:"User's name; the invitation's if omitted, which requires it has one"`
+	Phone    string `json:"phone,omitempty" example:"+43 660 1234567" redact:"true" doc:"International phone number; stored in E.164 form"`
+	Password string `json:"password" minLength:"8" maxLength:"72" writeOnly:"true" redact:"true" doc:"Password for post-v1-auth-login"`
+}
+
+type CreateInvitationInput struct {
+	AdminInput
+	Body CreateInvitationRequest
+}
+
+type ListInvitationsInput struct {
+	AdminInput
+	Status string `query:"status" enum:"pending,accepted,revoked,expired" doc:"Only list the invitations with this status"`
+}
+
+type InvitationIDInput struct {
+	AdminInput
+	ID string `path:"id" example:"inv_3f9a1c2b7d4e" doc:"Invitation ID"`
+}
+
+type AcceptInvitationInput struct {
+	Token string `path:"token" redact:"true" doc:"The invitation's token"`
+	Body  AcceptInvitationRequest
+}
+
+type NewInvitationOutput struct {
+	Body *NewInvitation
+}
+
+type InvitationsResponse struct {
+	Invitations []*Invitation `json:"invitations" doc:"The invitations, newest first"`
+}
+
+type InvitationsOutput struct {
+	Body *InvitationsResponse
+}
+
+var (
+	errInvitationNotFound = apiError(http.StatusNotFound, CodeInvitationNotFound, "invitation not found")
+	errInvitationAccepted = apiError(http.StatusConflict, CodeConflict, "the invitation was already accepted")
+)
+
+// StoredInvitation is an invitation as the store keeps it, with the hash
+// of its token.
+type StoredInvitation struct {
+	Invitation
+	TokenHash string `json:"token_hash"`
+}
+
+// status is inv's status at now.
+func (inv *StoredInvitation) status(now time.Time) InvitationStatus {
+	if inv.Status == InvitationPending && !now.Before(inv.ExpiresAt.Time) {
+		return InvitationExpired
+	}
+	return inv.Status
+}
+
+// view is a copy of inv with its status at now.
+func (inv *StoredInvitation) view(now time.Time) *Invitation {
+	out := inv.Invitation
+	out.Status = inv.status(now)
+	return &out
+}
+
+func (inv *StoredInvitation) clone() *StoredInvitation {
+	c := *inv
+	return &c
+}
+
+// InvitationService keeps the invitations to create an account, in the
+// store, and turns accepted ones into users.
+type InvitationService struct {
+	users *UserService
+	bus   *events.Bus
+	now   func() time.Time
+
+	mu sync.Mutex
+	// accepting holds the invitations whose user is being created, so each
+	// is only accepted, or revoked, once. Acceptances are writes, which
+	// raft followers forward to the leader, so one replica sees them all.
+	accepting map[string]bool
+}
+
+// NewInvitationService returns a service keeping invitations in the store
+// of users, which creates the users of accepted ones.
+func NewInvitationService(users *UserService, bus *events.Bus) *InvitationService {
+	return &InvitationService{users: users, bus: bus, now: time.Now, accepting: map[string]bool{}}
+}
+
+// Create invites req.Email, unless a user has that email or it has a
+// pending invitation already.
+func (v *InvitationService) Create(ctx context.Context, req CreateInvitationRequest) (*NewInvitation, error) {
+	users, err := v.users.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	folding := v.users.emailFolding
+	if slices.ContainsFunc(users, func(u *User) bool { return folding.same(u.Email, req.Email) }) {
+		return nil, apiError(http.StatusConflict, CodeConflict, "a user with the email already exists")
+	}
+	now := v.now()
+	b := make([]byte, 24)
+	rand.Read(b)
+	token := hex.EncodeToString(b)
+	id := make([]byte, 6)
+	rand.Read(id)
+	inv := &StoredInvitation{
+		Invitation: Invitation{
+			ID:        "inv_" + hex.EncodeToString(id),
+			Email:     req.Email,
+			Name:      req.Name,
+			Locale:    cmp.Or(req.Locale, email.Fallback),
+			Status:    InvitationPending,
+			CreatedAt: timestamp.From(now),
+			ExpiresAt: timestamp.From(now.Add(defaultInvitationTTL)),
+		},
+		TokenHash: hashAPIKey(token),
+	}
+	if req.ExpiresAt != nil {
+		inv.ExpiresAt = *req.ExpiresAt
+	}
+
+	v.mu.Lock()
+	invitations, err := v.users.store.ListInvitations(ctx)
+	if err == nil && slices.ContainsFunc(invitations, func(other *StoredInvitation) bool {
+		return other.status(now) == InvitationPending && folding.same(other.Email, req.Email)
+	}) {
+		err = apiError(http.StatusConflict, CodeConflict, "the email has a pending invitation already; revoke it first")
+	}
+	if err == nil {
+		err = v.users.store.PutInvitation(ctx, inv)
+	}
+	v.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	created := inv.view(now)
+	v.bus.Publish(ctx, events.Event{Type: EventInvitationCreated, Data: map[string]any{"invitation_id": inv.ID}})
+	return &NewInvitation{Invitation: *created, Token: token}, nil
+}
+
+// List returns the invitations, newest first, only those with status if it
+// isn't empty.
+func (v *InvitationService) List(ctx context.Context, status InvitationStatus) ([]*Invitation, error) {
+	invitations, err := v.users.store.ListInvitations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := v.now()
+	out := []*Invitation{}
+	for _, inv := range invitations {
+		if status == "" || inv.status(now) == status {
+			out = append(out, inv.view(now))
+		}
+	}
+	slices.SortFunc(out, func(a, b *Invitation) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt.Time), strings.Compare(b.ID, a.ID))
+	})
+	return out, nil
+}
+
+// Revoke stops a pending or expired invitation from being accepted.
+// Revoking it again is a no-op.
+func (v *InvitationService) Revoke(ctx context.Context, id string) error {
+	v.mu.Lock()
+	revoked, err := v.revoke(ctx, id)
+	v.mu.Unlock()
+	if err != nil || !revoked {
+		return err
+	}
+	v.bus.Publish(ctx, events.Event{Type: EventInvitationRevoked, Data: map[string]any{"invitation_id": id}})
+	return nil
+}
+
+// revoke revokes the invitation unless it was already. The caller holds
+// v.mu.
+func (v *InvitationService) revoke(ctx context.Context, id string) (bool, error) {
+	if v.accepting[id] {
+		return false, errInvitationAccepted
+	}
+	inv, err := v.users.store.GetInvitation(ctx, id)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return false, errInvitationNotFound
+	case err != nil:
+		return false, err
+	case inv.Status == InvitationAccepted:
+		return false, errInvitationAccepted
+	case inv.Status == InvitationRevoked:
+		return false, nil
+	}
+	inv.Status = InvitationRevoked
+	at := timestamp.From(v.now())
+	inv.RevokedAt = &at
+	return true, v.users.store.PutInvitation(ctx, inv)
+}
+
+// Accept creates the user the pending invitation with token invites, with
+// its email.
+func (v *InvitationService) Accept(ctx context.Context, token string, req AcceptInvitationRequest) (*User, error) {
+	inv, err := v.claim(ctx, token, req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		v.mu.Lock()
+		delete(v.accepting, inv.ID)
+		v.mu.Unlock()
+	}()
+
+	// The user, their credentials and the accepted invitation are written
+	// together, and only if the invitation is still pending, so one revoked
+	// or accepted meanwhile, by another replica say, makes no user.
+	user, err := v.users.create(ctx, CreateUserRequest{
+		Username: req.Username,
+		Name:     cmp.Or(req.Name, inv.Name),
+		Email:    inv.Email,
+		Phone:    req.Phone,
+		Password: req.Password,
+	}, func(tx Store, user *User) error {
+		cur, err := tx.GetInvitation(ctx, inv.ID)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return errInvitationNotFound
+		case err != nil:
+			return err
+		case cur.Status == InvitationRevoked:
+			return errInvitationNotFound
+		case cur.Status == InvitationAccepted:
+			return errInvitationAccepted
+		}
+		cur.Status, cur.UserID = InvitationAccepted, user.ID
+		at := timestamp.From(v.now())
+		cur.AcceptedAt = &at
+		return tx.PutInvitation(ctx, cur)
+	})
+	if err != nil {
+		return nil, err
+	}
+	v.bus.Publish(ctx, events.Event{Type: EventInvitationAccepted, Subject: user.ID, Data: map[string]any{"invitation_id": inv.ID}})
+	return user, nil
+}
+
+// claim finds the pending invitation with token and marks it as being
+// accepted, unless it can't be.
+func (v *InvitationService) claim(ctx context.Context, token string, req AcceptInvitationRequest) (*StoredInvitation, error) {
+	invitations, err := v.users.store.ListInvitations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hash := hashAPIKey(token)
+	i := slices.IndexFunc(invitations, func(inv *StoredInvitation) bool { return inv.TokenHash == hash })
+	if i < 0 || invitations[i].Status == InvitationRevoked {
+		return nil, errInvitationNotFound
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	// Read again now no other acceptance or revocation can run.
+	inv, err := v.users.store.GetInvitation(ctx, invitations[i].ID)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return nil, errInvitationNotFound
+	case err != nil:
+		return nil, err
+	case inv.Status == InvitationRevoked:
+		return nil, errInvitationNotFound
+	case inv.Status == InvitationAccepted || v.accepting[inv.ID]:
+		return nil, errInvitationAccepted
+	case inv.status(v.now()) == InvitationExpired:
+		return nil, apiError(http.StatusGone, CodeInvitationExpired, "the invitation has expired")
+	case req.Name == "" && inv.Name == "":
+		return nil, apiError(http.StatusUnprocessableEntity, CodeValidationFailed, "a name is required, as the invitation has none")
+	}
+	v.accepting[inv.ID] = true
+	return inv, nil
+}
+
+// createInvitation is the post-v1-invitations handler. It emails the
+// invitation if there is a dashboard to link to.
+func (s *Server) createInvitation(ctx context.Context, input *CreateInvitationInput) (*NewInvitationOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	inv, err := s.invitations.Create(ctx, input.Body)
+	if err != nil {
+		return nil, err
+	}
+	if s.cfg.AppURL != "" {
+		if err := s.sendInvitation(ctx, inv); err != nil {
+			s.logger.ErrorContext(ctx, "failed to send invitation", "invitation", inv.ID, "err", err)
+		} else {
+			inv.Emailed = true
+		}
+	}
+	return &NewInvitationOutput{Body: inv}, nil
+}
+
+func (s *Server) sendInvitation(ctx context.Context, inv *NewInvitation) error {
+	msg, err := email.Render(email.Invitation, inv.Locale, email.InvitationData{
+		Name:      inv.Name,
+		Link:      s.cfg.AppURL + "/invitations/accept?token=" + url.QueryEscape(inv.Token),
+		ValidDays: int(time.Until(inv.ExpiresAt.Time).Round(24*time.Hour) / (24 * time.Hour)),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.mail.send(ctx, inv.Email, msg)
+	return err
+}
+
+// listInvitations is the get-v1-invitations handler.
+func (s *Server) listInvitations(ctx context.Context, input *ListInvitationsInput) (*InvitationsOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	invitations, err := s.invitations.List(ctx, InvitationStatus(input.Status))
+	if err != nil {
+		return nil, err
+	}
+	return &InvitationsOutput{Body: &InvitationsResponse{Invitations: invitations}}, nil
+}
+
+// revokeInvitation is the delete-v1-invitations-by-id handler.
+func (s *Server) revokeInvitation(ctx context.Context, input *InvitationIDInput) (*struct{}, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	return nil, s.invitations.Revoke(ctx, input.ID)
+}
+
+// acceptInvitation is the post-v1-invitations-by-token-accept handler.
+func (s *Server) acceptInvitation(ctx context.Context, input *AcceptInvitationInput) (*UserOutput, error) {
+	user, err := s.invitations.Accept(ctx, input.Token, input.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &UserOutput{Body: user}, nil
+}
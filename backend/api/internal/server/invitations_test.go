@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/idgen"
+)
+
+// revokingStore revokes invitation when users are next listed, as another
+// replica might while an acceptance creates the user.
+type revokingStore struct {
+	*MemoryStore
+	invitation *string
+}
+
+func (s revokingStore) ListUsers(ctx context.Context) ([]*User, error) {
+	if id := *s.invitation; id != "" {
+		*s.invitation = ""
+		inv, err := s.MemoryStore.GetInvitation(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		inv.Status = InvitationRevoked
+		if err := s.MemoryStore.PutInvitation(ctx, inv); err != nil {
+			return nil, err
+		}
+	}
+	return s.MemoryStore.ListUsers(ctx)
+}
+
+func TestAcceptRevokedMeanwhileCreatesNoUser(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	var revoke string
+	users := NewUserService(revokingStore{store, &revoke}, events.New(), nil, slog.Default(), false, EmailFolding{}, idgen.UUIDv4{})
+	invitations := NewInvitationService(users, events.New())
+	inv, err := invitations.Create(ctx, CreateInvitationRequest{Email: "kim@example.com", Name: "Kim Lee"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revoke = inv.ID
+	_, err = invitations.Accept(ctx, inv.Token, AcceptInvitationRequest{Password: "correct horse"})
+	var status huma.StatusError
+	if !errors.As(err, &status) || status.GetStatus() != http.StatusNotFound {
+		t.Fatalf("accepting: %v, want a 404", err)
+	}
+	if all, _ := store.ListUsers(ctx); len(all) != 0 {
+		t.Errorf("%d users, want none", len(all))
+	}
+	if got, _ := store.GetInvitation(ctx, inv.ID); got.Status != InvitationRevoked {
+		t.Errorf("invitation %s, want it to stay revoked", got.Status)
+	}
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// IPRule admits the clients Allow matches, or every client if Allow is
+// empty, unless Deny matches them.
+type IPRule struct {
+	Allow []netip.Prefix
+	Deny  []netip.Prefix
+}
+
+func (r IPRule) admits(addr netip.Addr, ok bool) bool {
+	matches := func(p netip.Prefix) bool { return ok && p.Contains(addr) }
+	if slices.ContainsFunc(r.Deny, matches) {
+		return false
+	}
+	return len(r.Allow) == 0 || slices.ContainsFunc(r.Allow, matches)
+}
+
+func (r IPRule) equal(o IPRule) bool {
+	return slices.Equal(r.Allow, o.Allow) && slices.Equal(r.Deny, o.Deny)
+}
+
+// IPAccess is which client addresses may call the API. A request has to be
+// admitted by All and, for the admin API and /metrics, by Admin as well.
+type IPAccess struct {
+	All   IPRule
+	Admin IPRule
+}
+
+func (a IPAccess) equal(o IPAccess) bool {
+	return a.All.equal(o.All) && a.Admin.equal(o.Admin)
+}
+
+// ParsePrefixes parses a comma-separated list of CIDRs and addresses.
+func ParsePrefixes(s string) ([]netip.Prefix, error) {
+	var out []netip.Prefix
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		prefix, err := parsePrefix(entry)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, prefix)
+	}
+	return out, nil
+}
+
+// parsePrefix parses a CIDR, or an address as the prefix of just it.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if prefix, err := netip.ParsePrefix(s); err == nil {
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%s: want a CIDR or an address", s)
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// restrictIPs answers 403 to clients s.ipAccess doesn't admit. It runs after
+// resolveClientIP, so the rules apply to clients rather than to the proxies
+// in front of them. Probes are exempt from All.
+func (s *Server) restrictIPs(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		access := s.ipAccess.Load()
+		addr, err := netip.ParseAddr(remoteHost(r.RemoteAddr))
+		addr = addr.Unmap()
+		if !access.All.admits(addr, err == nil) && !probePath(r.URL.Path) ||
+			adminPath(r.URL.Path) && !access.Admin.admits(addr, err == nil) {
+			s.publishDenied(r, EventPermissionDenied, "", "ip_not_allowed", nil)
+			writeError(w, r, http.StatusForbidden, CodeIPNotAllowed, "your address may not call this endpoint")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
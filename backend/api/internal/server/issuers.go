@@ -0,0 +1,65 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/jwks"
+)
+
+// defaultJWKSRefreshInterval is how often the JWT issuers' keys are fetched
+// again without JWKS_REFRESH_INTERVAL.
+const defaultJWKSRefreshInterval = 15 * time.Minute
+
+// ParseJWTIssuers parses a comma-separated list of issuers, each its iss
+// URL optionally followed by a space and the URL of its JWKS, every one
+// expecting tokens for audience if set.
+func ParseJWTIssuers(s, audience string) ([]jwks.Issuer, error) {
+	var out []jwks.Issuer
+	for _, entry := range strings.Split(s, ",") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("%s: want the issuer, then optionally its JWKS URL", strings.TrimSpace(entry))
+		}
+		for _, f := range fields {
+			if u, err := url.Parse(f); err != nil || u.Scheme != "https" && u.Scheme != "http" || u.Host == "" {
+				return nil, fmt.Errorf("%s: want an http or https URL", f)
+			}
+		}
+		issuer := jwks.Issuer{URL: fields[0], Audience: audience}
+		if len(fields) == 2 {
+			issuer.JWKSURL = fields[1]
+		}
+		out = append(out, issuer)
+	}
+	return out, nil
+}
+
+// jwksLoop fetches the JWT issuers' keys at startup and every interval
+// after, so tokens signed with a rotated key are verified without waiting
+// for a fetch. A failed fetch keeps the keys fetched before.
+func (s *Server) jwksLoop(ctx context.Context) {
+	refresh := func() {
+		if err := s.jwks.Refresh(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Warn("failed to refresh JWT issuer keys", "err", err)
+		}
+	}
+	refresh()
+	ticker := time.NewTicker(cmp.Or(s.cfg.JWKSRefreshInterval, defaultJWKSRefreshInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			refresh()
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/lock"
+)
+
+// Singleton jobs, scheduled retention runs and digests, only run on the
+// replica that leads. With a RaftStore that is the raft leader; otherwise
+// the replicas elect one through the leader lock of s.locks, which with
+// the default in-process Locker means every replica leads itself.
+const (
+	ElectionRaft  = "raft"
+	ElectionLock  = "lock"
+	ElectionLocal = "local"
+)
+
+// lockLeader names the lock the leader holds.
+const lockLeader = "leader"
+
+// leaderTTL is how long the leader lock outlives a leader that died, and
+// so how long its jobs may go without a leader.
+const leaderTTL = 15 * time.Second
+
+// leadershipCheckInterval is how often Run reports whether this replica
+// leads, to the logs when it changes and to the metrics every time.
+const leadershipCheckInterval = 5 * time.Second
+
+// leadership tells whether this replica leads, and who does.
+type leadership interface {
+	IsLeader() bool
+	// Leader returns the ID of the replica that leads, or "" if none does.
+	Leader(ctx context.Context) (string, error)
+}
+
+// defaultReplicaID names this replica when REPLICA_ID is unset: its raft
+// node ID, or else its hostname, the pod name on Kubernetes.
+func defaultReplicaID(raftNodeID string) string {
+	host, _ := os.Hostname()
+	return cmp.Or(raftNodeID, host)
+}
+
+// watchLeadership reports whether this replica leads until ctx is done.
+func (s *Server) watchLeadership(ctx context.Context) {
+	ticker := time.NewTicker(leadershipCheckInterval)
+	defer ticker.Stop()
+	leading := false
+	for {
+		now := s.leader.IsLeader()
+		s.metrics.leader(now)
+		if now != leading {
+			if now {
+				s.logger.InfoContext(ctx, "became the leader, running the singleton jobs", "replica", s.replicaID)
+			} else {
+				s.logger.InfoContext(ctx, "no longer the leader", "replica", s.replicaID)
+			}
+			leading = now
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// LeaderStatus is who runs the singleton jobs.
+type LeaderStatus struct {
+	Leader   string `json:"leader,omitempty" example:"api-0" doc:"ID of the replica that leads; omitted while none does, as after the leader died and before another takes over"`
+	Replica  string `json:"replica" example:"api-1" doc:"ID of the replica that answered"`
+	IsLeader bool   `json:"is_leader" doc:"Whether the replica that answered leads"`
+	Election string `json:"election" enum:"raft,lock,local" doc:"How the leader is elected: it is the raft leader, holds the leader lock in Redis, or, with neither, every replica leads itself"`
+}
+
+type LeaderInput struct {
+	AdminInput
+}
+
+type LeaderOutput struct {
+	Body LeaderStatus
+}
+
+var errLeaderUnknown = apiError(http.StatusServiceUnavailable, CodeLeaderUnknown, "the leader couldn't be looked up")
+
+// getLeader is the get-admin-leader handler.
+func (s *Server) getLeader(ctx context.Context, input *LeaderInput) (*LeaderOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	leader, err := s.leader.Leader(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to look up the leader", "err", err)
+		return nil, errLeaderUnknown
+	}
+	return &LeaderOutput{Body: LeaderStatus{
+		Leader:   leader,
+		Replica:  s.replicaID,
+		IsLeader: s.leader.IsLeader(),
+		Election: s.election,
+	}}, nil
+}
+
+// newLeadership picks how s elects its leader; the returned Elector, if
+// any, has to be run.
+func (s *Server) newLeadership(store Store) *lock.Elector {
+	if rs, ok := store.(*RaftStore); ok {
+		s.leader, s.election = rs, ElectionRaft
+		return nil
+	}
+	e := lock.NewElector(s.locks, lockLeader, leaderTTL)
+	s.leader, s.election = e, ElectionLock
+	if s.cfg.Locker == nil {
+		s.election = ElectionLocal
+	}
+	return e
+}
@@ -0,0 +1,291 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Statuses of a component, as /readyz reports them.
+const (
+	ComponentStarting = "starting"
+	ComponentRunning  = "running"
+	ComponentStopping = "stopping"
+	ComponentStopped  = "stopped"
+	ComponentFailed   = "failed" // it failed to start or to stop
+)
+
+// component is a part of the server Run starts and shuts down: the store,
+// the background jobs, the listeners. It starts after the components it
+// depends on and stops before them.
+type component struct {
+	name string
+	// after names the components it depends on. Those that aren't
+	// registered, like optional ones that aren't configured, are ignored.
+	after []string
+	start func(ctx context.Context) error // nil if New set it up already
+	stop  func(ctx context.Context) error // nil if there is nothing to stop
+	// ready is its readiness gate: while it returns an error, /readyz
+	// fails. nil if it is ready once started.
+	ready func() error
+
+	status string
+	err    error // why it failed
+}
+
+// ComponentStatus is how a component is doing.
+type ComponentStatus struct {
+	Name   string `json:"name" example:"store" doc:"The component"`
+	Status string `json:"status" enum:"starting,running,stopping,stopped,failed" doc:"Where it is in its lifecycle"`
+	Ready  bool   `json:"ready" doc:"Whether it is running and its readiness gate, if it has one, passes"`
+	Error  string `json:"error,omitempty" example:"no raft leader" doc:"Why it failed, or isn't ready"`
+}
+
+// lifecycle starts the components of the server in dependency order and
+// stops them in reverse.
+type lifecycle struct {
+	logger *slog.Logger
+
+	mu         sync.Mutex
+	components []*component // in start order once start has sorted them
+	started    int          // how many of components were started
+}
+
+// add registers c, stopped until start starts it. Components must be added
+// before start.
+func (l *lifecycle) add(c *component) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	c.status = ComponentStopped
+	l.components = append(l.components, c)
+}
+
+// sort orders l.components so each comes after those it depends on, keeping
+// the order they were added in otherwise. It fails on a dependency cycle.
+// l.mu must be held.
+func (l *lifecycle) sort() error {
+	registered := map[string]bool{}
+	for _, c := range l.components {
+		registered[c.name] = true
+	}
+	placed := map[string]bool{}
+	sorted := make([]*component, 0, len(l.components))
+	for len(sorted) < len(l.components) {
+		i := slices.IndexFunc(l.components, func(c *component) bool {
+			return !placed[c.name] && !slices.ContainsFunc(c.after, func(dep string) bool { return registered[dep] && !placed[dep] })
+		})
+		if i < 0 {
+			var left []string
+			for _, c := range l.components {
+				if !placed[c.name] {
+					left = append(left, c.name)
+				}
+			}
+			return fmt.Errorf("components depend on each other: %s", strings.Join(left, ", "))
+		}
+		placed[l.components[i].name] = true
+		sorted = append(sorted, l.components[i])
+	}
+	l.components = sorted
+	return nil
+}
+
+func (l *lifecycle) setStatus(c *component, status string, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	c.status, c.err = status, err
+}
+
+// start starts the components in dependency order. If one fails, those
+// already started are stopped again, in reverse.
+func (l *lifecycle) start(ctx context.Context) error {
+	l.mu.Lock()
+	err := l.sort()
+	components := slices.Clone(l.components)
+	l.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	for i, c := range components {
+		l.setStatus(c, ComponentStarting, nil)
+		if c.start != nil {
+			if err := c.start(ctx); err != nil {
+				l.setStatus(c, ComponentFailed, err)
+				l.stop(ctx)
+				return fmt.Errorf("start %s: %w", c.name, err)
+			}
+		}
+		l.setStatus(c, ComponentRunning, nil)
+		l.mu.Lock()
+		l.started = i + 1
+		l.mu.Unlock()
+		l.logger.Debug("component started", "component", c.name)
+	}
+	return nil
+}
+
+// stop stops the components that were started, in reverse dependency
+// order, each after those that depend on it. A component that fails to stop
+// is logged and doesn't keep the others running.
+func (l *lifecycle) stop(ctx context.Context) error {
+	l.mu.Lock()
+	started := slices.Clone(l.components[:l.started])
+	l.started = 0
+	l.mu.Unlock()
+	var errs []error
+	for _, c := range slices.Backward(started) {
+		l.setStatus(c, ComponentStopping, nil)
+		if c.stop != nil {
+			if err := c.stop(ctx); err != nil {
+				l.logger.Error("failed to stop component", "component", c.name, "err", err)
+				l.setStatus(c, ComponentFailed, err)
+				errs = append(errs, fmt.Errorf("stop %s: %w", c.name, err))
+				continue
+			}
+		}
+		l.setStatus(c, ComponentStopped, nil)
+		l.logger.Debug("component stopped", "component", c.name)
+	}
+	return errors.Join(errs...)
+}
+
+// status reports every component, in start order, checking the readiness
+// gates of those that are running.
+func (l *lifecycle) status() []ComponentStatus {
+	l.mu.Lock()
+	components := slices.Clone(l.components)
+	out := make([]ComponentStatus, len(components))
+	for i, c := range components {
+		out[i] = ComponentStatus{Name: c.name, Status: c.status, Ready: c.status == ComponentRunning}
+		if c.err != nil {
+			out[i].Error = c.err.Error()
+		}
+	}
+	l.mu.Unlock()
+	// Outside the lock: a gate may take a moment.
+	for i, c := range components {
+		if out[i].Ready && c.ready != nil {
+			if err := c.ready(); err != nil {
+				out[i].Ready, out[i].Error = false, err.Error()
+			}
+		}
+	}
+	return out
+}
+
+// readiness is what /readyz answers: 503 while draining or while a
+// component isn't ready.
+func (s *Server) readiness() *ReadinessResponse {
+	components := s.lifecycle.status()
+	resp := &ReadinessResponse{Status: http.StatusOK, Components: components}
+	if s.draining.Load() {
+		resp.Status, resp.Reason = http.StatusServiceUnavailable, "draining"
+	} else if slices.ContainsFunc(components, func(c ComponentStatus) bool { return !c.Ready }) {
+		resp.Status, resp.Reason = http.StatusServiceUnavailable, "not_ready"
+	}
+	return resp
+}
+
+// addComponents registers the components Run starts before the jobs and
+// the listeners, most of which New set up already. snap is the store to
+// save a snapshot of on shutdown, if any.
+func (s *Server) addComponents(snap snapshotter) {
+	s.lifecycle.add(&component{name: "metrics", stop: func(context.Context) error { return s.metrics.close() }})
+	s.lifecycle.add(&component{name: "search", stop: func(context.Context) error { return s.search.index.Close() }})
+	s.lifecycle.add(&component{name: "locks", stop: func(context.Context) error { return closeIfCloser(s.locks) }})
+	store := &component{
+		name: "store",
+		stop: func(context.Context) error {
+			if snap != nil {
+				s.saveSnapshot(snap)
+			}
+			return closeIfCloser(s.store)
+		},
+	}
+	if rs, ok := s.store.(*RaftStore); ok {
+		// Writes are forwarded to the leader, so without one they fail.
+		store.ready = func() error {
+			if id, _ := rs.Leader(context.Background()); id == "" {
+				return errors.New("no raft leader")
+			}
+			return nil
+		}
+	}
+	s.lifecycle.add(store)
+	s.lifecycle.add(&component{name: "audit", stop: func(context.Context) error { return s.audit.Close() }})
+	// The deliveries aren't jobs: they deliver the events of the requests
+	// shutdown drains, so they stop after the listeners and the jobs.
+	if s.security != nil {
+		s.lifecycle.add(&component{
+			name:  "security_events",
+			start: func(context.Context) error { go s.security.run(); return nil },
+			stop:  func(ctx context.Context) error { s.security.close(ctx); return nil },
+		})
+	}
+	if s.purges != nil {
+		s.lifecycle.add(&component{
+			name:  "cache_purge",
+			start: func(context.Context) error { go s.purges.run(); return nil },
+			stop:  func(ctx context.Context) error { s.purges.close(ctx); return nil },
+		})
+	}
+	if s.shadow != nil {
+		s.lifecycle.add(&component{name: "shadow_traffic", stop: s.shadow.close})
+	}
+	s.lifecycle.add(&component{
+		name:  "activity",
+		start: func(context.Context) error { go s.activity.run(); return nil },
+		stop:  func(ctx context.Context) error { s.activity.close(ctx); return nil },
+	})
+	s.lifecycle.add(&component{
+		name:  "mail_queue",
+		start: func(context.Context) error { go s.mail.run(); return nil },
+		stop:  func(ctx context.Context) error { s.mail.close(ctx); return nil },
+	})
+}
+
+func closeIfCloser(v any) error {
+	if c, ok := v.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// startJobs starts the background jobs, which run until ctx ends: the
+// leader election and whatever runs on the leader or on a schedule.
+func (s *Server) startJobs(ctx context.Context, snap snapshotter) {
+	if s.elector != nil {
+		s.goJob(func() { s.elector.Run(ctx) })
+	}
+	s.goJob(func() { s.watchLeadership(ctx) })
+	s.goJob(func() { s.healthLoop(ctx) })
+	if snap != nil && s.cfg.StoreSnapshotInterval > 0 {
+		s.goJob(func() { s.snapshotLoop(ctx, snap, s.cfg.StoreSnapshotInterval) })
+	}
+	if s.retentionEnabled() {
+		s.goJob(func() { s.retentionLoop(ctx, cmp.Or(s.cfg.RetentionInterval, time.Hour)) })
+	}
+	if s.cfg.DigestSchedule {
+		s.goJob(func() { s.digestLoop(ctx) })
+	}
+	if s.jwks != nil {
+		s.goJob(func() { s.jwksLoop(ctx) })
+	}
+	if s.disposable != nil && s.disposable.url != "" {
+		s.goJob(func() { s.disposableLoop(ctx) })
+	}
+	if p, ok := s.locks.(pooledLocker); ok {
+		s.goJob(func() { s.poolStatsLoop(ctx, p) })
+	}
+	if s.telemetry.enabled {
+		s.goJob(func() { s.telemetryLoop(ctx) })
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLifecycle(t *testing.T) {
+	var log []string
+	gate := errors.New("warming up")
+	newLifecycle := func(failStart string) *lifecycle {
+		l := &lifecycle{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+		for _, c := range []struct{ name, after string }{{"http", "jobs"}, {"jobs", "store"}, {"store", ""}, {"metrics", "unregistered"}} {
+			l.add(&component{
+				name:  c.name,
+				after: []string{c.after},
+				start: func(context.Context) error {
+					if c.name == failStart {
+						return errors.New("boom")
+					}
+					log = append(log, "start "+c.name)
+					return nil
+				},
+				stop:  func(context.Context) error { log = append(log, "stop "+c.name); return nil },
+				ready: func() error { return gate },
+			})
+		}
+		return l
+	}
+	ctx := context.Background()
+
+	l := newLifecycle("")
+	if err := l.start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if st := l.status(); len(st) != 4 || st[0].Name != "store" || st[0].Status != ComponentRunning || st[0].Ready || st[0].Error != "warming up" {
+		t.Errorf("status behind a failing gate: %+v", st)
+	}
+	gate = nil
+	if st := l.status(); !st[0].Ready || st[0].Error != "" {
+		t.Errorf("status once the gate passes: %+v", st)
+	}
+	if err := l.stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"start store", "start jobs", "start http", "start metrics", "stop metrics", "stop http", "stop jobs", "stop store"}
+	if !slices.Equal(log, want) {
+		t.Errorf("ran %v, want %v", log, want)
+	}
+	if st := l.status(); st[3].Status != ComponentStopped || st[3].Ready {
+		t.Errorf("status after stopping: %+v", st)
+	}
+
+	// A failed start stops what already started.
+	log = nil
+	l = newLifecycle("http")
+	if err := l.start(ctx); err == nil || !strings.Contains(err.Error(), "start http: boom") {
+		t.Fatalf("start: %v, want http to fail", err)
+	}
+	if want := []string{"start store", "start jobs", "stop jobs", "stop store"}; !slices.Equal(log, want) {
+		t.Errorf("ran %v, want %v", log, want)
+	}
+	if st := l.status(); st[2].Status != ComponentFailed || st[2].Error != "boom" {
+		t.Errorf("status of the failed component: %+v", st[2])
+	}
+
+	l = &lifecycle{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	l.add(&component{name: "a", after: []string{"b"}})
+	l.add(&component{name: "b", after: []string{"a"}})
+	if err := l.start(ctx); err == nil {
+		t.Error("started components that depend on each other")
+	}
+}
@@ -0,0 +1,236 @@
+package server
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type ListUsersInput struct {
+	UserIncludes
+	TimeBudgetInput
+	Page            int      `query:"page" minimum:"1" default:"1" doc:"Page number, starting at 1, of the users in ID order: oldest first, unless ID_GENERATOR=uuidv4 makes IDs random"`
+	PerPage         int      `query:"per_page" minimum:"1" maximum:"500" default:"100" doc:"Users per page"`
+	After           string   `query:"after" maxLength:"100" example:"20240101120000" doc:"Start after the user with this ID, the last of the previous page, instead of at page; unlike page numbers, it neither skips nor repeats users when others are deleted or created between requests. next.after gives it"`
+	IncludeInactive bool     `query:"include_inactive" doc:"Also list users that are not active"`
+	Tag             []string `query:"tag,explode" doc:"Only list users carrying this tag; repeat to require several" example:"beta"`
+	InactiveSince   string   `query:"inactive_since" doc:"Only list users not seen since this long ago (e.g. 30d, 2w, 12h) or since an RFC 3339 timestamp" example:"30d"`
+
+	// The metadata.<key>=<value> filters aren't declared above because their
+	// names are open-ended; Resolve collects them from the raw query instead.
+	inactiveCutoff  time.Time
+	metadataFilters map[string]string
+	url             url.URL
+	cursor          cursor
+}
+
+// Resolve validates the list filters that can't be expressed in the schema.
+func (i *ListUsersInput) Resolve(ctx huma.Context) []error {
+	var errs []error
+	if i.InactiveSince != "" {
+		cutoff, err := parseSince(i.InactiveSince, time.Now())
+		if err != nil {
+			errs = append(errs, &ErrorDetail{
+				Location: "query.inactive_since",
+				Code:     "format",
+				Message:  err.Error(),
+				Value:    i.InactiveSince,
+			})
+		}
+		i.inactiveCutoff = cutoff
+	}
+	u := ctx.URL()
+	i.url = u
+	for name, values := range u.Query() {
+		key, ok := strings.CutPrefix(name, "metadata.")
+		if !ok {
+			continue
+		}
+		if key == "" || len(values) != 1 {
+			errs = append(errs, &ErrorDetail{
+				Location: "query." + name,
+				Code:     "invalid",
+				Message:  "expected exactly one value for a metadata filter",
+				Value:    values,
+			})
+			continue
+		}
+		if i.metadataFilters == nil {
+			i.metadataFilters = map[string]string{}
+		}
+		i.metadataFilters[key] = values[0]
+	}
+	// Embedded in a type with a Resolve of its own, huma doesn't call it.
+	return append(errs, i.UserIncludes.Resolve(ctx)...)
+}
+
+// matches reports whether u passes every filter in the request.
+func (i *ListUsersInput) matches(u *User) bool {
+	if !u.Active && !i.IncludeInactive {
+		return false
+	}
+	if !i.inactiveCutoff.IsZero() && !inactiveSince(u, i.inactiveCutoff) {
+		return false
+	}
+	if !hasTags(u, i.Tag) {
+		return false
+	}
+	return metadataMatches(u.Metadata, i.metadataFilters)
+}
+
+// sortUsers sorts users by ID so pages are stable between requests. ID
+// order is creation order with the time-ordered ID generators only; UUIDv4s
+// sort at random, if stably.
+func sortUsers(users []*User) {
+	sort.Slice(users, func(a, b int) bool { return users[a].ID < users[b].ID })
+}
+
+// paginate returns the requested page of users, sorted by sortUsers, along
+// with the total number of users.
+func (i *ListUsersInput) paginate(users []*User) (page []*User, total int) {
+	return keysetPage(&i.cursor, users, i.Page, i.PerPage, i.After, func(u *User) string { return u.ID })
+}
+
+// links builds an RFC 8288 Link header pointing at the first, previous, next
+// and last pages, keeping every other query parameter of the request.
+func (i *ListUsersInput) links(total int) string {
+	if i.After != "" {
+		return keysetLinks(i.url, i.PerPage, total, i.cursor)
+	}
+	return pageLinks(i.url, i.Page, i.PerPage, total)
+}
+
+// pagination is the Pagination of the page the request asks for.
+func (i *ListUsersInput) pagination(total int) Pagination {
+	return keysetPagination(i.url, i.Page, i.PerPage, total, i.After != "", i.cursor)
+}
+
+// Pagination tells a client walking a list whether there are more pages,
+// and how to get the next, without parsing the Link header.
+type Pagination struct {
+	HasMore bool      `json:"has_more" doc:"Whether there are pages after this one"`
+	Next    *PageLink `json:"next,omitempty" doc:"The next page; left out on the last one"`
+}
+
+// PageLink is a page of a list.
+type PageLink struct {
+	Href    string `json:"href" example:"/v1/users?page=2&per_page=100" doc:"URL of the page, keeping the filters of the request; it starts after the last entry of this one if the request passed after"`
+	Page    int    `json:"page" example:"2" doc:"Page number; after a request that passed after, where the page falls as of now"`
+	PerPage int    `json:"per_page" example:"100" doc:"Entries per page"`
+	After   string `json:"after,omitempty" example:"20240101120000" doc:"ID of the last entry of the page before, to pass as after to start this one; only on lists that take it"`
+}
+
+// pagination is the Pagination of page of a list at u with perPage entries
+// per page and total entries.
+func pagination(u url.URL, page, perPage, total int) Pagination {
+	if page >= lastPage(perPage, total) {
+		return Pagination{}
+	}
+	return Pagination{HasMore: true, Next: &PageLink{Href: pageURL(u, page+1, perPage), Page: page + 1, PerPage: perPage}}
+}
+
+// lastPage is the number of the last page of total entries, perPage a page;
+// an empty list has one, empty, page.
+func lastPage(perPage, total int) int {
+	return max((total+perPage-1)/perPage, 1)
+}
+
+// pageURL is the URL of page n of the list at u, with perPage entries per
+// page.
+func pageURL(u url.URL, n, perPage int) string {
+	q := u.Query()
+	q.Del("after")
+	q.Set("page", strconv.Itoa(n))
+	q.Set("per_page", strconv.Itoa(perPage))
+	u.RawQuery = q.Encode()
+	return u.RequestURI()
+}
+
+// pageLinks is the Link header for page of a list at u with perPage entries
+// per page and total entries.
+func pageLinks(u url.URL, page, perPage, total int) string {
+	last := lastPage(perPage, total)
+	ref := func(n int, rel string) string {
+		return fmt.Sprintf("<%s>; rel=%q", pageURL(u, n, perPage), rel)
+	}
+	links := []string{ref(1, "first")}
+	if page > 1 {
+		links = append(links, ref(min(page-1, last), "prev"))
+	}
+	if page < last {
+		links = append(links, ref(page+1, "next"))
+	}
+	links = append(links, ref(last, "last"))
+	return strings.Join(links, ", ")
+}
+
+// cursor is where a page of a list sorted by ID ended: the entry after its
+// last, and that last one's ID, which the next page starts after.
+type cursor struct {
+	end  int
+	last string
+}
+
+// keysetPage returns the page of items, sorted by id, that starts after the
+// entry with the ID after or, without it, the page numbered page, along with
+// the total number of items. Unlike a page number, after keeps its place
+// when entries before it are deleted or created, even the entry it names
+// itself, so walking a list with it neither skips nor repeats any. It keeps
+// where the page ended in c.
+func keysetPage[T any](c *cursor, items []T, page, perPage int, after string, id func(T) string) ([]T, int) {
+	total := len(items)
+	start := min((page-1)*perPage, total)
+	if after != "" {
+		start = sort.Search(total, func(n int) bool { return id(items[n]) > after })
+	}
+	end := min(start+perPage, total)
+	*c = cursor{end: end}
+	if end > start {
+		c.last = id(items[end-1])
+	}
+	return items[start:end], total
+}
+
+// keysetPagination is the Pagination of a page of a list at u that ended at
+// c, with perPage entries per page in total entries. byKey says the request
+// started it after an entry rather than at page; its next page is then
+// linked to the same way.
+func keysetPagination(u url.URL, page, perPage, total int, byKey bool, c cursor) Pagination {
+	if c.end >= total {
+		return Pagination{}
+	}
+	next := &PageLink{Href: pageURL(u, page+1, perPage), Page: page + 1, PerPage: perPage, After: c.last}
+	if byKey {
+		next.Href, next.Page = afterURL(u, c.last, perPage), c.end/perPage+1
+	}
+	return Pagination{HasMore: true, Next: next}
+}
+
+// afterURL is the URL of the page of the list at u that starts after the
+// entry with the ID after, with perPage entries per page.
+func afterURL(u url.URL, after string, perPage int) string {
+	q := u.Query()
+	q.Del("page")
+	q.Set("after", after)
+	q.Set("per_page", strconv.Itoa(perPage))
+	u.RawQuery = q.Encode()
+	return u.RequestURI()
+}
+
+// keysetLinks is the Link header for a page of a list at u that started
+// after an entry and ended at c: the first and last pages by number, and
+// the next after c. There is no previous page to link to; the list is only
+// walked forwards that way.
+func keysetLinks(u url.URL, perPage, total int, c cursor) string {
+	links := []string{fmt.Sprintf("<%s>; rel=%q", pageURL(u, 1, perPage), "first")}
+	if c.end < total {
+		links = append(links, fmt.Sprintf("<%s>; rel=%q", afterURL(u, c.last, perPage), "next"))
+	}
+	links = append(links, fmt.Sprintf("<%s>; rel=%q", pageURL(u, lastPage(perPage, total), perPage), "last"))
+	return strings.Join(links, ", ")
+}
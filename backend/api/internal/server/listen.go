@@ -0,0 +1,217 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Listener kinds.
+const (
+	// ListenHTTP serves the API in plain HTTP.
+	ListenHTTP = "http"
+	// ListenHTTPS serves the API over TLS with Config.TLSCertFile and
+	// Config.TLSKeyFile, checking client certificates against
+	// Config.ClientCAs if set.
+	ListenHTTPS = "https"
+	// ListenAdmin serves the admin API and /metrics in plain HTTP. Once
+	// there is one, the other listeners no longer serve them.
+	ListenAdmin = "admin"
+)
+
+// Listener is one address Run serves on.
+type Listener struct {
+	Kind    string // ListenHTTP, ListenHTTPS or ListenAdmin
+	Network string // "tcp", "unix", or "fd" for a socket inherited from systemd
+	Address string // the file descriptor's number for "fd"
+}
+
+func (l Listener) String() string {
+	switch l.Network {
+	case "unix":
+		return "unix://" + l.Address
+	case "fd":
+		return l.Kind + " (systemd fd " + l.Address + ")"
+	}
+	return l.Kind + "://" + l.Address
+}
+
+// ParseListeners parses a comma-separated list of http://host:port,
+// https://host:port, admin://host:port and unix:///path/to.sock, which
+// serves plain HTTP on a Unix socket. A bare host:port is http.
+func ParseListeners(s string) ([]Listener, error) {
+	var out []Listener
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		scheme, addr, ok := strings.Cut(entry, "://")
+		if !ok {
+			scheme, addr = ListenHTTP, entry
+		}
+		l := Listener{Kind: scheme, Network: "tcp", Address: addr}
+		switch scheme {
+		case ListenHTTP, ListenHTTPS, ListenAdmin:
+			if _, _, err := net.SplitHostPort(addr); err != nil {
+				return nil, fmt.Errorf("%s: %w", entry, err)
+			}
+		case "unix":
+			if !strings.HasPrefix(addr, "/") {
+				return nil, fmt.Errorf("%s: want an absolute socket path", entry)
+			}
+			l.Kind, l.Network = ListenHTTP, "unix"
+		default:
+			return nil, fmt.Errorf("%s: want http, https, admin or unix, got %q", entry, scheme)
+		}
+		out = append(out, l)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no addresses")
+	}
+	return out, nil
+}
+
+// listenFDsStart is the first file descriptor systemd passes sockets in;
+// tests pass theirs from another.
+var listenFDsStart = 3
+
+// activatedListeners returns the sockets systemd passed this process with
+// socket activation, per LISTEN_PID and LISTEN_FDS, or none if it wasn't
+// socket-activated. A socket's FileDescriptorName, from LISTEN_FDNAMES, is
+// its kind if it is http, https or admin; otherwise it is http. The
+// variables are unset so that they aren't passed on.
+func activatedListeners() ([]Listener, error) {
+	pid, fds := os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS")
+	if pid == "" || fds == "" {
+		return nil, nil
+	}
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+	if pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(fds)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("LISTEN_FDS: want a count, got %q", fds)
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	var out []Listener
+	for i := range n {
+		fd := listenFDsStart + i
+		kind := ListenHTTP
+		if i < len(names) {
+			switch names[i] {
+			case ListenHTTPS, ListenAdmin:
+				kind = names[i]
+			}
+		}
+		out = append(out, Listener{Kind: kind, Network: "fd", Address: strconv.Itoa(fd)})
+	}
+	return out, nil
+}
+
+// listening is a listener Run has opened, with the server that serves it.
+type listening struct {
+	Listener
+	ln  net.Listener
+	srv *http.Server
+}
+
+// listen opens every listener, closing the ones already open if one fails.
+// A Unix socket left behind by an earlier run is removed first.
+func (s *Server) listen(listeners []Listener) ([]*listening, error) {
+	hasAdmin := false
+	for _, l := range listeners {
+		hasAdmin = hasAdmin || l.Kind == ListenAdmin
+	}
+	var open []*listening
+	for _, l := range listeners {
+		if l.Network == "unix" {
+			if err := os.Remove(l.Address); err != nil && !errors.Is(err, os.ErrNotExist) {
+				closeListeners(open)
+				return nil, fmt.Errorf("listen on %s: %w", l, err)
+			}
+		}
+		ln, err := listenOn(l)
+		if err != nil {
+			closeListeners(open)
+			return nil, fmt.Errorf("listen on %s: %w", l, err)
+		}
+		open = append(open, &listening{Listener: l, ln: ln, srv: &http.Server{
+			Handler:      s.splitAdmin(l.Kind, hasAdmin),
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 10 * time.Second,
+			IdleTimeout:  60 * time.Second,
+		}})
+		if l.Kind == ListenHTTPS {
+			open[len(open)-1].srv.TLSConfig = s.clientTLSConfig()
+		}
+	}
+	return open, nil
+}
+
+func listenOn(l Listener) (net.Listener, error) {
+	if l.Network != "fd" {
+		return net.Listen(l.Network, l.Address)
+	}
+	fd, err := strconv.Atoi(l.Address)
+	if err != nil {
+		return nil, err
+	}
+	// FileListener dups the descriptor, with close-on-exec set, so the one
+	// systemd passed can be closed.
+	f := os.NewFile(uintptr(fd), l.String())
+	defer f.Close()
+	return net.FileListener(f)
+}
+
+func closeListeners(open []*listening) {
+	for _, l := range open {
+		l.ln.Close()
+	}
+}
+
+// serve serves l until it is shut down.
+func (s *Server) serve(l *listening) error {
+	var err error
+	if l.Kind == ListenHTTPS {
+		err = l.srv.ServeTLS(l.ln, s.cfg.TLSCertFile, s.cfg.TLSKeyFile)
+	} else {
+		err = l.srv.Serve(l.ln)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return fmt.Errorf("serve %s: %w", l.Listener, err)
+}
+
+// adminPath reports whether path belongs on an admin listener.
+func adminPath(path string) bool {
+	return strings.HasPrefix(path, "/admin/") || path == "/metrics"
+}
+
+// splitAdmin returns the handler for a listener of kind. With an admin
+// listener, it serves only the admin API, /metrics and the probes, and the
+// others serve everything else; without one, every listener serves it all.
+func (s *Server) splitAdmin(kind string, hasAdmin bool) http.Handler {
+	if !hasAdmin {
+		return s.router
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		admin := adminPath(r.URL.Path)
+		if kind == ListenAdmin && !admin && !probePath(r.URL.Path) || kind != ListenAdmin && admin {
+			writeError(w, r, http.StatusNotFound, CodeNotFound, "no route matches the path")
+			return
+		}
+		s.router.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,163 @@
+//go:build unix
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// unixClient is an HTTP client that dials the Unix socket at path whatever
+// the URL's host.
+func unixClient(path string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+}
+
+func TestListeners(t *testing.T) {
+	if _, err := ParseListeners("localhost:8080, unix://api.sock"); err == nil {
+		t.Error("relative socket path: no error")
+	}
+	sock := filepath.Join(t.TempDir(), "api.sock")
+	// A socket an earlier run left behind is replaced.
+	if err := os.WriteFile(sock, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// A free port, released for Run to take.
+	probe, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := probe.Addr().String()
+	probe.Close()
+	listeners, err := ParseListeners("http://" + addr + ", unix://" + sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(Config{Listeners: listeners}, NewMemoryStore())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+
+	tcp, unix := &http.Client{}, unixClient(sock)
+	waitReady(t, tcp, "http://"+addr+"/readyz")
+	for name, get := range map[string]func() (*http.Response, error){
+		"tcp":  func() (*http.Response, error) { return tcp.Get("http://" + addr + "/v1/users") },
+		"unix": func() (*http.Response, error) { return unix.Get("http://api/v1/users") },
+	} {
+		resp, err := get()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: GET /v1/users answered %d", name, resp.StatusCode)
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(15 * time.Second):
+		t.Fatal("Run still running 15s after its context ended")
+	}
+	if _, err := os.Stat(sock); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("socket file after shutdown: %v, want it removed", err)
+	}
+	if _, err := tcp.Get("http://" + addr + "/readyz"); err == nil {
+		t.Error("TCP listener still serving after shutdown")
+	}
+}
+
+func TestSocketActivation(t *testing.T) {
+	// The socket systemd would pass: a descriptor of its own, which the
+	// server closes once it has taken it.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	f, err := ln.(*net.TCPListener).File()
+	ln.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd, err := syscall.Dup(int(f.Fd()))
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(start int) { listenFDsStart = start }(listenFDsStart)
+	listenFDsStart = fd
+	pid := strconv.Itoa(os.Getpid())
+
+	for _, c := range []struct {
+		name, pid, fds string
+		err            bool
+	}{
+		{"not activated", "", "", false},
+		{"for another process", strconv.Itoa(os.Getpid() + 1), "1", false},
+		{"no fds", pid, "", false},
+		{"a count that isn't one", pid, "one", true},
+	} {
+		t.Setenv("LISTEN_PID", c.pid)
+		t.Setenv("LISTEN_FDS", c.fds)
+		t.Setenv("LISTEN_FDNAMES", "admin")
+		if got, err := activatedListeners(); got != nil || (err != nil) != c.err {
+			t.Errorf("%s: %v, %v", c.name, got, err)
+		}
+		if c.pid != "" && c.fds != "" {
+			if _, ok := os.LookupEnv("LISTEN_FDS"); ok {
+				t.Errorf("%s: LISTEN_FDS left set, to be passed on", c.name)
+			}
+		}
+	}
+
+	t.Setenv("LISTEN_PID", pid)
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_FDNAMES", "admin")
+	listeners, err := activatedListeners()
+	if want := (Listener{Kind: ListenAdmin, Network: "fd", Address: strconv.Itoa(fd)}); err != nil || len(listeners) != 1 || listeners[0] != want {
+		t.Fatalf("activated %v, %v, want %v", listeners, err, want)
+	}
+	for _, key := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+		if _, ok := os.LookupEnv(key); ok {
+			t.Errorf("%s left set, to be passed on", key)
+		}
+	}
+
+	s := NewServer(Config{}, NewMemoryStore())
+	open, err := s.listen(listeners)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.serve(open[0])
+	defer open[0].srv.Shutdown(context.Background())
+	// The inherited socket is the admin listener: it serves the admin API,
+	// /metrics and the probes, and nothing else.
+	for path, want := range map[string]int{"/metrics": http.StatusOK, "/livez": http.StatusOK, "/v1/users": http.StatusNotFound} {
+		resp, err := http.Get("http://" + addr + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("GET %s on the inherited socket: %d, want %d", path, resp.StatusCode, want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Reasons a request is shed, for http_shed_requests_total.
+const (
+	ShedQueueFull    = "queue_full"    // every slot was taken and the queue full
+	ShedQueueTimeout = "queue_timeout" // no slot freed up within the queue timeout
+)
+
+// defaultQueueTimeout is how long a queued request waits for a slot unless
+// Config.QueueTimeout says otherwise.
+const defaultQueueTimeout = time.Second
+
+// adminLaneSize is how many admin requests are served at once, and how many
+// more may queue, in the admin lane, apart from the API's slots.
+const adminLaneSize = 4
+
+// Lanes of the load shedder, for http_shed_requests_total.
+const (
+	laneAPI   = "api"
+	laneAdmin = "admin"
+)
+
+// shedRetryAfter is the Retry-After of shed requests, in seconds: soon, as
+// spikes are short, but not at once.
+const shedRetryAfter = "1"
+
+// loadShedder caps the requests of a lane served at once, at
+// Config.MaxInFlight for the API, so a traffic spike slows requests down
+// instead of melting the store. Requests beyond the cap wait in a queue,
+// of Config.MaxQueued for the API, for a slot to free up,
+// for at most the queue timeout; those that find the queue full, or time out
+// in it, are shed with a 503.
+type loadShedder struct {
+	slots   chan struct{}
+	queue   chan struct{}
+	timeout time.Duration
+}
+
+func newLoadShedder(maxInFlight, maxQueued int, timeout time.Duration) *loadShedder {
+	return &loadShedder{
+		slots:   make(chan struct{}, maxInFlight),
+		queue:   make(chan struct{}, maxQueued),
+		timeout: cmp.Or(timeout, defaultQueueTimeout),
+	}
+}
+
+// acquire takes a slot, waiting in the queue if need be, and returns its
+// release. Without a slot, it returns why the request is to be shed, or
+// neither if ctx ended first.
+func (l *loadShedder) acquire(ctx context.Context) (release func(), shed string) {
+	release = func() { <-l.slots }
+	select {
+	case l.slots <- struct{}{}:
+		return release, ""
+	default:
+	}
+	select {
+	case l.queue <- struct{}{}:
+		defer func() { <-l.queue }()
+	default:
+		return nil, ShedQueueFull
+	}
+	timer := time.NewTimer(l.timeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return release, ""
+	case <-timer.C:
+		return nil, ShedQueueTimeout
+	case <-ctx.Done():
+		return nil, ""
+	}
+}
+
+// laneOf returns the lane of the load shedder the request for path goes
+// through, or "" for one that is never shed. Probes and metrics scrapes
+// never are, so an overloaded pod isn't restarted for failing its liveness
+// probe, nor its overload hidden from dashboards. The admin API has a lane of
+// its own, so operators can still act during an overload.
+func laneOf(path string) string {
+	switch {
+	case probePath(path) || path == "/metrics":
+		return ""
+	case adminPath(path):
+		return laneAdmin
+	}
+	return laneAPI
+}
+
+// loadSlot is the slot a request holds, released once, either when it is
+// served or when it starts waiting on something other than the store.
+type loadSlot struct {
+	once    sync.Once
+	release func()
+}
+
+type loadSlotKey struct{}
+
+// releaseLoadSlot gives up the slots the request ctx belongs to holds, in
+// its lane and its route group, if any, for a long poll about to wait: a
+// request that waits doesn't load the store, and shouldn't keep others out
+// while it does.
+func releaseLoadSlot(ctx context.Context) {
+	for _, key := range []any{loadSlotKey{}, groupSlotKey{}} {
+		if slot, ok := ctx.Value(key).(*loadSlot); ok {
+			slot.once.Do(slot.release)
+		}
+	}
+}
+
+// shedLoad limits the requests served at once in each lane, if
+// Config.MaxInFlight is set, answering those it sheds with 503 OVERLOADED
+// and a Retry-After.
+func (s *Server) shedLoad(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lane := laneOf(r.URL.Path)
+		shedder := s.shedders[lane]
+		if shedder == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		release, shed := shedder.acquire(r.Context())
+		if release == nil {
+			if shed == "" {
+				return // the client is gone
+			}
+			s.metrics.shed(lane, shed)
+			w.Header().Set("Retry-After", shedRetryAfter)
+			writeError(w, r, http.StatusServiceUnavailable, CodeOverloaded, "the server is overloaded; retry after Retry-After")
+			return
+		}
+		slot := &loadSlot{release: release}
+		defer slot.once.Do(slot.release)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), loadSlotKey{}, slot)))
+	})
+}
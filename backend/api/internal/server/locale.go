@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/email"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/i18n"
+)
+
+// timeZoneHeader is the request header naming the client's IANA time zone,
+// as GitHub's API takes it.
+const timeZoneHeader = "Time-Zone"
+
+// languageTag matches the BCP 47 tags preferences take, like de or de-AT.
+var languageTag = regexp.MustCompile(`^[a-z]{2}(-[A-Z]{2})?$`)
+
+// requestLocale is the language and time zone output is formatted in, like
+// the timestamps of CSV exports and the dates in emails. API responses keep
+// their timestamps in UTC whatever it is.
+type requestLocale struct {
+	Lang     string
+	Location *time.Location
+}
+
+// defaultLocale is the locale of a request that says nothing about it.
+func defaultLocale() requestLocale {
+	return requestLocale{Lang: email.Fallback, Location: time.UTC}
+}
+
+// localeOf is the locale a user's preferences pick.
+func localeOf(prefs *UserPreferences) requestLocale {
+	l := defaultLocale()
+	if prefs.Locale != "" {
+		l.Lang = prefs.Locale
+	}
+	if loc, err := loadTimeZone(prefs.Timezone); err == nil {
+		l.Location = loc
+	}
+	return l
+}
+
+// loadTimeZone loads the IANA time zone name, refusing the empty name and
+// Local, which time.LoadLocation takes for UTC and the server's own zone.
+func loadTimeZone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return time.LoadLocation(name)
+}
+
+type localeKey struct{}
+
+// lazyLocale resolves a request's locale the first time it is asked for, so
+// requests that format nothing don't look up preferences.
+type lazyLocale struct {
+	once    sync.Once
+	resolve func() requestLocale
+	locale  requestLocale
+}
+
+// localeFrom returns the locale resolveLocale found for the request, or
+// defaultLocale outside one.
+func localeFrom(ctx context.Context) requestLocale {
+	l, _ := ctx.Value(localeKey{}).(*lazyLocale)
+	if l == nil {
+		return defaultLocale()
+	}
+	l.once.Do(func() { l.locale = l.resolve() })
+	return l.locale
+}
+
+// resolveLocale puts the request's locale on its context for localeFrom.
+// The language and time zone each come from the first of:
+//
+//   - the locale and tz query parameters, on any operation;
+//   - the preferences of the user the request authenticated as, if they
+//     saved any;
+//   - the Accept-Language and Time-Zone headers;
+//   - en and UTC.
+//
+// Query parameters that aren't a language tag or a time zone are refused;
+// headers that aren't are ignored, as browsers send what they like.
+func (s *Server) resolveLocale(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		lang := query.Get("locale")
+		if lang != "" && !languageTag.MatchString(lang) {
+			writeError(w, r, http.StatusBadRequest, CodeBadRequest, fmt.Sprintf("locale %q is not a language tag like de or de-AT", lang))
+			return
+		}
+		var zone *time.Location
+		if tz := query.Get("tz"); tz != "" {
+			var err error
+			if zone, err = loadTimeZone(tz); err != nil {
+				writeError(w, r, http.StatusBadRequest, CodeBadRequest, fmt.Sprintf("tz %q is not an IANA time zone name like Europe/Vienna", tz))
+				return
+			}
+		}
+		ctx := r.Context()
+		l := &lazyLocale{resolve: func() requestLocale {
+			l := defaultLocale()
+			if accept := r.Header.Get("Accept-Language"); accept != "" {
+				l.Lang = i18n.Match(accept)
+			}
+			if loc, err := loadTimeZone(r.Header.Get(timeZoneHeader)); err == nil {
+				l.Location = loc
+			}
+			if p := principalFrom(ctx); p != nil {
+				prefs, err := s.users.store.GetPreferences(ctx, p.UserID)
+				switch {
+				case err == nil:
+					l = localeOf(prefs)
+				case !errors.Is(err, ErrNotFound):
+					s.logger.WarnContext(ctx, "failed to load preferences for the locale", "user", p.UserID, "err", err)
+				}
+			}
+			if lang != "" {
+				l.Lang = lang
+			}
+			if zone != nil {
+				l.Location = zone
+			}
+			return l
+		}}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, localeKey{}, l)))
+	})
+}
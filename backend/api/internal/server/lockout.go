@@ -0,0 +1,259 @@
+package server
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+)
+
+// Security events about logins. Failures against a login that matches no
+// user have an empty subject.
+const (
+	EventLoginFailed     = "security.login_failed"
+	EventAccountLocked   = "security.account_locked"
+	EventAccountUnlocked = "security.account_unlocked"
+	EventAddressBlocked  = "security.address_blocked"
+)
+
+const (
+	// freeLoginFailures is how many failures in a row cost nothing; after
+	// that each one doubles the wait before the next attempt, from a second
+	// up to maxLoginDelay.
+	freeLoginFailures = 3
+	maxLoginDelay     = time.Minute
+
+	defaultLoginMaxFailures      = 10
+	defaultLoginMaxFailuresPerIP = 100
+	defaultLoginLockout          = 15 * time.Minute
+)
+
+// LoginPolicy is how hard LoginGuard comes down on failed logins. Zero
+// fields get the defaults.
+type LoginPolicy struct {
+	// MaxFailures locks an account after that many failures in a row; 10
+	// by default.
+	MaxFailures int
+	// MaxFailuresPerIP blocks an address after that many failures, against
+	// any accounts; 100 by default.
+	MaxFailuresPerIP int
+	// Lockout is how long a lock or block lasts, and how long after the
+	// last failure the count starts over; 15 minutes by default.
+	Lockout time.Duration
+}
+
+// LoginGuard counts failed logins per account and per client address. Past
+// a few failures on an account it makes the next attempt wait, longer after
+// each one, and past the policy's limits it locks the account or blocks the
+// address for a while. Addresses get no delays, as many users may share one
+// behind a NAT. It keeps its counts in memory, so each replica counts its
+// own and a restart forgets them.
+type LoginGuard struct {
+	policy LoginPolicy
+	bus    *events.Bus
+	now    func() time.Time
+
+	mu       sync.Mutex
+	accounts map[string]*loginFailures
+	addrs    map[string]*loginFailures
+	swept    time.Time
+}
+
+type loginFailures struct {
+	count   int
+	last    time.Time
+	retryAt time.Time // no attempts before then
+	locked  bool      // retryAt is a lockout rather than a delay
+}
+
+// NewLoginGuard returns a LoginGuard enforcing policy and publishing
+// security events on bus.
+func NewLoginGuard(policy LoginPolicy, bus *events.Bus) *LoginGuard {
+	if policy.MaxFailures <= 0 {
+		policy.MaxFailures = defaultLoginMaxFailures
+	}
+	if policy.MaxFailuresPerIP <= 0 {
+		policy.MaxFailuresPerIP = defaultLoginMaxFailuresPerIP
+	}
+	if policy.Lockout <= 0 {
+		policy.Lockout = defaultLoginLockout
+	}
+	return &LoginGuard{
+		policy:   policy,
+		bus:      bus,
+		now:      time.Now,
+		accounts: map[string]*loginFailures{},
+		addrs:    map[string]*loginFailures{},
+	}
+}
+
+// Check returns a 423 if account is locked, or a 429 if account or ip has to
+// wait before trying again, with Retry-After set either way.
+func (g *LoginGuard) Check(account, ip string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	if f := g.lookup(g.accounts, account, now); f != nil && now.Before(f.retryAt) {
+		if f.locked {
+			return retryAfter(apiError(http.StatusLocked, CodeAccountLocked, "account is locked after too many failed logins"), f.retryAt.Sub(now))
+		}
+		return retryAfter(apiError(http.StatusTooManyRequests, CodeLoginThrottled, "too many failed logins, try again later"), f.retryAt.Sub(now))
+	}
+	if f := g.lookup(g.addrs, ip, now); f != nil && now.Before(f.retryAt) {
+		return retryAfter(apiError(http.StatusTooManyRequests, CodeLoginThrottled, "too many failed logins, try again later"), f.retryAt.Sub(now))
+	}
+	return nil
+}
+
+// Fail counts a failed login for account from ip. known says whether
+// account is a user's ID; only those are named in the events.
+func (g *LoginGuard) Fail(ctx context.Context, account, ip string, known bool) {
+	g.mu.Lock()
+	now := g.now()
+	g.sweep(now)
+	acct := g.record(g.accounts, account, now)
+	acct.retryAt = now.Add(loginDelay(acct.count))
+	addr := g.record(g.addrs, ip, now)
+	subject := ""
+	if known {
+		subject = account
+	}
+	published := []events.Event{{Type: EventLoginFailed, Subject: subject, Data: map[string]any{"ip": ip, "failures": acct.count}}}
+	if acct.count >= g.policy.MaxFailures {
+		acct.retryAt, acct.locked = now.Add(g.policy.Lockout), true
+		if acct.count == g.policy.MaxFailures && known {
+			published = append(published, events.Event{Type: EventAccountLocked, Subject: subject, Data: map[string]any{"ip": ip, "until": acct.retryAt}})
+		}
+	}
+	if addr.count >= g.policy.MaxFailuresPerIP {
+		addr.retryAt, addr.locked = now.Add(g.policy.Lockout), true
+		if addr.count == g.policy.MaxFailuresPerIP {
+			published = append(published, events.Event{Type: EventAddressBlocked, Data: map[string]any{"ip": ip, "until": addr.retryAt}})
+		}
+	}
+	g.mu.Unlock()
+
+	for _, e := range published {
+		g.bus.Publish(ctx, e)
+	}
+}
+
+// Succeed forgets account's failures. Those from the address stay counted,
+// so one working login doesn't buy an attacker more guesses at others.
+func (g *LoginGuard) Succeed(account string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.accounts, account)
+}
+
+// Unlock forgets account's failures and reports whether it was locked and
+// how many failures it had.
+func (g *LoginGuard) Unlock(account string) (wasLocked bool, failures int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	f := g.lookup(g.accounts, account, now)
+	if f == nil {
+		return false, 0
+	}
+	delete(g.accounts, account)
+	return f.locked && now.Before(f.retryAt), f.count
+}
+
+// lookup returns key's failures, or nil once they have been forgotten. The
+// caller holds g.mu.
+func (g *LoginGuard) lookup(m map[string]*loginFailures, key string, now time.Time) *loginFailures {
+	f, ok := m[key]
+	if !ok {
+		return nil
+	}
+	if g.stale(f, now) {
+		delete(m, key)
+		return nil
+	}
+	return f
+}
+
+// record counts a failure for key. The caller holds g.mu.
+func (g *LoginGuard) record(m map[string]*loginFailures, key string, now time.Time) *loginFailures {
+	f := g.lookup(m, key, now)
+	if f == nil {
+		f = &loginFailures{}
+		m[key] = f
+	}
+	f.count++
+	f.last = now
+	return f
+}
+
+func (g *LoginGuard) stale(f *loginFailures, now time.Time) bool {
+	return now.Sub(f.last) >= g.policy.Lockout && !now.Before(f.retryAt)
+}
+
+// sweep drops forgotten failures now and then, so addresses that tried once
+// don't pile up. The caller holds g.mu.
+func (g *LoginGuard) sweep(now time.Time) {
+	if now.Sub(g.swept) < g.policy.Lockout {
+		return
+	}
+	g.swept = now
+	for _, m := range []map[string]*loginFailures{g.accounts, g.addrs} {
+		for key, f := range m {
+			if g.stale(f, now) {
+				delete(m, key)
+			}
+		}
+	}
+}
+
+// loginDelay is the wait after the nth failure in a row.
+func loginDelay(n int) time.Duration {
+	if n < freeLoginFailures {
+		return 0
+	}
+	return min(time.Second<<(n-freeLoginFailures), maxLoginDelay)
+}
+
+// retryAfter adds a Retry-After header to err of wait, in whole seconds.
+func retryAfter(err error, wait time.Duration) error {
+	secs := int(math.Ceil(wait.Seconds()))
+	return huma.ErrorWithHeaders(err, http.Header{"Retry-After": {strconv.Itoa(max(secs, 1))}})
+}
+
+type UnlockInput struct {
+	AdminInput
+	UserID string `path:"userID" doc:"ID of the user to unlock"`
+}
+
+type UnlockResponse struct {
+	UserID         string `json:"user_id" doc:"The user whose failed logins were cleared"`
+	WasLocked      bool   `json:"was_locked" doc:"Whether the account was locked out"`
+	FailedAttempts int    `json:"failed_attempts" doc:"Failed logins in a row that were cleared"`
+}
+
+type UnlockOutput struct {
+	Body *UnlockResponse
+}
+
+// unlock is the post-admin-unlock-by-user-id handler.
+func (s *Server) unlock(ctx context.Context, input *UnlockInput) (*UnlockOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	if _, err := s.users.Get(ctx, input.UserID); err != nil {
+		return nil, err
+	}
+	wasLocked, failures := s.logins.Unlock(input.UserID)
+	s.bus.Publish(ctx, events.Event{
+		Type:    EventAccountUnlocked,
+		Subject: input.UserID,
+		Data:    map[string]any{"was_locked": wasLocked, "failed_attempts": failures},
+	})
+	return &UnlockOutput{Body: &UnlockResponse{UserID: input.UserID, WasLocked: wasLocked, FailedAttempts: failures}}, nil
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/lock"
+)
+
+// Jobs that must not run twice at once, restores, retention runs and
+// digests, hold a lock named after them from s.locks while they run. With
+// cfg.Locker shared through Redis that holds across replicas; otherwise only
+// within this one.
+const (
+	lockRestore   = "restore"
+	lockRetention = "retention"
+	lockDigests   = "digests"
+)
+
+// jobLockTTL is how long a job's lock outlives a replica that died running
+// it; lock.Do extends it while the job runs.
+const jobLockTTL = time.Minute
+
+// poolStatsInterval is how often the connection pools of cfg.Locker are
+// sampled for the pool_* metrics.
+const poolStatsInterval = 10 * time.Second
+
+// pooledLocker is implemented by Lockers that keep connection pools, like
+// lock.Redis.
+type pooledLocker interface {
+	PoolStats() []lock.PoolStats
+}
+
+// poolStatsLoop samples the connection pools of p until ctx ends.
+func (s *Server) poolStatsLoop(ctx context.Context, p pooledLocker) {
+	last := map[string]lock.PoolStats{}
+	ticker := time.NewTicker(poolStatsInterval)
+	defer ticker.Stop()
+	for {
+		s.samplePools(p, last)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// samplePools records the pools of p, and the waits since the samples in
+// last, which it updates.
+func (s *Server) samplePools(p pooledLocker, last map[string]lock.PoolStats) {
+	for _, st := range p.PoolStats() {
+		prev := last[st.Server]
+		s.metrics.pool("redis/"+st.Server, st.InUse, st.Idle, st.Waits-prev.Waits, st.Timeouts-prev.Timeouts, st.Waited-prev.Waited)
+		last[st.Server] = st
+	}
+}
+
+var errAlreadyRunning = apiError(http.StatusConflict, CodeConflict, "the job is already running, here or on another replica")
+
+// exclusive runs fn holding the lock name; see lock.Do. It fails with
+// errAlreadyRunning if someone else holds it.
+func (s *Server) exclusive(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	err := lock.Do(ctx, s.locks, name, jobLockTTL, fn)
+	if errors.Is(err, lock.ErrLocked) {
+		return errAlreadyRunning
+	}
+	return err
+}
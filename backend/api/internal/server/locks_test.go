@@ -0,0 +1,39 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/lock"
+)
+
+// pools is a pooledLocker whose stats are set by the test.
+type pools []lock.PoolStats
+
+func (p *pools) PoolStats() []lock.PoolStats { return *p }
+
+func TestSamplePools(t *testing.T) {
+	s := NewServer(Config{}, NewMemoryStore())
+	p := &pools{{Server: "redis-0:6379", InUse: 1, Idle: 4, Waits: 2, Waited: time.Second}}
+	last := map[string]lock.PoolStats{}
+	s.samplePools(p, last)
+	*p = pools{{Server: "redis-0:6379", InUse: 3, Idle: 2, Waits: 5, Timeouts: 1, Waited: 3 * time.Second}}
+	s.samplePools(p, last)
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`pool_connections{pool="redis/redis-0:6379",state="in_use"} 3`,
+		`pool_connections{pool="redis/redis-0:6379",state="idle"} 2`,
+		`pool_waits_total{pool="redis/redis-0:6379"} 5`,
+		`pool_wait_seconds_total{pool="redis/redis-0:6379"} 3`,
+		`pool_timeouts_total{pool="redis/redis-0:6379"} 1`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/authtoken"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// loginTokenTTL is how long a token from post-v1-auth-login works.
+const loginTokenTTL = time.Hour
+
+// Credentials are what a user logs in with. Only a bcrypt hash of the
+// password is kept.
+type Credentials struct {
+	PasswordHash string         `json:"password_hash,omitempty"` // empty for a user with API keys but no password
+	ChangedAt    timestamp.Time `json:"changed_at"`
+	APIKeys      []StoredAPIKey `json:"api_keys,omitempty"`
+	// RevokedTokens are the user's tokens revoked before they expire.
+	RevokedTokens []RevokedToken `json:"revoked_tokens,omitempty"`
+}
+
+// newCredentials hashes password.
+func newCredentials(password string) (*Credentials, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	return &Credentials{PasswordHash: string(hash), ChangedAt: timestamp.Now()}, nil
+}
+
+// dummyHash is compared against when the login matches no one, so a wrong
+// username takes as long to refuse as a wrong password.
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("not a password"), bcrypt.DefaultCost)
+	return hash
+})
+
+type LoginRequest struct {
+	Login    string `json:"login" minLength:"1" maxLength:"320" example:"ro_chauhan" redact:"true" doc:"Username or email"`
+	Password string `json:"password" minLength:"1" maxLength:"72" redact:"true" doc:"The user's password"`
+}
+
+type LoginInput struct {
+	CaptchaInput
+	Body LoginRequest
+}
+
+// LoginToken is a user token issued for a password.
+type LoginToken struct {
+	Token     string         `json:"token" redact:"true" doc:"Bearer token acting as the user"`
+	TokenType string         `json:"token_type" example:"Bearer" doc:"Always Bearer"`
+	UserID    string         `json:"user_id" doc:"The user the token acts as"`
+	ExpiresAt timestamp.Time `json:"expires_at" doc:"When the token stops working"`
+}
+
+type LoginOutput struct {
+	Body *LoginToken
+}
+
+// errBadLogin is the one answer to every wrong login, whether the user
+// doesn't exist, can't log in or gave the wrong password, so it doesn't
+// reveal which.
+var errBadLogin = apiError(http.StatusUnauthorized, CodeInvalidCredentials, "invalid login or password")
+
+// login is the post-v1-auth-login handler. Failures, against the account
+// and the address the attempt came from, are throttled by s.logins; see
+// LoginGuard.
+func (s *Server) login(ctx context.Context, input *LoginInput) (*LoginOutput, error) {
+	if err := s.verifyCaptcha(ctx, input.CaptchaInput); err != nil {
+		return nil, err
+	}
+	ip := input.remoteIP
+	user, err := s.users.findByLogin(ctx, input.Body.Login)
+	if err != nil {
+		return nil, err
+	}
+	account := "login:" + strings.ToLower(input.Body.Login)
+	if user != nil {
+		account = user.ID
+	}
+	if err := s.logins.Check(account, ip); err != nil {
+		return nil, err
+	}
+	if !s.users.checkPassword(ctx, user, input.Body.Password) {
+		s.logins.Fail(ctx, account, ip, user != nil)
+		return nil, errBadLogin
+	}
+	s.logins.Succeed(account)
+	token, claims, err := s.tokens.Issue(authtoken.Claims{Subject: user.ID}, loginTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	s.bus.Publish(ctx, events.Event{Type: EventUserLoggedIn, Subject: user.ID, Data: map[string]any{"ip": ip, "token_id": claims.ID}})
+	return &LoginOutput{Body: &LoginToken{
+		Token:     token,
+		TokenType: "Bearer",
+		UserID:    user.ID,
+		ExpiresAt: timestamp.From(claims.Expiry()),
+	}}, nil
+}
+
+// findByLogin returns the user whose username is login or, failing that,
+// the one user whose email is, once folded. It returns nil if there is no
+// such user. Users who shared an email before emails had to be unique, or
+// whose emails only fold into the same one since EMAIL_FOLDING changed, log
+// in by username.
+func (u *UserService) findByLogin(ctx context.Context, login string) (*User, error) {
+	users, err := u.store.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var byEmail []*User
+	for _, user := range users {
+		if user.Username != "" && strings.EqualFold(user.Username, login) {
+			return user, nil
+		}
+		if u.emailFolding.same(user.Email, login) {
+			byEmail = append(byEmail, user)
+		}
+	}
+	if len(byEmail) == 1 {
+		return byEmail[0], nil
+	}
+	return nil, nil
+}
+
+// checkPassword reports whether user, which may be nil, is active and has
+// password. It takes about as long either way.
+func (u *UserService) checkPassword(ctx context.Context, user *User, password string) bool {
+	hash, found := dummyHash(), false
+	if user != nil {
+		creds, err := u.store.GetCredentials(ctx, user.ID)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			u.logger.ErrorContext(ctx, "failed to load credentials", "user", user.ID, "err", err)
+		}
+		if err == nil && creds.PasswordHash != "" {
+			hash, found = []byte(creds.PasswordHash), true
+		}
+	}
+	ok := bcrypt.CompareHashAndPassword(hash, []byte(password)) == nil
+	return ok && found && user.Status == UserStatusActive
+}
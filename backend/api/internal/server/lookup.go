@@ -0,0 +1,44 @@
+package server
+
+import (
+	"context"
+	"errors"
+)
+
+type LookupUsersRequest struct {
+	IDs []string `json:"ids" minItems:"1" maxItems:"100" doc:"User IDs to fetch, in the order results should be returned"`
+}
+
+type LookupUsersInput struct {
+	Body LookupUsersRequest
+}
+
+// UserLookupResult is one entry of a batch lookup. User is omitted when no
+// user has the ID.
+type UserLookupResult struct {
+	ID    string `json:"id" doc:"Requested user ID"`
+	Found bool   `json:"found" doc:"Whether a user with this ID exists"`
+	User  *User  `json:"user,omitempty" doc:"The user, if found"`
+}
+
+type LookupUsersResponse struct {
+	Results []UserLookupResult `json:"results" doc:"One entry per requested ID, in request order"`
+}
+
+type LookupUsersOutput struct {
+	Body *LookupUsersResponse
+}
+
+// lookupUsers resolves ids in order. Duplicates are kept so results line up
+// with the request one to one.
+func lookupUsers(ctx context.Context, store Store, ids []string) ([]UserLookupResult, error) {
+	results := make([]UserLookupResult, len(ids))
+	for i, id := range ids {
+		user, err := store.GetUser(ctx, id)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+		results[i] = UserLookupResult{ID: id, Found: user != nil, User: user}
+	}
+	return results, nil
+}
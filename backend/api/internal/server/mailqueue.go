@@ -0,0 +1,314 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/email"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// Retries of the emails the mail server refused.
+const (
+	mailQueueSize     = 10000
+	mailSendAttempts  = 8
+	mailRetryDelay    = 30 * time.Second
+	mailMaxRetryDelay = 30 * time.Minute
+)
+
+// errMailQueueFull is what send fails with once mailQueueSize emails wait.
+var errMailQueueFull = errors.New("mail queue is full")
+
+// mailDelivery is an email to send, as it is queued and the payload of its
+// dead letter.
+type mailDelivery struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
+
+// queuedEmail is an email waiting to be sent again.
+type queuedEmail struct {
+	mailDelivery
+	ID          string              `json:"id"`
+	Attempts    []DeadLetterAttempt `json:"attempts"`
+	NextAttempt time.Time           `json:"next_attempt"`
+	// DeadLetter is the dead letter it was requeued from, if any.
+	DeadLetter string `json:"dead_letter,omitempty"`
+}
+
+// mailQueue makes the mail server a soft dependency: an email it refuses
+// is queued and retried in the background, rather than failing the request
+// that sent it. Once emails are queued, new ones go behind them without
+// trying the mail server first, so a relay that is down holds up no
+// request. An email refused mailSendAttempts times in all goes to the
+// dead-letter queue. The queue is kept in memory, like the other
+// deliveries', and in a file too when openFile names one, so it outlives
+// restarts; the mail_queue_depth gauge tracks its length.
+type mailQueue struct {
+	sender  email.Sender
+	dead    *DeadLetters
+	metrics recorder
+	logger  *slog.Logger
+	// retryDelay is the wait before the first retry, doubled for each one
+	// after up to mailMaxRetryDelay.
+	retryDelay time.Duration
+
+	mu     sync.Mutex
+	queue  []*queuedEmail // oldest first
+	path   string         // where the queue is saved; empty keeps it in memory only
+	saveMu sync.Mutex     // keeps saves in order
+	wake   chan struct{}
+
+	ctx    context.Context // ends when close is called
+	cancel context.CancelFunc
+	done   chan struct{} // closed when run returns
+}
+
+func newMailQueue(sender email.Sender, dead *DeadLetters, metrics recorder, logger *slog.Logger) *mailQueue {
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &mailQueue{sender: sender, dead: dead, metrics: metrics, logger: logger, retryDelay: mailRetryDelay, wake: make(chan struct{}, 1), ctx: ctx, cancel: cancel, done: make(chan struct{})}
+	dead.handle(DeadLetterEmail, func(ctx context.Context, id string, payload any) error {
+		return q.enqueue(&queuedEmail{mailDelivery: payload.(mailDelivery), DeadLetter: id, NextAttempt: time.Now()})
+	})
+	return q
+}
+
+// openFile restores the queue from the file at path, if it exists, and
+// saves it there whenever it changes from then on.
+func (q *mailQueue) openFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	var queue []*queuedEmail
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &queue); err != nil {
+			return err
+		}
+	}
+	q.mu.Lock()
+	q.path = path
+	q.queue = append(queue, q.queue...)
+	n := len(q.queue)
+	q.mu.Unlock()
+	q.metrics.mailQueued(n)
+	if n > 0 {
+		q.logger.Info("restored queued emails", "path", path, "emails", n)
+	}
+	return nil
+}
+
+// send sends msg to to now, or queues it to be retried if the mail server
+// refuses it or emails are queued already. It only fails if msg can't be
+// queued.
+func (q *mailQueue) send(ctx context.Context, to string, msg *email.Message) (queued bool, err error) {
+	e := &queuedEmail{mailDelivery: mailDelivery{To: to, Subject: msg.Subject, Text: msg.Text, HTML: msg.HTML}, NextAttempt: time.Now()}
+	if q.depth() == 0 {
+		err := q.sender.Send(ctx, to, msg)
+		if err == nil {
+			return false, nil
+		}
+		e.Attempts = []DeadLetterAttempt{{Time: timestamp.Now(), Error: err.Error()}}
+		e.NextAttempt = time.Now().Add(q.backoff(1))
+		q.logger.WarnContext(ctx, "mail server refused an email; queued to retry", "subject", msg.Subject, "err", err)
+	}
+	if err := q.enqueue(e); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// depth is how many emails are queued.
+func (q *mailQueue) depth() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.queue)
+}
+
+// backoff is the wait after the email's nth failed attempt.
+func (q *mailQueue) backoff(n int) time.Duration {
+	return min(q.retryDelay<<(n-1), mailMaxRetryDelay)
+}
+
+func (q *mailQueue) enqueue(e *queuedEmail) error {
+	q.mu.Lock()
+	if len(q.queue) >= mailQueueSize {
+		q.mu.Unlock()
+		return errMailQueueFull
+	}
+	if e.ID == "" {
+		b := make([]byte, 6)
+		rand.Read(b)
+		e.ID = "mail_" + hex.EncodeToString(b)
+	}
+	q.queue = append(q.queue, e)
+	q.mu.Unlock()
+	q.changed()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// changed updates the gauge and saves the queue after it changed.
+func (q *mailQueue) changed() {
+	q.saveMu.Lock()
+	defer q.saveMu.Unlock()
+	q.mu.Lock()
+	path, queue := q.path, slices.Clone(q.queue)
+	q.mu.Unlock()
+	q.metrics.mailQueued(len(queue))
+	if path == "" {
+		return
+	}
+	if err := saveMailQueue(path, queue); err != nil {
+		q.logger.Error("failed to save the mail queue", "path", path, "err", err)
+	}
+}
+
+func saveMailQueue(path string, queue []*queuedEmail) error {
+	data, err := json.Marshal(queue)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // fails harmlessly once renamed
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// run retries the queued emails as they come due until close is called.
+func (q *mailQueue) run() {
+	defer close(q.done)
+	for {
+		var due <-chan time.Time
+		timer := time.NewTimer(0)
+		if next, ok := q.next(); ok {
+			timer.Reset(time.Until(next))
+			due = timer.C
+		}
+		select {
+		case <-due:
+		case <-q.wake:
+		case <-q.ctx.Done():
+			timer.Stop()
+			return
+		}
+		timer.Stop()
+		q.retry(q.ctx, time.Now())
+	}
+}
+
+// next is when the queued email due first is.
+func (q *mailQueue) next() (time.Time, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.queue) == 0 {
+		return time.Time{}, false
+	}
+	next := q.queue[0].NextAttempt
+	for _, e := range q.queue[1:] {
+		if e.NextAttempt.Before(next) {
+			next = e.NextAttempt
+		}
+	}
+	return next, true
+}
+
+// retry sends the emails due by now, oldest first. Those refused again
+// wait longer for their next attempt, or go to the dead-letter queue once
+// they have had mailSendAttempts.
+func (q *mailQueue) retry(ctx context.Context, now time.Time) {
+	q.mu.Lock()
+	var due []*queuedEmail
+	for _, e := range q.queue {
+		if !e.NextAttempt.After(now) {
+			due = append(due, e)
+		}
+	}
+	q.mu.Unlock()
+	for _, e := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		err := q.sender.Send(ctx, e.To, &email.Message{Subject: e.Subject, Text: e.Text, HTML: e.HTML})
+		q.mu.Lock()
+		switch {
+		case err == nil:
+			q.remove(e)
+		case ctx.Err() != nil:
+			// Shutting down: it stays queued as it was.
+		default:
+			e.Attempts = append(e.Attempts, DeadLetterAttempt{Time: timestamp.Now(), Error: err.Error()})
+			if len(e.Attempts) < mailSendAttempts {
+				e.NextAttempt = time.Now().Add(q.backoff(len(e.Attempts)))
+				break
+			}
+			q.remove(e)
+			id := q.dead.fail(DeadLetterEmail, e.DeadLetter, e.mailDelivery, e.Attempts)
+			q.logger.Warn("failed to send email", "subject", e.Subject, "attempts", len(e.Attempts), "err", err, "dead_letter", id)
+		}
+		q.mu.Unlock()
+		if err == nil {
+			q.dead.delivered(e.DeadLetter)
+		}
+		q.changed()
+	}
+}
+
+// remove takes e off the queue. q.mu must be held.
+func (q *mailQueue) remove(e *queuedEmail) {
+	q.queue = slices.DeleteFunc(q.queue, func(o *queuedEmail) bool { return o == e })
+}
+
+// close stops run. Without a file to keep them in, the emails still queued
+// are tried once more, for as long as ctx allows, and those that fail are
+// lost.
+func (q *mailQueue) close(ctx context.Context) {
+	q.cancel()
+	<-q.done
+	q.mu.Lock()
+	path, queue := q.path, slices.Clone(q.queue)
+	q.mu.Unlock()
+	if path != "" || len(queue) == 0 {
+		return
+	}
+	for _, e := range queue {
+		q.mu.Lock()
+		e.NextAttempt = time.Time{}
+		q.mu.Unlock()
+	}
+	q.retry(ctx, time.Now())
+	if n := q.depth(); n > 0 {
+		q.logger.Warn("emails still queued at shutdown are lost; set MAIL_QUEUE_PATH to keep them", "emails", n)
+	}
+}
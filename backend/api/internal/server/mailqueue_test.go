@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/email"
+)
+
+// flakyMailer refuses emails while failing is set.
+type flakyMailer struct {
+	failing atomic.Bool
+	mu      sync.Mutex
+	sent    []string // subjects
+	tried   int
+}
+
+func (m *flakyMailer) Send(_ context.Context, _ string, msg *email.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tried++
+	if m.failing.Load() {
+		return errors.New("connection refused")
+	}
+	m.sent = append(m.sent, msg.Subject)
+	return nil
+}
+
+func TestMailQueue(t *testing.T) {
+	mailer := &flakyMailer{}
+	mailer.failing.Store(true)
+	path := filepath.Join(t.TempDir(), "mail.json")
+	s := NewServer(Config{Mailer: mailer}, NewMemoryStore())
+	if err := s.mail.openFile(path); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// A refused email is queued rather than failing, and the ones after it
+	// go behind it without trying the mail server.
+	for _, subject := range []string{"first", "second"} {
+		if queued, err := s.mail.send(ctx, "kim@example.com", &email.Message{Subject: subject}); err != nil || !queued {
+			t.Fatalf("send %s: queued %v, %v; want it queued", subject, queued, err)
+		}
+	}
+	if mailer.tried != 1 || s.mail.depth() != 2 {
+		t.Fatalf("tried %d times, %d queued; want 1 try and 2 queued", mailer.tried, s.mail.depth())
+	}
+
+	// The queue outlives a restart.
+	restarted := NewServer(Config{Mailer: mailer}, NewMemoryStore())
+	if err := restarted.mail.openFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if n := restarted.mail.depth(); n != 2 {
+		t.Fatalf("restored %d queued emails, want 2", n)
+	}
+
+	// Retries back off until the email has had every attempt, when it is
+	// dead-lettered.
+	now := time.Now()
+	for range mailSendAttempts {
+		now = now.Add(mailMaxRetryDelay)
+		restarted.mail.retry(ctx, now)
+	}
+	dead := restarted.deadLetters.List(DeadLetterEmail, "")
+	if len(dead) != 2 || len(dead[1].Attempts) != mailSendAttempts || dead[1].Payload.(mailDelivery).Subject != "first" {
+		t.Fatalf("dead letters %+v, want both emails after %d attempts", dead, mailSendAttempts)
+	}
+	if n := restarted.mail.depth(); n != 0 {
+		t.Errorf("%d emails still queued", n)
+	}
+
+	// Requeued once the mail server is back, they are sent, in order.
+	mailer.failing.Store(false)
+	for _, l := range slices.Backward(dead) {
+		if _, err := restarted.deadLetters.Requeue(ctx, l.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	restarted.mail.retry(ctx, time.Now())
+	if !slices.Equal(mailer.sent, []string{"first", "second"}) || len(restarted.deadLetters.List("", "")) != 0 {
+		t.Errorf("sent %q, %d dead letters left", mailer.sent, len(restarted.deadLetters.List("", "")))
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "[]" {
+		t.Errorf("saved queue %q, %v; want it empty", data, err)
+	}
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// MaintenanceMode is how much of the API stays up during maintenance, such
+// as a store migration.
+type MaintenanceMode string
+
+const (
+	MaintenanceOff MaintenanceMode = "off"
+	// MaintenanceReadOnly serves reads and refuses writes.
+	MaintenanceReadOnly MaintenanceMode = "read_only"
+	// MaintenanceOn refuses everything but the exempt routes.
+	MaintenanceOn MaintenanceMode = "on"
+)
+
+// parseMaintenanceMode parses off, read_only or on; empty means off.
+func parseMaintenanceMode(s string) (MaintenanceMode, error) {
+	switch m := MaintenanceMode(s); m {
+	case "":
+		return MaintenanceOff, nil
+	case MaintenanceOff, MaintenanceReadOnly, MaintenanceOn:
+		return m, nil
+	}
+	return "", fmt.Errorf("want off, read_only or on, got %q", s)
+}
+
+// defaultMaintenanceRetryAfter is what 503s during maintenance tell clients
+// to wait when no estimate was given.
+const defaultMaintenanceRetryAfter = 5 * time.Minute
+
+// maintenanceExempt reports whether path stays reachable during
+// maintenance: health checks and metrics, so the load balancer and alerts
+// see the server up, the spec and docs, and the admin API, to run the
+// migration and end maintenance.
+func maintenanceExempt(path string) bool {
+	switch path {
+	case "/metrics", "/version", "/docs", "/openapi.json", "/openapi.yaml":
+		return true
+	}
+	return probePath(path) || strings.HasPrefix(path, "/admin/") || strings.HasPrefix(path, "/schemas/") || strings.HasPrefix(path, "/openapi-")
+}
+
+// Maintenance is the maintenance state in effect.
+type Maintenance struct {
+	Mode              MaintenanceMode `json:"mode" enum:"off,read_only,on" doc:"What is refused: nothing, writes, or everything but health, metrics, docs and the admin API"`
+	Message           string          `json:"message,omitempty" doc:"Shown to clients in the 503's detail"`
+	RetryAfterSeconds int             `json:"retry_after_seconds,omitempty" doc:"What the 503's Retry-After tells clients to wait"`
+	Since             *timestamp.Time `json:"since,omitempty" doc:"When maintenance started"`
+}
+
+type MaintenanceRequest struct {
+	Mode              MaintenanceMode `json:"mode" enum:"off,read_only,on" doc:"What to refuse: nothing, writes, or everything but health, metrics, docs and the admin API"`
+	Message           string          `json:"message,omitempty" maxLength:"500" example:"Migrating the user store" doc:"Shown to clients in the 503's detail; translated only if it is the default"`
+	RetryAfterSeconds int             `json:"retry_after_seconds,omitempty" minimum:"1" maximum:"86400" doc:"What the 503's Retry-After tells clients to wait; MAINTENANCE_RETRY_AFTER if omitted"`
+}
+
+type MaintenanceInput struct {
+	AdminInput
+	Body MaintenanceRequest
+}
+
+type GetMaintenanceInput struct {
+	AdminInput
+}
+
+type MaintenanceOutput struct {
+	Body *Maintenance
+}
+
+// setMaintenance switches maintenance to mode, until the next call or a
+// config reload that changes MAINTENANCE_MODE. The user service stays
+// read-only while maintenance is on, so nothing writes behind it.
+func (s *Server) setMaintenance(mode MaintenanceMode, message string, retryAfter time.Duration) *Maintenance {
+	m := &Maintenance{Mode: mode}
+	if mode != MaintenanceOff {
+		now := timestamp.Now()
+		if prev := s.maintenance.Load(); prev != nil && prev.Mode != MaintenanceOff {
+			now = *prev.Since
+		}
+		m.Message = message
+		m.RetryAfterSeconds = int(cmp.Or(retryAfter, s.cfg.MaintenanceRetryAfter, defaultMaintenanceRetryAfter).Seconds())
+		m.Since = &now
+	}
+	s.maintenance.Store(m)
+	s.users.SetReadOnly(mode != MaintenanceOff)
+	s.logger.Info("maintenance mode changed", "mode", mode, "retry_after", m.RetryAfterSeconds)
+	return m
+}
+
+// refuseDuringMaintenance answers 503 with Retry-After to the requests the
+// current maintenance mode doesn't let through.
+func (s *Server) refuseDuringMaintenance(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m := s.maintenance.Load()
+		if m == nil || m.Mode == MaintenanceOff || maintenanceExempt(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if m.Mode == MaintenanceReadOnly {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+		msg := "the API is down for maintenance"
+		if m.Mode == MaintenanceReadOnly {
+			msg = "the API is read-only for maintenance"
+		}
+		if m.Message != "" {
+			msg = m.Message
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(m.RetryAfterSeconds))
+		writeError(w, r, http.StatusServiceUnavailable, CodeMaintenance, msg)
+	})
+}
+
+// getMaintenance is the get-admin-maintenance handler.
+func (s *Server) getMaintenance(ctx context.Context, input *GetMaintenanceInput) (*MaintenanceOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	return &MaintenanceOutput{Body: s.maintenance.Load()}, nil
+}
+
+// putMaintenance is the put-admin-maintenance handler.
+func (s *Server) putMaintenance(ctx context.Context, input *MaintenanceInput) (*MaintenanceOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	retryAfter := time.Duration(input.Body.RetryAfterSeconds) * time.Second
+	return &MaintenanceOutput{Body: s.setMaintenance(input.Body.Mode, input.Body.Message, retryAfter)}, nil
+}
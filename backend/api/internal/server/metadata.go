@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// Limits on user metadata, measured on its JSON encoding so they hold no
+// matter which format the client sent.
+const (
+	maxMetadataBytes = 8 << 10
+	maxMetadataDepth = 5
+)
+
+// metadataSchemaKey carries Config.MetadataSchema in the request context, as
+// request bodies are validated before any handler runs.
+type metadataSchemaKey struct{}
+
+// withMetadataSchema makes schema available to validateMetadata for every
+// request.
+func withMetadataSchema(schema *huma.Schema) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), metadataSchemaKey{}, schema)))
+		})
+	}
+}
+
+// metadataRegistry resolves nothing; it only satisfies huma.Validate, as the
+// metadata schema is self-contained.
+var metadataRegistry = huma.NewMapRegistry("#/components/schemas/", huma.DefaultSchemaNamer)
+
+// loadMetadataSchema reads a JSON Schema document for user metadata. The
+// keywords huma validates are supported; `$ref` is not.
+func loadMetadataSchema(path string) (*huma.Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s huma.Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	s.PrecomputeMessages()
+	return &s, nil
+}
+
+// validateMetadata enforces the size and depth limits and the schema
+// configured for the request, reporting problems under prefix.metadata.
+func validateMetadata(ctx context.Context, prefix *huma.PathBuffer, m map[string]any) []error {
+	if m == nil {
+		return nil
+	}
+	loc := prefix.With("metadata")
+	encoded, err := json.Marshal(m)
+	if err != nil {
+		return []error{&ErrorDetail{Location: loc, Code: "type", Message: "expected JSON-encodable metadata"}}
+	}
+	if len(encoded) > maxMetadataBytes {
+		return []error{&ErrorDetail{Location: loc, Code: "max_size", Message: fmt.Sprintf("expected metadata of at most %d bytes", maxMetadataBytes)}}
+	}
+	var doc any
+	_ = json.Unmarshal(encoded, &doc)
+	if depth(doc) > maxMetadataDepth {
+		return []error{&ErrorDetail{Location: loc, Code: "max_depth", Message: fmt.Sprintf("expected metadata nested at most %d levels deep", maxMetadataDepth), Value: m}}
+	}
+	metadataSchema, _ := ctx.Value(metadataSchemaKey{}).(*huma.Schema)
+	if metadataSchema == nil {
+		return nil
+	}
+	res := &huma.ValidateResult{}
+	prefix.Push("metadata")
+	huma.Validate(metadataRegistry, metadataSchema, prefix, huma.ModeWriteToServer, doc, res)
+	prefix.Pop()
+	return res.Errors
+}
+
+// depth counts the levels of objects and arrays in a decoded JSON value.
+func depth(v any) int {
+	deepest := 0
+	switch v := v.(type) {
+	case map[string]any:
+		for _, child := range v {
+			deepest = max(deepest, depth(child))
+		}
+	case []any:
+		for _, child := range v {
+			deepest = max(deepest, depth(child))
+		}
+	default:
+		return 0
+	}
+	return deepest + 1
+}
+
+// metadataMatches reports whether m has every filter key set to the filter
+// value. Keys can reach into nested objects with dots (`address.city`), and
+// non-string values compare by their JSON text (`metadata.beta=true`).
+func metadataMatches(m map[string]any, filters map[string]string) bool {
+	for key, want := range filters {
+		var v any = m
+		for _, part := range strings.Split(key, ".") {
+			obj, ok := v.(map[string]any)
+			if !ok {
+				return false
+			}
+			if v, ok = obj[part]; !ok {
+				return false
+			}
+		}
+		if s, ok := v.(string); ok {
+			if s != want {
+				return false
+			}
+			continue
+		}
+		if b, err := json.Marshal(v); err != nil || string(b) != want {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,349 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics exporters Config.MetricsExporter can select.
+const (
+	MetricsPrometheus = "prometheus"
+	MetricsStatsD     = "statsd"
+	MetricsNone       = "none"
+)
+
+// recorder is the instrumentation layer: the middleware reports to it and
+// each exporter implements it.
+type recorder interface {
+	// request records one served request, part of the sampled trace
+	// traceID if that isn't empty.
+	request(method, route, statusClass string, took time.Duration, traceID string)
+	// canaryRequest records one request the canary store served, which
+	// request has recorded too.
+	canaryRequest(method, route, statusClass string, took time.Duration)
+	// shadowed records a request sampled to be mirrored to the shadow
+	// target, by result: sent, failed, dropped or skipped.
+	shadowed(result string)
+	// slowRequest records a request over the slow request threshold.
+	slowRequest(method, route string)
+	// eviction records a user the store dropped to stay within its bounds.
+	eviction(reason string)
+	// retentionPurged records n records the retention policy purged under
+	// rule.
+	retentionPurged(rule string, n int)
+	// deduplicated records a duplicate request to operation that got the
+	// first one's response.
+	deduplicated(operation string)
+	// shed records a request the load shedder turned away from lane, for
+	// reason.
+	shed(lane, reason string)
+	// concurrencyLimited records a request to group its ConcurrencyLimit
+	// turned away, for reason.
+	concurrencyLimited(group, reason string)
+	// leader records whether this replica leads the singleton jobs.
+	leader(leading bool)
+	// deadLetters records how many dead letters of kind are queued.
+	deadLetters(kind string, n int)
+	// mailQueued records how many emails are queued to be retried.
+	mailQueued(n int)
+	// pool records a sample of the connection pool name: the connections
+	// in use and idle, and the waits for a connection, the waits that timed
+	// out and the time waited since the last sample.
+	pool(name string, inUse, idle int, waits, timeouts uint64, waited time.Duration)
+	// close flushes anything buffered.
+	close() error
+}
+
+// unmatchedRoute labels requests no route matched, so scanners probing random
+// paths can't create a series per path.
+const unmatchedRoute = "unmatched"
+
+// promRecorder keeps the metrics in a Prometheus registry served on
+// /metrics. Each Server has its own registry so tests can build several
+// without colliding.
+type promRecorder struct {
+	registry     *prometheus.Registry
+	requests     *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	canaryCount  *prometheus.CounterVec
+	canaryTime   *prometheus.HistogramVec
+	shadow       *prometheus.CounterVec
+	slowRequests *prometheus.CounterVec
+	evictions    *prometheus.CounterVec
+	purged       *prometheus.CounterVec
+	deduped      *prometheus.CounterVec
+	shedRequests *prometheus.CounterVec
+	limited      *prometheus.CounterVec
+	leading      prometheus.Gauge
+	deadQueued   *prometheus.GaugeVec
+	mailQueue    prometheus.Gauge
+	poolConns    *prometheus.GaugeVec
+	poolWaits    *prometheus.CounterVec
+	poolWaited   *prometheus.CounterVec
+	poolTimeouts *prometheus.CounterVec
+}
+
+func newPromRecorder() *promRecorder {
+	m := &promRecorder{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Requests served, by route pattern and status class.",
+		}, []string{"method", "route", "status_class"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Time to serve a request, by route pattern and status class.",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		}, []string{"method", "route", "status_class"}),
+		canaryCount: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_canary_requests_total",
+			Help: "Requests the canary store served, by route pattern and status class; http_requests_total counts them too.",
+		}, []string{"method", "route", "status_class"}),
+		canaryTime: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_canary_request_duration_seconds",
+			Help:    "Time to serve a request from the canary store, by route pattern and status class.",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		}, []string{"method", "route", "status_class"}),
+		shadow: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_shadow_requests_total",
+			Help: "Requests sampled to be mirrored to SHADOW_URL, by result (sent, failed, dropped when too many were on their way, or skipped when the body wasn't read or was too large).",
+		}, []string{"result"}),
+		slowRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_slow_requests_total",
+			Help: "Requests that took longer than the slow request threshold, by route pattern.",
+		}, []string{"method", "route"}),
+		evictions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "store_evictions_total",
+			Help: "Users the in-memory store evicted to stay within its bounds, by reason (lru or ttl).",
+		}, []string{"reason"}),
+		purged: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "retention_purged_total",
+			Help: "Records the retention policy purged, by rule (deleted_users or audit).",
+		}, []string{"rule"}),
+		deduped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_deduplicated_requests_total",
+			Help: "Duplicate requests that got the response of the identical request just before them, by operation.",
+		}, []string{"operation"}),
+		shedRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_shed_requests_total",
+			Help: "Requests turned away with a 503 because their lane was full, by lane (api or admin) and reason (queue_full or queue_timeout).",
+		}, []string{"lane", "reason"}),
+		limited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_concurrency_limited_requests_total",
+			Help: "Requests turned away with a 429 because their route group was at its CONCURRENCY_LIMITS, by group and reason (queue_full or queue_timeout).",
+		}, []string{"group", "reason"}),
+		leading: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "leader",
+			Help: "1 while this replica leads, running the singleton jobs like retention and digests, 0 otherwise.",
+		}),
+		deadQueued: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dead_letters",
+			Help: "Background deliveries in the dead-letter queue after running out of attempts, by kind (security_event, cache_purge or email).",
+		}, []string{"kind"}),
+		mailQueue: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "mail_queue_depth",
+			Help: "Emails the mail server refused, or that came after those, queued to be retried.",
+		}),
+		poolConns: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "pool_connections",
+			Help: "Connections of a connection pool, by pool (like redis/host:port) and state (in_use or idle).",
+		}, []string{"pool", "state"}),
+		poolWaits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pool_waits_total",
+			Help: "Times a connection pool had none free and a caller waited for one, by pool.",
+		}, []string{"pool"}),
+		poolWaited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pool_wait_seconds_total",
+			Help: "Time callers spent waiting for a connection, by pool.",
+		}, []string{"pool"}),
+		poolTimeouts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pool_timeouts_total",
+			Help: "Waits for a connection that gave up after the pool timeout, by pool.",
+		}, []string{"pool"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.duration,
+		m.canaryCount,
+		m.canaryTime,
+		m.shadow,
+		m.slowRequests,
+		m.evictions,
+		m.purged,
+		m.deduped,
+		m.shedRequests,
+		m.limited,
+		m.leading,
+		m.deadQueued,
+		m.mailQueue,
+		m.poolConns,
+		m.poolWaits,
+		m.poolWaited,
+		m.poolTimeouts,
+	)
+	return m
+}
+
+// request records the duration of a traced request with its trace ID as
+// an exemplar, which Grafana links to the trace.
+func (m *promRecorder) request(method, route, statusClass string, took time.Duration, traceID string) {
+	labels := prometheus.Labels{"method": method, "route": route, "status_class": statusClass}
+	m.requests.With(labels).Inc()
+	duration := m.duration.With(labels)
+	if traceID == "" {
+		duration.Observe(took.Seconds())
+		return
+	}
+	duration.(prometheus.ExemplarObserver).ObserveWithExemplar(took.Seconds(), prometheus.Labels{"trace_id": traceID})
+}
+
+func (m *promRecorder) canaryRequest(method, route, statusClass string, took time.Duration) {
+	m.canaryCount.WithLabelValues(method, route, statusClass).Inc()
+	m.canaryTime.WithLabelValues(method, route, statusClass).Observe(took.Seconds())
+}
+
+func (m *promRecorder) shadowed(result string) {
+	m.shadow.WithLabelValues(result).Inc()
+}
+
+func (m *promRecorder) slowRequest(method, route string) {
+	m.slowRequests.WithLabelValues(method, route).Inc()
+}
+
+func (m *promRecorder) eviction(reason string) {
+	m.evictions.WithLabelValues(reason).Inc()
+}
+
+func (m *promRecorder) retentionPurged(rule string, n int) {
+	m.purged.WithLabelValues(rule).Add(float64(n))
+}
+
+func (m *promRecorder) deduplicated(operation string) {
+	m.deduped.WithLabelValues(operation).Inc()
+}
+
+func (m *promRecorder) shed(lane, reason string) {
+	m.shedRequests.WithLabelValues(lane, reason).Inc()
+}
+
+func (m *promRecorder) concurrencyLimited(group, reason string) {
+	m.limited.WithLabelValues(group, reason).Inc()
+}
+
+func (m *promRecorder) leader(leading bool) {
+	m.leading.Set(boolGauge(leading))
+}
+
+func (m *promRecorder) deadLetters(kind string, n int) {
+	m.deadQueued.WithLabelValues(kind).Set(float64(n))
+}
+
+func (m *promRecorder) mailQueued(n int) {
+	m.mailQueue.Set(float64(n))
+}
+
+func (m *promRecorder) pool(name string, inUse, idle int, waits, timeouts uint64, waited time.Duration) {
+	m.poolConns.WithLabelValues(name, "in_use").Set(float64(inUse))
+	m.poolConns.WithLabelValues(name, "idle").Set(float64(idle))
+	m.poolWaits.WithLabelValues(name).Add(float64(waits))
+	m.poolWaited.WithLabelValues(name).Add(waited.Seconds())
+	m.poolTimeouts.WithLabelValues(name).Add(float64(timeouts))
+}
+
+func (m *promRecorder) close() error { return nil }
+
+func boolGauge(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// handler serves the metrics in the Prometheus text format, or in the
+// OpenMetrics format, which is the one with exemplars, to scrapers asking
+// for it.
+func (m *promRecorder) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry, EnableOpenMetrics: true})
+}
+
+// noopRecorder drops everything, for MetricsNone.
+type noopRecorder struct{}
+
+func (noopRecorder) request(string, string, string, time.Duration, string) {}
+func (noopRecorder) canaryRequest(string, string, string, time.Duration)   {}
+func (noopRecorder) shadowed(string)                                       {}
+func (noopRecorder) slowRequest(string, string)                            {}
+func (noopRecorder) eviction(string)                                       {}
+func (noopRecorder) retentionPurged(string, int)                           {}
+func (noopRecorder) deduplicated(string)                                   {}
+func (noopRecorder) shed(string, string)                                   {}
+func (noopRecorder) concurrencyLimited(string, string)                     {}
+func (noopRecorder) leader(bool)                                           {}
+func (noopRecorder) deadLetters(string, int)                               {}
+func (noopRecorder) mailQueued(int)                                        {}
+func (noopRecorder) pool(string, int, int, uint64, uint64, time.Duration)  {}
+func (noopRecorder) close() error                                          { return nil }
+
+// instrument records the rate, errors and duration of every request, labeled
+// with the chi route pattern rather than the raw path, so /v1/users/{id} is
+// one series however many users there are. Requests the canary store
+// served are recorded again on their own.
+func instrument(rec recorder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sw, r)
+			took := time.Since(start)
+			method, route, statusClass := methodLabel(r.Method), routePattern(r), strconv.Itoa(sw.status/100)+"xx"
+			rec.request(method, route, statusClass, took, traceID(r.Context()))
+			if isCanary(r.Context()) {
+				rec.canaryRequest(method, route, statusClass, took)
+			}
+		})
+	}
+}
+
+// routePattern returns the pattern of the route that served r, or
+// unmatchedRoute. It is only complete once the router has routed r.
+func routePattern(r *http.Request) string {
+	if rc := chi.RouteContext(r.Context()); rc != nil && rc.RoutePattern() != "" {
+		return rc.RoutePattern()
+	}
+	return unmatchedRoute
+}
+
+// methodLabel folds methods the API doesn't use into "other", as clients can
+// send any token as a method.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method
+	}
+	return "other"
+}
+
+// statusWriter remembers the status code written through it.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusWriter) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (s *statusWriter) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ServiceIdentity is the service a request came from, per the client
+// certificate it presented on an https listener.
+type ServiceIdentity struct {
+	// Name is the service, from Config.ServiceIdentities.
+	Name string
+	// Subject is the certificate's name it was resolved from: its first
+	// URI SAN, such as a SPIFFE ID, DNS SAN or common name that is mapped.
+	Subject string
+	// Fingerprint is the SHA-256 of the certificate, in hex.
+	Fingerprint string
+}
+
+type serviceKey struct{}
+
+// serviceFrom returns the service ctx's request came from, or nil if it
+// presented no client certificate, or one that names no service.
+func serviceFrom(ctx context.Context) *ServiceIdentity {
+	id, _ := ctx.Value(serviceKey{}).(*ServiceIdentity)
+	return id
+}
+
+// loadClientCAs reads the PEM bundle of CAs client certificates must be
+// issued by.
+func loadClientCAs(path string) (*x509.CertPool, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, errors.New("no certificates in " + path)
+	}
+	return pool, nil
+}
+
+// ParseServiceIdentities parses a comma-separated list of name=service
+// pairs, mapping the names certificates carry to the services they
+// identify. A name is a URI SAN, a DNS SAN or a common name.
+func ParseServiceIdentities(s string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		// URIs hold = in their query, so the service is after the last one.
+		i := strings.LastIndex(entry, "=")
+		if i <= 0 || i == len(entry)-1 {
+			return nil, fmt.Errorf("%s: want name=service", entry)
+		}
+		out[strings.TrimSpace(entry[:i])] = strings.TrimSpace(entry[i+1:])
+	}
+	return out, nil
+}
+
+// certNames returns the names cert can be mapped by, in the order they are
+// tried: its URI SANs, DNS SANs and common name.
+func certNames(cert *x509.Certificate) []string {
+	var names []string
+	for _, u := range cert.URIs {
+		names = append(names, u.String())
+	}
+	names = append(names, cert.DNSNames...)
+	if cert.Subject.CommonName != "" {
+		names = append(names, cert.Subject.CommonName)
+	}
+	return names
+}
+
+// serviceIdentity resolves cert, which the listener has verified, to the
+// service its first mapped name identifies, or nil if none is mapped.
+func serviceIdentity(cert *x509.Certificate, identities map[string]string) *ServiceIdentity {
+	for _, name := range certNames(cert) {
+		if service, ok := identities[name]; ok {
+			sum := sha256.Sum256(cert.Raw)
+			return &ServiceIdentity{Name: service, Subject: name, Fingerprint: hex.EncodeToString(sum[:])}
+		}
+	}
+	return nil
+}
+
+// clientTLSConfig returns the TLS config of https listeners: with
+// Config.ClientCAs, they ask for a client certificate issued by one of
+// them, and with Config.RequireClientCerts refuse the handshake without.
+func (s *Server) clientTLSConfig() *tls.Config {
+	if s.cfg.ClientCAs == nil {
+		return nil
+	}
+	auth := tls.VerifyClientCertIfGiven
+	if s.cfg.RequireClientCerts {
+		auth = tls.RequireAndVerifyClientCert
+	}
+	return &tls.Config{ClientCAs: s.cfg.ClientCAs, ClientAuth: auth}
+}
+
+// identifyService puts the service the request's verified client
+// certificate identifies on its context; see serviceFrom. Certificates
+// naming no service are let through as if there were none, to be treated
+// like any other client.
+func (s *Server) identifyService(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cert := r.TLS.VerifiedChains[0][0]
+		id := serviceIdentity(cert, s.cfg.ServiceIdentities)
+		if id == nil {
+			s.logger.DebugContext(r.Context(), "client certificate names no service", "names", certNames(cert))
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), serviceKey{}, id)))
+	})
+}
+
+// adminService reports whether ctx's request came from a service allowed
+// to call the admin API without the admin token.
+func (s *Server) adminService(ctx context.Context) bool {
+	id := serviceFrom(ctx)
+	return id != nil && slices.Contains(s.cfg.AdminServices, id.Name)
+}
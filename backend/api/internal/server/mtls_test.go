@@ -0,0 +1,97 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestClientCertIdentifiesService(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newCert := func(tmpl, parent *x509.Certificate) *x509.Certificate {
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert
+	}
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	ca = newCert(ca, ca)
+	spiffe, _ := url.Parse("spiffe://example.org/backup")
+	client := newCert(&x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "backup.internal"},
+		URIs:         []*url.URL{spiffe},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	identities, err := ParseServiceIdentities("spiffe://example.org/backup=backup, reports.internal=reports")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(Config{ClientCAs: pool, ServiceIdentities: identities, AdminServices: []string{"backup"}}, NewMemoryStore())
+	srv := httptest.NewUnstartedServer(s.identifyService(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := serviceFrom(r.Context()); id != nil {
+			w.Write([]byte(id.Name + " " + id.Subject))
+		}
+		if err := s.authorizeAdmin(r.Context(), AdminInput{}); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})))
+	srv.TLS = s.clientTLSConfig()
+	srv.StartTLS()
+	defer srv.Close()
+
+	get := func(certs ...tls.Certificate) (int, string) {
+		// A transport of its own, so connections aren't reused.
+		tr := srv.Client().Transport.(*http.Transport).Clone()
+		tr.TLSClientConfig.Certificates = certs
+		defer tr.CloseIdleConnections()
+		resp, err := (&http.Client{Transport: tr}).Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	if status, body := get(tls.Certificate{Certificate: [][]byte{client.Raw}, PrivateKey: key}); status != http.StatusOK || body != "backup spiffe://example.org/backup" {
+		t.Errorf("with the certificate: %d %q, want the backup service, let into the admin API", status, body)
+	}
+	if status, body := get(); status != http.StatusUnauthorized || body != "" {
+		t.Errorf("without a certificate: %d %q, want no service, kept out of the admin API", status, body)
+	}
+
+	if _, err := ParseServiceIdentities("backup"); err == nil {
+		t.Error("ParseServiceIdentities accepted an entry without a service")
+	}
+}
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/idgen"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// NotificationType is what a notification tells its user about.
+type NotificationType string
+
+const (
+	NotificationWelcome NotificationType = "welcome"
+	NotificationComment NotificationType = "comment"
+	NotificationReply   NotificationType = "reply"
+	NotificationMention NotificationType = "mention"
+)
+
+// Notification tells a user about something that happened to or around
+// them, such as a comment on their post.
+type Notification struct {
+	ID        string           `json:"id" example:"20240101121000" doc:"Notification ID"`
+	UserID    string           `json:"user_id" example:"20240101120000" doc:"ID of the user notified"`
+	Type      NotificationType `json:"type" enum:"welcome,comment,reply,mention" doc:"welcome when the account was created; comment on their post; reply to their comment; mention of their @username in a post or comment"`
+	ActorID   string           `json:"actor_id,omitempty" example:"20240101120100" doc:"ID of the user whose action caused the notification"`
+	PostID    string           `json:"post_id,omitempty" example:"20240101120500" doc:"ID of the post it is about"`
+	CommentID string           `json:"comment_id,omitempty" example:"20240101120900" doc:"ID of the comment it is about"`
+	CreatedAt timestamp.Time   `json:"created_at" doc:"When it happened"`
+	ReadAt    *timestamp.Time  `json:"read_at,omitempty" doc:"When the user marked it read; absent while unread"`
+}
+
+type ListNotificationsInput struct {
+	UserInput
+	PageInput
+	Unread bool `query:"unread" doc:"Only list the unread notifications"`
+}
+
+type NotificationIDInput struct {
+	UserInput
+	ID string `path:"id" example:"20240101121000" doc:"Notification ID"`
+}
+
+type NotificationOutput struct {
+	Body *Notification
+}
+
+type NotificationsListResponse struct {
+	Notifications []*Notification `json:"notifications" doc:"This page of notifications, newest first"`
+	UnreadCount   int             `json:"unread_count" example:"3" doc:"Number of unread notifications, across all pages"`
+	Pagination
+}
+
+type NotificationsListOutput struct {
+	TotalCount int    `header:"X-Total-Count" doc:"Number of notifications matching the filters, across all pages"`
+	Link       string `header:"Link" doc:"RFC 8288 links to the first, prev, next and last pages"`
+	Body       *NotificationsListResponse
+}
+
+// mentionPattern finds @usernames in text; usernamePattern is what they can
+// be.
+var mentionPattern = regexp.MustCompile(`@([A-Za-z][A-Za-z0-9_]{2,31})\b`)
+
+// NotificationService turns events on the bus into notifications for the
+// users they concern:
+//
+//   - user.created welcomes the new user;
+//   - comment.created notifies the post's author, the author of the comment
+//     replied to, and the users it @mentions;
+//   - post.created notifies the users it @mentions.
+//
+// Nobody is notified of their own doing, or twice for one event. Like the
+// audit log, notifications are kept in memory, per replica, so they start
+// empty on every restart. Deleting a user for good drops theirs.
+type NotificationService struct {
+	store Store
+	idGen idgen.Generator
+
+	mu sync.RWMutex
+	// byUser holds each user's notifications, oldest first.
+	byUser map[string][]*Notification
+	ids    map[string]bool
+}
+
+// NewNotificationService returns a service notifying about bus's events,
+// looking up who to notify in store, with IDs idGen makes.
+func NewNotificationService(store Store, bus *events.Bus, idGen idgen.Generator) *NotificationService {
+	n := &NotificationService{store: store, idGen: idGen, byUser: map[string][]*Notification{}, ids: map[string]bool{}}
+	bus.Subscribe(n.notify)
+	return n
+}
+
+var errNotificationNotFound = apiError(http.StatusNotFound, CodeNotificationNotFound, "Notification not found")
+
+func (n *NotificationService) notify(e events.Event) {
+	ctx := context.Background()
+	var out []*Notification
+	switch e.Type {
+	case "user.created":
+		out = append(out, &Notification{UserID: e.Subject, Type: NotificationWelcome})
+	case "user.deleted", "user.purged":
+		n.Forget(e.Subject)
+	case "post.created":
+		post, err := n.store.GetPost(ctx, eventField(e, "post_id"))
+		if err != nil {
+			return
+		}
+		out = n.mentions(ctx, post.Title+" "+post.Body, &Notification{ActorID: post.AuthorID, PostID: post.ID})
+	case "comment.created":
+		comment, err := n.store.GetComment(ctx, eventField(e, "comment_id"))
+		if err != nil {
+			return
+		}
+		about := Notification{ActorID: comment.AuthorID, PostID: comment.PostID, CommentID: comment.ID}
+		if comment.ParentID != "" {
+			if parent, err := n.store.GetComment(ctx, comment.ParentID); err == nil {
+				out = append(out, about.to(parent.AuthorID, NotificationReply))
+			}
+		}
+		if post, err := n.store.GetPost(ctx, comment.PostID); err == nil {
+			out = append(out, about.to(post.AuthorID, NotificationComment))
+		}
+		out = append(out, n.mentions(ctx, comment.Body, &about)...)
+	}
+	n.add(e.Time, out)
+}
+
+// eventField returns the string field key of e's data.
+func eventField(e events.Event, key string) string {
+	switch data := e.Data.(type) {
+	case map[string]string:
+		return data[key]
+	case map[string]any:
+		v, _ := data[key].(string)
+		return v
+	}
+	return ""
+}
+
+// to is a copy of about for userID.
+func (about Notification) to(userID string, typ NotificationType) *Notification {
+	about.UserID, about.Type = userID, typ
+	return &about
+}
+
+// mentions returns a mention notification, like about, for every existing
+// user text @mentions.
+func (n *NotificationService) mentions(ctx context.Context, text string, about *Notification) []*Notification {
+	names := map[string]bool{}
+	for _, m := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		names[strings.ToLower(m[1])] = true
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	users, err := n.store.ListUsers(ctx)
+	if err != nil {
+		return nil
+	}
+	var out []*Notification
+	for _, u := range users {
+		if u.Username != "" && names[u.Username] {
+			out = append(out, about.to(u.ID, NotificationMention))
+		}
+	}
+	return out
+}
+
+// add records out, skipping notifications of users about their own doing
+// and all but the first for each user.
+func (n *NotificationService) add(at time.Time, out []*Notification) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	notified := map[string]bool{}
+	for _, note := range out {
+		if note.UserID == note.ActorID || notified[note.UserID] {
+			continue
+		}
+		notified[note.UserID] = true
+		note.ID = newID(n.idGen, n.ids, at)
+		note.CreatedAt = timestamp.From(at)
+		n.ids[note.ID] = true
+		n.byUser[note.UserID] = append(n.byUser[note.UserID], note)
+	}
+}
+
+// List returns the user's notifications, newest first, or with unreadOnly
+// only the unread ones, along with how many are unread.
+func (n *NotificationService) List(userID string, unreadOnly bool) (notes []*Notification, unread int) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	notes = []*Notification{}
+	for _, note := range slices.Backward(n.byUser[userID]) {
+		if note.ReadAt == nil {
+			unread++
+		} else if unreadOnly {
+			continue
+		}
+		c := *note
+		notes = append(notes, &c)
+	}
+	return notes, unread
+}
+
+// MarkRead marks one of the user's notifications read. Marking it again
+// keeps the time it was first read.
+func (n *NotificationService) MarkRead(userID, id string) (*Notification, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, note := range n.byUser[userID] {
+		if note.ID == id {
+			if note.ReadAt == nil {
+				now := timestamp.Now()
+				note.ReadAt = &now
+			}
+			c := *note
+			return &c, nil
+		}
+	}
+	return nil, errNotificationNotFound
+}
+
+// Forget drops the user's notifications.
+func (n *NotificationService) Forget(userID string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, note := range n.byUser[userID] {
+		delete(n.ids, note.ID)
+	}
+	delete(n.byUser, userID)
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"errors"
+	"strings"
+)
+
+var errInvalidPhone = errors.New("expected an international phone number like +43 660 1234567")
+
+// normalizePhone turns a phone number into E.164 (`+436601234567`). Spaces,
+// dashes, dots and parentheses are dropped and a leading 00 counts as +, but
+// the country code is never guessed: numbers must be international.
+func normalizePhone(raw string) (string, error) {
+	var b strings.Builder
+	for i, r := range strings.TrimSpace(raw) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", errInvalidPhone
+		}
+	}
+	n := b.String()
+	if rest, ok := strings.CutPrefix(n, "00"); ok && !strings.HasPrefix(n, "+") {
+		n = "+" + rest
+	}
+	digits, ok := strings.CutPrefix(n, "+")
+	if !ok || len(digits) < 2 || len(digits) > 15 || digits[0] == '0' {
+		return "", errInvalidPhone
+	}
+	return n, nil
+}
+
+// phoneTaken reports whether a user other than exceptID already has phone.
+// It only matters when Config.UniquePhones is set.
+func phoneTaken(users []*User, phone, exceptID string) bool {
+	if phone == "" {
+		return false
+	}
+	for _, u := range users {
+		if u.ID != exceptID && u.Phone == phone {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,402 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/idgen"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// Post is something a user wrote. Posts belong to their author: deleting the
+// user for good, by deleting, erasing, purging or evicting them, deletes
+// their posts too. Soft-deleted users keep theirs until they are purged.
+type Post struct {
+	ID        string         `json:"id" example:"20240101120500" doc:"Post ID"`
+	AuthorID  string         `json:"author_id" example:"20240101120000" doc:"ID of the user who wrote the post"`
+	Title     string         `json:"title" example:"Hello, world" doc:"Title"`
+	Body      string         `json:"body" example:"My first post." doc:"Text of the post"`
+	CreatedAt timestamp.Time `json:"created_at" readOnly:"true" doc:"When the post was created"`
+	UpdatedAt timestamp.Time `json:"updated_at" readOnly:"true" doc:"When the post was last changed"`
+
+	Comments []*Comment `json:"comments,omitempty" readOnly:"true" doc:"The published comments on the post, replies included, oldest first; only with include=posts.comments on a user, and omitted if there are none"`
+}
+
+type PostRequest struct {
+	Title string `json:"title" minLength:"1" maxLength:"200" example:"Hello, world" doc:"Title"`
+	Body  string `json:"body" maxLength:"20000" example:"My first post." doc:"Text of the post"`
+}
+
+type CreatePostRequest struct {
+	AuthorID string `json:"author_id" minLength:"1" example:"20240101120000" doc:"ID of the user writing the post"`
+	Title    string `json:"title" minLength:"1" maxLength:"200" example:"Hello, world" doc:"Title"`
+	Body     string `json:"body" maxLength:"20000" example:"My first post." doc:"Text of the post"`
+}
+
+type UpdatePostRequest struct {
+	Title *string `json:"title,omitempty" minLength:"1" maxLength:"200" example:"Hello again" doc:"Title"`
+	Body  *string `json:"body,omitempty" maxLength:"20000" example:"My first post, edited." doc:"Text of the post"`
+}
+
+// PageInput pages through a list of posts or comments.
+type PageInput struct {
+	Page    int `query:"page" minimum:"1" default:"1" doc:"Page number, starting at 1"`
+	PerPage int `query:"per_page" minimum:"1" maximum:"500" default:"100" doc:"Entries per page"`
+
+	url url.URL
+}
+
+// Resolve keeps the request URL for the Link header and the next page.
+func (i *PageInput) Resolve(ctx huma.Context) []error {
+	i.url = ctx.URL()
+	return nil
+}
+
+func (i *PageInput) links(total int) string {
+	return pageLinks(i.url, i.Page, i.PerPage, total)
+}
+
+func (i *PageInput) pagination(total int) Pagination {
+	return pagination(i.url, i.Page, i.PerPage, total)
+}
+
+// KeysetPageInput pages through a list sorted by ID, by number or, so that
+// deletions and creations between requests don't shift the pages, after
+// the last entry of the previous page.
+type KeysetPageInput struct {
+	PageInput
+	After string `query:"after" maxLength:"100" example:"20240101120500" doc:"Start after the entry with this ID, the last of the previous page, instead of at page; unlike page numbers, it neither skips nor repeats entries when others are deleted or created between requests. next.after gives it"`
+
+	cursor cursor
+}
+
+func (i *KeysetPageInput) links(total int) string {
+	if i.After != "" {
+		return keysetLinks(i.url, i.PerPage, total, i.cursor)
+	}
+	return i.PageInput.links(total)
+}
+
+func (i *KeysetPageInput) pagination(total int) Pagination {
+	return keysetPagination(i.url, i.Page, i.PerPage, total, i.After != "", i.cursor)
+}
+
+// keysetPageOf returns the page of items, sorted by id, input asks for,
+// along with the total number of items.
+func keysetPageOf[T any](input *KeysetPageInput, items []T, id func(T) string) (page []T, total int) {
+	return keysetPage(&input.cursor, items, input.Page, input.PerPage, input.After, id)
+}
+
+// pageOf returns the page of items input asks for, along with the total
+// number of items.
+func pageOf[T any](input *PageInput, items []T) (page []T, total int) {
+	total = len(items)
+	start := min((input.Page-1)*input.PerPage, total)
+	end := min(start+input.PerPage, total)
+	return items[start:end], total
+}
+
+type ListPostsInput struct {
+	KeysetPageInput
+	AuthorID string `query:"author_id" example:"20240101120000" doc:"Only list the posts of this user"`
+}
+
+type UserPostsInput struct {
+	KeysetPageInput
+	ID string `path:"id" example:"20240101120000" doc:"User ID"`
+}
+
+type CreatePostInput struct {
+	Body CreatePostRequest
+}
+
+type CreateUserPostInput struct {
+	ID   string `path:"id" example:"20240101120000" doc:"User ID"`
+	Body PostRequest
+}
+
+type PostIDInput struct {
+	ID string `path:"id" example:"20240101120500" doc:"Post ID"`
+}
+
+type UpdatePostInput struct {
+	ID   string `path:"id" example:"20240101120500" doc:"Post ID"`
+	Body UpdatePostRequest
+}
+
+type PostOutput struct {
+	Body *Post
+}
+
+type PostsListResponse struct {
+	Posts []*Post `json:"posts" doc:"This page of posts"`
+	Pagination
+}
+
+type PostsListOutput struct {
+	TotalCount int    `header:"X-Total-Count" doc:"Number of posts matching the filters, across all pages"`
+	Link       string `header:"Link" doc:"RFC 8288 links to the first, prev, next and last pages"`
+	Body       *PostsListResponse
+}
+
+// postsPage is the page of posts input asks for, in ID order; see paginate.
+func postsPage(input *KeysetPageInput, posts []*Post) *PostsListOutput {
+	sort.Slice(posts, func(a, b int) bool { return posts[a].ID < posts[b].ID })
+	page, total := keysetPageOf(input, posts, func(p *Post) string { return p.ID })
+	return &PostsListOutput{
+		TotalCount: total,
+		Link:       input.links(total),
+		Body:       &PostsListResponse{Posts: page, Pagination: input.pagination(total)},
+	}
+}
+
+// UserIncludes asks for extras that aren't part of a user by default, and
+// for related resources to embed, JSON:API style: posts embeds the user's
+// posts, and posts.comments the comments on them too.
+type UserIncludes struct {
+	Include []string `query:"include" example:"posts.comments" doc:"Extras to add to each user, comma-separated: post_count, the number of posts they wrote; posts, their posts, oldest first; posts.comments, their posts with the published comments on them"`
+}
+
+// userIncludePaths are what ?include takes on users.
+var userIncludePaths = []string{"post_count", "posts", "posts.comments"}
+
+// maxIncludeDepth bounds how many relations an include path goes through,
+// so one request can't embed the whole store.
+const maxIncludeDepth = 2
+
+// Resolve checks the include paths.
+func (i *UserIncludes) Resolve(ctx huma.Context) []error {
+	var errs []error
+	for _, path := range i.Include {
+		switch {
+		case strings.Count(path, ".") >= maxIncludeDepth:
+			errs = append(errs, &ErrorDetail{
+				Location: "query.include",
+				Code:     "max_depth",
+				Message:  fmt.Sprintf("include paths go at most %d relations deep", maxIncludeDepth),
+				Value:    path,
+			})
+		case !slices.Contains(userIncludePaths, path):
+			errs = append(errs, &ErrorDetail{
+				Location: "query.include",
+				Code:     "enum",
+				Message:  fmt.Sprintf("expected value to be one of %q", strings.Join(userIncludePaths, ", ")),
+				Value:    path,
+			})
+		}
+	}
+	return errs
+}
+
+// has reports whether extra is included, which an include of a path
+// through it, like posts.comments for posts, implies.
+func (i UserIncludes) has(extra string) bool {
+	return slices.ContainsFunc(i.Include, func(path string) bool {
+		return path == extra || strings.HasPrefix(path, extra+".")
+	})
+}
+
+type GetUserInput struct {
+	UserIncludes
+	ID string `path:"id" example:"20240101120000" doc:"User ID"`
+}
+
+// PostService holds the rules for posts: every post has an existing author,
+// and changes publish post.created, post.updated and post.deleted with the
+// author as the subject, so the audit entries about a user cover their posts.
+type PostService struct {
+	store Store
+	bus   *events.Bus
+	idGen idgen.Generator
+}
+
+func NewPostService(store Store, bus *events.Bus, idGen idgen.Generator) *PostService {
+	return &PostService{store: store, bus: bus, idGen: idGen}
+}
+
+var errPostNotFound = apiError(http.StatusNotFound, CodePostNotFound, "Post not found")
+
+// Get loads a post, turning a missing one into a 404.
+func (p *PostService) Get(ctx context.Context, id string) (*Post, error) {
+	post, err := p.store.GetPost(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return nil, errPostNotFound
+	}
+	return post, err
+}
+
+// List returns every post, or with authorID those of that user, in no
+// particular order.
+func (p *PostService) List(ctx context.Context, authorID string) ([]*Post, error) {
+	posts, err := p.store.ListPosts(ctx)
+	if err != nil || authorID == "" {
+		return posts, err
+	}
+	return slices.DeleteFunc(posts, func(post *Post) bool { return post.AuthorID != authorID }), nil
+}
+
+// ByAuthor is List for an author that has to exist.
+func (p *PostService) ByAuthor(ctx context.Context, authorID string) ([]*Post, error) {
+	if err := authorExists(ctx, p.store, authorID); err != nil {
+		return nil, err
+	}
+	return p.List(ctx, authorID)
+}
+
+// authorExists turns a missing user into a 404.
+func authorExists(ctx context.Context, store Store, id string) error {
+	_, err := store.GetUser(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return apiError(http.StatusNotFound, CodeUserNotFound, "User not found")
+	}
+	return err
+}
+
+// Create adds a post by req.AuthorID.
+func (p *PostService) Create(ctx context.Context, req CreatePostRequest) (*Post, error) {
+	if err := authorExists(ctx, p.store, req.AuthorID); err != nil {
+		return nil, err
+	}
+	posts, err := p.store.ListPosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool, len(posts))
+	for _, post := range posts {
+		taken[post.ID] = true
+	}
+	now := time.Now()
+	post := &Post{
+		ID:        newID(p.idGen, taken, now),
+		AuthorID:  req.AuthorID,
+		Title:     req.Title,
+		Body:      req.Body,
+		CreatedAt: timestamp.From(now),
+		UpdatedAt: timestamp.From(now),
+	}
+	if err := p.putPost(ctx, post); err != nil {
+		return nil, err
+	}
+	p.bus.Publish(ctx, events.Event{Type: "post.created", Subject: post.AuthorID, Data: map[string]string{"post_id": post.ID}})
+	return post, nil
+}
+
+// Update changes the fields set in req.
+func (p *PostService) Update(ctx context.Context, id string, req UpdatePostRequest) (*Post, error) {
+	post, err := p.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	fields := []string{}
+	if req.Title != nil {
+		post.Title = *req.Title
+		fields = append(fields, "title")
+	}
+	if req.Body != nil {
+		post.Body = *req.Body
+		fields = append(fields, "body")
+	}
+	post.UpdatedAt = timestamp.Now()
+	if err := p.putPost(ctx, post); err != nil {
+		return nil, err
+	}
+	p.bus.Publish(ctx, events.Event{Type: "post.updated", Subject: post.AuthorID, Data: map[string]any{"post_id": post.ID, "fields": fields}})
+	return post, nil
+}
+
+// putPost saves post, turning an author deleted in the meantime into a 404.
+func (p *PostService) putPost(ctx context.Context, post *Post) error {
+	err := p.store.PutPost(ctx, post)
+	if errors.Is(err, ErrNotFound) {
+		return apiError(http.StatusNotFound, CodeUserNotFound, "User not found")
+	}
+	return err
+}
+
+// Delete removes the post.
+func (p *PostService) Delete(ctx context.Context, id string) error {
+	post, err := p.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = p.store.DeletePost(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return errPostNotFound
+	}
+	if err != nil {
+		return err
+	}
+	p.bus.Publish(ctx, events.Event{Type: "post.deleted", Subject: post.AuthorID, Data: map[string]string{"post_id": post.ID}})
+	return nil
+}
+
+// postIDs returns the IDs of posts.
+func postIDs(posts []*Post) []string {
+	ids := make([]string, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+// addIncludes adds the extras asked for in inc to users. Whatever the
+// number of users, it makes one store call for their posts and one for the
+// comments on those.
+func (s *Server) addIncludes(ctx context.Context, inc UserIncludes, users ...*User) error {
+	if !inc.has("post_count") && !inc.has("posts") {
+		return nil
+	}
+	all, err := s.posts.store.ListPosts(ctx)
+	if err != nil {
+		return err
+	}
+	ids := map[string]bool{}
+	for _, u := range users {
+		ids[u.ID] = true
+	}
+	byAuthor := map[string][]*Post{}
+	var posts []*Post
+	for _, p := range all {
+		if ids[p.AuthorID] {
+			byAuthor[p.AuthorID] = append(byAuthor[p.AuthorID], p)
+			posts = append(posts, p)
+		}
+	}
+	if inc.has("posts.comments") && len(posts) > 0 {
+		comments, err := s.posts.store.ListComments(ctx, postIDs(posts)...)
+		if err != nil {
+			return err
+		}
+		sort.Slice(comments, func(a, b int) bool { return comments[a].ID < comments[b].ID })
+		byPost := map[string][]*Comment{}
+		for _, c := range comments {
+			if c.Status == CommentPublished {
+				byPost[c.PostID] = append(byPost[c.PostID], c)
+			}
+		}
+		for _, p := range posts {
+			p.Comments = byPost[p.ID]
+		}
+	}
+	for _, u := range users {
+		if inc.has("post_count") {
+			n := len(byAuthor[u.ID])
+			u.PostCount = &n
+		}
+		if inc.has("posts") {
+			u.Posts = byAuthor[u.ID]
+			sort.Slice(u.Posts, func(a, b int) bool { return u.Posts[a].ID < u.Posts[b].ID })
+		}
+	}
+	return nil
+}
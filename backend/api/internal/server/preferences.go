@@ -0,0 +1,51 @@
+package server
+
+import (
+	_ "time/tzdata" // validate time zones even where the OS ships no zoneinfo
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// UserPreferences are per-user settings kept apart from the core user record.
+type UserPreferences struct {
+	Locale        string                  `json:"locale" required:"false" default:"en" pattern:"^[a-z]{2}(-[A-Z]{2})?$" example:"de-AT" doc:"BCP 47 language tag used for emails and formatted output"`
+	Timezone      string                  `json:"timezone" required:"false" default:"UTC" example:"Europe/Vienna" doc:"IANA time zone name"`
+	Notifications NotificationPreferences `json:"notifications" required:"false" doc:"Which notifications the user receives"`
+}
+
+// The toggles are pointers so an explicit false can be told apart from an
+// omitted field, which gets the default.
+type NotificationPreferences struct {
+	Email  *bool  `json:"email" required:"false" default:"true" doc:"Send notifications by email"`
+	InApp  *bool  `json:"in_app" required:"false" default:"true" doc:"Show notifications in the app"`
+	Digest string `json:"digest" required:"false" default:"weekly" enum:"off,daily,weekly" doc:"How often to send the activity digest email"`
+}
+
+// defaultPreferences is what a user gets until they save their own. It must
+// match the `default` tags above, which fill in fields omitted from a PUT.
+func defaultPreferences() *UserPreferences {
+	email, inApp := true, true
+	return &UserPreferences{
+		Locale:   "en",
+		Timezone: "UTC",
+		Notifications: NotificationPreferences{
+			Email:  &email,
+			InApp:  &inApp,
+			Digest: "weekly",
+		},
+	}
+}
+
+// Resolve checks the time zone against the tz database, which the schema
+// can't express.
+func (p *UserPreferences) Resolve(ctx huma.Context, prefix *huma.PathBuffer) []error {
+	if _, err := loadTimeZone(p.Timezone); err != nil {
+		return []error{&ErrorDetail{
+			Location: prefix.With("timezone"),
+			Code:     "format",
+			Message:  "expected an IANA time zone name",
+			Value:    p.Timezone,
+		}}
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+package server
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// apiKeyIDKey carries the ID of the API key a request authenticated with.
+type apiKeyIDKey struct{}
+
+// apiKeyID returns the ID of the API key ctx's request authenticated with,
+// or "" if it didn't use one.
+func apiKeyID(ctx context.Context) string {
+	id, _ := ctx.Value(apiKeyIDKey{}).(string)
+	return id
+}
+
+// QuotaLimits caps the requests one API key makes per calendar day and
+// month, in UTC. Zero leaves that period uncapped.
+type QuotaLimits struct {
+	Daily   int `json:"daily" minimum:"0" example:"10000" doc:"Requests allowed per UTC day; 0 for no cap"`
+	Monthly int `json:"monthly" minimum:"0" example:"200000" doc:"Requests allowed per UTC calendar month; 0 for no cap"`
+}
+
+// QuotaUsage is the requests one API key made in the current periods.
+type QuotaUsage struct {
+	Day           int            `json:"day" doc:"Requests made today"`
+	Month         int            `json:"month" doc:"Requests made this month"`
+	DayResetsAt   timestamp.Time `json:"day_resets_at" doc:"When the daily count starts over"`
+	MonthResetsAt timestamp.Time `json:"month_resets_at" doc:"When the monthly count starts over"`
+}
+
+// RateLimit is one API key's quota and how much of it is used.
+type RateLimit struct {
+	KeyID string `json:"key_id" example:"key_3f9a1c" doc:"ID of the API key"`
+	QuotaLimits
+	Usage QuotaUsage `json:"usage" readOnly:"true" doc:"Requests counted against the quota so far"`
+}
+
+// quotaCounts is one key's requests in the periods starting at day and
+// month.
+type quotaCounts struct {
+	day, month           time.Time
+	dayCount, monthCount int
+}
+
+// quotaPeriods returns the starts of the UTC day and month now is in.
+func quotaPeriods(now time.Time) (day, month time.Time) {
+	now = now.UTC()
+	day = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return day, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// QuotaMeter counts the requests each API key makes and holds them to the
+// key's quota. Keys without one are counted all the same, so their usage
+// can be looked at before capping it. Like LoginGuard it keeps everything
+// in memory, so each replica enforces quotas on the requests it serves
+// only, and a restart forgets both the quotas and the counts.
+type QuotaMeter struct {
+	now func() time.Time
+
+	mu     sync.Mutex
+	limits map[string]QuotaLimits
+	counts map[string]*quotaCounts
+}
+
+func NewQuotaMeter() *QuotaMeter {
+	return &QuotaMeter{now: time.Now, limits: map[string]QuotaLimits{}, counts: map[string]*quotaCounts{}}
+}
+
+// current returns key's counts for the periods now is in, starting them
+// over if those have moved on; nil if key made no requests.
+func (m *QuotaMeter) current(key string, now time.Time) *quotaCounts {
+	c := m.counts[key]
+	if c == nil {
+		return nil
+	}
+	day, month := quotaPeriods(now)
+	if !c.day.Equal(day) {
+		c.day, c.dayCount = day, 0
+	}
+	if !c.month.Equal(month) {
+		c.month, c.monthCount = month, 0
+	}
+	return c
+}
+
+// Take counts a request by key, unless that would go over its quota, in
+// which case it returns which period is used up and how long until it
+// starts over.
+func (m *QuotaMeter) Take(key string) (exceeded string, wait time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	c := m.current(key, now)
+	if c == nil {
+		day, month := quotaPeriods(now)
+		c = &quotaCounts{day: day, month: month}
+		m.counts[key] = c
+	}
+	limits := m.limits[key]
+	if limits.Monthly > 0 && c.monthCount >= limits.Monthly {
+		return "monthly", c.month.AddDate(0, 1, 0).Sub(now)
+	}
+	if limits.Daily > 0 && c.dayCount >= limits.Daily {
+		return "daily", c.day.AddDate(0, 0, 1).Sub(now)
+	}
+	c.dayCount++
+	c.monthCount++
+	return "", 0
+}
+
+// Get returns key's quota, zero if it has none, and usage.
+func (m *QuotaMeter) Get(key string) RateLimit {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rateLimit(key, m.limits[key])
+}
+
+func (m *QuotaMeter) rateLimit(key string, limits QuotaLimits) RateLimit {
+	now := m.now()
+	day, month := quotaPeriods(now)
+	usage := QuotaUsage{DayResetsAt: timestamp.From(day.AddDate(0, 0, 1)), MonthResetsAt: timestamp.From(month.AddDate(0, 1, 0))}
+	if c := m.current(key, now); c != nil {
+		usage.Day, usage.Month = c.dayCount, c.monthCount
+	}
+	return RateLimit{KeyID: key, QuotaLimits: limits, Usage: usage}
+}
+
+// List returns every key with a quota or requests counted, by ID.
+func (m *QuotaMeter) List() []RateLimit {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, 0, len(m.counts))
+	for key := range m.counts {
+		keys = append(keys, key)
+	}
+	for key := range m.limits {
+		if m.counts[key] == nil {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	out := make([]RateLimit, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, m.rateLimit(key, m.limits[key]))
+	}
+	return out
+}
+
+// Set replaces key's quota. Its counts carry on.
+func (m *QuotaMeter) Set(key string, limits QuotaLimits) RateLimit {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.limits[key] = limits
+	return m.rateLimit(key, limits)
+}
+
+// Delete lifts key's quota, reporting whether it had one.
+func (m *QuotaMeter) Delete(key string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.limits[key]
+	delete(m.limits, key)
+	return ok
+}
+
+// enforceQuotas counts the requests made with an API key against its
+// quota, answering 429 QUOTA_EXCEEDED once it is used up. The admin API
+// and probes are neither counted nor refused.
+func (s *Server) enforceQuotas(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := apiKeyID(r.Context())
+		if key == "" || adminPath(r.URL.Path) || probePath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if period, wait := s.quotas.Take(key); period != "" {
+			w.Header().Set("Retry-After", strconv.Itoa(max(int(math.Ceil(wait.Seconds())), 1)))
+			writeError(w, r, http.StatusTooManyRequests, CodeQuotaExceeded, quotaExceeded[period])
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// quotaExceeded is the error message for each period Take reports used up.
+var quotaExceeded = map[string]string{
+	"daily":   "the API key's daily quota is used up",
+	"monthly": "the API key's monthly quota is used up",
+}
+
+type RateLimitsInput struct {
+	AdminInput
+}
+
+type RateLimits struct {
+	RateLimits []RateLimit `json:"rate_limits" doc:"Every API key with a quota or requests counted, by ID"`
+}
+
+type RateLimitsOutput struct {
+	Body *RateLimits
+}
+
+type RateLimitInput struct {
+	AdminInput
+	KeyID string `path:"keyID" doc:"ID of the API key"`
+}
+
+type PutRateLimitInput struct {
+	AdminInput
+	KeyID string `path:"keyID" doc:"ID of the API key"`
+	Body  QuotaLimits
+}
+
+type RateLimitOutput struct {
+	Body *RateLimit
+}
+
+// listRateLimits is the get-admin-ratelimits handler.
+func (s *Server) listRateLimits(ctx context.Context, input *RateLimitsInput) (*RateLimitsOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	return &RateLimitsOutput{Body: &RateLimits{RateLimits: s.quotas.List()}}, nil
+}
+
+// getRateLimit is the get-admin-ratelimits-by-key-id handler. A key
+// without a quota still has its usage.
+func (s *Server) getRateLimit(ctx context.Context, input *RateLimitInput) (*RateLimitOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	limit := s.quotas.Get(input.KeyID)
+	return &RateLimitOutput{Body: &limit}, nil
+}
+
+// putRateLimit is the put-admin-ratelimits-by-key-id handler.
+func (s *Server) putRateLimit(ctx context.Context, input *PutRateLimitInput) (*RateLimitOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	limit := s.quotas.Set(input.KeyID, input.Body)
+	s.logger.InfoContext(ctx, "quota set", "key_id", input.KeyID, "daily", input.Body.Daily, "monthly", input.Body.Monthly)
+	return &RateLimitOutput{Body: &limit}, nil
+}
+
+// deleteRateLimit is the delete-admin-ratelimits-by-key-id handler.
+func (s *Server) deleteRateLimit(ctx context.Context, input *RateLimitInput) (*struct{}, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	if !s.quotas.Delete(input.KeyID) {
+		return nil, apiError(http.StatusNotFound, CodeNotFound, "the API key has no quota")
+	}
+	s.logger.InfoContext(ctx, "quota lifted", "key_id", input.KeyID)
+	return nil, nil
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuotaMeter(t *testing.T) {
+	m := NewQuotaMeter()
+	now := time.Date(2026, 1, 30, 23, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	m.Set("key_1", QuotaLimits{Daily: 2, Monthly: 3})
+
+	for i := range 2 {
+		if period, _ := m.Take("key_1"); period != "" {
+			t.Fatalf("request %d: %s quota used up", i+1, period)
+		}
+	}
+	if period, wait := m.Take("key_1"); period != "daily" || wait != time.Hour {
+		t.Errorf("third request today: %q quota, wait %v; want daily, 1h", period, wait)
+	}
+	if period, _ := m.Take("key_2"); period != "" {
+		t.Errorf("key without a quota: %s quota used up", period)
+	}
+
+	// The day starts over, but the month doesn't until a day later.
+	now = now.Add(2 * time.Hour)
+	if period, _ := m.Take("key_1"); period != "" {
+		t.Errorf("first request of the next day: %s quota used up", period)
+	}
+	if period, wait := m.Take("key_1"); period != "monthly" || wait != 23*time.Hour {
+		t.Errorf("fourth request this month: %q quota, wait %v; want monthly, 23h", period, wait)
+	}
+	if got := m.Get("key_1").Usage; got.Day != 1 || got.Month != 3 {
+		t.Errorf("usage = %d today, %d this month; want 1 and 3, refused requests not counted", got.Day, got.Month)
+	}
+
+	if !m.Delete("key_1") || m.Delete("key_1") {
+		t.Error("Delete didn't report the quota it lifted, only that")
+	}
+	if period, _ := m.Take("key_1"); period != "" {
+		t.Errorf("after lifting the quota: %s quota used up", period)
+	}
+	if got := m.List(); len(got) != 2 || got[0].KeyID != "key_1" || got[0].Usage.Day != 2 {
+		t.Errorf("List() = %+v, want both keys, still counted", got)
+	}
+}
+
+func TestEnforceQuotas(t *testing.T) {
+	s := NewServer(Config{}, NewMemoryStore())
+	s.quotas.Set("key_1", QuotaLimits{Daily: 1})
+	h := s.enforceQuotas(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for _, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		r := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+		r = r.WithContext(context.WithValue(r.Context(), apiKeyIDKey{}, "key_1"))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != want {
+			t.Fatalf("status %d, want %d", w.Code, want)
+		}
+		if want == http.StatusTooManyRequests && (w.Header().Get("Retry-After") == "" || !strings.Contains(w.Body.String(), string(CodeQuotaExceeded))) {
+			t.Errorf("refused without Retry-After or %s: %s", CodeQuotaExceeded, w.Body)
+		}
+	}
+}
@@ -0,0 +1,378 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+)
+
+// ErrNotLeader is returned by RaftStore writes on a replica that isn't the
+// leader. The HTTP layer forwards writes to the leader, so clients only see
+// it while no leader is elected.
+var ErrNotLeader = errors.New("this replica is not the raft leader")
+
+// raftApplyTimeout bounds how long a write waits to be committed.
+const raftApplyTimeout = 5 * time.Second
+
+// RaftPeer is one member of the cluster.
+type RaftPeer struct {
+	ID   string
+	Addr string // host:port of its raft transport
+}
+
+// RaftConfig joins a RaftStore to a cluster.
+type RaftConfig struct {
+	NodeID string
+	// BindAddr is where the raft transport listens, the node's own peer
+	// address if empty.
+	BindAddr string
+	Peers    []RaftPeer
+	// HTTPPort is the port every replica serves the API on, used to forward
+	// writes to the leader's host.
+	HTTPPort string
+}
+
+// RaftStore is an experimental Store replicated across the peers with raft.
+// Each replica keeps the full data set in a local MemoryStore: reads are
+// served from it and may briefly lag the leader, and writes are committed
+// through the raft log, so they only succeed on the leader. Raft's own log
+// is kept in memory too; a restarted replica catches up from the others.
+type RaftStore struct {
+	raft    *raft.Raft
+	local   *MemoryStore
+	peers   map[raft.ServerID]string // raft address by ID
+	cfg     RaftConfig
+	forward *httputil.ReverseProxy
+}
+
+// NewRaftStore starts this node's raft replica on top of local and
+// bootstraps the cluster from cfg.Peers. Every node is started with the
+// same peer list, so whichever comes up first can bootstrap and the rest
+// just join.
+func NewRaftStore(cfg RaftConfig, local *MemoryStore) (*RaftStore, error) {
+	var self string
+	for _, p := range cfg.Peers {
+		if p.ID == cfg.NodeID {
+			self = p.Addr
+		}
+	}
+	if self == "" {
+		return nil, fmt.Errorf("node %q is not in the peer list", cfg.NodeID)
+	}
+	advertise, err := net.ResolveTCPAddr("tcp", self)
+	if err != nil {
+		return nil, fmt.Errorf("resolve raft address %s: %w", self, err)
+	}
+	bind := cfg.BindAddr
+	if bind == "" {
+		bind = self
+	}
+	transport, err := raft.NewTCPTransport(bind, advertise, 3, 10*time.Second, os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("start raft transport: %w", err)
+	}
+	return newRaftStore(cfg, local, transport)
+}
+
+// newRaftStore is NewRaftStore on transport; tests connect theirs in memory.
+func newRaftStore(cfg RaftConfig, local *MemoryStore, transport raft.Transport) (*RaftStore, error) {
+	peers := map[raft.ServerID]string{}
+	var servers []raft.Server
+	for _, p := range cfg.Peers {
+		peers[raft.ServerID(p.ID)] = p.Addr
+		servers = append(servers, raft.Server{ID: raft.ServerID(p.ID), Address: raft.ServerAddress(p.Addr)})
+	}
+	if _, ok := peers[raft.ServerID(cfg.NodeID)]; !ok {
+		return nil, fmt.Errorf("node %q is not in the peer list", cfg.NodeID)
+	}
+
+	rc := raft.DefaultConfig()
+	rc.LocalID = raft.ServerID(cfg.NodeID)
+	rc.Logger = hclog.New(&hclog.LoggerOptions{Name: "raft", Level: hclog.Warn, Output: os.Stderr})
+	logs := raft.NewInmemStore()
+	r, err := raft.NewRaft(rc, &raftFSM{local}, logs, logs, raft.NewInmemSnapshotStore(), transport)
+	if err != nil {
+		return nil, fmt.Errorf("start raft: %w", err)
+	}
+	err = r.BootstrapCluster(raft.Configuration{Servers: servers}).Error()
+	if err != nil && !errors.Is(err, raft.ErrCantBootstrap) {
+		return nil, fmt.Errorf("bootstrap raft cluster: %w", err)
+	}
+
+	s := &RaftStore{raft: r, local: local, peers: peers, cfg: cfg}
+	s.forward = &httputil.ReverseProxy{Rewrite: s.rewriteToLeader}
+	return s, nil
+}
+
+func (s *RaftStore) GetUser(ctx context.Context, id string) (*User, error) {
+	return s.local.GetUser(ctx, id)
+}
+
+func (s *RaftStore) ListUsers(ctx context.Context) ([]*User, error) {
+	return s.local.ListUsers(ctx)
+}
+
+func (s *RaftStore) PutUser(ctx context.Context, user *User) error {
+	return s.apply(walRecord{Op: walPutUser, User: user})
+}
+
+func (s *RaftStore) DeleteUser(ctx context.Context, id string) error {
+	if _, err := s.local.GetUser(ctx, id); err != nil {
+		return err
+	}
+	return s.apply(walRecord{Op: walDeleteUser, ID: id})
+}
+
+func (s *RaftStore) GetPreferences(ctx context.Context, userID string) (*UserPreferences, error) {
+	return s.local.GetPreferences(ctx, userID)
+}
+
+func (s *RaftStore) PutPreferences(ctx context.Context, userID string, prefs *UserPreferences) error {
+	return s.apply(walRecord{Op: walPutPreferences, ID: userID, Preferences: prefs})
+}
+
+func (s *RaftStore) GetCredentials(ctx context.Context, userID string) (*Credentials, error) {
+	return s.local.GetCredentials(ctx, userID)
+}
+
+func (s *RaftStore) PutCredentials(ctx context.Context, userID string, creds *Credentials) error {
+	return s.apply(walRecord{Op: walPutCredentials, ID: userID, Credentials: creds})
+}
+
+func (s *RaftStore) GetPost(ctx context.Context, id string) (*Post, error) {
+	return s.local.GetPost(ctx, id)
+}
+
+func (s *RaftStore) ListPosts(ctx context.Context) ([]*Post, error) {
+	return s.local.ListPosts(ctx)
+}
+
+func (s *RaftStore) PutPost(ctx context.Context, post *Post) error {
+	if _, err := s.local.GetUser(ctx, post.AuthorID); err != nil {
+		return err
+	}
+	return s.apply(walRecord{Op: walPutPost, Post: post})
+}
+
+func (s *RaftStore) DeletePost(ctx context.Context, id string) error {
+	if _, err := s.local.GetPost(ctx, id); err != nil {
+		return err
+	}
+	return s.apply(walRecord{Op: walDeletePost, ID: id})
+}
+
+func (s *RaftStore) GetComment(ctx context.Context, id string) (*Comment, error) {
+	return s.local.GetComment(ctx, id)
+}
+
+func (s *RaftStore) ListComments(ctx context.Context, postIDs ...string) ([]*Comment, error) {
+	return s.local.ListComments(ctx, postIDs...)
+}
+
+func (s *RaftStore) PutComment(ctx context.Context, comment *Comment) error {
+	if _, err := s.local.GetPost(ctx, comment.PostID); err != nil {
+		return err
+	}
+	if _, err := s.local.GetUser(ctx, comment.AuthorID); err != nil {
+		return err
+	}
+	return s.apply(walRecord{Op: walPutComment, Comment: comment})
+}
+
+func (s *RaftStore) DeleteComment(ctx context.Context, id string) error {
+	if _, err := s.local.GetComment(ctx, id); err != nil {
+		return err
+	}
+	return s.apply(walRecord{Op: walDeleteComment, ID: id})
+}
+
+func (s *RaftStore) GetInvitation(ctx context.Context, id string) (*StoredInvitation, error) {
+	return s.local.GetInvitation(ctx, id)
+}
+
+func (s *RaftStore) ListInvitations(ctx context.Context) ([]*StoredInvitation, error) {
+	return s.local.ListInvitations(ctx)
+}
+
+func (s *RaftStore) PutInvitation(ctx context.Context, inv *StoredInvitation) error {
+	return s.apply(walRecord{Op: walPutInvitation, Invitation: inv})
+}
+
+func (s *RaftStore) DeleteInvitation(ctx context.Context, id string) error {
+	if _, err := s.local.GetInvitation(ctx, id); err != nil {
+		return err
+	}
+	return s.apply(walRecord{Op: walDeleteInvitation, ID: id})
+}
+
+// OnEvict passes fn on to the local replica.
+func (s *RaftStore) OnEvict(fn func(reason string)) {
+	s.local.OnEvict(fn)
+}
+
+// Close hands leadership to another replica, if this one leads, and leaves
+// the cluster, so a rolling restart never waits out an election timeout.
+func (s *RaftStore) Close() error {
+	if s.raft.State() == raft.Leader && len(s.peers) > 1 {
+		_ = s.raft.LeadershipTransfer().Error()
+	}
+	return s.raft.Shutdown().Error()
+}
+
+// apply commits rec through the raft log and waits until this replica has
+// applied it.
+func (s *RaftStore) apply(rec walRecord) error {
+	if s.raft.State() != raft.Leader {
+		return ErrNotLeader
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	f := s.raft.Apply(b, raftApplyTimeout)
+	if err := f.Error(); err != nil {
+		if errors.Is(err, raft.ErrNotLeader) || errors.Is(err, raft.ErrLeadershipLost) {
+			return ErrNotLeader
+		}
+		return err
+	}
+	if err, ok := f.Response().(error); ok {
+		return err
+	}
+	return nil
+}
+
+// forwardHeader marks a request one replica forwarded to another, so a
+// stale view of who leads can't bounce it around.
+const forwardHeader = "X-Raft-Forwarded"
+
+// forwardWrites sends requests that change data to the leader, which is the
+// only replica that can commit them. Reads are served locally.
+func (s *RaftStore) forwardWrites(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		if s.raft.State() == raft.Leader {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if _, ok := s.leaderURL(); !ok || r.Header.Get(forwardHeader) != "" {
+			w.Header().Set("Retry-After", "1")
+			writeError(w, r, http.StatusServiceUnavailable, CodeNoLeader, "no raft leader; retry shortly")
+			return
+		}
+		s.forward.ServeHTTP(w, r)
+	})
+}
+
+func (s *RaftStore) rewriteToLeader(pr *httputil.ProxyRequest) {
+	leader, _ := s.leaderURL()
+	pr.SetURL(leader)
+	pr.SetXForwarded()
+	pr.Out.Header.Set(forwardHeader, s.cfg.NodeID)
+}
+
+// IsLeader reports whether this replica is the raft leader.
+func (s *RaftStore) IsLeader() bool {
+	return s.raft.State() == raft.Leader
+}
+
+// Leader returns the node ID of the raft leader, or "" while there is none.
+func (s *RaftStore) Leader(context.Context) (string, error) {
+	_, id := s.raft.LeaderWithID()
+	return string(id), nil
+}
+
+// leaderURL is the API base URL of the current leader: its raft host on the
+// shared HTTP port.
+func (s *RaftStore) leaderURL() (*url.URL, bool) {
+	_, id := s.raft.LeaderWithID()
+	addr, ok := s.peers[id]
+	if !ok {
+		return nil, false
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, false
+	}
+	return &url.URL{Scheme: "http", Host: net.JoinHostPort(host, s.cfg.HTTPPort)}, true
+}
+
+// ParseRaftPeers parses RAFT_PEERS, e.g. "api-0=api-0.api:7000,api-1=api-1.api:7000".
+func ParseRaftPeers(s string) ([]RaftPeer, error) {
+	var peers []RaftPeer
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, addr, ok := strings.Cut(entry, "=")
+		if !ok || id == "" || addr == "" {
+			return nil, fmt.Errorf("peer %q: want id=host:port", entry)
+		}
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return nil, fmt.Errorf("peer %q: %w", entry, err)
+		}
+		peers = append(peers, RaftPeer{ID: id, Addr: addr})
+	}
+	return peers, nil
+}
+
+// raftFSM applies the committed log to a replica's MemoryStore. Entries are
+// write-ahead log records, and snapshots are store snapshots.
+type raftFSM struct {
+	m *MemoryStore
+}
+
+func (f *raftFSM) Apply(l *raft.Log) any {
+	var rec walRecord
+	if err := json.Unmarshal(l.Data, &rec); err != nil {
+		return err
+	}
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	// Every replica has the same data at this point in the log, so they
+	// all agree on a conflict.
+	if err := f.m.conflict(rec); err != nil {
+		return err
+	}
+	return f.m.apply(rec)
+}
+
+func (f *raftFSM) Snapshot() (raft.FSMSnapshot, error) {
+	f.m.mu.RLock()
+	defer f.m.mu.RUnlock()
+	return raftSnapshot(f.m.snapshot()), nil
+}
+
+func (f *raftFSM) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+	return f.m.ReadSnapshot(rc)
+}
+
+type raftSnapshot snapshot
+
+func (s raftSnapshot) Persist(sink raft.SnapshotSink) error {
+	if err := writeSnapshot(sink, snapshot(s)); err != nil {
+		sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+func (raftSnapshot) Release() {}
@@ -0,0 +1,213 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// raftNode is one replica of a test cluster: its store, and the API it
+// serves on the cluster's HTTP port of its own loopback address.
+type raftNode struct {
+	store *RaftStore
+	api   *httptest.Server
+}
+
+// raftCluster starts n replicas, connected by in-memory raft transports,
+// and waits for them to elect a leader. Node i's raft address is
+// 127.0.0.<i+1>:7000, so it serves the API on 127.0.0.<i+1>, where the
+// others forward their writes to.
+func raftCluster(t *testing.T, n int) []*raftNode {
+	t.Helper()
+	probe, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, port, _ := net.SplitHostPort(probe.Addr().String())
+	probe.Close()
+
+	var peers []RaftPeer
+	transports := make([]*raft.InmemTransport, n)
+	for i := range n {
+		peers = append(peers, RaftPeer{ID: fmt.Sprint("api-", i), Addr: fmt.Sprintf("127.0.0.%d:7000", i+1)})
+		_, transports[i] = raft.NewInmemTransport(raft.ServerAddress(peers[i].Addr))
+	}
+	for i, a := range transports {
+		for j, b := range transports {
+			if i != j {
+				a.Connect(raft.ServerAddress(peers[j].Addr), b)
+			}
+		}
+	}
+	nodes := make([]*raftNode, n)
+	for i := range n {
+		rs, err := newRaftStore(RaftConfig{NodeID: peers[i].ID, Peers: peers, HTTPPort: port}, NewMemoryStore(), transports[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		ln, err := net.Listen("tcp", fmt.Sprintf("127.0.0.%d:%s", i+1, port))
+		if err != nil {
+			t.Fatal(err)
+		}
+		api := httptest.NewUnstartedServer(NewServer(Config{}, rs).Handler())
+		api.Listener.Close()
+		api.Listener = ln
+		api.Start()
+		nodes[i] = &raftNode{store: rs, api: api}
+		t.Cleanup(func() {
+			api.Close()
+			rs.raft.Shutdown()
+		})
+	}
+	raftLeader(t, nodes)
+	return nodes
+}
+
+// raftLeader waits for one of nodes to lead and returns it.
+func raftLeader(t *testing.T, nodes []*raftNode) *raftNode {
+	t.Helper()
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		for _, n := range nodes {
+			if n.store.IsLeader() {
+				return n
+			}
+		}
+	}
+	t.Fatal("no raft leader after 10s")
+	return nil
+}
+
+// eventually polls cond until it holds, failing the test after 5s.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !cond(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s: not after 5s", what)
+		}
+	}
+}
+
+func TestRaftStore(t *testing.T) {
+	ctx := context.Background()
+	nodes := raftCluster(t, 3)
+	leader := raftLeader(t, nodes)
+	var followers []*raftNode
+	for _, n := range nodes {
+		if n != leader {
+			followers = append(followers, n)
+		}
+	}
+
+	// The leader's writes are applied on every replica; a follower's fail.
+	if err := leader.store.PutUser(ctx, &User{ID: "a", Name: "Ada", Status: UserStatusActive, Active: true}); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range followers {
+		eventually(t, "Ada on "+f.store.cfg.NodeID, func() bool {
+			u, err := f.store.GetUser(ctx, "a")
+			return err == nil && u.Name == "Ada"
+		})
+	}
+	if err := followers[0].store.PutUser(ctx, &User{ID: "b", Name: "Grace"}); !errors.Is(err, ErrNotLeader) {
+		t.Errorf("write on a follower: %v, want ErrNotLeader", err)
+	}
+
+	// A follower forwards writes sent to it to the leader, and serves reads
+	// itself.
+	resp, err := http.Post(followers[0].api.URL+"/v1/users", "application/json", strings.NewReader(`{"name": "Grace", "email": "grace@example.com"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("POST /v1/users on a follower answered %d", resp.StatusCode)
+	}
+	var grace *User
+	eventually(t, "Grace on the leader", func() bool {
+		users, _ := leader.store.ListUsers(ctx)
+		for _, u := range users {
+			if u.Name == "Grace" {
+				grace = u
+			}
+		}
+		return grace != nil
+	})
+	eventually(t, "Grace served by the other follower", func() bool {
+		resp, err := http.Get(followers[1].api.URL + "/v1/users/" + grace.ID)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	})
+
+	// A snapshot restored on a fresh replica holds the same data, which the
+	// log then goes on from.
+	if err := leader.store.raft.Snapshot().Error(); err != nil {
+		t.Fatal(err)
+	}
+	snap, err := (&raftFSM{leader.store.local}).Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &bufferSink{}
+	if err := snap.Persist(sink); err != nil {
+		t.Fatal(err)
+	}
+	restored := NewMemoryStore()
+	fsm := &raftFSM{restored}
+	if err := fsm.Restore(io.NopCloser(&sink.buf)); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"a", grace.ID} {
+		if _, err := restored.GetUser(ctx, id); err != nil {
+			t.Errorf("user %s after restoring the snapshot: %v", id, err)
+		}
+	}
+	entry, _ := json.Marshal(walRecord{Op: walPutUser, ID: "c", User: &User{ID: "c", Name: "Kim"}})
+	if err, ok := fsm.Apply(&raft.Log{Data: entry}).(error); ok && err != nil {
+		t.Fatal(err)
+	}
+	if u, err := restored.GetUser(ctx, "c"); err != nil || u.Name != "Kim" {
+		t.Errorf("user c applied after the restore: %v, %v", u, err)
+	}
+
+	// Without a leader, writes get a 503 to retry.
+	leader.store.raft.Shutdown().Error()
+	followers[1].store.raft.Shutdown().Error()
+	last := followers[0]
+	eventually(t, "no leader", func() bool {
+		id, _ := last.store.Leader(ctx)
+		return id == ""
+	})
+	resp, err = http.Post(last.api.URL+"/v1/users", "application/json", strings.NewReader(`{"name": "Kim", "email": "kim@example.com"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") == "" || !bytes.Contains(body, []byte(CodeNoLeader)) {
+		t.Errorf("POST /v1/users without a leader: %d, Retry-After %q, %s; want a 503 NO_LEADER to retry", resp.StatusCode, resp.Header.Get("Retry-After"), body)
+	}
+}
+
+// bufferSink is a raft.SnapshotSink in memory.
+type bufferSink struct {
+	buf bytes.Buffer
+}
+
+func (s *bufferSink) Write(p []byte) (int, error) { return s.buf.Write(p) }
+func (s *bufferSink) Close() error                { return nil }
+func (s *bufferSink) ID() string                  { return "test" }
+func (s *bufferSink) Cancel() error               { return nil }
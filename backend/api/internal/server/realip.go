@@ -0,0 +1,147 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// TrustedProxies are the peers whose X-Forwarded-For and Forwarded headers
+// resolveClientIP believes.
+type TrustedProxies struct {
+	Prefixes []netip.Prefix
+	// Unix trusts whatever connects over a Unix socket, such as a sidecar
+	// proxy.
+	Unix bool
+}
+
+// ParseTrustedProxies parses a comma-separated list of CIDRs and addresses,
+// and "unix" for peers on a Unix socket.
+func ParseTrustedProxies(s string) (TrustedProxies, error) {
+	var t TrustedProxies
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		switch {
+		case entry == "":
+			continue
+		case entry == "unix":
+			t.Unix = true
+			continue
+		}
+		prefix, err := parsePrefix(entry)
+		if err != nil {
+			return t, fmt.Errorf("%s: want a CIDR, an address or unix", entry)
+		}
+		t.Prefixes = append(t.Prefixes, prefix)
+	}
+	return t, nil
+}
+
+func (t TrustedProxies) trusts(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range t.Prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveClientIP replaces the request's RemoteAddr with the client's
+// address, so the login throttle, CAPTCHA checks, audit events and logs all
+// see the client rather than the proxy in front of it. Only a trusted peer's
+// forwarding headers are believed, and only as far back as the chain of
+// trusted proxies goes: the client is the last address before the first
+// hop that isn't trusted, as any proxy can prepend whatever it likes.
+// Forwarded is used if present, otherwise X-Forwarded-For.
+func (s *Server) resolveClientIP(next http.Handler) http.Handler {
+	trusted := s.cfg.TrustedProxies
+	if len(trusted.Prefixes) == 0 && !trusted.Unix {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peer, ok := peerAddr(r)
+		if ok && !trusted.trusts(peer) || !ok && !(trusted.Unix && isUnixPeer(r)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		hops := forwardedFor(r.Header)
+		client := netip.Addr{}
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, err := parseHop(hops[i])
+			if err != nil {
+				// An obfuscated or garbled hop: nothing before it can be
+				// vouched for.
+				break
+			}
+			client = addr
+			if !trusted.trusts(addr) {
+				break
+			}
+		}
+		if client.IsValid() {
+			r.RemoteAddr = client.Unmap().String()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// remoteHost is addr, a RemoteAddr, without its port, if it has one.
+func remoteHost(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// peerAddr is the address of whatever r's connection came from.
+func peerAddr(r *http.Request) (netip.Addr, bool) {
+	addrPort, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addrPort.Addr(), true
+}
+
+// isUnixPeer reports whether r came in over a Unix socket.
+func isUnixPeer(r *http.Request) bool {
+	addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	return ok && addr.Network() == "unix"
+}
+
+// forwardedFor returns the addresses requests were forwarded for, client
+// first, from the Forwarded header's for= parameters or else from
+// X-Forwarded-For.
+func forwardedFor(h http.Header) []string {
+	var hops []string
+	for _, line := range h.Values("Forwarded") {
+		for _, element := range strings.Split(line, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+				if strings.EqualFold(key, "for") {
+					hops = append(hops, strings.Trim(value, `"`))
+				}
+			}
+		}
+	}
+	if len(hops) > 0 {
+		return hops
+	}
+	for _, line := range h.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(line, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	return hops
+}
+
+// parseHop parses an address from a forwarding header, with or without a
+// port, and with IPv6 in brackets or not.
+func parseHop(hop string) (netip.Addr, error) {
+	if addrPort, err := netip.ParseAddrPort(hop); err == nil {
+		return addrPort.Addr(), nil
+	}
+	return netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(hop, "["), "]"))
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/go-chi/chi/v5"
+)
+
+// anyMethod is the method of a route that takes every method, like a raw
+// chi handler registered with Handle.
+const anyMethod = "*"
+
+// pathParam matches a chi path parameter, with or without a pattern:
+// {id} or {id:[0-9]+}.
+var pathParam = regexp.MustCompile(`\{[^}]*\}`)
+
+// routeRegistry records every route registered on the router, huma
+// operations and raw chi handlers alike, so that two registrations of the
+// same method and path fail the server's start. chi would let the later one
+// replace the earlier without a word, leaving it shadowed. Paths are
+// compared with their parameters' names dropped, as chi matches them:
+// /v1/users/{id} and /v1/users/{user_id} are the same route. Operation IDs
+// must be unique too, or the spec and the generated clients break.
+type routeRegistry struct {
+	router chi.Router
+	// claims maps each path, normalized, and method to what registered
+	// it.
+	claims       map[string]map[string]routeClaim
+	operationIDs map[string]string // to the route of the operation
+	errs         []error
+}
+
+// routeClaim is a registered route: its path as registered, and what
+// registered it.
+type routeClaim struct {
+	pattern, owner string
+}
+
+func newRouteRegistry(router chi.Router) *routeRegistry {
+	return &routeRegistry{router: router, claims: map[string]map[string]routeClaim{}, operationIDs: map[string]string{}}
+}
+
+// claim records that owner registered method on pattern, noting a conflict
+// with whatever registered it first.
+func (r *routeRegistry) claim(method, pattern, owner string) {
+	path := pathParam.ReplaceAllString(pattern, "{}")
+	methods := r.claims[path]
+	if methods == nil {
+		methods = map[string]routeClaim{}
+		r.claims[path] = methods
+	}
+	for m, first := range methods {
+		if m == method || m == anyMethod || method == anyMethod {
+			r.errs = append(r.errs, fmt.Errorf("%s %s of %s is already registered as %s %s of %s", method, pattern, owner, m, first.pattern, first.owner))
+			return
+		}
+	}
+	methods[method] = routeClaim{pattern: pattern, owner: owner}
+}
+
+// handle registers h for every method on pattern, like chi's Handle.
+func (r *routeRegistry) handle(pattern string, h http.Handler) {
+	r.claim(anyMethod, pattern, "a chi handler")
+	r.router.Handle(pattern, h)
+}
+
+// adapter wraps the huma adapter of the router so its operations are
+// claimed as they are registered, huma's own spec and docs routes included.
+func (r *routeRegistry) adapter(a huma.Adapter) huma.Adapter {
+	return registryAdapter{Adapter: a, routes: r}
+}
+
+type registryAdapter struct {
+	huma.Adapter
+	routes *routeRegistry
+}
+
+func (a registryAdapter) Handle(op *huma.Operation, handler func(huma.Context)) {
+	route := op.Method + " " + op.Path
+	if id := op.OperationID; id != "" {
+		if first, ok := a.routes.operationIDs[id]; ok {
+			a.routes.errs = append(a.routes.errs, fmt.Errorf("operation ID %s of %s is taken by %s", id, route, first))
+		}
+		a.routes.operationIDs[id] = route
+	}
+	a.routes.claim(strings.ToUpper(op.Method), op.Path, cmp.Or(op.OperationID, "huma's "+route))
+	a.Adapter.Handle(op, handler)
+}
+
+// err is every conflict found, or nil.
+func (r *routeRegistry) err() error {
+	if len(r.errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("server: conflicting routes: %w", errors.Join(r.errs...))
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humachi"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRouteRegistry(t *testing.T) {
+	router := chi.NewRouter()
+	routes := newRouteRegistry(router)
+	api := huma.NewAPI(huma.DefaultConfig("Test", "1.0.0"), routes.adapter(humachi.NewAdapter(router)))
+	register := func(id, method, path string) {
+		huma.Register(api, huma.Operation{OperationID: id, Method: method, Path: path}, func(ctx context.Context, input *struct{}) (*struct{}, error) {
+			return nil, nil
+		})
+	}
+	register("get-v1-users-by-id", http.MethodGet, "/v1/users/{id}")
+	register("put-v1-users-by-id", http.MethodPut, "/v1/users/{id}")
+	register("get-v1-users-stream", http.MethodGet, "/v1/users/stream")
+	routes.handle("/metrics", http.NotFoundHandler())
+	if err := routes.err(); err != nil {
+		t.Fatalf("distinct routes: %v", err)
+	}
+
+	register("get-v1-users-by-user-id", http.MethodGet, "/v1/users/{user_id}")
+	register("get-metrics", http.MethodGet, "/metrics")
+	register("get-v1-users-by-id", http.MethodGet, "/v2/users/{id}")
+	err := routes.err()
+	for _, want := range []string{
+		"GET /v1/users/{user_id} of get-v1-users-by-user-id is already registered as GET /v1/users/{id} of get-v1-users-by-id",
+		"GET /metrics of get-metrics is already registered as * /metrics of a chi handler",
+		"operation ID get-v1-users-by-id of GET /v2/users/{id} is taken by GET /v1/users/{id}",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("conflicts %v, want %q", err, want)
+		}
+	}
+}
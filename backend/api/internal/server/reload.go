@@ -0,0 +1,127 @@
+package server
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// Reload applies the settings in cfg that can change while the server is
+// running: the log level, the CORS policies, USER_PHONE_UNIQUE, the slow
+// request threshold, maintenance mode and the IP allow and deny lists. A
+// changed log level or maintenance mode replaces one set through the admin
+// API. It logs and returns one line per setting that changed. The other
+// settings, the fields of Config not tagged reload:"live", only take effect
+// on restart, so the names of those that changed are logged as a warning
+// and the changes otherwise ignored.
+func (s *Server) Reload(cfg Config) []string {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	var changed []string
+	if cfg.LogLevel != s.cfg.LogLevel {
+		changed = append(changed, fmt.Sprintf("log level %s -> %s", s.cfg.LogLevel, cfg.LogLevel))
+		// A new configured level also ends any admin override.
+		if s.levelRevert != nil {
+			s.levelRevert.Stop()
+			s.levelRevert = nil
+		}
+		s.logLevel.Set(cfg.LogLevel)
+		s.cfg.LogLevel = cfg.LogLevel
+	}
+	if !cfg.CORS.equal(s.cfg.CORS) || !sameCORS(cfg.AdminCORS, s.cfg.AdminCORS) {
+		if !cfg.CORS.equal(s.cfg.CORS) {
+			changed = append(changed, fmt.Sprintf("CORS policy %+v -> %+v", s.cfg.CORS, cfg.CORS))
+		}
+		if !sameCORS(cfg.AdminCORS, s.cfg.AdminCORS) {
+			changed = append(changed, fmt.Sprintf("admin CORS policy %s -> %s", adminCORSString(s.cfg.AdminCORS), adminCORSString(cfg.AdminCORS)))
+		}
+		s.cfg.CORS, s.cfg.AdminCORS = cfg.CORS, cfg.AdminCORS
+		s.cors.Store(newCORSPolicies(s.cfg))
+	}
+	if cfg.UniquePhones != s.cfg.UniquePhones {
+		changed = append(changed, fmt.Sprintf("unique phones %t -> %t", s.cfg.UniquePhones, cfg.UniquePhones))
+		s.users.SetUniquePhones(cfg.UniquePhones)
+		s.cfg.UniquePhones = cfg.UniquePhones
+	}
+
+	if cfg.SlowRequestThreshold != s.cfg.SlowRequestThreshold {
+		changed = append(changed, fmt.Sprintf("slow request threshold %s -> %s", s.cfg.SlowRequestThreshold, cfg.SlowRequestThreshold))
+		s.slowThreshold.Store(int64(cfg.SlowRequestThreshold))
+		s.cfg.SlowRequestThreshold = cfg.SlowRequestThreshold
+	}
+	if cfg.MaintenanceRetryAfter != s.cfg.MaintenanceRetryAfter {
+		changed = append(changed, fmt.Sprintf("maintenance retry-after %s -> %s", s.cfg.MaintenanceRetryAfter, cfg.MaintenanceRetryAfter))
+		s.cfg.MaintenanceRetryAfter = cfg.MaintenanceRetryAfter
+	}
+	if cfg.Maintenance != s.cfg.Maintenance {
+		changed = append(changed, fmt.Sprintf("maintenance %s -> %s", cmp.Or(s.cfg.Maintenance, MaintenanceOff), cmp.Or(cfg.Maintenance, MaintenanceOff)))
+		// Like the log level, a changed setting replaces one made through
+		// the admin API.
+		s.setMaintenance(cmp.Or(cfg.Maintenance, MaintenanceOff), "", 0)
+		s.cfg.Maintenance = cfg.Maintenance
+	}
+
+	if !cfg.IPAccess.equal(s.cfg.IPAccess) {
+		changed = append(changed, "IP allow and deny lists")
+		s.ipAccess.Store(&cfg.IPAccess)
+		s.cfg.IPAccess = cfg.IPAccess
+	}
+
+	if len(changed) == 0 {
+		s.logger.Info("config reloaded, nothing changed")
+	} else {
+		s.logger.Info("config reloaded", "changed", changed)
+	}
+	if restart := restartSettings(s.cfg, cfg); len(restart) > 0 {
+		s.logger.Warn("config changes need a restart", "settings", restart)
+	}
+	return changed
+}
+
+// sameSettings compares the fields of Config that reflect.DeepEqual can't
+// tell apart by their settings.
+var sameSettings = map[string]func(a, b Config) bool{
+	"Addr":           func(a, b Config) bool { return cmp.Or(a.Addr, ":8080") == cmp.Or(b.Addr, ":8080") },
+	"MetadataSchema": func(a, b Config) bool { return sameSchema(a.MetadataSchema, b.MetadataSchema) },
+	"IDGenerator":    func(a, b Config) bool { return sameIDGenerator(a.IDGenerator, b.IDGenerator) },
+}
+
+// restartSettings returns the names of the fields of next, in Config's
+// order, that differ from cur and only take effect on restart, then those
+// of the changed variables the resources that ConfigFromEnv builds come
+// from.
+func restartSettings(cur, next Config) []string {
+	a, b := reflect.ValueOf(cur), reflect.ValueOf(next)
+	var names []string
+	for i := range a.NumField() {
+		f := a.Type().Field(i)
+		if !f.IsExported() || f.Tag.Get("reload") != "" {
+			continue
+		}
+		same, ok := sameSettings[f.Name]
+		if ok && !same(cur, next) || !ok && !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			names = append(names, f.Name)
+		}
+	}
+	for _, key := range resourceEnvKeys {
+		if cur.resourceEnv[key] != next.resourceEnv[key] {
+			names = append(names, key)
+		}
+	}
+	return names
+}
+
+// sameSchema reports whether a and b describe the same JSON Schema.
+func sameSchema(a, b *huma.Schema) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	s := NewServer(Config{AdminToken: "old", SlowRequestThreshold: time.Second}, NewMemoryStore())
+	var logs bytes.Buffer
+	s.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+	cfg := s.cfg
+	cfg.LogLevel = slog.LevelDebug
+	cfg.UniquePhones = true
+	cfg.SlowRequestThreshold = 2 * time.Second
+	cfg.AdminToken = "new"
+	cfg.SentryDSN = "https://key@sentry.example.com/1"
+	cfg.StoreSnapshotInterval = time.Hour
+	changed := s.Reload(cfg)
+	if len(changed) != 3 {
+		t.Errorf("changed %q, want the log level, unique phones and slow request threshold", changed)
+	}
+	if s.logLevel.Level() != slog.LevelDebug || !s.users.uniquePhones.Load() || time.Duration(s.slowThreshold.Load()) != 2*time.Second {
+		t.Error("the live settings weren't applied")
+	}
+	// The others are warned about, by name, and left as they were.
+	if want := "settings=\"[AdminToken SentryDSN StoreSnapshotInterval]\""; !strings.Contains(logs.String(), want) {
+		t.Errorf("logs %s, want a warning with %s", logs.String(), want)
+	}
+	if s.cfg.AdminToken != "old" {
+		t.Errorf("admin token %q, want the old one until a restart", s.cfg.AdminToken)
+	}
+
+	logs.Reset()
+	if changed := s.Reload(s.cfg); len(changed) != 0 || strings.Contains(logs.String(), "restart") {
+		t.Errorf("reloading the same config: %q, %s", changed, logs.String())
+	}
+}
+
+// TestReloadComparesEveryField checks that a change to any field Reload
+// doesn't apply is warned about, so fields added to Config later are too.
+func TestReloadComparesEveryField(t *testing.T) {
+	typ := reflect.TypeFor[Config]()
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		if !f.IsExported() || f.Tag.Get("reload") != "" {
+			continue
+		}
+		if _, special := sameSettings[f.Name]; special {
+			continue // compared by what they were built from
+		}
+		var cfg Config
+		reflect.ValueOf(&cfg).Elem().Field(i).Set(nonZero(f.Type))
+		if got := restartSettings(Config{}, cfg); !slices.Equal(got, []string{f.Name}) {
+			t.Errorf("a changed %s: restart settings %q", f.Name, got)
+		}
+	}
+}
+
+// nonZero returns a value of typ other than its zero value.
+func nonZero(typ reflect.Type) reflect.Value {
+	v := reflect.New(typ).Elem()
+	switch typ.Kind() {
+	case reflect.String:
+		v.SetString("x")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		v.SetInt(1)
+	case reflect.Float64:
+		v.SetFloat(1)
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(typ, 1, 1))
+	case reflect.Map:
+		v.Set(reflect.MakeMap(typ))
+		v.SetMapIndex(nonZero(typ.Key()), reflect.New(typ.Elem()).Elem())
+	case reflect.Pointer:
+		v.Set(reflect.New(typ.Elem()))
+	case reflect.Struct:
+		v.Field(0).Set(nonZero(typ.Field(0).Type))
+	default:
+		panic("nonZero: " + typ.String())
+	}
+	return v
+}
+
+// TestSettingsFromEnvOpensNothing checks that reloading reads settings
+// without building resources again, which would leak the ones built
+// before, and warns about changes to what they were built from.
+func TestSettingsFromEnvOpensNothing(t *testing.T) {
+	events := filepath.Join(t.TempDir(), "security.log")
+	t.Setenv("SECURITY_EVENTS", events)
+	t.Setenv("SMTP_ADDR", "smtp.example.com:587")
+	t.Setenv("SEARCH_BACKEND", "elasticsearch")
+	t.Setenv("SEARCH_URL", "http://localhost:9200")
+	cfg, err := SettingsFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(events); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("the security event log was opened: %v", err)
+	}
+	if cfg.SecurityEvents != nil || cfg.Mailer != nil || cfg.Search != nil {
+		t.Error("resources were built")
+	}
+	if cfg.SecurityEventsTarget != events {
+		t.Errorf("security events target %q, want %q", cfg.SecurityEventsTarget, events)
+	}
+
+	t.Setenv("SMTP_ADDR", "smtp.example.com:465")
+	next, err := SettingsFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := restartSettings(cfg, next); !slices.Equal(got, []string{"SMTP_ADDR"}) {
+		t.Errorf("restart settings %q, want SMTP_ADDR", got)
+	}
+}
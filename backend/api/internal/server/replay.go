@@ -0,0 +1,159 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// maxEventsPage bounds the events get-v1-events returns, and
+// post-admin-events-replay replays, in one call.
+const maxEventsPage = 1000
+
+// Targets events can be replayed to.
+const (
+	ReplaySecurityEvents = "security_events" // the security event stream, SECURITY_EVENTS
+	ReplayCachePurge     = "cache_purge"     // the CDN purges, CACHE_PURGE_URL
+)
+
+// eventRange returns up to limit entries with sequence numbers from from to
+// to, oldest first, and the sequence number of the oldest entry still kept.
+func (a *AuditLog) eventRange(from, to int64, limit int) (out []AuditEntry, oldest int64) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	oldest = a.nextID
+	if len(a.entries) > 0 {
+		oldest = a.entries[0].ID
+	}
+	i, _ := slices.BinarySearchFunc(a.entries, from, func(e AuditEntry, seq int64) int { return cmp.Compare(e.ID, seq) })
+	out = []AuditEntry{}
+	for _, e := range a.entries[i:] {
+		if e.ID > to || len(out) == limit {
+			break
+		}
+		out = append(out, e)
+	}
+	return out, oldest
+}
+
+type EventsInput struct {
+	AdminInput
+	FromSeq int64 `query:"from_seq" minimum:"1" default:"1" doc:"Sequence number of the first event to return"`
+	Limit   int   `query:"limit" minimum:"1" maximum:"1000" default:"100" doc:"Maximum number of events to return"`
+}
+
+type EventsPage struct {
+	Events    []AuditEntry `json:"events" doc:"The events from from_seq on, oldest first"`
+	NextSeq   int64        `json:"next_seq" example:"43" doc:"What to pass as from_seq to get the events after these"`
+	OldestSeq int64        `json:"oldest_seq" example:"1" doc:"Sequence number of the oldest event still kept. If it is past from_seq, the events before it were pruned by the retention policy, or lost on a restart without AUDIT_LOG_PATH"`
+}
+
+type EventsOutput struct {
+	Body *EventsPage
+}
+
+// listEvents is the get-v1-events handler.
+func (s *Server) listEvents(ctx context.Context, input *EventsInput) (*EventsOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	entries, oldest := s.audit.eventRange(input.FromSeq, math.MaxInt64, input.Limit)
+	next := input.FromSeq
+	if len(entries) > 0 {
+		next = entries[len(entries)-1].ID + 1
+	}
+	return &EventsOutput{Body: &EventsPage{Events: entries, NextSeq: next, OldestSeq: oldest}}, nil
+}
+
+type ReplayEventsRequest struct {
+	FromSeq int64    `json:"from_seq" minimum:"1" example:"40" doc:"Sequence number of the first event to replay"`
+	ToSeq   int64    `json:"to_seq,omitempty" minimum:"1" example:"42" doc:"Sequence number of the last event to replay; the latest if omitted"`
+	Targets []string `json:"targets,omitempty" enum:"security_events,cache_purge" doc:"Where to deliver the events again: the security event stream, the CDN purges or both. Every one that is configured if omitted"`
+}
+
+type ReplayEventsInput struct {
+	AdminInput
+	Body ReplayEventsRequest
+}
+
+type EventReplay struct {
+	Events         int   `json:"events" example:"3" doc:"Events in the range replayed"`
+	SecurityEvents int   `json:"security_events" example:"1" doc:"Events queued on the security event stream"`
+	CachePurges    int   `json:"cache_purges" example:"2" doc:"Purges queued for the CDN"`
+	NextSeq        int64 `json:"next_seq,omitempty" example:"1040" doc:"If the range holds more than 1000 events, the from_seq to replay the rest from"`
+}
+
+type ReplayEventsOutput struct {
+	Body *EventReplay
+}
+
+// replayEvents is the post-admin-events-replay handler. The events are
+// queued to be delivered as they were the first time, waiting for room in
+// the queues, so a replay doesn't drop what it replays.
+func (s *Server) replayEvents(ctx context.Context, input *ReplayEventsInput) (*ReplayEventsOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	req := input.Body
+	configured := map[string]bool{ReplaySecurityEvents: s.security != nil, ReplayCachePurge: s.purges != nil}
+	targets := req.Targets
+	if len(targets) == 0 {
+		for _, t := range []string{ReplaySecurityEvents, ReplayCachePurge} {
+			if configured[t] {
+				targets = append(targets, t)
+			}
+		}
+		if len(targets) == 0 {
+			return nil, apiError(http.StatusConflict, CodeReplayNotConfigured, "there is nowhere to replay events to: neither SECURITY_EVENTS nor CACHE_PURGE_URL is set")
+		}
+	}
+	var errs []error
+	for i, t := range targets {
+		if !configured[t] {
+			errs = append(errs, &ErrorDetail{Location: fmt.Sprintf("body.targets[%d]", i), Code: "invalid", Message: "expected a target that is configured", Value: t})
+		}
+	}
+	to := req.ToSeq
+	if to == 0 {
+		to = math.MaxInt64
+	} else if to < req.FromSeq {
+		errs = append(errs, &ErrorDetail{Location: "body.to_seq", Code: "minimum", Message: "expected to_seq to be at least from_seq", Value: to})
+	}
+	if len(errs) > 0 {
+		return nil, newError(http.StatusUnprocessableEntity, "validation failed", errs...)
+	}
+
+	entries, _ := s.audit.eventRange(req.FromSeq, to, maxEventsPage+1)
+	replay := &EventReplay{}
+	if len(entries) > maxEventsPage {
+		replay.NextSeq = entries[maxEventsPage].ID
+		entries = entries[:maxEventsPage]
+	}
+	replay.Events = len(entries)
+	for _, entry := range entries {
+		e := entry.event()
+		if slices.Contains(targets, ReplaySecurityEvents) && securityEvent(e.Type) {
+			if err := s.security.replay(ctx, e); err != nil {
+				return nil, err
+			}
+			replay.SecurityEvents++
+		}
+		if slices.Contains(targets, ReplayCachePurge) {
+			// An anonymized entry has lost the IDs its keys hold.
+			keys := slices.DeleteFunc(purgeKeys(e), func(k string) bool { return strings.HasSuffix(k, ":") })
+			if len(keys) == 0 {
+				continue
+			}
+			if err := s.purges.replay(ctx, keys); err != nil {
+				return nil, err
+			}
+			replay.CachePurges++
+		}
+	}
+	s.logger.InfoContext(ctx, "replayed events", "from_seq", req.FromSeq, "events", replay.Events, "security_events", replay.SecurityEvents, "cache_purges", replay.CachePurges)
+	return &ReplayEventsOutput{Body: replay}, nil
+}
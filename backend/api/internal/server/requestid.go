@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+)
+
+// Every request has an ID, and a correlation ID shared by everything done
+// for the same action, so a click in the frontend can be followed through
+// the logs and the events it causes. Clients may send their own; the
+// response echoes whichever are used.
+const (
+	headerRequestID     = "X-Request-ID"
+	headerCorrelationID = "X-Correlation-ID"
+)
+
+// headerTraceparent carries the W3C trace context of a request traced
+// upstream, by a gateway or a frontend instrumented with OpenTelemetry.
+const headerTraceparent = "traceparent"
+
+// traceparentFormat is version-traceid-parentid-flags, with anything a newer
+// version adds after the flags.
+var traceparentFormat = regexp.MustCompile(`^([0-9a-f]{2})-([0-9a-f]{32})-[0-9a-f]{16}-([0-9a-f]{2})(-.*)?$`)
+
+// validTraceID is what a client-supplied ID has to look like to be used;
+// others, which could forge log lines or carry personal data, are replaced.
+var validTraceID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// newRequestID returns a fresh request ID.
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "req_" + hex.EncodeToString(b)
+}
+
+// parseTraceparent returns the trace ID of a traceparent header, or "" if
+// it isn't valid or the trace wasn't sampled, and so wasn't kept by the
+// tracing backend.
+func parseTraceparent(h string) string {
+	m := traceparentFormat.FindStringSubmatch(h)
+	if m == nil || m[1] == "ff" || (m[1] == "00" && m[4] != "") || strings.Trim(m[2], "0") == "" {
+		return ""
+	}
+	flags, _ := strconv.ParseUint(m[3], 16, 8)
+	if flags&1 == 0 {
+		return ""
+	}
+	return m[2]
+}
+
+type requestIDKey struct{}
+
+type traceIDKey struct{}
+
+// traceID returns the sampled trace the request ctx belongs to is part of,
+// or "".
+func traceID(ctx context.Context) string {
+	id, _ := ctx.Value(traceIDKey{}).(string)
+	return id
+}
+
+// requestID returns the ID of the request ctx belongs to, or "" outside
+// of one.
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// traceRequests gives every request its ID and correlation ID. The
+// correlation ID defaults to the request ID, making the request the start
+// of its flow. Events published while handling it carry the correlation
+// ID and name the request as their cause. The trace ID of a sampled
+// traceparent is kept too, to link the request's logs and metrics to its
+// trace.
+func traceRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(headerRequestID)
+		if !validTraceID.MatchString(id) {
+			id = newRequestID()
+		}
+		correlation := r.Header.Get(headerCorrelationID)
+		if !validTraceID.MatchString(correlation) {
+			correlation = id
+		}
+		// Set them on the request too, so a write forwarded to the raft
+		// leader keeps them.
+		r.Header.Set(headerRequestID, id)
+		r.Header.Set(headerCorrelationID, correlation)
+		w.Header().Set(headerRequestID, id)
+		w.Header().Set(headerCorrelationID, correlation)
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		ctx = events.WithTrace(ctx, events.Trace{CorrelationID: correlation, CausationID: id})
+		if trace := parseTraceparent(r.Header.Get(headerTraceparent)); trace != "" {
+			ctx = context.WithValue(ctx, traceIDKey{}, trace)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// traceHandler adds the request and correlation IDs, and trace ID if any, of
+// the request being handled to the records logged with its context.
+type traceHandler struct {
+	slog.Handler
+}
+
+func (h traceHandler) Handle(ctx context.Context, rec slog.Record) error {
+	if id := requestID(ctx); id != "" {
+		rec.AddAttrs(slog.String("request_id", id), slog.String("correlation_id", events.TraceFrom(ctx).CorrelationID))
+	}
+	if trace := traceID(ctx); trace != "" {
+		rec.AddAttrs(slog.String("trace_id", trace))
+	}
+	return h.Handler.Handle(ctx, rec)
+}
+
+func (h traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return traceHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h traceHandler) WithGroup(name string) slog.Handler {
+	return traceHandler{h.Handler.WithGroup(name)}
+}
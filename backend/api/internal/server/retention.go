@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// Retention rules, as reported and used as the metrics' rule label.
+const (
+	RetentionRuleDeletedUsers = "deleted_users"
+	RetentionRuleAudit        = "audit"
+)
+
+// RetentionReport is the outcome of one run of the retention policy.
+type RetentionReport struct {
+	DryRun bool                  `json:"dry_run" doc:"Whether the run only reported what it would purge"`
+	Rules  []RetentionRuleReport `json:"rules" doc:"One entry per configured rule; rules without a retention period are skipped"`
+}
+
+// RetentionRuleReport is what one rule purged.
+type RetentionRuleReport struct {
+	Rule   string         `json:"rule" enum:"deleted_users,audit" doc:"What the rule purges"`
+	MaxAge string         `json:"max_age" example:"720h0m0s" doc:"How long records are kept"`
+	Cutoff timestamp.Time `json:"cutoff" doc:"Records from before this were purged"`
+	Purged int            `json:"purged" doc:"Records purged, or that would be on a dry run"`
+	IDs    []string       `json:"ids,omitempty" doc:"IDs of the purged users, for the deleted_users rule"`
+}
+
+type RetentionInput struct {
+	AdminInput
+	DryRun bool `query:"dry_run" doc:"Only report what would be purged"`
+}
+
+type RetentionOutput struct {
+	Body *RetentionReport
+}
+
+// retentionEnabled reports whether any retention rule is configured.
+func (s *Server) retentionEnabled() bool {
+	return s.cfg.RetentionDeletedUsers > 0 || s.cfg.RetentionAudit > 0
+}
+
+// runRetention applies the configured rules once: soft-deleted users are
+// purged RetentionDeletedUsers after their deletion, and audit entries
+// RetentionAudit after they were recorded. A failed rule stops the run, but
+// what was already purged stays in the returned report.
+func (s *Server) runRetention(ctx context.Context, dryRun bool) (*RetentionReport, error) {
+	now := time.Now().UTC()
+	report := &RetentionReport{DryRun: dryRun, Rules: []RetentionRuleReport{}}
+	if age := s.cfg.RetentionDeletedUsers; age > 0 {
+		cutoff := now.Add(-age)
+		ids, err := s.users.PurgeDeleted(ctx, cutoff, dryRun)
+		report.Rules = append(report.Rules, RetentionRuleReport{
+			Rule: RetentionRuleDeletedUsers, MaxAge: age.String(), Cutoff: timestamp.From(cutoff), Purged: len(ids), IDs: ids,
+		})
+		if !dryRun {
+			s.metrics.retentionPurged(RetentionRuleDeletedUsers, len(ids))
+		}
+		if err != nil {
+			return report, err
+		}
+	}
+	if age := s.cfg.RetentionAudit; age > 0 {
+		cutoff := now.Add(-age)
+		n := s.audit.Prune(cutoff, dryRun)
+		report.Rules = append(report.Rules, RetentionRuleReport{
+			Rule: RetentionRuleAudit, MaxAge: age.String(), Cutoff: timestamp.From(cutoff), Purged: n,
+		})
+		if !dryRun {
+			s.metrics.retentionPurged(RetentionRuleAudit, n)
+		}
+	}
+	return report, nil
+}
+
+// retentionLoop runs the retention policy every interval until ctx is done,
+// as a dry run if cfg.RetentionDryRun is set.
+func (s *Server) retentionLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if m := s.maintenance.Load(); m != nil && m.Mode != MaintenanceOff {
+				s.logger.InfoContext(ctx, "skipped retention run during maintenance")
+				continue
+			}
+			if !s.leader.IsLeader() {
+				continue
+			}
+			var report *RetentionReport
+			err := s.exclusive(ctx, lockRetention, func(ctx context.Context) (err error) {
+				report, err = s.runRetention(ctx, s.cfg.RetentionDryRun)
+				s.logRetention(ctx, report)
+				return err
+			})
+			if errors.Is(err, errAlreadyRunning) {
+				s.logger.InfoContext(ctx, "skipped retention run, it is already running")
+			} else if err != nil {
+				s.logger.ErrorContext(ctx, "failed to apply retention policy", "err", err)
+			}
+		}
+	}
+}
+
+// logRetention logs what each rule of a run purged and, unless it was a
+// dry run, saves a snapshot so purged users don't linger on disk.
+func (s *Server) logRetention(ctx context.Context, report *RetentionReport) {
+	for _, r := range report.Rules {
+		s.logger.InfoContext(ctx, "applied retention rule", "rule", r.Rule, "max_age", r.MaxAge, "purged", r.Purged, "dry_run", report.DryRun)
+	}
+	if !report.DryRun {
+		s.purgeErasedData()
+	}
+}
+
+// applyRetention is the post-admin-retention handler.
+func (s *Server) applyRetention(ctx context.Context, input *RetentionInput) (*RetentionOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	var report *RetentionReport
+	err := s.exclusive(ctx, lockRetention, func(ctx context.Context) (err error) {
+		report, err = s.runRetention(ctx, input.DryRun)
+		s.logRetention(ctx, report)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &RetentionOutput{Body: report}, nil
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/authtoken"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/jwks"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// EventTokenRevoked is published when a user token is revoked through
+// post-v1-auth-revoke. Its subject is the user the token acted as.
+const EventTokenRevoked = "security.token_revoked"
+
+// RevokedToken is a user token that stopped working before it expired,
+// kept with its user's credentials until it expires.
+type RevokedToken struct {
+	// Hash is the SHA-256 of the token, so tokens of issuers that don't
+	// give them IDs can be revoked too.
+	Hash      string         `json:"hash"`
+	ExpiresAt timestamp.Time `json:"expires_at"`
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// tokenRevoked reports whether token, which acts as userID, was revoked.
+func (u *UserService) tokenRevoked(ctx context.Context, userID, token string) (bool, error) {
+	creds, err := u.store.GetCredentials(ctx, userID)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if len(creds.RevokedTokens) == 0 {
+		return false, nil
+	}
+	hash := hashToken(token)
+	return slices.ContainsFunc(creds.RevokedTokens, func(t RevokedToken) bool { return t.Hash == hash }), nil
+}
+
+// revokeToken adds token, which p authenticated with, to its user's revoked
+// tokens, dropping those that have expired since.
+func (u *UserService) revokeToken(ctx context.Context, p *Principal, token string, now time.Time) error {
+	creds, err := u.credentials(ctx, p.UserID)
+	if err != nil {
+		return err
+	}
+	hash := hashToken(token)
+	revoked := slices.DeleteFunc(slices.Clone(creds.RevokedTokens), func(t RevokedToken) bool {
+		return !now.Before(t.ExpiresAt.Time)
+	})
+	if slices.ContainsFunc(revoked, func(t RevokedToken) bool { return t.Hash == hash }) {
+		return nil
+	}
+	creds.RevokedTokens = append(revoked, RevokedToken{Hash: hash, ExpiresAt: timestamp.From(p.ExpiresAt)})
+	if err := u.store.PutCredentials(ctx, p.UserID, creds); err != nil {
+		return err
+	}
+	data := map[string]any{"token_id": p.TokenID}
+	if p.Issuer != "" {
+		data["issuer"] = p.Issuer
+	}
+	u.bus.Publish(ctx, events.Event{Type: EventTokenRevoked, Subject: p.UserID, Data: data})
+	return nil
+}
+
+// activePrincipal returns who token, a user token or an API key, acts as,
+// or nil if it doesn't work: it is malformed, forged, expired or revoked.
+// It only fails if that can't be told, as when the token's issuer can't be
+// reached.
+func (s *Server) activePrincipal(ctx context.Context, token string) (*Principal, error) {
+	if strings.HasPrefix(token, apiKeyPrefix) {
+		p, err := s.users.authenticateAPIKey(ctx, token, time.Now())
+		if errors.Is(err, errBadAPIKey) {
+			return nil, nil
+		}
+		return p, err
+	}
+	if !authtoken.LooksLikeToken(token) {
+		return nil, nil
+	}
+	p, err := s.verifyToken(ctx, token)
+	switch {
+	case errors.Is(err, authtoken.ErrExpired), errors.Is(err, jwks.ErrExpired),
+		errors.Is(err, authtoken.ErrInvalid), errors.Is(err, jwks.ErrInvalid):
+		return nil, nil
+	case err != nil:
+		s.logger.ErrorContext(ctx, "failed to fetch JWT issuer keys", "err", err)
+		return nil, apiError(http.StatusServiceUnavailable, CodeIssuerUnavailable, "the token's issuer is unavailable")
+	}
+	revoked, err := s.users.tokenRevoked(ctx, p.UserID, token)
+	if err != nil || revoked {
+		return nil, err
+	}
+	return p, nil
+}
+
+type TokenRequest struct {
+	Token         string `json:"token" minLength:"1" maxLength:"4096" redact:"true" doc:"A user token or API key"`
+	TokenTypeHint string `json:"token_type_hint,omitempty" enum:"access_token,api_key" doc:"What the token is, if known; the token is recognized either way"`
+}
+
+type TokenInput struct {
+	Body TokenRequest
+}
+
+// TokenIntrospection is what a token is, in the shape of RFC 7662's
+// responses. Only active is set for a token that doesn't work.
+type TokenIntrospection struct {
+	Active    bool   `json:"active" doc:"Whether the token works: it is well-formed, correctly signed, not expired and not revoked"`
+	TokenType string `json:"token_type,omitempty" enum:"Bearer,api_key" doc:"Bearer for a user token, api_key for an API key"`
+	Subject   string `json:"sub,omitempty" example:"20240101120000" doc:"ID of the user the token acts as"`
+	TokenID   string `json:"jti,omitempty" example:"5f3c2a9b8e7d6c1f0a4b3e2d" doc:"The user token's ID, as in audit entries"`
+	Issuer    string `json:"iss,omitempty" example:"https://example.us.auth0.com/" doc:"The identity provider that issued the token, if it isn't the API's own"`
+	APIKeyID  string `json:"api_key_id,omitempty" doc:"The API key's ID"`
+	Scope     string `json:"scope,omitempty" example:"users:read notifications:read" doc:"What the API key may do, space-separated; absent for a user token, which may do anything"`
+	ExpiresAt int64  `json:"exp,omitempty" example:"1704114000" doc:"When the token stops working, in seconds since the Unix epoch; absent for an API key that doesn't expire"`
+}
+
+type TokenIntrospectionOutput struct {
+	Body *TokenIntrospection
+}
+
+// introspectToken is the post-v1-auth-introspect handler.
+func (s *Server) introspectToken(ctx context.Context, input *TokenInput) (*TokenIntrospectionOutput, error) {
+	p, err := s.activePrincipal(ctx, input.Body.Token)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return &TokenIntrospectionOutput{Body: &TokenIntrospection{}}, nil
+	}
+	out := &TokenIntrospection{
+		Active:    true,
+		TokenType: "Bearer",
+		Subject:   p.UserID,
+		TokenID:   p.TokenID,
+		Issuer:    p.Issuer,
+		APIKeyID:  p.APIKeyID,
+	}
+	if p.APIKeyID != "" {
+		out.TokenType = "api_key"
+		out.Scope = strings.Join(p.Scopes, " ")
+	}
+	if !p.ExpiresAt.IsZero() {
+		out.ExpiresAt = p.ExpiresAt.Unix()
+	}
+	return &TokenIntrospectionOutput{Body: out}, nil
+}
+
+// revokeToken is the post-v1-auth-revoke handler. As RFC 7009 has it, a
+// token that already doesn't work is revoked too.
+func (s *Server) revokeToken(ctx context.Context, input *TokenInput) (*struct{}, error) {
+	p, err := s.activePrincipal(ctx, input.Body.Token)
+	if err != nil || p == nil {
+		return nil, err
+	}
+	if p.APIKeyID != "" {
+		return nil, s.users.RevokeAPIKey(ctx, p.UserID, p.APIKeyID)
+	}
+	return nil, s.users.revokeToken(ctx, p, input.Body.Token, time.Now())
+}
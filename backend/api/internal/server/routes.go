@@ -0,0 +1,1254 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/buildinfo"
+)
+
+func (s *Server) registerRoutes() {
+	api := s.api
+
+	// Hello
+	huma.Get(api, "/hello", func(ctx context.Context, input *struct{}) (*HelloOutput, error) {
+		return &HelloOutput{Body: &HelloResponse{Message: "Hello, world!"}}, nil
+	})
+
+	// Health
+	huma.Register(api, huma.Operation{
+		OperationID: "get-health",
+		Method:      http.MethodGet,
+		Path:        "/health",
+		Summary:     "Get health",
+		Description: "Answer 200, or 503 while the server drains before shutting down. `format=plain` answers in a line of text and `format=prometheus` with a health_up gauge, like the probes.",
+		Responses:   healthResponses(api, HealthResponse{}),
+	}, func(ctx context.Context, input *HealthInput) (*HealthOutput, error) {
+		if s.draining.Load() {
+			return &HealthOutput{Status: http.StatusServiceUnavailable, ContentType: input.contentType(), Body: &HealthResponse{Status: http.StatusServiceUnavailable}}, nil
+		}
+		return &HealthOutput{Status: http.StatusOK, ContentType: input.contentType(), Body: &HealthResponse{Status: 200}}, nil
+	})
+
+	// Kubernetes probes
+	huma.Register(api, huma.Operation{
+		OperationID: "get-livez",
+		Method:      http.MethodGet,
+		Path:        "/livez",
+		Summary:     "Liveness probe",
+		Description: "Answer 200 as long as the process serves requests, draining included, so the orchestrator only restarts a server that stopped responding. Takes `format` as get-health does.",
+		Responses:   healthResponses(api, HealthResponse{}),
+	}, func(ctx context.Context, input *HealthInput) (*HealthOutput, error) {
+		return &HealthOutput{Status: http.StatusOK, ContentType: input.contentType(), Body: &HealthResponse{Status: http.StatusOK}}, nil
+	})
+	huma.Register(api, huma.Operation{
+		OperationID: "get-readyz",
+		Method:      http.MethodGet,
+		Path:        "/readyz",
+		Summary:     "Readiness probe",
+		Description: "Answer 200 while the server takes traffic, and 503 from the moment it gets SIGTERM, so the orchestrator takes it out of rotation during SHUTDOWN_DELAY. Listeners only open once every other component has started, so there is no warm-up to wait for, but a component with a readiness gate fails it while it can't serve, like a raft store without a leader. Every component is listed with its status. `format=plain` answers with a line per component, and `format=prometheus` with a health_up gauge and a health_component_ready gauge per component.",
+		Responses:   healthResponses(api, ReadinessResponse{}),
+	}, func(ctx context.Context, input *HealthInput) (*ReadinessOutput, error) {
+		resp := s.readiness()
+		return &ReadinessOutput{Status: resp.Status, ContentType: input.contentType(), Body: resp}, nil
+	})
+
+	// Version
+	huma.Register(api, huma.Operation{
+		OperationID: "get-version",
+		Method:      http.MethodGet,
+		Path:        "/version",
+		Summary:     "Get build version",
+		Description: "Report the version, git commit and build date of the running server, and the Go version it was built with.",
+		Metadata:    cached(CachePolicy{MaxAge: time.Minute, Public: true}),
+	}, func(ctx context.Context, input *struct{}) (*VersionOutput, error) {
+		info := buildinfo.Get()
+		return &VersionOutput{Body: &VersionResponse{
+			Version:   info.Version,
+			Commit:    info.Commit,
+			Date:      info.Date,
+			GoVersion: info.GoVersion,
+		}}, nil
+	})
+
+	// Changelog
+	huma.Register(api, huma.Operation{
+		OperationID: "get-v1-changelog",
+		Method:      http.MethodGet,
+		Path:        "/v1/changelog",
+		Summary:     "Get the API changelog",
+		Description: "List the changes to the API per release, newest first, starting with the changes not released yet. Operations added and removed are listed even where the changelog doesn't mention them, from the operations each release served. Pass since to get only what changed after the release a client was built against.",
+		Errors:      []int{http.StatusNotFound},
+		Metadata:    cached(CachePolicy{MaxAge: 5 * time.Minute, Public: true}),
+	}, s.getChangelog)
+
+	// Create User
+	huma.Register(api, huma.Operation{
+		OperationID:   "post-v1-users",
+		Method:        http.MethodPost,
+		Path:          "/v1/users",
+		Summary:       "Create a new user",
+		Description:   "Create a new user with name and email. When the server has CAPTCHA_PROVIDER set, the request needs an `X-Captcha-Token`.",
+		Errors:        []int{http.StatusBadRequest, http.StatusForbidden, http.StatusConflict, http.StatusServiceUnavailable},
+		DefaultStatus: http.StatusCreated,
+		Metadata:      metadata(deduplicated(doubleSubmitWindow), exampled(createUserExamples)),
+	}, func(ctx context.Context, input *CreateUserInput) (*UserOutput, error) {
+		if err := s.verifyCaptcha(ctx, input.CaptchaInput); err != nil {
+			return nil, err
+		}
+		user, err := s.users.Create(ctx, input.Body)
+		if err != nil {
+			return nil, err
+		}
+		return &UserOutput{Body: user}, nil
+	})
+
+	// List Users
+	huma.Register(api, huma.Operation{
+		OperationID: "get-v1-users",
+		Method:      http.MethodGet,
+		Path:        "/v1/users",
+		Summary:     "List all users",
+		Description: "Get a page of active users, or of all users with `include_inactive=true`, in ID order, which is oldest first unless ID_GENERATOR=uuidv4 makes IDs random. `X-Total-Count` and `Link` headers describe the other pages. `include=post_count` adds how many posts each user wrote.",
+		Errors:      []int{http.StatusUnprocessableEntity},
+	}, func(ctx context.Context, input *ListUsersInput) (*UsersListOutput, error) {
+		users, err := s.users.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		deadline := input.deadline(ctx)
+		partial := false
+		// In ID order, so a spent budget cuts off the newest users rather
+		// than whichever the store happened to list last.
+		sortUsers(users)
+		list := make([]*User, 0, len(users))
+		for _, u := range users {
+			if spent(deadline) {
+				partial = true
+				break
+			}
+			if input.matches(u) {
+				list = append(list, u)
+			}
+		}
+		counts := tagCounts(list)
+		list, total := input.paginate(list)
+		if err := s.addIncludes(ctx, input.UserIncludes, list...); err != nil {
+			return nil, err
+		}
+		s.logger.DebugContext(ctx, "listed users", "returned", len(list), "total", total, "partial", partial)
+		return &UsersListOutput{
+			TotalCount: total,
+			Link:       input.links(total),
+			Body:       &UsersListResponse{Users: list, Status: 200, TagCounts: counts, Pagination: input.pagination(total), Partial: partial},
+		}, nil
+	})
+
+	// Search Users
+	huma.Register(api, huma.Operation{
+		OperationID: "get-v1-users-search",
+		Method:      http.MethodGet,
+		Path:        "/v1/users/search",
+		Summary:     "Search users by prefix",
+		Description: "Typeahead search over active users' names and emails. Returns only the fields an autocomplete needs, best match first.",
+		Errors:      []int{http.StatusUnprocessableEntity},
+		Metadata:    concurrencyGroup(GroupSearch),
+	}, func(ctx context.Context, input *SearchUsersInput) (*SearchUsersOutput, error) {
+		results, partial, err := s.users.Search(ctx, input.Q, input.Limit, input.deadline(ctx))
+		if err != nil {
+			return nil, err
+		}
+		return &SearchUsersOutput{Body: &SearchUsersResponse{Results: results, Partial: partial}}, nil
+	})
+
+	// Search
+	huma.Register(api, huma.Operation{
+		OperationID: "get-v1-search",
+		Method:      http.MethodGet,
+		Path:        "/v1/search",
+		Summary:     "Search users and posts",
+		Description: "Full-text search over active users' names, usernames and emails and over posts' titles and bodies, best match first. Each result has the user or post and the fragments of its fields that matched, with the matched words highlighted.",
+		Errors:      []int{http.StatusUnprocessableEntity, http.StatusServiceUnavailable},
+		Metadata:    concurrencyGroup(GroupSearch),
+	}, func(ctx context.Context, input *SearchInput) (*SearchOutput, error) {
+		results, partial, err := s.search.Search(ctx, input.Q, input.Type, input.Limit, input.deadline(ctx))
+		if err != nil {
+			return nil, err
+		}
+		return &SearchOutput{Body: &SearchResponse{Results: results, Partial: partial}}, nil
+	})
+
+	// Look Up Users
+	huma.Register(api, huma.Operation{
+		OperationID: "post-v1-users-lookup",
+		Method:      http.MethodPost,
+		Path:        "/v1/users/lookup",
+		Summary:     "Get users by IDs",
+		Description: "Fetch up to 100 users in one call, in request order. IDs without a user come back with `found: false` instead of failing the request.",
+		Errors:      []int{http.StatusBadRequest},
+	}, func(ctx context.Context, input *LookupUsersInput) (*LookupUsersOutput, error) {
+		results, err := s.users.Lookup(ctx, input.Body.IDs)
+		if err != nil {
+			return nil, err
+		}
+		return &LookupUsersOutput{Body: &LookupUsersResponse{Results: results}}, nil
+	})
+
+	// User Changes
+	huma.Register(api, huma.Operation{
+		OperationID: "get-v1-users-changes",
+		Method:      http.MethodGet,
+		Path:        "/v1/users/changes",
+		Summary:     "Poll for user changes",
+		Description: "A long-polling change feed for clients that can't use WebSockets or server-sent events. Returns the changes after `since` in order, waiting up to `wait` for one if there are none yet; pass `next_since` as `since` on the next call. Start with `since=-1`. A 410 means changes were missed, after a restart or the retention policy pruned them: list the users again and start over.",
+		Errors:      []int{http.StatusGone},
+		Middlewares: huma.Middlewares{holdOpen},
+	}, s.userChanges)
+
+	// Stream Users
+	huma.Register(api, huma.Operation{
+		OperationID: "get-v1-users-stream",
+		Method:      http.MethodGet,
+		Path:        "/v1/users/stream",
+		Summary:     "Stream all users",
+		Description: "Stream every user get-v1-users would list, in ID order, like its pages, and without them, as newline-delimited JSON: one user per line, as get-v1-users-by-id returns them, each flushed as it is written. For bulk consumers that would rather not page through millions of users or hold them all in memory. Takes the filters of get-v1-users, `metadata.<key>=<value>` included. Once the stream has started, a failure can only cut it short, so count the lines if completeness matters.",
+		Errors:      []int{http.StatusUnprocessableEntity},
+		Metadata:    linesOf(reflect.TypeFor[User]()),
+		Responses: map[string]*huma.Response{
+			"200": {
+				Description: "One user per line",
+				Content: map[string]*huma.MediaType{
+					ndjsonContentType: {Schema: &huma.Schema{Type: "string", Format: "binary"}},
+				},
+			},
+		},
+	}, s.streamUsers)
+
+	// Get User
+	huma.Register(api, huma.Operation{
+		OperationID: "get-v1-users-by-id",
+		Method:      http.MethodGet,
+		Path:        "/v1/users/{id}",
+		Summary:     "Get user by ID",
+		Description: "Get a user by their ID. `include=post_count` adds how many posts they wrote.",
+		Errors:      []int{http.StatusNotFound},
+	}, func(ctx context.Context, input *GetUserInput) (*UserOutput, error) {
+		user, err := s.users.Get(ctx, input.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.addIncludes(ctx, input.UserIncludes, user); err != nil {
+			return nil, err
+		}
+		return &UserOutput{Body: user}, nil
+	})
+
+	// Update User
+	huma.Register(api, huma.Operation{
+		OperationID: "put-v1-users-by-id",
+		Method:      http.MethodPut,
+		Path:        "/v1/users/{id}",
+		Summary:     "Update user by ID",
+		Description: "Update a user's username, name, email, phone and/or metadata by their ID. Fields left out keep their values; an empty phone clears it, and metadata replaces the stored metadata wholesale.",
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+		Metadata:    exampled(updateUserExamples),
+	}, func(ctx context.Context, input *UpdateUserInput) (*UserOutput, error) {
+		user, err := s.users.Update(ctx, input.ID, input.Body)
+		if err != nil {
+			return nil, err
+		}
+		return &UserOutput{Body: user}, nil
+	})
+
+	// Batch Update Users
+	huma.Register(api, huma.Operation{
+		OperationID: "patch-v1-users-batch",
+		Method:      http.MethodPatch,
+		Path:        "/v1/users/batch",
+		Summary:     "Update several users",
+		Description: "Apply up to 100 updates in order, each `changes` being what put-v1-users-by-id takes. Every entry is validated and applied on its own, and `results` reports each one's outcome with the status and error the single update would have answered, so one bad entry doesn't fail the rest. The body may be sent gzipped, with `Content-Encoding: gzip`.",
+		Errors:      []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType},
+		Metadata:    metadata(gzipBodies(), concurrencyGroup(GroupBulk)),
+	}, func(ctx context.Context, input *BatchUpdateUsersInput) (*BatchUpdateUsersOutput, error) {
+		return &BatchUpdateUsersOutput{Body: s.batchUpdate(ctx, input.Body.Updates)}, nil
+	})
+
+	// Transaction
+	huma.Register(api, huma.Operation{
+		OperationID: "post-v1-batch",
+		Method:      http.MethodPost,
+		Path:        "/v1/batch",
+		Summary:     "Run operations atomically",
+		Description: "Run up to 100 create, update and delete operations in order, as one transaction: either all of them succeed and are committed, or none are. Each operation follows the rules of its single endpoint and sees the writes of the ones before it; `$N` as an `id` names what operation N created. `results` reports every operation's outcome, and the error of the one that failed the transaction. Nothing is locked while it runs: if a request changes a user the transaction read before it commits, it runs again on the new data, and fails with 409 if that keeps happening. When the server has CAPTCHA_PROVIDER set, a transaction that creates users needs an `X-Captcha-Token`. The body may be sent gzipped, with `Content-Encoding: gzip`.",
+		Errors:      []int{http.StatusBadRequest, http.StatusForbidden, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType, http.StatusServiceUnavailable},
+		Metadata:    metadata(gzipBodies(), concurrencyGroup(GroupBulk)),
+	}, s.runTransaction)
+
+	// Change User Status
+	huma.Register(api, huma.Operation{
+		OperationID: "post-v1-users-by-id-status",
+		Method:      http.MethodPost,
+		Path:        "/v1/users/{id}/status",
+		Summary:     "Change user status",
+		Description: "Move a user to another lifecycle status. Invited users can be activated, active and suspended users can be moved between those two, and any user can be deleted; deleted is final.",
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+	}, func(ctx context.Context, input *UserStatusInput) (*UserOutput, error) {
+		user, err := s.users.ChangeStatus(ctx, input.ID, input.Body.Status)
+		if err != nil {
+			return nil, err
+		}
+		return &UserOutput{Body: user}, nil
+	})
+
+	// Activate User
+	huma.Register(api, huma.Operation{
+		OperationID: "post-v1-users-by-id-activate",
+		Method:      http.MethodPost,
+		Path:        "/v1/users/{id}/activate",
+		Summary:     "Activate user",
+		Description: "Activate an invited or suspended user. Activating an active user is a no-op.",
+		Errors:      []int{http.StatusNotFound, http.StatusConflict},
+	}, func(ctx context.Context, input *UserIDInput) (*UserOutput, error) {
+		user, err := s.users.ChangeStatus(ctx, input.ID, UserStatusActive)
+		if err != nil {
+			return nil, err
+		}
+		return &UserOutput{Body: user}, nil
+	})
+
+	// Deactivate User
+	huma.Register(api, huma.Operation{
+		OperationID: "post-v1-users-by-id-deactivate",
+		Method:      http.MethodPost,
+		Path:        "/v1/users/{id}/deactivate",
+		Summary:     "Deactivate user",
+		Description: "Suspend an active user, hiding them from the default listing. Deactivating a suspended user is a no-op.",
+		Errors:      []int{http.StatusNotFound, http.StatusConflict},
+	}, func(ctx context.Context, input *UserIDInput) (*UserOutput, error) {
+		user, err := s.users.ChangeStatus(ctx, input.ID, UserStatusSuspended)
+		if err != nil {
+			return nil, err
+		}
+		return &UserOutput{Body: user}, nil
+	})
+
+	// Check Username Availability
+	huma.Register(api, huma.Operation{
+		OperationID: "get-v1-usernames-by-name-available",
+		Method:      http.MethodGet,
+		Path:        "/v1/usernames/{name}/available",
+		Summary:     "Check username availability",
+		Description: "Check whether a username can be registered, for validating signup forms as the user types.",
+	}, func(ctx context.Context, input *UsernameInput) (*UsernameAvailabilityOutput, error) {
+		result, err := s.users.UsernameAvailability(ctx, input.Name)
+		if err != nil {
+			return nil, err
+		}
+		return &UsernameAvailabilityOutput{Body: result}, nil
+	})
+
+	// Replace User Tags
+	huma.Register(api, huma.Operation{
+		OperationID: "put-v1-users-by-id-tags",
+		Method:      http.MethodPut,
+		Path:        "/v1/users/{id}/tags",
+		Summary:     "Replace user tags",
+		Description: "Replace the set of tags on a user. An empty list removes all tags.",
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	}, func(ctx context.Context, input *UserTagsInput) (*UserTagsOutput, error) {
+		if _, err := s.users.SetTags(ctx, input.ID, input.Body.Tags); err != nil {
+			return nil, err
+		}
+		return &UserTagsOutput{Body: &UserTags{Tags: input.Body.Tags}}, nil
+	})
+
+	// Get User Preferences
+	huma.Register(api, huma.Operation{
+		OperationID: "get-v1-users-by-id-preferences",
+		Method:      http.MethodGet,
+		Path:        "/v1/users/{id}/preferences",
+		Summary:     "Get user preferences",
+		Description: "Get a user's preferences. Users who never saved any get the defaults.",
+		Errors:      []int{http.StatusNotFound},
+	}, func(ctx context.Context, input *UserIDInput) (*UserPreferencesOutput, error) {
+		prefs, err := s.users.Preferences(ctx, input.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &UserPreferencesOutput{Body: prefs}, nil
+	})
+
+	// Replace User Preferences
+	huma.Register(api, huma.Operation{
+		OperationID: "put-v1-users-by-id-preferences",
+		Method:      http.MethodPut,
+		Path:        "/v1/users/{id}/preferences",
+		Summary:     "Replace user preferences",
+		Description: "Replace a user's preferences. Omitted fields are reset to their defaults.",
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	}, func(ctx context.Context, input *UserPreferencesInput) (*UserPreferencesOutput, error) {
+		prefs := input.Body
+		if err := s.users.SetPreferences(ctx, input.ID, &prefs); err != nil {
+			return nil, err
+		}
+		return &UserPreferencesOutput{Body: &prefs}, nil
+	})
+
+	// Delete User
+	huma.Register(api, huma.Operation{
+		OperationID: "delete-v1-users-by-id",
+		Method:      http.MethodDelete,
+		Path:        "/v1/users/{id}",
+		Summary:     "Delete user by ID",
+		Description: "Delete a user, their preferences, posts and comments by their ID. With `mode=erase`, for GDPR erasure requests, the audit log entries about them are anonymized too, and the erasure is audited without naming them.",
+		Responses: map[string]*huma.Response{
+			"404": {
+				Description: "User not found",
+				Content: map[string]*huma.MediaType{
+					"application/json": {Schema: api.OpenAPI().Components.Schemas.Schema(reflect.TypeOf(DeleteUserResponse{}), true, "")},
+				},
+			},
+		},
+	}, func(ctx context.Context, input *DeleteUserInput) (*DeleteUserOutput, error) {
+		var err error
+		if input.Mode == "erase" {
+			err = s.users.Erase(ctx, input.ID)
+		} else {
+			err = s.users.Delete(ctx, input.ID)
+		}
+		if errors.Is(err, ErrNotFound) {
+			return &DeleteUserOutput{Status: http.StatusNotFound, Body: &DeleteUserResponse{Deleted: false}}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if input.Mode == "erase" {
+			// user.erased doesn't name the user, so their notifications
+			// and search entries are dropped here rather than by the event.
+			s.notifications.Forget(input.ID)
+			if err := s.search.Forget(ctx, input.ID); err != nil {
+				s.logger.ErrorContext(ctx, "failed to update the search index", "event", "user.erased", "err", err)
+			}
+			s.purgeErasedData()
+		}
+		return &DeleteUserOutput{Status: http.StatusOK, Body: &DeleteUserResponse{Deleted: true}}, nil
+	})
+
+	// Export User Data
+	huma.Register(api, huma.Operation{
+		OperationID: "get-v1-users-by-id-data-export",
+		Method:      http.MethodGet,
+		Path:        "/v1/users/{id}/data-export",
+		Summary:     "Export a user's data",
+		Description: "Get everything stored about a user, for GDPR access requests: the user record, their saved preferences, their posts and comments and the audit log entries about them. The export itself is audited.",
+		Errors:      []int{http.StatusNotFound},
+		Metadata:    concurrencyGroup(GroupExports),
+	}, func(ctx context.Context, input *UserIDInput) (*UserDataExportOutput, error) {
+		export, err := s.users.Export(ctx, input.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &UserDataExportOutput{Body: export}, nil
+	})
+
+	// List User Posts
+	huma.Register(api, huma.Operation{
+		OperationID: "get-v1-users-by-id-posts",
+		Method:      http.MethodGet,
+		Path:        "/v1/users/{id}/posts",
+		Summary:     "List a user's posts",
+		Description: "Get a page of the posts a user wrote, in ID order, which is oldest first unless ID_GENERATOR=uuidv4 makes IDs random. `X-Total-Count` and `Link` headers describe the other pages.",
+		Errors:      []int{http.StatusNotFound},
+	}, func(ctx context.Context, input *UserPostsInput) (*PostsListOutput, error) {
+		posts, err := s.posts.ByAuthor(ctx, input.ID)
+		if err != nil {
+			return nil, err
+		}
+		return postsPage(&input.KeysetPageInput, posts), nil
+	})
+
+	// Create User Post
+	huma.Register(api, huma.Operation{
+		OperationID:   "post-v1-users-by-id-posts",
+		Method:        http.MethodPost,
+		Path:          "/v1/users/{id}/posts",
+		Summary:       "Write a post for a user",
+		Description:   "Create a post written by the user. The same as post-v1-posts with the user as `author_id`.",
+		Errors:        []int{http.StatusBadRequest, http.StatusNotFound},
+		DefaultStatus: http.StatusCreated,
+	}, func(ctx context.Context, input *CreateUserPostInput) (*PostOutput, error) {
+		post, err := s.posts.Create(ctx, CreatePostRequest{AuthorID: input.ID, Title: input.Body.Title, Body: input.Body.Body})
+		if err != nil {
+			return nil, err
+		}
+		return &PostOutput{Body: post}, nil
+	})
+
+	// List Posts
+	huma.Register(api, huma.Operation{
+		OperationID: "get-v1-posts",
+		Method:      http.MethodGet,
+		Path:        "/v1/posts",
+		Summary:     "List all posts",
+		Description: "Get a page of every user's posts, or with `author_id` of one user's, in ID order, which is oldest first unless ID_GENERATOR=uuidv4 makes IDs random. `X-Total-Count` and `Link` headers describe the other pages.",
+		Errors:      []int{http.StatusUnprocessableEntity},
+		Metadata:    cached(CachePolicy{MaxAge: 30 * time.Second, Public: true, Tagged: true}),
+	}, func(ctx context.Context, input *ListPostsInput) (*PostsListOutput, error) {
+		posts, err := s.posts.List(ctx, input.AuthorID)
+		if err != nil {
+			return nil, err
+		}
+		return postsPage(&input.KeysetPageInput, posts), nil
+	})
+
+	// Create Post
+	huma.Register(api, huma.Operation{
+		OperationID:   "post-v1-posts",
+		Method:        http.MethodPost,
+		Path:          "/v1/posts",
+		Summary:       "Create a post",
+		Description:   "Create a post with a title and body, written by the user `author_id` names. Posts belong to their author: deleting the user for good deletes their posts.",
+		Errors:        []int{http.StatusBadRequest, http.StatusNotFound},
+		DefaultStatus: http.StatusCreated,
+		Metadata:      metadata(deduplicated(doubleSubmitWindow), exampled(createPostExamples)),
+	}, func(ctx context.Context, input *CreatePostInput) (*PostOutput, error) {
+		post, err := s.posts.Create(ctx, input.Body)
+		if err != nil {
+			return nil, err
+		}
+		return &PostOutput{Body: post}, nil
+	})
+
+	// Get Post
+	huma.Register(api, huma.Operation{
+		OperationID: "get-v1-posts-by-id",
+		Method:      http.MethodGet,
+		Path:        "/v1/posts/{id}",
+		Summary:     "Get post by ID",
+		Description: "Get a post by its ID.",
+		Errors:      []int{http.StatusNotFound},
+		Metadata:    cached(CachePolicy{MaxAge: 30 * time.Second, Public: true, Tagged: true}),
+	}, func(ctx context.Context, input *PostIDInput) (*PostOutput, error) {
+		post, err := s.posts.Get(ctx, input.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &PostOutput{Body: post}, nil
+	})
+
+	// Update Post
+	huma.Register(api, huma.Operation{
+		OperationID: "put-v1-posts-by-id",
+		Method:      http.MethodPut,
+		Path:        "/v1/posts/{id}",
+		Summary:     "Update post by ID",
+		Description: "Change a post's title and/or body. Its author can't be changed.",
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	}, func(ctx context.Context, input *UpdatePostInput) (*PostOutput, error) {
+		post, err := s.posts.Update(ctx, input.ID, input.Body)
+		if err != nil {
+			return nil, err
+		}
+		return &PostOutput{Body: post}, nil
+	})
+
+	// Delete Post
+	huma.Register(api, huma.Operation{
+		OperationID:   "delete-v1-posts-by-id",
+		Method:        http.MethodDelete,
+		Path:          "/v1/posts/{id}",
+		Summary:       "Delete post by ID",
+		Description:   "Delete a post and the comments on it by its ID.",
+		Errors:        []int{http.StatusNotFound},
+		DefaultStatus: http.StatusNoContent,
+	}, func(ctx context.Context, input *PostIDInput) (*struct{}, error) {
+		return nil, s.posts.Delete(ctx, input.ID)
+	})
+
+	// List Comments
+	huma.Register(api, huma.Operation{
+		OperationID: "get-v1-posts-by-id-comments",
+		Method:      http.MethodGet,
+		Path:        "/v1/posts/{id}/comments",
+		Summary:     "List a post's comments",
+		Description: "Get a page of the top-level comments on a post, or with `parent_id` of the replies to a comment, in ID order, which is oldest first unless ID_GENERATOR=uuidv4 makes IDs random. Each comes with its `reply_count`; walk a thread by listing the replies of those that have some. Comments hidden by moderation are left out unless `include_hidden=true`. `X-Total-Count` and `Link` headers describe the other pages.",
+		Errors:      []int{http.StatusNotFound},
+		Metadata:    cached(CachePolicy{MaxAge: 30 * time.Second, Public: true, Tagged: true}),
+	}, func(ctx context.Context, input *ListCommentsInput) (*CommentsListOutput, error) {
+		comments, err := s.comments.List(ctx, input.PostID, input.ParentID, input.IncludeHidden)
+		if err != nil {
+			return nil, err
+		}
+		page, total := keysetPageOf(&input.KeysetPageInput, comments, func(c *Comment) string { return c.ID })
+		return &CommentsListOutput{
+			TotalCount: total,
+			Link:       input.links(total),
+			Body:       &CommentsListResponse{Comments: page, Pagination: input.pagination(total)},
+		}, nil
+	})
+
+	// Create Comment
+	huma.Register(api, huma.Operation{
+		OperationID:   "post-v1-posts-by-id-comments",
+		Method:        http.MethodPost,
+		Path:          "/v1/posts/{id}/comments",
+		Summary:       "Comment on a post",
+		Description:   "Write a comment on a post as the user `author_id` names, or with `parent_id` a reply to another comment on the post. Comments are published straight away.",
+		Errors:        []int{http.StatusBadRequest, http.StatusNotFound},
+		DefaultStatus: http.StatusCreated,
+		Metadata:      deduplicated(doubleSubmitWindow),
+	}, func(ctx context.Context, input *CreateCommentInput) (*CommentOutput, error) {
+		comment, err := s.comments.Create(ctx, input.PostID, input.Body)
+		if err != nil {
+			return nil, err
+		}
+		return &CommentOutput{Body: comment}, nil
+	})
+
+	// Get Comment
+	huma.Register(api, huma.Operation{
+		OperationID: "get-v1-posts-by-id-comments-by-comment-id",
+		Method:      http.MethodGet,
+		Path:        "/v1/posts/{id}/comments/{commentID}",
+		Summary:     "Get a comment",
+		Description: "Get a comment on a post, with the number of its published replies.",
+		Errors:      []int{http.StatusNotFound},
+		Metadata:    cached(CachePolicy{MaxAge: 30 * time.Second, Public: true, Tagged: true}),
+	}, func(ctx context.Context, input *CommentIDInput) (*CommentOutput, error) {
+		comment, err := s.comments.Get(ctx, input.PostID, input.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &CommentOutput{Body: comment}, nil
+	})
+
+	// Update Comment
+	huma.Register(api, huma.Operation{
+		OperationID: "put-v1-posts-by-id-comments-by-comment-id",
+		Method:      http.MethodPut,
+		Path:        "/v1/posts/{id}/comments/{commentID}",
+		Summary:     "Edit a comment",
+		Description: "Replace the body of a comment on a post.",
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	}, func(ctx context.Context, input *UpdateCommentInput) (*CommentOutput, error) {
+		comment, err := s.comments.Update(ctx, input.PostID, input.ID, input.Body)
+		if err != nil {
+			return nil, err
+		}
+		return &CommentOutput{Body: comment}, nil
+	})
+
+	// Moderate Comment
+	huma.Register(api, huma.Operation{
+		OperationID: "post-v1-posts-by-id-comments-by-comment-id-status",
+		Method:      http.MethodPost,
+		Path:        "/v1/posts/{id}/comments/{commentID}/status",
+		Summary:     "Moderate a comment",
+		Description: "Hide a comment from listings, or publish it again. Its replies stay listed under it. Moving a comment to the status it has is a no-op. Requires the admin token.",
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound},
+		Security:    adminSecurity,
+	}, func(ctx context.Context, input *CommentStatusInput) (*CommentOutput, error) {
+		if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+			return nil, err
+		}
+		comment, err := s.comments.SetStatus(ctx, input.PostID, input.ID, input.Body.Status)
+		if err != nil {
+			return nil, err
+		}
+		return &CommentOutput{Body: comment}, nil
+	})
+
+	// Delete Comment
+	huma.Register(api, huma.Operation{
+		OperationID:   "delete-v1-posts-by-id-comments-by-comment-id",
+		Method:        http.MethodDelete,
+		Path:          "/v1/posts/{id}/comments/{commentID}",
+		Summary:       "Delete a comment",
+		Description:   "Delete a comment on a post, along with the replies to it and theirs.",
+		Errors:        []int{http.StatusNotFound},
+		DefaultStatus: http.StatusNoContent,
+	}, func(ctx context.Context, input *CommentIDInput) (*struct{}, error) {
+		return nil, s.comments.Delete(ctx, input.PostID, input.ID)
+	})
+
+	// List Notifications
+	huma.Register(api, huma.Operation{
+		OperationID: "get-v1-notifications",
+		Method:      http.MethodGet,
+		Path:        "/v1/notifications",
+		Summary:     "List your notifications",
+		Description: "Get a page of the notifications of the user the token acts as, newest first, with how many are unread. Users are notified when their account is created, when someone comments on their post or replies to their comment, and when a post or comment @mentions their username. Notifications are kept in memory, so they start empty on every restart. An API key needs the `notifications:read` scope.",
+		Errors:      []int{http.StatusUnauthorized, http.StatusForbidden},
+		Security:    scoped(ScopeNotificationsRead),
+	}, func(ctx context.Context, input *ListNotificationsInput) (*NotificationsListOutput, error) {
+		notes, unread := s.notifications.List(principalFrom(ctx).UserID, input.Unread)
+		page, total := pageOf(&input.PageInput, notes)
+		return &NotificationsListOutput{
+			TotalCount: total,
+			Link:       input.links(total),
+			Body:       &NotificationsListResponse{Notifications: page, UnreadCount: unread, Pagination: input.pagination(total)},
+		}, nil
+	})
+
+	// Mark Notification Read
+	huma.Register(api, huma.Operation{
+		OperationID: "post-v1-notifications-by-id-read",
+		Method:      http.MethodPost,
+		Path:        "/v1/notifications/{id}/read",
+		Summary:     "Mark a notification read",
+		Description: "Mark one of your notifications read. Marking it again keeps the time it was first read. An API key needs the `notifications:write` scope.",
+		Errors:      []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+		Security:    scoped(ScopeNotificationsWrite),
+	}, func(ctx context.Context, input *NotificationIDInput) (*NotificationOutput, error) {
+		note, err := s.notifications.MarkRead(principalFrom(ctx).UserID, input.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &NotificationOutput{Body: note}, nil
+	})
+
+	// Current User
+	huma.Register(api, huma.Operation{
+		OperationID: "get-v1-me",
+		Method:      http.MethodGet,
+		Path:        "/v1/me",
+		Summary:     "Get the current user",
+		Description: "Get the user a user token or API key acts as and, for an impersonation token, who is acting as them, or for an API key, its scopes. An API key needs the `users:read` scope.",
+		Errors:      []int{http.StatusUnauthorized, http.StatusForbidden},
+		Security:    scoped(ScopeUsersRead),
+	}, s.currentUser)
+
+	// API Keys
+	huma.Register(api, huma.Operation{
+		OperationID: "get-v1-api-keys",
+		Method:      http.MethodGet,
+		Path:        "/v1/api-keys",
+		Summary:     "List your API keys",
+		Description: "List the API keys of the user the token acts as, oldest first, with their scopes and when each was last used. Only the start of each key is shown. Needs a user token; API keys can't manage API keys.",
+		Errors:      []int{http.StatusUnauthorized, http.StatusForbidden},
+		Security:    userTokenSecurity,
+	}, s.listAPIKeys)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "post-v1-api-keys",
+		Method:        http.MethodPost,
+		Path:          "/v1/api-keys",
+		Summary:       "Create an API key",
+		Description:   "Create an API key acting as you, for scripts and integrations, limited to the given scopes and optionally expiring. The key is only shown in this response; send it as `Authorization: Bearer <key>`. A user can have up to 20 keys. Keys stop working when the user is deactivated. Recorded in the audit log as `security.api_key_created`. Needs a user token.",
+		Errors:        []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusConflict, http.StatusUnprocessableEntity},
+		DefaultStatus: http.StatusCreated,
+		Security:      userTokenSecurity,
+		Metadata:      deduplicated(doubleSubmitWindow),
+	}, s.createAPIKey)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "post-v1-api-keys-by-id-rotate",
+		Method:      http.MethodPost,
+		Path:        "/v1/api-keys/{id}/rotate",
+		Summary:     "Rotate an API key",
+		Description: "Replace the secret of one of your API keys, keeping its ID, name and scopes. The old key stops working at once; the new one is only shown in this response. Recorded in the audit log as `security.api_key_rotated`. Needs a user token.",
+		Errors:      []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+		Security:    userTokenSecurity,
+	}, s.rotateAPIKey)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "delete-v1-api-keys-by-id",
+		Method:        http.MethodDelete,
+		Path:          "/v1/api-keys/{id}",
+		Summary:       "Revoke an API key",
+		Description:   "Delete one of your API keys; it stops working at once. Recorded in the audit log as `security.api_key_revoked`. Needs a user token.",
+		Errors:        []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+		DefaultStatus: http.StatusNoContent,
+		Security:      userTokenSecurity,
+	}, s.revokeAPIKey)
+
+	// Invitations
+	huma.Register(api, huma.Operation{
+		OperationID:   "post-v1-invitations",
+		Method:        http.MethodPost,
+		Path:          "/v1/invitations",
+		Summary:       "Invite someone to create an account",
+		Description:   "Create a pending invitation for an email no user has, valid for 7 days unless `expires_at` says otherwise. The token to accept it with is only shown in this response. With APP_URL set, it is also emailed as a link to `<APP_URL>/invitations/accept?token=...`, in the invitation's locale. An email can have one pending invitation at a time. Invitations are kept in memory on the replica that created them, so they are lost on restart. Requires the admin token.",
+		Errors:        []int{http.StatusUnauthorized, http.StatusConflict, http.StatusUnprocessableEntity},
+		DefaultStatus: http.StatusCreated,
+		Security:      adminSecurity,
+		Metadata:      deduplicated(doubleSubmitWindow),
+	}, s.createInvitation)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-v1-invitations",
+		Method:      http.MethodGet,
+		Path:        "/v1/invitations",
+		Summary:     "List invitations",
+		Description: "List the invitations on this replica, newest first, optionally only those with a status. Requires the admin token.",
+		Errors:      []int{http.StatusUnauthorized, http.StatusUnprocessableEntity},
+		Security:    adminSecurity,
+	}, s.listInvitations)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "delete-v1-invitations-by-id",
+		Method:        http.MethodDelete,
+		Path:          "/v1/invitations/{id}",
+		Summary:       "Revoke an invitation",
+		Description:   "Revoke an invitation that hasn't been accepted; its token stops working at once. It is still listed, as revoked. Revoking it again is a no-op. Requires the admin token.",
+		Errors:        []int{http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict},
+		DefaultStatus: http.StatusNoContent,
+		Security:      adminSecurity,
+	}, s.revokeInvitation)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "post-v1-invitations-by-token-accept",
+		Method:        http.MethodPost,
+		Path:          "/v1/invitations/{token}/accept",
+		Summary:       "Accept an invitation",
+		Description:   "Create the user a pending invitation invites, with its email and the password given, who can then log in. The name defaults to the invitation's. A token that is unknown or revoked gets 404 `INVITATION_NOT_FOUND`, an expired one 410 `INVITATION_EXPIRED`, and one already accepted 409.",
+		Errors:        []int{http.StatusNotFound, http.StatusConflict, http.StatusGone, http.StatusUnprocessableEntity},
+		DefaultStatus: http.StatusCreated,
+	}, s.acceptInvitation)
+
+	// Exports
+	huma.Register(api, huma.Operation{
+		OperationID:   "post-v1-exports",
+		Method:        http.MethodPost,
+		Path:          "/v1/exports",
+		Summary:       "Export users in the background",
+		Description:   "Start exporting the users the filters select, which are those of get-v1-users, as NDJSON or CSV, in ID order. The export is built in the background, so it takes no longer than the request to start it; poll the `Location` it returns until `status` is `completed`, then fetch its `download_url`. Exports are kept in memory on the replica that built them for a day after they finish, and are lost on restart. Requires the admin token.",
+		Errors:        []int{http.StatusUnauthorized, http.StatusUnprocessableEntity},
+		DefaultStatus: http.StatusAccepted,
+		Security:      adminSecurity,
+		Metadata:      deduplicated(doubleSubmitWindow),
+	}, s.createExport)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-v1-exports-by-id",
+		Method:      http.MethodGet,
+		Path:        "/v1/exports/{id}",
+		Summary:     "Get an export",
+		Description: "Get the status of an export. Once it has completed, `download_url` is a link to the file that works for an hour without credentials; each request returns a fresh one. Requires the admin token.",
+		Errors:      []int{http.StatusUnauthorized, http.StatusNotFound},
+		Security:    adminSecurity,
+	}, s.getExport)
+
+	// Downloads
+	huma.Register(api, huma.Operation{
+		OperationID: "get-v1-downloads-by-kind-by-id",
+		Method:      http.MethodGet,
+		Path:        "/v1/downloads/{kind}/{id}",
+		Summary:     "Download a file through a signed link",
+		Description: "Download a file through a time-limited signed link another operation handed out, such as the `download_url` of get-v1-exports-by-id. The link's signature stands in for credentials, so it can be opened in a browser or passed to another service. A link that was altered or has expired gets 403 `INVALID_SIGNATURE`.",
+		Errors:      []int{http.StatusForbidden, http.StatusNotFound, http.StatusUnprocessableEntity},
+		Metadata:    concurrencyGroup(GroupExports),
+		Responses: map[string]*huma.Response{
+			"200": {
+				Description: "The file",
+				Content: map[string]*huma.MediaType{
+					"application/x-ndjson": {Schema: &huma.Schema{Type: "string", Format: "binary"}},
+					"text/csv":             {Schema: &huma.Schema{Type: "string", Format: "binary"}},
+				},
+			},
+		},
+	}, s.download)
+
+	// Stats
+	huma.Register(api, huma.Operation{
+		OperationID: "get-v1-stats",
+		Method:      http.MethodGet,
+		Path:        "/v1/stats",
+		Summary:     "Get aggregate counts",
+		Description: "Count the users, in total, created today and by status and tag, and the posts and comments. The store counts them in place rather than listing everything. With `STATS_CACHE_TTL` set, the counts are reused for that long; `computed_at` says when they were taken. Requires the admin token.",
+		Errors:      []int{http.StatusUnauthorized},
+		Security:    adminSecurity,
+	}, s.getStats)
+
+	// Log In
+	huma.Register(api, huma.Operation{
+		OperationID: "post-v1-auth-login",
+		Method:      http.MethodPost,
+		Path:        "/v1/auth/login",
+		Summary:     "Log in",
+		Description: "Exchange an active user's username or email and password for a user token valid for an hour. After a few failed logins in a row, each further attempt has to wait, longer every time (429 `LOGIN_THROTTLED`); after LOGIN_MAX_FAILURES the account is locked for LOGIN_LOCKOUT (423 `ACCOUNT_LOCKED`), and an address with LOGIN_MAX_FAILURES_PER_IP failures is blocked as long. Both come with Retry-After. Failures, lockouts and blocks are recorded in the audit log. When the server has CAPTCHA_PROVIDER set, the request needs an `X-Captcha-Token`.",
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusLocked, http.StatusTooManyRequests, http.StatusServiceUnavailable},
+	}, s.login)
+
+	// Introspect Token
+	huma.Register(api, huma.Operation{
+		OperationID: "post-v1-auth-introspect",
+		Method:      http.MethodPost,
+		Path:        "/v1/auth/introspect",
+		Summary:     "Introspect a token",
+		Description: "Say whether a user token or API key works, in the shape of an RFC 7662 introspection response, taking the token as JSON rather than a form: `active` is false, and nothing else is set, for a token that is malformed, forged, expired or revoked. For one that works it says who the token acts as, until when and, for an API key, with which scopes. Holding the token is all it takes.",
+		Errors:      []int{http.StatusServiceUnavailable},
+	}, s.introspectToken)
+
+	// Revoke Token
+	huma.Register(api, huma.Operation{
+		OperationID:   "post-v1-auth-revoke",
+		Method:        http.MethodPost,
+		Path:          "/v1/auth/revoke",
+		Summary:       "Revoke a token",
+		Description:   "Make a user token or API key stop working at once, before it expires, as in RFC 7009; holding the token is all it takes. A revoked user token is refused with 401 `INVALID_TOKEN`, and an API key is deleted, as `delete-v1-api-keys-by-id` does. A token that already doesn't work is accepted as revoked. Recorded in the audit log as `security.token_revoked` or `security.api_key_revoked`.",
+		DefaultStatus: http.StatusOK,
+		Errors:        []int{http.StatusServiceUnavailable},
+	}, s.revokeToken)
+
+	// Set Log Level
+	huma.Register(api, huma.Operation{
+		OperationID: "put-admin-loglevel",
+		Method:      http.MethodPut,
+		Path:        "/admin/loglevel",
+		Summary:     "Change the log level",
+		Description: "Change the minimum log level of the running server, optionally going back to the configured level after `revert_after_minutes`. Requires the admin token.",
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized},
+		Security:    adminSecurity,
+	}, func(ctx context.Context, input *LogLevelInput) (*LogLevelOutput, error) {
+		if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+			return nil, err
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(input.Body.Level)); err != nil {
+			return nil, apiError(http.StatusUnprocessableEntity, CodeInvalidLogLevel, err.Error())
+		}
+		revertAfter := time.Duration(input.Body.RevertAfterMinutes) * time.Minute
+		return &LogLevelOutput{Body: s.setLogLevel(level, revertAfter)}, nil
+	})
+
+	// Back Up Store
+	huma.Register(api, huma.Operation{
+		OperationID: "get-admin-backup",
+		Method:      http.MethodGet,
+		Path:        "/admin/backup",
+		Summary:     "Back up the store",
+		Description: "Download every user, their preferences, password hashes, posts and comments as a gzipped JSON archive, which `post-admin-restore` accepts. Requires the admin token.",
+		Errors:      []int{http.StatusUnauthorized},
+		Security:    adminSecurity,
+		Responses: map[string]*huma.Response{
+			"200": {
+				Description: "Backup archive",
+				Content:     map[string]*huma.MediaType{backupContentType: {Schema: &huma.Schema{Type: "string", Format: "binary"}}},
+			},
+		},
+	}, s.backup)
+
+	// Restore Store
+	huma.Register(api, huma.Operation{
+		OperationID:  "post-admin-restore",
+		Method:       http.MethodPost,
+		Path:         "/admin/restore",
+		Summary:      "Restore the store from a backup",
+		Description:  "Replace every user, their preferences, posts and comments with the contents of an archive from `get-admin-backup`. Users not in the archive are deleted. Fails with 409 while another restore is in progress, on this or another replica. Requires the admin token.",
+		Errors:       []int{http.StatusUnauthorized, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity},
+		Security:     adminSecurity,
+		MaxBodyBytes: maxBackupBytes,
+	}, s.restore)
+
+	// Re-encrypt PII
+	huma.Register(api, huma.Operation{
+		OperationID: "post-admin-reencrypt",
+		Method:      http.MethodPost,
+		Path:        "/admin/reencrypt",
+		Summary:     "Re-encrypt users' PII",
+		Description: "Rewrite every user's email and phone that is plaintext or encrypted under an older key under the primary key, so the older key can be removed. Fails with 409 if field encryption is off. Requires the admin token.",
+		Errors:      []int{http.StatusUnauthorized, http.StatusConflict},
+		Security:    adminSecurity,
+	}, s.reencryptUsers)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "post-admin-retention",
+		Method:      http.MethodPost,
+		Path:        "/admin/retention",
+		Summary:     "Apply the retention policy",
+		Description: "Run the configured retention rules now instead of waiting for the next scheduled run: purge users soft-deleted longer ago than RETENTION_DELETED_USERS and audit entries older than RETENTION_AUDIT. With dry_run it only reports what would be purged. Fails with 409 while another run is in progress, on this or another replica. Requires the admin token.",
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusConflict},
+		Security:    adminSecurity,
+	}, s.applyRetention)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "post-admin-digest",
+		Method:      http.MethodPost,
+		Path:        "/admin/digest",
+		Summary:     "Send the activity digests",
+		Description: "Send the activity digest email now to every active user whose preferences ask for the `period` digest, instead of waiting for DIGEST_AT. It summarizes the users created and changed in the last day or week, from the audit log, in each user's locale. With dry_run it only counts who would get one. Fails with 409 while the period's digests are being sent, on this or another replica. Requires the admin token.",
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusConflict},
+		Security:    adminSecurity,
+	}, s.applyDigest)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "post-admin-impersonate-by-user-id",
+		Method:      http.MethodPost,
+		Path:        "/admin/impersonate/{userID}",
+		Summary:     "Impersonate a user",
+		Description: "Issue a short-lived user token that acts as the user, with an `impersonated_by` claim naming `actor`, so support staff can reproduce what the user sees. Issuing it and every request made with it are recorded in the audit log; requests made with it don't count as the user being seen. Requires the admin token.",
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound},
+		Security:    adminSecurity,
+	}, s.impersonate)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-admin-maintenance",
+		Method:      http.MethodGet,
+		Path:        "/admin/maintenance",
+		Summary:     "Get the maintenance mode",
+		Description: "Report whether this replica is in maintenance mode, and since when. Requires the admin token.",
+		Errors:      []int{http.StatusUnauthorized},
+		Security:    adminSecurity,
+	}, s.getMaintenance)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-admin-leader",
+		Method:      http.MethodGet,
+		Path:        "/admin/leader",
+		Summary:     "Get the leader",
+		Description: "Report which replica leads, running the scheduled retention and digests, and whether the one answering does. The leader is the raft leader with RAFT_NODE_ID set, or else whoever holds the leader lock in REDIS_URLS; without either, every replica leads itself. Requires the admin token.",
+		Errors:      []int{http.StatusUnauthorized, http.StatusServiceUnavailable},
+		Security:    adminSecurity,
+	}, s.getLeader)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-admin-health-history",
+		Method:      http.MethodGet,
+		Path:        "/admin/health/history",
+		Summary:     "Get the health history",
+		Description: "Report what this replica's health probes found: it probes its readiness, as /readyz answers it, every HEALTH_PROBE_INTERVAL (10s by default) and keeps the latest 8640 outcomes. `uptime_percent` is the share of those that found it up, and `transitions` are the latest 100 changes between up and down, newest first, with why it was down. The history is kept in memory and starts over on restart. Requires the admin token.",
+		Errors:      []int{http.StatusUnauthorized},
+		Security:    adminSecurity,
+	}, s.getHealthHistory)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "put-admin-maintenance",
+		Method:      http.MethodPut,
+		Path:        "/admin/maintenance",
+		Summary:     "Switch maintenance mode",
+		Description: "Put this replica in maintenance mode, or take it out. `on` answers every request but health, metrics, version, the docs and the admin API with 503 `MAINTENANCE` and Retry-After; `read_only` still serves GET and HEAD. Background writes, like recording user activity and scheduled retention, pause too. Holds until changed again or MAINTENANCE_MODE changes on reload. Requires the admin token.",
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized},
+		Security:    adminSecurity,
+	}, s.putMaintenance)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-admin-requests",
+		Method:      http.MethodGet,
+		Path:        "/admin/requests",
+		Summary:     "List recent requests",
+		Description: "List the latest requests this replica served, newest first, with their responses, for debugging clients. Headers, parameters and bodies are redacted like the logs, and bodies truncated to 4 KiB. Health checks and metrics scrapes are left out. Only available with CAPTURE_REQUESTS set, or in dev mode. Requires the admin token.",
+		Errors:      []int{http.StatusUnauthorized, http.StatusNotFound, http.StatusUnprocessableEntity},
+		Security:    adminSecurity,
+	}, s.listCaptured)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "delete-admin-requests",
+		Method:      http.MethodDelete,
+		Path:        "/admin/requests",
+		Summary:     "Clear the recent requests",
+		Description: "Drop the requests GET /admin/requests lists, e.g. before reproducing a problem. Requires the admin token.",
+		Errors:      []int{http.StatusUnauthorized, http.StatusNotFound},
+		Security:    adminSecurity,
+	}, s.clearCaptured)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-admin-inflight",
+		Method:      http.MethodGet,
+		Path:        "/admin/inflight",
+		Summary:     "List in-flight requests",
+		Description: "List the requests this replica is serving, oldest first, with their route, client and how long they have been running, to find the one holding the server up. Requires the admin token.",
+		Errors:      []int{http.StatusUnauthorized},
+		Security:    adminSecurity,
+	}, s.listInFlight)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "post-admin-inflight-by-id-cancel",
+		Method:      http.MethodPost,
+		Path:        "/admin/inflight/{id}/cancel",
+		Summary:     "Cancel an in-flight request",
+		Description: "Cancel the context of the request with the X-Request-ID, e.g. a stuck export, so its handler gives up. A request canceled before it started its response gets a 503 `REQUEST_CANCELED`; one already streaming its response is cut short. The work a handler doesn't check its context for may still be done. Returns the canceled requests, more than one if clients sent the same ID. Requires the admin token.",
+		Errors:      []int{http.StatusUnauthorized, http.StatusNotFound},
+		Security:    adminSecurity,
+	}, s.cancelInFlight)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-admin-telemetry",
+		Method:      http.MethodGet,
+		Path:        "/admin/telemetry",
+		Summary:     "Show the usage report",
+		Description: "Show whether this replica sends anonymous usage reports, which it only does with TELEMETRY=true, where and how often, and exactly what the next report would hold were it sent now: how many requests each operation got, the version, the platform and the store backend. Nothing about users, their data or addresses, or the configuration is reported. Requires the admin token.",
+		Errors:      []int{http.StatusUnauthorized},
+		Security:    adminSecurity,
+	}, s.getTelemetry)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-admin-faults",
+		Method:      http.MethodGet,
+		Path:        "/admin/faults",
+		Summary:     "List the fault rules",
+		Description: "List the rules this replica injects faults with. Only available with FAULT_INJECTION set, or in dev mode. Requires the admin token.",
+		Errors:      []int{http.StatusUnauthorized, http.StatusNotFound},
+		Security:    adminSecurity,
+	}, s.getFaults)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "put-admin-faults",
+		Method:      http.MethodPut,
+		Path:        "/admin/faults",
+		Summary:     "Set the fault rules",
+		Description: "Replace the rules this replica injects faults with, to exercise clients' timeouts and retries: each makes a share of a route's requests wait, fail with a 5xx `INJECTED_FAULT`, or lose their connection. The admin API and probes are never hit. An empty list turns faults off. Rules live in memory, per replica. Only available with FAULT_INJECTION set, or in dev mode. Requires the admin token.",
+		Errors:      []int{http.StatusUnauthorized, http.StatusNotFound, http.StatusUnprocessableEntity},
+		Security:    adminSecurity,
+	}, s.putFaults)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-admin-ratelimits",
+		Method:      http.MethodGet,
+		Path:        "/admin/ratelimits",
+		Summary:     "List the API key quotas",
+		Description: "List every API key with a quota or with requests counted on this replica, with its daily and monthly caps and the requests it made in the current UTC day and month. Requires the admin token.",
+		Errors:      []int{http.StatusUnauthorized},
+		Security:    adminSecurity,
+	}, s.listRateLimits)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-admin-ratelimits-by-key-id",
+		Method:      http.MethodGet,
+		Path:        "/admin/ratelimits/{keyID}",
+		Summary:     "Get an API key's quota",
+		Description: "Get an API key's daily and monthly caps, zero if it has none, and the requests it made on this replica in the current UTC day and month. Requires the admin token.",
+		Errors:      []int{http.StatusUnauthorized},
+		Security:    adminSecurity,
+	}, s.getRateLimit)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "put-admin-ratelimits-by-key-id",
+		Method:      http.MethodPut,
+		Path:        "/admin/ratelimits/{keyID}",
+		Summary:     "Set an API key's quota",
+		Description: "Cap the requests an API key makes per UTC day and month. Once either is used up, its requests get 429 `QUOTA_EXCEEDED` with Retry-After until the period starts over; the admin API and probes are never refused. Lowering a cap below the requests already made takes effect at once. Quotas and counts live in memory, per replica. Requires the admin token.",
+		Errors:      []int{http.StatusUnauthorized, http.StatusUnprocessableEntity},
+		Security:    adminSecurity,
+	}, s.putRateLimit)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID:   "delete-admin-ratelimits-by-key-id",
+		Method:        http.MethodDelete,
+		Path:          "/admin/ratelimits/{keyID}",
+		Summary:       "Lift an API key's quota",
+		Description:   "Remove an API key's caps. Its requests are still counted. Requires the admin token.",
+		Errors:        []int{http.StatusUnauthorized, http.StatusNotFound},
+		DefaultStatus: http.StatusNoContent,
+		Security:      adminSecurity,
+	}, s.deleteRateLimit)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-admin-traffic-blocks",
+		Method:      http.MethodGet,
+		Path:        "/admin/traffic/blocks",
+		Summary:     "List the clients the traffic analyzer acted on",
+		Description: "List the client addresses this replica's traffic analyzer has banned, or put to a CAPTCHA, and not yet let go, with why and until when. Requires the admin token.",
+		Errors:      []int{http.StatusUnauthorized},
+		Security:    adminSecurity,
+	}, s.listTrafficBlocks)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID:   "delete-admin-traffic-blocks-by-ip",
+		Method:        http.MethodDelete,
+		Path:          "/admin/traffic/blocks/{ip}",
+		Summary:       "Let a client go",
+		Description:   "Lift a ban or CAPTCHA the traffic analyzer put on a client address on this replica before it runs out. Requires the admin token.",
+		Errors:        []int{http.StatusUnauthorized, http.StatusNotFound},
+		DefaultStatus: http.StatusNoContent,
+		Security:      adminSecurity,
+	}, s.liftTrafficBlock)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-dev-emails-by-name",
+		Method:      http.MethodGet,
+		Path:        "/dev/emails/{name}",
+		Summary:     "Preview an email",
+		Description: "Render one of the emails the API sends, with made-up data, as HTML for a browser or as the plain-text part. The subject comes in X-Email-Subject. Only available in dev mode.",
+		Errors:      []int{http.StatusNotFound},
+		Responses: map[string]*huma.Response{
+			"200": {
+				Description: "Rendered email",
+				Content: map[string]*huma.MediaType{
+					"text/html":  {Schema: &huma.Schema{Type: "string"}},
+					"text/plain": {Schema: &huma.Schema{Type: "string"}},
+				},
+			},
+		},
+	}, s.previewEmail)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "post-admin-unlock-by-user-id",
+		Method:      http.MethodPost,
+		Path:        "/admin/unlock/{userID}",
+		Summary:     "Unlock a user's account",
+		Description: "Clear a user's failed logins, lifting a lockout from too many of them before LOGIN_LOCKOUT runs out. Failures counted against client addresses stay. The unlock is recorded in the audit log. Requires the admin token.",
+		Errors:      []int{http.StatusUnauthorized, http.StatusNotFound},
+		Security:    adminSecurity,
+	}, s.unlock)
+
+	// Events
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-v1-events",
+		Method:      http.MethodGet,
+		Path:        "/v1/events",
+		Summary:     "List the events",
+		Description: "List the domain events published on this replica from `from_seq` on, oldest first, as the audit log records them under sequence numbers; pass `next_seq` as `from_seq` to get the next page. The log is kept in memory, and with AUDIT_LOG_PATH in a file too, so it outlives restarts; `oldest_seq` tells whether events before it are gone. Requires the admin token.",
+		Errors:      []int{http.StatusUnauthorized, http.StatusUnprocessableEntity},
+		Security:    adminSecurity,
+	}, s.listEvents)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "post-admin-events-replay",
+		Method:      http.MethodPost,
+		Path:        "/admin/events/replay",
+		Summary:     "Replay events",
+		Description: "Deliver the events from `from_seq` to `to_seq` again, up to 1000 at a time, to the consumers outside the API that may have missed them during an outage: the security event stream, marked `replayed`, and the CDN purges. The receivers get the events' original IDs, to skip those they had. Fails with 409 if neither is configured. Requires the admin token.",
+		Errors:      []int{http.StatusUnauthorized, http.StatusConflict, http.StatusUnprocessableEntity},
+		Security:    adminSecurity,
+	}, s.replayEvents)
+
+	// Dead letters
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-admin-dead-letters",
+		Method:      http.MethodGet,
+		Path:        "/admin/dead-letters",
+		Summary:     "List dead letters",
+		Description: "List the background deliveries that failed every attempt, newest first: security events the SECURITY_EVENTS sink didn't take and purges the CDN refused. Each has what was to be delivered and every failed attempt with its error. The queue is kept in memory on this replica, holds up to 10000, and is lost on restart. Requires the admin token.",
+		Errors:      []int{http.StatusUnauthorized, http.StatusUnprocessableEntity},
+		Security:    adminSecurity,
+	}, s.listDeadLetters)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-admin-dead-letters-by-id",
+		Method:      http.MethodGet,
+		Path:        "/admin/dead-letters/{id}",
+		Summary:     "Get a dead letter",
+		Description: "Get a dead letter with its payload and error history. Requires the admin token.",
+		Errors:      []int{http.StatusUnauthorized, http.StatusNotFound},
+		Security:    adminSecurity,
+	}, s.getDeadLetter)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID:   "post-admin-dead-letters-by-id-requeue",
+		Method:        http.MethodPost,
+		Path:          "/admin/dead-letters/{id}/requeue",
+		Summary:       "Requeue a dead letter",
+		Description:   "Put a dead letter back on its queue, to be delivered again once. It is listed as `requeued` until it is delivered, when it leaves the queue, or fails again, when it is `dead` again with the new attempt in its history. Fails with 409 if it is already requeued. Requires the admin token.",
+		Errors:        []int{http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict},
+		DefaultStatus: http.StatusAccepted,
+		Security:      adminSecurity,
+	}, s.requeueDeadLetter)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID:   "delete-admin-dead-letters-by-id",
+		Method:        http.MethodDelete,
+		Path:          "/admin/dead-letters/{id}",
+		Summary:       "Discard a dead letter",
+		Description:   "Drop a dead letter without delivering it. Requires the admin token.",
+		Errors:        []int{http.StatusUnauthorized, http.StatusNotFound},
+		DefaultStatus: http.StatusNoContent,
+		Security:      adminSecurity,
+	}, s.deleteDeadLetter)
+}
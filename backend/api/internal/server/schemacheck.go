@@ -0,0 +1,222 @@
+package server
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// maxCheckedBody is the largest response body checked against its schema;
+// the bodies of larger ones aren't.
+const maxCheckedBody = 1 << 20
+
+// linesKey is the operation metadata linesOf puts the schema of an NDJSON
+// response's lines under.
+const linesKey = "lines"
+
+// linesOf is the Metadata of an operation answering newline-delimited JSON
+// with a t on each line, which the spec can only document as binary, so
+// Config.ValidateResponses checks the lines against t's schema.
+func linesOf(t reflect.Type) map[string]any {
+	return map[string]any{linesKey: t}
+}
+
+// SchemaViolation is a response that doesn't match what the spec documents
+// for its operation and status.
+type SchemaViolation struct {
+	OperationID string
+	Method      string
+	Path        string
+	Status      int
+	ContentType string
+	// Errors says how it doesn't match, as in "expected required property
+	// id to be present (body)" or "status 418 is not documented".
+	Errors []string
+}
+
+// schemaCheck checks the responses of the operations against the spec, to
+// catch what huma's types don't pin down drifting from it: the bodies of
+// streams and downloads written straight to the connection, the changes of
+// the transformers and router middlewares, and statuses and content types
+// nothing documents.
+type schemaCheck struct {
+	registry huma.Registry
+	formats  map[string]bool            // content types huma negotiates
+	ops      map[string]*huma.Operation // by method and path
+	lines    map[*huma.Operation]*huma.Schema
+	logger   *slog.Logger
+	report   func(SchemaViolation)
+}
+
+// newSchemaCheck indexes the operations of spec, once every route is
+// registered. The formats are those of the API's config.
+func newSchemaCheck(spec *huma.OpenAPI, formats map[string]huma.Format, logger *slog.Logger, report func(SchemaViolation)) *schemaCheck {
+	c := &schemaCheck{
+		registry: spec.Components.Schemas,
+		formats:  map[string]bool{},
+		ops:      map[string]*huma.Operation{},
+		lines:    map[*huma.Operation]*huma.Schema{},
+		logger:   logger,
+		report:   report,
+	}
+	for ct := range formats {
+		if strings.Contains(ct, "/") {
+			c.formats[ct] = true
+		}
+	}
+	for _, item := range spec.Paths {
+		for _, op := range []*huma.Operation{item.Get, item.Put, item.Post, item.Delete, item.Patch} {
+			if op == nil {
+				continue
+			}
+			c.ops[op.Method+" "+op.Path] = op
+			if t, ok := op.Metadata[linesKey].(reflect.Type); ok {
+				c.lines[op] = c.registry.Schema(t, true, "")
+			}
+		}
+	}
+	return c
+}
+
+// validateResponses checks, with Config.ValidateResponses or in dev mode,
+// every response an operation served against its schema, and logs those
+// that don't match. It keeps a copy of each body to do so, which is why it
+// is for dev and tests only. Responses to requests no route matched, or
+// that were refused before the router got to routing them, aren't checked.
+func (s *Server) validateResponses(next http.Handler) http.Handler {
+	if !s.cfg.ValidateResponses && !s.cfg.Dev {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &checkedWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		if s.schemas == nil {
+			return
+		}
+		op := s.schemas.ops[r.Method+" "+routePattern(r)]
+		if op == nil {
+			return
+		}
+		contentType := w.Header().Get("Content-Type")
+		if errs := s.schemas.check(op, rec.status, contentType, &rec.body, rec.over); len(errs) > 0 {
+			s.schemas.violated(SchemaViolation{
+				OperationID: op.OperationID,
+				Method:      r.Method,
+				Path:        op.Path,
+				Status:      rec.status,
+				ContentType: contentType,
+				Errors:      errs,
+			})
+		}
+	})
+}
+
+func (c *schemaCheck) violated(v SchemaViolation) {
+	c.logger.Warn("response doesn't match the spec", "operation", v.OperationID, "status", v.Status, "content_type", v.ContentType, "errors", v.Errors)
+	if c.report != nil {
+		c.report(v)
+	}
+}
+
+// check returns how a response of op doesn't match the spec, if it doesn't.
+func (c *schemaCheck) check(op *huma.Operation, status int, contentType string, body *bytes.Buffer, over bool) []string {
+	resp := op.Responses[strconv.Itoa(status)]
+	if resp == nil {
+		resp = op.Responses["default"]
+	}
+	if resp == nil {
+		return []string{fmt.Sprintf("status %d is not documented", status)}
+	}
+	if body.Len() == 0 || over {
+		return nil
+	}
+	ct, _, _ := mime.ParseMediaType(contentType)
+	// Some are documented with their parameters.
+	mt := cmp.Or(resp.Content[contentType], resp.Content[ct])
+	if mt == nil && c.formats[ct] && jsonMediaType(resp.Content) != nil {
+		// Another format of the values the JSON documents, which the
+		// client asked for.
+		return nil
+	}
+	if mt == nil {
+		return []string{fmt.Sprintf("content type %q is not documented for status %d", ct, status)}
+	}
+	switch {
+	case ct == ndjsonContentType:
+		if schema := c.lines[op]; schema != nil {
+			return c.validateLines(schema, body.Bytes())
+		}
+	case ct == "application/json" || strings.HasSuffix(ct, "+json"):
+		if mt.Schema != nil {
+			return c.validate(mt.Schema, body.Bytes(), "body")
+		}
+	}
+	return nil
+}
+
+func (c *schemaCheck) validateLines(schema *huma.Schema, body []byte) []string {
+	var errs []string
+	for n, line := range bytes.Split(bytes.TrimSuffix(body, []byte("\n")), []byte("\n")) {
+		errs = append(errs, c.validate(schema, line, fmt.Sprintf("line[%d]", n))...)
+	}
+	return errs
+}
+
+func (c *schemaCheck) validate(schema *huma.Schema, data []byte, path string) []string {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return []string{fmt.Sprintf("%s is not JSON: %v", path, err)}
+	}
+	res := &huma.ValidateResult{}
+	huma.Validate(c.registry, schema, huma.NewPathBuffer([]byte(path), len(path)), huma.ModeReadFromServer, v, res)
+	errs := make([]string, len(res.Errors))
+	for i, err := range res.Errors {
+		// Without the values, which can be whole bodies.
+		if d, ok := err.(*huma.ErrorDetail); ok {
+			errs[i] = fmt.Sprintf("%s (%s)", d.Message, d.Location)
+		} else {
+			errs[i] = err.Error()
+		}
+	}
+	return errs
+}
+
+// checkedWriter passes a response through while keeping its status and up
+// to maxCheckedBody of its body.
+type checkedWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+	over   bool // more than maxCheckedBody
+}
+
+func (c *checkedWriter) WriteHeader(status int) {
+	c.status = status
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *checkedWriter) Write(p []byte) (int, error) {
+	if !c.over {
+		if c.body.Len()+len(p) > maxCheckedBody {
+			c.over = true
+			c.body = bytes.Buffer{}
+		} else {
+			c.body.Write(p)
+		}
+	}
+	return c.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (c *checkedWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestResponseValidation(t *testing.T) {
+	var violations []SchemaViolation
+	store := NewMemoryStore()
+	// A user the store lets through that the spec doesn't, as if an older
+	// version had written it.
+	store.PutUser(context.Background(), &User{ID: "drifted", Name: "Drifted", Email: "drifted@example.com", Status: "archived"})
+	s := NewServer(Config{ValidateResponses: true, OnSchemaViolation: func(v SchemaViolation) { violations = append(violations, v) }}, store)
+	for _, path := range []string{"/v1/users/drifted", "/v1/users/stream?include_inactive=true", "/version"} {
+		s.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	var ops []string
+	for _, v := range violations {
+		ops = append(ops, v.OperationID)
+		if len(v.Errors) == 0 || !strings.Contains(v.Errors[0], "status") {
+			t.Errorf("%s violation %q, want one about the status", v.OperationID, v.Errors)
+		}
+	}
+	// The stream's lines are checked against the User schema, though the
+	// spec can only call them binary.
+	if want := []string{"get-v1-users-by-id", "get-v1-users-stream"}; !slices.Equal(ops, want) {
+		t.Errorf("violations of %v, want %v", ops, want)
+	}
+}
@@ -0,0 +1,313 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/search"
+)
+
+type SearchUsersInput struct {
+	TimeBudgetInput
+	Q     string `query:"q" required:"true" minLength:"1" maxLength:"100" doc:"Prefix to match against names and emails" example:"ro"`
+	Limit int    `query:"limit" minimum:"1" maximum:"50" default:"10" doc:"Maximum number of results"`
+}
+
+// UserSuggestion is the trimmed-down user returned to autocomplete widgets.
+type UserSuggestion struct {
+	ID    string `json:"id" doc:"User ID"`
+	Name  string `json:"name" redact:"true" doc:"User's name"`
+	Email string `json:"email" redact:"true" doc:"User's email"`
+}
+
+type SearchUsersResponse struct {
+	Results []UserSuggestion `json:"results" doc:"Matching users, best match first"`
+	Partial bool             `json:"partial,omitempty" doc:"true if the time budget ran out before every user was looked at; the results are the best of those that were"`
+}
+
+type SearchUsersOutput struct {
+	Body *SearchUsersResponse
+}
+
+// matchRank scores how well u matches the lowercase prefix q, lower being
+// better; ok is false if it doesn't match at all. A full name beats the
+// start of a name, which beats the start of a later word in the name, which
+// beats an email.
+func matchRank(u *User, q string) (rank int, ok bool) {
+	name := strings.ToLower(u.Name)
+	switch {
+	case name == q:
+		return 0, true
+	case strings.HasPrefix(name, q):
+		return 1, true
+	}
+	for _, word := range strings.Fields(name)[1:] {
+		if strings.HasPrefix(word, q) {
+			return 2, true
+		}
+	}
+	if strings.HasPrefix(strings.ToLower(u.Email), q) {
+		return 3, true
+	}
+	return 0, false
+}
+
+// searchUsers returns up to limit active users matching the prefix q, ranked
+// by matchRank and then alphabetically.
+func searchUsers(users []*User, q string, limit int, deadline time.Time) (results []UserSuggestion, partial bool) {
+	q = strings.ToLower(strings.TrimSpace(q))
+	type hit struct {
+		user *User
+		rank int
+	}
+	var hits []hit
+	for _, u := range users {
+		if spent(deadline) {
+			partial = true
+			break
+		}
+		if !u.Active {
+			continue
+		}
+		if rank, ok := matchRank(u, q); ok {
+			hits = append(hits, hit{u, rank})
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].rank != hits[j].rank {
+			return hits[i].rank < hits[j].rank
+		}
+		if hits[i].user.Name != hits[j].user.Name {
+			return hits[i].user.Name < hits[j].user.Name
+		}
+		return hits[i].user.ID < hits[j].user.ID
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	results = make([]UserSuggestion, len(hits))
+	for i, h := range hits {
+		results[i] = UserSuggestion{ID: h.user.ID, Name: h.user.Name, Email: h.user.Email}
+	}
+	return results, partial
+}
+
+type SearchInput struct {
+	TimeBudgetInput
+	Q     string   `query:"q" required:"true" minLength:"1" maxLength:"200" doc:"Words to search for" example:"hello world"`
+	Type  []string `query:"type" enum:"user,post" doc:"Only search these kinds of resources, comma-separated; all of them by default"`
+	Limit int      `query:"limit" minimum:"1" maximum:"50" default:"10" doc:"Maximum number of results"`
+}
+
+// SearchResult is a resource matching a search, with the user or the post
+// itself depending on its type.
+type SearchResult struct {
+	Type       string              `json:"type" enum:"user,post" doc:"Kind of resource"`
+	ID         string              `json:"id" example:"20240101120000" doc:"ID of the user or post"`
+	Score      float64             `json:"score" example:"1.25" doc:"How well it matches, higher being better; only comparable within one response"`
+	Highlights map[string][]string `json:"highlights" doc:"Fragments of the fields that matched, by field, HTML-escaped with the matched words in <mark> tags"`
+	User       *UserSuggestion     `json:"user,omitempty" doc:"The user, for type user"`
+	Post       *Post               `json:"post,omitempty" doc:"The post, for type post"`
+}
+
+type SearchResponse struct {
+	Results []SearchResult `json:"results" doc:"Matching resources, best match first"`
+	Partial bool           `json:"partial,omitempty" doc:"true if the time budget ran out before the search finished; the results are those found by then, which may be none"`
+}
+
+type SearchOutput struct {
+	Body *SearchResponse
+}
+
+// Document types in the search index.
+const (
+	searchUser = "user"
+	searchPost = "post"
+)
+
+// searchUserEvents are the events after which a user is indexed again, or
+// dropped from the index if they are gone.
+var searchUserEvents = map[string]bool{
+	"user.created": true,
+	"user.updated": true,
+	"user.deleted": true,
+	"user.purged":  true,
+}
+
+func init() {
+	for _, typ := range userStatusEvents {
+		searchUserEvents[typ] = true
+	}
+}
+
+// SearchService keeps a full-text index of active users' names, usernames
+// and emails and of posts' titles and bodies, updated from the events on the
+// bus, and searches it. Results are checked against the store, so entries
+// the index still has for users or posts that are gone, or for users that
+// are no longer active, are left out. An index update that fails is logged
+// and doesn't fail the change it follows; the next Rebuild catches up.
+type SearchService struct {
+	index  search.Index
+	store  Store
+	logger *slog.Logger
+}
+
+// NewSearchService returns a service keeping index up to date with the
+// users and posts in store as bus reports changes to them.
+func NewSearchService(index search.Index, store Store, bus *events.Bus, logger *slog.Logger) *SearchService {
+	x := &SearchService{index: index, store: store, logger: logger}
+	bus.Subscribe(x.update)
+	return x
+}
+
+var errSearchUnavailable = apiError(http.StatusServiceUnavailable, CodeSearchUnavailable, "the search index couldn't be queried")
+
+func (x *SearchService) update(e events.Event) {
+	ctx := context.Background()
+	var err error
+	switch {
+	case searchUserEvents[e.Type]:
+		err = x.updateUser(ctx, e.Subject)
+	case e.Type == "post.created", e.Type == "post.updated":
+		err = x.updatePost(ctx, eventField(e, "post_id"))
+	case e.Type == "post.deleted":
+		err = x.index.Delete(ctx, searchPost, eventField(e, "post_id"))
+	}
+	if err != nil {
+		x.logger.Error("failed to update the search index", "event", e.Type, "subject", e.Subject, "err", err)
+	}
+}
+
+func (x *SearchService) updateUser(ctx context.Context, id string) error {
+	user, err := x.store.GetUser(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return x.Forget(ctx, id)
+	}
+	if err != nil {
+		return err
+	}
+	if !user.Active {
+		return x.index.Delete(ctx, searchUser, id)
+	}
+	return x.index.Index(ctx, userDocument(user))
+}
+
+func (x *SearchService) updatePost(ctx context.Context, id string) error {
+	post, err := x.store.GetPost(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return x.index.Delete(ctx, searchPost, id)
+	}
+	if err != nil {
+		return err
+	}
+	return x.index.Index(ctx, postDocument(post))
+}
+
+func userDocument(u *User) search.Document {
+	return search.Document{Type: searchUser, ID: u.ID, Owner: u.ID, Fields: map[string]string{
+		"name": u.Name, "username": u.Username, "email": u.Email,
+	}}
+}
+
+func postDocument(p *Post) search.Document {
+	return search.Document{Type: searchPost, ID: p.ID, Owner: p.AuthorID, Fields: map[string]string{
+		"title": p.Title, "body": p.Body,
+	}}
+}
+
+// Forget drops the user and their posts from the index.
+func (x *SearchService) Forget(ctx context.Context, userID string) error {
+	if err := x.index.Delete(ctx, searchUser, userID); err != nil {
+		return err
+	}
+	return x.index.DeleteOwner(ctx, userID)
+}
+
+// Rebuild indexes every active user and every post in the store again, for
+// an index that missed changes: one that starts empty, or after the store
+// was restored.
+func (x *SearchService) Rebuild(ctx context.Context) error {
+	users, err := x.store.ListUsers(ctx)
+	if err != nil {
+		return err
+	}
+	posts, err := x.store.ListPosts(ctx)
+	if err != nil {
+		return err
+	}
+	docs := make([]search.Document, 0, len(users)+len(posts))
+	for _, u := range users {
+		if u.Active {
+			docs = append(docs, userDocument(u))
+		}
+	}
+	for _, p := range posts {
+		docs = append(docs, postDocument(p))
+	}
+	return x.index.Index(ctx, docs...)
+}
+
+// Search returns up to limit of the resources of types, or of any type,
+// matching q, best match first. There can be fewer than limit even when more
+// match, if the index had stale entries among the first. If deadline isn't
+// zero, Search returns what it has found by then, with partial true.
+func (x *SearchService) Search(ctx context.Context, q string, types []string, limit int, deadline time.Time) (results []SearchResult, partial bool, err error) {
+	results = []SearchResult{}
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return results, false, nil
+	}
+	indexCtx := ctx
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+		indexCtx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+	hits, err := x.index.Search(indexCtx, search.Query{Text: q, Types: types, Limit: limit})
+	if err != nil && spent(deadline) && ctx.Err() == nil {
+		return results, true, nil
+	}
+	if err != nil {
+		x.logger.ErrorContext(ctx, "search failed", "err", err)
+		return nil, false, errSearchUnavailable
+	}
+	for _, hit := range hits {
+		if spent(deadline) {
+			return results, true, nil
+		}
+		r := SearchResult{Type: hit.Type, ID: hit.ID, Score: hit.Score, Highlights: hit.Highlights}
+		switch hit.Type {
+		case searchUser:
+			u, err := x.store.GetUser(ctx, hit.ID)
+			if errors.Is(err, ErrNotFound) || err == nil && !u.Active {
+				continue
+			}
+			if err != nil {
+				return nil, false, err
+			}
+			r.User = &UserSuggestion{ID: u.ID, Name: u.Name, Email: u.Email}
+		case searchPost:
+			p, err := x.store.GetPost(ctx, hit.ID)
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, false, err
+			}
+			r.Post = p
+		default:
+			continue
+		}
+		if r.Highlights == nil {
+			r.Highlights = map[string][]string{}
+		}
+		results = append(results, r)
+	}
+	return results, false, nil
+}
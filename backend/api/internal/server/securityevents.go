@@ -0,0 +1,273 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// Security events published besides the login, lockout and API key ones.
+const (
+	// EventAuthFailed is published for credentials that are sent but don't
+	// work: a forged or expired user token, an unknown API key, or a wrong
+	// admin token.
+	EventAuthFailed = "security.auth_failed"
+	// EventPermissionDenied is published when a client is refused for who
+	// it is rather than what it sent: an API key without the scope an
+	// operation needs, or an address the IP lists keep out.
+	EventPermissionDenied = "security.permission_denied"
+)
+
+// publishDenied publishes an event of type, EventAuthFailed or
+// EventPermissionDenied, about r being refused for reason, and subject, the
+// user it authenticated as, if any.
+func (s *Server) publishDenied(r *http.Request, typ, subject, reason string, data map[string]any) {
+	details := map[string]any{"ip": remoteHost(r.RemoteAddr), "method": r.Method, "path": r.URL.Path, "reason": reason}
+	for k, v := range data {
+		details[k] = v
+	}
+	s.bus.Publish(r.Context(), events.Event{Type: typ, Subject: subject, Data: details})
+}
+
+// securityEvent reports whether events of type t belong on the security
+// event stream: every security.* event, and those of staff acting as users.
+func securityEvent(t string) bool {
+	return strings.HasPrefix(t, "security.") || t == EventUserImpersonated || t == EventImpersonatedRequest
+}
+
+// SecurityEvent is an event as the security event stream delivers it.
+type SecurityEvent struct {
+	events.Event
+	// Replica is the replica that published it.
+	Replica string `json:"replica"`
+	// Replayed marks an event delivered again by post-admin-events-replay,
+	// which the receiver may have had already; events.Event.ID tells.
+	Replayed bool `json:"replayed,omitempty"`
+
+	deadLetter string // the dead letter it was requeued from, if any
+}
+
+// SecuritySink receives the security event stream, apart from the
+// application's logs, for a SIEM to collect. Send is called from one
+// goroutine at a time; an error has the event sent again, a few times.
+type SecuritySink interface {
+	Send(ctx context.Context, e SecurityEvent) error
+}
+
+// NewSecuritySink returns the sink target names: "stdout", a file to
+// append JSON lines to, or an http(s) URL to POST each event to, signed
+// with secret if set.
+func NewSecuritySink(target, secret string) (SecuritySink, error) {
+	switch {
+	case target == "stdout":
+		return &logSink{w: os.Stdout}, nil
+	case strings.HasPrefix(target, "http://"), strings.HasPrefix(target, "https://"):
+		if _, err := url.Parse(target); err != nil {
+			return nil, err
+		}
+		return &webhookSink{url: target, secret: []byte(secret), client: &http.Client{Timeout: 5 * time.Second}}, nil
+	}
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &logSink{w: f}, nil
+}
+
+// logSink writes each event as a line of JSON.
+type logSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *logSink) Send(_ context.Context, e SecurityEvent) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.w.Write(append(b, '\n'))
+	return err
+}
+
+// webhookSink POSTs each event as JSON. With a secret, the body's
+// HMAC-SHA256 under it is sent in X-Signature-256, as sha256=<hex>, so the
+// receiver can tell the events are the API's.
+type webhookSink struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+func (h *webhookSink) Send(ctx context.Context, e SecurityEvent) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(h.secret) > 0 {
+		mac := hmac.New(sha256.New, h.secret)
+		mac.Write(b)
+		req.Header.Set("X-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Delivery of the security event stream.
+const (
+	securityQueueSize    = 1000
+	securitySendAttempts = 3
+	securityRetryDelay   = time.Second
+)
+
+// SecurityStream delivers the security events published on the bus to a
+// SecuritySink, in the background so a slow sink holds up no request. If
+// the sink falls behind by more than securityQueueSize events, the newest
+// are dropped, and counted in the logs. Events that fail every attempt go
+// to the dead-letter queue.
+type SecurityStream struct {
+	sink    SecuritySink
+	replica string
+	logger  *slog.Logger
+	queue   chan SecurityEvent
+	dropped atomic.Int64
+	// dead gets the events that fail every attempt, retryDelay apart and
+	// then twice that.
+	dead       *DeadLetters
+	retryDelay time.Duration
+
+	ctx    context.Context // ends when close is called
+	cancel context.CancelFunc
+	done   chan struct{} // closed when run returns
+}
+
+func newSecurityStream(sink SecuritySink, bus *events.Bus, replica string, dead *DeadLetters, logger *slog.Logger) *SecurityStream {
+	ctx, cancel := context.WithCancel(context.Background())
+	st := &SecurityStream{sink: sink, replica: replica, logger: logger, queue: make(chan SecurityEvent, securityQueueSize), dead: dead, retryDelay: securityRetryDelay, ctx: ctx, cancel: cancel, done: make(chan struct{})}
+	dead.handle(DeadLetterSecurityEvent, func(ctx context.Context, id string, payload any) error {
+		e := payload.(SecurityEvent)
+		e.deadLetter = id
+		select {
+		case st.queue <- e:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	bus.Subscribe(func(e events.Event) {
+		if !securityEvent(e.Type) {
+			return
+		}
+		select {
+		case st.queue <- SecurityEvent{Event: e, Replica: replica}:
+		default:
+			st.dropped.Add(1)
+		}
+	})
+	return st
+}
+
+// replay queues e to be delivered again, marked as replayed, waiting for
+// room in the queue until ctx ends.
+func (st *SecurityStream) replay(ctx context.Context, e events.Event) error {
+	select {
+	case st.queue <- SecurityEvent{Event: e, Replica: st.replica, Replayed: true}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run delivers the queued events until close is called.
+func (st *SecurityStream) run() {
+	defer close(st.done)
+	for {
+		select {
+		case e := <-st.queue:
+			st.deliver(e)
+		case <-st.ctx.Done():
+			return
+		}
+	}
+}
+
+func (st *SecurityStream) deliver(e SecurityEvent) {
+	if n := st.dropped.Swap(0); n > 0 {
+		st.logger.Warn("security event stream fell behind, events dropped", "dropped", n)
+	}
+	var attempts []DeadLetterAttempt
+	for attempt := range securitySendAttempts {
+		if attempt > 0 {
+			select {
+			case <-time.After(st.retryDelay << (attempt - 1)):
+			case <-st.ctx.Done():
+			}
+		}
+		err := st.sink.Send(st.ctx, e)
+		if err == nil {
+			st.dead.delivered(e.deadLetter)
+			return
+		}
+		attempts = append(attempts, DeadLetterAttempt{Time: timestamp.Now(), Error: err.Error()})
+		if st.ctx.Err() != nil {
+			// Shutting down: close sends it once more.
+			select {
+			case st.queue <- e:
+			default:
+				st.dropped.Add(1)
+			}
+			return
+		}
+	}
+	id := st.dead.fail(DeadLetterSecurityEvent, e.deadLetter, e, attempts)
+	st.logger.Warn("failed to deliver security event", "type", e.Type, "event_id", e.ID, "err", attempts[len(attempts)-1].Error, "dead_letter", id)
+}
+
+// close stops run, once the requests are done publishing, and sends the
+// events still queued once each, for as long as ctx allows.
+func (st *SecurityStream) close(ctx context.Context) {
+	st.cancel()
+	<-st.done
+	for {
+		select {
+		case e := <-st.queue:
+			if err := st.sink.Send(ctx, e); err != nil {
+				st.logger.Warn("failed to deliver security event", "type", e.Type, "event_id", e.ID, "err", err)
+			} else {
+				st.dead.delivered(e.deadLetter)
+			}
+		default:
+			return
+		}
+	}
+}
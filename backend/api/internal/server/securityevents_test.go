@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+)
+
+// recordingSink keeps the events sent to it.
+type recordingSink struct {
+	mu     sync.Mutex
+	events []SecurityEvent
+}
+
+func (r *recordingSink) Send(_ context.Context, e SecurityEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+	return nil
+}
+
+func TestSecurityStream(t *testing.T) {
+	sink := &recordingSink{}
+	s := NewServer(Config{SecurityEvents: sink, ReplicaID: "api-1"}, NewMemoryStore())
+	go s.security.run()
+	for _, typ := range []string{EventLoginFailed, EventUserSeen, EventUserImpersonated, EventAuthFailed, "user.created"} {
+		s.bus.Publish(context.Background(), events.Event{Type: typ})
+	}
+	s.security.close(context.Background())
+
+	var types []string
+	for _, e := range sink.events {
+		if e.Replica != "api-1" {
+			t.Errorf("%s: replica %q, want api-1", e.Type, e.Replica)
+		}
+		types = append(types, e.Type)
+	}
+	if want := []string{EventLoginFailed, EventUserImpersonated, EventAuthFailed}; !slices.Equal(types, want) {
+		t.Errorf("streamed %v, want %v", types, want)
+	}
+}
+
+func TestSecurityWebhookSignsEvents(t *testing.T) {
+	got := make(chan *http.Request, 1)
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		got <- r
+	}))
+	defer srv.Close()
+	sink, err := NewSecuritySink(srv.URL, "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Send(context.Background(), SecurityEvent{Event: events.Event{ID: "e1", Type: EventAuthFailed}}); err != nil {
+		t.Fatal(err)
+	}
+	r := <-got
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); r.Header.Get("X-Signature-256") != want {
+		t.Errorf("X-Signature-256 = %q, want %q", r.Header.Get("X-Signature-256"), want)
+	}
+	if !strings.Contains(string(body), `"type":"security.auth_failed"`) {
+		t.Errorf("body %s", body)
+	}
+}
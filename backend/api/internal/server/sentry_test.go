@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// sentryEvents is a Sentry transport keeping the events it is sent.
+type sentryEvents struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+func (s *sentryEvents) SendEvent(e *sentry.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, e)
+}
+
+func (s *sentryEvents) Flush(time.Duration) bool              { return true }
+func (s *sentryEvents) FlushWithContext(context.Context) bool { return true }
+func (s *sentryEvents) Configure(sentry.ClientOptions)        {}
+func (s *sentryEvents) Close()                                {}
+
+// brokenStore fails to get user "broken" and panics getting user "panic".
+type brokenStore struct {
+	*MemoryStore
+}
+
+func (b brokenStore) GetUser(ctx context.Context, id string) (*User, error) {
+	switch id {
+	case "broken":
+		return nil, errors.New("disk on fire")
+	case "panic":
+		panic("store panicked")
+	}
+	return b.MemoryStore.GetUser(ctx, id)
+}
+
+func TestErrorReporting(t *testing.T) {
+	cfg := Config{SentryDSN: "https://key@sentry.example.com/1", SentrySampleRate: 1}
+	sent := &sentryEvents{}
+	opts := sentryOptions(cfg)
+	opts.Transport = sent
+	if err := sentry.Init(opts); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sentry.CurrentHub().BindClient(nil) })
+	s := NewServer(cfg, brokenStore{NewMemoryStore()})
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	// A 500 is reported with its route and operation, and the query
+	// parameters redacted.
+	if w := get("/v1/users/broken?q=kim@example.com"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("GET /v1/users/broken answered %d, want 500", w.Code)
+	}
+	if len(sent.events) != 1 {
+		t.Fatalf("%d events sent, want 1", len(sent.events))
+	}
+	e := sent.events[0]
+	if len(e.Exception) == 0 || e.Exception[len(e.Exception)-1].Value != "disk on fire" {
+		t.Errorf("exception %+v, want the store's error", e.Exception)
+	}
+	if e.Tags["route"] != "/v1/users/{id}" || e.Tags["operation"] != "get-v1-users-by-id" {
+		t.Errorf("tags %v, want the route and operation", e.Tags)
+	}
+	if e.Request == nil || strings.Contains(e.Request.QueryString, "kim@example.com") {
+		t.Errorf("request %+v, want the email redacted", e.Request)
+	}
+
+	// A panic is reported, and then panics on.
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("the panic was swallowed")
+			}
+		}()
+		get("/v1/users/panic")
+	}()
+	if len(sent.events) != 2 || sent.events[1].Message != "store panicked" {
+		t.Errorf("events %+v, want the panic reported", sent.events[1:])
+	}
+
+	// Errors the client can fix aren't.
+	get("/v1/users/missing")
+	if len(sent.events) != 2 {
+		t.Errorf("%d events sent, want no more for a 404", len(sent.events))
+	}
+}
@@ -0,0 +1,578 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humachi"
+	"github.com/danielgtaylor/huma/v2/formats/cbor"
+	sentryhttp "github.com/getsentry/sentry-go/http"
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/authtoken"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/i18n"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/idgen"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/jwks"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/lock"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/redact"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/search"
+)
+
+// Server is the API with every route registered. It is an http.Handler.
+type Server struct {
+	cfg      Config
+	logger   *slog.Logger
+	logLevel *slog.LevelVar
+	cors     atomic.Pointer[corsPolicies]
+	ipAccess atomic.Pointer[IPAccess]
+	metrics  recorder
+	// slowThreshold is cfg.SlowRequestThreshold, readable while Reload
+	// changes it.
+	slowThreshold atomic.Int64
+	router        *chi.Mux
+	api           huma.API
+	store         Store
+	users         *UserService
+	posts         *PostService
+	comments      *CommentService
+	notifications *NotificationService
+	invitations   *InvitationService
+	exports       *ExportService
+	links         *DownloadLinks
+	downloads     map[string]downloadSource
+	search        *SearchService
+	locks         lock.Locker
+	replicaID     string
+	leader        leadership
+	elector       *lock.Elector // nil unless the leader is elected through s.locks
+	election      string
+	audit         *AuditLog
+	bus           *events.Bus
+	tokens        *authtoken.Signer
+	jwks          *jwks.Verifier     // nil without JWT issuers
+	disposable    *disposableDomains // nil without BlockDisposableEmails
+	security      *SecurityStream    // nil without Config.SecurityEvents
+	purges        *cachePurges       // nil without Config.CachePurger
+	deadLetters   *DeadLetters
+	mail          *mailQueue
+	activity      *activityRecorder
+	health        *healthHistory
+	telemetry     *telemetry
+	logins        *LoginGuard
+	traffic       TrafficAnalyzer // nil unless traffic is analyzed
+	trafficBlocks *trafficBlocks
+	quotas        *QuotaMeter
+	maintenance   atomic.Pointer[Maintenance]
+	captured      *requestCapture         // nil unless requests are captured
+	shadow        *shadowTraffic          // nil without Config.ShadowURL
+	schemas       *schemaCheck            // nil unless Config.ValidateResponses or dev mode
+	faults        *faultInjector          // nil unless fault injection is on
+	shedders      map[string]*loadShedder // by lane; nil unless Config.MaxInFlight is set
+	groupLimiters map[string]*loadShedder // by route group; see Config.ConcurrencyLimits
+	txMu          sync.Mutex              // runs post-v1-batch transactions one at a time
+	dedupes       *requestDedupe
+	statsCache    statsCache
+	// draining is set once shutdown has begun; /health and /readyz fail
+	// from then on.
+	draining atomic.Bool
+	// stopping is closed once shutdown has begun, to end long polls.
+	stopping    chan struct{}
+	lifecycle   *lifecycle
+	inFlight    atomic.Int64
+	inflight    inFlightRequests
+	jobs        sync.WaitGroup
+	jobsRunning atomic.Int64
+
+	reloadMu    sync.Mutex  // serializes changes to cfg and the log level
+	levelRevert *time.Timer // pending revert of an admin log level change
+}
+
+// New builds a Server on a MemoryStore bounded by cfg.StoreMaxUsers and
+// cfg.StoreUserTTL, restored from cfg.StoreSnapshotPath if that exists and
+// then from the write-ahead log at cfg.StoreWALPath. With cfg.RaftNodeID set
+// the store is instead replicated across cfg.RaftPeers; see RaftStore.
+// Unless cfg.Search is set, the search index is kept on disk in
+// cfg.SearchIndexPath, or in memory if that is empty too. With
+// cfg.AuditLogPath set, the audit log is restored from and kept in that
+// file, and likewise the mail queue with cfg.MailQueuePath.
+func New(cfg Config) (*Server, error) {
+	if cfg.Search == nil && cfg.SearchIndexPath != "" {
+		index, err := search.OpenBleve(cfg.SearchIndexPath)
+		if err != nil {
+			return nil, fmt.Errorf("open search index: %w", err)
+		}
+		cfg.Search = index
+	}
+	store, err := newStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s := NewServer(cfg, store)
+	if cfg.AuditLogPath != "" {
+		if err := s.audit.OpenFile(cfg.AuditLogPath, s.logger); err != nil {
+			return nil, fmt.Errorf("open audit log: %w", err)
+		}
+	}
+	if cfg.MailQueuePath != "" {
+		if err := s.mail.openFile(cfg.MailQueuePath); err != nil {
+			return nil, fmt.Errorf("open mail queue: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// newStore returns the store New builds the Server on.
+func newStore(cfg Config) (Store, error) {
+	store := NewMemoryStore(WithMaxUsers(cfg.StoreMaxUsers), WithUserTTL(cfg.StoreUserTTL))
+	if cfg.RaftNodeID != "" {
+		_, port, err := net.SplitHostPort(cmp.Or(cfg.Addr, ":8080"))
+		if err != nil {
+			return nil, fmt.Errorf("listen address: %w", err)
+		}
+		rs, err := NewRaftStore(RaftConfig{
+			NodeID:   cfg.RaftNodeID,
+			BindAddr: cfg.RaftBindAddr,
+			Peers:    cfg.RaftPeers,
+			HTTPPort: port,
+		}, store)
+		if err != nil {
+			return nil, fmt.Errorf("join raft cluster: %w", err)
+		}
+		return rs, nil
+	}
+	if cfg.StoreSnapshotPath != "" {
+		if err := store.LoadSnapshot(cfg.StoreSnapshotPath); err != nil {
+			return nil, fmt.Errorf("load store snapshot: %w", err)
+		}
+	}
+	if cfg.StoreWALPath != "" {
+		if err := store.OpenWAL(cfg.StoreWALPath); err != nil {
+			return nil, fmt.Errorf("open write-ahead log: %w", err)
+		}
+	}
+	return store, nil
+}
+
+// evictingStore is implemented by stores that drop users on their own, like
+// a bounded MemoryStore, so NewServer can count the evictions.
+type evictingStore interface {
+	OnEvict(func(reason string))
+}
+
+// userStoreFor wraps store as the user service sees it: alongside
+// cfg.CanaryStore if set, timed, with cfg.PIIKeys encrypted, in dev mode
+// with its reads counted and, with cfg.CallTracer, traced.
+func userStoreFor(cfg Config, store Store) Store {
+	if cfg.CanaryStore != nil {
+		store = canaryStore{primary: store, canary: cfg.CanaryStore}
+	}
+	var userStore Store = timedStore{store}
+	if cfg.PIIKeys != nil {
+		userStore = encryptedStore{userStore, cfg.PIIKeys}
+	}
+	if cfg.Dev {
+		userStore = countedStore{userStore}
+	}
+	if cfg.CallTracer != nil {
+		userStore = tracedStore{userStore}
+	}
+	return userStore
+}
+
+// NewServer is the composition root: it builds the logger from cfg, the
+// services on top of store, and the router and API that expose them. Nothing
+// below it reads globals; every dependency is passed in here.
+func NewServer(cfg Config, store Store) *Server {
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(cfg.LogLevel)
+	logger := slog.New(traceHandler{slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel, ReplaceAttr: redact.Attr})})
+	bus := events.New()
+	audit := NewAuditLog(bus)
+	userStore := userStoreFor(cfg, store)
+	if cfg.IDGenerator == nil {
+		cfg.IDGenerator = idgen.Timestamp{}
+	}
+	idGen := cfg.IDGenerator
+	users := NewUserService(userStore, bus, audit, logger, cfg.UniquePhones, cfg.EmailFolding, idGen)
+	index := cfg.Search
+	if index == nil {
+		var err error
+		if index, err = search.OpenBleve(""); err != nil {
+			// An index in memory has nothing to fail on but a bug.
+			panic(err)
+		}
+		if !search.BleveBuilt {
+			logger.Warn("this binary was built without Bleve; get-v1-search answers 503 unless SEARCH_BACKEND names a cluster")
+		}
+	}
+
+	// --- Setup OpenAPI + router ---
+	config := huma.DefaultConfig("Monorepo API", "1.0.0")
+	config.Formats = map[string]huma.Format{
+		"application/json": huma.DefaultJSONFormat,
+		"json":             huma.DefaultJSONFormat,
+		"application/cbor": cbor.DefaultCBORFormat,
+		"cbor":             cbor.DefaultCBORFormat,
+		"application/yaml": yamlFormat,
+		"yaml":             yamlFormat,
+		halContentType:     huma.DefaultJSONFormat,
+	}
+	if cfg.Dev {
+		config.Formats["application/json"] = prettyJSONFormat
+		config.Formats["json"] = prettyJSONFormat
+		config.Formats[halContentType] = prettyJSONFormat
+	}
+	for ct, f := range healthFormats {
+		config.Formats[ct] = f
+	}
+	config.Transformers = append(config.Transformers, i18n.LocalizeErrors)
+	router := chi.NewRouter()
+	links := NewDownloadLinks(cfg.URLSigningKey)
+	s := &Server{
+		cfg:           cfg,
+		logger:        logger,
+		logLevel:      logLevel,
+		router:        router,
+		store:         store,
+		users:         users,
+		posts:         NewPostService(userStore, bus, idGen),
+		comments:      NewCommentService(userStore, bus, idGen),
+		notifications: NewNotificationService(userStore, bus, idGen),
+		invitations:   NewInvitationService(users, bus),
+		exports:       NewExportService(users, bus, links),
+		links:         links,
+		search:        NewSearchService(index, userStore, bus, logger),
+		locks:         cfg.Locker,
+		replicaID:     cmp.Or(cfg.ReplicaID, defaultReplicaID(cfg.RaftNodeID)),
+		audit:         audit,
+		bus:           bus,
+		metrics:       newRecorder(cfg, logger),
+		stopping:      make(chan struct{}),
+		lifecycle:     &lifecycle{logger: logger},
+		tokens:        newTokenSigner(cfg.TokenSigningKey),
+		logins: NewLoginGuard(LoginPolicy{
+			MaxFailures:      cfg.LoginMaxFailures,
+			MaxFailuresPerIP: cfg.LoginMaxFailuresPerIP,
+			Lockout:          cfg.LoginLockout,
+		}, bus),
+		quotas:        NewQuotaMeter(),
+		dedupes:       newRequestDedupe(),
+		traffic:       cfg.TrafficAnalyzer,
+		trafficBlocks: &trafficBlocks{blocks: map[string]TrafficBlock{}},
+	}
+	// Each kind of download get-v1-downloads-by-kind-by-id serves, by the
+	// name its links carry.
+	s.downloads = map[string]downloadSource{"exports": s.exports.File}
+	if p := cfg.TrafficPolicy; s.traffic == nil && (p.MaxPerMinute > 0 || p.MaxErrorRate > 0) {
+		s.traffic = NewRateAnalyzer(p)
+	}
+	if len(cfg.JWTIssuers) > 0 {
+		s.jwks = jwks.New(cfg.JWTIssuers)
+	}
+	s.deadLetters = newDeadLetters(logger, s.metrics, cfg.DeadLetterAlert)
+	if cfg.SecurityEvents != nil {
+		s.security = newSecurityStream(cfg.SecurityEvents, bus, s.replicaID, s.deadLetters, logger)
+	}
+	if cfg.CachePurger != nil {
+		s.purges = newCachePurges(cfg.CachePurger, bus, s.deadLetters, logger)
+	}
+	s.mail = newMailQueue(s.mailer(), s.deadLetters, s.metrics, logger)
+	s.health = &healthHistory{}
+	s.telemetry = newTelemetry(cfg, store, logger)
+	if s.locks == nil {
+		s.locks = lock.NewMemory(s.replicaID)
+	}
+	s.elector = s.newLeadership(store)
+	s.slowThreshold.Store(int64(cfg.SlowRequestThreshold))
+	if size := cfg.CaptureRequests; size > 0 || cfg.Dev && size == 0 {
+		s.captured = newRequestCapture(cmp.Or(size, defaultDevCaptureRequests))
+	}
+	if cfg.ShadowURL != nil {
+		s.shadow = newShadowTraffic(cfg, s.metrics, logger)
+	}
+	if cfg.FaultInjection || cfg.Dev {
+		s.faults = newFaultInjector()
+	}
+	if cfg.MaxInFlight > 0 {
+		s.shedders = map[string]*loadShedder{
+			laneAPI:   newLoadShedder(cfg.MaxInFlight, cfg.MaxQueued, cfg.QueueTimeout),
+			laneAdmin: newLoadShedder(adminLaneSize, adminLaneSize, cfg.QueueTimeout),
+		}
+	}
+	s.groupLimiters = newGroupLimiters(cfg)
+	if cfg.Maintenance != "" && cfg.Maintenance != MaintenanceOff {
+		s.setMaintenance(cfg.Maintenance, "", 0)
+	} else {
+		s.maintenance.Store(&Maintenance{Mode: MaintenanceOff})
+	}
+	if es, ok := store.(evictingStore); ok {
+		es.OnEvict(s.metrics.eviction)
+	}
+	s.cors.Store(newCORSPolicies(cfg))
+	s.ipAccess.Store(&cfg.IPAccess)
+
+	use := router.Use
+	if cfg.CallTracer != nil {
+		router.Use(cfg.CallTracer.begin)
+		use = func(mws ...func(http.Handler) http.Handler) { router.Use(traceMiddlewares(mws)...) }
+	}
+	use(traceRequests, s.resolveClientIP, s.restrictIPs, s.analyzeTraffic, s.countInFlight, s.trackInFlight, s.routeCanary, instrument(s.metrics), s.shedLoad, s.logSlowRequests, s.captureRequests, s.mirrorRequests, s.injectFaults)
+
+	use(s.handleCORS, s.validateResponses)
+	// Before forwarding, so every replica refuses what its own mode says.
+	use(s.refuseDuringMaintenance)
+	if rs, ok := store.(*RaftStore); ok {
+		use(rs.forwardWrites)
+	}
+	use(s.identifyService, s.authenticate, s.enforceQuotas, s.resolveLocale)
+	if cfg.Dev {
+		use(logBodies(logger), detectRepeatedReads(logger), recoverWithStack(logger))
+	}
+	if cfg.SentryDSN != "" {
+		// Innermost so it sees panics before any recoverer; it panics
+		// again after reporting.
+		use(sentryhttp.New(sentryhttp.Options{Repanic: true}).Handle)
+	}
+	use(withMetadataSchema(cfg.MetadataSchema))
+	if cfg.BlockDisposableEmails {
+		s.disposable = newDisposableDomains(cfg.DisposableDomainsURL)
+		use(withDisposableDomains(s.disposable))
+	}
+	router.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "no route matches the path")
+	})
+	router.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "the route does not support the method")
+	})
+
+	config.Components.SecuritySchemes = map[string]*huma.SecurityScheme{
+		"adminToken": {Type: "http", Scheme: "bearer", Description: "The ADMIN_TOKEN the server was started with."},
+		"userToken":  {Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "A user token, such as one from post-admin-impersonate-by-user-id, or a JWT from one of the JWT_ISSUERS."},
+		"apiKey":     {Type: "http", Scheme: "bearer", Description: apiKeySchemeDoc()},
+		"mutualTLS":  {Type: "mutualTLS", Description: "A client certificate, on an https listener, from a CA in TLS_CLIENT_CA_FILE and mapped by SERVICE_IDENTITIES to one of the ADMIN_SERVICES."},
+	}
+	config.Transformers = append(config.Transformers, userSchemaLink, surrogateKeys, s.halTransformer, uncacheErrors, emptyLists)
+	routes := newRouteRegistry(router)
+	s.api = huma.NewAPI(config, routes.adapter(humachi.NewAdapter(router)))
+	middlewares := []func(huma.Context, func(huma.Context)){timeHandler, s.nameInFlight, s.countUsage, s.cacheControl, s.refuseUnacceptable(config.Formats), s.authorize, s.limitConcurrency, s.inflate, s.limitShape, s.dedupe}
+	if cfg.CallTracer != nil {
+		middlewares = traceHumaMiddlewares(middlewares)
+	}
+	s.api.UseMiddleware(middlewares...)
+	if prom, ok := s.metrics.(*promRecorder); ok {
+		routes.handle("/metrics", prom.handler())
+	}
+
+	// --- Event bus ---
+	bus.Subscribe(func(e events.Event) {
+		logger.Info("event", "type", e.Type, "subject", e.Subject, "id", e.ID, "correlation_id", e.CorrelationID, "causation_id", e.CausationID)
+	})
+	s.activity = newActivityRecorder(users, bus, func() bool { return s.election == ElectionRaft && !s.leader.IsLeader() })
+	if err := s.search.Rebuild(context.Background()); err != nil {
+		logger.Error("failed to build the search index", "err", err)
+	}
+
+	s.registerRoutes()
+	// A route registered twice is a bug that would otherwise only show as
+	// the earlier one not answering.
+	if err := routes.err(); err != nil {
+		panic(err)
+	}
+	documentErrors(s.api.OpenAPI())
+	documentCaching(s.api.OpenAPI())
+	documentDeduplication(s.api.OpenAPI())
+	documentSurrogateKeys(s.api.OpenAPI())
+	documentExamples(s.api.OpenAPI())
+	if cfg.ValidateResponses || cfg.Dev {
+		s.schemas = newSchemaCheck(s.api.OpenAPI(), config.Formats, logger, cfg.OnSchemaViolation)
+	}
+	return s
+}
+
+// Audit returns the audit log of everything that happened to users since
+// the server started.
+func (s *Server) Audit() *AuditLog {
+	return s.audit
+}
+
+// Handler returns the API as an http.Handler, for embedding it in another
+// server or calling it from tests.
+func (s *Server) Handler() http.Handler {
+	return s.router
+}
+
+// Run serves the API on cfg.Addr until ctx is done, then shuts down
+// gracefully. It starts the components of the server in dependency order,
+// the store first and the listeners last, and stops them in reverse; see
+// lifecycle. On shutdown it first waits cfg.ShutdownDelay, if set, with /readyz failing
+// so the load balancer stops sending traffic; then it stops accepting
+// connections and gives in-flight requests and background jobs up to
+// cfg.ShutdownTimeout, 10 seconds by default, to finish. With
+// cfg.StoreSnapshotPath set, a store that supports it is saved there every
+// cfg.StoreSnapshotInterval and once more after the shutdown. With a
+// retention rule set, the retention policy runs every cfg.RetentionInterval
+// on the replica that leads; see leadership. With cfg.WarmUp, /readyz
+// fails until the server has warmed up; see warmUp.
+func (s *Server) Run(ctx context.Context) error {
+	listeners := s.cfg.Listeners
+	activated, err := activatedListeners()
+	if err != nil {
+		return err
+	}
+	if len(activated) > 0 {
+		if len(listeners) > 0 {
+			s.logger.Warn("LISTEN is ignored, serving the sockets systemd passed")
+		}
+		listeners = activated
+		if slices.ContainsFunc(listeners, func(l Listener) bool { return l.Kind == ListenHTTPS }) &&
+			(s.cfg.TLSCertFile == "" || s.cfg.TLSKeyFile == "") {
+			return errors.New("an https socket from systemd needs TLS_CERT_FILE and TLS_KEY_FILE")
+		}
+	}
+	if len(listeners) == 0 {
+		listeners = []Listener{{Kind: ListenHTTP, Network: "tcp", Address: cmp.Or(s.cfg.Addr, ":8080")}}
+	}
+
+	if s.faults != nil {
+		s.logger.Warn("fault injection is on; PUT /admin/faults can make requests fail, don't enable it in production")
+	}
+	if s.cfg.TokenSigningKey == nil {
+		s.logger.Warn("TOKEN_SIGNING_KEY is not set; user tokens are signed with a random key and stop working on restart")
+	}
+	if s.cfg.URLSigningKey == nil {
+		s.logger.Warn("URL_SIGNING_KEY is not set; download links are signed with a random key and stop working on restart")
+	}
+	if s.cfg.CanaryPercent > 0 && s.cfg.CanaryStore == nil {
+		s.logger.Warn("CANARY_PERCENT is set but there is no canary store; every request goes to the store")
+	}
+	snap, _ := s.store.(snapshotter)
+	if s.cfg.StoreSnapshotPath == "" {
+		snap = nil
+	}
+	// The jobs get a context of their own so a failed start, or a listener
+	// that stops serving, ends them too.
+	jobsCtx, cancelJobs := context.WithCancel(ctx)
+	defer cancelJobs()
+	var open []*listening
+	errc := make(chan error, len(listeners))
+	s.addComponents(snap)
+	s.lifecycle.add(&component{
+		name:  "http",
+		after: []string{"store", "audit", "search", "locks", "metrics", "security_events", "cache_purge", "jobs"},
+		start: func(context.Context) error {
+			var err error
+			if open, err = s.listen(listeners); err != nil {
+				return err
+			}
+			for _, l := range open {
+				s.logger.Info("server running", "listen", l.Listener.String())
+				go func() { errc <- s.serve(l) }()
+			}
+			return nil
+		},
+		stop: func(ctx context.Context) error {
+			inFlight := s.inFlight.Load()
+			// Shutdown closes the listeners before waiting for the requests.
+			var wg sync.WaitGroup
+			errs := make([]error, len(open))
+			for i, l := range open {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					errs[i] = l.srv.Shutdown(ctx)
+				}()
+			}
+			wg.Wait()
+			abandoned := s.inFlight.Load()
+			s.logger.Info("drained requests", "requests", inFlight-abandoned)
+			if abandoned > 0 {
+				s.logger.Warn("shutdown timed out", "abandoned_requests", abandoned)
+			}
+			return errors.Join(errs...)
+		},
+	})
+	s.lifecycle.add(&component{
+		name:  "jobs",
+		after: []string{"store", "audit", "search", "locks", "metrics", "security_events", "cache_purge"},
+		start: func(context.Context) error {
+			s.startJobs(jobsCtx, snap)
+			return nil
+		},
+		stop: func(ctx context.Context) error {
+			cancelJobs()
+			jobs, abandoned := s.drainJobs(ctx)
+			s.logger.Info("drained background jobs", "background_jobs", jobs)
+			if abandoned > 0 {
+				s.logger.Warn("shutdown timed out", "abandoned_jobs", abandoned)
+			}
+			return nil
+		},
+	})
+	if s.cfg.WarmUp {
+		s.lifecycle.add(s.warmUpComponent())
+	}
+	if err := s.lifecycle.start(ctx); err != nil {
+		return err
+	}
+
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+	}
+	close(s.stopping)
+	s.draining.Store(true)
+
+	if delay := s.cfg.ShutdownDelay; delay > 0 && err == nil {
+		// Connections kept alive would otherwise keep coming back here.
+		for _, l := range open {
+			l.srv.SetKeepAlivesEnabled(false)
+		}
+		s.logger.Info("draining before shutdown", "delay", delay)
+		time.Sleep(delay)
+	}
+	timeout := cmp.Or(s.cfg.ShutdownTimeout, defaultShutdownTimeout)
+	s.logger.Info("graceful shutdown", "in_flight", s.inFlight.Load(), "timeout", timeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return errors.Join(err, s.lifecycle.stop(shutdownCtx))
+}
+
+// OpenAPI returns the spec describing every registered operation.
+func (s *Server) OpenAPI() *huma.OpenAPI {
+	return s.api.OpenAPI()
+}
+
+// Events returns the bus lifecycle events are published on.
+func (s *Server) Events() *events.Bus {
+	return s.bus
+}
+
+// newRecorder builds the exporter cfg.MetricsExporter selects. A StatsD
+// client that can't be set up is logged and metrics are dropped rather than
+// failing startup.
+func newRecorder(cfg Config, logger *slog.Logger) recorder {
+	switch cfg.MetricsExporter {
+	case MetricsNone:
+		return noopRecorder{}
+	case MetricsStatsD:
+		rec, err := newStatsdRecorder(cfg.StatsDAddr)
+		if err != nil {
+			logger.Error("failed to set up StatsD, metrics are disabled", "addr", cfg.StatsDAddr, "err", err)
+			return noopRecorder{}
+		}
+		return rec
+	}
+	return newPromRecorder()
+}
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/redact"
+)
+
+// What of a request Config.ShadowBodies lets through to the shadow target.
+const (
+	// ShadowBodiesRedacted, the default, sends bodies redacted like the
+	// logs and no credentials.
+	ShadowBodiesRedacted = "redacted"
+	// ShadowBodiesFull sends requests as they came, credentials and all,
+	// for a target trusted with them.
+	ShadowBodiesFull = "full"
+	// ShadowBodiesNone sends no bodies and no credentials, only the
+	// methods, paths and queries.
+	ShadowBodiesNone = "none"
+)
+
+const (
+	// maxShadowBody is the largest body mirrored; requests with larger
+	// ones aren't.
+	maxShadowBody = 1 << 20
+	// shadowConcurrency bounds the mirrored requests on their way at
+	// once; beyond it, they are dropped.
+	shadowConcurrency = 64
+	// shadowTimeout bounds each mirrored request.
+	shadowTimeout = 10 * time.Second
+	// headerShadowOf is set on mirrored requests to the X-Request-ID of
+	// the request they copy.
+	headerShadowOf = "X-Shadow-Of"
+)
+
+// shadowTraffic mirrors a sample of requests to a second deployment, such as
+// a new implementation being load-tested with the shapes of real traffic.
+// Mirrored requests are sent once the request they copy has been served,
+// from their own goroutines, and what the target answers is thrown away, so
+// nothing it does reaches the clients.
+type shadowTraffic struct {
+	target  *url.URL
+	percent float64
+	bodies  string
+	client  *http.Client
+	rec     recorder
+	logger  *slog.Logger
+	slots   chan struct{}
+	wg      sync.WaitGroup
+}
+
+func newShadowTraffic(cfg Config, rec recorder, logger *slog.Logger) *shadowTraffic {
+	return &shadowTraffic{
+		target:  cfg.ShadowURL,
+		percent: cmp.Or(cfg.ShadowPercent, 100),
+		bodies:  cmp.Or(cfg.ShadowBodies, ShadowBodiesRedacted),
+		client:  &http.Client{Timeout: shadowTimeout},
+		rec:     rec,
+		logger:  logger,
+		slots:   make(chan struct{}, shadowConcurrency),
+	}
+}
+
+// mirrorRequests sends s.shadow its sample of the requests, leaving out the
+// admin API, /metrics and the probes.
+func (s *Server) mirrorRequests(next http.Handler) http.Handler {
+	if s.shadow == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if adminPath(r.URL.Path) || probePath(r.URL.Path) || rand.Float64()*100 >= s.shadow.percent {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body := &shadowBody{}
+		if r.Body != nil && r.Body != http.NoBody {
+			// The handler reads the body as it comes; it is only kept.
+			body.r = r.Body
+			r.Body = readCloser{body, r.Body}
+		} else {
+			body.eof = true
+		}
+		next.ServeHTTP(w, r)
+		s.shadow.mirror(r, body)
+	})
+}
+
+// shadowBody keeps what is read through it, up to maxShadowBody.
+type shadowBody struct {
+	r    io.Reader
+	buf  bytes.Buffer
+	eof  bool // read to the end
+	over bool // more than maxShadowBody
+}
+
+func (b *shadowBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if !b.over {
+		if b.buf.Len()+n > maxShadowBody {
+			b.over = true
+			b.buf = bytes.Buffer{}
+		} else {
+			b.buf.Write(p[:n])
+		}
+	}
+	if err == io.EOF {
+		b.eof = true
+	}
+	return n, err
+}
+
+// mirror sends a copy of r, whose body the handler read through body, if a
+// slot is free. A request whose handler didn't read its whole body, or whose
+// body is too large, isn't mirrored, as what it sent isn't known.
+func (t *shadowTraffic) mirror(r *http.Request, body *shadowBody) {
+	if !body.eof || body.over {
+		t.rec.shadowed("skipped")
+		return
+	}
+	select {
+	case t.slots <- struct{}{}:
+	default:
+		t.rec.shadowed("dropped")
+		return
+	}
+	method, path, query, header := r.Method, r.URL.Path, r.URL.RawQuery, r.Header.Clone()
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer func() { <-t.slots }()
+		req, err := t.request(method, path, query, header, body.buf.Bytes())
+		if err != nil {
+			t.logger.Warn("failed to build a shadow request", "method", method, "path", path, "err", err)
+			t.rec.shadowed("failed")
+			return
+		}
+		t.send(req)
+	}()
+}
+
+// request copies a request, as far as t.bodies lets it, to be sent to the
+// target.
+func (t *shadowTraffic) request(method, path, query string, header http.Header, body []byte) (*http.Request, error) {
+	u := *t.target
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	u.RawQuery = query
+	switch t.bodies {
+	case ShadowBodiesRedacted:
+		body = redact.JSON(body)
+	case ShadowBodiesNone:
+		body = nil
+		header.Del("Content-Type")
+	}
+	if t.bodies != ShadowBodiesFull {
+		// A compressed body was redacted as a whole.
+		header.Del("Content-Encoding")
+	}
+	// The client's timeout bounds it; it mustn't end with the context of
+	// the request it copies.
+	req, err := http.NewRequestWithContext(context.Background(), method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		if t.bodies != ShadowBodiesFull && (redact.Key(name) || strings.Contains(strings.ToLower(name), "token")) {
+			continue
+		}
+		if name == "Content-Length" {
+			continue
+		}
+		req.Header[name] = values
+	}
+	req.Header.Set(headerShadowOf, header.Get(headerRequestID))
+	req.Header.Del(headerRequestID)
+	return req, nil
+}
+
+// send sends req and discards the answer.
+func (t *shadowTraffic) send(req *http.Request) {
+	resp, err := t.client.Do(req)
+	if err != nil {
+		t.logger.Debug("shadow request failed", "method", req.Method, "path", req.URL.Path, "err", err)
+		t.rec.shadowed("failed")
+		return
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	t.rec.shadowed("sent")
+}
+
+// close waits for the mirrored requests on their way, until ctx ends.
+func (t *shadowTraffic) close(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("shadow requests still on their way: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/validation"
+)
+
+// maxFreeFormDepth is how many levels of objects and arrays a request body
+// may nest below a point its schema stops describing, such as user
+// metadata or a transaction operation's body. Metadata has a tighter limit
+// of its own.
+const maxFreeFormDepth = 10
+
+// limitShape is a huma middleware holding JSON request bodies to the limits
+// of their operation's schema as they are read: array lengths (maxItems),
+// object sizes (maxProperties), string lengths (maxLength), and how deep
+// they nest, which is as deep as the schema goes plus maxFreeFormDepth
+// where it allows anything. Huma checks the first three too, but only
+// after decoding the whole body; a body past one of them is refused with a
+// 422 naming the first it breaks as soon as it is read that far. Bodies
+// that aren't valid JSON, or too large, go on to huma as they are, for it
+// to refuse.
+func (s *Server) limitShape(ctx huma.Context, next func(huma.Context)) {
+	op := ctx.Operation()
+	schema := jsonBodySchema(op)
+	if schema == nil || !isJSON(ctx.Header("Content-Type")) {
+		next(ctx)
+		return
+	}
+	var read bytes.Buffer
+	c := &shapeCheck{
+		dec:     json.NewDecoder(io.TeeReader(io.LimitReader(ctx.BodyReader(), op.MaxBodyBytes+1), &read)),
+		schemas: s.api.OpenAPI().Components.Schemas,
+	}
+	c.dec.UseNumber()
+	var violation *ErrorDetail
+	if errors.As(c.scan(schema, 0, 0), &violation) {
+		s.writeErr(ctx, huma.NewError(http.StatusUnprocessableEntity, "validation failed", violation))
+		return
+	}
+	next(&recordingContext{humaContext: ctx, body: io.MultiReader(&read, ctx.BodyReader())})
+}
+
+// jsonBodySchema returns the schema of op's JSON request body, or nil if it
+// takes none.
+func jsonBodySchema(op *huma.Operation) *huma.Schema {
+	if op.RequestBody == nil {
+		return nil
+	}
+	if mt := op.RequestBody.Content["application/json"]; mt != nil {
+		return mt.Schema
+	}
+	return nil
+}
+
+// isJSON reports whether a request with Content-Type ct has a JSON body,
+// as huma assumes when there is no Content-Type.
+func isJSON(ct string) bool {
+	mt, _, _ := strings.Cut(ct, ";")
+	mt = strings.ToLower(strings.TrimSpace(mt))
+	return mt == "" || mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+// shapeCheck reads a JSON body token by token, against its schema.
+type shapeCheck struct {
+	dec     *json.Decoder
+	schemas huma.Registry
+	path    []string // of the value being read, below the body
+}
+
+// scan reads the next value, which schema describes or, if nil, nothing
+// does. depth is how many objects and arrays hold the value, and maxDepth
+// how many may where the body is free-form, or 0 if it isn't. It returns an
+// *ErrorDetail for a value past a limit.
+func (c *shapeCheck) scan(schema *huma.Schema, depth, maxDepth int) error {
+	schema = c.resolve(schema)
+	if schema == nil && maxDepth == 0 {
+		maxDepth = depth + maxFreeFormDepth
+	}
+	tok, err := c.dec.Token()
+	if err != nil {
+		return err
+	}
+	switch tok := tok.(type) {
+	case json.Delim:
+		if schema == nil && depth >= maxDepth {
+			return c.violation("max_depth", fmt.Sprintf("expected body nested at most %d levels deep", maxDepth))
+		}
+		if tok == '[' {
+			return c.scanArray(schema, depth, maxDepth)
+		}
+		return c.scanObject(schema, depth, maxDepth)
+	case string:
+		if schema != nil && schema.MaxLength != nil && utf8.RuneCountInString(tok) > *schema.MaxLength {
+			return c.violation("max_length", fmt.Sprintf(validation.MsgExpectedMaxLength, *schema.MaxLength))
+		}
+	}
+	return nil
+}
+
+func (c *shapeCheck) scanArray(schema *huma.Schema, depth, maxDepth int) error {
+	var items *huma.Schema
+	if schema != nil {
+		items = schema.Items
+	}
+	for n := 0; c.dec.More(); n++ {
+		if schema != nil && schema.MaxItems != nil && n == *schema.MaxItems {
+			return c.violation("max_items", fmt.Sprintf(validation.MsgExpectedMaxItems, *schema.MaxItems))
+		}
+		c.path = append(c.path, "["+strconv.Itoa(n)+"]")
+		if err := c.scan(items, depth+1, maxDepth); err != nil {
+			return err
+		}
+		c.path = c.path[:len(c.path)-1]
+	}
+	_, err := c.dec.Token()
+	return err
+}
+
+func (c *shapeCheck) scanObject(schema *huma.Schema, depth, maxDepth int) error {
+	for n := 0; c.dec.More(); n++ {
+		if schema != nil && schema.MaxProperties != nil && n == *schema.MaxProperties {
+			return c.violation("max_properties", fmt.Sprintf(validation.MsgExpectedMaxProperties, *schema.MaxProperties))
+		}
+		tok, err := c.dec.Token()
+		if err != nil {
+			return err
+		}
+		name, _ := tok.(string)
+		c.path = append(c.path, "."+name)
+		if err := c.scan(propertySchema(schema, name), depth+1, maxDepth); err != nil {
+			return err
+		}
+		c.path = c.path[:len(c.path)-1]
+	}
+	_, err := c.dec.Token()
+	return err
+}
+
+// resolve follows schema's $ref, and returns nil for a schema that doesn't
+// pin down a single type, as one with oneOf or anyOf doesn't.
+func (c *shapeCheck) resolve(schema *huma.Schema) *huma.Schema {
+	if schema != nil && schema.Ref != "" {
+		schema = c.schemas.SchemaFromRef(schema.Ref)
+	}
+	if schema == nil || schema.Type == "" {
+		return nil
+	}
+	return schema
+}
+
+// propertySchema returns the schema of object's property name, or nil if
+// it has none: then the property is free-form, or unexpected and left to
+// huma to refuse.
+func propertySchema(object *huma.Schema, name string) *huma.Schema {
+	if object == nil {
+		return nil
+	}
+	if p, ok := object.Properties[name]; ok {
+		return p
+	}
+	if p, ok := object.AdditionalProperties.(*huma.Schema); ok {
+		return p
+	}
+	return nil
+}
+
+// violation returns the *ErrorDetail of the value being read breaking a
+// limit.
+func (c *shapeCheck) violation(code, msg string) *ErrorDetail {
+	return &ErrorDetail{Code: code, Message: msg, Location: "body" + strings.Join(c.path, "")}
+}
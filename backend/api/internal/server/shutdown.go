@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// defaultShutdownTimeout bounds a graceful shutdown when
+// Config.ShutdownTimeout is zero.
+const defaultShutdownTimeout = 10 * time.Second
+
+// kubernetesShutdownDelay is Config.ShutdownDelay in Kubernetes unless
+// SHUTDOWN_DELAY is set: long enough for kube-proxy and ingress
+// controllers to stop routing to a terminating pod.
+const kubernetesShutdownDelay = 5 * time.Second
+
+// probePath reports whether path is a health probe. Probes are exempt from
+// the IP lists and maintenance, and served on every listener, so the load
+// balancer and orchestrator always reach them.
+func probePath(path string) bool {
+	switch path {
+	case "/health", "/livez", "/readyz":
+		return true
+	}
+	return false
+}
+
+// countInFlight keeps s.inFlight at the number of requests being served, so
+// shutdown can log how many it waited for.
+func (s *Server) countInFlight(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// goJob runs fn, a background job that returns once Run's context ends, on
+// its own goroutine. Run waits for the jobs before it returns.
+func (s *Server) goJob(fn func()) {
+	s.jobs.Add(1)
+	s.jobsRunning.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		defer s.jobsRunning.Add(-1)
+		fn()
+	}()
+}
+
+// drainJobs waits for the background jobs until ctx ends and returns how
+// many finished and how many were still running.
+func (s *Server) drainJobs(ctx context.Context) (drained, abandoned int64) {
+	before := s.jobsRunning.Load()
+	done := make(chan struct{})
+	go func() {
+		s.jobs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	left := s.jobsRunning.Load()
+	return before - left, left
+}
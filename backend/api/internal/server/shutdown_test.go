@@ -0,0 +1,131 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitReady polls url until it answers 200, failing the test after 5s.
+func waitReady(t *testing.T, client *http.Client, url string) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		resp, err := client.Get(url)
+		if err == nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s not ready after 5s: %v", url, err)
+		}
+	}
+}
+
+// blockingStore holds up the first read of a user until release is
+// closed, closing entered as the read starts.
+type blockingStore struct {
+	*MemoryStore
+	once             *sync.Once
+	entered, release chan struct{}
+}
+
+func (b blockingStore) GetUser(ctx context.Context, id string) (*User, error) {
+	b.once.Do(func() {
+		close(b.entered)
+		<-b.release
+	})
+	return b.MemoryStore.GetUser(ctx, id)
+}
+
+func TestShutdownDrainsRequests(t *testing.T) {
+	store := blockingStore{MemoryStore: NewMemoryStore(), once: &sync.Once{}, entered: make(chan struct{}), release: make(chan struct{})}
+	store.PutUser(context.Background(), &User{ID: "a", Name: "Ada", Status: UserStatusActive, Active: true})
+	probe, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := probe.Addr().String()
+	probe.Close()
+	snapshot := filepath.Join(t.TempDir(), "store.json")
+	s := NewServer(Config{
+		Listeners:         []Listener{{Kind: ListenHTTP, Network: "tcp", Address: addr}},
+		StoreSnapshotPath: snapshot,
+	}, store)
+	var logs bytes.Buffer
+	s.logger = slog.New(slog.NewTextHandler(&logs, nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+	client := &http.Client{}
+	waitReady(t, client, "http://"+addr+"/readyz")
+
+	// An update is in flight when the shutdown starts.
+	answered := make(chan int, 1)
+	go func() {
+		req, _ := http.NewRequest(http.MethodPut, "http://"+addr+"/v1/users/a", strings.NewReader(`{"name":"Ada King"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			answered <- 0
+			return
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		answered <- resp.StatusCode
+	}()
+	<-store.entered
+	cancel()
+
+	// New connections are refused while it finishes.
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			break
+		}
+		conn.Close()
+		if time.Now().After(deadline) {
+			t.Fatal("still accepting connections 5s into the shutdown")
+		}
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("Run returned %v with a request in flight", err)
+	default:
+	}
+	close(store.release)
+	if code := <-answered; code != http.StatusOK {
+		t.Errorf("the in-flight update answered %d, want 200", code)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(15 * time.Second):
+		t.Fatal("Run still running 15s after its context ended")
+	}
+	if !strings.Contains(logs.String(), "msg=\"drained requests\" requests=1") {
+		t.Errorf("logs %s, want the drained request counted", logs.String())
+	}
+
+	// The final snapshot has the update.
+	restored := NewMemoryStore()
+	if err := restored.LoadSnapshot(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if u, err := restored.GetUser(context.Background(), "a"); err != nil || u.Name != "Ada King" {
+		t.Errorf("snapshot has %+v, %v; want Ada King", u, err)
+	}
+}
@@ -0,0 +1,232 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// snapshotVersion is bumped when the snapshot layout changes incompatibly.
+const snapshotVersion = 1
+
+// snapshot is the file format of MemoryStore.WriteSnapshot.
+type snapshot struct {
+	Version     int                         `json:"version"`
+	TakenAt     timestamp.Time              `json:"taken_at"`
+	Users       []*User                     `json:"users"`
+	Preferences map[string]*UserPreferences `json:"preferences,omitempty"`
+	Credentials map[string]*Credentials     `json:"credentials,omitempty"`
+	Posts       []*Post                     `json:"posts,omitempty"`
+	Comments    []*Comment                  `json:"comments,omitempty"`
+	Invitations []*StoredInvitation         `json:"invitations,omitempty"`
+}
+
+// snapshotter is implemented by stores that can save themselves to a file,
+// which Run then does periodically and on shutdown.
+type snapshotter interface {
+	SaveSnapshot(path string) error
+}
+
+// WriteSnapshot writes every user, their preferences, credentials, posts and
+// comments, and the invitations, to w as JSON.
+func (m *MemoryStore) WriteSnapshot(w io.Writer) error {
+	m.mu.RLock()
+	snap := m.snapshot()
+	m.mu.RUnlock()
+	return writeSnapshot(w, snap)
+}
+
+// snapshot copies the store's contents. The caller holds a lock.
+func (m *MemoryStore) snapshot() snapshot {
+	snap := snapshot{
+		Version:     snapshotVersion,
+		TakenAt:     timestamp.Now(),
+		Users:       make([]*User, 0, len(m.users)),
+		Preferences: make(map[string]*UserPreferences, len(m.preferences)),
+		Credentials: make(map[string]*Credentials, len(m.credentials)),
+		Posts:       make([]*Post, 0, len(m.posts)),
+		Comments:    make([]*Comment, 0, len(m.comments)),
+		Invitations: make([]*StoredInvitation, 0, len(m.invitations)),
+	}
+	for _, u := range m.users {
+		snap.Users = append(snap.Users, u.clone())
+	}
+	for id, p := range m.preferences {
+		c := *p
+		snap.Preferences[id] = &c
+	}
+	for id, cr := range m.credentials {
+		c := *cr
+		snap.Credentials[id] = &c
+	}
+	for _, p := range m.posts {
+		snap.Posts = append(snap.Posts, p.clone())
+	}
+	for _, c := range m.comments {
+		snap.Comments = append(snap.Comments, c.clone())
+	}
+	for _, inv := range m.invitations {
+		snap.Invitations = append(snap.Invitations, inv.clone())
+	}
+	return snap
+}
+
+func writeSnapshot(w io.Writer, snap snapshot) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(snap)
+}
+
+// ReadSnapshot replaces the store's contents with a snapshot read from r.
+// Restored users count as just used; if there are more than the store's cap,
+// the surplus is evicted.
+func (m *MemoryStore) ReadSnapshot(r io.Reader) error {
+	var snap snapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("snapshot version %d is not supported, want %d", snap.Version, snapshotVersion)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.users = make(map[string]*User, len(snap.Users))
+	m.preferences = make(map[string]*UserPreferences, len(snap.Preferences))
+	m.credentials = make(map[string]*Credentials, len(snap.Credentials))
+	m.posts = make(map[string]*Post, len(snap.Posts))
+	m.comments = make(map[string]*Comment, len(snap.Comments))
+	m.invitations = make(map[string]*StoredInvitation, len(snap.Invitations))
+	m.recency.Init()
+	clear(m.entries)
+	for _, u := range snap.Users {
+		m.users[u.ID] = u
+		m.touch(u.ID)
+	}
+	for id, p := range snap.Preferences {
+		if _, ok := m.users[id]; ok {
+			m.preferences[id] = p
+		}
+	}
+	for id, c := range snap.Credentials {
+		if _, ok := m.users[id]; ok {
+			m.credentials[id] = c
+		}
+	}
+	for _, p := range snap.Posts {
+		m.putPost(p)
+	}
+	for _, c := range snap.Comments {
+		if m.canComment(c) {
+			m.comments[c.ID] = c
+		}
+	}
+	for _, inv := range snap.Invitations {
+		m.invitations[inv.ID] = inv
+	}
+	for m.maxUsers > 0 && len(m.users) > m.maxUsers {
+		m.evict(m.recency.Back(), EvictedLRU)
+	}
+	return nil
+}
+
+// SaveSnapshot writes a snapshot to path. It writes a temporary file next to
+// it first and renames it into place, so a crash mid-write leaves the last
+// good snapshot intact. With a write-ahead log open, it also compacts the
+// log: the entries the snapshot covers are set aside when it is taken and
+// deleted once it is in place.
+func (m *MemoryStore) SaveSnapshot(path string) error {
+	m.saving.Lock()
+	defer m.saving.Unlock()
+	m.mu.Lock()
+	snap := m.snapshot()
+	compacting, err := m.rotateWAL()
+	m.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("rotate write-ahead log: %w", err)
+	}
+	if err := saveSnapshotFile(path, snap); err != nil {
+		return err
+	}
+	if compacting != "" {
+		return os.Remove(compacting)
+	}
+	return nil
+}
+
+func saveSnapshotFile(path string, snap snapshot) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // fails harmlessly once renamed
+	if err := writeSnapshot(f, snap); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// LoadSnapshot restores the store from the snapshot at path. A missing file
+// is not an error: the store starts empty, as on first boot.
+func (m *MemoryStore) LoadSnapshot(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return m.ReadSnapshot(f)
+}
+
+// snapshotLoop saves the store every interval until ctx is done.
+func (s *Server) snapshotLoop(ctx context.Context, snap snapshotter, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.saveSnapshot(snap)
+		}
+	}
+}
+
+func (s *Server) saveSnapshot(snap snapshotter) {
+	start := time.Now()
+	if err := snap.SaveSnapshot(s.cfg.StoreSnapshotPath); err != nil {
+		s.logger.Error("failed to save store snapshot", "path", s.cfg.StoreSnapshotPath, "err", err)
+		return
+	}
+	s.logger.Debug("saved store snapshot", "path", s.cfg.StoreSnapshotPath, "took", time.Since(start))
+}
+
+// purgeErasedData saves a snapshot straight away after an erasure or a
+// retention purge, which also compacts the write-ahead log, so the removed
+// users don't linger on disk until the next scheduled save.
+func (s *Server) purgeErasedData() {
+	if snap, ok := s.store.(snapshotter); ok && s.cfg.StoreSnapshotPath != "" {
+		s.saveSnapshot(snap)
+	}
+}
@@ -0,0 +1,326 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	humayaml "github.com/danielgtaylor/huma/v2/yaml"
+)
+
+// SpecOptions selects which variants of the OpenAPI document WriteSpec
+// writes next to the JSON one, and what it stamps and signs them with.
+type SpecOptions struct {
+	YAML    bool // v1.yaml alongside v1.json
+	Bundled bool // v1.bundled.json (and .yaml) with every schema $ref inlined
+
+	// Build is stamped into the spec as info.x-build.
+	Build SpecBuild
+	// SigningKey, if set, signs the checksum file, as v1.sha256.sig.
+	SigningKey ed25519.PrivateKey
+}
+
+// SpecBuild says what a spec file was generated from.
+type SpecBuild struct {
+	Version     string `json:"version"`
+	Commit      string `json:"commit,omitempty"`
+	GeneratedAt string `json:"generated_at"`
+}
+
+// specBuildKey is the info extension holding the SpecBuild.
+const specBuildKey = "x-build"
+
+// checksumsPath and signaturePath name the files that go with the spec at
+// jsonPath: v1.json gives v1.sha256, in the format of sha256sum, and
+// v1.sha256.sig, the base64 Ed25519 signature of v1.sha256.
+func checksumsPath(jsonPath string) string {
+	return strings.TrimSuffix(jsonPath, ".json") + ".sha256"
+}
+
+func signaturePath(jsonPath string) string {
+	return checksumsPath(jsonPath) + ".sig"
+}
+
+// marshalSpec returns the spec's JSON stamped with build.
+func marshalSpec(spec *huma.OpenAPI, build SpecBuild) ([]byte, error) {
+	saved := spec.Info.Extensions
+	defer func() { spec.Info.Extensions = saved }()
+	spec.Info.Extensions = map[string]any{}
+	for k, v := range saved {
+		spec.Info.Extensions[k] = v
+	}
+	spec.Info.Extensions[specBuildKey] = build
+	return spec.MarshalJSON()
+}
+
+// committedBuild returns the build the spec file b is stamped with, and
+// whether it has one.
+func committedBuild(b []byte) (SpecBuild, bool) {
+	var doc struct {
+		Info struct {
+			Build *SpecBuild `json:"x-build"`
+		} `json:"info"`
+	}
+	if json.Unmarshal(b, &doc) != nil || doc.Info.Build == nil {
+		return SpecBuild{}, false
+	}
+	return *doc.Info.Build, true
+}
+
+// currentSpec returns the spec s serves as it would be written over the
+// file at jsonPath. If only the x-build stamp would change, the file's is
+// kept, so regenerating an unchanged spec leaves the files alone.
+func (s *Server) currentSpec(jsonPath string, build SpecBuild) ([]byte, error) {
+	if old, err := os.ReadFile(jsonPath); err == nil {
+		if committed, ok := committedBuild(old); ok {
+			b, err := marshalSpec(s.OpenAPI(), committed)
+			if err != nil {
+				return nil, err
+			}
+			if bytes.Equal(bytes.TrimSpace(b), bytes.TrimSpace(old)) {
+				return b, nil
+			}
+		}
+	}
+	if build.GeneratedAt == "" {
+		build.GeneratedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	return marshalSpec(s.OpenAPI(), build)
+}
+
+// WriteSpec writes the OpenAPI spec to jsonPath plus the variants selected in
+// opts and returns the paths it wrote. The other files share its name:
+// v1.json gives v1.yaml, v1.bundled.json and v1.bundled.yaml, and v1.sha256
+// with their checksums, which VerifySpec checks.
+func (s *Server) WriteSpec(jsonPath string, opts SpecOptions) ([]string, error) {
+	if err := os.MkdirAll(filepath.Dir(jsonPath), 0755); err != nil {
+		return nil, fmt.Errorf("create contracts directory: %w", err)
+	}
+	b, err := s.currentSpec(jsonPath, opts.Build)
+	if err != nil {
+		return nil, fmt.Errorf("marshal OpenAPI JSON: %w", err)
+	}
+	docs := map[string][]byte{jsonPath: b}
+	bundledPath := strings.TrimSuffix(jsonPath, ".json") + ".bundled.json"
+	if opts.Bundled {
+		bundled, err := dereference(b)
+		if err != nil {
+			return nil, fmt.Errorf("bundle OpenAPI spec: %w", err)
+		}
+		docs[bundledPath] = bundled
+	}
+	if opts.YAML {
+		var y bytes.Buffer
+		err := humayaml.Convert(&y, bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("marshal OpenAPI YAML: %w", err)
+		}
+		docs[strings.TrimSuffix(jsonPath, ".json")+".yaml"] = y.Bytes()
+		if bundled, ok := docs[bundledPath]; ok {
+			var buf bytes.Buffer
+			if err := humayaml.Convert(&buf, bytes.NewReader(bundled)); err != nil {
+				return nil, fmt.Errorf("marshal bundled OpenAPI YAML: %w", err)
+			}
+			docs[strings.TrimSuffix(bundledPath, ".json")+".yaml"] = buf.Bytes()
+		}
+	}
+
+	written := make([]string, 0, len(docs)+2)
+	for path, b := range docs {
+		if err := os.WriteFile(path, b, 0644); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	sort.Strings(written)
+
+	var sums bytes.Buffer
+	for _, path := range written {
+		sum := sha256.Sum256(docs[path])
+		fmt.Fprintf(&sums, "%x  %s\n", sum, filepath.Base(path))
+	}
+	if err := os.WriteFile(checksumsPath(jsonPath), sums.Bytes(), 0644); err != nil {
+		return written, err
+	}
+	written = append(written, checksumsPath(jsonPath))
+	if opts.SigningKey != nil {
+		sig := base64.StdEncoding.EncodeToString(ed25519.Sign(opts.SigningKey, sums.Bytes()))
+		if err := os.WriteFile(signaturePath(jsonPath), []byte(sig+"\n"), 0644); err != nil {
+			return written, err
+		}
+		written = append(written, signaturePath(jsonPath))
+	}
+	return written, nil
+}
+
+// VerifySpec checks the spec files at jsonPath: that they match their
+// checksums, so they weren't edited by hand or corrupted, that the
+// checksums are signed with key, if it is non-nil, and that the spec is
+// the one s serves, so it isn't stale.
+func (s *Server) VerifySpec(jsonPath string, key ed25519.PublicKey) error {
+	sums, err := os.ReadFile(checksumsPath(jsonPath))
+	if err != nil {
+		return fmt.Errorf("read checksums: %w", err)
+	}
+	var errs []error
+	listed := false
+	sc := bufio.NewScanner(bytes.NewReader(sums))
+	for sc.Scan() {
+		want, name, ok := strings.Cut(sc.Text(), "  ")
+		if !ok {
+			return fmt.Errorf("%s: malformed line %q", checksumsPath(jsonPath), sc.Text())
+		}
+		b, err := os.ReadFile(filepath.Join(filepath.Dir(jsonPath), name))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if sum := sha256.Sum256(b); hex.EncodeToString(sum[:]) != want {
+			errs = append(errs, fmt.Errorf("%s doesn't match its checksum", name))
+		}
+		listed = listed || name == filepath.Base(jsonPath)
+	}
+	if !listed {
+		errs = append(errs, fmt.Errorf("%s doesn't list %s", checksumsPath(jsonPath), filepath.Base(jsonPath)))
+	}
+
+	if key != nil {
+		sig, err := os.ReadFile(signaturePath(jsonPath))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("read signature: %w", err))
+		} else if raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig))); err != nil || !ed25519.Verify(key, sums, raw) {
+			errs = append(errs, errors.New("the checksums aren't signed with the key"))
+		}
+	}
+
+	committed, err := os.ReadFile(jsonPath)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	build, _ := committedBuild(committed)
+	want, err := marshalSpec(s.OpenAPI(), build)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	if !bytes.Equal(bytes.TrimSpace(committed), bytes.TrimSpace(want)) {
+		errs = append(errs, fmt.Errorf("%s is out of date", jsonPath))
+	}
+	return errors.Join(errs...)
+}
+
+// ParseSigningKey parses a PEM-encoded PKCS #8 Ed25519 private key, as
+// `openssl genpkey -algorithm ed25519` writes.
+func ParseSigningKey(b []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("no PEM block in the signing key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("the signing key isn't an Ed25519 key")
+	}
+	return priv, nil
+}
+
+// ParseVerifyKey parses a PEM-encoded PKIX Ed25519 public key, as
+// `openssl pkey -pubout` writes.
+func ParseVerifyKey(b []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("no PEM block in the public key")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("the public key isn't an Ed25519 key")
+	}
+	return pub, nil
+}
+
+// dereference inlines every `#/components/schemas/...` reference in the JSON
+// document b. Recursive schemas can't be inlined, so a reference back into a
+// schema that is already being expanded is kept, and so is that schema under
+// components; everything else is dropped from components.
+func dereference(b []byte) ([]byte, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	components, _ := doc["components"].(map[string]any)
+	schemas, _ := components["schemas"].(map[string]any)
+	kept := map[string]any{}
+
+	var expand func(v any, stack map[string]bool) any
+	expand = func(v any, stack map[string]bool) any {
+		switch v := v.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok {
+				name, isSchema := strings.CutPrefix(ref, "#/components/schemas/")
+				target, found := schemas[name]
+				if isSchema && found {
+					if stack[name] {
+						kept[name] = target
+						return v
+					}
+					stack[name] = true
+					inlined := expand(target, stack)
+					delete(stack, name)
+					return inlined
+				}
+			}
+			out := make(map[string]any, len(v))
+			for k, child := range v {
+				out[k] = expand(child, stack)
+			}
+			return out
+		case []any:
+			out := make([]any, len(v))
+			for i, child := range v {
+				out[i] = expand(child, stack)
+			}
+			return out
+		}
+		return v
+	}
+
+	for k, v := range doc {
+		if k != "components" {
+			doc[k] = expand(v, map[string]bool{})
+		}
+	}
+	if components != nil {
+		for name := range kept {
+			kept[name] = expand(schemas[name], map[string]bool{name: true})
+		}
+		if len(kept) > 0 {
+			components["schemas"] = kept
+		} else {
+			delete(components, "schemas")
+		}
+		if len(components) == 0 {
+			delete(doc, "components")
+		}
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
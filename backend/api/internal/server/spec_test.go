@@ -0,0 +1,46 @@
+package server
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSpecChecksumsAndSignature(t *testing.T) {
+	s := NewServer(Config{}, NewMemoryStore())
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "v1.json")
+	if _, err := s.WriteSpec(path, SpecOptions{YAML: true, SigningKey: priv, Build: SpecBuild{Version: "v1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.VerifySpec(path, pub); err != nil {
+		t.Fatalf("VerifySpec() of a fresh spec = %v", err)
+	}
+	first, _ := os.ReadFile(path)
+
+	// Regenerating an unchanged spec keeps its x-build stamp.
+	if _, err := s.WriteSpec(path, SpecOptions{YAML: true, SigningKey: priv, Build: SpecBuild{Version: "v2"}}); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := os.ReadFile(path); !bytes.Equal(again, first) {
+		t.Error("regenerating an unchanged spec rewrote its x-build stamp")
+	}
+
+	other, _, _ := ed25519.GenerateKey(nil)
+	if err := s.VerifySpec(path, other); err == nil {
+		t.Error("VerifySpec() with another key succeeded")
+	}
+	yamlPath := strings.TrimSuffix(path, ".json") + ".yaml"
+	if err := os.WriteFile(yamlPath, []byte("openapi: 3.1.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.VerifySpec(path, nil); err == nil || !strings.Contains(err.Error(), "v1.yaml doesn't match its checksum") {
+		t.Errorf("VerifySpec() of an edited v1.yaml = %v", err)
+	}
+}
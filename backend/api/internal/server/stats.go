@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// idDateLayout formats the day a user was created on, in the server's time
+// zone, as the ID generator tells it.
+const idDateLayout = "20060102"
+
+// Stats are aggregate counts over the store.
+type Stats struct {
+	Users        int            `json:"users" doc:"Number of users, whatever their status"`
+	CreatedToday int            `json:"created_today" doc:"Number of users created since midnight, in the server's time zone; always 0 with ID_GENERATOR=uuidv4, as those IDs don't tell"`
+	ByStatus     map[string]int `json:"by_status" example:"{\"active\":120,\"suspended\":3}" doc:"Number of users with each status; statuses no user has are left out"`
+	ByTag        map[string]int `json:"by_tag" example:"{\"beta\":12}" doc:"Number of users carrying each tag"`
+	Posts        int            `json:"posts" doc:"Number of posts"`
+	Comments     int            `json:"comments" doc:"Number of comments, replies included"`
+	ComputedAt   timestamp.Time `json:"computed_at" doc:"When the counts were taken; up to STATS_CACHE_TTL ago"`
+}
+
+type StatsInput struct {
+	AdminInput
+}
+
+type StatsOutput struct {
+	Body *Stats
+}
+
+// statsStore is implemented by stores that can count what they hold without
+// copying it out, as ListUsers does. Stats falls back to listing for the
+// others.
+type statsStore interface {
+	// Stats counts the users, posts and comments; created_today counts
+	// the users whose IDs createdToday is true of.
+	Stats(ctx context.Context, createdToday func(id string) bool) (*Stats, error)
+}
+
+// statsCounter tallies users into Stats.
+type statsCounter struct {
+	stats        *Stats
+	createdToday func(id string) bool
+}
+
+func newStatsCounter(createdToday func(id string) bool) *statsCounter {
+	return &statsCounter{stats: &Stats{ByStatus: map[string]int{}, ByTag: map[string]int{}}, createdToday: createdToday}
+}
+
+func (c *statsCounter) add(u *User) {
+	c.stats.Users++
+	if c.createdToday(u.ID) {
+		c.stats.CreatedToday++
+	}
+	c.stats.ByStatus[string(u.Status)]++
+	for _, tag := range u.Tags {
+		c.stats.ByTag[tag]++
+	}
+}
+
+// Stats counts under the read lock, without cloning anything.
+func (m *MemoryStore) Stats(ctx context.Context, createdToday func(id string) bool) (*Stats, error) {
+	defer m.lockForRead()()
+	m.expire()
+	c := newStatsCounter(createdToday)
+	for _, u := range m.users {
+		c.add(u)
+	}
+	c.stats.Posts, c.stats.Comments = len(m.posts), len(m.comments)
+	return c.stats, nil
+}
+
+// Stats counts what this replica has applied, like its other reads.
+func (s *RaftStore) Stats(ctx context.Context, createdToday func(id string) bool) (*Stats, error) {
+	return s.local.Stats(ctx, createdToday)
+}
+
+// statsCache keeps the last Stats for Config.StatsCacheTTL.
+type statsCache struct {
+	mu    sync.Mutex
+	stats *Stats
+}
+
+// stats returns the counts, computed by the store if it can, cached for
+// cfg.StatsCacheTTL.
+func (s *Server) stats(ctx context.Context) (*Stats, error) {
+	now := time.Now()
+	s.statsCache.mu.Lock()
+	defer s.statsCache.mu.Unlock()
+	if c := s.statsCache.stats; c != nil && now.Sub(c.ComputedAt.Time) < s.cfg.StatsCacheTTL {
+		return c, nil
+	}
+	// UUIDv4s don't tell when they were made, so none count as today's.
+	today := now.Format(idDateLayout)
+	createdToday := func(id string) bool {
+		t, ok := s.users.idGen.Time(id)
+		return ok && t.In(now.Location()).Format(idDateLayout) == today
+	}
+	var stats *Stats
+	if ss, ok := s.store.(statsStore); ok {
+		var err error
+		if stats, err = ss.Stats(ctx, createdToday); err != nil {
+			return nil, err
+		}
+	} else {
+		users, err := s.store.ListUsers(ctx)
+		if err != nil {
+			return nil, err
+		}
+		posts, err := s.store.ListPosts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]string, len(posts))
+		for i, p := range posts {
+			ids[i] = p.ID
+		}
+		comments, err := s.store.ListComments(ctx, ids...)
+		if err != nil {
+			return nil, err
+		}
+		c := newStatsCounter(createdToday)
+		for _, u := range users {
+			c.add(u)
+		}
+		stats = c.stats
+		stats.Posts, stats.Comments = len(posts), len(comments)
+	}
+	stats.ComputedAt = timestamp.From(now)
+	s.statsCache.stats = stats
+	return stats, nil
+}
+
+// getStats is the get-v1-stats handler.
+func (s *Server) getStats(ctx context.Context, input *StatsInput) (*StatsOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	stats, err := s.stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &StatsOutput{Body: stats}, nil
+}
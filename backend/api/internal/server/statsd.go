@@ -0,0 +1,89 @@
+package server
+
+import (
+	"time"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+)
+
+// statsdRecorder sends the metrics to a DogStatsD agent, tagged the same way
+// the Prometheus labels are.
+type statsdRecorder struct {
+	client *statsd.Client
+}
+
+// newStatsdRecorder sends to addr, or to DD_AGENT_HOST and DD_DOGSTATSD_PORT
+// when addr is empty, with every metric prefixed "api.".
+func newStatsdRecorder(addr string) (*statsdRecorder, error) {
+	client, err := statsd.New(addr, statsd.WithNamespace("api."))
+	if err != nil {
+		return nil, err
+	}
+	return &statsdRecorder{client: client}, nil
+}
+
+// request drops traceID; DogStatsD has no exemplars.
+func (s *statsdRecorder) request(method, route, statusClass string, took time.Duration, traceID string) {
+	tags := []string{"method:" + method, "route:" + route, "status_class:" + statusClass}
+	// Send errors only mean the agent is unreachable; metrics are best effort.
+	_ = s.client.Incr("http.requests", tags, 1)
+	_ = s.client.Distribution("http.request.duration", took.Seconds(), tags, 1)
+}
+
+func (s *statsdRecorder) canaryRequest(method, route, statusClass string, took time.Duration) {
+	tags := []string{"method:" + method, "route:" + route, "status_class:" + statusClass}
+	_ = s.client.Incr("http.canary_requests", tags, 1)
+	_ = s.client.Distribution("http.canary_request.duration", took.Seconds(), tags, 1)
+}
+
+func (s *statsdRecorder) shadowed(result string) {
+	_ = s.client.Incr("http.shadow_requests", []string{"result:" + result}, 1)
+}
+
+func (s *statsdRecorder) slowRequest(method, route string) {
+	_ = s.client.Incr("http.slow_requests", []string{"method:" + method, "route:" + route}, 1)
+}
+
+func (s *statsdRecorder) eviction(reason string) {
+	_ = s.client.Incr("store.evictions", []string{"reason:" + reason}, 1)
+}
+
+func (s *statsdRecorder) retentionPurged(rule string, n int) {
+	_ = s.client.Count("retention.purged", int64(n), []string{"rule:" + rule}, 1)
+}
+
+func (s *statsdRecorder) deduplicated(operation string) {
+	_ = s.client.Incr("http.deduplicated_requests", []string{"operation:" + operation}, 1)
+}
+
+func (s *statsdRecorder) shed(lane, reason string) {
+	_ = s.client.Incr("http.shed_requests", []string{"lane:" + lane, "reason:" + reason}, 1)
+}
+
+func (s *statsdRecorder) concurrencyLimited(group, reason string) {
+	_ = s.client.Incr("http.concurrency_limited_requests", []string{"group:" + group, "reason:" + reason}, 1)
+}
+
+func (s *statsdRecorder) leader(leading bool) {
+	_ = s.client.Gauge("leader", boolGauge(leading), nil, 1)
+}
+
+func (s *statsdRecorder) deadLetters(kind string, n int) {
+	_ = s.client.Gauge("dead_letters", float64(n), []string{"kind:" + kind}, 1)
+}
+
+func (s *statsdRecorder) mailQueued(n int) {
+	_ = s.client.Gauge("mail.queue_depth", float64(n), nil, 1)
+}
+
+func (s *statsdRecorder) pool(name string, inUse, idle int, waits, timeouts uint64, waited time.Duration) {
+	_ = s.client.Gauge("pool.connections", float64(inUse), []string{"pool:" + name, "state:in_use"}, 1)
+	_ = s.client.Gauge("pool.connections", float64(idle), []string{"pool:" + name, "state:idle"}, 1)
+	_ = s.client.Count("pool.waits", int64(waits), []string{"pool:" + name}, 1)
+	_ = s.client.Count("pool.wait_ms", waited.Milliseconds(), []string{"pool:" + name}, 1)
+	_ = s.client.Count("pool.timeouts", int64(timeouts), []string{"pool:" + name}, 1)
+}
+
+func (s *statsdRecorder) close() error {
+	return s.client.Close()
+}
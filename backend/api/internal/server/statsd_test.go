@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatsDExporter(t *testing.T) {
+	agent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer agent.Close()
+	s := NewServer(Config{MetricsExporter: MetricsStatsD, StatsDAddr: agent.LocalAddr().String()}, NewMemoryStore())
+	rec, ok := s.metrics.(*statsdRecorder)
+	if !ok {
+		t.Fatalf("metrics go to a %T, want StatsD", s.metrics)
+	}
+	s.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/users", nil))
+	// Closing flushes what the client buffered.
+	if err := rec.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got strings.Builder
+	buf := make([]byte, 64<<10)
+	agent.SetReadDeadline(time.Now().Add(5 * time.Second))
+	want := []string{"api.http.requests:1|c|#method:GET,route:/v1/users,status_class:2xx", "api.http.request.duration:"}
+	for !containsAll(got.String(), want) {
+		n, _, err := agent.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("the agent got %q, want %q: %v", got.String(), want, err)
+		}
+		got.Write(buf[:n])
+		got.WriteByte('\n')
+	}
+}
+
+// containsAll reports whether s contains every one of subs.
+func containsAll(s string, subs []string) bool {
+	for _, sub := range subs {
+		if !strings.Contains(s, sub) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,35 @@
+package server
+
+import "slices"
+
+// UserStatus is where a user is in their account lifecycle.
+type UserStatus string
+
+const (
+	UserStatusInvited   UserStatus = "invited"
+	UserStatusActive    UserStatus = "active"
+	UserStatusSuspended UserStatus = "suspended"
+	UserStatusDeleted   UserStatus = "deleted"
+)
+
+// userStatusTransitions lists the statuses each status may move to. Deleted is
+// terminal; everything else can be deleted.
+var userStatusTransitions = map[UserStatus][]UserStatus{
+	UserStatusInvited:   {UserStatusActive, UserStatusDeleted},
+	UserStatusActive:    {UserStatusSuspended, UserStatusDeleted},
+	UserStatusSuspended: {UserStatusActive, UserStatusDeleted},
+	UserStatusDeleted:   {},
+}
+
+// userStatusEvents is the event published when a user enters each status.
+var userStatusEvents = map[UserStatus]string{
+	UserStatusInvited:   "user.invited",
+	UserStatusActive:    "user.activated",
+	UserStatusSuspended: "user.deactivated",
+	UserStatusDeleted:   "user.deleted",
+}
+
+// CanTransitionTo reports whether a user in status s may be moved to next.
+func (s UserStatus) CanTransitionTo(next UserStatus) bool {
+	return slices.Contains(userStatusTransitions[s], next)
+}
@@ -0,0 +1,532 @@
+package server
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by a Store when the requested record doesn't exist.
+var ErrNotFound = errors.New("not found")
+
+// Store persists users, their preferences, their login credentials, the
+// posts they write and the comments on those. Implementations must be safe
+// for concurrent use and hand out copies: changing a returned user has no
+// effect until it is passed to PutUser. The storetest package checks an
+// implementation against all of this.
+type Store interface {
+	GetUser(ctx context.Context, id string) (*User, error)
+	// ListUsers returns every user, in no particular order.
+	ListUsers(ctx context.Context) ([]*User, error)
+	// PutUser creates the user or replaces the one with the same ID.
+	PutUser(ctx context.Context, user *User) error
+	// DeleteUser removes the user along with their preferences,
+	// credentials, posts and comments, or returns ErrNotFound.
+	DeleteUser(ctx context.Context, id string) error
+
+	GetPreferences(ctx context.Context, userID string) (*UserPreferences, error)
+	PutPreferences(ctx context.Context, userID string, prefs *UserPreferences) error
+
+	// GetCredentials returns ErrNotFound for users who have neither a
+	// password nor API keys.
+	GetCredentials(ctx context.Context, userID string) (*Credentials, error)
+	PutCredentials(ctx context.Context, userID string, creds *Credentials) error
+
+	GetPost(ctx context.Context, id string) (*Post, error)
+	// ListPosts returns every post, in no particular order.
+	ListPosts(ctx context.Context) ([]*Post, error)
+	// PutPost creates the post or replaces the one with the same ID. It
+	// returns ErrNotFound if the author doesn't exist.
+	PutPost(ctx context.Context, post *Post) error
+	// DeletePost removes the post along with the comments on it, or
+	// returns ErrNotFound.
+	DeletePost(ctx context.Context, id string) error
+
+	GetComment(ctx context.Context, id string) (*Comment, error)
+	// ListComments returns the comments on the posts, replies included, in
+	// no particular order. It takes several posts so their comments can be
+	// loaded at once rather than one call per post.
+	ListComments(ctx context.Context, postIDs ...string) ([]*Comment, error)
+	// PutComment creates the comment or replaces the one with the same ID.
+	// It returns ErrNotFound if the post or author doesn't exist.
+	PutComment(ctx context.Context, comment *Comment) error
+	// DeleteComment removes the comment along with the replies to it, or
+	// returns ErrNotFound.
+	DeleteComment(ctx context.Context, id string) error
+
+	// GetInvitation returns ErrNotFound for an invitation that doesn't exist.
+	GetInvitation(ctx context.Context, id string) (*StoredInvitation, error)
+	// ListInvitations returns every invitation, in no particular order.
+	ListInvitations(ctx context.Context) ([]*StoredInvitation, error)
+	// PutInvitation creates the invitation or replaces the one with the
+	// same ID.
+	PutInvitation(ctx context.Context, inv *StoredInvitation) error
+	// DeleteInvitation removes the invitation, or returns ErrNotFound.
+	DeleteInvitation(ctx context.Context, id string) error
+}
+
+// MemoryStore is a Store that keeps everything in process memory. It is the
+// default, and what tests use. Unless bounded with WithMaxUsers or
+// WithUserTTL it grows without limit.
+type MemoryStore struct {
+	mu          sync.RWMutex
+	users       map[string]*User
+	preferences map[string]*UserPreferences
+	credentials map[string]*Credentials
+	posts       map[string]*Post
+	comments    map[string]*Comment
+	invitations map[string]*StoredInvitation
+
+	maxUsers int
+	ttl      time.Duration
+	now      func() time.Time
+	onEvict  func(reason string)
+	// recency orders user IDs by last access, most recent first, when the
+	// store is bounded; entries maps an ID to its element.
+	recency *list.List
+	entries map[string]*list.Element
+
+	// wal, once OpenWAL has been called, gets every mutation before it is
+	// applied.
+	wal     *os.File
+	walPath string
+	// saving is held by SaveSnapshot from rotating the log until the
+	// compacted entries are removed, so an older snapshot never replaces
+	// a newer one whose entries are gone.
+	saving sync.Mutex
+}
+
+// recencyEntry is an element of MemoryStore.recency.
+type recencyEntry struct {
+	id      string
+	touched time.Time
+}
+
+// Eviction reasons passed to the OnEvict callback.
+const (
+	EvictedLRU = "lru" // the store was full
+	EvictedTTL = "ttl" // the user wasn't read or written within the TTL
+)
+
+// MemoryStoreOption configures NewMemoryStore.
+type MemoryStoreOption func(*MemoryStore)
+
+// WithMaxUsers caps the store at n users, evicting the least recently read
+// or written one to make room. Zero means no cap.
+func WithMaxUsers(n int) MemoryStoreOption {
+	return func(m *MemoryStore) { m.maxUsers = n }
+}
+
+// WithUserTTL evicts users that haven't been read or written for ttl. Zero
+// means they never expire.
+func WithUserTTL(ttl time.Duration) MemoryStoreOption {
+	return func(m *MemoryStore) { m.ttl = ttl }
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore(opts ...MemoryStoreOption) *MemoryStore {
+	m := &MemoryStore{
+		users:       map[string]*User{},
+		preferences: map[string]*UserPreferences{},
+		credentials: map[string]*Credentials{},
+		posts:       map[string]*Post{},
+		comments:    map[string]*Comment{},
+		invitations: map[string]*StoredInvitation{},
+		now:         time.Now,
+		recency:     list.New(),
+		entries:     map[string]*list.Element{},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// OnEvict registers fn to be called with the reason for every user the store
+// evicts. fn runs with the store locked and must not call back into it.
+func (m *MemoryStore) OnEvict(fn func(reason string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onEvict = fn
+}
+
+func (m *MemoryStore) bounded() bool {
+	return m.maxUsers > 0 || m.ttl > 0
+}
+
+// lockForRead takes the read lock, or the write lock if reads have to update
+// the recency list, and returns the matching unlock.
+func (m *MemoryStore) lockForRead() (unlock func()) {
+	if m.bounded() {
+		m.mu.Lock()
+		return m.mu.Unlock
+	}
+	m.mu.RLock()
+	return m.mu.RUnlock
+}
+
+func (m *MemoryStore) GetUser(ctx context.Context, id string) (*User, error) {
+	defer m.lockForRead()()
+	m.expire()
+	user, ok := m.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	m.touch(id)
+	return user.clone(), nil
+}
+
+func (m *MemoryStore) ListUsers(ctx context.Context) ([]*User, error) {
+	defer m.lockForRead()()
+	m.expire()
+	users := make([]*User, 0, len(m.users))
+	for _, u := range m.users {
+		users = append(users, u.clone())
+	}
+	return users, nil
+}
+
+func (m *MemoryStore) PutUser(ctx context.Context, user *User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.logMutation(walRecord{Op: walPutUser, User: user}); err != nil {
+		return err
+	}
+	m.putUser(user.clone())
+	return nil
+}
+
+// putUser stores user, which the store now owns. The caller holds the write
+// lock.
+func (m *MemoryStore) putUser(user *User) {
+	m.users[user.ID] = user
+	m.touch(user.ID)
+	m.expire()
+	for m.maxUsers > 0 && len(m.users) > m.maxUsers {
+		m.evict(m.recency.Back(), EvictedLRU)
+	}
+}
+
+func (m *MemoryStore) DeleteUser(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.users[id]; !ok {
+		return ErrNotFound
+	}
+	if err := m.logMutation(walRecord{Op: walDeleteUser, ID: id}); err != nil {
+		return err
+	}
+	m.remove(id)
+	return nil
+}
+
+func (m *MemoryStore) GetPreferences(ctx context.Context, userID string) (*UserPreferences, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	prefs, ok := m.preferences[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c := *prefs
+	return &c, nil
+}
+
+func (m *MemoryStore) PutPreferences(ctx context.Context, userID string, prefs *UserPreferences) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.logMutation(walRecord{Op: walPutPreferences, ID: userID, Preferences: prefs}); err != nil {
+		return err
+	}
+	c := *prefs
+	m.preferences[userID] = &c
+	return nil
+}
+
+func (m *MemoryStore) GetCredentials(ctx context.Context, userID string) (*Credentials, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	creds, ok := m.credentials[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c := *creds
+	return &c, nil
+}
+
+func (m *MemoryStore) PutCredentials(ctx context.Context, userID string, creds *Credentials) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.logMutation(walRecord{Op: walPutCredentials, ID: userID, Credentials: creds}); err != nil {
+		return err
+	}
+	c := *creds
+	m.credentials[userID] = &c
+	return nil
+}
+
+func (m *MemoryStore) GetPost(ctx context.Context, id string) (*Post, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	post, ok := m.posts[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return post.clone(), nil
+}
+
+func (m *MemoryStore) ListPosts(ctx context.Context) ([]*Post, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	posts := make([]*Post, 0, len(m.posts))
+	for _, p := range m.posts {
+		posts = append(posts, p.clone())
+	}
+	return posts, nil
+}
+
+func (m *MemoryStore) PutPost(ctx context.Context, post *Post) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.users[post.AuthorID]; !ok {
+		return ErrNotFound
+	}
+	if err := m.logMutation(walRecord{Op: walPutPost, Post: post}); err != nil {
+		return err
+	}
+	m.putPost(post.clone())
+	return nil
+}
+
+// putPost stores post, which the store now owns, unless its author is gone:
+// their posts went with them. The caller holds the write lock.
+func (m *MemoryStore) putPost(post *Post) {
+	if _, ok := m.users[post.AuthorID]; ok {
+		m.posts[post.ID] = post
+	}
+}
+
+func (m *MemoryStore) DeletePost(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.posts[id]; !ok {
+		return ErrNotFound
+	}
+	if err := m.logMutation(walRecord{Op: walDeletePost, ID: id}); err != nil {
+		return err
+	}
+	m.removePost(id)
+	return nil
+}
+
+// removePost drops the post and the comments on it.
+func (m *MemoryStore) removePost(id string) {
+	delete(m.posts, id)
+	m.removeComments(func(c *Comment) bool { return c.PostID == id })
+}
+
+func (m *MemoryStore) GetComment(ctx context.Context, id string) (*Comment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	comment, ok := m.comments[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return comment.clone(), nil
+}
+
+// idSet returns the set of ids.
+func idSet(ids []string) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+func (m *MemoryStore) ListComments(ctx context.Context, postIDs ...string) ([]*Comment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	posts := idSet(postIDs)
+	comments := []*Comment{}
+	for _, c := range m.comments {
+		if posts[c.PostID] {
+			comments = append(comments, c.clone())
+		}
+	}
+	return comments, nil
+}
+
+func (m *MemoryStore) PutComment(ctx context.Context, comment *Comment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.canComment(comment) {
+		return ErrNotFound
+	}
+	if err := m.logMutation(walRecord{Op: walPutComment, Comment: comment}); err != nil {
+		return err
+	}
+	m.comments[comment.ID] = comment.clone()
+	return nil
+}
+
+// canComment reports whether the post and author of comment exist. The
+// caller holds a lock.
+func (m *MemoryStore) canComment(comment *Comment) bool {
+	_, post := m.posts[comment.PostID]
+	_, author := m.users[comment.AuthorID]
+	return post && author
+}
+
+func (m *MemoryStore) DeleteComment(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.comments[id]; !ok {
+		return ErrNotFound
+	}
+	if err := m.logMutation(walRecord{Op: walDeleteComment, ID: id}); err != nil {
+		return err
+	}
+	m.removeComments(func(c *Comment) bool { return c.ID == id })
+	return nil
+}
+
+func (m *MemoryStore) GetInvitation(ctx context.Context, id string) (*StoredInvitation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	inv, ok := m.invitations[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return inv.clone(), nil
+}
+
+func (m *MemoryStore) ListInvitations(ctx context.Context) ([]*StoredInvitation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	invitations := make([]*StoredInvitation, 0, len(m.invitations))
+	for _, inv := range m.invitations {
+		invitations = append(invitations, inv.clone())
+	}
+	return invitations, nil
+}
+
+func (m *MemoryStore) PutInvitation(ctx context.Context, inv *StoredInvitation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.logMutation(walRecord{Op: walPutInvitation, Invitation: inv}); err != nil {
+		return err
+	}
+	m.invitations[inv.ID] = inv.clone()
+	return nil
+}
+
+func (m *MemoryStore) DeleteInvitation(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.invitations[id]; !ok {
+		return ErrNotFound
+	}
+	if err := m.logMutation(walRecord{Op: walDeleteInvitation, ID: id}); err != nil {
+		return err
+	}
+	delete(m.invitations, id)
+	return nil
+}
+
+// removeComments drops the comments drop matches and, level by level, the
+// replies under them. The caller holds the write lock.
+func (m *MemoryStore) removeComments(drop func(*Comment) bool) {
+	gone := map[string]bool{}
+	for id, c := range m.comments {
+		if drop(c) {
+			gone[id] = true
+		}
+	}
+	for len(gone) > 0 {
+		for id := range gone {
+			delete(m.comments, id)
+		}
+		replies := map[string]bool{}
+		for id, c := range m.comments {
+			if gone[c.ParentID] {
+				replies[id] = true
+			}
+		}
+		gone = replies
+	}
+}
+
+// touch marks id as just used. The caller holds the write lock if the store
+// is bounded.
+func (m *MemoryStore) touch(id string) {
+	if !m.bounded() {
+		return
+	}
+	entry := recencyEntry{id: id, touched: m.now()}
+	if e, ok := m.entries[id]; ok {
+		e.Value = entry
+		m.recency.MoveToFront(e)
+		return
+	}
+	m.entries[id] = m.recency.PushFront(entry)
+}
+
+// expire evicts the users whose TTL has run out. The least recently used are
+// at the back, so it stops at the first one still fresh.
+func (m *MemoryStore) expire() {
+	if m.ttl <= 0 {
+		return
+	}
+	cutoff := m.now().Add(-m.ttl)
+	for e := m.recency.Back(); e != nil && e.Value.(recencyEntry).touched.Before(cutoff); e = m.recency.Back() {
+		m.evict(e, EvictedTTL)
+	}
+}
+
+func (m *MemoryStore) evict(e *list.Element, reason string) {
+	m.remove(e.Value.(recencyEntry).id)
+	if m.onEvict != nil {
+		m.onEvict(reason)
+	}
+}
+
+// remove drops the user, their preferences, credentials, posts, comments and
+// recency entry.
+func (m *MemoryStore) remove(id string) {
+	delete(m.users, id)
+	delete(m.preferences, id)
+	delete(m.credentials, id)
+	posts := map[string]bool{}
+	for postID, p := range m.posts {
+		if p.AuthorID == id {
+			delete(m.posts, postID)
+			posts[postID] = true
+		}
+	}
+	m.removeComments(func(c *Comment) bool { return c.AuthorID == id || posts[c.PostID] })
+	if e, ok := m.entries[id]; ok {
+		m.recency.Remove(e)
+		delete(m.entries, id)
+	}
+}
+
+// clone copies u deeply enough that the copy can be changed field by field.
+// Metadata, tags and unknown fields are only ever replaced wholesale, so
+// they are shared.
+func (u *User) clone() *User {
+	c := *u
+	return &c
+}
+
+func (p *Post) clone() *Post {
+	c := *p
+	return &c
+}
+
+func (c *Comment) clone() *Comment {
+	cc := *c
+	return &cc
+}
@@ -0,0 +1,369 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/fieldcrypt"
+)
+
+func TestMemoryStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	var evicted []string
+	m := NewMemoryStore(WithMaxUsers(2))
+	m.OnEvict(func(reason string) { evicted = append(evicted, reason) })
+
+	m.PutUser(ctx, &User{ID: "a"})
+	m.PutUser(ctx, &User{ID: "b"})
+	m.GetUser(ctx, "a") // b is now the least recently used
+	m.PutUser(ctx, &User{ID: "c"})
+
+	if _, err := m.GetUser(ctx, "b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("b should have been evicted, got err %v", err)
+	}
+	for _, id := range []string{"a", "c"} {
+		if _, err := m.GetUser(ctx, id); err != nil {
+			t.Errorf("%s should still be stored: %v", id, err)
+		}
+	}
+	if len(evicted) != 1 || evicted[0] != EvictedLRU {
+		t.Errorf("evictions = %v, want [lru]", evicted)
+	}
+}
+
+func TestMemoryStoreExpiresIdleUsers(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var evicted []string
+	m := NewMemoryStore(WithUserTTL(time.Hour))
+	m.now = func() time.Time { return now }
+	m.OnEvict(func(reason string) { evicted = append(evicted, reason) })
+
+	m.PutUser(ctx, &User{ID: "idle"})
+	m.PutPreferences(ctx, "idle", &UserPreferences{Locale: "de"})
+	now = now.Add(30 * time.Minute)
+	m.PutUser(ctx, &User{ID: "busy"})
+	now = now.Add(45 * time.Minute)
+
+	users, _ := m.ListUsers(ctx)
+	if len(users) != 1 || users[0].ID != "busy" {
+		t.Fatalf("users = %v, want only busy", users)
+	}
+	if _, err := m.GetPreferences(ctx, "idle"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("preferences of an expired user should go with them, got err %v", err)
+	}
+	if len(evicted) != 1 || evicted[0] != EvictedTTL {
+		t.Errorf("evictions = %v, want [ttl]", evicted)
+	}
+}
+
+func TestMemoryStoreSnapshotRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir() + "/store.json"
+	m := NewMemoryStore()
+	m.PutUser(ctx, &User{ID: "a", Name: "Ada", Tags: []string{"beta"}, Metadata: map[string]any{"plan": "pro"}})
+	m.PutPreferences(ctx, "a", &UserPreferences{Locale: "de", Timezone: "Europe/Vienna"})
+	if err := m.SaveSnapshot(path); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := NewMemoryStore()
+	if err := restored.LoadSnapshot(path); err != nil {
+		t.Fatal(err)
+	}
+	u, err := restored.GetUser(ctx, "a")
+	if err != nil || u.Name != "Ada" || len(u.Tags) != 1 || u.Metadata["plan"] != "pro" {
+		t.Errorf("restored user = %+v, %v", u, err)
+	}
+	p, err := restored.GetPreferences(ctx, "a")
+	if err != nil || p.Timezone != "Europe/Vienna" {
+		t.Errorf("restored preferences = %+v, %v", p, err)
+	}
+
+	if err := NewMemoryStore().LoadSnapshot(path + ".missing"); err != nil {
+		t.Errorf("a missing snapshot should start empty, got %v", err)
+	}
+}
+
+func TestSnapshotKeepsUnknownFields(t *testing.T) {
+	ctx := context.Background()
+	// A snapshot a newer version wrote, with a field this one doesn't know.
+	newer := `{"version":1,"taken_at":"2024-01-01T12:00:00.000Z","users":[{"id":"a","name":"Ada","email":"ada@example.com","status":"active","active":true,"pronouns":"she/her"}]}`
+	m := NewMemoryStore()
+	if err := m.ReadSnapshot(strings.NewReader(newer)); err != nil {
+		t.Fatal(err)
+	}
+	u, _ := m.GetUser(ctx, "a")
+	u.Name = "Ada King"
+	m.PutUser(ctx, u)
+
+	var buf bytes.Buffer
+	if err := m.WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var snap struct {
+		Users []map[string]any
+	}
+	if err := json.Unmarshal(buf.Bytes(), &snap); err != nil {
+		t.Fatal(err)
+	}
+	if got := snap.Users[0]; got["name"] != "Ada King" || got["pronouns"] != "she/her" {
+		t.Errorf("snapshot user = %v, want the change and the unknown field", got)
+	}
+}
+
+func TestMemoryStoreReplaysWriteAheadLog(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	snapPath, walPath := dir+"/store.json", dir+"/store.wal"
+
+	m := NewMemoryStore()
+	if err := m.OpenWAL(walPath); err != nil {
+		t.Fatal(err)
+	}
+	m.PutUser(ctx, &User{ID: "a", Name: "Ada"})
+	m.PutPost(ctx, &Post{ID: "pa", AuthorID: "a", Title: "Notes"})
+	m.PutInvitation(ctx, &StoredInvitation{Invitation: Invitation{ID: "ia", Email: "kim@example.com", Status: InvitationPending}})
+	if err := m.SaveSnapshot(snapPath); err != nil { // compacts a, pa and ia into the snapshot
+		t.Fatal(err)
+	}
+	m.PutUser(ctx, &User{ID: "b", Name: "Grace"})
+	m.PutPreferences(ctx, "b", &UserPreferences{Locale: "en"})
+	m.PutPost(ctx, &Post{ID: "pb", AuthorID: "b", Title: "Compilers"})
+	m.PutComment(ctx, &Comment{ID: "ca", PostID: "pb", AuthorID: "a", Body: "Nice."})
+	m.PutComment(ctx, &Comment{ID: "cb", PostID: "pb", AuthorID: "b", ParentID: "ca", Body: "Thanks!"})
+	m.PutComment(ctx, &Comment{ID: "cc", PostID: "pb", AuthorID: "b", Body: "Errata."})
+	m.DeleteUser(ctx, "a") // and pa, ca and the reply cb with them
+	m.DeleteInvitation(ctx, "ia")
+	m.PutInvitation(ctx, &StoredInvitation{Invitation: Invitation{ID: "ib", Email: "lee@example.com", Status: InvitationRevoked}})
+	tx := newTxStore(m)
+	tx.PutUser(ctx, &User{ID: "c", Name: "Linus"})
+	tx.PutPreferences(ctx, "c", &UserPreferences{Locale: "fi"})
+	if err := tx.commit(); err != nil { // logged as one batch record
+		t.Fatal(err)
+	}
+	// No Close or final snapshot: the process "crashed".
+
+	restored := NewMemoryStore()
+	if err := restored.LoadSnapshot(snapPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := restored.OpenWAL(walPath); err != nil {
+		t.Fatal(err)
+	}
+	defer restored.Close()
+	if _, err := restored.GetUser(ctx, "a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("a was deleted after the snapshot, got err %v", err)
+	}
+	if u, err := restored.GetUser(ctx, "b"); err != nil || u.Name != "Grace" {
+		t.Errorf("b = %+v, %v; want it replayed from the log", u, err)
+	}
+	if _, err := restored.GetPreferences(ctx, "b"); err != nil {
+		t.Errorf("b's preferences should be replayed: %v", err)
+	}
+	if _, err := restored.GetPost(ctx, "pa"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("pa went with its author, got err %v", err)
+	}
+	if p, err := restored.GetPost(ctx, "pb"); err != nil || p.Title != "Compilers" {
+		t.Errorf("pb = %+v, %v; want it replayed from the log", p, err)
+	}
+	if c, err := restored.ListComments(ctx, "pb"); err != nil || len(c) != 1 || c[0].ID != "cc" {
+		t.Errorf("comments on pb = %v, %v; want only cc, ca's thread went with a", c, err)
+	}
+	if p, err := restored.GetPreferences(ctx, "c"); err != nil || p.Locale != "fi" {
+		t.Errorf("c's preferences = %+v, %v; want the transaction replayed", p, err)
+	}
+	if invitations, err := restored.ListInvitations(ctx); err != nil || len(invitations) != 1 || invitations[0].ID != "ib" || invitations[0].Status != InvitationRevoked {
+		t.Errorf("invitations = %v, %v; want only ib, revoked, ia was deleted after the snapshot", invitations, err)
+	}
+}
+
+func TestWALDropsTornTail(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir() + "/store.wal"
+	m := NewMemoryStore()
+	if err := m.OpenWAL(path); err != nil {
+		t.Fatal(err)
+	}
+	m.PutUser(ctx, &User{ID: "a", Name: "Ada"})
+	m.Close()
+	// A crash mid-append, then a restart that writes on.
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	f.WriteString(`{"op":"put_user","user":{"id":"b","na`)
+	f.Close()
+	m = NewMemoryStore()
+	if err := m.OpenWAL(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GetUser(ctx, "b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("b from the torn line: err %v, want ErrNotFound", err)
+	}
+	m.PutUser(ctx, &User{ID: "c", Name: "Kim"})
+	m.Close()
+
+	m = NewMemoryStore()
+	if err := m.OpenWAL(path); err != nil {
+		t.Fatalf("reopening after writing past a torn line: %v", err)
+	}
+	defer m.Close()
+	for _, id := range []string{"a", "c"} {
+		if _, err := m.GetUser(ctx, id); err != nil {
+			t.Errorf("user %s: %v", id, err)
+		}
+	}
+}
+
+func TestConcurrentSnapshotsKeepEveryWrite(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	snapPath, walPath := dir+"/store.json", dir+"/store.wal"
+	m := NewMemoryStore()
+	if err := m.OpenWAL(walPath); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := range 20 {
+		m.PutUser(ctx, &User{ID: fmt.Sprint("u", i)})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := m.SaveSnapshot(snapPath); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	m.Close()
+
+	restored := NewMemoryStore()
+	if err := restored.LoadSnapshot(snapPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := restored.OpenWAL(walPath); err != nil {
+		t.Fatal(err)
+	}
+	defer restored.Close()
+	if users, _ := restored.ListUsers(ctx); len(users) != 20 {
+		t.Errorf("%d users after the restart, want all 20", len(users))
+	}
+}
+
+func TestWithTx(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+	m.PutUser(ctx, &User{ID: "a", Name: "Ada"})
+	store := timedStore{m}
+
+	// A write to a user the transaction read has it run again.
+	runs := 0
+	err := WithTx(ctx, store, func(tx Store) error {
+		runs++
+		u, err := tx.GetUser(ctx, "a")
+		if err != nil {
+			return err
+		}
+		if runs == 1 {
+			m.PutUser(ctx, &User{ID: "a", Name: "Ada Lovelace"})
+		}
+		u.Name += " (edited)"
+		if err := tx.PutUser(ctx, u); err != nil {
+			return err
+		}
+		return tx.PutPreferences(ctx, "a", &UserPreferences{Locale: "en"})
+	})
+	if err != nil || runs != 2 {
+		t.Fatalf("WithTx = %v after %d runs, want it to succeed on the second", err, runs)
+	}
+	if u, _ := m.GetUser(ctx, "a"); u.Name != "Ada Lovelace (edited)" {
+		t.Errorf("name = %q, want the outside write edited", u.Name)
+	}
+
+	// One that keeps happening fails the transaction, writing nothing.
+	err = WithTx(ctx, store, func(tx Store) error {
+		tx.GetUser(ctx, "b")
+		m.PutUser(ctx, &User{ID: "b", Name: fmt.Sprint("Grace ", runs)})
+		runs++
+		return tx.PutPreferences(ctx, "c", &UserPreferences{Locale: "fr"})
+	})
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("WithTx = %v, want ErrConflict", err)
+	}
+	if _, err := m.GetPreferences(ctx, "c"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("preferences of c were written by a transaction that failed: %v", err)
+	}
+
+	// An error from fn drops its writes.
+	boom := errors.New("boom")
+	err = WithTx(ctx, store, func(tx Store) error {
+		tx.DeleteUser(ctx, "a")
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Errorf("WithTx = %v, want fn's error", err)
+	}
+	if _, err := m.GetUser(ctx, "a"); err != nil {
+		t.Errorf("a was deleted by a transaction that failed: %v", err)
+	}
+}
+
+func TestEncryptedStore(t *testing.T) {
+	ctx := context.Background()
+	newKey := func(id string, b byte) fieldcrypt.KEK {
+		kek, err := fieldcrypt.LocalKey(id, bytes.Repeat([]byte{b}, 32))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return kek
+	}
+	old, _ := fieldcrypt.NewKeyring(newKey("k1", 1))
+	raw := NewMemoryStore()
+	store := encryptedStore{raw, old}
+	if err := store.PutUser(ctx, &User{ID: "u1", Email: "ada@example.com", Phone: "+436601234567"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := raw.PutUser(ctx, &User{ID: "u2", Email: "legacy@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, _ := raw.GetUser(ctx, "u1")
+	if strings.Contains(stored.Email, "ada") || strings.Contains(stored.Phone, "660") {
+		t.Fatalf("stored plaintext: %+v", stored)
+	}
+	if err := store.PutInvitation(ctx, &StoredInvitation{Invitation: Invitation{ID: "i1", Email: "kim@example.com"}}); err != nil {
+		t.Fatal(err)
+	}
+	if inv, _ := raw.GetInvitation(ctx, "i1"); strings.Contains(inv.Email, "kim") {
+		t.Fatalf("stored plaintext: %+v", inv)
+	}
+	if inv, err := store.GetInvitation(ctx, "i1"); err != nil || inv.Email != "kim@example.com" {
+		t.Fatalf("GetInvitation = %+v, %v", inv, err)
+	}
+	got, err := store.GetUser(ctx, "u1")
+	if err != nil || got.Email != "ada@example.com" || got.Phone != "+436601234567" {
+		t.Fatalf("GetUser = %+v, %v", got, err)
+	}
+
+	rotated, _ := fieldcrypt.NewKeyring(newKey("k2", 2), newKey("k1", 1))
+	res, err := reencrypt(ctx, raw, rotated)
+	if err != nil || res.Checked != 2 || res.Reencrypted != 2 {
+		t.Fatalf("reencrypt = %+v, %v", res, err)
+	}
+	users, _ := raw.ListUsers(ctx)
+	for _, u := range users {
+		if !rotated.Current(u.Email) {
+			t.Errorf("user %s email %q not under the new key", u.ID, u.Email)
+		}
+	}
+	current, _ := fieldcrypt.NewKeyring(newKey("k2", 2))
+	if got, err := (encryptedStore{raw, current}).GetUser(ctx, "u2"); err != nil || got.Email != "legacy@example.com" {
+		t.Errorf("GetUser after retiring k1 = %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humachi"
+)
+
+// ndjsonContentType is the media type of newline-delimited JSON.
+const ndjsonContentType = "application/x-ndjson"
+
+// streamWriteTimeout is how long get-v1-users-stream waits for the client to
+// take each user. Unlike the servers' WriteTimeout it starts over with every
+// user, so a stream lasts as long as the client keeps reading.
+const streamWriteTimeout = 10 * time.Second
+
+type StreamUsersInput struct {
+	IncludeInactive bool     `query:"include_inactive" doc:"Also stream users that are not active"`
+	Tag             []string `query:"tag,explode" doc:"Only stream users carrying this tag; repeat to require several" example:"beta"`
+	InactiveSince   string   `query:"inactive_since" doc:"Only stream users not seen since this long ago (e.g. 30d, 2w, 12h) or since an RFC 3339 timestamp" example:"30d"`
+
+	filters *ListUsersInput
+}
+
+// Resolve validates the filters as get-v1-users does, metadata.<key>=<value>
+// ones included.
+func (i *StreamUsersInput) Resolve(ctx huma.Context) []error {
+	i.filters = &ListUsersInput{IncludeInactive: i.IncludeInactive, Tag: i.Tag, InactiveSince: i.InactiveSince}
+	return i.filters.Resolve(ctx)
+}
+
+// streamUsers is the get-v1-users-stream handler. It writes the users the
+// filters select in ID order, one JSON object per line, flushing each, so
+// neither side holds the whole response. Time spent waiting for the client
+// to read doesn't count towards the slow request threshold.
+func (s *Server) streamUsers(ctx context.Context, input *StreamUsersInput) (*huma.StreamResponse, error) {
+	users, err := s.users.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	users = slices.DeleteFunc(users, func(u *User) bool { return !input.filters.matches(u) })
+	slices.SortFunc(users, func(a, b *User) int { return strings.Compare(a.ID, b.ID) })
+
+	return &huma.StreamResponse{Body: func(hctx huma.Context) {
+		hctx.SetHeader("Content-Type", ndjsonContentType)
+		_, w := humachi.Unwrap(hctx)
+		rc := http.NewResponseController(w)
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		timing := timingFrom(ctx)
+		for n, u := range users {
+			start := time.Now()
+			// Writers that can't have a deadline or be flushed, like
+			// httptest's, don't need either.
+			rc.SetWriteDeadline(start.Add(streamWriteTimeout))
+			err := enc.Encode(u)
+			if err == nil {
+				rc.Flush()
+			}
+			if timing != nil {
+				timing.waited.Add(int64(time.Since(start)))
+			}
+			if err != nil {
+				s.logger.DebugContext(ctx, "user stream ended early", "sent", n, "total", len(users), "err", err)
+				return
+			}
+		}
+	}}, nil
+}
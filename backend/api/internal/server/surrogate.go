@@ -0,0 +1,307 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// A CDN or reverse proxy in front of the API may keep the responses of
+// operations with a public CachePolicy. Those of Tagged ones name what they
+// hold in a Surrogate-Key header, and when it last changed in
+// Last-Modified; when something changes, the keys naming it are purged
+// through a CachePurger, so caches needn't wait out the max-age.
+const surrogateKeyHeader = "Surrogate-Key"
+
+// surrogated is implemented by response bodies that can name the resources
+// in them.
+type surrogated interface {
+	// surrogateKeys returns the keys naming what the body holds, each of
+	// which is purged when that changes.
+	surrogateKeys() []string
+	// lastModified returns when what the body holds last changed, or zero.
+	lastModified() time.Time
+}
+
+// Surrogate keys. A list is tagged with its items' keys as well as its own,
+// so changing an item purges the lists it is on.
+func postKey(id string) string     { return "post:" + id }
+func commentKey(id string) string  { return "comment:" + id }
+func commentsKey(id string) string { return "comments:" + id } // everything about the comments on post id
+func userKey(id string) string     { return "user:" + id }     // everything user id wrote
+
+// postsKey tags every list of posts, which any new post may join.
+const postsKey = "posts"
+
+func (p *Post) surrogateKeys() []string {
+	return []string{postKey(p.ID), userKey(p.AuthorID)}
+}
+
+func (p *Post) lastModified() time.Time { return p.UpdatedAt.Time }
+
+func (c *Comment) surrogateKeys() []string {
+	// The whole post's comments, as the comment's reply count changes with
+	// the replies written to it.
+	return []string{commentKey(c.ID), commentsKey(c.PostID), userKey(c.AuthorID)}
+}
+
+func (c *Comment) lastModified() time.Time { return c.UpdatedAt.Time }
+
+func (l *PostsListResponse) surrogateKeys() []string {
+	return listKeys([]string{postsKey}, l.Posts)
+}
+
+func (l *PostsListResponse) lastModified() time.Time { return listLastModified(l.Posts) }
+
+func (l *CommentsListResponse) surrogateKeys() []string {
+	return listKeys(nil, l.Comments)
+}
+
+func (l *CommentsListResponse) lastModified() time.Time { return listLastModified(l.Comments) }
+
+func listKeys[T surrogated](keys []string, items []T) []string {
+	for _, item := range items {
+		for _, key := range item.surrogateKeys() {
+			if !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+func listLastModified[T surrogated](items []T) time.Time {
+	var last time.Time
+	for _, item := range items {
+		if t := item.lastModified(); t.After(last) {
+			last = t
+		}
+	}
+	return last
+}
+
+// surrogateKeys is a huma transformer setting the Surrogate-Key and
+// Last-Modified of the successful responses of operations with a Tagged
+// CachePolicy. It runs before the HAL transformer wraps the body.
+func surrogateKeys(ctx huma.Context, status string, v any) (any, error) {
+	policy, _ := ctx.Operation().Metadata[cachePolicyKey].(CachePolicy)
+	body, ok := v.(surrogated)
+	if !policy.Tagged || !ok || !strings.HasPrefix(status, "2") {
+		return v, nil
+	}
+	if keys := body.surrogateKeys(); len(keys) > 0 {
+		ctx.SetHeader(surrogateKeyHeader, strings.Join(keys, " "))
+	}
+	if t := body.lastModified(); !t.IsZero() {
+		ctx.SetHeader("Last-Modified", t.UTC().Format(http.TimeFormat))
+	}
+	return v, nil
+}
+
+// purgeKeys returns the surrogate keys event makes stale.
+func purgeKeys(e events.Event) []string {
+	switch e.Type {
+	case "post.created":
+		return []string{postsKey}
+	case "post.updated":
+		return []string{postKey(eventField(e, "post_id"))}
+	case "post.deleted":
+		id := eventField(e, "post_id")
+		return []string{postKey(id), commentsKey(id)}
+	case "comment.created", "comment.updated", "comment.moderated", "comment.deleted":
+		return []string{commentsKey(eventField(e, "post_id"))}
+	case "user.deleted", "user.purged":
+		return []string{userKey(e.Subject)}
+	}
+	return nil
+}
+
+// CachePurger invalidates what a CDN or reverse proxy in front of the API
+// keeps under surrogate keys. Purge is called from one goroutine at a time.
+type CachePurger interface {
+	Purge(ctx context.Context, keys []string) error
+}
+
+// NewCachePurger returns a purger sending the keys to rawURL, with token,
+// if set, as a bearer token.
+func NewCachePurger(rawURL, token string) (CachePurger, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("want an http(s) URL, got %q", rawURL)
+	}
+	return &httpPurger{url: rawURL, token: token, client: &http.Client{Timeout: 5 * time.Second}}, nil
+}
+
+// httpPurger POSTs the keys to purge in a Surrogate-Key header, to a
+// Varnish configured to purge by it with the xkey module, or to a relay to
+// the CDN's purge API.
+type httpPurger struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (h *httpPurger) Purge(ctx context.Context, keys []string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(surrogateKeyHeader, strings.Join(keys, " "))
+	if h.token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.token)
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("purge returned %s", resp.Status)
+	}
+	return nil
+}
+
+// cachePurgeQueueSize bounds the purges waiting to be sent; beyond it they
+// are dropped, and the cached responses expire on their own.
+const cachePurgeQueueSize = 1000
+
+// cachePurges sends the purges the events published on the bus call for
+// to a CachePurger, in the background so a slow CDN holds up no request.
+// A purge that fails goes to the dead-letter queue.
+type cachePurges struct {
+	purger CachePurger
+	logger *slog.Logger
+	queue  chan purge
+	dead   *DeadLetters
+
+	ctx    context.Context // ends when close is called
+	cancel context.CancelFunc
+	done   chan struct{} // closed when run returns
+}
+
+// purge is a queued purge of keys.
+type purge struct {
+	keys       []string
+	deadLetter string // the dead letter it was requeued from, if any
+}
+
+func newCachePurges(purger CachePurger, bus *events.Bus, dead *DeadLetters, logger *slog.Logger) *cachePurges {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &cachePurges{purger: purger, logger: logger, queue: make(chan purge, cachePurgeQueueSize), dead: dead, ctx: ctx, cancel: cancel, done: make(chan struct{})}
+	bus.Subscribe(func(e events.Event) {
+		keys := purgeKeys(e)
+		if len(keys) == 0 {
+			return
+		}
+		select {
+		case p.queue <- purge{keys: keys}:
+		default:
+			logger.Warn("cache purge queue is full, purge dropped", "keys", strings.Join(keys, " "))
+		}
+	})
+	dead.handle(DeadLetterCachePurge, func(ctx context.Context, id string, payload any) error {
+		return p.enqueue(ctx, purge{keys: payload.([]string), deadLetter: id})
+	})
+	return p
+}
+
+// replay queues keys to be purged again, waiting for room in the queue
+// until ctx ends.
+func (p *cachePurges) replay(ctx context.Context, keys []string) error {
+	return p.enqueue(ctx, purge{keys: keys})
+}
+
+func (p *cachePurges) enqueue(ctx context.Context, pg purge) error {
+	select {
+	case p.queue <- pg:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run sends the queued purges until close is called. A purge under way
+// when it is is finished, within the purger's own timeout.
+func (p *cachePurges) run() {
+	defer close(p.done)
+	for {
+		select {
+		case pg := <-p.queue:
+			p.purge(context.Background(), pg)
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// close stops run and sends the purges still queued, until ctx ends.
+func (p *cachePurges) close(ctx context.Context) {
+	p.cancel()
+	<-p.done
+	for {
+		select {
+		case pg := <-p.queue:
+			p.purge(ctx, pg)
+		default:
+			return
+		}
+	}
+}
+
+func (p *cachePurges) purge(ctx context.Context, pg purge) {
+	if err := p.purger.Purge(ctx, pg.keys); err != nil {
+		id := p.dead.fail(DeadLetterCachePurge, pg.deadLetter, pg.keys, []DeadLetterAttempt{{Time: timestamp.Now(), Error: err.Error()}})
+		p.logger.Warn("failed to purge cached responses", "keys", strings.Join(pg.keys, " "), "err", err, "dead_letter", id)
+		return
+	}
+	p.dead.delivered(pg.deadLetter)
+}
+
+// documentSurrogateKeys adds Surrogate-Key and Last-Modified to the spec of
+// the successful responses of operations with a Tagged CachePolicy.
+func documentSurrogateKeys(spec *huma.OpenAPI) {
+	for _, item := range spec.Paths {
+		for _, op := range []*huma.Operation{item.Get, item.Put, item.Post, item.Delete, item.Patch} {
+			if op == nil {
+				continue
+			}
+			if policy, _ := op.Metadata[cachePolicyKey].(CachePolicy); !policy.Tagged {
+				continue
+			}
+			for key, resp := range op.Responses {
+				if code, _ := strconv.Atoi(key); code < 200 || code > 299 {
+					continue
+				}
+				if resp.Headers == nil {
+					resp.Headers = map[string]*huma.Param{}
+				}
+				resp.Headers[surrogateKeyHeader] = &huma.Param{
+					Description: "Space-separated keys naming what the response holds, for a CDN to purge it by when that changes",
+					Schema:      &huma.Schema{Type: "string"},
+					Example:     "post:20240101120500 user:20240101120000",
+				}
+				resp.Headers["Last-Modified"] = &huma.Param{
+					Description: "When what the response holds last changed",
+					Schema:      &huma.Schema{Type: "string"},
+					Example:     "Mon, 01 Jan 2024 12:05:00 GMT",
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+)
+
+func TestCachePurges(t *testing.T) {
+	var mu sync.Mutex
+	var purged []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer tok" {
+			t.Errorf("purge sent as %s with %q", r.Method, r.Header.Get("Authorization"))
+		}
+		mu.Lock()
+		purged = append(purged, r.Header.Get("Surrogate-Key"))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+	purger, err := NewCachePurger(srv.URL, "tok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	store := NewMemoryStore()
+	if err := store.PutUser(ctx, &User{ID: "u1", Name: "Ada", Email: "ada@example.com", Status: UserStatusActive}); err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(Config{CachePurger: purger}, store)
+	go s.purges.run()
+	post, err := s.posts.Create(ctx, CreatePostRequest{AuthorID: "u1", Title: "Hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	title := "Hello again"
+	if _, err := s.posts.Update(ctx, post.ID, UpdatePostRequest{Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.comments.Create(ctx, post.ID, CreateCommentRequest{AuthorID: "u1", Body: "First"}); err != nil {
+		t.Fatal(err)
+	}
+	s.bus.Publish(ctx, events.Event{Type: "user.updated", Subject: "u1"})
+	s.purges.close(ctx)
+
+	want := []string{"posts", "post:" + post.ID, "comments:" + post.ID}
+	if !slices.Equal(purged, want) {
+		t.Errorf("purged %q, want %q", purged, want)
+	}
+	if _, err := NewCachePurger("varnish:6081", ""); err == nil {
+		t.Error("NewCachePurger accepted a URL without an http scheme")
+	}
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+const maxUserTags = 20
+
+// tagPattern allows short lowercase labels such as "beta" or "early-access".
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+var errInvalidTag = errors.New("expected up to 32 lowercase letters, digits, dashes or underscores")
+
+type UserTags struct {
+	Tags []string `json:"tags" maxItems:"20" doc:"Labels for the user. Tags are lowercased, deduplicated and sorted." example:"[\"beta\"]"`
+}
+
+type UserTagsInput struct {
+	ID   string `path:"id" doc:"User ID"`
+	Body UserTags
+}
+
+type UserTagsOutput struct {
+	Body *UserTags
+}
+
+// Resolve normalizes the tags so "Beta" and "beta " are stored as one tag.
+func (t *UserTags) Resolve(ctx huma.Context, prefix *huma.PathBuffer) []error {
+	var errs []error
+	tags := make([]string, 0, len(t.Tags))
+	prefix.Push("tags")
+	defer prefix.Pop()
+	for i, raw := range t.Tags {
+		tag := strings.ToLower(strings.TrimSpace(raw))
+		if !tagPattern.MatchString(tag) {
+			errs = append(errs, &ErrorDetail{Location: prefix.WithIndex(i), Code: "pattern", Message: errInvalidTag.Error(), Value: raw})
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	slices.Sort(tags)
+	t.Tags = slices.Compact(tags)
+	return errs
+}
+
+// hasTags reports whether u carries every tag in want.
+func hasTags(u *User, want []string) bool {
+	for _, tag := range want {
+		if !slices.Contains(u.Tags, strings.ToLower(tag)) {
+			return false
+		}
+	}
+	return true
+}
+
+// tagCounts counts how many of users carry each tag, for facets in the admin
+// UI.
+func tagCounts(users []*User) map[string]int {
+	counts := map[string]int{}
+	for _, u := range users {
+		for _, tag := range u.Tags {
+			counts[tag]++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,213 @@
+package server
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/buildinfo"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// defaultTelemetryInterval is how often usage is reported when
+// Config.TelemetryInterval is zero.
+const defaultTelemetryInterval = 24 * time.Hour
+
+// TelemetryReport is what a usage report holds: aggregate counts and what
+// the server runs on, and nothing about its users, data, addresses or
+// configuration.
+type TelemetryReport struct {
+	InstanceID    string           `json:"instance_id" example:"9f2c4e1a7b3d5f60" doc:"Random ID of this process, new on every start, so reports can be told apart without saying whose they are"`
+	Version       string           `json:"version" example:"v1.2.0" doc:"The server's version"`
+	GoVersion     string           `json:"go_version" example:"go1.24.1" doc:"Go version the server was built with"`
+	Platform      string           `json:"platform" example:"linux/amd64" doc:"Operating system and architecture"`
+	Store         string           `json:"store" enum:"memory,raft,custom" doc:"The store backend: memory, raft for the clustered mode, or custom for one an embedding program provides"`
+	Since         timestamp.Time   `json:"since" doc:"Start of the period the counts cover: the last report sent, or the server's start"`
+	UptimeSeconds int64            `json:"uptime_seconds" example:"86400" doc:"How long the server has been running"`
+	Requests      map[string]int64 `json:"requests" doc:"Requests served in the period, by operation ID"`
+}
+
+// telemetry counts the requests to each operation and, when Config.Telemetry
+// is set, reports them to Config.TelemetryURL every interval, starting a new
+// period each time a report is accepted.
+type telemetry struct {
+	enabled  bool
+	url      string
+	interval time.Duration
+	instance string
+	store    string
+	started  time.Time
+	client   *http.Client
+	logger   *slog.Logger
+
+	mu        sync.Mutex
+	since     time.Time
+	requests  map[string]int64
+	lastSent  time.Time
+	lastError string
+}
+
+func newTelemetry(cfg Config, store Store, logger *slog.Logger) *telemetry {
+	id := make([]byte, 8)
+	rand.Read(id)
+	now := time.Now()
+	return &telemetry{
+		enabled:  cfg.Telemetry,
+		url:      cfg.TelemetryURL,
+		interval: cmp.Or(cfg.TelemetryInterval, defaultTelemetryInterval),
+		instance: hex.EncodeToString(id),
+		store:    storeBackend(store),
+		started:  now,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		logger:   logger,
+		since:    now,
+		requests: map[string]int64{},
+	}
+}
+
+// storeBackend names the kind of store, without anything about where it
+// keeps its data.
+func storeBackend(store Store) string {
+	switch store.(type) {
+	case *MemoryStore:
+		return "memory"
+	case *RaftStore:
+		return "raft"
+	}
+	return "custom"
+}
+
+// countUsage is a huma middleware counting the requests to each operation
+// for s.telemetry. It counts whether or not reports are sent, so
+// get-admin-telemetry shows what the first one would hold.
+func (s *Server) countUsage(ctx huma.Context, next func(huma.Context)) {
+	s.telemetry.mu.Lock()
+	s.telemetry.requests[ctx.Operation().OperationID]++
+	s.telemetry.mu.Unlock()
+	next(ctx)
+}
+
+// report returns the report that would be sent now.
+func (t *telemetry) report(now time.Time) TelemetryReport {
+	info := buildinfo.Get()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return TelemetryReport{
+		InstanceID:    t.instance,
+		Version:       info.Version,
+		GoVersion:     info.GoVersion,
+		Platform:      runtime.GOOS + "/" + runtime.GOARCH,
+		Store:         t.store,
+		Since:         timestamp.From(t.since),
+		UptimeSeconds: int64(now.Sub(t.started).Seconds()),
+		Requests:      maps.Clone(t.requests),
+	}
+}
+
+// send posts the report and, if it was accepted, starts a new period with
+// what was counted since the report was taken.
+func (t *telemetry) send(ctx context.Context, now time.Time) error {
+	r := t.report(now)
+	err := t.post(ctx, r)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err != nil {
+		t.lastError = err.Error()
+		return err
+	}
+	for op, n := range r.Requests {
+		if t.requests[op] -= n; t.requests[op] == 0 {
+			delete(t.requests, op)
+		}
+	}
+	t.since, t.lastSent, t.lastError = now, now, ""
+	return nil
+}
+
+func (t *telemetry) post(ctx context.Context, r TelemetryReport) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("telemetry endpoint returned %s", resp.Status)
+	}
+	return nil
+}
+
+// telemetryLoop sends a report every interval until ctx ends. A report that
+// fails is logged, and its counts go into the next one.
+func (s *Server) telemetryLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.telemetry.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := s.telemetry.send(ctx, now); err != nil {
+				s.logger.Warn("failed to send the usage report", "err", err)
+			}
+		}
+	}
+}
+
+type TelemetryInput struct {
+	AdminInput
+}
+
+type TelemetryStatus struct {
+	Enabled         bool            `json:"enabled" doc:"Whether reports are sent; only with TELEMETRY=true"`
+	URL             string          `json:"url,omitempty" example:"https://telemetry.example.com/v1/reports" doc:"Where reports are sent"`
+	IntervalSeconds int64           `json:"interval_seconds" example:"86400" doc:"How often reports are sent"`
+	LastSent        *timestamp.Time `json:"last_sent,omitempty" doc:"When the last report was accepted"`
+	LastError       string          `json:"last_error,omitempty" doc:"Why the last report failed, if it did"`
+	Report          TelemetryReport `json:"report" doc:"Exactly what the next report would hold, were it sent now"`
+}
+
+type TelemetryOutput struct {
+	Body *TelemetryStatus
+}
+
+// getTelemetry is the get-admin-telemetry handler.
+func (s *Server) getTelemetry(ctx context.Context, input *TelemetryInput) (*TelemetryOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	t := s.telemetry
+	out := &TelemetryStatus{Enabled: t.enabled, IntervalSeconds: int64(t.interval.Seconds()), Report: t.report(time.Now())}
+	if t.enabled {
+		out.URL = t.url
+	}
+	t.mu.Lock()
+	if !t.lastSent.IsZero() {
+		at := timestamp.From(t.lastSent)
+		out.LastSent = &at
+	}
+	out.LastError = t.lastError
+	t.mu.Unlock()
+	return &TelemetryOutput{Body: out}, nil
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTelemetry(t *testing.T) {
+	var fail atomic.Bool
+	got := make(chan TelemetryReport, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var report TelemetryReport
+		json.NewDecoder(r.Body).Decode(&report)
+		got <- report
+		if fail.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+	s := NewServer(Config{Telemetry: true, TelemetryURL: srv.URL}, NewMemoryStore())
+	for range 2 {
+		s.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/users", nil))
+	}
+
+	// A report that fails is kept for the next one.
+	fail.Store(true)
+	if err := s.telemetry.send(context.Background(), time.Now()); err == nil {
+		t.Error("report refused with a 502 sent without an error")
+	}
+	<-got
+	fail.Store(false)
+	s.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/users", nil))
+	if err := s.telemetry.send(context.Background(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	report := <-got
+	if report.Store != "memory" || report.Version == "" || len(report.InstanceID) != 16 || !maps.Equal(report.Requests, map[string]int64{"get-v1-users": 3}) {
+		t.Errorf("report %+v, want three get-v1-users on the memory store", report)
+	}
+	if r := s.telemetry.report(time.Now()); len(r.Requests) != 0 {
+		t.Errorf("counts %v left after the report was sent, want none", r.Requests)
+	}
+}
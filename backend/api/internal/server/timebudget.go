@@ -0,0 +1,35 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"time"
+)
+
+// TimeBudgetInput lets a client of a list or search bound how long it
+// waits: once the budget is spent, the server stops looking and answers
+// with the results it has found, marked partial, instead of leaving the
+// client to time out with nothing.
+type TimeBudgetInput struct {
+	TimeBudget      int `header:"X-Time-Budget" minimum:"1" maximum:"60000" example:"250" doc:"Milliseconds the request may take, counted from when the server got it. Once they are spent, the response has the results found so far, with partial set to true"`
+	TimeBudgetQuery int `query:"time_budget" minimum:"1" maximum:"60000" example:"250" doc:"X-Time-Budget, for clients that can't set headers; the header wins if both are set"`
+}
+
+// deadline is when the budget of the request ctx belongs to runs out, or
+// the zero time if it has none.
+func (in *TimeBudgetInput) deadline(ctx context.Context) time.Time {
+	ms := cmp.Or(in.TimeBudget, in.TimeBudgetQuery)
+	if ms == 0 {
+		return time.Time{}
+	}
+	start := time.Now()
+	if t := timingFrom(ctx); t != nil {
+		start = t.start
+	}
+	return start.Add(time.Duration(ms) * time.Millisecond)
+}
+
+// spent reports whether deadline, unless zero, has passed.
+func spent(deadline time.Time) bool {
+	return !deadline.IsZero() && !time.Now().Before(deadline)
+}
@@ -0,0 +1,245 @@
+package server
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/go-chi/chi/v5"
+)
+
+// safeParams are the query and path parameters whose values slow request
+// logs show as is. Every other value is redacted, since queries and paths
+// can carry emails, phone numbers and usernames.
+var safeParams = map[string]bool{
+	"id":               true,
+	"page":             true,
+	"per_page":         true,
+	"limit":            true,
+	"include_inactive": true,
+	"inactive_since":   true,
+	"tag":              true,
+	"include":          true,
+	"author_id":        true,
+	"parent_id":        true,
+	"include_hidden":   true,
+	"unread":           true,
+	"type":             true,
+	"locale":           true,
+	"tz":               true,
+}
+
+// requestTiming accumulates where one request spent its time. The handler
+// span covers huma's decoding, validation and the handler function; store
+// time is counted inside it.
+type requestTiming struct {
+	start   time.Time    // when the server got the request
+	handler atomic.Int64 // nanoseconds
+	store   atomic.Int64 // nanoseconds
+	// waited is how long a long poll waited on purpose, which doesn't
+	// count towards the threshold.
+	waited atomic.Int64 // nanoseconds
+}
+
+type requestTimingKey struct{}
+
+func timingFrom(ctx context.Context) *requestTiming {
+	t, _ := ctx.Value(requestTimingKey{}).(*requestTiming)
+	return t
+}
+
+// logSlowRequests times every request and, for those slower than the
+// threshold, logs a warning with the route, redacted parameters and how the
+// time split between middleware, handler and store, and counts them per
+// route. A threshold of zero turns it off.
+func (s *Server) logSlowRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		timing := &requestTiming{start: start}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestTimingKey{}, timing)))
+
+		threshold := time.Duration(s.slowThreshold.Load())
+		total := time.Since(start)
+		if threshold <= 0 || total-time.Duration(timing.waited.Load()) < threshold {
+			return
+		}
+		route := routePattern(r)
+		handler := time.Duration(timing.handler.Load())
+		store := time.Duration(timing.store.Load())
+		s.metrics.slowRequest(methodLabel(r.Method), route)
+		s.logger.WarnContext(r.Context(), "slow request",
+			"method", r.Method,
+			"route", route,
+			"ip", remoteHost(r.RemoteAddr),
+			"params", requestParams(r),
+			"total", total,
+			"middleware", total-handler,
+			"handler", handler-store,
+			"store", store,
+		)
+	})
+}
+
+// timeHandler is a huma middleware adding the operation's own time to the
+// request's timing.
+func timeHandler(ctx huma.Context, next func(huma.Context)) {
+	start := time.Now()
+	next(ctx)
+	if t := timingFrom(ctx.Context()); t != nil {
+		t.handler.Add(int64(time.Since(start)))
+	}
+}
+
+// requestParams is redactParams for r's route and query parameters.
+func requestParams(r *http.Request) string {
+	var params *chi.RouteParams
+	if rc := chi.RouteContext(r.Context()); rc != nil {
+		params = &rc.URLParams
+	}
+	return redactParams(params, r.URL.Query())
+}
+
+// redactParams renders path and query parameters as name=value pairs with
+// the values of anything not in safeParams replaced.
+func redactParams(path *chi.RouteParams, query url.Values) string {
+	var parts []string
+	show := func(k, v string) {
+		if !safeParams[k] {
+			v = "REDACTED"
+		}
+		parts = append(parts, k+"="+v)
+	}
+	if path != nil {
+		for i, k := range path.Keys {
+			show(k, path.Values[i])
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(query)) {
+		for _, v := range query[k] {
+			show(k, v)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// timedStore adds the time spent in each call to the request's timing.
+type timedStore struct {
+	Store
+}
+
+func (t timedStore) track(ctx context.Context, start time.Time) {
+	if timing := timingFrom(ctx); timing != nil {
+		timing.store.Add(int64(time.Since(start)))
+	}
+}
+
+// withTx times the transaction's calls like any others.
+func (t timedStore) withTx(ctx context.Context, fn func(tx Store) error) error {
+	return WithTx(ctx, t.Store, func(tx Store) error { return fn(timedStore{tx}) })
+}
+
+func (t timedStore) GetUser(ctx context.Context, id string) (*User, error) {
+	defer t.track(ctx, time.Now())
+	return t.Store.GetUser(ctx, id)
+}
+
+func (t timedStore) ListUsers(ctx context.Context) ([]*User, error) {
+	defer t.track(ctx, time.Now())
+	return t.Store.ListUsers(ctx)
+}
+
+func (t timedStore) PutUser(ctx context.Context, user *User) error {
+	defer t.track(ctx, time.Now())
+	return t.Store.PutUser(ctx, user)
+}
+
+func (t timedStore) DeleteUser(ctx context.Context, id string) error {
+	defer t.track(ctx, time.Now())
+	return t.Store.DeleteUser(ctx, id)
+}
+
+func (t timedStore) GetPreferences(ctx context.Context, userID string) (*UserPreferences, error) {
+	defer t.track(ctx, time.Now())
+	return t.Store.GetPreferences(ctx, userID)
+}
+
+func (t timedStore) PutPreferences(ctx context.Context, userID string, prefs *UserPreferences) error {
+	defer t.track(ctx, time.Now())
+	return t.Store.PutPreferences(ctx, userID, prefs)
+}
+
+func (t timedStore) GetCredentials(ctx context.Context, userID string) (*Credentials, error) {
+	defer t.track(ctx, time.Now())
+	return t.Store.GetCredentials(ctx, userID)
+}
+
+func (t timedStore) PutCredentials(ctx context.Context, userID string, creds *Credentials) error {
+	defer t.track(ctx, time.Now())
+	return t.Store.PutCredentials(ctx, userID, creds)
+}
+
+func (t timedStore) GetPost(ctx context.Context, id string) (*Post, error) {
+	defer t.track(ctx, time.Now())
+	return t.Store.GetPost(ctx, id)
+}
+
+func (t timedStore) ListPosts(ctx context.Context) ([]*Post, error) {
+	defer t.track(ctx, time.Now())
+	return t.Store.ListPosts(ctx)
+}
+
+func (t timedStore) PutPost(ctx context.Context, post *Post) error {
+	defer t.track(ctx, time.Now())
+	return t.Store.PutPost(ctx, post)
+}
+
+func (t timedStore) DeletePost(ctx context.Context, id string) error {
+	defer t.track(ctx, time.Now())
+	return t.Store.DeletePost(ctx, id)
+}
+
+func (t timedStore) GetComment(ctx context.Context, id string) (*Comment, error) {
+	defer t.track(ctx, time.Now())
+	return t.Store.GetComment(ctx, id)
+}
+
+func (t timedStore) ListComments(ctx context.Context, postIDs ...string) ([]*Comment, error) {
+	defer t.track(ctx, time.Now())
+	return t.Store.ListComments(ctx, postIDs...)
+}
+
+func (t timedStore) PutComment(ctx context.Context, comment *Comment) error {
+	defer t.track(ctx, time.Now())
+	return t.Store.PutComment(ctx, comment)
+}
+
+func (t timedStore) DeleteComment(ctx context.Context, id string) error {
+	defer t.track(ctx, time.Now())
+	return t.Store.DeleteComment(ctx, id)
+}
+
+func (t timedStore) GetInvitation(ctx context.Context, id string) (*StoredInvitation, error) {
+	defer t.track(ctx, time.Now())
+	return t.Store.GetInvitation(ctx, id)
+}
+
+func (t timedStore) ListInvitations(ctx context.Context) ([]*StoredInvitation, error) {
+	defer t.track(ctx, time.Now())
+	return t.Store.ListInvitations(ctx)
+}
+
+func (t timedStore) PutInvitation(ctx context.Context, inv *StoredInvitation) error {
+	defer t.track(ctx, time.Now())
+	return t.Store.PutInvitation(ctx, inv)
+}
+
+func (t timedStore) DeleteInvitation(ctx context.Context, id string) error {
+	defer t.track(ctx, time.Now())
+	return t.Store.DeleteInvitation(ctx, id)
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlowRequests(t *testing.T) {
+	m := NewMemoryStore()
+	m.PutUser(context.Background(), &User{ID: "a", Name: "Ada", Status: UserStatusActive, Active: true})
+	s := NewServer(Config{SlowRequestThreshold: time.Nanosecond}, m)
+	var logs bytes.Buffer
+	s.logger = slog.New(slog.NewTextHandler(&logs, nil))
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	// Over the threshold, a request is logged with its route, its
+	// parameters redacted, and where the time went.
+	if w := get("/v1/users/a?q=kim@example.com"); w.Code != http.StatusOK {
+		t.Fatalf("GET /v1/users/a answered %d", w.Code)
+	}
+	for _, want := range []string{`msg="slow request"`, "route=/v1/users/{id}", `params="id=a q=REDACTED"`, "middleware=", "handler=", "store="} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("logs %s, want %s", logs.String(), want)
+		}
+	}
+	if strings.Contains(logs.String(), "kim@example.com") {
+		t.Errorf("logs %s, want the email redacted", logs.String())
+	}
+	if w := get("/metrics"); !strings.Contains(w.Body.String(), `http_slow_requests_total{method="GET",route="/v1/users/{id}"} 1`) {
+		t.Errorf("slow request not counted:\n%s", w.Body)
+	}
+
+	// Under it, it isn't.
+	logs.Reset()
+	s.slowThreshold.Store(int64(time.Hour))
+	get("/v1/users/a")
+	if strings.Contains(logs.String(), "slow request") {
+		t.Errorf("logs %s, want no slow request", logs.String())
+	}
+}
@@ -0,0 +1,321 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/captcha"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// Security events about abusive traffic.
+const (
+	EventClientBanned     = "security.client_banned"
+	EventClientChallenged = "security.client_challenged"
+	EventClientCleared    = "security.client_cleared"
+)
+
+// What a TrafficAnalyzer can have done about a client.
+const (
+	// TrafficBan refuses the client's requests with 403 CLIENT_BANNED.
+	TrafficBan = "ban"
+	// TrafficCaptcha refuses the client's requests until one comes with a
+	// solved CAPTCHA in X-Captcha-Token. Without a CAPTCHA provider it is
+	// a ban.
+	TrafficCaptcha = "captcha"
+)
+
+// defaultTrafficBan is how long a verdict lasts without a duration.
+const defaultTrafficBan = 10 * time.Minute
+
+// TrafficSample is a finished request, as a TrafficAnalyzer sees it.
+type TrafficSample struct {
+	// ClientIP is the client's address, per TRUSTED_PROXIES.
+	ClientIP string
+	Method   string
+	// Route is the pattern the request matched, like /v1/users/{id}, or
+	// its path if it matched none.
+	Route    string
+	Status   int
+	Duration time.Duration
+	Time     time.Time
+}
+
+// TrafficVerdict is what a TrafficAnalyzer decides to do about a client.
+// The zero value leaves it be.
+type TrafficVerdict struct {
+	// Action is TrafficBan, TrafficCaptcha, or empty for nothing.
+	Action string
+	// For is how long the action lasts, 10 minutes if zero.
+	For time.Duration
+	// Reason says why, for the security event and the admin API.
+	Reason string
+}
+
+// TrafficAnalyzer watches each client's requests for abuse, and decides
+// when to ban a client for a while or put it to a CAPTCHA. Observe is
+// called after every request but those of banned or challenged clients,
+// the admin API's and probes', on the request's goroutine, so it must be
+// quick and safe for concurrent use. Implement it to plug in other
+// detection, e.g. one asking an outside service in the background;
+// RateAnalyzer is the one ConfigFromEnv sets up.
+type TrafficAnalyzer interface {
+	Observe(ctx context.Context, sample TrafficSample) TrafficVerdict
+}
+
+// TrafficPolicy is what RateAnalyzer counts as abuse. A zero limit is not
+// checked.
+type TrafficPolicy struct {
+	// MaxPerMinute is how many requests a client may make in a minute.
+	MaxPerMinute int
+	// MaxErrorRate is the share of a client's requests in a minute, 0 to 1,
+	// that may fail with a 4xx. It is only checked from MinRequests on, 20
+	// if zero, so a few typos don't get anyone banned.
+	MaxErrorRate float64
+	MinRequests  int
+	// Action is TrafficBan, the default, or TrafficCaptcha, and For how
+	// long it lasts.
+	Action string
+	For    time.Duration
+}
+
+// RateAnalyzer is a TrafficAnalyzer that counts each client's requests,
+// and those failing with a 4xx, per minute, and acts on a client that goes
+// over its policy's limits. Like LoginGuard it keeps its counts in memory,
+// per replica.
+type RateAnalyzer struct {
+	policy TrafficPolicy
+
+	mu      sync.Mutex
+	windows map[string]*trafficWindow
+	swept   time.Time
+}
+
+type trafficWindow struct {
+	start            time.Time
+	requests, errors int
+}
+
+func NewRateAnalyzer(policy TrafficPolicy) *RateAnalyzer {
+	if policy.MinRequests <= 0 {
+		policy.MinRequests = 20
+	}
+	if policy.Action == "" {
+		policy.Action = TrafficBan
+	}
+	return &RateAnalyzer{policy: policy, windows: map[string]*trafficWindow{}}
+}
+
+func (a *RateAnalyzer) Observe(_ context.Context, sample TrafficSample) TrafficVerdict {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := sample.Time
+	if now.Sub(a.swept) >= time.Minute {
+		a.swept = now
+		for ip, w := range a.windows {
+			if now.Sub(w.start) >= time.Minute {
+				delete(a.windows, ip)
+			}
+		}
+	}
+	w := a.windows[sample.ClientIP]
+	if w == nil || now.Sub(w.start) >= time.Minute {
+		w = &trafficWindow{start: now}
+		a.windows[sample.ClientIP] = w
+	}
+	w.requests++
+	if sample.Status >= 400 && sample.Status < 500 {
+		w.errors++
+	}
+	reason := ""
+	switch p := a.policy; {
+	case p.MaxPerMinute > 0 && w.requests > p.MaxPerMinute:
+		reason = "more than " + strconv.Itoa(p.MaxPerMinute) + " requests a minute"
+	case p.MaxErrorRate > 0 && w.requests >= p.MinRequests && float64(w.errors)/float64(w.requests) > p.MaxErrorRate:
+		reason = strconv.Itoa(w.errors) + " of " + strconv.Itoa(w.requests) + " requests in a minute failed"
+	default:
+		return TrafficVerdict{}
+	}
+	// The client starts over once the verdict runs out.
+	delete(a.windows, sample.ClientIP)
+	return TrafficVerdict{Action: a.policy.Action, For: a.policy.For, Reason: reason}
+}
+
+// TrafficBlock is a verdict in force against a client.
+type TrafficBlock struct {
+	ClientIP string         `json:"client_ip" example:"203.0.113.9" doc:"The client's address"`
+	Action   string         `json:"action" enum:"ban,captcha" doc:"ban refuses its requests; captcha refuses them until one comes with a solved CAPTCHA"`
+	Reason   string         `json:"reason" example:"more than 600 requests a minute" doc:"Why the traffic analyzer acted"`
+	Since    timestamp.Time `json:"since" doc:"When it started"`
+	Until    timestamp.Time `json:"until" doc:"When it ends"`
+}
+
+// trafficBlocks holds the verdicts in force, by client address, in memory.
+type trafficBlocks struct {
+	mu     sync.Mutex
+	blocks map[string]TrafficBlock
+}
+
+// get returns the verdict in force against ip at now, if any.
+func (b *trafficBlocks) get(ip string, now time.Time) (TrafficBlock, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	block, ok := b.blocks[ip]
+	if ok && !now.Before(block.Until.Time) {
+		delete(b.blocks, ip)
+		return TrafficBlock{}, false
+	}
+	return block, ok
+}
+
+func (b *trafficBlocks) set(block TrafficBlock) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.blocks[block.ClientIP] = block
+}
+
+// lift ends the verdict against ip, reporting whether there was one.
+func (b *trafficBlocks) lift(ip string, now time.Time) bool {
+	_, ok := b.get(ip, now)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.blocks, ip)
+	return ok
+}
+
+// list returns the verdicts in force at now, by address.
+func (b *trafficBlocks) list(now time.Time) []TrafficBlock {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := []TrafficBlock{}
+	for ip, block := range b.blocks {
+		if !now.Before(block.Until.Time) {
+			delete(b.blocks, ip)
+			continue
+		}
+		out = append(out, block)
+	}
+	slices.SortFunc(out, func(a, b TrafficBlock) int { return strings.Compare(a.ClientIP, b.ClientIP) })
+	return out
+}
+
+// analyzeTraffic refuses the requests of clients the traffic analyzer has
+// banned or put to a CAPTCHA, and shows it every other request once it is
+// done. The admin API and probes are neither analyzed nor refused.
+func (s *Server) analyzeTraffic(next http.Handler) http.Handler {
+	if s.traffic == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if adminPath(r.URL.Path) || probePath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ip := remoteHost(r.RemoteAddr)
+		start := time.Now()
+		if block, ok := s.trafficBlocks.get(ip, start); ok && !s.passChallenge(w, r, block, start) {
+			return
+		}
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		verdict := s.traffic.Observe(r.Context(), TrafficSample{
+			ClientIP: ip,
+			Method:   r.Method,
+			Route:    routePattern(r),
+			Status:   sw.status,
+			Duration: time.Since(start),
+			Time:     start,
+		})
+		if verdict.Action != TrafficBan && verdict.Action != TrafficCaptcha {
+			return
+		}
+		if verdict.Action == TrafficCaptcha && s.cfg.Captcha == nil {
+			verdict.Action = TrafficBan
+		}
+		block := TrafficBlock{ClientIP: ip, Action: verdict.Action, Reason: verdict.Reason, Since: timestamp.From(start), Until: timestamp.From(start.Add(cmp.Or(verdict.For, defaultTrafficBan)))}
+		s.trafficBlocks.set(block)
+		typ := EventClientBanned
+		if block.Action == TrafficCaptcha {
+			typ = EventClientChallenged
+		}
+		s.logger.WarnContext(r.Context(), "traffic analyzer acted on a client", "ip", ip, "action", block.Action, "reason", block.Reason, "until", block.Until)
+		s.bus.Publish(r.Context(), events.Event{Type: typ, Data: map[string]any{"ip": ip, "reason": block.Reason, "until": block.Until}})
+	})
+}
+
+// passChallenge answers a request from a client block is in force against
+// and reports whether it may go on after all: a client put to a CAPTCHA
+// may, with a solved one in X-Captcha-Token, which also lifts the block. If
+// the CAPTCHA provider can't be asked, clients are let through rather than
+// all being kept out while it is down.
+func (s *Server) passChallenge(w http.ResponseWriter, r *http.Request, block TrafficBlock, now time.Time) bool {
+	wait := block.Until.Sub(now)
+	w.Header().Set("Retry-After", strconv.Itoa(max(int(math.Ceil(wait.Seconds())), 1)))
+	if block.Action != TrafficCaptcha {
+		writeError(w, r, http.StatusForbidden, CodeClientBanned, "your address is temporarily banned")
+		return false
+	}
+	token := r.Header.Get("X-Captcha-Token")
+	err := s.cfg.Captcha.Verify(r.Context(), token, block.ClientIP)
+	switch {
+	case err == nil:
+		s.trafficBlocks.lift(block.ClientIP, now)
+		w.Header().Del("Retry-After")
+		return true
+	case token == "":
+		writeError(w, r, http.StatusForbidden, CodeCaptchaFailed, "captcha token required")
+	case errors.Is(err, captcha.ErrRejected):
+		writeError(w, r, http.StatusForbidden, CodeCaptchaFailed, "captcha verification failed")
+	default:
+		s.logger.WarnContext(r.Context(), "captcha provider unavailable, letting a challenged client through", "err", err)
+		w.Header().Del("Retry-After")
+		return true
+	}
+	return false
+}
+
+type TrafficBlocksInput struct {
+	AdminInput
+}
+
+type TrafficBlocks struct {
+	Blocks []TrafficBlock `json:"blocks" doc:"The clients banned or put to a CAPTCHA, by address"`
+}
+
+type TrafficBlocksOutput struct {
+	Body *TrafficBlocks
+}
+
+type TrafficBlockInput struct {
+	AdminInput
+	ClientIP string `path:"ip" example:"203.0.113.9" doc:"The client's address"`
+}
+
+// listTrafficBlocks is the get-admin-traffic-blocks handler.
+func (s *Server) listTrafficBlocks(ctx context.Context, input *TrafficBlocksInput) (*TrafficBlocksOutput, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	return &TrafficBlocksOutput{Body: &TrafficBlocks{Blocks: s.trafficBlocks.list(time.Now())}}, nil
+}
+
+// liftTrafficBlock is the delete-admin-traffic-blocks-by-ip handler.
+func (s *Server) liftTrafficBlock(ctx context.Context, input *TrafficBlockInput) (*struct{}, error) {
+	if err := s.authorizeAdmin(ctx, input.AdminInput); err != nil {
+		return nil, err
+	}
+	if !s.trafficBlocks.lift(input.ClientIP, time.Now()) {
+		return nil, apiError(http.StatusNotFound, CodeNotFound, "the client is not banned or challenged")
+	}
+	s.bus.Publish(ctx, events.Event{Type: EventClientCleared, Data: map[string]any{"ip": input.ClientIP}})
+	return nil, nil
+}
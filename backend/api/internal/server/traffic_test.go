@@ -0,0 +1,45 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateAnalyzer(t *testing.T) {
+	a := NewRateAnalyzer(TrafficPolicy{MaxPerMinute: 5, MaxErrorRate: 0.5, MinRequests: 4})
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	observe := func(ip string, status int) TrafficVerdict {
+		return a.Observe(context.Background(), TrafficSample{ClientIP: ip, Status: status, Time: now})
+	}
+
+	// Errors only count once there are enough requests to judge by.
+	for i := range 3 {
+		if v := observe("203.0.113.1", http.StatusNotFound); v.Action != "" {
+			t.Fatalf("error %d: %+v", i+1, v)
+		}
+	}
+	if v := observe("203.0.113.1", http.StatusNotFound); v.Action != TrafficBan || v.Reason != "4 of 4 requests in a minute failed" {
+		t.Errorf("fourth error: %+v", v)
+	}
+
+	// Server errors aren't the client's fault; a sixth request is too many.
+	for i := range 5 {
+		if v := observe("203.0.113.2", http.StatusServiceUnavailable); v.Action != "" {
+			t.Fatalf("request %d: %+v", i+1, v)
+		}
+	}
+	if v := observe("203.0.113.2", http.StatusOK); v.Action != TrafficBan {
+		t.Errorf("sixth request in a minute: %+v", v)
+	}
+
+	// A minute later, the count starts over.
+	for range 5 {
+		observe("203.0.113.3", http.StatusOK)
+	}
+	now = now.Add(time.Minute)
+	if v := observe("203.0.113.3", http.StatusOK); v.Action != "" {
+		t.Errorf("a minute later: %+v", v)
+	}
+}
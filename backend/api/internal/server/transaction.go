@@ -0,0 +1,686 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+)
+
+// atomicStore is implemented by stores that can commit several writes at
+// once, all of them or none: MemoryStore in one write-ahead log record and
+// RaftStore in one raft log entry. commit fails with ErrConflict, writing
+// nothing, unless the records it expects are as they were read; see
+// walRecord.Expect.
+type atomicStore interface {
+	Store
+	commit(recs, expect []walRecord) error
+}
+
+// commit applies recs together, logging them as one record first.
+func (m *MemoryStore) commit(recs, expect []walRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec := walRecord{Op: walBatch, Batch: recs, Expect: expect}
+	if err := rec.check(); err != nil {
+		return err
+	}
+	if err := m.conflict(rec); err != nil {
+		return err
+	}
+	// What was checked needn't be replayed.
+	rec.Expect = nil
+	if err := m.logMutation(rec); err != nil {
+		return err
+	}
+	m.applyChecked(rec)
+	return nil
+}
+
+// commit applies recs together through one raft log entry. The entry
+// carries expect, so each replica checks it as it applies the entry.
+func (s *RaftStore) commit(recs, expect []walRecord) error {
+	return s.apply(walRecord{Op: walBatch, Batch: recs, Expect: expect})
+}
+
+// conflict returns ErrConflict if a record the batch rec expects has
+// changed. The caller holds the write lock.
+func (m *MemoryStore) conflict(rec walRecord) error {
+	for _, e := range rec.Expect {
+		var same bool
+		switch e.Op {
+		case walPutUser:
+			same = unchanged(m.users, e.ID, e.User)
+		case walPutPreferences:
+			same = unchanged(m.preferences, e.ID, e.Preferences)
+		case walPutCredentials:
+			same = unchanged(m.credentials, e.ID, e.Credentials)
+		case walPutPost:
+			same = unchanged(m.posts, e.ID, e.Post)
+		case walPutComment:
+			same = unchanged(m.comments, e.ID, e.Comment)
+		case walPutInvitation:
+			same = unchanged(m.invitations, e.ID, e.Invitation)
+		}
+		if !same {
+			return ErrConflict
+		}
+	}
+	return nil
+}
+
+// unchanged reports whether records[id] is still want, or still missing if
+// want is nil. Records are compared as JSON, which is what replicas and a
+// replayed log agree on.
+func unchanged[T any](records map[string]*T, id string, want *T) bool {
+	got, ok := records[id]
+	if !ok || want == nil {
+		return !ok && want == nil
+	}
+	a, errA := json.Marshal(got)
+	b, errB := json.Marshal(want)
+	return errA == nil && errB == nil && bytes.Equal(a, b)
+}
+
+// ErrConflict is returned by WithTx when what a transaction read kept
+// changing under it, so it couldn't commit.
+var ErrConflict = errors.New("the data a transaction read changed before it could commit")
+
+// maxTxAttempts is how often WithTx runs a transaction whose reads went
+// stale before it gives up.
+const maxTxAttempts = 3
+
+// txWrapper is implemented by stores that wrap another, like timedStore and
+// encryptedStore, so WithTx can run the transaction on the store underneath
+// and wrap it the same way.
+type txWrapper interface {
+	withTx(ctx context.Context, fn func(tx Store) error) error
+}
+
+// WithTx runs fn as a unit of work on store. fn's writes through tx are
+// staged, and visible to its reads, until it returns: if it returns nil they
+// are committed together, and if it returns an error none of them are
+// written. Concurrency is optimistic: nothing is locked while fn runs, and
+// if a record fn read was changed by another write before the commit, fn
+// runs again on fresh data, up to maxTxAttempts times, and then WithTx
+// returns ErrConflict. So fn must do nothing but read and write through tx.
+// Lists aren't checked, only the records fn got by ID. On a store that
+// can't commit atomically, like the txStore of a transaction already
+// running, fn runs against store itself.
+func WithTx(ctx context.Context, store Store, fn func(tx Store) error) error {
+	if w, ok := store.(txWrapper); ok {
+		return w.withTx(ctx, fn)
+	}
+	base, ok := store.(atomicStore)
+	if !ok {
+		return fn(store)
+	}
+	for range maxTxAttempts {
+		tx := newTxStore(base)
+		if err := fn(tx); err != nil {
+			return err
+		}
+		if err := tx.commit(); !errors.Is(err, ErrConflict) {
+			return err
+		}
+	}
+	return ErrConflict
+}
+
+// txStore stages writes on top of base, reading them back, until commit
+// hands them to base in one go. A nil entry in its maps is a staged delete.
+// Staged deletes hide what the base store drops along with the deleted
+// record: a user's posts and comments, a post's comments and a comment's
+// replies. What it reads from base by ID is noted in expect, for commit to
+// check it hasn't changed.
+type txStore struct {
+	base        atomicStore
+	users       map[string]*User
+	prefs       map[string]*UserPreferences
+	creds       map[string]*Credentials
+	posts       map[string]*Post
+	comments    map[string]*Comment
+	invitations map[string]*StoredInvitation
+	records     []walRecord
+	expect      []walRecord
+	read        map[string]bool // the records in expect, by op and ID
+}
+
+func newTxStore(base atomicStore) *txStore {
+	return &txStore{
+		base:        base,
+		users:       map[string]*User{},
+		prefs:       map[string]*UserPreferences{},
+		creds:       map[string]*Credentials{},
+		posts:       map[string]*Post{},
+		comments:    map[string]*Comment{},
+		invitations: map[string]*StoredInvitation{},
+		read:        map[string]bool{},
+	}
+}
+
+// noteRead adds rec, what a read of a record from base found, to t.expect
+// unless the record was read before. Reads that failed for another reason
+// than ErrNotFound aren't noted; their error fails the transaction.
+func (t *txStore) noteRead(rec walRecord, err error) {
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return
+	}
+	key := rec.Op + ":" + rec.ID
+	if !t.read[key] {
+		t.read[key] = true
+		t.expect = append(t.expect, rec)
+	}
+}
+
+func (t *txStore) GetUser(ctx context.Context, id string) (*User, error) {
+	if user, ok := t.users[id]; ok {
+		if user == nil {
+			return nil, ErrNotFound
+		}
+		return user.clone(), nil
+	}
+	user, err := t.base.GetUser(ctx, id)
+	rec := walRecord{Op: walPutUser, ID: id}
+	if user != nil {
+		rec.User = user.clone()
+	}
+	t.noteRead(rec, err)
+	return user, err
+}
+
+func (t *txStore) ListUsers(ctx context.Context) ([]*User, error) {
+	users, err := t.base.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := users[:0]
+	for _, u := range users {
+		if _, staged := t.users[u.ID]; !staged {
+			out = append(out, u)
+		}
+	}
+	for _, u := range t.users {
+		if u != nil {
+			out = append(out, u.clone())
+		}
+	}
+	return out, nil
+}
+
+func (t *txStore) PutUser(ctx context.Context, user *User) error {
+	c := user.clone()
+	t.users[user.ID] = c
+	t.records = append(t.records, walRecord{Op: walPutUser, User: c})
+	return nil
+}
+
+func (t *txStore) DeleteUser(ctx context.Context, id string) error {
+	if _, err := t.GetUser(ctx, id); err != nil {
+		return err
+	}
+	t.users[id], t.prefs[id], t.creds[id] = nil, nil, nil
+	t.records = append(t.records, walRecord{Op: walDeleteUser, ID: id})
+	return nil
+}
+
+func (t *txStore) GetPreferences(ctx context.Context, userID string) (*UserPreferences, error) {
+	if prefs, ok := t.prefs[userID]; ok {
+		if prefs == nil {
+			return nil, ErrNotFound
+		}
+		c := *prefs
+		return &c, nil
+	}
+	prefs, err := t.base.GetPreferences(ctx, userID)
+	rec := walRecord{Op: walPutPreferences, ID: userID}
+	if prefs != nil {
+		c := *prefs
+		rec.Preferences = &c
+	}
+	t.noteRead(rec, err)
+	return prefs, err
+}
+
+func (t *txStore) PutPreferences(ctx context.Context, userID string, prefs *UserPreferences) error {
+	c := *prefs
+	t.prefs[userID] = &c
+	t.records = append(t.records, walRecord{Op: walPutPreferences, ID: userID, Preferences: &c})
+	return nil
+}
+
+func (t *txStore) GetCredentials(ctx context.Context, userID string) (*Credentials, error) {
+	if creds, ok := t.creds[userID]; ok {
+		if creds == nil {
+			return nil, ErrNotFound
+		}
+		c := *creds
+		return &c, nil
+	}
+	creds, err := t.base.GetCredentials(ctx, userID)
+	rec := walRecord{Op: walPutCredentials, ID: userID}
+	if creds != nil {
+		c := *creds
+		rec.Credentials = &c
+	}
+	t.noteRead(rec, err)
+	return creds, err
+}
+
+func (t *txStore) PutCredentials(ctx context.Context, userID string, creds *Credentials) error {
+	c := *creds
+	t.creds[userID] = &c
+	t.records = append(t.records, walRecord{Op: walPutCredentials, ID: userID, Credentials: &c})
+	return nil
+}
+
+func (t *txStore) GetPost(ctx context.Context, id string) (*Post, error) {
+	post, ok := t.posts[id]
+	if !ok {
+		p, err := t.base.GetPost(ctx, id)
+		t.noteRead(walRecord{Op: walPutPost, ID: id, Post: p}, err)
+		if err != nil {
+			return nil, err
+		}
+		post = p
+	}
+	if post == nil || t.authorDeleted(post) {
+		return nil, ErrNotFound
+	}
+	return post.clone(), nil
+}
+
+func (t *txStore) authorDeleted(post *Post) bool {
+	author, staged := t.users[post.AuthorID]
+	return staged && author == nil
+}
+
+func (t *txStore) ListPosts(ctx context.Context) ([]*Post, error) {
+	posts, err := t.base.ListPosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := posts[:0]
+	for _, p := range posts {
+		if _, staged := t.posts[p.ID]; !staged && !t.authorDeleted(p) {
+			out = append(out, p)
+		}
+	}
+	for _, p := range t.posts {
+		if p != nil && !t.authorDeleted(p) {
+			out = append(out, p.clone())
+		}
+	}
+	return out, nil
+}
+
+func (t *txStore) PutPost(ctx context.Context, post *Post) error {
+	if _, err := t.GetUser(ctx, post.AuthorID); err != nil {
+		return err
+	}
+	c := post.clone()
+	t.posts[post.ID] = c
+	t.records = append(t.records, walRecord{Op: walPutPost, Post: c})
+	return nil
+}
+
+func (t *txStore) DeletePost(ctx context.Context, id string) error {
+	if _, err := t.GetPost(ctx, id); err != nil {
+		return err
+	}
+	t.posts[id] = nil
+	t.records = append(t.records, walRecord{Op: walDeletePost, ID: id})
+	return nil
+}
+
+func (t *txStore) GetComment(ctx context.Context, id string) (*Comment, error) {
+	comment, ok := t.comments[id]
+	if !ok {
+		c, err := t.base.GetComment(ctx, id)
+		t.noteRead(walRecord{Op: walPutComment, ID: id, Comment: c}, err)
+		if err != nil {
+			return nil, err
+		}
+		comment = c
+	}
+	if comment == nil || t.commentGone(ctx, comment) {
+		return nil, ErrNotFound
+	}
+	return comment.clone(), nil
+}
+
+// commentGone reports whether a staged delete took comment with it.
+func (t *txStore) commentGone(ctx context.Context, comment *Comment) bool {
+	if author, staged := t.users[comment.AuthorID]; staged && author == nil {
+		return true
+	}
+	if _, err := t.GetPost(ctx, comment.PostID); err != nil {
+		return true
+	}
+	if comment.ParentID != "" {
+		_, err := t.GetComment(ctx, comment.ParentID)
+		return err != nil
+	}
+	return false
+}
+
+func (t *txStore) ListComments(ctx context.Context, postIDs ...string) ([]*Comment, error) {
+	comments, err := t.base.ListComments(ctx, postIDs...)
+	if err != nil {
+		return nil, err
+	}
+	posts := idSet(postIDs)
+	out := comments[:0]
+	for _, c := range comments {
+		if _, staged := t.comments[c.ID]; !staged && !t.commentGone(ctx, c) {
+			out = append(out, c)
+		}
+	}
+	for _, c := range t.comments {
+		if c != nil && posts[c.PostID] && !t.commentGone(ctx, c) {
+			out = append(out, c.clone())
+		}
+	}
+	return out, nil
+}
+
+func (t *txStore) PutComment(ctx context.Context, comment *Comment) error {
+	if _, err := t.GetPost(ctx, comment.PostID); err != nil {
+		return err
+	}
+	if _, err := t.GetUser(ctx, comment.AuthorID); err != nil {
+		return err
+	}
+	c := comment.clone()
+	t.comments[comment.ID] = c
+	t.records = append(t.records, walRecord{Op: walPutComment, Comment: c})
+	return nil
+}
+
+func (t *txStore) DeleteComment(ctx context.Context, id string) error {
+	if _, err := t.GetComment(ctx, id); err != nil {
+		return err
+	}
+	t.comments[id] = nil
+	t.records = append(t.records, walRecord{Op: walDeleteComment, ID: id})
+	return nil
+}
+
+func (t *txStore) GetInvitation(ctx context.Context, id string) (*StoredInvitation, error) {
+	if inv, ok := t.invitations[id]; ok {
+		if inv == nil {
+			return nil, ErrNotFound
+		}
+		return inv.clone(), nil
+	}
+	inv, err := t.base.GetInvitation(ctx, id)
+	rec := walRecord{Op: walPutInvitation, ID: id}
+	if inv != nil {
+		rec.Invitation = inv.clone()
+	}
+	t.noteRead(rec, err)
+	return inv, err
+}
+
+func (t *txStore) ListInvitations(ctx context.Context) ([]*StoredInvitation, error) {
+	invitations, err := t.base.ListInvitations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := invitations[:0]
+	for _, inv := range invitations {
+		if _, staged := t.invitations[inv.ID]; !staged {
+			out = append(out, inv)
+		}
+	}
+	for _, inv := range t.invitations {
+		if inv != nil {
+			out = append(out, inv.clone())
+		}
+	}
+	return out, nil
+}
+
+func (t *txStore) PutInvitation(ctx context.Context, inv *StoredInvitation) error {
+	c := inv.clone()
+	t.invitations[inv.ID] = c
+	t.records = append(t.records, walRecord{Op: walPutInvitation, Invitation: c})
+	return nil
+}
+
+func (t *txStore) DeleteInvitation(ctx context.Context, id string) error {
+	if _, err := t.GetInvitation(ctx, id); err != nil {
+		return err
+	}
+	t.invitations[id] = nil
+	t.records = append(t.records, walRecord{Op: walDeleteInvitation, ID: id})
+	return nil
+}
+
+// commit hands the staged writes to the base store.
+func (t *txStore) commit() error {
+	if len(t.records) == 0 {
+		return nil
+	}
+	return t.base.commit(t.records, t.expect)
+}
+
+// Transaction operations.
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// TransactionOperation is one step of a transaction. Resource leaves room
+// for resources besides users.
+type TransactionOperation struct {
+	Op       string         `json:"op" enum:"create,update,delete" doc:"What to do"`
+	Resource string         `json:"resource" enum:"user" doc:"The kind of resource to do it to"`
+	ID       string         `json:"id,omitempty" example:"$0" doc:"For update and delete, the resource's ID, or $N for the one operation N, counting from 0, created"`
+	Body     map[string]any `json:"body,omitempty" example:"{\"name\":\"Rohan Chauhan\",\"email\":\"rohan@example.com\"}" doc:"For create, what post-v1-users takes (CreateUserRequest); for update, what put-v1-users-by-id takes (UpdateUserRequest)"`
+}
+
+type TransactionRequest struct {
+	Operations []TransactionOperation `json:"operations" minItems:"1" maxItems:"100" doc:"Operations to run, in order"`
+}
+
+type TransactionInput struct {
+	CaptchaInput
+	Body TransactionRequest
+}
+
+// Outcomes of a transaction's operations.
+const (
+	OutcomeCommitted  = "committed"
+	OutcomeRolledBack = "rolled_back"
+	OutcomeFailed     = "failed"
+	OutcomeSkipped    = "skipped"
+)
+
+type TransactionResult struct {
+	Outcome string      `json:"outcome" enum:"committed,rolled_back,failed,skipped" doc:"committed if the transaction was; otherwise failed for the operation that failed, rolled_back for those before it and skipped for those after it"`
+	Status  int         `json:"status,omitempty" example:"201" doc:"What the operation answered, or would have on its own: 201 for a create, 200 for an update or delete, or the status of Error. Absent for skipped operations"`
+	ID      string      `json:"id,omitempty" example:"20240101120000" doc:"ID of the resource; absent for a create that was rolled back"`
+	User    *User       `json:"user,omitempty" doc:"The user as a committed create or update left them"`
+	Error   *ErrorModel `json:"error,omitempty" doc:"Why the operation failed"`
+}
+
+type TransactionResponse struct {
+	Committed bool                `json:"committed" doc:"Whether every operation succeeded and was committed. If not, none of them were"`
+	Results   []TransactionResult `json:"results" doc:"One per operation, in request order"`
+}
+
+// Translate lets i18n.LocalizeErrors translate the failed operation's
+// error.
+func (r *TransactionResponse) Translate(translate func(string) string) any {
+	out := *r
+	out.Results = make([]TransactionResult, len(r.Results))
+	for i, res := range r.Results {
+		if res.Error != nil {
+			res.Error = res.Error.Translate(translate).(*ErrorModel)
+		}
+		out.Results[i] = res
+	}
+	return &out
+}
+
+type TransactionOutput struct {
+	Body *TransactionResponse
+}
+
+// errRolledBack has WithTx drop the writes of a transaction an operation of
+// which failed.
+var errRolledBack = errors.New("rolled back")
+
+// runTransaction is the post-v1-batch handler. It runs the operations in
+// order in a WithTx transaction, through a UserService of their own, so that
+// they see each other's writes and uphold the same rules as the single
+// endpoints. If all of them succeed the writes are committed together and
+// the events they published go out on the bus; otherwise nothing is written
+// or published. Transactions run one at a time, but writes outside them
+// aren't held up: one that changes a user a transaction read has it run
+// again, or fail with 409 if that keeps happening. Lists aren't checked, so
+// like a single request's, a transaction's uniqueness checks can race with
+// them.
+func (s *Server) runTransaction(ctx context.Context, input *TransactionInput) (*TransactionOutput, error) {
+	ops := input.Body.Operations
+	for _, op := range ops {
+		if op.Op == OpCreate {
+			if err := s.verifyCaptcha(ctx, input.CaptchaInput); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	if _, ok := s.store.(atomicStore); !ok {
+		return nil, errors.New("the store can't commit transactions")
+	}
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+	// The transaction's users are checked for uniqueness against the store
+	// as it is, so no other create or update may write one until it has
+	// committed.
+	s.users.unique.Lock()
+	defer s.users.unique.Unlock()
+
+	var out *TransactionResponse
+	var published []events.Event
+	failed := -1
+	err := WithTx(ctx, s.store, func(tx Store) error {
+		bus := events.New()
+		published = nil
+		bus.Subscribe(func(e events.Event) { published = append(published, e) })
+		users := NewUserService(userStoreFor(s.cfg, tx), bus, s.audit, s.logger, s.users.uniquePhones.Load(), s.users.emailFolding, s.users.idGen)
+		out = &TransactionResponse{Results: make([]TransactionResult, len(ops))}
+		for i := range ops {
+			res, err := s.runOperation(ctx, users, ops, out.Results[:i], i)
+			if err != nil {
+				em := s.errorModel(ctx, err)
+				out.Results[i] = TransactionResult{Outcome: OutcomeFailed, Status: em.Status, ID: res.ID, Error: em}
+				failed = i
+				return errRolledBack
+			}
+			out.Results[i] = res
+		}
+		return nil
+	})
+	if errors.Is(err, errRolledBack) {
+		for i := range out.Results {
+			switch res := &out.Results[i]; {
+			case i < failed:
+				res.Outcome, res.User = OutcomeRolledBack, nil
+				if ops[i].Op == OpCreate {
+					res.ID = ""
+				}
+			case i > failed:
+				res.Outcome = OutcomeSkipped
+			}
+		}
+		return &TransactionOutput{Body: out}, nil
+	}
+	if errors.Is(err, ErrConflict) {
+		return nil, apiError(http.StatusConflict, CodeConflict, "the users the transaction read kept changing while it ran; retry it")
+	}
+	if err != nil {
+		return nil, err
+	}
+	out.Committed = true
+	for i := range out.Results {
+		out.Results[i].Outcome = OutcomeCommitted
+	}
+	for _, e := range published {
+		s.bus.Publish(ctx, e)
+	}
+	return &TransactionOutput{Body: out}, nil
+}
+
+// runOperation runs ops[i], given the results of the ones before it.
+func (s *Server) runOperation(ctx context.Context, users *UserService, ops []TransactionOperation, earlier []TransactionResult, i int) (TransactionResult, error) {
+	op := ops[i]
+	res := TransactionResult{Status: http.StatusOK}
+	if op.Op != OpCreate {
+		id, err := resolveRef(op.ID, ops, earlier, i)
+		if err != nil {
+			return res, err
+		}
+		res.ID = id
+	}
+	var err error
+	switch op.Op {
+	case OpCreate:
+		var req CreateUserRequest
+		if err := s.decodeBody(ctx, bodyPath("operations", i, "body"), op.Body, &req); err != nil {
+			return res, err
+		}
+		res.Status = http.StatusCreated
+		res.User, err = users.Create(ctx, req)
+	case OpUpdate:
+		var req UpdateUserRequest
+		if err := s.decodeBody(ctx, bodyPath("operations", i, "body"), op.Body, &req); err != nil {
+			return res, err
+		}
+		res.User, err = users.Update(ctx, res.ID, req)
+	case OpDelete:
+		err = users.Delete(ctx, res.ID)
+		if errors.Is(err, ErrNotFound) {
+			err = apiError(http.StatusNotFound, CodeUserNotFound, "User not found")
+		}
+	}
+	if res.User != nil {
+		res.ID = res.User.ID
+	}
+	return res, err
+}
+
+// resolveRef returns the ID operation i refers to: id itself, or for $N the
+// ID of what operation N created.
+func resolveRef(id string, ops []TransactionOperation, earlier []TransactionResult, i int) (string, error) {
+	location := bodyPath("operations", i, "id").String()
+	if id == "" {
+		return "", newError(http.StatusUnprocessableEntity, "validation failed", &ErrorDetail{
+			Location: location,
+			Code:     "required",
+			Message:  "expected an id for update and delete",
+		})
+	}
+	ref, ok := strings.CutPrefix(id, "$")
+	if !ok {
+		return id, nil
+	}
+	n, err := strconv.Atoi(ref)
+	if err != nil || n < 0 || n >= i || ops[n].Op != OpCreate {
+		return "", newError(http.StatusUnprocessableEntity, "validation failed", &ErrorDetail{
+			Location: location,
+			Code:     "invalid",
+			Message:  "expected $N to name an earlier create operation",
+			Value:    id,
+		})
+	}
+	return earlier[n].ID, nil
+}
@@ -0,0 +1,212 @@
+package server
+
+import (
+	"encoding/json"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/redact"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// Fields tagged redact:"true" hold personal data or credentials. Registering
+// their types keeps those fields, and JSON properties of the same names, out
+// of logs and error reports.
+func init() {
+	redact.Register(User{}, CreateUserRequest{}, UpdateUserRequest{}, UsernameAvailability{}, UserSuggestion{}, AdminInput{},
+		UserInput{}, CurrentUser{}, ImpersonateRequest{}, ImpersonationToken{}, LoginRequest{}, LoginToken{}, CaptchaInput{})
+}
+
+// --- Response types ---
+type HelloResponse struct {
+	Message string `json:"message" doc:"A welcome message from the API"`
+}
+
+type HealthResponse struct {
+	Status int `json:"status" example:"200" doc:"200, or 503 while the server drains before shutting down"`
+}
+
+type ReadinessResponse struct {
+	Status     int               `json:"status" example:"200" doc:"200 while the server takes traffic, or 503"`
+	Reason     string            `json:"reason,omitempty" enum:"draining,not_ready" doc:"Why the server doesn't take traffic: it got SIGTERM and drains before shutting down, or a component isn't ready"`
+	Components []ComponentStatus `json:"components,omitempty" doc:"The components of the server, in the order they start; empty when the API is embedded and not run on its own"`
+}
+
+type HelloOutput struct {
+	Body *HelloResponse
+}
+
+type HealthOutput struct {
+	// Status is 503 while the server drains before shutting down.
+	Status      int
+	ContentType string `header:"Content-Type"`
+	Body        *HealthResponse
+}
+
+type ReadinessOutput struct {
+	Status      int
+	ContentType string `header:"Content-Type"`
+	Body        *ReadinessResponse
+}
+
+type VersionResponse struct {
+	Version   string `json:"version" doc:"Release version, or dev for local builds"`
+	Commit    string `json:"commit" doc:"Git commit SHA the binary was built from"`
+	Date      string `json:"date" doc:"Build time (RFC 3339), or the commit time if it wasn't set at build"`
+	GoVersion string `json:"go_version" doc:"Go runtime version"`
+}
+
+type VersionOutput struct {
+	Body *VersionResponse
+}
+
+type DeleteUserResponse struct {
+	Deleted bool `json:"deleted" doc:"Whether there was a user to delete"`
+}
+
+type UsersListResponse struct {
+	Users     []*User        `json:"users" doc:"This page of users"`
+	Status    int            `json:"status" example:"200" doc:"Always 200; kept for older clients"`
+	TagCounts map[string]int `json:"tag_counts" doc:"Number of listed users carrying each tag"`
+	Pagination
+	Partial bool `json:"partial,omitempty" doc:"true if the time budget ran out before every user was looked at; the users, tag counts and pages are then of those that were, oldest first"`
+}
+
+// --- User types ---
+type User struct {
+	// Schema is set for responses by userSchemaLink.
+	Schema string `json:"$schema,omitempty" format:"uri" readOnly:"true" example:"https://example.com/schemas/User.json" doc:"A URL to the JSON Schema for this object."`
+
+	ID       string     `json:"id" example:"20240101120000" doc:"User ID"`
+	Username string     `json:"username,omitempty" example:"ro_chauhan" redact:"true" doc:"Unique lowercase handle"`
+	Name     string     `json:"name" example:"Rohan Chauhan" redact:"true" doc:"User's name"`
+	Email    string     `json:"email" format:"email" example:"rohan@example.com" redact:"true" doc:"User's email, in its canonical form: the domain lowercased, and folded further as EMAIL_FOLDING says"`
+	EmailRaw string     `json:"email_raw,omitempty" readOnly:"true" example:"Rohan+news@Example.com" redact:"true" doc:"The email as it was given, if that differs from its canonical form"`
+	Phone    string     `json:"phone,omitempty" format:"e164" example:"+436601234567" redact:"true" doc:"Phone number in E.164 form"`
+	Status   UserStatus `json:"status" enum:"invited,active,suspended,deleted" doc:"Lifecycle status of the user"`
+	Active   bool       `json:"active" readOnly:"true" doc:"Whether the user is active; inactive users are hidden from the default listing"`
+
+	LastLoginAt *timestamp.Time `json:"last_login_at,omitempty" readOnly:"true" doc:"When the user last logged in"`
+	LastSeenAt  *timestamp.Time `json:"last_seen_at,omitempty" readOnly:"true" doc:"When the user last made an authenticated request"`
+	DeletedAt   *timestamp.Time `json:"deleted_at,omitempty" readOnly:"true" doc:"When the user was soft-deleted; the retention policy purges them some time after"`
+
+	Metadata map[string]any `json:"metadata,omitempty" doc:"Free-form attributes for integrations"`
+	Tags     []string       `json:"tags,omitempty" readOnly:"true" doc:"Labels, managed through /v1/users/{id}/tags"`
+
+	PostCount *int    `json:"post_count,omitempty" readOnly:"true" doc:"Number of posts the user wrote; only with include=post_count"`
+	Posts     []*Post `json:"posts,omitempty" readOnly:"true" doc:"The posts the user wrote, oldest first; only with include=posts or include=posts.comments, and omitted if there are none"`
+
+	// Fields written by a newer version, kept as they were; see
+	// unknownfields.go.
+	_       struct{} `json:"-" additionalProperties:"true"`
+	unknown map[string]json.RawMessage
+}
+
+// Request bodies are decoded strictly: properties that are not part of the
+// schema are rejected with a 422 that lists each one under `errors`, so typos
+// like "emial" can't be silently dropped. An endpoint opts out through its body
+// type by adding a `_ struct{}` field tagged `additionalProperties:"true"`, in
+// which case unknown properties are ignored instead.
+type CreateUserRequest struct {
+	Username string         `json:"username,omitempty" example:"ro_chauhan" redact:"true" doc:"Unique handle: 3-32 lowercase letters, digits or underscores, starting with a letter"`
+	Name     string         `json:"name" minLength:"1" maxLength:"200" example:"Rohan Chauhan" redact:"true" doc:"User's name"`
+	Email    string         `json:"email" format:"email" maxLength:"254" example:"rohan@example.com" redact:"true" doc:"User's email"`
+	Phone    string         `json:"phone,omitempty" example:"+43 660 1234567" redact:"true" doc:"International phone number; stored in E.164 form"`
+	Password string         `json:"password,omitempty" minLength:"8" maxLength:"72" writeOnly:"true" redact:"true" doc:"Password for post-v1-auth-login; without one the user can't log in"`
+	Metadata map[string]any `json:"metadata,omitempty" doc:"Free-form attributes for integrations"`
+}
+
+// UpdateUserRequest stays lenient because existing clients PUT back the user
+// they fetched, including read-only fields like `id` and `status`, and
+// fields from a newer version, which are kept.
+type UpdateUserRequest struct {
+	_        struct{} `json:"-" additionalProperties:"true"`
+	Username *string  `json:"username,omitempty" example:"ro_chauhan" redact:"true" doc:"Unique handle: 3-32 lowercase letters, digits or underscores, starting with a letter"`
+	Name     *string  `json:"name,omitempty" minLength:"1" maxLength:"200" example:"Rohan Chauhan" redact:"true" doc:"User's name"`
+	Email    *string  `json:"email,omitempty" format:"email" maxLength:"254" example:"rohan@example.com" redact:"true" doc:"User's email"`
+	Phone    *string  `json:"phone,omitempty" example:"+43 660 1234567" redact:"true" doc:"International phone number; stored in E.164 form. Empty clears it"`
+	// Metadata replaces the stored metadata wholesale when present.
+	Metadata map[string]any `json:"metadata,omitempty" doc:"Free-form attributes for integrations; replaces the existing metadata"`
+
+	// unknown are the fields users don't have in this version, which
+	// Update keeps.
+	unknown map[string]json.RawMessage
+}
+
+type UsernameAvailability struct {
+	Username  string `json:"username" redact:"true" doc:"The name that was checked, normalized to lowercase"`
+	Available bool   `json:"available" doc:"Whether the name can be registered"`
+	Reason    string `json:"reason,omitempty" enum:"invalid,reserved,taken" doc:"Why the name is unavailable"`
+}
+
+type UserStatusRequest struct {
+	Status UserStatus `json:"status" enum:"invited,active,suspended,deleted" doc:"Status to move the user to"`
+}
+
+// --- Operation inputs/outputs ---
+type UserIDInput struct {
+	ID string `path:"id" example:"20240101120000" doc:"User ID"`
+}
+
+type CreateUserInput struct {
+	CaptchaInput
+	Body CreateUserRequest
+}
+
+type UpdateUserInput struct {
+	ID   string `path:"id" example:"20240101120000" doc:"User ID"`
+	Body UpdateUserRequest
+}
+
+type UserStatusInput struct {
+	ID   string `path:"id" example:"20240101120000" doc:"User ID"`
+	Body UserStatusRequest
+}
+
+type UserPreferencesInput struct {
+	ID   string `path:"id" example:"20240101120000" doc:"User ID"`
+	Body UserPreferences
+}
+
+type UserPreferencesOutput struct {
+	Body *UserPreferences
+}
+
+type UsernameInput struct {
+	Name string `path:"name" doc:"Username to check"`
+}
+
+type UsernameAvailabilityOutput struct {
+	Body *UsernameAvailability
+}
+
+type UserOutput struct {
+	Body *User
+}
+
+type UsersListOutput struct {
+	TotalCount int    `header:"X-Total-Count" doc:"Number of users matching the filters, across all pages"`
+	Link       string `header:"Link" doc:"RFC 8288 links to the first, prev, next and last pages"`
+	Body       *UsersListResponse
+}
+
+type DeleteUserInput struct {
+	ID   string `path:"id" example:"20240101120000" doc:"User ID"`
+	Mode string `query:"mode" enum:"delete,erase" default:"delete" doc:"erase also anonymizes the audit log entries about the user, for GDPR erasure requests"`
+}
+
+type UserDataExport struct {
+	ExportedAt  timestamp.Time   `json:"exported_at" doc:"When the export was generated"`
+	User        *User            `json:"user" doc:"The user record"`
+	Preferences *UserPreferences `json:"preferences,omitempty" doc:"Saved preferences, left out if the user never saved any"`
+	Posts       []*Post          `json:"posts" doc:"The user's posts, oldest first"`
+	Comments    []*Comment       `json:"comments" doc:"The comments the user wrote, on anyone's posts, oldest first"`
+	Audit       []AuditEntry     `json:"audit" doc:"Audit log entries about the user, oldest first"`
+}
+
+type UserDataExportOutput struct {
+	Body *UserDataExport
+}
+
+type DeleteUserOutput struct {
+	Status int
+	Body   *DeleteUserResponse
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/redact"
+)
+
+// A user body may carry fields this version doesn't know, written by a
+// newer one during a rolling deploy or by a client that fetched the user
+// from it. Rather than dropping them, which would lose the newer version's
+// data the next time an older client PUTs the user back, users keep such
+// fields as they were and send them back at the top level, in responses
+// and in everything the store persists as JSON: snapshots, the WAL and the
+// raft log. So a field a newer version wrote survives a round trip through
+// an older one.
+
+// maxUnknownBytes caps the unknown fields a user keeps, measured like
+// metadata, so they can't grow into hidden storage.
+const maxUnknownBytes = maxMetadataBytes
+
+// userFields are the JSON names of the fields of User.
+var userFields = jsonFieldNames(reflect.TypeFor[User]())
+
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := map[string]bool{}
+	for _, f := range reflect.VisibleFields(t) {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if f.IsExported() && name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// unknownField reports whether name is a field of a user body this version
+// doesn't know. Names starting with $ or _ are annotations, huma's $schema
+// and HAL's _links and _embedded, rather than data, and aren't kept; nor are
+// credentials like a password sent by mistake.
+func unknownField(name string) bool {
+	return !userFields[name] && !strings.HasPrefix(name, "$") && !strings.HasPrefix(name, "_") && !redact.Key(name)
+}
+
+// unknownFields returns the unknown fields of the JSON object data, or nil
+// if there are none.
+func unknownFields(data []byte) (map[string]json.RawMessage, error) {
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	var unknown map[string]json.RawMessage
+	for name, v := range all {
+		if !unknownField(name) {
+			continue
+		}
+		if unknown == nil {
+			unknown = map[string]json.RawMessage{}
+		}
+		unknown[name] = v
+	}
+	return unknown, nil
+}
+
+// userJSON is User without its JSON methods.
+type userJSON User
+
+// MarshalJSON encodes u with its unknown fields after its own.
+func (u User) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(userJSON(u))
+	if err != nil || len(u.unknown) == 0 {
+		return data, err
+	}
+	buf := bytes.NewBuffer(data[:len(data)-1])
+	for _, name := range slices.Sorted(maps.Keys(u.unknown)) {
+		key, _ := json.Marshal(name)
+		buf.WriteByte(',')
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(u.unknown[name])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON decodes data into u, keeping the fields User doesn't have.
+func (u *User) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*userJSON)(u)); err != nil {
+		return err
+	}
+	unknown, err := unknownFields(data)
+	u.unknown = unknown
+	return err
+}
+
+// userSchemaLink does for a user what huma's schema link transformer does for
+// other response bodies: it sets the $schema and the describedBy link. That
+// copies a body's fields into a struct of its own, which would lose the
+// unknown fields, so User declares $schema itself and is left alone.
+func userSchemaLink(ctx huma.Context, status string, v any) (any, error) {
+	u, ok := v.(*User)
+	if !ok {
+		return v, nil
+	}
+	ref := "/schemas/User.json"
+	ctx.AppendHeader("Link", "<"+ref+`>; rel="describedBy"`)
+	scheme := "https://"
+	if host := ctx.Host(); strings.HasPrefix(host, "localhost") || strings.HasPrefix(host, "127.0.0.1") {
+		scheme = "http://"
+	}
+	c := *u
+	c.Schema = scheme + ctx.Host() + ref
+	return &c, nil
+}
+
+// updateUserJSON is UpdateUserRequest without its JSON methods.
+type updateUserJSON UpdateUserRequest
+
+// UnmarshalJSON decodes data into r, keeping the fields users don't have
+// for Update to store.
+func (r *UpdateUserRequest) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*updateUserJSON)(r)); err != nil {
+		return err
+	}
+	unknown, err := unknownFields(data)
+	r.unknown = unknown
+	return err
+}
+
+// validateUnknown enforces maxUnknownBytes on unknown, reporting a problem
+// at loc.
+func validateUnknown(loc string, unknown map[string]json.RawMessage) error {
+	size := 0
+	for name, v := range unknown {
+		size += len(name) + len(v)
+	}
+	if size <= maxUnknownBytes {
+		return nil
+	}
+	return &ErrorDetail{Location: loc, Code: "max_size", Message: fmt.Sprintf("expected unknown fields of at most %d bytes", maxUnknownBytes)}
+}
+
+// mergeUnknown returns the unknown fields of a user after an update sets
+// those in update, a null removing one. kept isn't changed, as clones share
+// it.
+func mergeUnknown(kept, update map[string]json.RawMessage) map[string]json.RawMessage {
+	merged := maps.Clone(kept)
+	if merged == nil {
+		merged = map[string]json.RawMessage{}
+	}
+	for name, v := range update {
+		if string(v) == "null" {
+			delete(merged, name)
+		} else {
+			merged[name] = v
+		}
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
+
+// setUnknown applies the unknown fields of req to user, returning their
+// names for user.updated.
+func setUnknown(user *User, req UpdateUserRequest) ([]string, error) {
+	if len(req.unknown) == 0 {
+		return nil, nil
+	}
+	merged := mergeUnknown(user.unknown, req.unknown)
+	if err := validateUnknown("body", merged); err != nil {
+		return nil, newError(http.StatusUnprocessableEntity, "validation failed", err)
+	}
+	user.unknown = merged
+	return slices.Sorted(maps.Keys(req.unknown)), nil
+}
+
+// validateUnknownFields is validateUnknown for a request body at prefix.
+func validateUnknownFields(prefix *huma.PathBuffer, unknown map[string]json.RawMessage) []error {
+	if err := validateUnknown(prefix.String(), unknown); err != nil {
+		return []error{err}
+	}
+	return nil
+}
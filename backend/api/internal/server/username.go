@@ -0,0 +1,53 @@
+package server
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// usernamePattern allows 3-32 lowercase letters, digits and underscores,
+// starting with a letter.
+var usernamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{2,31}$`)
+
+// reservedUsernames can't be registered because they'd be confusing in URLs,
+// emails or support conversations.
+var reservedUsernames = map[string]bool{
+	"admin": true, "administrator": true, "api": true, "root": true,
+	"system": true, "support": true, "help": true, "security": true,
+	"staff": true, "moderator": true, "owner": true, "me": true,
+	"settings": true, "account": true, "login": true, "logout": true,
+	"signup": true, "register": true, "www": true, "mail": true,
+	"null": true, "undefined": true, "anonymous": true, "everyone": true,
+}
+
+var (
+	errInvalidUsername  = errors.New("expected 3-32 lowercase letters, digits or underscores, starting with a letter")
+	errReservedUsername = errors.New("username is reserved")
+)
+
+// normalizeUsername lowercases a username and checks it against the pattern
+// and the reserved list. Uniqueness is checked separately by usernameTaken.
+func normalizeUsername(raw string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(raw))
+	if !usernamePattern.MatchString(name) {
+		return name, errInvalidUsername
+	}
+	if reservedUsernames[name] {
+		return name, errReservedUsername
+	}
+	return name, nil
+}
+
+// usernameTaken reports whether a user other than exceptID already has name.
+func usernameTaken(users []*User, name, exceptID string) bool {
+	if name == "" {
+		return false
+	}
+	for _, u := range users {
+		if u.ID != exceptID && u.Username == name {
+			return true
+		}
+	}
+	return false
+}
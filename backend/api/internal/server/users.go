@@ -0,0 +1,454 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/events"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/idgen"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// UserService holds the user business rules: uniqueness, status transitions
+// and the lifecycle events they publish. Handlers translate between HTTP and
+// its methods, which return huma errors for problems the client can fix.
+type UserService struct {
+	store        Store
+	bus          *events.Bus
+	audit        *AuditLog
+	logger       *slog.Logger
+	uniquePhones atomic.Bool
+	readOnly     atomic.Bool
+	emailFolding EmailFolding
+	idGen        idgen.Generator
+	// unique is held from checking that a username, email or phone is
+	// free until the write using it, so two requests can't both take it.
+	// Writes are forwarded to the raft leader, so one replica checks them
+	// all.
+	unique sync.Mutex
+}
+
+// NewUserService returns a UserService on store, recording what happens in
+// audit. uniquePhones makes a phone number belong to at most one user,
+// emailFolding is how emails are folded into their canonical form, and
+// idGen makes the IDs of new users.
+func NewUserService(store Store, bus *events.Bus, audit *AuditLog, logger *slog.Logger, uniquePhones bool, emailFolding EmailFolding, idGen idgen.Generator) *UserService {
+	u := &UserService{store: store, bus: bus, audit: audit, logger: logger, emailFolding: emailFolding, idGen: idGen}
+	u.uniquePhones.Store(uniquePhones)
+	return u
+}
+
+// SetUniquePhones turns the phone uniqueness check on or off for later
+// requests. Users who already share a number keep it.
+func (u *UserService) SetUniquePhones(on bool) {
+	u.uniquePhones.Store(on)
+}
+
+// SetReadOnly stops or resumes the writes the service makes on its own, like
+// recording activity, for maintenance.
+func (u *UserService) SetReadOnly(on bool) {
+	u.readOnly.Store(on)
+}
+
+// Get loads a user, turning a missing one into a 404.
+func (u *UserService) Get(ctx context.Context, id string) (*User, error) {
+	user, err := u.store.GetUser(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return nil, apiError(http.StatusNotFound, CodeUserNotFound, "User not found")
+	}
+	return user, err
+}
+
+// List returns every user, in no particular order.
+func (u *UserService) List(ctx context.Context) ([]*User, error) {
+	return u.store.ListUsers(ctx)
+}
+
+// Create adds an active user.
+func (u *UserService) Create(ctx context.Context, req CreateUserRequest) (*User, error) {
+	return u.create(ctx, req, nil)
+}
+
+// create is Create, which also runs also, if set, in the transaction that
+// stores the user, for writes that must happen with theirs.
+func (u *UserService) create(ctx context.Context, req CreateUserRequest, also func(tx Store, user *User) error) (*User, error) {
+	u.unique.Lock()
+	defer u.unique.Unlock()
+	users, err := u.store.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if u.uniquePhones.Load() && phoneTaken(users, req.Phone, "") {
+		return nil, apiError(http.StatusConflict, CodePhoneTaken, "phone number is already in use")
+	}
+	if usernameTaken(users, req.Username, "") {
+		return nil, apiError(http.StatusConflict, CodeUsernameTaken, "username is already taken")
+	}
+	if u.emailFolding.taken(users, req.Email, "") {
+		return nil, apiError(http.StatusConflict, CodeEmailTaken, "email is already in use")
+	}
+	var creds *Credentials
+	if req.Password != "" {
+		if creds, err = newCredentials(req.Password); err != nil {
+			return nil, err
+		}
+	}
+	now := time.Now()
+	user := &User{
+		Username: req.Username,
+		Name:     req.Name,
+		Phone:    req.Phone,
+		Status:   UserStatusActive,
+		Active:   true,
+		Metadata: req.Metadata,
+	}
+	u.setEmail(user, req.Email)
+	// Together, so a user is never stored without the password they were
+	// created with.
+	err = WithTx(ctx, u.store, func(tx Store) error {
+		if user.ID, err = newUserID(ctx, tx, u.idGen, users, now); err != nil {
+			return err
+		}
+		if err := tx.PutUser(ctx, user); err != nil {
+			return err
+		}
+		if creds != nil {
+			if err := tx.PutCredentials(ctx, user.ID, creds); err != nil {
+				return err
+			}
+		}
+		if also != nil {
+			return also(tx, user)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	u.bus.Publish(ctx, events.Event{Type: "user.created", Subject: user.ID})
+	return user, nil
+}
+
+// newUserID is newID among users and the users of tx. It reads the ID it
+// returns from tx, so if another create stores a user under it before tx
+// commits, the commit fails and WithTx runs again, to take the next suffix.
+func newUserID(ctx context.Context, tx Store, gen idgen.Generator, users []*User, now time.Time) (string, error) {
+	taken := make(map[string]bool, len(users))
+	for _, u := range users {
+		taken[u.ID] = true
+	}
+	for {
+		id := newID(gen, taken, now)
+		_, err := tx.GetUser(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			return id, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		taken[id] = true
+	}
+}
+
+// newID is the ID gen makes at now, with a -2, -3, ... suffix if taken
+// already has it. Only Timestamp, to the second as IDs have always been,
+// clashes more than in theory.
+func newID(gen idgen.Generator, taken map[string]bool, now time.Time) string {
+	base := gen.New(now)
+	id := base
+	for n := 2; taken[id]; n++ {
+		id = base + "-" + strconv.Itoa(n)
+	}
+	return id
+}
+
+// Update changes the fields set in req and publishes user.updated with
+// their names. It reads and writes the user in one transaction, so a write
+// made meanwhile, like recorded activity, isn't overwritten with what was
+// read before it.
+func (u *UserService) Update(ctx context.Context, id string, req UpdateUserRequest) (*User, error) {
+	if req.Phone != nil || req.Username != nil || req.Email != nil {
+		u.unique.Lock()
+		defer u.unique.Unlock()
+	}
+	var user *User
+	var fields []string
+	err := WithTx(ctx, u.store, func(tx Store) error {
+		var err error
+		user, err = tx.GetUser(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			return apiError(http.StatusNotFound, CodeUserNotFound, "User not found")
+		}
+		if err != nil {
+			return err
+		}
+		fields, err = u.update(ctx, tx, user, req)
+		if err != nil {
+			return err
+		}
+		return tx.PutUser(ctx, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+	u.bus.Publish(ctx, events.Event{Type: "user.updated", Subject: user.ID, Data: map[string][]string{"fields": fields}})
+	return user, nil
+}
+
+// update applies req to user, read from tx, and returns the names of the
+// fields it set.
+func (u *UserService) update(ctx context.Context, tx Store, user *User, req UpdateUserRequest) ([]string, error) {
+	// An email that stays the same once folded isn't checked, so users
+	// who shared one before it had to be unique can still PUT themselves
+	// back.
+	emailChanged := req.Email != nil && !u.emailFolding.same(*req.Email, user.Email)
+	if req.Phone != nil || req.Username != nil || emailChanged {
+		users, err := tx.ListUsers(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if req.Phone != nil && u.uniquePhones.Load() && phoneTaken(users, *req.Phone, user.ID) {
+			return nil, apiError(http.StatusConflict, CodePhoneTaken, "phone number is already in use")
+		}
+		if req.Username != nil && usernameTaken(users, *req.Username, user.ID) {
+			return nil, apiError(http.StatusConflict, CodeUsernameTaken, "username is already taken")
+		}
+		if emailChanged && u.emailFolding.taken(users, *req.Email, user.ID) {
+			return nil, apiError(http.StatusConflict, CodeEmailTaken, "email is already in use")
+		}
+	}
+	var fields []string
+	if req.Username != nil {
+		user.Username = *req.Username
+		fields = append(fields, "username")
+	}
+	if req.Name != nil {
+		user.Name = *req.Name
+		fields = append(fields, "name")
+	}
+	if req.Email != nil {
+		u.setEmail(user, *req.Email)
+		fields = append(fields, "email")
+	}
+	if req.Phone != nil {
+		user.Phone = *req.Phone
+		fields = append(fields, "phone")
+	}
+	if req.Metadata != nil {
+		user.Metadata = req.Metadata
+		fields = append(fields, "metadata")
+	}
+	unknown, err := setUnknown(user, req)
+	if err != nil {
+		return nil, err
+	}
+	return append(fields, unknown...), nil
+}
+
+// ChangeStatus moves the user to next, saves them and publishes the matching
+// lifecycle event. Moving a user to the status it already has is a no-op, so
+// the activate/deactivate actions can be retried safely.
+func (u *UserService) ChangeStatus(ctx context.Context, id string, next UserStatus) (*User, error) {
+	user, err := u.Get(ctx, id)
+	if err != nil || user.Status == next {
+		return user, err
+	}
+	if !user.Status.CanTransitionTo(next) {
+		return nil, apiError(http.StatusConflict, CodeInvalidStatusChange, fmt.Sprintf("cannot change status from %s to %s", user.Status, next))
+	}
+	prev := user.Status
+	user.Status = next
+	user.Active = next == UserStatusActive
+	if next == UserStatusDeleted {
+		now := timestamp.Now()
+		user.DeletedAt = &now
+	}
+	if err := u.store.PutUser(ctx, user); err != nil {
+		return nil, err
+	}
+	u.bus.Publish(ctx, events.Event{
+		Type:    userStatusEvents[next],
+		Subject: user.ID,
+		Data:    map[string]UserStatus{"from": prev, "to": next},
+	})
+	return user, nil
+}
+
+// SetTags replaces the user's tags with the already normalized tags.
+func (u *UserService) SetTags(ctx context.Context, id string, tags []string) (*User, error) {
+	user, err := u.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	user.Tags = tags
+	if len(user.Tags) == 0 {
+		user.Tags = nil
+	}
+	if err := u.store.PutUser(ctx, user); err != nil {
+		return nil, err
+	}
+	u.bus.Publish(ctx, events.Event{Type: "user.updated", Subject: user.ID, Data: map[string][]string{"fields": {"tags"}}})
+	return user, nil
+}
+
+// Delete removes the user. It returns ErrNotFound for a missing user, as the
+// delete endpoint reports that with its own body rather than an error.
+func (u *UserService) Delete(ctx context.Context, id string) error {
+	if err := u.store.DeleteUser(ctx, id); err != nil {
+		return err
+	}
+	u.bus.Publish(ctx, events.Event{Type: "user.deleted", Subject: id})
+	return nil
+}
+
+// Erase removes the user for good, for a GDPR erasure request: besides
+// deleting them and their preferences, it anonymizes the audit entries
+// about them. The erasure itself is audited under the same placeholder, so
+// there is a record that it happened without one of who it was. Like
+// Delete, it returns ErrNotFound for a missing user.
+func (u *UserService) Erase(ctx context.Context, id string) error {
+	if err := u.store.DeleteUser(ctx, id); err != nil {
+		return err
+	}
+	placeholder := erasedSubject()
+	n := u.audit.Anonymize(id, placeholder)
+	u.bus.Publish(ctx, events.Event{Type: "user.erased", Subject: placeholder, Data: map[string]int{"anonymized_entries": n}})
+	return nil
+}
+
+// PurgeDeleted removes the users soft-deleted before cutoff, for the
+// retention policy, and returns their IDs. With dryRun it only lists them.
+func (u *UserService) PurgeDeleted(ctx context.Context, cutoff time.Time, dryRun bool) ([]string, error) {
+	users, err := u.store.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for _, user := range users {
+		if user.Status != UserStatusDeleted || user.DeletedAt == nil || !user.DeletedAt.Before(cutoff) {
+			continue
+		}
+		if !dryRun {
+			err := u.store.DeleteUser(ctx, user.ID)
+			if errors.Is(err, ErrNotFound) {
+				// Deleted for good by someone else in the meantime.
+				continue
+			}
+			if err != nil {
+				return ids, err
+			}
+			u.bus.Publish(ctx, events.Event{Type: "user.purged", Subject: user.ID})
+		}
+		ids = append(ids, user.ID)
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// Export collects everything stored about the user, for a GDPR access
+// request, and audits that it was handed out.
+func (u *UserService) Export(ctx context.Context, id string) (*UserDataExport, error) {
+	user, err := u.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	prefs, err := u.store.GetPreferences(ctx, id)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	posts, err := u.store.ListPosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	all, err := u.store.ListComments(ctx, postIDs(posts)...)
+	if err != nil {
+		return nil, err
+	}
+	comments := []*Comment{}
+	for _, c := range all {
+		if c.AuthorID == id {
+			comments = append(comments, c)
+		}
+	}
+	slices.SortFunc(comments, func(a, b *Comment) int { return strings.Compare(a.ID, b.ID) })
+	posts = slices.DeleteFunc(posts, func(p *Post) bool { return p.AuthorID != id })
+	slices.SortFunc(posts, func(a, b *Post) int { return strings.Compare(a.ID, b.ID) })
+	export := &UserDataExport{
+		ExportedAt:  timestamp.Now(),
+		User:        user,
+		Preferences: prefs,
+		Posts:       posts,
+		Comments:    comments,
+		Audit:       u.audit.ForSubject(id),
+	}
+	u.bus.Publish(ctx, events.Event{Type: "user.data_exported", Subject: id})
+	return export, nil
+}
+
+// Preferences returns the user's preferences, or the defaults if they never
+// saved any.
+func (u *UserService) Preferences(ctx context.Context, id string) (*UserPreferences, error) {
+	if _, err := u.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	prefs, err := u.store.GetPreferences(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return defaultPreferences(), nil
+	}
+	return prefs, err
+}
+
+// SetPreferences replaces the user's preferences.
+func (u *UserService) SetPreferences(ctx context.Context, id string, prefs *UserPreferences) error {
+	if _, err := u.Get(ctx, id); err != nil {
+		return err
+	}
+	return u.store.PutPreferences(ctx, id, prefs)
+}
+
+// UsernameAvailability reports whether name could be registered, and why not.
+func (u *UserService) UsernameAvailability(ctx context.Context, name string) (*UsernameAvailability, error) {
+	users, err := u.store.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name, err = normalizeUsername(name)
+	result := &UsernameAvailability{Username: name}
+	switch {
+	case err == errReservedUsername:
+		result.Reason = "reserved"
+	case err != nil:
+		result.Reason = "invalid"
+	case usernameTaken(users, name, ""):
+		result.Reason = "taken"
+	default:
+		result.Available = true
+	}
+	return result, nil
+}
+
+// Search returns up to limit active users whose name or email starts with q,
+// or the best of those it looked at by deadline, unless zero, with partial
+// true.
+func (u *UserService) Search(ctx context.Context, q string, limit int, deadline time.Time) (results []UserSuggestion, partial bool, err error) {
+	users, err := u.store.ListUsers(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	results, partial = searchUsers(users, q, limit, deadline)
+	return results, partial, nil
+}
+
+// Lookup resolves ids in order; see lookupUsers.
+func (u *UserService) Lookup(ctx context.Context, ids []string) ([]UserLookupResult, error) {
+	return lookupUsers(ctx, u.store, ids)
+}
@@ -0,0 +1,2147 @@
+package server_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"maps"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/formats/cbor"
+	"gopkg.in/yaml.v3"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/apitest"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/captcha"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/email"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/idgen"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/lock"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/search"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/server"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+func TestListUsersHidesInactive(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+
+	s.Get("/v1/users").Do().
+		Status(http.StatusOK).
+		HasHeader("X-Total-Count", "2").
+		Field("users.0.id", apitest.AdaID).
+		Field("users.1.id", apitest.GraceID)
+
+	s.Get("/v1/users").Query("include_inactive", "true").Do().
+		Status(http.StatusOK).
+		HasHeader("X-Total-Count", "3")
+
+	s.Get("/v1/users").Query("tag", "beta").Do().
+		Status(http.StatusOK).
+		HasHeader("X-Total-Count", "1").
+		Field("tag_counts", map[string]int{"beta": 1})
+}
+
+func TestEmptyListsAreNotNull(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+	for path, list := range map[string]string{
+		"/v1/users?tag=none":    "users",
+		"/v1/posts":             "posts",
+		"/v1/invitations":       "invitations",
+		"/admin/dead-letters":   "dead_letters",
+		"/admin/ratelimits":     "rate_limits",
+		"/admin/traffic/blocks": "blocks",
+	} {
+		s.Get(path).AsAdmin().Do().Status(http.StatusOK).Field(list, []any{})
+	}
+	s.Get("/v1/users?tag=none").Do().Field("tag_counts", map[string]any{})
+
+	// Linus never saved preferences, so they are left out rather than null.
+	export := s.Get("/v1/users/"+apitest.LinusID+"/data-export").AsAdmin().Do().
+		Status(http.StatusOK).
+		Field("posts", []any{}).
+		Field("comments", []any{})
+	if bytes.Contains(export.Body, []byte("null")) {
+		t.Errorf("data export holds null: %s", export.Body)
+	}
+}
+
+func TestCreateUserRejectsTakenUsername(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+
+	s.Post("/v1/users", map[string]string{"name": "Ada", "email": "ada2@example.com", "username": "ADA"}).Do().
+		Status(http.StatusConflict).
+		Field("code", "USERNAME_TAKEN").
+		Field("detail", "username is already taken")
+
+	s.Post("/v1/users", map[string]string{"name": "Ada", "email": "ada2@example.com", "username": "admin"}).Do().
+		Status(http.StatusUnprocessableEntity).
+		Field("code", "VALIDATION_FAILED").
+		Field("errors.0.location", "body.username")
+}
+
+func TestEmailFolding(t *testing.T) {
+	s := apitest.New(t)
+	s.Post("/v1/users", map[string]string{"name": "Kim", "email": "Kim@Example.COM"}).Do().
+		Status(http.StatusCreated).
+		Field("email", "Kim@example.com").
+		Field("email_raw", "Kim@Example.COM")
+
+	s = apitest.New(t, apitest.WithConfig(server.Config{EmailFolding: server.EmailFolding{Gmail: true}}))
+	var user struct{ ID string }
+	s.Post("/v1/users", map[string]string{"name": "Ada", "email": "Ada.Lovelace+news@googlemail.com"}).Do().
+		Status(http.StatusCreated).
+		Field("email", "adalovelace@gmail.com").
+		Field("email_raw", "Ada.Lovelace+news@googlemail.com").
+		Decode(&user)
+	s.Post("/v1/invitations", map[string]string{"email": "ada.lovelace@gmail.com"}).AsAdmin().Do().
+		Status(http.StatusConflict)
+	var inv struct{ Token string }
+	s.Post("/v1/invitations", map[string]string{"email": "grace+work@example.com"}).AsAdmin().Do().
+		Status(http.StatusCreated).
+		Decode(&inv)
+
+	var raw map[string]any
+	s.Put("/v1/users/"+user.ID, map[string]string{"email": "adalovelace@gmail.com"}).Do().
+		Status(http.StatusOK).
+		Field("email", "adalovelace@gmail.com").
+		Decode(&raw)
+	if _, ok := raw["email_raw"]; ok {
+		t.Errorf("email_raw = %v for an email given in canonical form", raw["email_raw"])
+	}
+
+	// No two users have the same email once folded, however they came by it.
+	s.Post("/v1/users", map[string]string{"name": "Ada", "email": "ada.lovelace@gmail.com"}).Do().
+		Status(http.StatusConflict).
+		Field("code", "EMAIL_TAKEN")
+	var grace struct{ ID string }
+	s.Post("/v1/users", map[string]string{"name": "Grace", "email": "grace+work@example.com"}).Do().
+		Status(http.StatusCreated).
+		Decode(&grace)
+	s.Post("/v1/invitations/"+inv.Token+"/accept", map[string]string{"password": "correct horse", "name": "Grace"}).Do().
+		Status(http.StatusConflict).
+		Field("code", "EMAIL_TAKEN")
+	s.Put("/v1/users/"+grace.ID, map[string]string{"email": "AdaLovelace+work@gmail.com"}).Do().
+		Status(http.StatusConflict).
+		Field("code", "EMAIL_TAKEN")
+	s.Request(http.MethodPatch, "/v1/users/batch").Body(map[string]any{"updates": []map[string]any{
+		{"id": grace.ID, "changes": map[string]any{"email": "adalovelace@googlemail.com"}},
+	}}).Do().
+		Status(http.StatusOK).
+		Field("results.0.status", 409).
+		Field("results.0.error.code", "EMAIL_TAKEN")
+	// A user keeping their email isn't checked.
+	s.Put("/v1/users/"+grace.ID, map[string]string{"email": "grace+work@example.com", "name": "Grace Hopper"}).Do().
+		Status(http.StatusOK)
+}
+
+func TestDisposableEmails(t *testing.T) {
+	s := apitest.New(t)
+	s.Post("/v1/users", map[string]string{"name": "Kim", "email": "kim@mailinator.com"}).Do().
+		Status(http.StatusCreated)
+
+	s = apitest.New(t, apitest.WithUsers(apitest.Users()...), apitest.WithConfig(server.Config{BlockDisposableEmails: true}))
+	for _, email := range []string{"kim@mailinator.com", "kim@EU.Mailinator.com"} {
+		s.Post("/v1/users", map[string]string{"name": "Kim", "email": email}).Do().
+			Status(http.StatusUnprocessableEntity).
+			Field("errors.0.location", "body.email").
+			Field("errors.0.code", "disposable")
+	}
+	s.Put("/v1/users/"+apitest.AdaID, map[string]string{"email": "ada@yopmail.com"}).Do().
+		Status(http.StatusUnprocessableEntity).
+		Field("errors.0.code", "disposable")
+	s.Post("/v1/invitations", map[string]string{"email": "kim@guerrillamail.com"}).AsAdmin().Do().
+		Status(http.StatusUnprocessableEntity).
+		Field("errors.0.code", "disposable")
+	s.Post("/v1/users", map[string]string{"name": "Kim", "email": "kim@notmailinator.com"}).Do().
+		Status(http.StatusCreated)
+}
+
+func TestValidationReportsEveryProblem(t *testing.T) {
+	s := apitest.New(t)
+
+	s.Post("/v1/users", map[string]string{"email": "ada@example.com", "username": "A!", "phone": "12"}).Do().
+		Status(http.StatusUnprocessableEntity).
+		Field("errors.0.field", "name").
+		Field("errors.0.code", "required").
+		Field("errors.1.field", "phone").
+		Field("errors.1.code", "format").
+		Field("errors.2.field", "username").
+		Field("errors.2.code", "pattern")
+
+	// A value the schema rejects isn't reported again by the tag resolver.
+	s.Put("/v1/users/"+apitest.AdaID+"/tags", `{"tags":["Bad Tag!",3]}`).Do().
+		Status(http.StatusUnprocessableEntity).
+		Field("errors.0.location", "body.tags[1]").
+		Field("errors.0.code", "type").
+		Field("errors.1.location", "body.tags[0]").
+		Field("errors.1.code", "pattern")
+
+	s.Get("/v1/users").Query("per_page", "abc").Query("inactive_since", "soon").Do().
+		Status(http.StatusUnprocessableEntity).
+		Field("errors.0.field", "per_page").
+		Field("errors.1.field", "inactive_since")
+}
+
+func TestBodyShapeLimits(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+
+	ids := make([]string, 101)
+	for i := range ids {
+		ids[i] = apitest.AdaID
+	}
+	s.Post("/v1/users/lookup", map[string]any{"ids": ids}).Do().
+		Status(http.StatusUnprocessableEntity).
+		Field("errors.0.location", "body.ids").
+		Field("errors.0.code", "max_items").
+		Field("errors.0.message", "expected array length <= 100")
+
+	// Free-form values may nest ten levels below where the schema stops.
+	nested := func(levels int) map[string]any {
+		v := map[string]any{"leaf": true}
+		for range levels - 1 {
+			v = map[string]any{"a": v}
+		}
+		return v
+	}
+	user := map[string]any{"name": "Deep", "email": "deep@example.com", "metadata": map[string]any{"a": nested(10)}}
+	s.Post("/v1/users", user).Do().
+		Status(http.StatusUnprocessableEntity).
+		Field("errors.0.code", "max_depth").
+		Field("errors.0.message", "expected metadata nested at most 5 levels deep")
+	user["metadata"] = map[string]any{"a": nested(11)}
+	s.Post("/v1/users", user).Do().
+		Status(http.StatusUnprocessableEntity).
+		Field("errors.0.location", "body.metadata.a"+strings.Repeat(".a", 10)).
+		Field("errors.0.code", "max_depth").
+		Field("errors.0.message", "expected body nested at most 12 levels deep")
+
+	// Bodies that aren't JSON are left to huma.
+	s.Post("/v1/users/lookup", `{"ids": [`).Do().
+		Status(http.StatusBadRequest).
+		Field("errors.0.code", "malformed")
+}
+
+func TestEraseUserAnonymizesAudit(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+
+	s.Post("/v1/users/"+apitest.AdaID+"/deactivate", nil).Do().Status(http.StatusOK)
+	s.Get("/v1/users/"+apitest.AdaID+"/data-export").Do().
+		Status(http.StatusOK).
+		Field("user.id", apitest.AdaID).
+		Field("audit.0.type", "user.deactivated")
+
+	s.Delete("/v1/users/"+apitest.AdaID).Query("mode", "erase").Do().Status(http.StatusOK)
+	s.Get("/v1/users/" + apitest.AdaID + "/data-export").Do().Status(http.StatusNotFound)
+
+	for _, e := range s.API.Audit().Entries() {
+		if e.Subject == apitest.AdaID {
+			t.Errorf("audit entry %d (%s) still names the erased user", e.ID, e.Type)
+		}
+	}
+	entries := s.API.Audit().Entries()
+	if last := entries[len(entries)-1]; last.Type != "user.erased" || !strings.HasPrefix(last.Subject, "erased-") {
+		t.Errorf("last audit entry = %+v, want the erasure under a placeholder", last)
+	}
+}
+
+func TestRetentionPurgesDeletedUsers(t *testing.T) {
+	s := apitest.New(t,
+		apitest.WithConfig(server.Config{RetentionDeletedUsers: time.Millisecond}),
+		apitest.WithUsers(apitest.Users()...))
+
+	s.Post("/v1/users/"+apitest.AdaID+"/status", map[string]string{"status": "deleted"}).Do().Status(http.StatusOK)
+	time.Sleep(5 * time.Millisecond)
+
+	s.Post("/admin/retention", nil).Query("dry_run", "true").AsAdmin().Do().
+		Status(http.StatusOK).
+		Field("rules.0.rule", "deleted_users").
+		Field("rules.0.ids", []string{apitest.AdaID})
+	s.Get("/v1/users/" + apitest.AdaID).Do().Status(http.StatusOK)
+
+	s.Post("/admin/retention", nil).AsAdmin().Do().
+		Status(http.StatusOK).
+		Field("rules.0.purged", 1)
+	s.Get("/v1/users/" + apitest.AdaID).Do().Status(http.StatusNotFound)
+}
+
+func TestImpersonationIsAudited(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+
+	var tok struct{ Token string }
+	s.Post("/admin/impersonate/"+apitest.GraceID, map[string]string{"actor": "sam@support.example.com", "reason": "ticket 1234"}).AsAdmin().Do().
+		Status(http.StatusOK).
+		Field("impersonated_by", "sam@support.example.com").
+		Decode(&tok)
+
+	s.Get("/v1/me").Header("Authorization", "Bearer "+tok.Token).Do().
+		Status(http.StatusOK).
+		Field("user.id", apitest.GraceID).
+		Field("impersonated_by", "sam@support.example.com")
+	s.Get("/v1/me").Header("Authorization", "Bearer "+tok.Token+"x").Do().
+		Status(http.StatusUnauthorized).
+		Field("code", "INVALID_TOKEN")
+
+	var types []string
+	for _, e := range s.API.Audit().ForSubject(apitest.GraceID) {
+		types = append(types, e.Type)
+	}
+	if want := []string{"user.impersonated", "user.impersonated_request"}; !slices.Equal(types, want) {
+		t.Errorf("audit for the impersonated user = %v, want %v", types, want)
+	}
+}
+
+func TestFailedLoginsLockAccount(t *testing.T) {
+	s := apitest.New(t, apitest.WithConfig(server.Config{LoginMaxFailures: 3}))
+
+	var user struct{ ID string }
+	s.Post("/v1/users", map[string]string{"name": "Lin", "email": "lin@example.com", "username": "lin_p", "password": "correct horse"}).Do().
+		Status(http.StatusCreated).
+		Decode(&user)
+	wrong := map[string]string{"login": "lin_p", "password": "wrong horse"}
+	right := map[string]string{"login": "lin_p", "password": "correct horse"}
+
+	for range 3 {
+		s.Post("/v1/auth/login", wrong).Do().
+			Status(http.StatusUnauthorized).
+			Field("code", "INVALID_CREDENTIALS")
+	}
+	// Locked: even the right password is refused until an admin steps in.
+	s.Post("/v1/auth/login", right).Do().
+		Status(http.StatusLocked).
+		Field("code", "ACCOUNT_LOCKED").
+		HasHeader("Retry-After", "900")
+	s.Post("/admin/unlock/"+user.ID, nil).AsAdmin().Do().
+		Status(http.StatusOK).
+		Field("was_locked", true).
+		Field("failed_attempts", float64(3))
+	s.Post("/v1/auth/login", right).Do().
+		Status(http.StatusOK).
+		Field("user_id", user.ID)
+
+	var types []string
+	for _, e := range s.API.Audit().ForSubject(user.ID) {
+		types = append(types, e.Type)
+	}
+	want := []string{"user.created", "security.login_failed", "security.login_failed", "security.login_failed",
+		"security.account_locked", "security.account_unlocked", "user.logged_in"}
+	if !slices.Equal(types, want) {
+		t.Errorf("audit = %v, want %v", types, want)
+	}
+}
+
+func TestClientIPFromTrustedProxies(t *testing.T) {
+	failedFrom := func(proxies string, header, value string) any {
+		trusted, err := server.ParseTrustedProxies(proxies)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := apitest.New(t, apitest.WithConfig(server.Config{TrustedProxies: trusted}), apitest.WithUsers(apitest.Users()...))
+		s.Post("/v1/auth/login", map[string]string{"login": "nobody", "password": "wrong horse"}).Header(header, value).Do().
+			Status(http.StatusUnauthorized)
+		entries := s.API.Audit().ForSubject("")
+		return entries[len(entries)-1].Data.(map[string]any)["ip"]
+	}
+
+	// The test server's peer is the loopback address.
+	for _, tc := range []struct {
+		proxies, header, value string
+		want                   string
+	}{
+		{"", "X-Forwarded-For", "203.0.113.9", "127.0.0.1"},
+		{"10.0.0.0/8", "X-Forwarded-For", "203.0.113.9", "127.0.0.1"},
+		{"127.0.0.1", "X-Forwarded-For", "203.0.113.9", "203.0.113.9"},
+		// A spoofed first hop is ignored: 198.51.100.7 is the last address
+		// before the trusted ones.
+		{"127.0.0.0/8,10.0.0.0/8", "X-Forwarded-For", "1.2.3.4, 198.51.100.7, 10.1.2.3", "198.51.100.7"},
+		{"127.0.0.1", "Forwarded", `for="[2001:db8::1]:4711";proto=https`, "2001:db8::1"},
+	} {
+		if got := failedFrom(tc.proxies, tc.header, tc.value); got != tc.want {
+			t.Errorf("TRUSTED_PROXIES=%q, %s: %s: ip = %v, want %s", tc.proxies, tc.header, tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestIPAllowAndDenyLists(t *testing.T) {
+	office, _ := server.ParsePrefixes("198.51.100.0/24")
+	cfg := server.Config{IPAccess: server.IPAccess{Admin: server.IPRule{Allow: office}}}
+	s := apitest.New(t, apitest.WithConfig(cfg), apitest.WithUsers(apitest.Users()...))
+
+	// The test server's peer, the loopback address, is outside the office.
+	s.Get("/v1/users").Do().Status(http.StatusOK)
+	s.Get("/admin/maintenance").AsAdmin().Do().
+		Status(http.StatusForbidden).
+		Field("code", "IP_NOT_ALLOWED")
+
+	loopback, _ := server.ParsePrefixes("127.0.0.1")
+	cfg.IPAccess.Admin.Allow = append(office, loopback...)
+	cfg.IPAccess.All.Deny = loopback
+	s.API.Reload(cfg)
+	s.Get("/v1/users").Do().Status(http.StatusForbidden)
+	s.Get("/admin/maintenance").AsAdmin().Do().Status(http.StatusForbidden)
+	s.Get("/health").Do().Status(http.StatusOK)
+	s.Get("/readyz").Do().Status(http.StatusOK)
+}
+
+func TestCapturedRequestsAreRedacted(t *testing.T) {
+	s := apitest.New(t, apitest.WithConfig(server.Config{CaptureRequests: 2}), apitest.WithUsers(apitest.Users()...))
+
+	s.Get("/v1/users").Do().Status(http.StatusOK)
+	s.Get("/v1/users/missing").Do().Status(http.StatusNotFound)
+	s.Post("/v1/auth/login", map[string]string{"login": "ada", "password": "hunter2 hunter2"}).Do().
+		Status(http.StatusUnauthorized)
+
+	var list struct {
+		Capacity int
+		Requests []struct {
+			Path           string
+			Status         int
+			RequestHeaders map[string]string `json:"request_headers"`
+			RequestBody    string            `json:"request_body"`
+		}
+	}
+	s.Get("/admin/requests").AsAdmin().Do().Status(http.StatusOK).Decode(&list)
+	if len(list.Requests) != 2 || list.Requests[0].Path != "/v1/auth/login" || list.Requests[1].Status != http.StatusNotFound {
+		t.Fatalf("captured %+v, want the login and the 404, newest first", list.Requests)
+	}
+	if body := list.Requests[0].RequestBody; strings.Contains(body, "hunter2") || !strings.Contains(body, `"password":"REDACTED"`) {
+		t.Errorf("captured login body %s, want the password redacted", body)
+	}
+
+	s.Get("/admin/requests").AsAdmin().Do().Status(http.StatusOK)
+	s.Get("/v1/users").Header("Authorization", "Bearer secret").Do()
+	s.Get("/admin/requests?limit=1").AsAdmin().Do().Status(http.StatusOK).Decode(&list)
+	if got := list.Requests[0].RequestHeaders["Authorization"]; got != "REDACTED" {
+		t.Errorf("captured Authorization %q, want REDACTED", got)
+	}
+}
+
+func TestUserChangesLongPoll(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+	var feed struct {
+		Changes []struct {
+			Seq    int64
+			Type   string
+			UserID string `json:"user_id"`
+		}
+		NextSince int64 `json:"next_since"`
+	}
+	s.Get("/v1/users/changes").Do().Status(http.StatusOK).Decode(&feed)
+	if len(feed.Changes) != 0 {
+		t.Fatalf("since=-1 returned %+v, want no changes", feed.Changes)
+	}
+	since := strconv.FormatInt(feed.NextSince, 10)
+
+	time.AfterFunc(50*time.Millisecond, func() {
+		req, _ := http.NewRequest(http.MethodPut, s.URL+"/v1/users/"+apitest.AdaID, strings.NewReader(`{"name":"Ada King"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if resp, err := http.DefaultClient.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	})
+	s.Get("/v1/users/changes").Query("since", since).Query("wait", "5s").Do().Status(http.StatusOK).Decode(&feed)
+	if len(feed.Changes) != 1 || feed.Changes[0].Type != "user.updated" || feed.Changes[0].UserID != apitest.AdaID {
+		t.Fatalf("long poll returned %+v, want Ada's update", feed.Changes)
+	}
+	if feed.NextSince != feed.Changes[0].Seq {
+		t.Errorf("next_since = %d, want the change's seq %d", feed.NextSince, feed.Changes[0].Seq)
+	}
+
+	since = strconv.FormatInt(feed.NextSince, 10)
+	s.Get("/v1/users/changes").Query("since", since).Query("wait", "10ms").Do().
+		Status(http.StatusOK).
+		Field("changes", []any{}).
+		Field("next_since", feed.NextSince)
+	s.Get("/v1/users/changes").Query("since", strconv.FormatInt(feed.NextSince+100, 10)).Do().
+		Status(http.StatusGone).
+		Field("code", "CHANGES_EXPIRED")
+	s.Get("/v1/users/changes").Query("wait", "1m").Do().
+		Status(http.StatusUnprocessableEntity)
+}
+
+func TestStreamUsers(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+	resp, err := http.Get(s.URL + "/v1/users/stream?include_inactive=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q, want application/x-ndjson", ct)
+	}
+	var ids []string
+	lines := bufio.NewScanner(resp.Body)
+	for lines.Scan() {
+		var u struct{ ID string }
+		if err := json.Unmarshal(lines.Bytes(), &u); err != nil {
+			t.Fatalf("line %q: %v", lines.Text(), err)
+		}
+		ids = append(ids, u.ID)
+	}
+	if want := []string{apitest.AdaID, apitest.GraceID, apitest.LinusID}; !slices.Equal(ids, want) {
+		t.Errorf("streamed %v, want %v", ids, want)
+	}
+
+	body := s.Get("/v1/users/stream").Query("tag", "beta").Do().Status(http.StatusOK).Body
+	if got := strings.Count(string(body), "\n"); got != 1 || !strings.Contains(string(body), apitest.GraceID) {
+		t.Errorf("tag=beta streamed %s, want only Grace", body)
+	}
+	s.Get("/v1/users/stream").Query("metadata.plan", "pro").Query("metadata.plan", "free").Do().
+		Status(http.StatusUnprocessableEntity)
+}
+
+func TestBatchUpdateReportsEachEntry(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+	var batch struct {
+		Results []struct {
+			ID     string
+			Status int
+			User   *struct{ Name string }
+			Error  *struct {
+				Code   string
+				Errors []struct{ Location string }
+			}
+		}
+		Updated, Failed int
+	}
+	s.Request(http.MethodPatch, "/v1/users/batch").Body(map[string]any{"updates": []map[string]any{
+		{"id": apitest.AdaID, "changes": map[string]any{"name": "Ada King"}},
+		{"id": apitest.GraceID, "changes": map[string]any{"email": "not an email"}},
+		{"id": "missing", "changes": map[string]any{"name": "Nobody"}},
+		{"id": apitest.GraceID, "changes": map[string]any{"username": "ada"}},
+	}}).Do().Status(http.StatusOK).Decode(&batch)
+
+	if batch.Updated != 1 || batch.Failed != 3 || len(batch.Results) != 4 {
+		t.Fatalf("got %+v, want 1 of 4 entries updated", batch)
+	}
+	if r := batch.Results[0]; r.Status != http.StatusOK || r.User == nil || r.User.Name != "Ada King" {
+		t.Errorf("first entry %+v, want Ada renamed", r)
+	}
+	if r := batch.Results[1]; r.Status != http.StatusUnprocessableEntity || r.Error == nil ||
+		len(r.Error.Errors) != 1 || r.Error.Errors[0].Location != "body.updates[1].changes.email" {
+		t.Errorf("second entry %+v, want a 422 for its email", r)
+	}
+	if r := batch.Results[2]; r.Status != http.StatusNotFound || r.Error.Code != "USER_NOT_FOUND" {
+		t.Errorf("third entry %+v, want USER_NOT_FOUND", r)
+	}
+	if r := batch.Results[3]; r.Status != http.StatusConflict || r.Error.Code != "USERNAME_TAKEN" {
+		t.Errorf("fourth entry %+v, want USERNAME_TAKEN", r)
+	}
+	s.Get("/v1/users/"+apitest.GraceID).Do().Status(http.StatusOK).Field("email", "grace@example.com")
+}
+
+func TestGzippedBodies(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+	gzipped := func(body string) []byte {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write([]byte(body))
+		zw.Close()
+		return buf.Bytes()
+	}
+	update := `{"updates":[{"id":"` + apitest.AdaID + `","changes":{"name":"Ada King"}}]}`
+
+	s.Request(http.MethodPatch, "/v1/users/batch").Body(gzipped(update)).Header("Content-Encoding", "gzip").Do().
+		Status(http.StatusOK).
+		Field("updated", 1)
+	s.Get("/v1/users/"+apitest.AdaID).Do().Status(http.StatusOK).Field("name", "Ada King")
+
+	// Two megabytes of whitespace compress to a few kilobytes, but inflate
+	// past the 1 MB limit.
+	bomb := gzipped(update[:len(update)-1] + strings.Repeat(" ", 2<<20) + "}")
+	if len(bomb) > 16<<10 {
+		t.Fatalf("bomb is %d bytes compressed", len(bomb))
+	}
+	s.Request(http.MethodPatch, "/v1/users/batch").Body(bomb).Header("Content-Encoding", "gzip").Do().
+		Status(http.StatusRequestEntityTooLarge).
+		Field("code", "REQUEST_TOO_LARGE")
+	s.Request(http.MethodPost, "/v1/batch").Body(update).Header("Content-Encoding", "gzip").Do().
+		Status(http.StatusBadRequest)
+	s.Request(http.MethodPost, "/v1/batch").Body(update).Header("Content-Encoding", "br").Do().
+		Status(http.StatusUnsupportedMediaType).
+		Field("code", "UNSUPPORTED_MEDIA_TYPE")
+}
+
+func TestUnknownFieldsSurviveRoundTrip(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+	// A client PUTs back the user as a newer version sent it.
+	s.Put("/v1/users/"+apitest.AdaID, map[string]any{
+		"$schema":  "http://localhost/schemas/User.json",
+		"id":       apitest.AdaID,
+		"name":     "Ada King",
+		"pronouns": "she/her",
+		"address":  map[string]string{"city": "London"},
+		"password": "not-kept",
+	}).Do().
+		Status(http.StatusOK).
+		Field("name", "Ada King").
+		Field("pronouns", "she/her")
+
+	var user map[string]any
+	s.Get("/v1/users/" + apitest.AdaID).Do().Status(http.StatusOK).Decode(&user)
+	if user["pronouns"] != "she/her" || user["address"] == nil {
+		t.Errorf("user = %v, want the unknown fields kept", user)
+	}
+	if _, ok := user["password"]; ok {
+		t.Error("user kept the password sent with it")
+	}
+
+	// Fields left out stay, and null removes one.
+	s.Put("/v1/users/"+apitest.AdaID, map[string]any{"address": nil}).Do().
+		Status(http.StatusOK).
+		Field("pronouns", "she/her")
+	user = nil
+	s.Get("/v1/users/" + apitest.AdaID).Do().Status(http.StatusOK).Decode(&user)
+	if _, ok := user["address"]; ok {
+		t.Errorf("address = %v after setting it to null", user["address"])
+	}
+
+	s.Put("/v1/users/"+apitest.AdaID, map[string]any{"bio": strings.Repeat("x", 9<<10)}).Do().
+		Status(http.StatusUnprocessableEntity).
+		Field("errors.0.code", "max_size")
+}
+
+func TestTransactionIsAllOrNothing(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+	var tx struct {
+		Committed bool
+		Results   []struct {
+			Outcome string
+			Status  int
+			ID      string
+			Error   *struct{ Code string }
+		}
+	}
+	s.Post("/v1/batch", map[string]any{"operations": []map[string]any{
+		{"op": "create", "resource": "user", "body": map[string]any{"name": "Lin", "email": "lin@example.com", "username": "lin"}},
+		{"op": "update", "resource": "user", "id": apitest.AdaID, "body": map[string]any{"username": "lin"}},
+		{"op": "delete", "resource": "user", "id": apitest.GraceID},
+	}}).Do().Status(http.StatusOK).Decode(&tx)
+	if tx.Committed || len(tx.Results) != 3 {
+		t.Fatalf("got %+v, want the transaction rolled back", tx)
+	}
+	if r := tx.Results[0]; r.Outcome != "rolled_back" || r.ID != "" {
+		t.Errorf("create %+v, want rolled_back without an ID", r)
+	}
+	if r := tx.Results[1]; r.Outcome != "failed" || r.Status != http.StatusConflict || r.Error == nil || r.Error.Code != "USERNAME_TAKEN" {
+		t.Errorf("update %+v, want it to fail on the new user's username", r)
+	}
+	if r := tx.Results[2]; r.Outcome != "skipped" || r.Status != 0 {
+		t.Errorf("delete %+v, want skipped", r)
+	}
+	s.Get("/v1/users").Do().Status(http.StatusOK).HasHeader("X-Total-Count", "2")
+	s.Get("/v1/users/" + apitest.GraceID).Do().Status(http.StatusOK)
+
+	s.Post("/v1/batch", map[string]any{"operations": []map[string]any{
+		{"op": "create", "resource": "user", "body": map[string]any{"name": "Lin", "email": "lin@example.com"}},
+		{"op": "create", "resource": "user", "body": map[string]any{"name": "Max", "email": "max@example.com"}},
+		{"op": "update", "resource": "user", "id": "$1", "body": map[string]any{"name": "Max Mustermann"}},
+		{"op": "delete", "resource": "user", "id": apitest.GraceID},
+	}}).Do().Status(http.StatusOK).Decode(&tx)
+	if !tx.Committed || tx.Results[0].ID == tx.Results[1].ID || tx.Results[2].ID != tx.Results[1].ID {
+		t.Fatalf("got %+v, want both users created and the second renamed", tx)
+	}
+	s.Get("/v1/users/"+tx.Results[1].ID).Do().Status(http.StatusOK).Field("name", "Max Mustermann")
+	s.Get("/v1/users/" + apitest.GraceID).Do().Status(http.StatusNotFound)
+	if got := s.API.Audit().ForSubject(tx.Results[1].ID); len(got) != 2 {
+		t.Errorf("audit has %d entries for the new user, want its creation and update", len(got))
+	}
+}
+
+func TestPostsGoWithTheirAuthor(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+	var post struct{ ID string }
+	s.Post("/v1/users/"+apitest.AdaID+"/posts", map[string]string{"title": "Notes", "body": "On engines."}).Do().
+		Status(http.StatusCreated).
+		Field("author_id", apitest.AdaID).
+		Decode(&post)
+	s.Post("/v1/posts", map[string]string{"author_id": apitest.GraceID, "title": "Compilers", "body": ""}).Do().Status(http.StatusCreated)
+	s.Post("/v1/posts", map[string]string{"author_id": "missing", "title": "Lost", "body": ""}).Do().Status(http.StatusNotFound)
+
+	s.Get("/v1/users/"+apitest.AdaID).Query("include", "post_count").Do().Status(http.StatusOK).Field("post_count", 1)
+	s.Get("/v1/users").Query("include", "post_count").Do().Status(http.StatusOK).Field("users.1.post_count", 1)
+	s.Get("/v1/posts").Do().Status(http.StatusOK).HasHeader("X-Total-Count", "2")
+
+	s.Delete("/v1/users/" + apitest.AdaID).Do().Status(http.StatusOK)
+	s.Get("/v1/posts/"+post.ID).Do().Status(http.StatusNotFound).Field("code", "POST_NOT_FOUND")
+	s.Get("/v1/posts").Do().Status(http.StatusOK).HasHeader("X-Total-Count", "1").Field("posts.0.author_id", apitest.GraceID)
+}
+
+func TestCommentThreads(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+	var post, top, reply struct{ ID string }
+	s.Post("/v1/posts", map[string]string{"author_id": apitest.GraceID, "title": "Compilers", "body": ""}).Do().
+		Status(http.StatusCreated).
+		Decode(&post)
+	comments := "/v1/posts/" + post.ID + "/comments"
+	s.Post(comments, map[string]string{"author_id": apitest.AdaID, "body": "Nice notes."}).Do().
+		Status(http.StatusCreated).
+		Field("status", "published").
+		Decode(&top)
+	s.Post(comments, map[string]string{"author_id": apitest.GraceID, "parent_id": top.ID, "body": "Thanks!"}).Do().
+		Status(http.StatusCreated).
+		Field("parent_id", top.ID).
+		Decode(&reply)
+	s.Post(comments, map[string]string{"author_id": apitest.GraceID, "body": "Errata below."}).Do().Status(http.StatusCreated)
+
+	s.Get(comments).Do().Status(http.StatusOK).
+		HasHeader("X-Total-Count", "2").
+		Field("comments.0.reply_count", 1)
+	s.Get(comments).Query("parent_id", top.ID).Do().Status(http.StatusOK).Field("comments.0.id", reply.ID)
+
+	s.Post(comments+"/"+reply.ID+"/status", map[string]string{"status": "hidden"}).AsAdmin().Do().Status(http.StatusOK)
+	s.Get(comments+"/"+top.ID).Do().Status(http.StatusOK).Field("reply_count", 0)
+	s.Get(comments).Query("include_hidden", "true").Do().Status(http.StatusOK).Field("comments.0.reply_count", 1)
+
+	// Deleting Ada takes their comment and the replies to it along.
+	s.Delete("/v1/users/" + apitest.AdaID).Do().Status(http.StatusOK)
+	s.Get(comments+"/"+reply.ID).Do().Status(http.StatusNotFound).Field("code", "COMMENT_NOT_FOUND")
+	s.Get(comments).Query("include_hidden", "true").Do().Status(http.StatusOK).HasHeader("X-Total-Count", "1")
+}
+
+func TestNotifications(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+	tokenFor := func(id string) string {
+		var tok struct{ Token string }
+		s.Post("/admin/impersonate/"+id, map[string]string{"actor": "sam@support.example.com", "reason": "test"}).AsAdmin().Do().
+			Status(http.StatusOK).
+			Decode(&tok)
+		return "Bearer " + tok.Token
+	}
+	var post, comment struct{ ID string }
+	s.Post("/v1/posts", map[string]string{"author_id": apitest.GraceID, "title": "Compilers", "body": ""}).Do().
+		Status(http.StatusCreated).
+		Decode(&post)
+	comments := "/v1/posts/" + post.ID + "/comments"
+	s.Post(comments, map[string]string{"author_id": apitest.AdaID, "body": "Nice, @Linus should read this."}).Do().
+		Status(http.StatusCreated).
+		Decode(&comment)
+	s.Post(comments, map[string]string{"author_id": apitest.GraceID, "parent_id": comment.ID, "body": "Thanks @ada!"}).Do().
+		Status(http.StatusCreated)
+
+	grace := tokenFor(apitest.GraceID)
+	var list struct{ Notifications []struct{ ID string } }
+	s.Get("/v1/notifications").Header("Authorization", grace).Do().
+		Status(http.StatusOK).
+		Field("unread_count", 1).
+		Field("notifications.0.type", "comment").
+		Field("notifications.0.actor_id", apitest.AdaID).
+		Decode(&list)
+	s.Get("/v1/notifications").Header("Authorization", tokenFor(apitest.LinusID)).Do().
+		Status(http.StatusOK).
+		Field("notifications.0.type", "mention")
+	// Mentioned and replied to at once, Ada hears about it once.
+	s.Get("/v1/notifications").Header("Authorization", tokenFor(apitest.AdaID)).Do().
+		Status(http.StatusOK).
+		HasHeader("X-Total-Count", "1").
+		Field("notifications.0.type", "reply")
+
+	s.Post("/v1/notifications/"+list.Notifications[0].ID+"/read", nil).Header("Authorization", grace).Do().Status(http.StatusOK)
+	s.Get("/v1/notifications").Query("unread", "true").Header("Authorization", grace).Do().
+		Status(http.StatusOK).
+		Field("unread_count", 0).
+		HasHeader("X-Total-Count", "0")
+	s.Post("/v1/notifications/"+list.Notifications[0].ID+"/read", nil).Header("Authorization", tokenFor(apitest.AdaID)).Do().
+		Status(http.StatusNotFound).
+		Field("code", "NOTIFICATION_NOT_FOUND")
+}
+
+func TestSearch(t *testing.T) {
+	if !search.BleveBuilt {
+		t.Skip("built without Bleve")
+	}
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+	var post struct{ ID string }
+	s.Post("/v1/posts", map[string]string{"author_id": apitest.AdaID, "title": "Notes on the engine", "body": "Grace & I <3 compilers."}).Do().
+		Status(http.StatusCreated).
+		Decode(&post)
+
+	// The fixtures were indexed on startup, the post when it was created.
+	var found struct {
+		Results []struct {
+			Type       string
+			ID         string
+			Highlights map[string][]string
+		}
+	}
+	s.Get("/v1/search").Query("q", "grace").Do().
+		Status(http.StatusOK).
+		Decode(&found)
+	if len(found.Results) != 2 || found.Results[0].ID != apitest.GraceID {
+		t.Fatalf("results = %+v, want Grace and then the post", found.Results)
+	}
+	if got := found.Results[1].Highlights["body"]; len(got) != 1 || got[0] != "<mark>Grace</mark> &amp; I &lt;3 compilers." {
+		t.Errorf("body highlights = %q", got)
+	}
+	s.Get("/v1/search").Query("q", "grace").Query("type", "post").Do().
+		Status(http.StatusOK).
+		Field("results.0.type", "post").
+		Field("results.0.post.title", "Notes on the engine")
+	// Linus is suspended.
+	s.Get("/v1/search").Query("q", "linus").Do().Status(http.StatusOK).Field("results", []any{})
+
+	s.Put("/v1/posts/"+post.ID, map[string]string{"title": "Difference engine"}).Do().Status(http.StatusOK)
+	s.Get("/v1/search").Query("q", "difference").Do().Status(http.StatusOK).Field("results.0.id", post.ID)
+	s.Put("/v1/users/"+apitest.GraceID, map[string]string{"name": "Amazing Grace"}).Do().Status(http.StatusOK)
+	s.Get("/v1/search").Query("q", "amazing").Do().Status(http.StatusOK).Field("results.0.user.name", "Amazing Grace")
+
+	// Erasing Ada takes their post out of the index too.
+	s.Delete("/v1/users/"+apitest.AdaID).Query("mode", "erase").Do().Status(http.StatusOK)
+	s.Get("/v1/search").Query("q", "engine lovelace").Do().Status(http.StatusOK).Field("results", []any{})
+}
+
+func TestEmailPreview(t *testing.T) {
+	s := apitest.New(t, apitest.WithConfig(server.Config{Dev: true}))
+	resp := s.Get("/dev/emails/reset").Query("lang", "fr-CA").Query("format", "text").Do().
+		Status(http.StatusOK).
+		HasHeader("Content-Type", "text/plain; charset=utf-8")
+	if !strings.Contains(string(resp.Body), "Bonjour Ada Lovelace") {
+		t.Errorf("preview = %s, want the French text part", resp.Body)
+	}
+	s.Get("/dev/emails/digest").Do().
+		Status(http.StatusOK).
+		HasHeader("Content-Type", "text/html; charset=utf-8").
+		HasHeader("X-Email-Subject", "Your weekly activity digest")
+
+	apitest.New(t).Get("/dev/emails/reset").Do().Status(http.StatusNotFound)
+}
+
+// sentMail records the emails a test server sends.
+type sentMail struct {
+	to   []string
+	msgs []*email.Message
+}
+
+func (m *sentMail) Send(ctx context.Context, to string, msg *email.Message) error {
+	m.to, m.msgs = append(m.to, to), append(m.msgs, msg)
+	return nil
+}
+
+func TestDigests(t *testing.T) {
+	mail := &sentMail{}
+	s := apitest.New(t, apitest.WithConfig(server.Config{Mailer: mail, AppURL: "https://app.example.com"}), apitest.WithUsers(apitest.Users()...))
+	s.Put("/v1/users/"+apitest.GraceID+"/preferences", map[string]any{"locale": "de-AT", "timezone": "Pacific/Kiritimati", "notifications": map[string]string{"digest": "daily"}}).Do().
+		Status(http.StatusOK)
+	s.Post("/v1/users", map[string]string{"name": "Lin", "email": "lin@example.com"}).Do().Status(http.StatusCreated)
+	s.Put("/v1/users/"+apitest.AdaID, map[string]string{"name": "Ada King"}).Do().Status(http.StatusOK)
+
+	s.Post("/admin/digest", nil).Query("period", "daily").AsAdmin().Do().
+		Status(http.StatusOK).
+		Field("sent", 1).
+		Field("new_users", 1).
+		Field("updated_users", 1)
+	if len(mail.msgs) != 1 || mail.to[0] != "grace@example.com" || mail.msgs[0].Subject != "Deine tägliche Aktivitätsübersicht" {
+		t.Fatalf("sent %v %+v, want Grace's digest in German", mail.to, mail.msgs)
+	}
+	if !strings.Contains(mail.msgs[0].Text, "https://app.example.com/settings/notifications") {
+		t.Errorf("digest lacks the preferences link:\n%s", mail.msgs[0].Text)
+	}
+	// The date is in Grace's time zone, 14 hours ahead of UTC.
+	kiritimati, _ := time.LoadLocation("Pacific/Kiritimati")
+	if since := time.Now().AddDate(0, 0, -1).In(kiritimati).Format("02.01.2006"); !strings.Contains(mail.msgs[0].Text, "seit dem "+since) {
+		t.Errorf("digest isn't since %s in Grace's time zone:\n%s", since, mail.msgs[0].Text)
+	}
+
+	// Ada gets the weekly digest by default; Linus is suspended.
+	s.Post("/admin/digest", nil).Query("period", "weekly").Query("dry_run", "true").AsAdmin().Do().
+		Status(http.StatusOK).
+		Field("sent", 2)
+	if len(mail.msgs) != 1 {
+		t.Errorf("a dry run sent %d emails", len(mail.msgs)-1)
+	}
+}
+
+func TestInvitations(t *testing.T) {
+	mail := &sentMail{}
+	s := apitest.New(t, apitest.WithConfig(server.Config{Mailer: mail, AppURL: "https://app.example.com"}), apitest.WithUsers(apitest.Users()...))
+
+	s.Post("/v1/invitations", map[string]string{"email": "ada@example.com"}).AsAdmin().Do().Status(http.StatusConflict)
+	var inv struct {
+		ID, Token, Status string
+		Emailed           bool
+	}
+	s.Post("/v1/invitations", map[string]string{"email": "kim@example.com", "name": "Kim", "locale": "fr"}).AsAdmin().Do().
+		Status(http.StatusCreated).
+		Decode(&inv)
+	if inv.Status != "pending" || !inv.Emailed || inv.Token == "" {
+		t.Fatalf("created %+v", inv)
+	}
+	if len(mail.msgs) != 1 || mail.to[0] != "kim@example.com" || !strings.Contains(mail.msgs[0].Text, "https://app.example.com/invitations/accept?token="+inv.Token) {
+		t.Fatalf("sent %v %+v, want Kim's invitation with the link", mail.to, mail.msgs)
+	}
+	if mail.msgs[0].Subject != "Vous êtes invité sur Monorepo Demo" {
+		t.Errorf("subject %q, want the French one", mail.msgs[0].Subject)
+	}
+	s.Post("/v1/invitations", map[string]string{"email": "KIM@example.com"}).AsAdmin().Do().Status(http.StatusConflict)
+	s.Get("/v1/invitations").Query("status", "pending").AsAdmin().Do().
+		Status(http.StatusOK).
+		Field("invitations.0.id", inv.ID).
+		Field("invitations.0.email", "kim@example.com")
+
+	var user struct{ ID, Name, Email string }
+	s.Post("/v1/invitations/"+inv.Token+"/accept", map[string]string{"password": "correct horse"}).Do().
+		Status(http.StatusCreated).
+		Decode(&user)
+	if user.Name != "Kim" || user.Email != "kim@example.com" {
+		t.Fatalf("accepted as %+v", user)
+	}
+	s.Post("/v1/auth/login", map[string]string{"login": "kim@example.com", "password": "correct horse"}).Do().Status(http.StatusOK)
+	s.Post("/v1/invitations/"+inv.Token+"/accept", map[string]string{"password": "correct horse"}).Do().Status(http.StatusConflict)
+	s.Delete("/v1/invitations/" + inv.ID).AsAdmin().Do().Status(http.StatusConflict)
+	s.Get("/v1/invitations").Query("status", "accepted").AsAdmin().Do().
+		Status(http.StatusOK).
+		Field("invitations.0.user_id", user.ID)
+	if !slices.ContainsFunc(s.API.Audit().ForSubject(user.ID), func(e server.AuditEntry) bool { return e.Type == server.EventInvitationAccepted }) {
+		t.Error("invitation.accepted not recorded")
+	}
+
+	// A revoked invitation's token stops working.
+	s.Post("/v1/invitations", map[string]string{"email": "lee@example.com", "name": "Lee"}).AsAdmin().Do().
+		Status(http.StatusCreated).
+		Decode(&inv)
+	s.Delete("/v1/invitations/" + inv.ID).AsAdmin().Do().Status(http.StatusNoContent)
+	s.Post("/v1/invitations/"+inv.Token+"/accept", map[string]string{"password": "correct horse"}).Do().Status(http.StatusNotFound)
+	s.Get("/v1/invitations").Query("status", "revoked").AsAdmin().Do().
+		Status(http.StatusOK).
+		Field("invitations.0.id", inv.ID)
+
+	// Expired ones can't be accepted either.
+	s.Post("/v1/invitations", map[string]any{"email": "mo@example.com", "name": "Mo", "expires_at": time.Now().Add(time.Second).Format(time.RFC3339Nano)}).AsAdmin().Do().
+		Status(http.StatusCreated).
+		Decode(&inv)
+	time.Sleep(time.Second)
+	s.Post("/v1/invitations/"+inv.Token+"/accept", map[string]string{"password": "correct horse"}).Do().
+		Status(http.StatusGone).
+		Field("code", "INVITATION_EXPIRED")
+
+	// Invitations are kept in the store, and so in its backups: a pending
+	// one can be accepted on a server restored from one.
+	s.Post("/v1/invitations", map[string]string{"email": "noa@example.com", "name": "Noa"}).AsAdmin().Do().
+		Status(http.StatusCreated).
+		Decode(&inv)
+	var backup bytes.Buffer
+	if err := server.ExportStore(context.Background(), s.Store, &backup); err != nil {
+		t.Fatal(err)
+	}
+	restored := apitest.New(t)
+	if _, err := server.ImportStore(context.Background(), restored.Store, &backup); err != nil {
+		t.Fatal(err)
+	}
+	restored.Get("/v1/invitations").AsAdmin().Do().
+		Status(http.StatusOK).
+		Field("invitations.0.id", inv.ID).
+		Field("invitations.3.status", "accepted")
+	restored.Post("/v1/invitations/"+inv.Token+"/accept", map[string]string{"password": "correct horse"}).Do().
+		Status(http.StatusCreated).
+		Field("email", "noa@example.com")
+}
+
+func TestExports(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+
+	// wait polls the export on s until it finished.
+	type export struct {
+		ID, Status, Error string
+		Rows              int
+		DownloadURL       string `json:"download_url"`
+	}
+	wait := func(s *apitest.Server, id string) export {
+		t.Helper()
+		var exp export
+		for range 100 {
+			s.Get("/v1/exports/" + id).AsAdmin().Do().Status(http.StatusOK).Decode(&exp)
+			if exp.Status == "completed" || exp.Status == "failed" {
+				return exp
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("export %s still %s", id, exp.Status)
+		return exp
+	}
+
+	var exp export
+	s.Post("/v1/exports", map[string]any{"format": "csv", "filters": map[string]any{"include_inactive": true}}).AsAdmin().Do().
+		Status(http.StatusAccepted).
+		Decode(&exp)
+	if exp.DownloadURL != "" {
+		t.Errorf("a pending export has a download URL")
+	}
+	exp = wait(s, exp.ID)
+	if exp.Status != "completed" || exp.Rows != 3 {
+		t.Fatalf("export %+v, want 3 rows", exp)
+	}
+	resp := s.Get(exp.DownloadURL).Do().
+		Status(http.StatusOK).
+		HasHeader("Content-Type", "text/csv")
+	rows, err := csv.NewReader(bytes.NewReader(resp.Body)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 || rows[0][0] != "id" || rows[1][0] != apitest.AdaID || rows[2][6] != "beta" {
+		t.Errorf("exported %q", rows)
+	}
+
+	// The link only works as it was signed.
+	s.Get(strings.Replace(exp.DownloadURL, "expires=", "expires=1", 1)).Do().
+		Status(http.StatusForbidden).
+		Field("code", "INVALID_SIGNATURE")
+	s.Get(strings.Replace(exp.DownloadURL, exp.ID, "exp_other", 1)).Do().Status(http.StatusForbidden)
+
+	// The filters are those of get-v1-users.
+	s.Post("/v1/exports", map[string]any{"filters": map[string]any{"tag": []string{"beta"}}}).AsAdmin().Do().
+		Status(http.StatusAccepted).
+		Decode(&exp)
+	exp = wait(s, exp.ID)
+	var user struct{ ID string }
+	if err := json.Unmarshal(s.Get(exp.DownloadURL).Do().Status(http.StatusOK).Body, &user); err != nil || exp.Rows != 1 || user.ID != apitest.GraceID {
+		t.Errorf("exported %+v %+v, want Grace as NDJSON", exp, user)
+	}
+
+	// Replicas sharing URL_SIGNING_KEY accept each other's links, though
+	// only the one that built an export has its file.
+	key := bytes.Repeat([]byte{7}, 32)
+	a := apitest.New(t, apitest.WithConfig(server.Config{URLSigningKey: key}))
+	b := apitest.New(t, apitest.WithConfig(server.Config{URLSigningKey: key}))
+	a.Post("/v1/exports", map[string]any{}).AsAdmin().Do().
+		Status(http.StatusAccepted).
+		Decode(&exp)
+	exp = wait(a, exp.ID)
+	b.Get(exp.DownloadURL).Do().
+		Status(http.StatusNotFound).
+		Field("code", "EXPORT_NOT_FOUND")
+	s.Get(exp.DownloadURL).Do().Status(http.StatusForbidden)
+}
+
+func TestExportTimeZone(t *testing.T) {
+	users := apitest.Users()
+	seen := timestamp.From(time.Date(2024, 1, 1, 23, 30, 0, 0, time.UTC))
+	users[0].LastSeenAt = &seen
+	s := apitest.New(t, apitest.WithUsers(users...))
+
+	export := func(req *apitest.Request) (string, []string) {
+		t.Helper()
+		var exp struct {
+			ID, Timezone string
+			Status       string
+			DownloadURL  string `json:"download_url"`
+		}
+		req.AsAdmin().Do().Status(http.StatusAccepted).Decode(&exp)
+		for exp.Status != "completed" {
+			if exp.Status == "failed" {
+				t.Fatalf("export %s failed", exp.ID)
+			}
+			time.Sleep(10 * time.Millisecond)
+			s.Get("/v1/exports/" + exp.ID).AsAdmin().Do().Status(http.StatusOK).Decode(&exp)
+		}
+		rows, err := csv.NewReader(bytes.NewReader(s.Get(exp.DownloadURL).Do().Status(http.StatusOK).Body)).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return exp.Timezone, rows[1]
+	}
+	body := map[string]any{"format": "csv"}
+
+	// UTC unless the request says otherwise.
+	if tz, ada := export(s.Post("/v1/exports", body)); tz != "UTC" || ada[8] != "2024-01-01T23:30:00Z" {
+		t.Errorf("exported in %s, last seen %s", tz, ada[8])
+	}
+	if tz, ada := export(s.Post("/v1/exports", body).Header("Time-Zone", "Europe/Vienna")); tz != "Europe/Vienna" || ada[8] != "2024-01-02T00:30:00+01:00" {
+		t.Errorf("exported in %s, last seen %s, want Vienna's time", tz, ada[8])
+	}
+	// The query parameter overrides the header.
+	if tz, ada := export(s.Post("/v1/exports", body).Header("Time-Zone", "Europe/Vienna").Query("tz", "America/New_York")); tz != "America/New_York" || ada[8] != "2024-01-01T18:30:00-05:00" {
+		t.Errorf("exported in %s, last seen %s, want New York's time", tz, ada[8])
+	}
+	// A header that isn't a time zone is ignored; a query parameter is not.
+	if tz, _ := export(s.Post("/v1/exports", body).Header("Time-Zone", "Mars/Olympus")); tz != "UTC" {
+		t.Errorf("exported in %s, want UTC", tz)
+	}
+	s.Post("/v1/exports", body).Query("tz", "Mars/Olympus").AsAdmin().Do().
+		Status(http.StatusBadRequest).
+		Field("code", "BAD_REQUEST")
+	s.Get("/v1/users").Query("locale", "german").Do().Status(http.StatusBadRequest)
+}
+
+func TestStats(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+	s.Get("/v1/stats").Do().Status(http.StatusUnauthorized)
+	s.Get("/v1/stats").AsAdmin().Do().Status(http.StatusOK).
+		Field("users", 3).
+		Field("created_today", 0).
+		Field("by_status", map[string]int{"active": 2, "suspended": 1}).
+		Field("by_tag", map[string]int{"beta": 1})
+
+	s.Post("/v1/users", map[string]string{"name": "Kim", "email": "kim@example.com", "username": "kim"}).Do().Status(http.StatusCreated)
+	s.Get("/v1/stats").AsAdmin().Do().Status(http.StatusOK).
+		Field("users", 4).
+		Field("created_today", 1).
+		Field("by_status", map[string]int{"active": 3, "suspended": 1})
+
+	cached := apitest.New(t, apitest.WithUsers(apitest.Users()...), apitest.WithConfig(server.Config{StatsCacheTTL: time.Hour}))
+	var first struct {
+		ComputedAt string `json:"computed_at"`
+	}
+	cached.Get("/v1/stats").AsAdmin().Do().Status(http.StatusOK).Decode(&first)
+	cached.Post("/v1/users", map[string]string{"name": "Kim", "email": "kim@example.com", "username": "kim"}).Do().Status(http.StatusCreated)
+	cached.Get("/v1/stats").AsAdmin().Do().Status(http.StatusOK).
+		Field("users", 3).
+		Field("computed_at", first.ComputedAt)
+}
+
+func TestIDGenerator(t *testing.T) {
+	snowflake, _ := idgen.NewSnowflake(3)
+	for _, c := range []struct {
+		gen   idgen.Generator
+		id    string
+		today int
+	}{
+		{idgen.UUIDv7{}, `^[0-9a-f]{8}-[0-9a-f]{4}-7`, 1},
+		{idgen.ULID{}, `^[0-9A-Z]{26}$`, 1},
+		{snowflake, `^[0-9]{19}$`, 1},
+		{idgen.UUIDv4{}, `^[0-9a-f]{8}-[0-9a-f]{4}-4`, 0},
+	} {
+		s := apitest.New(t, apitest.WithConfig(server.Config{IDGenerator: c.gen}))
+		var user, post struct {
+			ID string `json:"id"`
+		}
+		s.Post("/v1/users", map[string]string{"name": "Kim", "email": "kim@example.com", "username": "kim"}).Do().Status(http.StatusCreated).Decode(&user)
+		s.Post("/v1/posts", map[string]string{"author_id": user.ID, "title": "Hello", "body": "First post"}).Do().Status(http.StatusCreated).Decode(&post)
+		for _, id := range []string{user.ID, post.ID} {
+			if !regexp.MustCompile(c.id).MatchString(id) {
+				t.Errorf("%T: ID %q, want %s", c.gen, id, c.id)
+			}
+		}
+		s.Get("/v1/stats").AsAdmin().Do().Status(http.StatusOK).Field("created_today", c.today)
+	}
+}
+
+func TestDeduplicatesDoubleSubmits(t *testing.T) {
+	s := apitest.New(t)
+	body := `{"name":"Kim","email":"kim@example.com"}`
+
+	// A double click sends the form twice at once: one user is created, and
+	// both requests get it.
+	var wg sync.WaitGroup
+	ids := make([]string, 2)
+	deduped := make([]string, 2)
+	for i := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := http.Post(s.URL+"/v1/users", "application/json", strings.NewReader(body))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer resp.Body.Close()
+			var user struct{ ID string }
+			json.NewDecoder(resp.Body).Decode(&user)
+			if resp.StatusCode != http.StatusCreated {
+				t.Errorf("request %d: status %d", i, resp.StatusCode)
+			}
+			ids[i], deduped[i] = user.ID, resp.Header.Get("X-Deduplicated")
+		}()
+	}
+	wg.Wait()
+	if ids[0] == "" || ids[0] != ids[1] {
+		t.Fatalf("created %q, want one user for both", ids)
+	}
+	if (deduped[0] == "true") == (deduped[1] == "true") {
+		t.Errorf("X-Deduplicated %q, want it on the duplicate only", deduped)
+	}
+	s.Get("/v1/users").Do().Status(http.StatusOK).HasHeader("X-Total-Count", "1")
+
+	// Another body is another request, and failed requests run again.
+	s.Post("/v1/users", map[string]string{"name": "Lee", "email": "lee@example.com"}).Do().Status(http.StatusCreated)
+	s.Post("/v1/users", map[string]string{"name": "Mo"}).Do().Status(http.StatusUnprocessableEntity)
+	s.Post("/v1/users", map[string]string{"name": "Mo"}).Do().
+		Status(http.StatusUnprocessableEntity).
+		HasHeader("X-Deduplicated", "")
+	s.Get("/v1/users").Do().Status(http.StatusOK).HasHeader("X-Total-Count", "2")
+}
+
+func TestJobsDontOverlap(t *testing.T) {
+	ctx := context.Background()
+	// Another replica sharing the locker is applying the retention policy
+	// and sending the daily digests.
+	locker := lock.NewMemory("api-1")
+	retention, err := locker.TryLock(ctx, "retention", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := locker.TryLock(ctx, "digests:daily", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	s := apitest.New(t, apitest.WithConfig(server.Config{Locker: locker, RetentionAudit: time.Hour}))
+
+	s.Post("/admin/retention", nil).AsAdmin().Do().
+		Status(http.StatusConflict).
+		Field("code", "CONFLICT")
+	s.Post("/admin/digest", nil).Query("period", "daily").AsAdmin().Do().Status(http.StatusConflict)
+	s.Post("/admin/digest", nil).Query("period", "daily").Query("dry_run", "true").AsAdmin().Do().Status(http.StatusOK)
+	s.Post("/admin/digest", nil).Query("period", "weekly").AsAdmin().Do().Status(http.StatusOK)
+
+	retention.Unlock(ctx)
+	s.Post("/admin/retention", nil).AsAdmin().Do().Status(http.StatusOK)
+	// Each run released its lock.
+	s.Post("/admin/retention", nil).AsAdmin().Do().Status(http.StatusOK)
+}
+
+func TestLeader(t *testing.T) {
+	s := apitest.New(t, apitest.WithConfig(server.Config{ReplicaID: "api-0"}))
+	s.Get("/admin/leader").AsAdmin().Do().
+		Status(http.StatusOK).
+		Field("replica", "api-0").
+		Field("election", "local")
+
+	// Another replica sharing the locker leads.
+	locker := lock.NewMemory("api-1")
+	if _, err := locker.TryLock(context.Background(), "leader", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	s = apitest.New(t, apitest.WithConfig(server.Config{ReplicaID: "api-0", Locker: locker}))
+	s.Get("/admin/leader").AsAdmin().Do().
+		Status(http.StatusOK).
+		Field("leader", "api-1").
+		Field("replica", "api-0").
+		Field("is_leader", false).
+		Field("election", "lock")
+}
+
+func TestFaultInjection(t *testing.T) {
+	s := apitest.New(t)
+	s.Get("/admin/faults").AsAdmin().Do().Status(http.StatusNotFound)
+
+	s = apitest.New(t, apitest.WithConfig(server.Config{FaultInjection: true}), apitest.WithUsers(apitest.Users()...))
+	s.Put("/admin/faults", map[string]any{"rules": []map[string]any{
+		{"route": "/v1/users/{id}", "method": "GET", "rate": 1, "status": 503},
+		{"route": "/v1/*", "rate": 0, "status": 500},
+	}}).AsAdmin().Do().Status(http.StatusOK)
+
+	s.Get("/v1/users/"+apitest.AdaID).Do().
+		Status(http.StatusServiceUnavailable).
+		Field("code", "INJECTED_FAULT")
+	s.Get("/v1/users").Do().Status(http.StatusOK)
+	s.Get("/v1/users/" + apitest.AdaID + "/preferences").Do().Status(http.StatusOK)
+	// The admin API is never hit, so the rules can always be changed back.
+	s.Get("/admin/faults").AsAdmin().Do().
+		Status(http.StatusOK).
+		Field("rules.0.route", "/v1/users/{id}")
+
+	s.Put("/admin/faults", map[string]any{"rules": []any{}}).AsAdmin().Do().Status(http.StatusOK)
+	s.Get("/v1/users/" + apitest.AdaID).Do().Status(http.StatusOK)
+}
+
+func TestHealthFormats(t *testing.T) {
+	s := apitest.New(t)
+
+	s.Get("/readyz").Do().Status(http.StatusOK).Field("status", float64(200))
+	resp := s.Get("/readyz").Query("format", "plain").Do().
+		Status(http.StatusOK).
+		HasHeader("Content-Type", "text/plain; charset=utf-8")
+	if got := string(resp.Body); got != "ok\n" {
+		t.Errorf("plain readiness: %q", got)
+	}
+	resp = s.Get("/health").Query("format", "prometheus").Do().
+		Status(http.StatusOK).
+		HasHeader("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if got := string(resp.Body); !strings.Contains(got, "\nhealth_up 1\n") {
+		t.Errorf("prometheus health: %q", got)
+	}
+	// Only ?format= picks the text forms.
+	s.Get("/livez").Header("Accept", "text/plain").Do().
+		Status(http.StatusOK).
+		HasHeader("Content-Type", "application/json")
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	s := apitest.New(t, apitest.WithCallTracing(), apitest.WithUsers(apitest.Users()...))
+
+	resp := s.Get("/v1/users/" + apitest.AdaID).Do().Status(http.StatusOK)
+	resp.CalledInOrder(
+		"middleware:traceRequests",
+		"middleware:resolveClientIP",
+		"middleware:restrictIPs",
+		"middleware:shedLoad",
+		"middleware:refuseDuringMaintenance",
+		"middleware:authenticate",
+		"middleware:enforceQuotas",
+		"middleware:authorize",
+		"middleware:limitConcurrency",
+		"handler:get-v1-users-by-id",
+		"store:GetUser",
+	)
+
+	// A request refused for maintenance goes no further.
+	s.Put("/admin/maintenance", map[string]any{"mode": "read_only"}).AsAdmin().Do().Status(http.StatusOK)
+	resp = s.Post("/v1/users", map[string]any{"name": "Late", "email": "late@example.com"}).Do().
+		Status(http.StatusServiceUnavailable).
+		CalledInOrder("middleware:refuseDuringMaintenance")
+	if calls := resp.Calls(); slices.Contains(calls, "middleware:authenticate") || slices.Contains(calls, "handler:post-v1-users") {
+		t.Errorf("a request refused for maintenance went on to %v", calls)
+	}
+}
+
+func TestCanaryRouting(t *testing.T) {
+	s := apitest.New(t, apitest.WithConfig(server.Config{CanaryStore: server.NewMemoryStore()}), apitest.WithUsers(apitest.Users()...))
+
+	// The store has the users, the canary store doesn't.
+	if h := s.Get("/v1/users/" + apitest.AdaID).Do().Status(http.StatusOK).Header.Get("X-Canary"); h != "" {
+		t.Errorf("request served from the store got X-Canary %q, want none", h)
+	}
+	s.Get("/v1/users/"+apitest.AdaID).Header("X-Canary", "true").Do().
+		Status(http.StatusNotFound).
+		HasHeader("X-Canary", "true")
+	var created server.User
+	s.Post("/v1/users", map[string]any{"name": "Canary", "email": "canary@example.com"}).Header("X-Canary", "true").Do().
+		Status(http.StatusCreated).
+		Decode(&created)
+	s.Get("/v1/users/"+created.ID).Header("X-Canary", "true").Do().Status(http.StatusOK)
+	s.Get("/v1/users/" + created.ID).Do().Status(http.StatusNotFound)
+
+	resp := s.Get("/metrics").Do().Status(http.StatusOK)
+	for _, want := range []string{
+		`http_canary_requests_total{method="GET",route="/v1/users/{id}",status_class="2xx"} 1`,
+		`http_canary_requests_total{method="GET",route="/v1/users/{id}",status_class="4xx"} 1`,
+		`http_requests_total{method="GET",route="/v1/users/{id}",status_class="4xx"} 2`,
+	} {
+		if !strings.Contains(string(resp.Body), want) {
+			t.Errorf("metrics lack %s: %s", want, resp.Body)
+		}
+	}
+
+	// With every request sampled, X-Canary: false still opts out, and the
+	// probes stay on the store.
+	s = apitest.New(t, apitest.WithConfig(server.Config{CanaryStore: server.NewMemoryStore(), CanaryPercent: 100}), apitest.WithUsers(apitest.Users()...))
+	s.Get("/v1/users/"+apitest.AdaID).Do().Status(http.StatusNotFound).HasHeader("X-Canary", "true")
+	s.Get("/v1/users/"+apitest.AdaID).Header("X-Canary", "false").Do().Status(http.StatusOK)
+	if h := s.Get("/readyz").Do().Status(http.StatusOK).Header.Get("X-Canary"); h != "" {
+		t.Errorf("probe got X-Canary %q, want none", h)
+	}
+}
+
+func TestShadowTraffic(t *testing.T) {
+	type mirrored struct {
+		method, path, auth, shadowOf string
+		body                         map[string]any
+	}
+	got := make(chan mirrored, 10)
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m := mirrored{method: r.Method, path: r.URL.RequestURI(), auth: r.Header.Get("Authorization"), shadowOf: r.Header.Get("X-Shadow-Of")}
+		json.NewDecoder(r.Body).Decode(&m.body)
+		got <- m
+		// What the target answers doesn't matter.
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer target.Close()
+	u, _ := url.Parse(target.URL + "/v2")
+	s := apitest.New(t, apitest.WithConfig(server.Config{ShadowURL: u}), apitest.WithUsers(apitest.Users()...))
+	next := func() mirrored {
+		t.Helper()
+		select {
+		case m := <-got:
+			return m
+		case <-time.After(5 * time.Second):
+			t.Fatal("request not mirrored")
+			return mirrored{}
+		}
+	}
+
+	s.Get("/v1/users").Query("per_page", "2").Header("Authorization", "Bearer secret").Header("X-Request-ID", "req-list").Do().Status(http.StatusOK)
+	if m := next(); m.method != http.MethodGet || m.path != "/v2/v1/users?per_page=2" || m.auth != "" || m.shadowOf != "req-list" {
+		t.Errorf("mirrored %+v, want GET /v2/v1/users?per_page=2 without credentials, of req-list", m)
+	}
+	s.Post("/v1/users", map[string]any{"name": "Shadow", "email": "shadow@example.com"}).Do().Status(http.StatusCreated)
+	if m := next(); m.method != http.MethodPost || len(m.body) != 2 || m.body["email"] != "REDACTED" {
+		t.Errorf("mirrored %+v, want the new user redacted", m)
+	}
+
+	// The admin API and the probes aren't mirrored.
+	s.Get("/admin/maintenance").AsAdmin().Do().Status(http.StatusOK)
+	s.Get("/readyz").Do().Status(http.StatusOK)
+	s.Get("/v1/users/" + apitest.AdaID).Do().Status(http.StatusOK)
+	if m := next(); m.path != "/v2/v1/users/"+apitest.AdaID {
+		t.Errorf("mirrored %s, want only the API request", m.path)
+	}
+}
+
+func TestTelemetryReport(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+	s.Get("/v1/users").Do().Status(http.StatusOK)
+	s.Get("/v1/users/" + apitest.AdaID).Do().Status(http.StatusOK)
+
+	var status struct {
+		Enabled bool
+		URL     string
+		Report  struct {
+			Store    string
+			Requests map[string]int64
+		}
+	}
+	s.Get("/admin/telemetry").AsAdmin().Do().Status(http.StatusOK).Decode(&status)
+	if status.Enabled || status.URL != "" || status.Report.Store != "memory" {
+		t.Errorf("telemetry %+v, want it off on the memory store", status)
+	}
+	want := map[string]int64{"get-v1-users": 1, "get-v1-users-by-id": 1, "get-admin-telemetry": 1}
+	if !maps.Equal(status.Report.Requests, want) {
+		t.Errorf("report counts %v, want %v", status.Report.Requests, want)
+	}
+}
+
+func TestKeysetPagination(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+	s.Get("/v1/users").Query("per_page", "1").Query("include_inactive", "true").Do().
+		Status(http.StatusOK).
+		Field("next.page", 2).
+		Field("next.after", apitest.AdaID)
+
+	// Deleting a user on a page already read shifts the page numbers, but
+	// not what comes after it.
+	if err := s.Store.DeleteUser(context.Background(), apitest.AdaID); err != nil {
+		t.Fatal(err)
+	}
+	s.Get("/v1/users").Query("page", "2").Query("per_page", "1").Query("include_inactive", "true").Do().
+		Status(http.StatusOK).
+		Field("users.0.id", apitest.LinusID)
+	resp := s.Get("/v1/users").Query("after", apitest.AdaID).Query("per_page", "1").Query("include_inactive", "true").Do().
+		Status(http.StatusOK).
+		Field("users.0.id", apitest.GraceID).
+		Field("next.href", "/v1/users?after="+apitest.GraceID+"&include_inactive=true&per_page=1").
+		Field("next.after", apitest.GraceID)
+	if link := resp.Header.Get("Link"); strings.Contains(link, `rel="prev"`) || !strings.Contains(link, "after="+apitest.GraceID) {
+		t.Errorf("Link %q, want the next page after %s and no previous one", link, apitest.GraceID)
+	}
+
+	// Past the end, an after that names a deleted entry still finds its
+	// place.
+	s.Get("/v1/users").Query("after", apitest.LinusID+"~").Query("include_inactive", "true").Do().
+		Status(http.StatusOK).
+		Field("has_more", false).
+		Field("users", []any{})
+	s.Get("/v1/posts").Query("after", apitest.AdaID).Do().
+		Status(http.StatusOK).
+		Field("has_more", false)
+}
+
+func TestResponseFormats(t *testing.T) {
+	s := apitest.New(t)
+
+	// A user created in CBOR comes back in CBOR, and reads back in YAML.
+	var body bytes.Buffer
+	if err := cbor.DefaultCBORFormat.Marshal(&body, map[string]any{"name": "Lin", "email": "lin@example.com", "metadata": map[string]any{"plan": "pro"}}); err != nil {
+		t.Fatal(err)
+	}
+	resp := s.Post("/v1/users", body.Bytes()).
+		Header("Content-Type", "application/cbor").
+		Header("Accept", "application/cbor").
+		Do().Status(http.StatusCreated).HasHeader("Content-Type", "application/cbor")
+	var created server.User
+	if err := cbor.DefaultCBORFormat.Unmarshal(resp.Body, &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.ID == "" || created.Name != "Lin" || created.Email != "lin@example.com" || created.Metadata["plan"] != "pro" {
+		t.Errorf("created %+v from CBOR", created)
+	}
+	resp = s.Get("/v1/users/"+created.ID).Header("Accept", "application/yaml").Do().
+		Status(http.StatusOK).HasHeader("Content-Type", "application/yaml")
+	var read server.User
+	if err := yaml.Unmarshal(resp.Body, &read); err != nil {
+		t.Fatal(err)
+	}
+	if read.ID != created.ID || read.Name != "Lin" || read.Email != "lin@example.com" || read.Status != "active" {
+		t.Errorf("read %+v in YAML, want the user created in CBOR", read)
+	}
+
+	// And one created in YAML reads back in CBOR.
+	resp = s.Post("/v1/users", "name: Ada\nemail: ada@example.com\n").
+		Header("Content-Type", "application/yaml").
+		Header("Accept", "application/yaml").
+		Do().Status(http.StatusCreated).HasHeader("Content-Type", "application/yaml")
+	if err := yaml.Unmarshal(resp.Body, &created); err != nil || created.Name != "Ada" {
+		t.Fatalf("created %+v from YAML: %v", created, err)
+	}
+	resp = s.Get("/v1/users/"+created.ID).Header("Accept", "application/cbor").Do().Status(http.StatusOK)
+	read = server.User{}
+	if err := cbor.DefaultCBORFormat.Unmarshal(resp.Body, &read); err != nil || read.Email != "ada@example.com" {
+		t.Errorf("read %+v in CBOR: %v", read, err)
+	}
+
+	// Wildcards, q-values and suffixes are honoured; a format nothing
+	// serves is refused as the error catalog documents.
+	for _, c := range []struct {
+		accept string
+		status int
+	}{
+		{"*/*", http.StatusOK},
+		{"text/html, */*;q=0.8", http.StatusOK},
+		{"application/*", http.StatusOK},
+		{"application/problem+json", http.StatusOK},
+		{"application/json; charset=utf-8", http.StatusOK},
+		{"text/csv", http.StatusNotAcceptable},
+		{"application/xml, text/html", http.StatusNotAcceptable},
+		{"application/json;q=0, application/xml", http.StatusNotAcceptable},
+	} {
+		resp := s.Get("/v1/users/"+created.ID).Header("Accept", c.accept).Do().Status(c.status)
+		if c.status == http.StatusNotAcceptable {
+			resp.HasHeader("Content-Type", "application/problem+json").Field("code", "NOT_ACCEPTABLE")
+		}
+	}
+	// Content types an operation documents are acceptable for it.
+	s.Get("/readyz").Header("Accept", "text/plain").Do().Status(http.StatusOK)
+}
+
+func TestInFlightRequests(t *testing.T) {
+	s := apitest.New(t, apitest.WithConfig(server.Config{FaultInjection: true}), apitest.WithUsers(apitest.Users()...))
+	s.Put("/admin/faults", map[string]any{"rules": []map[string]any{
+		{"route": "/v1/users/{id}", "rate": 1, "latency_ms": 5000},
+	}}).AsAdmin().Do().Status(http.StatusOK)
+	done := make(chan *apitest.Response)
+	go func() {
+		done <- s.Get("/v1/users/"+apitest.AdaID).Header("X-Request-ID", "req-stuck").Do()
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	s.Get("/admin/inflight").Do().Status(http.StatusUnauthorized)
+	s.Get("/admin/inflight").AsAdmin().Do().
+		Status(http.StatusOK).
+		Field("requests.0.id", "req-stuck").
+		Field("requests.0.path", "/v1/users/"+apitest.AdaID).
+		Field("requests.1.path", "/admin/inflight")
+	s.Post("/admin/inflight/req-other/cancel", nil).AsAdmin().Do().Status(http.StatusNotFound)
+	s.Post("/admin/inflight/req-stuck/cancel", nil).AsAdmin().Do().
+		Status(http.StatusOK).
+		Field("requests.0.id", "req-stuck").
+		Field("requests.0.canceled", true)
+
+	select {
+	case resp := <-done:
+		resp.Status(http.StatusServiceUnavailable).Field("code", "REQUEST_CANCELED")
+	case <-time.After(time.Second):
+		t.Fatal("the canceled request is still running")
+	}
+	s.Get("/admin/inflight").AsAdmin().Do().
+		Status(http.StatusOK).
+		Field("requests.0.path", "/admin/inflight")
+}
+
+func TestLoadShedding(t *testing.T) {
+	// busy holds the only slot for a second while fn runs.
+	busy := func(s *apitest.Server, fn func()) {
+		t.Helper()
+		s.Put("/admin/faults", map[string]any{"rules": []map[string]any{
+			{"route": "/v1/users/{id}", "rate": 1, "latency_ms": 1000},
+		}}).AsAdmin().Do().Status(http.StatusOK)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Get("/v1/users/" + apitest.AdaID).Do().Status(http.StatusOK)
+		}()
+		time.Sleep(100 * time.Millisecond)
+		fn()
+		wg.Wait()
+	}
+
+	s := apitest.New(t, apitest.WithConfig(server.Config{FaultInjection: true, MaxInFlight: 1, QueueTimeout: 50 * time.Millisecond}), apitest.WithUsers(apitest.Users()...))
+	busy(s, func() {
+		s.Get("/v1/users").Header("Accept-Language", "de").Do().
+			Status(http.StatusServiceUnavailable).
+			HasHeader("Retry-After", "1").
+			Field("code", "OVERLOADED").
+			Field("detail", "Der Server ist überlastet; nach Retry-After erneut versuchen")
+		// Probes, scrapes and the admin API have lanes of their own.
+		s.Get("/health").Do().Status(http.StatusOK)
+		s.Get("/livez").Do().Status(http.StatusOK)
+		s.Get("/metrics").Do().Status(http.StatusOK)
+		s.Get("/admin/maintenance").AsAdmin().Do().Status(http.StatusOK)
+	})
+	s.Get("/v1/users").Do().Status(http.StatusOK)
+	resp := s.Get("/metrics").Do().Status(http.StatusOK)
+	if !strings.Contains(string(resp.Body), `http_shed_requests_total{lane="api",reason="queue_full"} 1`) {
+		t.Errorf("shed request not counted: %s", resp.Body)
+	}
+
+	// With room in the queue, a request waits for the slot, and is shed once
+	// it has waited for longer than the timeout.
+	s = apitest.New(t, apitest.WithConfig(server.Config{FaultInjection: true, MaxInFlight: 1, MaxQueued: 1, QueueTimeout: 50 * time.Millisecond}), apitest.WithUsers(apitest.Users()...))
+	busy(s, func() {
+		s.Get("/v1/users").Do().Status(http.StatusServiceUnavailable).Field("code", "OVERLOADED")
+	})
+	s = apitest.New(t, apitest.WithConfig(server.Config{FaultInjection: true, MaxInFlight: 1, MaxQueued: 1, QueueTimeout: 5 * time.Second}), apitest.WithUsers(apitest.Users()...))
+	busy(s, func() {
+		s.Get("/v1/users").Do().Status(http.StatusOK)
+	})
+}
+
+func TestTimeBudget(t *testing.T) {
+	s := apitest.New(t, apitest.WithConfig(server.Config{FaultInjection: true}), apitest.WithUsers(apitest.Users()...))
+	var list struct {
+		Users   []map[string]any `json:"users"`
+		Partial *bool            `json:"partial"`
+	}
+	s.Get("/v1/users").Header("X-Time-Budget", "60000").Do().Status(http.StatusOK).Decode(&list)
+	if len(list.Users) != 2 || list.Partial != nil {
+		t.Errorf("within the budget: %d users, partial %v", len(list.Users), list.Partial)
+	}
+
+	// A budget spent before the handler starts leaves nothing looked at.
+	s.Put("/admin/faults", map[string]any{"rules": []map[string]any{
+		{"route": "/v1/users", "rate": 1, "latency_ms": 50},
+		{"route": "/v1/users/search", "rate": 1, "latency_ms": 50},
+	}}).AsAdmin().Do().Status(http.StatusOK)
+	s.Get("/v1/users").Header("X-Time-Budget", "10").Do().Status(http.StatusOK).
+		Field("partial", true).
+		Field("users", []any{}).
+		HasHeader("X-Total-Count", "0")
+	s.Get("/v1/users/search").Query("q", "a").Query("time_budget", "10").Do().Status(http.StatusOK).
+		Field("partial", true).
+		Field("results", []any{})
+	s.Get("/v1/users").Do().Status(http.StatusOK).Field("users.1.id", apitest.GraceID)
+	s.Get("/v1/users").Header("X-Time-Budget", "0").Do().Status(http.StatusUnprocessableEntity)
+}
+
+// humanOnly accepts the token "human" from a known address.
+type humanOnly struct{}
+
+func (humanOnly) Verify(ctx context.Context, token, remoteIP string) error {
+	if token != "human" || remoteIP == "" {
+		return captcha.ErrRejected
+	}
+	return nil
+}
+
+func TestSignupNeedsCaptcha(t *testing.T) {
+	s := apitest.New(t, apitest.WithConfig(server.Config{Captcha: humanOnly{}}))
+	body := map[string]string{"name": "Lin", "email": "lin@example.com"}
+
+	s.Post("/v1/users", body).Do().
+		Status(http.StatusForbidden).
+		Field("code", "CAPTCHA_FAILED")
+	s.Post("/v1/users", body).Header("X-Captcha-Token", "bot").Do().
+		Status(http.StatusForbidden).
+		Field("code", "CAPTCHA_FAILED")
+	s.Post("/v1/users", body).Header("X-Captcha-Token", "human").Do().
+		Status(http.StatusCreated)
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+	body := map[string]string{"name": "Lin", "email": "lin@example.com"}
+
+	s.Put("/admin/maintenance", map[string]any{"mode": "read_only", "retry_after_seconds": 60}).AsAdmin().Do().
+		Status(http.StatusOK).
+		Field("mode", "read_only")
+	s.Post("/v1/users", body).Do().
+		Status(http.StatusServiceUnavailable).
+		Field("code", "MAINTENANCE").
+		HasHeader("Retry-After", "60")
+	s.Get("/v1/users").Do().Status(http.StatusOK)
+
+	s.Put("/admin/maintenance", map[string]string{"mode": "on"}).AsAdmin().Do().Status(http.StatusOK)
+	s.Get("/v1/users").Do().Status(http.StatusServiceUnavailable)
+	s.Get("/health").Do().Status(http.StatusOK)
+	s.Get("/readyz").Do().Status(http.StatusOK)
+
+	s.Put("/admin/maintenance", map[string]string{"mode": "off"}).AsAdmin().Do().Status(http.StatusOK)
+	s.Post("/v1/users", body).Do().Status(http.StatusCreated)
+}
+
+func TestRequestTracing(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+	s.Put("/v1/users/"+apitest.AdaID, map[string]string{"name": "Ada King"}).
+		Header("X-Request-ID", "req-1").
+		Header("X-Correlation-ID", "click-42").
+		Do().
+		Status(http.StatusOK).
+		HasHeader("X-Request-ID", "req-1").
+		HasHeader("X-Correlation-ID", "click-42")
+
+	// IDs that don't look like one are replaced, and the correlation ID
+	// defaults to the request ID.
+	resp := s.Get("/v1/users").Header("X-Request-ID", "not an id").Do().Status(http.StatusOK)
+	id := resp.Header.Get("X-Request-ID")
+	if !strings.HasPrefix(id, "req_") {
+		t.Errorf("X-Request-ID = %q, want a generated one", id)
+	}
+	resp.HasHeader("X-Correlation-ID", id)
+
+	s.Get("/v1/users/changes").Query("since", "0").Do().
+		Status(http.StatusOK).
+		Field("changes.0.type", "user.updated").
+		Field("changes.0.correlation_id", "click-42")
+
+	var export struct {
+		Audit []struct {
+			Type          string
+			EventID       string `json:"event_id"`
+			CorrelationID string `json:"correlation_id"`
+			CausationID   string `json:"causation_id"`
+		}
+	}
+	s.Get("/v1/users/" + apitest.AdaID + "/data-export").Do().Status(http.StatusOK).Decode(&export)
+	if len(export.Audit) == 0 {
+		t.Fatal("data export has no audit entries")
+	}
+	if e := export.Audit[0]; e.Type != "user.updated" || e.EventID == "" || e.CorrelationID != "click-42" || e.CausationID != "req-1" {
+		t.Errorf("first audit entry = %+v, want Ada's update caused by req-1 in click-42", e)
+	}
+}
+
+func TestMetricsExemplars(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+	const trace = "4bf92f3577b34da6a3ce929d0e0e4736"
+	s.Get("/v1/users").Header("traceparent", "00-"+trace+"-00f067aa0ba902b7-01").Do().Status(http.StatusOK)
+	// An unsampled trace wasn't kept, so there's nothing to link to.
+	s.Get("/v1/users").Header("traceparent", "00-0af7651916cd43dd8448eb211c80319c-00f067aa0ba902b7-00").Do().Status(http.StatusOK)
+
+	resp := s.Get("/metrics").Header("Accept", "application/openmetrics-text").Do().Status(http.StatusOK)
+	exemplars := 0
+	for _, line := range strings.Split(string(resp.Body), "\n") {
+		if strings.HasPrefix(line, "http_request_duration_seconds_bucket") && strings.Contains(line, `route="/v1/users"`) && strings.Contains(line, " # {") {
+			exemplars++
+			if !strings.Contains(line, `# {trace_id="`+trace+`"}`) {
+				t.Errorf("exemplar of the wrong trace: %s", line)
+			}
+		}
+	}
+	if exemplars != 1 {
+		t.Errorf("got %d exemplars for /v1/users, want 1; metrics:\n%s", exemplars, resp.Body)
+	}
+}
+
+func TestChangelog(t *testing.T) {
+	s := apitest.New(t)
+	var changelog struct {
+		Releases []struct{ Version string }
+	}
+	s.Get("/v1/changelog").Do().Status(http.StatusOK).Decode(&changelog)
+	if len(changelog.Releases) == 0 {
+		t.Fatal("changelog has no releases")
+	}
+	first := changelog.Releases[len(changelog.Releases)-1].Version
+	s.Get("/v1/changelog").Query("since", first).Do().Status(http.StatusOK).Decode(&changelog)
+	for _, r := range changelog.Releases {
+		if r.Version == first {
+			t.Errorf("since=%s returned that release too", first)
+		}
+	}
+	s.Get("/v1/changelog").Query("since", "unreleased").Do().Status(http.StatusNotFound)
+}
+
+func TestIncludeRelatedResources(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+	var post struct{ ID string }
+	s.Post("/v1/users/"+apitest.AdaID+"/posts", map[string]string{"title": "Notes", "body": "On engines."}).Do().
+		Status(http.StatusCreated).
+		Decode(&post)
+	comments := "/v1/posts/" + post.ID + "/comments"
+	s.Post(comments, map[string]string{"author_id": apitest.GraceID, "body": "Nice notes."}).Do().Status(http.StatusCreated)
+	var hidden struct{ ID string }
+	s.Post(comments, map[string]string{"author_id": apitest.GraceID, "body": "Spam."}).Do().
+		Status(http.StatusCreated).
+		Decode(&hidden)
+	s.Post(comments+"/"+hidden.ID+"/status", map[string]string{"status": "hidden"}).AsAdmin().Do().Status(http.StatusOK)
+
+	var ada struct {
+		Posts []struct {
+			ID       string
+			Comments []struct{ Body string }
+		}
+	}
+	s.Get("/v1/users/"+apitest.AdaID).Query("include", "posts").Do().Status(http.StatusOK).Decode(&ada)
+	if len(ada.Posts) != 1 || ada.Posts[0].ID != post.ID || ada.Posts[0].Comments != nil {
+		t.Errorf("include=posts gave posts %+v, want Ada's post without comments", ada.Posts)
+	}
+	s.Get("/v1/users/"+apitest.AdaID).Query("include", "posts.comments,post_count").Do().
+		Status(http.StatusOK).
+		Field("post_count", 1).
+		Decode(&ada)
+	if len(ada.Posts) != 1 || len(ada.Posts[0].Comments) != 1 || ada.Posts[0].Comments[0].Body != "Nice notes." {
+		t.Errorf("include=posts.comments gave posts %+v, want Ada's post with the published comment", ada.Posts)
+	}
+	s.Get("/v1/users").Query("include", "posts").Do().Status(http.StatusOK).Field("users.0.posts.0.id", post.ID)
+
+	var problem struct{ Errors []struct{ Code string } }
+	s.Get("/v1/users/"+apitest.AdaID).Query("include", "posts.comments.author").Do().
+		Status(http.StatusUnprocessableEntity).
+		Decode(&problem)
+	if len(problem.Errors) != 1 || problem.Errors[0].Code != "max_depth" {
+		t.Errorf("a three-level include got errors %+v, want one max_depth", problem.Errors)
+	}
+	s.Get("/v1/users").Query("include", "org").Do().
+		Status(http.StatusUnprocessableEntity).
+		Field("errors.0.code", "enum")
+}
+
+func TestPaginationMetadata(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+	s.Get("/v1/users").Query("per_page", "1").Query("include_inactive", "true").Do().
+		Status(http.StatusOK).
+		Field("has_more", true).
+		Field("next.href", "/v1/users?include_inactive=true&page=2&per_page=1").
+		Field("next.page", 2).
+		Field("next.per_page", 1)
+
+	var last map[string]any
+	s.Get("/v1/users").Query("page", "3").Query("per_page", "1").Query("include_inactive", "true").Do().
+		Status(http.StatusOK).
+		Field("has_more", false).
+		Decode(&last)
+	if _, ok := last["next"]; ok {
+		t.Errorf("last page has next %v", last["next"])
+	}
+
+	s.Get("/v1/posts").Query("author_id", apitest.AdaID).Do().
+		Status(http.StatusOK).
+		Field("has_more", false)
+}
+
+func TestHALOutput(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+	s.Get("/v1/users/"+apitest.AdaID).Header("Accept", "application/hal+json").Do().
+		Status(http.StatusOK).
+		HasHeader("Content-Type", "application/hal+json").
+		Field("name", "Ada Lovelace").
+		Field("_links.self.href", "/v1/users/"+apitest.AdaID).
+		Field("_links.posts.href", "/v1/users/"+apitest.AdaID+"/posts")
+
+	var page struct {
+		Links    map[string]struct{ Href string } `json:"_links"`
+		Embedded struct {
+			Users []struct {
+				ID    string
+				Links map[string]struct{ Href string } `json:"_links"`
+			}
+		} `json:"_embedded"`
+		Users []any
+	}
+	s.Get("/v1/users").Query("page", "2").Query("per_page", "1").Header("Accept", "application/hal+json").Do().
+		Status(http.StatusOK).
+		Decode(&page)
+	for rel, want := range map[string]string{
+		"self":  "/v1/users?page=2&per_page=1",
+		"first": "/v1/users?page=1&per_page=1",
+		"prev":  "/v1/users?page=1&per_page=1",
+		"next":  "",
+		"last":  "/v1/users?page=2&per_page=1",
+	} {
+		if got := page.Links[rel].Href; got != want {
+			t.Errorf("%s link = %q, want %q", rel, got, want)
+		}
+	}
+	if len(page.Embedded.Users) != 1 || page.Users != nil {
+		t.Fatalf("page embeds %d users and lists %d, want them all embedded", len(page.Embedded.Users), len(page.Users))
+	}
+	if u := page.Embedded.Users[0]; u.Links["self"].Href != "/v1/users/"+u.ID {
+		t.Errorf("embedded user's self link = %q", u.Links["self"].Href)
+	}
+
+	// Plain JSON has no links.
+	var plain map[string]any
+	s.Get("/v1/users/" + apitest.AdaID).Do().Status(http.StatusOK).Decode(&plain)
+	if _, ok := plain["_links"]; ok {
+		t.Error("JSON response has _links")
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+	var tok struct{ Token string }
+	s.Post("/admin/impersonate/"+apitest.AdaID, map[string]string{"actor": "sam@support.example.com", "reason": "test"}).AsAdmin().Do().
+		Status(http.StatusOK).
+		Decode(&tok)
+	user := "Bearer " + tok.Token
+
+	var key struct{ ID, Key, Prefix string }
+	s.Post("/v1/api-keys", map[string]any{"name": "CI", "scopes": []string{"users:read"}}).Header("Authorization", user).Do().
+		Status(http.StatusCreated).
+		Field("name", "CI").
+		Decode(&key)
+	if !strings.HasPrefix(key.Key, key.Prefix+"_") {
+		t.Fatalf("key %q doesn't start with its prefix %q", key.Key, key.Prefix)
+	}
+	apiKey := "Bearer " + key.Key
+
+	s.Get("/v1/me").Header("Authorization", apiKey).Do().
+		Status(http.StatusOK).
+		Field("user.id", apitest.AdaID).
+		Field("api_key_id", key.ID).
+		Field("scopes", []string{"users:read"})
+	s.Get("/v1/notifications").Header("Authorization", apiKey).Do().
+		Status(http.StatusForbidden).
+		Field("code", "INSUFFICIENT_SCOPE")
+	s.Get("/v1/api-keys").Header("Authorization", apiKey).Do().Status(http.StatusForbidden)
+
+	var list struct {
+		APIKeys []map[string]any `json:"api_keys"`
+	}
+	s.Get("/v1/api-keys").Header("Authorization", user).Do().Status(http.StatusOK).Decode(&list)
+	if len(list.APIKeys) != 1 || list.APIKeys[0]["last_used_at"] == nil || list.APIKeys[0]["key"] != nil || list.APIKeys[0]["secret_hash"] != nil {
+		t.Errorf("listed %v, want the one key, used, without its secret", list.APIKeys)
+	}
+
+	// Rotating keeps the key but not the secret.
+	var rotated struct{ ID, Key string }
+	s.Post("/v1/api-keys/"+key.ID+"/rotate", nil).Header("Authorization", user).Do().Status(http.StatusOK).Decode(&rotated)
+	if rotated.ID != key.ID || rotated.Key == key.Key {
+		t.Errorf("rotated to %+v, want %s with a new secret", rotated, key.ID)
+	}
+	s.Get("/v1/me").Header("Authorization", apiKey).Do().Status(http.StatusUnauthorized).Field("code", "INVALID_TOKEN")
+	s.Get("/v1/me").Header("Authorization", "Bearer "+rotated.Key).Do().Status(http.StatusOK)
+
+	// Both refusals are security events.
+	reasons := func(subject, typ string) []string {
+		var out []string
+		for _, e := range s.API.Audit().ForSubject(subject) {
+			if e.Type == typ {
+				out = append(out, e.Data.(map[string]any)["reason"].(string))
+			}
+		}
+		return out
+	}
+	if got, want := reasons(apitest.AdaID, "security.permission_denied"), []string{"missing_scope", "api_key_not_allowed"}; !slices.Equal(got, want) {
+		t.Errorf("permission_denied reasons = %v, want %v", got, want)
+	}
+	if got := reasons("", "security.auth_failed"); !slices.Equal(got, []string{"invalid_api_key"}) {
+		t.Errorf("auth_failed reasons = %v, want invalid_api_key", got)
+	}
+
+	// Every request the key authenticated counts against its quota, even
+	// those it lacked the scope for.
+	s.Get("/admin/ratelimits/"+key.ID).AsAdmin().Do().Status(http.StatusOK).Field("usage.day", 4)
+
+	s.Delete("/v1/api-keys/"+key.ID).Header("Authorization", user).Do().Status(http.StatusNoContent)
+	s.Get("/v1/me").Header("Authorization", "Bearer "+rotated.Key).Do().Status(http.StatusUnauthorized)
+	s.Delete("/v1/api-keys/"+key.ID).Header("Authorization", user).Do().Status(http.StatusNotFound).Field("code", "API_KEY_NOT_FOUND")
+}
+
+func TestTokenRevocation(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+	var tok struct{ Token string }
+	s.Post("/admin/impersonate/"+apitest.AdaID, map[string]string{"actor": "sam@support.example.com", "reason": "test"}).AsAdmin().Do().
+		Status(http.StatusOK).
+		Decode(&tok)
+	user := "Bearer " + tok.Token
+
+	s.Post("/v1/auth/introspect", map[string]string{"token": tok.Token}).Do().
+		Status(http.StatusOK).
+		Field("active", true).
+		Field("token_type", "Bearer").
+		Field("sub", apitest.AdaID)
+	s.Post("/v1/auth/introspect", map[string]string{"token": tok.Token + "x"}).Do().
+		Status(http.StatusOK).
+		Field("active", false)
+
+	var key struct{ ID, Key string }
+	s.Post("/v1/api-keys", map[string]any{"name": "CI", "scopes": []string{"users:read"}}).Header("Authorization", user).Do().
+		Status(http.StatusCreated).
+		Decode(&key)
+	s.Post("/v1/auth/introspect", map[string]string{"token": key.Key}).Do().
+		Status(http.StatusOK).
+		Field("active", true).
+		Field("token_type", "api_key").
+		Field("api_key_id", key.ID).
+		Field("scope", "users:read")
+
+	// A revoked user token is refused from then on, and revoking it again,
+	// or revoking garbage, is a no-op.
+	s.Post("/v1/auth/revoke", map[string]string{"token": tok.Token}).Do().Status(http.StatusOK)
+	s.Get("/v1/me").Header("Authorization", user).Do().
+		Status(http.StatusUnauthorized).
+		Field("code", "INVALID_TOKEN").
+		Field("detail", "user token revoked")
+	s.Post("/v1/auth/introspect", map[string]string{"token": tok.Token}).Do().
+		Status(http.StatusOK).
+		Field("active", false)
+	s.Post("/v1/auth/revoke", map[string]string{"token": tok.Token}).Do().Status(http.StatusOK)
+	s.Post("/v1/auth/revoke", map[string]string{"token": "not a token"}).Do().Status(http.StatusOK)
+	var revoked int
+	for _, e := range s.API.Audit().ForSubject(apitest.AdaID) {
+		if e.Type == "security.token_revoked" {
+			revoked++
+		}
+	}
+	if revoked != 1 {
+		t.Errorf("audit has %d security.token_revoked for Ada, want 1", revoked)
+	}
+
+	// The user's other credentials still work; an API key is revoked by
+	// deleting it.
+	s.Get("/v1/me").Header("Authorization", "Bearer "+key.Key).Do().Status(http.StatusOK)
+	s.Post("/v1/auth/revoke", map[string]string{"token": key.Key, "token_type_hint": "api_key"}).Do().Status(http.StatusOK)
+	s.Get("/v1/me").Header("Authorization", "Bearer "+key.Key).Do().Status(http.StatusUnauthorized)
+}
+
+func TestJWTIssuers(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := base64.RawURLEncoding
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "k1", "n": b64.EncodeToString(key.N.Bytes()), "e": b64.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	defer idp.Close()
+	issuers, err := server.ParseJWTIssuers("https://example.auth0.com/ "+idp.URL, "https://api.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := func(claims map[string]any) string {
+		head, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1"})
+		payload, _ := json.Marshal(claims)
+		signed := b64.EncodeToString(head) + "." + b64.EncodeToString(payload)
+		digest := sha256.Sum256([]byte(signed))
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "Bearer " + signed + "." + b64.EncodeToString(sig)
+	}
+	s := apitest.New(t, apitest.WithConfig(server.Config{JWTIssuers: issuers}), apitest.WithUsers(apitest.Users()...))
+	exp := time.Now().Add(time.Hour).Unix()
+
+	s.Get("/v1/me").Header("Authorization", token(map[string]any{"iss": "https://example.auth0.com/", "sub": apitest.AdaID, "aud": "https://api.example.com", "exp": exp})).Do().
+		Status(http.StatusOK).
+		Field("user.id", apitest.AdaID).
+		Field("issuer", "https://example.auth0.com/")
+	s.Get("/v1/me").Header("Authorization", token(map[string]any{"iss": "https://example.auth0.com/", "sub": apitest.AdaID, "aud": "https://other.example.com", "exp": exp})).Do().
+		Status(http.StatusUnauthorized).
+		Field("code", "INVALID_TOKEN")
+}
+
+// TestOperationScopes checks that every scope an operation asks an API key
+// for is one the apiKey scheme documents, and so one a key can be given.
+func TestOperationScopes(t *testing.T) {
+	s := apitest.New(t)
+	spec := s.API.OpenAPI()
+	doc := spec.Components.SecuritySchemes["apiKey"].Description
+	for path, item := range spec.Paths {
+		for _, op := range []*huma.Operation{item.Get, item.Put, item.Post, item.Delete, item.Patch} {
+			if op == nil {
+				continue
+			}
+			for _, req := range op.Security {
+				for _, scope := range req["apiKey"] {
+					if !strings.Contains(doc, "`"+scope+"`") {
+						t.Errorf("%s %s: scope %s isn't documented", op.Method, path, scope)
+					}
+				}
+			}
+		}
+	}
+	if me := spec.Paths["/v1/me"].Get; len(me.Security) != 2 || !slices.Equal(me.Security[1]["apiKey"], []string{"users:read"}) {
+		t.Errorf("get-v1-me security = %v, want a user token or an API key with users:read", me.Security)
+	}
+}
+
+func TestTrafficAnalysis(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...), apitest.WithConfig(server.Config{
+		TrafficPolicy: server.TrafficPolicy{MaxErrorRate: 0.5, MinRequests: 4, Action: server.TrafficCaptcha},
+		Captcha:       humanOnly{},
+	}))
+
+	for range 4 {
+		s.Get("/v1/users/missing").Do().Status(http.StatusNotFound)
+	}
+	s.Get("/v1/users").Do().
+		Status(http.StatusForbidden).
+		Field("code", "CAPTCHA_FAILED")
+	s.Get("/v1/users").Header("X-Captcha-Token", "bot").Do().
+		Status(http.StatusForbidden)
+	s.Get("/admin/traffic/blocks").AsAdmin().Do().
+		Status(http.StatusOK).
+		Field("blocks.0.action", "captcha").
+		Field("blocks.0.reason", "4 of 4 requests in a minute failed")
+
+	// A solved CAPTCHA lets the client go.
+	s.Get("/v1/users").Header("X-Captcha-Token", "human").Do().Status(http.StatusOK)
+	s.Get("/v1/users").Do().Status(http.StatusOK)
+	s.Delete("/admin/traffic/blocks/192.0.2.1").AsAdmin().Do().Status(http.StatusNotFound)
+
+	var challenged int
+	for _, e := range s.API.Audit().ForSubject("") {
+		if e.Type == server.EventClientChallenged {
+			challenged++
+		}
+	}
+	if challenged != 1 {
+		t.Errorf("%d %s events, want 1", challenged, server.EventClientChallenged)
+	}
+}
+
+func TestCacheControl(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+
+	resp := s.Get("/version").Do().
+		Status(http.StatusOK).
+		HasHeader("Cache-Control", "public, max-age=60")
+	if vary := resp.Header.Values("Vary"); !slices.Contains(vary, "Accept") {
+		t.Errorf("Vary = %v, want Accept among them", vary)
+	}
+	s.Get("/v1/posts").Do().
+		Status(http.StatusOK).
+		HasHeader("Cache-Control", "public, max-age=30")
+
+	// User data and errors are never cached, even where responses may be.
+	s.Get("/v1/users/"+apitest.AdaID).Do().
+		Status(http.StatusOK).
+		HasHeader("Cache-Control", "no-store")
+	s.Get("/v1/posts/missing").Do().
+		Status(http.StatusNotFound).
+		HasHeader("Cache-Control", "no-store")
+	s.Get("/v1/me").Do().
+		Status(http.StatusUnauthorized).
+		HasHeader("Cache-Control", "no-store")
+}
+
+func TestSurrogateKeys(t *testing.T) {
+	s := apitest.New(t, apitest.WithUsers(apitest.Users()...))
+	var post, comment struct {
+		ID        string
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+	s.Post("/v1/posts", map[string]string{"author_id": apitest.AdaID, "title": "Hello", "body": "World"}).Do().
+		Status(http.StatusCreated).
+		Decode(&post)
+	s.Post("/v1/posts/"+post.ID+"/comments", map[string]string{"author_id": apitest.GraceID, "body": "Nice"}).Do().
+		Status(http.StatusCreated).
+		Decode(&comment)
+	if h := s.Post("/v1/posts", map[string]string{"author_id": apitest.AdaID, "title": "Again", "body": "."}).Do().Header.Get("Surrogate-Key"); h != "" {
+		t.Errorf("create got Surrogate-Key %q, want none", h)
+	}
+
+	s.Get("/v1/posts/"+post.ID).Do().
+		Status(http.StatusOK).
+		HasHeader("Surrogate-Key", "post:"+post.ID+" user:"+apitest.AdaID).
+		HasHeader("Last-Modified", post.UpdatedAt.UTC().Format(http.TimeFormat))
+	s.Get("/v1/posts").Query("author_id", apitest.AdaID).Query("per_page", "1").Do().
+		Status(http.StatusOK).
+		HasHeader("Surrogate-Key", "posts post:"+post.ID+" user:"+apitest.AdaID)
+	s.Get("/v1/posts/"+post.ID+"/comments").Do().
+		Status(http.StatusOK).
+		HasHeader("Surrogate-Key", "comment:"+comment.ID+" comments:"+post.ID+" user:"+apitest.GraceID)
+	s.Get("/v1/posts/"+post.ID+"/comments/"+comment.ID).Do().
+		Status(http.StatusOK).
+		HasHeader("Last-Modified", comment.UpdatedAt.UTC().Format(http.TimeFormat))
+	if h := s.Get("/v1/posts/missing").Do().Status(http.StatusNotFound).Header.Get("Surrogate-Key"); h != "" {
+		t.Errorf("404 got Surrogate-Key %q, want none", h)
+	}
+}
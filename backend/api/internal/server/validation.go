@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// Resolve validates and normalizes the fields the schema can't fully
+// describe. It runs after schema validation, so types are already correct.
+func (r *CreateUserRequest) Resolve(ctx huma.Context, prefix *huma.PathBuffer) []error {
+	return r.normalize(ctx.Context(), prefix)
+}
+
+// normalize is Resolve for callers outside huma's request handling; see
+// decodeBody.
+func (r *CreateUserRequest) normalize(ctx context.Context, prefix *huma.PathBuffer) []error {
+	errs := append(validateMetadata(ctx, prefix, r.Metadata), validateEmailDomain(ctx, prefix, r.Email)...)
+	if r.Phone != "" {
+		phone, err := normalizePhone(r.Phone)
+		if err != nil {
+			errs = append(errs, &ErrorDetail{Location: prefix.With("phone"), Code: "format", Message: err.Error(), Value: r.Phone})
+		}
+		r.Phone = phone
+	}
+	if r.Username != "" {
+		name, err := normalizeUsername(r.Username)
+		if err != nil {
+			errs = append(errs, &ErrorDetail{Location: prefix.With("username"), Code: usernameCode(err), Message: err.Error(), Value: r.Username})
+		}
+		r.Username = name
+	}
+	return errs
+}
+
+// Resolve validates and normalizes the fields the schema can't fully
+// describe. An empty phone clears it; usernames can be changed but not
+// cleared.
+func (r *UpdateUserRequest) Resolve(ctx huma.Context, prefix *huma.PathBuffer) []error {
+	return r.normalize(ctx.Context(), prefix)
+}
+
+// normalize is Resolve for callers outside huma's request handling; see
+// decodeBody.
+func (r *UpdateUserRequest) normalize(ctx context.Context, prefix *huma.PathBuffer) []error {
+	errs := append(validateMetadata(ctx, prefix, r.Metadata), validateUnknownFields(prefix, r.unknown)...)
+	if r.Email != nil {
+		errs = append(errs, validateEmailDomain(ctx, prefix, *r.Email)...)
+	}
+	if r.Phone != nil && *r.Phone != "" {
+		phone, err := normalizePhone(*r.Phone)
+		if err != nil {
+			errs = append(errs, &ErrorDetail{Location: prefix.With("phone"), Code: "format", Message: err.Error(), Value: *r.Phone})
+		}
+		r.Phone = &phone
+	}
+	if r.Username != nil {
+		name, err := normalizeUsername(*r.Username)
+		if err != nil {
+			errs = append(errs, &ErrorDetail{Location: prefix.With("username"), Code: usernameCode(err), Message: err.Error(), Value: *r.Username})
+		}
+		r.Username = &name
+	}
+	return errs
+}
+
+// usernameCode is the ErrorDetail code for an error from normalizeUsername.
+func usernameCode(err error) string {
+	if err == errReservedUsername {
+		return "reserved"
+	}
+	return "pattern"
+}
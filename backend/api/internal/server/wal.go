@@ -0,0 +1,303 @@
+package server
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// Write-ahead log operations.
+const (
+	walPutUser          = "put_user"
+	walDeleteUser       = "delete_user"
+	walPutPreferences   = "put_preferences"
+	walPutCredentials   = "put_credentials"
+	walPutPost          = "put_post"
+	walDeletePost       = "delete_post"
+	walPutComment       = "put_comment"
+	walDeleteComment    = "delete_comment"
+	walPutInvitation    = "put_invitation"
+	walDeleteInvitation = "delete_invitation"
+	// walBatch holds several records, applied together or not at all.
+	walBatch = "batch"
+)
+
+// walRecord is one line of the write-ahead log.
+type walRecord struct {
+	Op          string            `json:"op"`
+	ID          string            `json:"id,omitempty"`
+	User        *User             `json:"user,omitempty"`
+	Preferences *UserPreferences  `json:"preferences,omitempty"`
+	Credentials *Credentials      `json:"credentials,omitempty"`
+	Post        *Post             `json:"post,omitempty"`
+	Comment     *Comment          `json:"comment,omitempty"`
+	Invitation  *StoredInvitation `json:"invitation,omitempty"`
+	Batch       []walRecord       `json:"batch,omitempty"`
+	// Expect holds, for a batch, the records the transaction that wrote it
+	// read: put records with what was read, or with nothing for a record
+	// that wasn't found. The batch applies only if they are still so.
+	Expect []walRecord `json:"expect,omitempty"`
+}
+
+// walMaxRecord bounds one log line; users are well under it.
+const walMaxRecord = 1 << 20
+
+// OpenWAL replays the write-ahead log at path on top of the store's current
+// contents, normally just restored from a snapshot, and then appends every
+// mutation to it, synced to disk before the mutation is applied. A snapshot
+// saved with SaveSnapshot compacts the log. A torn last line, from a crash
+// mid-append, is dropped, and cut off the file so the next record doesn't
+// end up on it; the mutation it held was never acknowledged.
+func (m *MemoryStore) OpenWAL(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.wal != nil {
+		return errors.New("write-ahead log is already open")
+	}
+	// Entries set aside by a compaction that didn't finish come first.
+	for _, p := range []string{compactingPath(path), path} {
+		good, err := m.replayWAL(p)
+		if err != nil {
+			return fmt.Errorf("replay %s: %w", p, err)
+		}
+		if err := truncateTorn(p, good); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	m.wal, m.walPath = f, path
+	return nil
+}
+
+// Close closes the write-ahead log, if one is open.
+func (m *MemoryStore) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.wal == nil {
+		return nil
+	}
+	err := m.wal.Close()
+	m.wal = nil
+	return err
+}
+
+func compactingPath(path string) string {
+	return path + ".compacting"
+}
+
+// replayWAL applies the records in the log at path and returns the length
+// of the lines it applied, where a torn line after them starts. A last line
+// without its newline is torn too, as records are written with theirs. The
+// caller holds the write lock and the log is not open yet, so nothing is
+// logged again.
+func (m *MemoryStore) replayWAL(path string) (int64, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64<<10), walMaxRecord)
+	var read, good int64
+	terminated := false
+	sc.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		read += int64(advance)
+		terminated = advance > 0 && data[advance-1] == '\n'
+		return advance, token, err
+	})
+	var pending error
+	for line := 1; sc.Scan(); line++ {
+		if pending != nil {
+			return 0, pending // a bad line that wasn't the last one
+		}
+		var rec walRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil || !terminated {
+			pending = fmt.Errorf("line %d: %w", line, cmp.Or(err, io.ErrUnexpectedEOF))
+			continue
+		}
+		if err := m.apply(rec); err != nil {
+			return 0, fmt.Errorf("line %d: %w", line, err)
+		}
+		good = read
+	}
+	return good, sc.Err()
+}
+
+// truncateTorn cuts the log at path, if it exists, down to its first good
+// bytes.
+func truncateTorn(path string, good int64) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil || info.Size() == good {
+		return err
+	}
+	if err := os.Truncate(path, good); err != nil {
+		return fmt.Errorf("drop the torn end of %s: %w", path, err)
+	}
+	return nil
+}
+
+// check reports what is wrong with rec, before any of it is applied.
+func (rec walRecord) check() error {
+	switch rec.Op {
+	case walPutUser:
+		if rec.User == nil {
+			return errors.New("put_user without a user")
+		}
+	case walDeleteUser:
+	case walPutPreferences:
+		if rec.Preferences == nil {
+			return errors.New("put_preferences without preferences")
+		}
+	case walPutCredentials:
+		if rec.Credentials == nil {
+			return errors.New("put_credentials without credentials")
+		}
+	case walPutPost:
+		if rec.Post == nil {
+			return errors.New("put_post without a post")
+		}
+	case walDeletePost:
+	case walPutComment:
+		if rec.Comment == nil {
+			return errors.New("put_comment without a comment")
+		}
+	case walDeleteComment:
+	case walPutInvitation:
+		if rec.Invitation == nil {
+			return errors.New("put_invitation without an invitation")
+		}
+	case walDeleteInvitation:
+	case walBatch:
+		for _, r := range rec.Batch {
+			if r.Op == walBatch {
+				return errors.New("batch inside a batch")
+			}
+			if err := r.check(); err != nil {
+				return err
+			}
+		}
+		for _, r := range rec.Expect {
+			switch r.Op {
+			case walPutUser, walPutPreferences, walPutCredentials, walPutPost, walPutComment, walPutInvitation:
+			default:
+				return fmt.Errorf("batch expects a %q record", r.Op)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown operation %q", rec.Op)
+	}
+	return nil
+}
+
+// apply replays one record, or none of it if it doesn't check out. The
+// caller holds the write lock.
+func (m *MemoryStore) apply(rec walRecord) error {
+	if err := rec.check(); err != nil {
+		return err
+	}
+	m.applyChecked(rec)
+	return nil
+}
+
+func (m *MemoryStore) applyChecked(rec walRecord) {
+	switch rec.Op {
+	case walPutUser:
+		m.putUser(rec.User)
+	case walDeleteUser:
+		m.remove(rec.ID)
+	case walPutPreferences:
+		m.preferences[rec.ID] = rec.Preferences
+	case walPutCredentials:
+		m.credentials[rec.ID] = rec.Credentials
+	case walPutPost:
+		m.putPost(rec.Post)
+	case walDeletePost:
+		m.removePost(rec.ID)
+	case walPutComment:
+		// Like putPost, a comment whose post or author is gone went with
+		// them.
+		if m.canComment(rec.Comment) {
+			m.comments[rec.Comment.ID] = rec.Comment
+		}
+	case walDeleteComment:
+		m.removeComments(func(c *Comment) bool { return c.ID == rec.ID })
+	case walPutInvitation:
+		m.invitations[rec.Invitation.ID] = rec.Invitation
+	case walDeleteInvitation:
+		delete(m.invitations, rec.ID)
+	case walBatch:
+		for _, r := range rec.Batch {
+			m.applyChecked(r)
+		}
+	}
+}
+
+// logMutation appends rec to the write-ahead log, if one is open, and syncs
+// it. The caller holds the write lock.
+func (m *MemoryStore) logMutation(rec walRecord) error {
+	if m.wal == nil {
+		return nil
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := m.wal.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("append to write-ahead log: %w", err)
+	}
+	if err := m.wal.Sync(); err != nil {
+		return fmt.Errorf("sync write-ahead log: %w", err)
+	}
+	return nil
+}
+
+// rotateWAL moves the log's entries to the compacting file and starts an
+// empty log, returning the compacting file's path. Entries left there by an
+// earlier compaction that failed are kept in front. The caller holds the
+// write lock and is about to save a snapshot covering every moved entry.
+func (m *MemoryStore) rotateWAL() (string, error) {
+	if m.wal == nil {
+		return "", nil
+	}
+	compacting := compactingPath(m.walPath)
+	dst, err := os.OpenFile(compacting, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return "", err
+	}
+	src, err := os.Open(m.walPath)
+	if err != nil {
+		dst.Close()
+		return "", err
+	}
+	_, err = io.Copy(dst, src)
+	src.Close()
+	if err == nil {
+		err = dst.Sync()
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := m.wal.Truncate(0); err != nil {
+		return "", err
+	}
+	return compacting, nil
+}
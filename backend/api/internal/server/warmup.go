@@ -0,0 +1,132 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// defaultWarmUpTimeout bounds the warm-up when Config.WarmUpTimeout is
+// zero.
+const defaultWarmUpTimeout = 30 * time.Second
+
+// warmUpRounds is how many times the warm-up sends each of warmUpPaths.
+const warmUpRounds = 3
+
+// warmUpUserAgent is the User-Agent of the warm-up's requests, which go
+// through every middleware and so show in the logs and metrics like any
+// other.
+const warmUpUserAgent = "backend-api-warm-up"
+
+// warmUpPaths are the requests the warm-up serves itself: the first pages of
+// the lists clients load first.
+var warmUpPaths = []string{"/v1/users", "/v1/users?include_inactive=true", "/v1/posts"}
+
+var errWarmingUp = errors.New("warming up")
+
+// warmUp is the warm_up component Run adds with Config.WarmUp. Once the
+// listeners are up, it primes the stats cache and serves the server
+// warmUpPaths, so what the first requests after a deploy would pay for,
+// like the JSON encoders of the response types, which encoding/json builds
+// on first use, and the stores' pages, isn't paid by clients. Its readiness
+// gate fails until it is done, so /readyz keeps the load balancer from
+// sending traffic before; a warm-up that takes longer than
+// Config.WarmUpTimeout is given up on. Requests that fail are logged and
+// don't keep the server from being ready.
+type warmUp struct {
+	s      *Server
+	done   atomic.Bool
+	cancel context.CancelFunc
+	exited chan struct{}
+}
+
+func (s *Server) warmUpComponent() *component {
+	w := &warmUp{s: s, exited: make(chan struct{})}
+	return &component{
+		name:  "warm_up",
+		after: []string{"http"},
+		start: w.start,
+		stop:  w.stop,
+		ready: func() error {
+			if !w.done.Load() {
+				return errWarmingUp
+			}
+			return nil
+		},
+	}
+}
+
+// start warms up in the background, so Run can serve and shut down
+// meanwhile.
+func (w *warmUp) start(context.Context) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cmp.Or(w.s.cfg.WarmUpTimeout, defaultWarmUpTimeout))
+	w.cancel = cancel
+	go func() {
+		defer close(w.exited)
+		defer cancel()
+		w.run(ctx)
+		w.done.Store(true)
+	}()
+	return nil
+}
+
+// stop ends a warm-up still running and waits for it, until ctx ends.
+func (w *warmUp) stop(ctx context.Context) error {
+	w.cancel()
+	select {
+	case <-w.exited:
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+func (w *warmUp) run(ctx context.Context) {
+	s, started := w.s, time.Now()
+	if s.cfg.StatsCacheTTL > 0 {
+		if _, err := s.stats(ctx); err != nil {
+			s.logger.Warn("failed to prime the stats cache", "err", err)
+		}
+	}
+	var sent, failed int
+	for range warmUpRounds {
+		for _, path := range warmUpPaths {
+			if ctx.Err() != nil {
+				s.logger.Warn("warm-up timed out", "requests", sent, "failed", failed, "duration", time.Since(started))
+				return
+			}
+			sent++
+			if status := w.serve(ctx, path); status >= http.StatusBadRequest {
+				failed++
+				s.logger.Debug("warm-up request failed", "path", path, "status", status)
+			}
+		}
+	}
+	s.logger.Info("warmed up", "requests", sent, "failed", failed, "duration", time.Since(started))
+}
+
+// serve sends the router a GET of path, as if from the host itself, and
+// returns the status it answered with.
+func (w *warmUp) serve(ctx context.Context, path string) int {
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return http.StatusInternalServerError
+	}
+	r.Host, r.RequestURI, r.RemoteAddr = "localhost", path, "127.0.0.1:0"
+	r.Header.Set("User-Agent", warmUpUserAgent)
+	rec := &discardWriter{header: http.Header{}, status: http.StatusOK}
+	w.s.router.ServeHTTP(rec, r)
+	return rec.status
+}
+
+// discardWriter is a ResponseWriter keeping only the status.
+type discardWriter struct {
+	header http.Header
+	status int
+}
+
+func (d *discardWriter) Header() http.Header         { return d.header }
+func (d *discardWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (d *discardWriter) WriteHeader(status int)      { d.status = status }
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWarmUp(t *testing.T) {
+	s := NewServer(Config{WarmUp: true, StatsCacheTTL: time.Minute}, NewMemoryStore())
+	c := s.warmUpComponent()
+	if err := c.ready(); err == nil {
+		t.Error("ready before warming up")
+	}
+	if err := c.start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); c.ready() != nil; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("not ready after 5s")
+		}
+	}
+	if err := c.stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	s.statsCache.mu.Lock()
+	primed := s.statsCache.stats != nil
+	s.statsCache.mu.Unlock()
+	if !primed {
+		t.Error("stats cache not primed")
+	}
+	if r := s.telemetry.report(time.Now()); r.Requests["get-v1-users"] != 2*warmUpRounds || r.Requests["get-v1-posts"] != warmUpRounds {
+		t.Errorf("served %v, want every warm-up request %d times", r.Requests, warmUpRounds)
+	}
+}
@@ -0,0 +1,67 @@
+package signedurl
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func testSigner(t *testing.T, b byte) *Signer {
+	t.Helper()
+	s, err := New(bytes.Repeat([]byte{b}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// parse splits a signed link into what Verify takes.
+func parse(t *testing.T, link string) (path string, expires int64, signature string) {
+	t.Helper()
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expires, err = strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Path, expires, u.Query().Get("signature")
+}
+
+func TestSignVerify(t *testing.T) {
+	s := testSigner(t, 1)
+	now := time.Now()
+	path, expires, sig := parse(t, s.Sign("/v1/downloads/exports/exp_1", now.Add(time.Hour)))
+	if path != "/v1/downloads/exports/exp_1" || expires != now.Add(time.Hour).Unix() {
+		t.Fatalf("signed %s until %d", path, expires)
+	}
+	if err := s.Verify(path, expires, sig, now); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Verify(path, expires, sig, now.Add(time.Hour)); !errors.Is(err, ErrExpired) {
+		t.Errorf("Verify after expiry = %v, want ErrExpired", err)
+	}
+	if err := s.Verify(path, expires+3600, sig, now); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Verify of a later expiry = %v, want ErrInvalid", err)
+	}
+	if err := s.Verify("/v1/downloads/exports/exp_2", expires, sig, now); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Verify of another path = %v, want ErrInvalid", err)
+	}
+	if err := testSigner(t, 2).Verify(path, expires, sig, now); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Verify under another key = %v, want ErrInvalid", err)
+	}
+	if err := s.Verify(path, expires, "not base64!", now); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Verify of a malformed signature = %v, want ErrInvalid", err)
+	}
+}
+
+func TestNewRejectsShortKey(t *testing.T) {
+	if _, err := New([]byte("short")); err == nil {
+		t.Fatal("New accepted a 5 byte key")
+	}
+}
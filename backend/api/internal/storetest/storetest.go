@@ -0,0 +1,635 @@
+// Package storetest is the conformance suite of server.Store: what the
+// services count on any implementation to do, checked against it, plus
+// benchmarks to compare implementations by. A Store runs both from its own
+// tests:
+//
+//	func TestStore(t *testing.T) { storetest.Run(t, newStore) }
+//
+//	func BenchmarkStore(b *testing.B) { storetest.Benchmark(b, newStore) }
+//
+// Run with -race, the concurrency tests are the race test of the store.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/server"
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/timestamp"
+)
+
+// NewStore returns an empty store for one test or benchmark, registering
+// whatever has to be cleaned up after it with tb.Cleanup.
+type NewStore func(tb testing.TB) server.Store
+
+// Run checks the store newStore returns against everything the Store
+// interface promises, each in a subtest of t with a store of its own.
+func Run(t *testing.T, newStore NewStore) {
+	for _, tc := range []struct {
+		name string
+		test func(*testing.T, server.Store)
+	}{
+		{"Users", testUsers},
+		{"Copies", testCopies},
+		{"PreferencesAndCredentials", testPreferencesAndCredentials},
+		{"Posts", testPosts},
+		{"Comments", testComments},
+		{"Invitations", testInvitations},
+		{"DeleteUserCascades", testDeleteUserCascades},
+		{"ConcurrentWriters", testConcurrentWriters},
+		{"ConcurrentReadersAndWriters", testConcurrentReadersAndWriters},
+	} {
+		t.Run(tc.name, func(t *testing.T) { tc.test(t, newStore(t)) })
+	}
+}
+
+// fixed is the time of every fixture, down to the millisecond like
+// timestamp.Time, so stores that encode it give back the same.
+var fixed = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func newUser(id string) *server.User {
+	login := timestamp.From(fixed.Add(time.Hour))
+	return &server.User{
+		ID:          id,
+		Username:    "user_" + id,
+		Name:        "User " + id,
+		Email:       id + "@example.com",
+		Phone:       "+436601234567",
+		Status:      server.UserStatusActive,
+		Active:      true,
+		LastLoginAt: &login,
+		// Numbers are float64, as they come out of JSON.
+		Metadata: map[string]any{"plan": "pro", "seats": float64(3), "flags": []any{"beta"}},
+		Tags:     []string{"vip"},
+	}
+}
+
+func newPost(id, authorID string) *server.Post {
+	return &server.Post{ID: id, AuthorID: authorID, Title: "Post " + id, Body: "Text of " + id, CreatedAt: timestamp.From(fixed), UpdatedAt: timestamp.From(fixed)}
+}
+
+func newComment(id, postID, authorID, parentID string) *server.Comment {
+	return &server.Comment{ID: id, PostID: postID, AuthorID: authorID, ParentID: parentID, Body: "Comment " + id, Status: server.CommentPublished, CreatedAt: timestamp.From(fixed), UpdatedAt: timestamp.From(fixed)}
+}
+
+// must fails the test if err is set.
+func must(t testing.TB, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func wantNotFound(t *testing.T, what string, err error) {
+	t.Helper()
+	if !errors.Is(err, server.ErrNotFound) {
+		t.Errorf("%s: err = %v, want ErrNotFound", what, err)
+	}
+}
+
+func userIDs(users []*server.User) []string {
+	ids := make([]string, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func commentIDs(comments []*server.Comment) []string {
+	ids := make([]string, len(comments))
+	for i, c := range comments {
+		ids[i] = c.ID
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func testUsers(t *testing.T, s server.Store) {
+	ctx := context.Background()
+	_, err := s.GetUser(ctx, "missing")
+	wantNotFound(t, "GetUser of a missing user", err)
+	wantNotFound(t, "DeleteUser of a missing user", s.DeleteUser(ctx, "missing"))
+	if users, err := s.ListUsers(ctx); err != nil || len(users) != 0 {
+		t.Errorf("ListUsers of an empty store = %d users, %v", len(users), err)
+	}
+
+	ada := newUser("ada")
+	must(t, s.PutUser(ctx, ada))
+	got, err := s.GetUser(ctx, "ada")
+	must(t, err)
+	if !reflect.DeepEqual(got, ada) {
+		t.Errorf("GetUser = %+v, want %+v", got, ada)
+	}
+
+	// PutUser replaces the user with the same ID.
+	renamed := newUser("ada")
+	renamed.Name, renamed.Tags, renamed.Metadata = "Ada L.", nil, nil
+	must(t, s.PutUser(ctx, renamed))
+	if got, err := s.GetUser(ctx, "ada"); err != nil || !reflect.DeepEqual(got, renamed) {
+		t.Errorf("GetUser after replacing = %+v, %v; want %+v", got, err, renamed)
+	}
+
+	must(t, s.PutUser(ctx, newUser("lin")))
+	users, err := s.ListUsers(ctx)
+	must(t, err)
+	if got := userIDs(users); !slices.Equal(got, []string{"ada", "lin"}) {
+		t.Errorf("ListUsers = %v, want ada and lin", got)
+	}
+
+	must(t, s.DeleteUser(ctx, "ada"))
+	_, err = s.GetUser(ctx, "ada")
+	wantNotFound(t, "GetUser after DeleteUser", err)
+	users, err = s.ListUsers(ctx)
+	must(t, err)
+	if got := userIDs(users); !slices.Equal(got, []string{"lin"}) {
+		t.Errorf("ListUsers after DeleteUser = %v, want lin", got)
+	}
+}
+
+// testCopies checks that the store hands out copies: changing what was put
+// or got, field by field, doesn't change what is stored. Slices and maps
+// are only ever replaced wholesale, so the store may share them.
+func testCopies(t *testing.T, s server.Store) {
+	ctx := context.Background()
+	ada := newUser("ada")
+	must(t, s.PutUser(ctx, ada))
+	ada.Name, ada.Metadata = "changed after PutUser", map[string]any{}
+	got, err := s.GetUser(ctx, "ada")
+	must(t, err)
+	got.Email, got.Status, got.Tags = "changed@example.com", server.UserStatusSuspended, nil
+	listed, err := s.ListUsers(ctx)
+	must(t, err)
+	listed[0].Username = "changed"
+	if again, err := s.GetUser(ctx, "ada"); err != nil || !reflect.DeepEqual(again, newUser("ada")) {
+		t.Errorf("a changed copy changed the stored user: %+v, %v", again, err)
+	}
+
+	must(t, s.PutPost(ctx, newPost("p1", "ada")))
+	post, err := s.GetPost(ctx, "p1")
+	must(t, err)
+	post.Title = "changed"
+	must(t, s.PutComment(ctx, newComment("c1", "p1", "ada", "")))
+	comment, err := s.GetComment(ctx, "c1")
+	must(t, err)
+	comment.Status = server.CommentHidden
+	if again, _ := s.GetPost(ctx, "p1"); again == nil || again.Title != "Post p1" {
+		t.Errorf("a changed copy changed the stored post: %+v", again)
+	}
+	if again, _ := s.GetComment(ctx, "c1"); again == nil || again.Status != server.CommentPublished {
+		t.Errorf("a changed copy changed the stored comment: %+v", again)
+	}
+
+	prefs := &server.UserPreferences{Locale: "de-AT", Timezone: "Europe/Vienna"}
+	must(t, s.PutPreferences(ctx, "ada", prefs))
+	prefs.Locale = "fr"
+	if got, err := s.GetPreferences(ctx, "ada"); err != nil || got.Locale != "de-AT" {
+		t.Errorf("a changed copy changed the stored preferences: %+v, %v", got, err)
+	}
+}
+
+func testPreferencesAndCredentials(t *testing.T, s server.Store) {
+	ctx := context.Background()
+	must(t, s.PutUser(ctx, newUser("ada")))
+	_, err := s.GetPreferences(ctx, "ada")
+	wantNotFound(t, "GetPreferences before any were saved", err)
+	_, err = s.GetCredentials(ctx, "ada")
+	wantNotFound(t, "GetCredentials before any were saved", err)
+
+	on := true
+	prefs := &server.UserPreferences{Locale: "de-AT", Timezone: "Europe/Vienna", Notifications: server.NotificationPreferences{Email: &on, Digest: "daily"}}
+	must(t, s.PutPreferences(ctx, "ada", prefs))
+	if got, err := s.GetPreferences(ctx, "ada"); err != nil || !reflect.DeepEqual(got, prefs) {
+		t.Errorf("GetPreferences = %+v, %v; want %+v", got, err, prefs)
+	}
+
+	used := timestamp.From(fixed.Add(time.Minute))
+	creds := &server.Credentials{
+		PasswordHash: "$2a$10$hash",
+		ChangedAt:    timestamp.From(fixed),
+		APIKeys: []server.StoredAPIKey{{
+			APIKey:     server.APIKey{ID: "key_1", Name: "CI", Prefix: "mk_ada_key_1", Scopes: []string{server.ScopeUsersRead}, CreatedAt: timestamp.From(fixed), LastUsedAt: &used},
+			SecretHash: "secret",
+		}},
+	}
+	must(t, s.PutCredentials(ctx, "ada", creds))
+	if got, err := s.GetCredentials(ctx, "ada"); err != nil || !reflect.DeepEqual(got, creds) {
+		t.Errorf("GetCredentials = %+v, %v; want %+v", got, err, creds)
+	}
+
+	// Saving again replaces them.
+	creds = &server.Credentials{PasswordHash: "$2a$10$other", ChangedAt: timestamp.From(fixed.Add(time.Hour))}
+	must(t, s.PutCredentials(ctx, "ada", creds))
+	if got, err := s.GetCredentials(ctx, "ada"); err != nil || !reflect.DeepEqual(got, creds) {
+		t.Errorf("GetCredentials after replacing = %+v, %v; want %+v", got, err, creds)
+	}
+}
+
+func testPosts(t *testing.T, s server.Store) {
+	ctx := context.Background()
+	wantNotFound(t, "PutPost by a missing author", s.PutPost(ctx, newPost("p1", "missing")))
+	_, err := s.GetPost(ctx, "p1")
+	wantNotFound(t, "GetPost of a missing post", err)
+	wantNotFound(t, "DeletePost of a missing post", s.DeletePost(ctx, "p1"))
+
+	must(t, s.PutUser(ctx, newUser("ada")))
+	post := newPost("p1", "ada")
+	must(t, s.PutPost(ctx, post))
+	if got, err := s.GetPost(ctx, "p1"); err != nil || !reflect.DeepEqual(got, post) {
+		t.Errorf("GetPost = %+v, %v; want %+v", got, err, post)
+	}
+	edited := newPost("p1", "ada")
+	edited.Title = "Edited"
+	must(t, s.PutPost(ctx, edited))
+	must(t, s.PutPost(ctx, newPost("p2", "ada")))
+	posts, err := s.ListPosts(ctx)
+	must(t, err)
+	if len(posts) != 2 {
+		t.Fatalf("ListPosts = %d posts, want 2", len(posts))
+	}
+	for _, p := range posts {
+		if p.ID == "p1" && p.Title != "Edited" {
+			t.Errorf("ListPosts has p1 titled %q, want it replaced", p.Title)
+		}
+	}
+
+	// Deleting a post takes its comments, replies included, with it.
+	must(t, s.PutComment(ctx, newComment("c1", "p1", "ada", "")))
+	must(t, s.PutComment(ctx, newComment("c2", "p1", "ada", "c1")))
+	must(t, s.PutComment(ctx, newComment("c3", "p2", "ada", "")))
+	must(t, s.DeletePost(ctx, "p1"))
+	_, err = s.GetPost(ctx, "p1")
+	wantNotFound(t, "GetPost after DeletePost", err)
+	_, err = s.GetComment(ctx, "c2")
+	wantNotFound(t, "GetComment of a reply on a deleted post", err)
+	comments, err := s.ListComments(ctx, "p1", "p2")
+	must(t, err)
+	if got := commentIDs(comments); !slices.Equal(got, []string{"c3"}) {
+		t.Errorf("ListComments after DeletePost = %v, want c3", got)
+	}
+}
+
+func testComments(t *testing.T, s server.Store) {
+	ctx := context.Background()
+	must(t, s.PutUser(ctx, newUser("ada")))
+	must(t, s.PutPost(ctx, newPost("p1", "ada")))
+	must(t, s.PutPost(ctx, newPost("p2", "ada")))
+	wantNotFound(t, "PutComment on a missing post", s.PutComment(ctx, newComment("c1", "missing", "ada", "")))
+	wantNotFound(t, "PutComment by a missing author", s.PutComment(ctx, newComment("c1", "p1", "missing", "")))
+	_, err := s.GetComment(ctx, "c1")
+	wantNotFound(t, "GetComment of a missing comment", err)
+	wantNotFound(t, "DeleteComment of a missing comment", s.DeleteComment(ctx, "c1"))
+	if comments, err := s.ListComments(ctx); err != nil || len(comments) != 0 {
+		t.Errorf("ListComments of no posts = %d comments, %v", len(comments), err)
+	}
+
+	// c1 has a thread of replies three deep; c5 is on another post.
+	comment := newComment("c1", "p1", "ada", "")
+	must(t, s.PutComment(ctx, comment))
+	must(t, s.PutComment(ctx, newComment("c2", "p1", "ada", "c1")))
+	must(t, s.PutComment(ctx, newComment("c3", "p1", "ada", "c2")))
+	must(t, s.PutComment(ctx, newComment("c4", "p1", "ada", "")))
+	must(t, s.PutComment(ctx, newComment("c5", "p2", "ada", "")))
+	if got, err := s.GetComment(ctx, "c1"); err != nil || !reflect.DeepEqual(got, comment) {
+		t.Errorf("GetComment = %+v, %v; want %+v", got, err, comment)
+	}
+	for _, tc := range []struct {
+		posts []string
+		want  []string
+	}{
+		{[]string{"p1"}, []string{"c1", "c2", "c3", "c4"}},
+		{[]string{"p2"}, []string{"c5"}},
+		{[]string{"p1", "p2", "missing"}, []string{"c1", "c2", "c3", "c4", "c5"}},
+	} {
+		comments, err := s.ListComments(ctx, tc.posts...)
+		must(t, err)
+		if got := commentIDs(comments); !slices.Equal(got, tc.want) {
+			t.Errorf("ListComments(%v) = %v, want %v", tc.posts, got, tc.want)
+		}
+	}
+
+	hidden := newComment("c4", "p1", "ada", "")
+	hidden.Status = server.CommentHidden
+	must(t, s.PutComment(ctx, hidden))
+	if got, err := s.GetComment(ctx, "c4"); err != nil || got.Status != server.CommentHidden {
+		t.Errorf("GetComment after replacing = %+v, %v; want it hidden", got, err)
+	}
+
+	// Deleting a comment takes the whole thread under it.
+	must(t, s.DeleteComment(ctx, "c1"))
+	comments, err := s.ListComments(ctx, "p1")
+	must(t, err)
+	if got := commentIDs(comments); !slices.Equal(got, []string{"c4"}) {
+		t.Errorf("ListComments after DeleteComment = %v, want c4", got)
+	}
+}
+
+func newInvitation(id string) *server.StoredInvitation {
+	return &server.StoredInvitation{
+		Invitation: server.Invitation{ID: id, Email: id + "@example.com", Name: "Invitee " + id, Locale: "en", Status: server.InvitationPending, CreatedAt: timestamp.From(fixed), ExpiresAt: timestamp.From(fixed.Add(24 * time.Hour))},
+		TokenHash:  "hash of " + id,
+	}
+}
+
+func testInvitations(t *testing.T, s server.Store) {
+	ctx := context.Background()
+	_, err := s.GetInvitation(ctx, "inv_1")
+	wantNotFound(t, "GetInvitation of a missing invitation", err)
+	wantNotFound(t, "DeleteInvitation of a missing invitation", s.DeleteInvitation(ctx, "inv_1"))
+	if invitations, err := s.ListInvitations(ctx); err != nil || len(invitations) != 0 {
+		t.Errorf("ListInvitations of an empty store = %d invitations, %v", len(invitations), err)
+	}
+
+	inv := newInvitation("inv_1")
+	must(t, s.PutInvitation(ctx, inv))
+	must(t, s.PutInvitation(ctx, newInvitation("inv_2")))
+	got, err := s.GetInvitation(ctx, "inv_1")
+	must(t, err)
+	if !reflect.DeepEqual(got, inv) {
+		t.Errorf("GetInvitation = %+v, want %+v", got, inv)
+	}
+	inv.Email = "changed after PutInvitation"
+	got.Name = "changed after GetInvitation"
+	if again, err := s.GetInvitation(ctx, "inv_1"); err != nil || !reflect.DeepEqual(again, newInvitation("inv_1")) {
+		t.Errorf("a changed copy changed the stored invitation: %+v, %v", again, err)
+	}
+
+	// PutInvitation replaces the invitation with the same ID.
+	accepted := newInvitation("inv_1")
+	at := timestamp.From(fixed.Add(time.Hour))
+	accepted.Status, accepted.AcceptedAt, accepted.UserID = server.InvitationAccepted, &at, "ada"
+	must(t, s.PutInvitation(ctx, accepted))
+	if got, err := s.GetInvitation(ctx, "inv_1"); err != nil || !reflect.DeepEqual(got, accepted) {
+		t.Errorf("GetInvitation after replacing = %+v, %v; want %+v", got, err, accepted)
+	}
+
+	must(t, s.DeleteInvitation(ctx, "inv_2"))
+	_, err = s.GetInvitation(ctx, "inv_2")
+	wantNotFound(t, "GetInvitation after DeleteInvitation", err)
+	invitations, err := s.ListInvitations(ctx)
+	must(t, err)
+	if len(invitations) != 1 || !reflect.DeepEqual(invitations[0], accepted) {
+		t.Errorf("ListInvitations after DeleteInvitation = %+v, want inv_1 accepted", invitations)
+	}
+}
+
+func testDeleteUserCascades(t *testing.T, s server.Store) {
+	ctx := context.Background()
+	must(t, s.PutUser(ctx, newUser("ada")))
+	must(t, s.PutUser(ctx, newUser("lin")))
+	must(t, s.PutPreferences(ctx, "ada", &server.UserPreferences{Locale: "en"}))
+	must(t, s.PutCredentials(ctx, "ada", &server.Credentials{PasswordHash: "hash"}))
+	must(t, s.PutPost(ctx, newPost("ada-post", "ada")))
+	must(t, s.PutPost(ctx, newPost("lin-post", "lin")))
+	// Lin's comment on Ada's post goes with the post; Ada's on Lin's post,
+	// and Lin's reply to it, go with Ada.
+	must(t, s.PutComment(ctx, newComment("lin-on-ada", "ada-post", "lin", "")))
+	must(t, s.PutComment(ctx, newComment("ada-on-lin", "lin-post", "ada", "")))
+	must(t, s.PutComment(ctx, newComment("lin-reply", "lin-post", "lin", "ada-on-lin")))
+	must(t, s.PutComment(ctx, newComment("lin-on-lin", "lin-post", "lin", "")))
+
+	must(t, s.DeleteUser(ctx, "ada"))
+	_, err := s.GetPreferences(ctx, "ada")
+	wantNotFound(t, "GetPreferences of a deleted user", err)
+	_, err = s.GetCredentials(ctx, "ada")
+	wantNotFound(t, "GetCredentials of a deleted user", err)
+	_, err = s.GetPost(ctx, "ada-post")
+	wantNotFound(t, "GetPost of a deleted user's post", err)
+	comments, err := s.ListComments(ctx, "ada-post", "lin-post")
+	must(t, err)
+	if got := commentIDs(comments); !slices.Equal(got, []string{"lin-on-lin"}) {
+		t.Errorf("ListComments after DeleteUser = %v, want lin-on-lin", got)
+	}
+	if _, err := s.GetPost(ctx, "lin-post"); err != nil {
+		t.Errorf("GetPost of another user's post: %v", err)
+	}
+}
+
+// workers is how many goroutines the concurrency tests run at once.
+const workers = 8
+
+// testConcurrentWriters has every worker write users, posts and comments of
+// its own at once, then checks none were lost.
+func testConcurrentWriters(t *testing.T, s server.Store) {
+	ctx := context.Background()
+	const perWorker = 24
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perWorker {
+				id := fmt.Sprintf("w%d-%d", w, i)
+				err := errors.Join(
+					s.PutUser(ctx, newUser(id)),
+					s.PutPreferences(ctx, id, &server.UserPreferences{Locale: "en"}),
+					s.PutPost(ctx, newPost(id, id)),
+					s.PutComment(ctx, newComment(id, id, id, "")),
+				)
+				// Every other user goes again, to race deletes with writes.
+				if err == nil && i%2 == 1 {
+					err = s.DeleteUser(ctx, id)
+				}
+				if err != nil {
+					errs <- fmt.Errorf("%s: %w", id, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	users, err := s.ListUsers(ctx)
+	must(t, err)
+	posts, err := s.ListPosts(ctx)
+	must(t, err)
+	postIDs := make([]string, len(posts))
+	for i, p := range posts {
+		postIDs[i] = p.ID
+	}
+	comments, err := s.ListComments(ctx, postIDs...)
+	must(t, err)
+	want := workers * perWorker / 2
+	if len(users) != want || len(posts) != want || len(comments) != want {
+		t.Errorf("%d users, %d posts and %d comments; want %d of each", len(users), len(posts), len(comments), want)
+	}
+}
+
+// testConcurrentReadersAndWriters reads while others write the same
+// records, for the race detector to catch what isn't copied or locked.
+func testConcurrentReadersAndWriters(t *testing.T, s server.Store) {
+	ctx := context.Background()
+	must(t, s.PutUser(ctx, newUser("ada")))
+	must(t, s.PutPost(ctx, newPost("p1", "ada")))
+	const rounds = 50
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range rounds {
+				if w%2 == 0 {
+					u := newUser("ada")
+					u.Name = fmt.Sprintf("Ada %d-%d", w, i)
+					u.Metadata = map[string]any{"round": float64(i)}
+					s.PutUser(ctx, u)
+					s.PutComment(ctx, newComment(fmt.Sprintf("c%d-%d", w, i), "p1", "ada", ""))
+					s.PutCredentials(ctx, "ada", &server.Credentials{PasswordHash: u.Name})
+					continue
+				}
+				if u, err := s.GetUser(ctx, "ada"); err == nil {
+					_ = u.Name + fmt.Sprint(u.Metadata["round"])
+				}
+				s.ListUsers(ctx)
+				s.ListComments(ctx, "p1")
+				s.GetCredentials(ctx, "ada")
+			}
+		}()
+	}
+	wg.Wait()
+	comments, err := s.ListComments(ctx, "p1")
+	must(t, err)
+	if want := workers / 2 * rounds; len(comments) != want {
+		t.Errorf("%d comments, want %d", len(comments), want)
+	}
+}
+
+// Benchmark measures the store newStore returns at what the API does most,
+// each in a sub-benchmark of b with a store of its own, filled with
+// benchUsers users with a post and comments each. Compare stores with
+// benchstat.
+func Benchmark(b *testing.B, newStore NewStore) {
+	for _, bc := range []struct {
+		name  string
+		bench func(*testing.B, server.Store)
+	}{
+		{"GetUser", benchGetUser},
+		{"GetUserParallel", benchGetUserParallel},
+		{"PutUser", benchPutUser},
+		{"PutUserParallel", benchPutUserParallel},
+		{"ListUsers", benchListUsers},
+		{"ListComments", benchListComments},
+		{"MixedParallel", benchMixedParallel},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			s := newStore(b)
+			fill(b, s)
+			b.ReportAllocs()
+			b.ResetTimer()
+			bc.bench(b, s)
+		})
+	}
+}
+
+// benchUsers is how many users the benchmarks' stores start with.
+const benchUsers = 1000
+
+func benchID(i int) string { return fmt.Sprintf("u%05d", i%benchUsers) }
+
+func fill(b *testing.B, s server.Store) {
+	ctx := context.Background()
+	for i := range benchUsers {
+		id := benchID(i)
+		must(b, s.PutUser(ctx, newUser(id)))
+		must(b, s.PutPost(ctx, newPost(id, id)))
+		for c := range 3 {
+			must(b, s.PutComment(ctx, newComment(fmt.Sprintf("%s-c%d", id, c), id, id, "")))
+		}
+	}
+}
+
+func benchGetUser(b *testing.B, s server.Store) {
+	ctx := context.Background()
+	for i := range b.N {
+		if _, err := s.GetUser(ctx, benchID(i)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchGetUserParallel(b *testing.B, s server.Store) {
+	ctx := context.Background()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			if _, err := s.GetUser(ctx, benchID(i)); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func benchPutUser(b *testing.B, s server.Store) {
+	ctx := context.Background()
+	for i := range b.N {
+		if err := s.PutUser(ctx, newUser(benchID(i))); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchPutUserParallel(b *testing.B, s server.Store) {
+	ctx := context.Background()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			if err := s.PutUser(ctx, newUser(benchID(i))); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func benchListUsers(b *testing.B, s server.Store) {
+	ctx := context.Background()
+	for range b.N {
+		if users, err := s.ListUsers(ctx); err != nil || len(users) != benchUsers {
+			b.Fatalf("ListUsers = %d users, %v", len(users), err)
+		}
+	}
+}
+
+// benchListComments loads the comments of a page of 20 posts at once, as
+// listing users with include=posts.comments does.
+func benchListComments(b *testing.B, s server.Store) {
+	ctx := context.Background()
+	page := make([]string, 20)
+	for i := range b.N {
+		for j := range page {
+			page[j] = benchID(i*len(page) + j)
+		}
+		if comments, err := s.ListComments(ctx, page...); err != nil || len(comments) != 3*len(page) {
+			b.Fatalf("ListComments = %d comments, %v", len(comments), err)
+		}
+	}
+}
+
+// benchMixedParallel is nine reads to a write, from every CPU at once.
+func benchMixedParallel(b *testing.B, s server.Store) {
+	ctx := context.Background()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			var err error
+			if i%10 == 0 {
+				err = s.PutUser(ctx, newUser(benchID(i)))
+			} else {
+				_, err = s.GetUser(ctx, benchID(i))
+			}
+			if err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
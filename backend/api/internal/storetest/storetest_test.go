@@ -0,0 +1,37 @@
+package storetest
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/server"
+)
+
+// The stores of this repo, as the server can be configured to use them.
+var stores = map[string]NewStore{
+	"Memory": func(testing.TB) server.Store { return server.NewMemoryStore() },
+	// Bounded loosely enough that nothing is evicted, to check the locking
+	// of reads that update the recency list.
+	"MemoryBounded": func(testing.TB) server.Store {
+		return server.NewMemoryStore(server.WithMaxUsers(1<<20), server.WithUserTTL(time.Hour))
+	},
+	"MemoryWAL": func(tb testing.TB) server.Store {
+		m := server.NewMemoryStore()
+		must(tb, m.OpenWAL(filepath.Join(tb.TempDir(), "store.wal")))
+		tb.Cleanup(func() { m.Close() })
+		return m
+	},
+}
+
+func TestStores(t *testing.T) {
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) { Run(t, newStore) })
+	}
+}
+
+func BenchmarkStores(b *testing.B) {
+	for name, newStore := range stores {
+		b.Run(name, func(b *testing.B) { Benchmark(b, newStore) })
+	}
+}
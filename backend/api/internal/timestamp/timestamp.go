@@ -0,0 +1,138 @@
+// Package timestamp is how the API writes and reads points in time. Every
+// timestamp the API sends is UTC in RFC 3339 with exactly millisecond
+// precision, like 2024-01-02T15:04:05.000Z, which JavaScript's Date parses
+// and produces as is. Reading is more forgiving, to take what clients and
+// older stored data commonly send; see Parse.
+package timestamp
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Layout is the format timestamps are written in.
+const Layout = "2006-01-02T15:04:05.000Z07:00"
+
+// Time is a time.Time that encodes as Layout in UTC, and is documented as a
+// date-time string in the spec.
+type Time struct {
+	time.Time
+}
+
+// From returns t as a Time, in UTC and truncated to the millisecond, so what
+// is kept is what clients see.
+func From(t time.Time) Time {
+	return Time{t.UTC().Truncate(time.Millisecond)}
+}
+
+// Ptr is From for optional timestamps; nil stays nil.
+func Ptr(t *time.Time) *Time {
+	if t == nil {
+		return nil
+	}
+	ts := From(*t)
+	return &ts
+}
+
+// Now is the current time as a Time.
+func Now() Time {
+	return From(time.Now())
+}
+
+// layouts are what Parse accepts besides RFC 3339. Those without a zone are
+// taken as UTC. time.Parse takes fractional seconds after the seconds of any
+// of them.
+var layouts = []string{
+	"2006-01-02T15:04:05Z0700", // zone without a colon
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// Parse reads a timestamp: RFC 3339 with or without fractional seconds, the
+// same with a space for the T, a zone without its colon or no zone at all,
+// which means UTC, a date alone, which means midnight UTC, or Unix time in
+// milliseconds.
+func Parse(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t.UTC(), nil
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(ms).UTC(), nil
+	}
+	return time.Time{}, errors.New("expected an RFC 3339 timestamp like 2024-01-02T15:04:05.000Z")
+}
+
+func (t Time) String() string {
+	return t.UTC().Format(Layout)
+}
+
+func (t Time) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *Time) UnmarshalText(b []byte) error {
+	parsed, err := Parse(string(b))
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+func (t Time) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + t.String() + `"`), nil
+}
+
+// UnmarshalJSON takes a string for Parse, or Unix time in milliseconds as a
+// number.
+func (t *Time) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	if s, err := strconv.Unquote(string(b)); err == nil {
+		b = []byte(s)
+	}
+	return t.UnmarshalText(b)
+}
+
+// MarshalCBOR writes a standard date/time string (tag 0), where the embedded
+// time.Time would otherwise have CBOR use its binary form.
+func (t Time) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(cbor.Tag{Number: 0, Content: t.String()})
+}
+
+// UnmarshalCBOR takes a date/time (tag 0 or 1) or a string for Parse.
+func (t *Time) UnmarshalCBOR(b []byte) error {
+	var v any
+	if err := cbor.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case time.Time:
+		t.Time = v.UTC()
+		return nil
+	case string:
+		return t.UnmarshalText([]byte(v))
+	}
+	return errors.New("expected a CBOR date/time or string")
+}
+
+// Schema documents Time as an RFC 3339 date-time string.
+func (Time) Schema(r huma.Registry) *huma.Schema {
+	return &huma.Schema{Type: huma.TypeString, Format: "date-time", Examples: []any{"2024-01-02T15:04:05.000Z"}}
+}
@@ -0,0 +1,55 @@
+package timestamp
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+func TestParse(t *testing.T) {
+	want := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	for _, s := range []string{
+		"2024-01-02T15:04:05Z",
+		"2024-01-02T15:04:05.000Z",
+		"2024-01-02T16:04:05+01:00",
+		"2024-01-02T16:04:05+0100",
+		"2024-01-02 15:04:05Z",
+		"2024-01-02T15:04:05",
+		"2024-01-02 15:04:05",
+		"1704207845000",
+	} {
+		got, err := Parse(s)
+		if err != nil || !got.Equal(want) || got.Location() != time.UTC {
+			t.Errorf("Parse(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	if got, _ := Parse("2024-01-02"); !got.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Parse(date) = %v, want midnight UTC", got)
+	}
+	if _, err := Parse("yesterday"); err == nil {
+		t.Error("Parse accepted yesterday")
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	ts := From(time.Date(2024, 1, 2, 16, 4, 5, 123456789, time.FixedZone("CET", 3600)))
+	b, err := json.Marshal(struct{ T Time }{ts})
+	if err != nil || string(b) != `{"T":"2024-01-02T15:04:05.123Z"}` {
+		t.Errorf("json.Marshal = %s, %v", b, err)
+	}
+	var back struct{ T Time }
+	if err := json.Unmarshal(b, &back); err != nil || !back.T.Equal(ts.Time) {
+		t.Errorf("json.Unmarshal = %v, %v, want %v", back.T, err, ts)
+	}
+
+	b, err = cbor.Marshal(ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromCBOR Time
+	if err := cbor.Unmarshal(b, &fromCBOR); err != nil || !fromCBOR.Equal(ts.Time) {
+		t.Errorf("CBOR round trip = %v, %v, want %v", fromCBOR, err, ts)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/go-chi/cors"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/mock"
+)
+
+// runMock serves spec's example responses on addr, open to any origin,
+// until ctx is done.
+func runMock(ctx context.Context, addr string, spec *huma.OpenAPI) error {
+	h, err := mock.Handler(spec)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Addr: cmp.Or(addr, ":8080"),
+		Handler: cors.Handler(cors.Options{
+			AllowOriginFunc: func(*http.Request, string) bool { return true },
+			AllowedMethods:  []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"},
+			AllowedHeaders:  []string{"*"},
+			ExposedHeaders:  []string{"X-Mock"},
+		})(h),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	log.Printf("Mock server running on %s, answering every operation with its example response\n", srv.Addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/replay"
+)
+
+// runRecord implements `api record`: it proxies --listen to a running API
+// at --target and records every exchange to --fixtures. It returns the
+// process exit code.
+//
+//	go run ./backend/api record --fixtures apps/dashboard/e2e/fixtures.ndjson
+func runRecord(args []string) int {
+	fs := flag.NewFlagSet("record", flag.ContinueOnError)
+	fixtures := fs.String("fixtures", "", "fixture file to record to; it is overwritten")
+	target := fs.String("target", "http://localhost:8080", "base URL of the API")
+	listen := fs.String("listen", ":8081", "address to proxy on")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *fixtures == "" {
+		fmt.Fprintln(os.Stderr, "record: --fixtures is required")
+		return 2
+	}
+	u, err := url.Parse(*target)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		fmt.Fprintf(os.Stderr, "record: --target: want an absolute URL, got %q\n", *target)
+		return 2
+	}
+	rec, err := replay.NewRecorder(u, *fixtures)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "record: %v\n", err)
+		return 1
+	}
+	defer rec.Close()
+	fmt.Printf("Recording %s to %s; point the client at %s\n", *target, *fixtures, *listen)
+	return serveUntilSignal("record", *listen, rec)
+}
+
+// runReplay implements `api replay`: it answers requests on --listen with
+// the responses `api record` recorded to --fixtures, without an API behind
+// it. It returns the process exit code.
+//
+//	go run ./backend/api replay --fixtures apps/dashboard/e2e/fixtures.ndjson
+func runReplay(args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	fixtures := fs.String("fixtures", "", "fixture file to replay")
+	listen := fs.String("listen", ":8080", "address to serve on")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *fixtures == "" {
+		fmt.Fprintln(os.Stderr, "replay: --fixtures is required")
+		return 2
+	}
+	rp, err := replay.Load(*fixtures)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Replaying %s on %s\n", *fixtures, *listen)
+	return serveUntilSignal("replay", *listen, rp)
+}
+
+// serveUntilSignal serves h on addr until SIGINT or SIGTERM and returns the
+// exit code.
+func serveUntilSignal(cmd, addr string, h http.Handler) int {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	srv := &http.Server{Addr: addr, Handler: h, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmd, err)
+		return 1
+	}
+	return 0
+}
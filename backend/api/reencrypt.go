@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// runReencrypt implements `api reencrypt`: after a new primary key is added
+// to PII_ENCRYPTION_KEYS or PII_KMS_KEY_IDS and the servers restarted, it
+// has a running API rewrite every email and phone under that key, after
+// which the old key can be removed. It returns the process exit code.
+//
+//	ADMIN_TOKEN=... go run ./backend/api reencrypt --target http://localhost:8080
+func runReencrypt(args []string) int {
+	fs := flag.NewFlagSet("reencrypt", flag.ContinueOnError)
+	target := fs.String("target", "http://localhost:8080", "base URL of the API")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	body, err := adminCall(ctx, http.MethodPost, strings.TrimSuffix(*target, "/")+"/admin/reencrypt", nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reencrypt: %v\n", err)
+		return 1
+	}
+	var res struct {
+		Checked     int    `json:"checked"`
+		Reencrypted int    `json:"reencrypted"`
+		KeyID       string `json:"key_id"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		fmt.Fprintf(os.Stderr, "reencrypt: decode response: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Re-encrypted %d of %d users under key %s\n", res.Reencrypted, res.Checked, res.KeyID)
+	return 0
+}
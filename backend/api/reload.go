@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/rohanchauhan02/monorepo-demo/backend/api/internal/server"
+)
+
+// configPollInterval is how often CONFIG_FILE is checked for changes.
+const configPollInterval = 2 * time.Second
+
+// watchConfig re-reads the configuration and applies it to srv whenever hup
+// receives a signal or, if CONFIG_FILE is set, the file's modification time
+// changes. A configuration that fails to load is logged and the current one
+// kept. It returns when ctx is done.
+func watchConfig(ctx context.Context, srv *server.Server, hup <-chan os.Signal) {
+	path := os.Getenv("CONFIG_FILE")
+	var poll <-chan time.Time
+	var modTime time.Time
+	if path != "" {
+		ticker := time.NewTicker(configPollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+		modTime = fileModTime(path)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			log.Println("SIGHUP received, reloading config")
+		case <-poll:
+			m := fileModTime(path)
+			if m.Equal(modTime) {
+				continue
+			}
+			modTime = m
+			log.Printf("%s changed, reloading config\n", path)
+		}
+		cfg, err := server.SettingsFromEnv()
+		if err != nil {
+			log.Printf("Config reload failed, keeping current settings: %v", err)
+			continue
+		}
+		srv.Reload(cfg)
+	}
+}
+
+// fileModTime returns path's modification time, or the zero time if it
+// can't be read.
+func fileModTime(path string) time.Time {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
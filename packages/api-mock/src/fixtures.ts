@@ -0,0 +1,6429 @@
+// Code generated by `api gen:mock` from the OpenAPI spec. DO NOT EDIT.
+
+import type { Fixture } from "./types";
+
+export const fixtures: Fixture[] = [
+  {
+    "operationId": "get-admin-backup",
+    "method": "GET",
+    "path": "/admin/backup",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/gzip",
+        "body": "string"
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-admin-dead-letters",
+    "method": "GET",
+    "path": "/admin/dead-letters",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/DeadLetterList.json",
+          "dead_letters": [
+            {
+              "$schema": "https://example.com/schemas/DeadLetter.json",
+              "attempts": [
+                {
+                  "error": "webhook returned 503 Service Unavailable",
+                  "time": "2024-01-02T15:04:05.000Z"
+                }
+              ],
+              "created_at": "2024-01-02T15:04:05.000Z",
+              "id": "dlq_3f9a1c2b7e4d",
+              "kind": "security_event",
+              "payload": null,
+              "status": "dead",
+              "updated_at": "2024-01-02T15:04:05.000Z"
+            }
+          ]
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "delete-admin-dead-letters-by-id",
+    "method": "DELETE",
+    "path": "/admin/dead-letters/{id}",
+    "status": 204,
+    "responses": {
+      "204": {},
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-admin-dead-letters-by-id",
+    "method": "GET",
+    "path": "/admin/dead-letters/{id}",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/DeadLetter.json",
+          "attempts": [
+            {
+              "error": "webhook returned 503 Service Unavailable",
+              "time": "2024-01-02T15:04:05.000Z"
+            }
+          ],
+          "created_at": "2024-01-02T15:04:05.000Z",
+          "id": "dlq_3f9a1c2b7e4d",
+          "kind": "security_event",
+          "payload": null,
+          "status": "dead",
+          "updated_at": "2024-01-02T15:04:05.000Z"
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-admin-dead-letters-by-id-requeue",
+    "method": "POST",
+    "path": "/admin/dead-letters/{id}/requeue",
+    "status": 202,
+    "responses": {
+      "202": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/DeadLetter.json",
+          "attempts": [
+            {
+              "error": "webhook returned 503 Service Unavailable",
+              "time": "2024-01-02T15:04:05.000Z"
+            }
+          ],
+          "created_at": "2024-01-02T15:04:05.000Z",
+          "id": "dlq_3f9a1c2b7e4d",
+          "kind": "security_event",
+          "payload": null,
+          "status": "dead",
+          "updated_at": "2024-01-02T15:04:05.000Z"
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "409": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USERNAME_TAKEN",
+          "title": "Conflict",
+          "status": 409,
+          "detail": "username is already taken"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-admin-digest",
+    "method": "POST",
+    "path": "/admin/digest",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/DigestReport.json",
+          "dry_run": true,
+          "failed": 1,
+          "new_users": 1,
+          "period": "daily",
+          "queued": 1,
+          "sent": 1,
+          "since": "2024-01-02T15:04:05.000Z",
+          "updated_users": 1
+        }
+      },
+      "400": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "BAD_REQUEST",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "",
+              "code": "malformed",
+              "message": "invalid character '}' looking for beginning of object key string",
+              "location": "body",
+              "value": "{\"name\":\"Rohan\",}"
+            }
+          ]
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "409": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USERNAME_TAKEN",
+          "title": "Conflict",
+          "status": 409,
+          "detail": "username is already taken"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-admin-events-replay",
+    "method": "POST",
+    "path": "/admin/events/replay",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/EventReplay.json",
+          "cache_purges": 2,
+          "events": 3,
+          "next_seq": 1040,
+          "security_events": 1
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "409": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USERNAME_TAKEN",
+          "title": "Conflict",
+          "status": 409,
+          "detail": "username is already taken"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-admin-faults",
+    "method": "GET",
+    "path": "/admin/faults",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/FaultRules.json",
+          "rules": [
+            {
+              "drop": true,
+              "latency_ms": 0,
+              "method": "GET",
+              "rate": 0.25,
+              "route": "/v1/users/{id}",
+              "status": 500
+            }
+          ]
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "put-admin-faults",
+    "method": "PUT",
+    "path": "/admin/faults",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/FaultRules.json",
+          "rules": [
+            {
+              "drop": true,
+              "latency_ms": 0,
+              "method": "GET",
+              "rate": 0.25,
+              "route": "/v1/users/{id}",
+              "status": 500
+            }
+          ]
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-admin-health-history",
+    "method": "GET",
+    "path": "/admin/health/history",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/HealthHistory.json",
+          "interval_seconds": 10,
+          "probes": 8640,
+          "since": "2024-01-02T15:04:05.000Z",
+          "status": "up",
+          "transitions": [
+            {
+              "components": [
+                "store"
+              ],
+              "reason": "draining",
+              "status": "up",
+              "time": "2024-01-02T15:04:05.000Z"
+            }
+          ],
+          "uptime_percent": 99.95
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-admin-impersonate-by-user-id",
+    "method": "POST",
+    "path": "/admin/impersonate/{userID}",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/ImpersonationToken.json",
+          "expires_at": "2024-01-02T15:04:05.000Z",
+          "impersonated_by": "string",
+          "token": "string",
+          "token_type": "Bearer",
+          "user_id": "string"
+        }
+      },
+      "400": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "BAD_REQUEST",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "",
+              "code": "malformed",
+              "message": "invalid character '}' looking for beginning of object key string",
+              "location": "body",
+              "value": "{\"name\":\"Rohan\",}"
+            }
+          ]
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-admin-inflight",
+    "method": "GET",
+    "path": "/admin/inflight",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/InFlightList.json",
+          "requests": [
+            {
+              "age_ms": 48210.5,
+              "canceled": true,
+              "client_ip": "203.0.113.9",
+              "id": "req_4bf92f3577b34da6a3ce929d0e0e4736",
+              "method": "GET",
+              "path": "/v1/users/20240101120000/data-export",
+              "route": "/v1/users/{userID}/data-export",
+              "started": "2024-01-02T15:04:05.000Z"
+            }
+          ]
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-admin-inflight-by-id-cancel",
+    "method": "POST",
+    "path": "/admin/inflight/{id}/cancel",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/InFlightList.json",
+          "requests": [
+            {
+              "age_ms": 48210.5,
+              "canceled": true,
+              "client_ip": "203.0.113.9",
+              "id": "req_4bf92f3577b34da6a3ce929d0e0e4736",
+              "method": "GET",
+              "path": "/v1/users/20240101120000/data-export",
+              "route": "/v1/users/{userID}/data-export",
+              "started": "2024-01-02T15:04:05.000Z"
+            }
+          ]
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-admin-leader",
+    "method": "GET",
+    "path": "/admin/leader",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/LeaderStatus.json",
+          "election": "raft",
+          "is_leader": true,
+          "leader": "api-0",
+          "replica": "api-1"
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      },
+      "503": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "MAINTENANCE",
+          "title": "Service Unavailable",
+          "status": 503,
+          "detail": "the API is down for maintenance"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "put-admin-loglevel",
+    "method": "PUT",
+    "path": "/admin/loglevel",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/LogLevelResponse.json",
+          "level": "string",
+          "previous": "string",
+          "revert_at": "2024-01-02T15:04:05.000Z"
+        }
+      },
+      "400": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "BAD_REQUEST",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "",
+              "code": "malformed",
+              "message": "invalid character '}' looking for beginning of object key string",
+              "location": "body",
+              "value": "{\"name\":\"Rohan\",}"
+            }
+          ]
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-admin-maintenance",
+    "method": "GET",
+    "path": "/admin/maintenance",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/Maintenance.json",
+          "message": "string",
+          "mode": "off",
+          "retry_after_seconds": 1,
+          "since": "2024-01-02T15:04:05.000Z"
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "put-admin-maintenance",
+    "method": "PUT",
+    "path": "/admin/maintenance",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/Maintenance.json",
+          "message": "string",
+          "mode": "off",
+          "retry_after_seconds": 1,
+          "since": "2024-01-02T15:04:05.000Z"
+        }
+      },
+      "400": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "BAD_REQUEST",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "",
+              "code": "malformed",
+              "message": "invalid character '}' looking for beginning of object key string",
+              "location": "body",
+              "value": "{\"name\":\"Rohan\",}"
+            }
+          ]
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-admin-ratelimits",
+    "method": "GET",
+    "path": "/admin/ratelimits",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/RateLimits.json",
+          "rate_limits": [
+            {
+              "$schema": "https://example.com/schemas/RateLimit.json",
+              "daily": 10000,
+              "key_id": "key_3f9a1c",
+              "monthly": 200000,
+              "usage": {
+                "day": 1,
+                "day_resets_at": "2024-01-02T15:04:05.000Z",
+                "month": 1,
+                "month_resets_at": "2024-01-02T15:04:05.000Z"
+              }
+            }
+          ]
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "delete-admin-ratelimits-by-key-id",
+    "method": "DELETE",
+    "path": "/admin/ratelimits/{keyID}",
+    "status": 204,
+    "responses": {
+      "204": {},
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-admin-ratelimits-by-key-id",
+    "method": "GET",
+    "path": "/admin/ratelimits/{keyID}",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/RateLimit.json",
+          "daily": 10000,
+          "key_id": "key_3f9a1c",
+          "monthly": 200000,
+          "usage": {
+            "day": 1,
+            "day_resets_at": "2024-01-02T15:04:05.000Z",
+            "month": 1,
+            "month_resets_at": "2024-01-02T15:04:05.000Z"
+          }
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "put-admin-ratelimits-by-key-id",
+    "method": "PUT",
+    "path": "/admin/ratelimits/{keyID}",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/RateLimit.json",
+          "daily": 10000,
+          "key_id": "key_3f9a1c",
+          "monthly": 200000,
+          "usage": {
+            "day": 1,
+            "day_resets_at": "2024-01-02T15:04:05.000Z",
+            "month": 1,
+            "month_resets_at": "2024-01-02T15:04:05.000Z"
+          }
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-admin-reencrypt",
+    "method": "POST",
+    "path": "/admin/reencrypt",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/ReencryptResponse.json",
+          "checked": 1,
+          "key_id": "string",
+          "reencrypted": 1
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "409": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USERNAME_TAKEN",
+          "title": "Conflict",
+          "status": 409,
+          "detail": "username is already taken"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "delete-admin-requests",
+    "method": "DELETE",
+    "path": "/admin/requests",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/ClearCapturedOutputBody.json",
+          "cleared": 1
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-admin-requests",
+    "method": "GET",
+    "path": "/admin/requests",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/CapturedList.json",
+          "capacity": 1,
+          "requests": [
+            {
+              "client_ip": "203.0.113.9",
+              "duration_ms": 3.25,
+              "id": 1,
+              "method": "PATCH",
+              "params": "string",
+              "path": "/v1/users/20240101120000",
+              "request_body": "string",
+              "request_headers": {},
+              "response_body": "string",
+              "response_headers": {},
+              "route": "/v1/users/{userID}",
+              "status": 422,
+              "time": "2024-01-02T15:04:05.000Z"
+            }
+          ]
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-admin-restore",
+    "method": "POST",
+    "path": "/admin/restore",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/RestoreResponse.json",
+          "users": 1
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "409": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USERNAME_TAKEN",
+          "title": "Conflict",
+          "status": 409,
+          "detail": "username is already taken"
+        }
+      },
+      "413": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "REQUEST_TOO_LARGE",
+          "title": "Request Entity Too Large",
+          "status": 413,
+          "detail": "request body is too large limit=1048576 bytes"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-admin-retention",
+    "method": "POST",
+    "path": "/admin/retention",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/RetentionReport.json",
+          "dry_run": true,
+          "rules": [
+            {
+              "cutoff": "2024-01-02T15:04:05.000Z",
+              "ids": [
+                "string"
+              ],
+              "max_age": "720h0m0s",
+              "purged": 1,
+              "rule": "deleted_users"
+            }
+          ]
+        }
+      },
+      "400": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "BAD_REQUEST",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "",
+              "code": "malformed",
+              "message": "invalid character '}' looking for beginning of object key string",
+              "location": "body",
+              "value": "{\"name\":\"Rohan\",}"
+            }
+          ]
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "409": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USERNAME_TAKEN",
+          "title": "Conflict",
+          "status": 409,
+          "detail": "username is already taken"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-admin-telemetry",
+    "method": "GET",
+    "path": "/admin/telemetry",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/TelemetryStatus.json",
+          "enabled": true,
+          "interval_seconds": 86400,
+          "last_error": "string",
+          "last_sent": "2024-01-02T15:04:05.000Z",
+          "report": {
+            "go_version": "go1.24.1",
+            "instance_id": "9f2c4e1a7b3d5f60",
+            "platform": "linux/amd64",
+            "requests": {},
+            "since": "2024-01-02T15:04:05.000Z",
+            "store": "memory",
+            "uptime_seconds": 86400,
+            "version": "v1.2.0"
+          },
+          "url": "https://telemetry.example.com/v1/reports"
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-admin-traffic-blocks",
+    "method": "GET",
+    "path": "/admin/traffic/blocks",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/TrafficBlocks.json",
+          "blocks": [
+            {
+              "action": "ban",
+              "client_ip": "203.0.113.9",
+              "reason": "more than 600 requests a minute",
+              "since": "2024-01-02T15:04:05.000Z",
+              "until": "2024-01-02T15:04:05.000Z"
+            }
+          ]
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "delete-admin-traffic-blocks-by-ip",
+    "method": "DELETE",
+    "path": "/admin/traffic/blocks/{ip}",
+    "status": 204,
+    "responses": {
+      "204": {},
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-admin-unlock-by-user-id",
+    "method": "POST",
+    "path": "/admin/unlock/{userID}",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/UnlockResponse.json",
+          "failed_attempts": 1,
+          "user_id": "string",
+          "was_locked": true
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-dev-emails-by-name",
+    "method": "GET",
+    "path": "/dev/emails/{name}",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "text/html",
+        "body": "string"
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-health",
+    "method": "GET",
+    "path": "/health",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/HealthResponse.json",
+          "status": 200
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-hello",
+    "method": "GET",
+    "path": "/hello",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/HelloResponse.json",
+          "message": "string"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-livez",
+    "method": "GET",
+    "path": "/livez",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/HealthResponse.json",
+          "status": 200
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-readyz",
+    "method": "GET",
+    "path": "/readyz",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/ReadinessResponse.json",
+          "components": [
+            {
+              "error": "no raft leader",
+              "name": "store",
+              "ready": true,
+              "status": "starting"
+            }
+          ],
+          "reason": "draining",
+          "status": 200
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-v1-api-keys",
+    "method": "GET",
+    "path": "/v1/api-keys",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/APIKeysResponse.json",
+          "api_keys": [
+            {
+              "created_at": "2024-01-02T15:04:05.000Z",
+              "expires_at": "2024-01-02T15:04:05.000Z",
+              "id": "key_3f9a1c2b7d4e",
+              "last_used_at": "2024-01-02T15:04:05.000Z",
+              "name": "CI deploys",
+              "prefix": "mk_66697874757265_3f9a1c2b7d4e",
+              "rotated_at": "2024-01-02T15:04:05.000Z",
+              "scopes": [
+                "string"
+              ]
+            }
+          ]
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "403": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "CAPTCHA_FAILED",
+          "title": "Forbidden",
+          "status": 403,
+          "detail": "captcha verification failed"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-v1-api-keys",
+    "method": "POST",
+    "path": "/v1/api-keys",
+    "status": 201,
+    "responses": {
+      "201": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/NewAPIKey.json",
+          "created_at": "2024-01-02T15:04:05.000Z",
+          "expires_at": "2024-01-02T15:04:05.000Z",
+          "id": "key_3f9a1c2b7d4e",
+          "key": "mk_66697874757265_3f9a1c2b7d4e_9c1f…",
+          "last_used_at": "2024-01-02T15:04:05.000Z",
+          "name": "CI deploys",
+          "prefix": "mk_66697874757265_3f9a1c2b7d4e",
+          "rotated_at": "2024-01-02T15:04:05.000Z",
+          "scopes": [
+            "string"
+          ]
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "403": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "CAPTCHA_FAILED",
+          "title": "Forbidden",
+          "status": 403,
+          "detail": "captcha verification failed"
+        }
+      },
+      "409": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USERNAME_TAKEN",
+          "title": "Conflict",
+          "status": 409,
+          "detail": "username is already taken"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "delete-v1-api-keys-by-id",
+    "method": "DELETE",
+    "path": "/v1/api-keys/{id}",
+    "status": 204,
+    "responses": {
+      "204": {},
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "403": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "CAPTCHA_FAILED",
+          "title": "Forbidden",
+          "status": 403,
+          "detail": "captcha verification failed"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-v1-api-keys-by-id-rotate",
+    "method": "POST",
+    "path": "/v1/api-keys/{id}/rotate",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/NewAPIKey.json",
+          "created_at": "2024-01-02T15:04:05.000Z",
+          "expires_at": "2024-01-02T15:04:05.000Z",
+          "id": "key_3f9a1c2b7d4e",
+          "key": "mk_66697874757265_3f9a1c2b7d4e_9c1f…",
+          "last_used_at": "2024-01-02T15:04:05.000Z",
+          "name": "CI deploys",
+          "prefix": "mk_66697874757265_3f9a1c2b7d4e",
+          "rotated_at": "2024-01-02T15:04:05.000Z",
+          "scopes": [
+            "string"
+          ]
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "403": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "CAPTCHA_FAILED",
+          "title": "Forbidden",
+          "status": 403,
+          "detail": "captcha verification failed"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-v1-auth-introspect",
+    "method": "POST",
+    "path": "/v1/auth/introspect",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/TokenIntrospection.json",
+          "active": true,
+          "api_key_id": "string",
+          "exp": 1704114000,
+          "iss": "https://example.us.auth0.com/",
+          "jti": "5f3c2a9b8e7d6c1f0a4b3e2d",
+          "scope": "users:read notifications:read",
+          "sub": "20240101120000",
+          "token_type": "Bearer"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      },
+      "503": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "MAINTENANCE",
+          "title": "Service Unavailable",
+          "status": 503,
+          "detail": "the API is down for maintenance"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-v1-auth-login",
+    "method": "POST",
+    "path": "/v1/auth/login",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/LoginToken.json",
+          "expires_at": "2024-01-02T15:04:05.000Z",
+          "token": "string",
+          "token_type": "Bearer",
+          "user_id": "string"
+        }
+      },
+      "400": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "BAD_REQUEST",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "",
+              "code": "malformed",
+              "message": "invalid character '}' looking for beginning of object key string",
+              "location": "body",
+              "value": "{\"name\":\"Rohan\",}"
+            }
+          ]
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "403": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "CAPTCHA_FAILED",
+          "title": "Forbidden",
+          "status": 403,
+          "detail": "captcha verification failed"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "423": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "ACCOUNT_LOCKED",
+          "title": "Locked",
+          "status": 423,
+          "detail": "account is locked after too many failed logins"
+        }
+      },
+      "429": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "LOGIN_THROTTLED",
+          "title": "Too Many Requests",
+          "status": 429,
+          "detail": "too many failed logins, try again later"
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      },
+      "503": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "MAINTENANCE",
+          "title": "Service Unavailable",
+          "status": 503,
+          "detail": "the API is down for maintenance"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-v1-auth-revoke",
+    "method": "POST",
+    "path": "/v1/auth/revoke",
+    "status": 200,
+    "responses": {
+      "200": {},
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      },
+      "503": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "MAINTENANCE",
+          "title": "Service Unavailable",
+          "status": 503,
+          "detail": "the API is down for maintenance"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-v1-batch",
+    "method": "POST",
+    "path": "/v1/batch",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/TransactionResponse.json",
+          "committed": true,
+          "results": [
+            {
+              "error": {
+                "$schema": "https://example.com/schemas/ErrorModel.json",
+                "code": "BAD_REQUEST",
+                "detail": "Property foo is required but is missing.",
+                "errors": [
+                  {
+                    "code": "required",
+                    "field": "tags[0]",
+                    "location": "body.tags[0]",
+                    "message": "string",
+                    "value": null
+                  }
+                ],
+                "instance": "https://example.com/error-log/abc123",
+                "status": 400,
+                "title": "Bad Request",
+                "type": "https://example.com/errors/example"
+              },
+              "id": "20240101120000",
+              "outcome": "committed",
+              "status": 201,
+              "user": {
+                "$schema": "https://example.com/schemas/User.json",
+                "active": true,
+                "deleted_at": "2024-01-02T15:04:05.000Z",
+                "email": "rohan@example.com",
+                "email_raw": "Rohan+news@Example.com",
+                "id": "20240101120000",
+                "last_login_at": "2024-01-02T15:04:05.000Z",
+                "last_seen_at": "2024-01-02T15:04:05.000Z",
+                "metadata": {},
+                "name": "Rohan Chauhan",
+                "phone": "+436601234567",
+                "post_count": 1,
+                "posts": [
+                  {
+                    "$schema": "https://example.com/schemas/Post.json",
+                    "author_id": "20240101120000",
+                    "body": "My first post.",
+                    "comments": [
+                      {
+                        "$schema": "https://example.com/schemas/Comment.json",
+                        "author_id": "20240101120000",
+                        "body": "Nice post!",
+                        "created_at": "2024-01-02T15:04:05.000Z",
+                        "id": "20240101121000",
+                        "parent_id": "20240101120900",
+                        "post_id": "20240101120500",
+                        "reply_count": 1,
+                        "status": "published",
+                        "updated_at": "2024-01-02T15:04:05.000Z"
+                      }
+                    ],
+                    "created_at": "2024-01-02T15:04:05.000Z",
+                    "id": "20240101120500",
+                    "title": "Hello, world",
+                    "updated_at": "2024-01-02T15:04:05.000Z"
+                  }
+                ],
+                "status": "invited",
+                "tags": [
+                  "string"
+                ],
+                "username": "ro_chauhan"
+              }
+            }
+          ]
+        }
+      },
+      "400": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "BAD_REQUEST",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "",
+              "code": "malformed",
+              "message": "invalid character '}' looking for beginning of object key string",
+              "location": "body",
+              "value": "{\"name\":\"Rohan\",}"
+            }
+          ]
+        }
+      },
+      "403": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "CAPTCHA_FAILED",
+          "title": "Forbidden",
+          "status": 403,
+          "detail": "captcha verification failed"
+        }
+      },
+      "409": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USERNAME_TAKEN",
+          "title": "Conflict",
+          "status": 409,
+          "detail": "username is already taken"
+        }
+      },
+      "413": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "REQUEST_TOO_LARGE",
+          "title": "Request Entity Too Large",
+          "status": 413,
+          "detail": "request body is too large limit=1048576 bytes"
+        }
+      },
+      "415": {
+        "contentType": "application/problem+json",
+        "body": {
+          "$schema": "https://example.com/schemas/ErrorModel.json",
+          "code": "BAD_REQUEST",
+          "detail": "Property foo is required but is missing.",
+          "errors": [
+            {
+              "code": "required",
+              "field": "tags[0]",
+              "location": "body.tags[0]",
+              "message": "string",
+              "value": null
+            }
+          ],
+          "instance": "https://example.com/error-log/abc123",
+          "status": 400,
+          "title": "Bad Request",
+          "type": "https://example.com/errors/example"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      },
+      "503": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "MAINTENANCE",
+          "title": "Service Unavailable",
+          "status": 503,
+          "detail": "the API is down for maintenance"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-v1-changelog",
+    "method": "GET",
+    "path": "/v1/changelog",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/Changelog.json",
+          "releases": [
+            {
+              "changes": [
+                {
+                  "description": "string",
+                  "kind": "added",
+                  "operation": "get-v1-users"
+                }
+              ],
+              "date": "2024-01-01",
+              "version": "1.0.0"
+            }
+          ]
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-v1-downloads-by-kind-by-id",
+    "method": "GET",
+    "path": "/v1/downloads/{kind}/{id}",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/x-ndjson",
+        "body": "string"
+      },
+      "403": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "CAPTCHA_FAILED",
+          "title": "Forbidden",
+          "status": 403,
+          "detail": "captcha verification failed"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-v1-events",
+    "method": "GET",
+    "path": "/v1/events",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/EventsPage.json",
+          "events": [
+            {
+              "causation_id": "string",
+              "correlation_id": "string",
+              "data": null,
+              "event_id": "string",
+              "id": 1,
+              "subject": "string",
+              "time": "2024-01-02T15:04:05.000Z",
+              "type": "user.activated"
+            }
+          ],
+          "next_seq": 43,
+          "oldest_seq": 1
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-v1-exports",
+    "method": "POST",
+    "path": "/v1/exports",
+    "status": 202,
+    "responses": {
+      "202": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/Export.json",
+          "completed_at": "2024-01-02T15:04:05.000Z",
+          "created_at": "2024-01-02T15:04:05.000Z",
+          "download_expires_at": "2024-01-02T15:04:05.000Z",
+          "download_url": "/v1/downloads/exports/exp_5b0e2a9c1d7f?expires=1704114000\u0026signature=n2Xc…",
+          "error": "string",
+          "expires_at": "2024-01-02T15:04:05.000Z",
+          "filters": {
+            "inactive_since": "30d",
+            "include_inactive": true,
+            "metadata": {
+              "plan": "pro"
+            },
+            "tag": [
+              "beta"
+            ]
+          },
+          "format": "ndjson",
+          "id": "exp_5b0e2a9c1d7f",
+          "rows": 1,
+          "size": 1,
+          "status": "pending",
+          "timezone": "Europe/Vienna"
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-v1-exports-by-id",
+    "method": "GET",
+    "path": "/v1/exports/{id}",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/Export.json",
+          "completed_at": "2024-01-02T15:04:05.000Z",
+          "created_at": "2024-01-02T15:04:05.000Z",
+          "download_expires_at": "2024-01-02T15:04:05.000Z",
+          "download_url": "/v1/downloads/exports/exp_5b0e2a9c1d7f?expires=1704114000\u0026signature=n2Xc…",
+          "error": "string",
+          "expires_at": "2024-01-02T15:04:05.000Z",
+          "filters": {
+            "inactive_since": "30d",
+            "include_inactive": true,
+            "metadata": {
+              "plan": "pro"
+            },
+            "tag": [
+              "beta"
+            ]
+          },
+          "format": "ndjson",
+          "id": "exp_5b0e2a9c1d7f",
+          "rows": 1,
+          "size": 1,
+          "status": "pending",
+          "timezone": "Europe/Vienna"
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-v1-invitations",
+    "method": "GET",
+    "path": "/v1/invitations",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/InvitationsResponse.json",
+          "invitations": [
+            {
+              "accepted_at": "2024-01-02T15:04:05.000Z",
+              "created_at": "2024-01-02T15:04:05.000Z",
+              "email": "kim@example.com",
+              "expires_at": "2024-01-02T15:04:05.000Z",
+              "id": "inv_3f9a1c2b7d4e",
+              "locale": "de",
+              "name": "Kim Lee",
+              "revoked_at": "2024-01-02T15:04:05.000Z",
+              "status": "pending",
+              "user_id": "20240101120000"
+            }
+          ]
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-v1-invitations",
+    "method": "POST",
+    "path": "/v1/invitations",
+    "status": 201,
+    "responses": {
+      "201": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/NewInvitation.json",
+          "accepted_at": "2024-01-02T15:04:05.000Z",
+          "created_at": "2024-01-02T15:04:05.000Z",
+          "email": "kim@example.com",
+          "emailed": true,
+          "expires_at": "2024-01-02T15:04:05.000Z",
+          "id": "inv_3f9a1c2b7d4e",
+          "locale": "de",
+          "name": "Kim Lee",
+          "revoked_at": "2024-01-02T15:04:05.000Z",
+          "status": "pending",
+          "token": "9c1f0d3e…",
+          "user_id": "20240101120000"
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "409": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USERNAME_TAKEN",
+          "title": "Conflict",
+          "status": 409,
+          "detail": "username is already taken"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "delete-v1-invitations-by-id",
+    "method": "DELETE",
+    "path": "/v1/invitations/{id}",
+    "status": 204,
+    "responses": {
+      "204": {},
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "409": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USERNAME_TAKEN",
+          "title": "Conflict",
+          "status": 409,
+          "detail": "username is already taken"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-v1-invitations-by-token-accept",
+    "method": "POST",
+    "path": "/v1/invitations/{token}/accept",
+    "status": 201,
+    "responses": {
+      "201": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/User.json",
+          "active": true,
+          "deleted_at": "2024-01-02T15:04:05.000Z",
+          "email": "rohan@example.com",
+          "email_raw": "Rohan+news@Example.com",
+          "id": "20240101120000",
+          "last_login_at": "2024-01-02T15:04:05.000Z",
+          "last_seen_at": "2024-01-02T15:04:05.000Z",
+          "metadata": {},
+          "name": "Rohan Chauhan",
+          "phone": "+436601234567",
+          "post_count": 1,
+          "posts": [
+            {
+              "$schema": "https://example.com/schemas/Post.json",
+              "author_id": "20240101120000",
+              "body": "My first post.",
+              "comments": [
+                {
+                  "$schema": "https://example.com/schemas/Comment.json",
+                  "author_id": "20240101120000",
+                  "body": "Nice post!",
+                  "created_at": "2024-01-02T15:04:05.000Z",
+                  "id": "20240101121000",
+                  "parent_id": "20240101120900",
+                  "post_id": "20240101120500",
+                  "reply_count": 1,
+                  "status": "published",
+                  "updated_at": "2024-01-02T15:04:05.000Z"
+                }
+              ],
+              "created_at": "2024-01-02T15:04:05.000Z",
+              "id": "20240101120500",
+              "title": "Hello, world",
+              "updated_at": "2024-01-02T15:04:05.000Z"
+            }
+          ],
+          "status": "invited",
+          "tags": [
+            "string"
+          ],
+          "username": "ro_chauhan"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "409": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USERNAME_TAKEN",
+          "title": "Conflict",
+          "status": 409,
+          "detail": "username is already taken"
+        }
+      },
+      "410": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "CHANGES_EXPIRED",
+          "title": "Gone",
+          "status": 410,
+          "detail": "the changes after since are no longer kept; list the users again and resume with since=-1"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-v1-me",
+    "method": "GET",
+    "path": "/v1/me",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/CurrentUser.json",
+          "api_key_id": "string",
+          "impersonated_by": "string",
+          "issuer": "https://example.us.auth0.com/",
+          "scopes": [
+            "string"
+          ],
+          "token_expires_at": "2024-01-02T15:04:05.000Z",
+          "user": {
+            "$schema": "https://example.com/schemas/User.json",
+            "active": true,
+            "deleted_at": "2024-01-02T15:04:05.000Z",
+            "email": "rohan@example.com",
+            "email_raw": "Rohan+news@Example.com",
+            "id": "20240101120000",
+            "last_login_at": "2024-01-02T15:04:05.000Z",
+            "last_seen_at": "2024-01-02T15:04:05.000Z",
+            "metadata": {},
+            "name": "Rohan Chauhan",
+            "phone": "+436601234567",
+            "post_count": 1,
+            "posts": [
+              {
+                "$schema": "https://example.com/schemas/Post.json",
+                "author_id": "20240101120000",
+                "body": "My first post.",
+                "comments": [
+                  {
+                    "$schema": "https://example.com/schemas/Comment.json",
+                    "author_id": "20240101120000",
+                    "body": "Nice post!",
+                    "created_at": "2024-01-02T15:04:05.000Z",
+                    "id": "20240101121000",
+                    "parent_id": "20240101120900",
+                    "post_id": "20240101120500",
+                    "reply_count": 1,
+                    "status": "published",
+                    "updated_at": "2024-01-02T15:04:05.000Z"
+                  }
+                ],
+                "created_at": "2024-01-02T15:04:05.000Z",
+                "id": "20240101120500",
+                "title": "Hello, world",
+                "updated_at": "2024-01-02T15:04:05.000Z"
+              }
+            ],
+            "status": "invited",
+            "tags": [
+              "string"
+            ],
+            "username": "ro_chauhan"
+          }
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "403": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "CAPTCHA_FAILED",
+          "title": "Forbidden",
+          "status": 403,
+          "detail": "captcha verification failed"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-v1-notifications",
+    "method": "GET",
+    "path": "/v1/notifications",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/NotificationsListResponse.json",
+          "has_more": true,
+          "next": {
+            "after": "20240101120000",
+            "href": "/v1/users?page=2\u0026per_page=100",
+            "page": 2,
+            "per_page": 100
+          },
+          "notifications": [
+            {
+              "$schema": "https://example.com/schemas/Notification.json",
+              "actor_id": "20240101120100",
+              "comment_id": "20240101120900",
+              "created_at": "2024-01-02T15:04:05.000Z",
+              "id": "20240101121000",
+              "post_id": "20240101120500",
+              "read_at": "2024-01-02T15:04:05.000Z",
+              "type": "welcome",
+              "user_id": "20240101120000"
+            }
+          ],
+          "unread_count": 3
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "403": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "CAPTCHA_FAILED",
+          "title": "Forbidden",
+          "status": 403,
+          "detail": "captcha verification failed"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-v1-notifications-by-id-read",
+    "method": "POST",
+    "path": "/v1/notifications/{id}/read",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/Notification.json",
+          "actor_id": "20240101120100",
+          "comment_id": "20240101120900",
+          "created_at": "2024-01-02T15:04:05.000Z",
+          "id": "20240101121000",
+          "post_id": "20240101120500",
+          "read_at": "2024-01-02T15:04:05.000Z",
+          "type": "welcome",
+          "user_id": "20240101120000"
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "403": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "CAPTCHA_FAILED",
+          "title": "Forbidden",
+          "status": 403,
+          "detail": "captcha verification failed"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-v1-posts",
+    "method": "GET",
+    "path": "/v1/posts",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/PostsListResponse.json",
+          "has_more": true,
+          "next": {
+            "after": "20240101120000",
+            "href": "/v1/users?page=2\u0026per_page=100",
+            "page": 2,
+            "per_page": 100
+          },
+          "posts": [
+            {
+              "$schema": "https://example.com/schemas/Post.json",
+              "author_id": "20240101120000",
+              "body": "My first post.",
+              "comments": [
+                {
+                  "$schema": "https://example.com/schemas/Comment.json",
+                  "author_id": "20240101120000",
+                  "body": "Nice post!",
+                  "created_at": "2024-01-02T15:04:05.000Z",
+                  "id": "20240101121000",
+                  "parent_id": "20240101120900",
+                  "post_id": "20240101120500",
+                  "reply_count": 1,
+                  "status": "published",
+                  "updated_at": "2024-01-02T15:04:05.000Z"
+                }
+              ],
+              "created_at": "2024-01-02T15:04:05.000Z",
+              "id": "20240101120500",
+              "title": "Hello, world",
+              "updated_at": "2024-01-02T15:04:05.000Z"
+            }
+          ]
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-v1-posts",
+    "method": "POST",
+    "path": "/v1/posts",
+    "status": 201,
+    "responses": {
+      "201": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/Post.json",
+          "author_id": "20240101120000",
+          "body": "My first post.",
+          "comments": [
+            {
+              "$schema": "https://example.com/schemas/Comment.json",
+              "author_id": "20240101120000",
+              "body": "Nice post!",
+              "created_at": "2024-01-02T15:04:05.000Z",
+              "id": "20240101121000",
+              "parent_id": "20240101120900",
+              "post_id": "20240101120500",
+              "reply_count": 1,
+              "status": "published",
+              "updated_at": "2024-01-02T15:04:05.000Z"
+            }
+          ],
+          "created_at": "2024-01-02T15:04:05.000Z",
+          "id": "20240101120500",
+          "title": "Hello, world",
+          "updated_at": "2024-01-02T15:04:05.000Z"
+        }
+      },
+      "400": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "BAD_REQUEST",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "",
+              "code": "malformed",
+              "message": "invalid character '}' looking for beginning of object key string",
+              "location": "body",
+              "value": "{\"name\":\"Rohan\",}"
+            }
+          ]
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "$schema": "https://example.com/schemas/ErrorModel.json",
+          "code": "BAD_REQUEST",
+          "detail": "Property foo is required but is missing.",
+          "errors": [
+            {
+              "code": "required",
+              "field": "tags[0]",
+              "location": "body.tags[0]",
+              "message": "string",
+              "value": null
+            }
+          ],
+          "instance": "https://example.com/error-log/abc123",
+          "status": 400,
+          "title": "Bad Request",
+          "type": "https://example.com/errors/example"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "$schema": "https://example.com/schemas/ErrorModel.json",
+          "code": "BAD_REQUEST",
+          "detail": "Property foo is required but is missing.",
+          "errors": [
+            {
+              "code": "required",
+              "field": "tags[0]",
+              "location": "body.tags[0]",
+              "message": "string",
+              "value": null
+            }
+          ],
+          "instance": "https://example.com/error-log/abc123",
+          "status": 400,
+          "title": "Bad Request",
+          "type": "https://example.com/errors/example"
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "delete-v1-posts-by-id",
+    "method": "DELETE",
+    "path": "/v1/posts/{id}",
+    "status": 204,
+    "responses": {
+      "204": {},
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-v1-posts-by-id",
+    "method": "GET",
+    "path": "/v1/posts/{id}",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/Post.json",
+          "author_id": "20240101120000",
+          "body": "My first post.",
+          "comments": [
+            {
+              "$schema": "https://example.com/schemas/Comment.json",
+              "author_id": "20240101120000",
+              "body": "Nice post!",
+              "created_at": "2024-01-02T15:04:05.000Z",
+              "id": "20240101121000",
+              "parent_id": "20240101120900",
+              "post_id": "20240101120500",
+              "reply_count": 1,
+              "status": "published",
+              "updated_at": "2024-01-02T15:04:05.000Z"
+            }
+          ],
+          "created_at": "2024-01-02T15:04:05.000Z",
+          "id": "20240101120500",
+          "title": "Hello, world",
+          "updated_at": "2024-01-02T15:04:05.000Z"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "put-v1-posts-by-id",
+    "method": "PUT",
+    "path": "/v1/posts/{id}",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/Post.json",
+          "author_id": "20240101120000",
+          "body": "My first post.",
+          "comments": [
+            {
+              "$schema": "https://example.com/schemas/Comment.json",
+              "author_id": "20240101120000",
+              "body": "Nice post!",
+              "created_at": "2024-01-02T15:04:05.000Z",
+              "id": "20240101121000",
+              "parent_id": "20240101120900",
+              "post_id": "20240101120500",
+              "reply_count": 1,
+              "status": "published",
+              "updated_at": "2024-01-02T15:04:05.000Z"
+            }
+          ],
+          "created_at": "2024-01-02T15:04:05.000Z",
+          "id": "20240101120500",
+          "title": "Hello, world",
+          "updated_at": "2024-01-02T15:04:05.000Z"
+        }
+      },
+      "400": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "BAD_REQUEST",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "",
+              "code": "malformed",
+              "message": "invalid character '}' looking for beginning of object key string",
+              "location": "body",
+              "value": "{\"name\":\"Rohan\",}"
+            }
+          ]
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-v1-posts-by-id-comments",
+    "method": "GET",
+    "path": "/v1/posts/{id}/comments",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/CommentsListResponse.json",
+          "comments": [
+            {
+              "$schema": "https://example.com/schemas/Comment.json",
+              "author_id": "20240101120000",
+              "body": "Nice post!",
+              "created_at": "2024-01-02T15:04:05.000Z",
+              "id": "20240101121000",
+              "parent_id": "20240101120900",
+              "post_id": "20240101120500",
+              "reply_count": 1,
+              "status": "published",
+              "updated_at": "2024-01-02T15:04:05.000Z"
+            }
+          ],
+          "has_more": true,
+          "next": {
+            "after": "20240101120000",
+            "href": "/v1/users?page=2\u0026per_page=100",
+            "page": 2,
+            "per_page": 100
+          }
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-v1-posts-by-id-comments",
+    "method": "POST",
+    "path": "/v1/posts/{id}/comments",
+    "status": 201,
+    "responses": {
+      "201": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/Comment.json",
+          "author_id": "20240101120000",
+          "body": "Nice post!",
+          "created_at": "2024-01-02T15:04:05.000Z",
+          "id": "20240101121000",
+          "parent_id": "20240101120900",
+          "post_id": "20240101120500",
+          "reply_count": 1,
+          "status": "published",
+          "updated_at": "2024-01-02T15:04:05.000Z"
+        }
+      },
+      "400": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "BAD_REQUEST",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "",
+              "code": "malformed",
+              "message": "invalid character '}' looking for beginning of object key string",
+              "location": "body",
+              "value": "{\"name\":\"Rohan\",}"
+            }
+          ]
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "delete-v1-posts-by-id-comments-by-comment-id",
+    "method": "DELETE",
+    "path": "/v1/posts/{id}/comments/{commentID}",
+    "status": 204,
+    "responses": {
+      "204": {},
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-v1-posts-by-id-comments-by-comment-id",
+    "method": "GET",
+    "path": "/v1/posts/{id}/comments/{commentID}",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/Comment.json",
+          "author_id": "20240101120000",
+          "body": "Nice post!",
+          "created_at": "2024-01-02T15:04:05.000Z",
+          "id": "20240101121000",
+          "parent_id": "20240101120900",
+          "post_id": "20240101120500",
+          "reply_count": 1,
+          "status": "published",
+          "updated_at": "2024-01-02T15:04:05.000Z"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "put-v1-posts-by-id-comments-by-comment-id",
+    "method": "PUT",
+    "path": "/v1/posts/{id}/comments/{commentID}",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/Comment.json",
+          "author_id": "20240101120000",
+          "body": "Nice post!",
+          "created_at": "2024-01-02T15:04:05.000Z",
+          "id": "20240101121000",
+          "parent_id": "20240101120900",
+          "post_id": "20240101120500",
+          "reply_count": 1,
+          "status": "published",
+          "updated_at": "2024-01-02T15:04:05.000Z"
+        }
+      },
+      "400": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "BAD_REQUEST",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "",
+              "code": "malformed",
+              "message": "invalid character '}' looking for beginning of object key string",
+              "location": "body",
+              "value": "{\"name\":\"Rohan\",}"
+            }
+          ]
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-v1-posts-by-id-comments-by-comment-id-status",
+    "method": "POST",
+    "path": "/v1/posts/{id}/comments/{commentID}/status",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/Comment.json",
+          "author_id": "20240101120000",
+          "body": "Nice post!",
+          "created_at": "2024-01-02T15:04:05.000Z",
+          "id": "20240101121000",
+          "parent_id": "20240101120900",
+          "post_id": "20240101120500",
+          "reply_count": 1,
+          "status": "published",
+          "updated_at": "2024-01-02T15:04:05.000Z"
+        }
+      },
+      "400": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "BAD_REQUEST",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "",
+              "code": "malformed",
+              "message": "invalid character '}' looking for beginning of object key string",
+              "location": "body",
+              "value": "{\"name\":\"Rohan\",}"
+            }
+          ]
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-v1-search",
+    "method": "GET",
+    "path": "/v1/search",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/SearchResponse.json",
+          "partial": true,
+          "results": [
+            {
+              "highlights": {},
+              "id": "20240101120000",
+              "post": {
+                "$schema": "https://example.com/schemas/Post.json",
+                "author_id": "20240101120000",
+                "body": "My first post.",
+                "comments": [
+                  {
+                    "$schema": "https://example.com/schemas/Comment.json",
+                    "author_id": "20240101120000",
+                    "body": "Nice post!",
+                    "created_at": "2024-01-02T15:04:05.000Z",
+                    "id": "20240101121000",
+                    "parent_id": "20240101120900",
+                    "post_id": "20240101120500",
+                    "reply_count": 1,
+                    "status": "published",
+                    "updated_at": "2024-01-02T15:04:05.000Z"
+                  }
+                ],
+                "created_at": "2024-01-02T15:04:05.000Z",
+                "id": "20240101120500",
+                "title": "Hello, world",
+                "updated_at": "2024-01-02T15:04:05.000Z"
+              },
+              "score": 1.25,
+              "type": "user",
+              "user": {
+                "email": "string",
+                "id": "string",
+                "name": "string"
+              }
+            }
+          ]
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      },
+      "503": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "MAINTENANCE",
+          "title": "Service Unavailable",
+          "status": 503,
+          "detail": "the API is down for maintenance"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-v1-stats",
+    "method": "GET",
+    "path": "/v1/stats",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/Stats.json",
+          "by_status": {
+            "active": 120,
+            "suspended": 3
+          },
+          "by_tag": {
+            "beta": 12
+          },
+          "comments": 1,
+          "computed_at": "2024-01-02T15:04:05.000Z",
+          "created_today": 1,
+          "posts": 1,
+          "users": 1
+        }
+      },
+      "401": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "UNAUTHORIZED",
+          "title": "Unauthorized",
+          "status": 401,
+          "detail": "admin token required"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-v1-usernames-by-name-available",
+    "method": "GET",
+    "path": "/v1/usernames/{name}/available",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/UsernameAvailability.json",
+          "available": true,
+          "reason": "invalid",
+          "username": "string"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-v1-users",
+    "method": "GET",
+    "path": "/v1/users",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/UsersListResponse.json",
+          "has_more": true,
+          "next": {
+            "after": "20240101120000",
+            "href": "/v1/users?page=2\u0026per_page=100",
+            "page": 2,
+            "per_page": 100
+          },
+          "partial": true,
+          "status": 200,
+          "tag_counts": {},
+          "users": [
+            {
+              "$schema": "https://example.com/schemas/User.json",
+              "active": true,
+              "deleted_at": "2024-01-02T15:04:05.000Z",
+              "email": "rohan@example.com",
+              "email_raw": "Rohan+news@Example.com",
+              "id": "20240101120000",
+              "last_login_at": "2024-01-02T15:04:05.000Z",
+              "last_seen_at": "2024-01-02T15:04:05.000Z",
+              "metadata": {},
+              "name": "Rohan Chauhan",
+              "phone": "+436601234567",
+              "post_count": 1,
+              "posts": [
+                {
+                  "$schema": "https://example.com/schemas/Post.json",
+                  "author_id": "20240101120000",
+                  "body": "My first post.",
+                  "comments": [
+                    {
+                      "$schema": "https://example.com/schemas/Comment.json",
+                      "author_id": "20240101120000",
+                      "body": "Nice post!",
+                      "created_at": "2024-01-02T15:04:05.000Z",
+                      "id": "20240101121000",
+                      "parent_id": "20240101120900",
+                      "post_id": "20240101120500",
+                      "reply_count": 1,
+                      "status": "published",
+                      "updated_at": "2024-01-02T15:04:05.000Z"
+                    }
+                  ],
+                  "created_at": "2024-01-02T15:04:05.000Z",
+                  "id": "20240101120500",
+                  "title": "Hello, world",
+                  "updated_at": "2024-01-02T15:04:05.000Z"
+                }
+              ],
+              "status": "invited",
+              "tags": [
+                "string"
+              ],
+              "username": "ro_chauhan"
+            }
+          ]
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-v1-users",
+    "method": "POST",
+    "path": "/v1/users",
+    "status": 201,
+    "responses": {
+      "201": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/User.json",
+          "active": true,
+          "deleted_at": "2024-01-02T15:04:05.000Z",
+          "email": "rohan@example.com",
+          "email_raw": "Rohan+news@Example.com",
+          "id": "20240101120000",
+          "last_login_at": "2024-01-02T15:04:05.000Z",
+          "last_seen_at": "2024-01-02T15:04:05.000Z",
+          "metadata": {},
+          "name": "Rohan Chauhan",
+          "phone": "+436601234567",
+          "post_count": 1,
+          "posts": [
+            {
+              "$schema": "https://example.com/schemas/Post.json",
+              "author_id": "20240101120000",
+              "body": "My first post.",
+              "comments": [
+                {
+                  "$schema": "https://example.com/schemas/Comment.json",
+                  "author_id": "20240101120000",
+                  "body": "Nice post!",
+                  "created_at": "2024-01-02T15:04:05.000Z",
+                  "id": "20240101121000",
+                  "parent_id": "20240101120900",
+                  "post_id": "20240101120500",
+                  "reply_count": 1,
+                  "status": "published",
+                  "updated_at": "2024-01-02T15:04:05.000Z"
+                }
+              ],
+              "created_at": "2024-01-02T15:04:05.000Z",
+              "id": "20240101120500",
+              "title": "Hello, world",
+              "updated_at": "2024-01-02T15:04:05.000Z"
+            }
+          ],
+          "status": "invited",
+          "tags": [
+            "string"
+          ],
+          "username": "ro_chauhan"
+        }
+      },
+      "400": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "BAD_REQUEST",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "",
+              "code": "malformed",
+              "message": "invalid character '}' looking for beginning of object key string",
+              "location": "body",
+              "value": "{\"name\":\"Rohan\",}"
+            }
+          ]
+        }
+      },
+      "403": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "CAPTCHA_FAILED",
+          "title": "Forbidden",
+          "status": 403,
+          "detail": "captcha verification failed"
+        }
+      },
+      "409": {
+        "contentType": "application/problem+json",
+        "body": {
+          "$schema": "https://example.com/schemas/ErrorModel.json",
+          "code": "BAD_REQUEST",
+          "detail": "Property foo is required but is missing.",
+          "errors": [
+            {
+              "code": "required",
+              "field": "tags[0]",
+              "location": "body.tags[0]",
+              "message": "string",
+              "value": null
+            }
+          ],
+          "instance": "https://example.com/error-log/abc123",
+          "status": 400,
+          "title": "Bad Request",
+          "type": "https://example.com/errors/example"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "$schema": "https://example.com/schemas/ErrorModel.json",
+          "code": "BAD_REQUEST",
+          "detail": "Property foo is required but is missing.",
+          "errors": [
+            {
+              "code": "required",
+              "field": "tags[0]",
+              "location": "body.tags[0]",
+              "message": "string",
+              "value": null
+            }
+          ],
+          "instance": "https://example.com/error-log/abc123",
+          "status": 400,
+          "title": "Bad Request",
+          "type": "https://example.com/errors/example"
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      },
+      "503": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "MAINTENANCE",
+          "title": "Service Unavailable",
+          "status": 503,
+          "detail": "the API is down for maintenance"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "patch-v1-users-batch",
+    "method": "PATCH",
+    "path": "/v1/users/batch",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/BatchUpdateResponse.json",
+          "failed": 1,
+          "results": [
+            {
+              "error": {
+                "$schema": "https://example.com/schemas/ErrorModel.json",
+                "code": "BAD_REQUEST",
+                "detail": "Property foo is required but is missing.",
+                "errors": [
+                  {
+                    "code": "required",
+                    "field": "tags[0]",
+                    "location": "body.tags[0]",
+                    "message": "string",
+                    "value": null
+                  }
+                ],
+                "instance": "https://example.com/error-log/abc123",
+                "status": 400,
+                "title": "Bad Request",
+                "type": "https://example.com/errors/example"
+              },
+              "id": "20240101120000",
+              "status": 200,
+              "user": {
+                "$schema": "https://example.com/schemas/User.json",
+                "active": true,
+                "deleted_at": "2024-01-02T15:04:05.000Z",
+                "email": "rohan@example.com",
+                "email_raw": "Rohan+news@Example.com",
+                "id": "20240101120000",
+                "last_login_at": "2024-01-02T15:04:05.000Z",
+                "last_seen_at": "2024-01-02T15:04:05.000Z",
+                "metadata": {},
+                "name": "Rohan Chauhan",
+                "phone": "+436601234567",
+                "post_count": 1,
+                "posts": [
+                  {
+                    "$schema": "https://example.com/schemas/Post.json",
+                    "author_id": "20240101120000",
+                    "body": "My first post.",
+                    "comments": [
+                      {
+                        "$schema": "https://example.com/schemas/Comment.json",
+                        "author_id": "20240101120000",
+                        "body": "Nice post!",
+                        "created_at": "2024-01-02T15:04:05.000Z",
+                        "id": "20240101121000",
+                        "parent_id": "20240101120900",
+                        "post_id": "20240101120500",
+                        "reply_count": 1,
+                        "status": "published",
+                        "updated_at": "2024-01-02T15:04:05.000Z"
+                      }
+                    ],
+                    "created_at": "2024-01-02T15:04:05.000Z",
+                    "id": "20240101120500",
+                    "title": "Hello, world",
+                    "updated_at": "2024-01-02T15:04:05.000Z"
+                  }
+                ],
+                "status": "invited",
+                "tags": [
+                  "string"
+                ],
+                "username": "ro_chauhan"
+              }
+            }
+          ],
+          "updated": 2
+        }
+      },
+      "400": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "BAD_REQUEST",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "",
+              "code": "malformed",
+              "message": "invalid character '}' looking for beginning of object key string",
+              "location": "body",
+              "value": "{\"name\":\"Rohan\",}"
+            }
+          ]
+        }
+      },
+      "413": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "REQUEST_TOO_LARGE",
+          "title": "Request Entity Too Large",
+          "status": 413,
+          "detail": "request body is too large limit=1048576 bytes"
+        }
+      },
+      "415": {
+        "contentType": "application/problem+json",
+        "body": {
+          "$schema": "https://example.com/schemas/ErrorModel.json",
+          "code": "BAD_REQUEST",
+          "detail": "Property foo is required but is missing.",
+          "errors": [
+            {
+              "code": "required",
+              "field": "tags[0]",
+              "location": "body.tags[0]",
+              "message": "string",
+              "value": null
+            }
+          ],
+          "instance": "https://example.com/error-log/abc123",
+          "status": 400,
+          "title": "Bad Request",
+          "type": "https://example.com/errors/example"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-v1-users-changes",
+    "method": "GET",
+    "path": "/v1/users/changes",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/UserChanges.json",
+          "changes": [
+            {
+              "correlation_id": "string",
+              "seq": 42,
+              "time": "2024-01-02T15:04:05.000Z",
+              "type": "user.updated",
+              "user_id": "20240101120000"
+            }
+          ],
+          "next_since": 42
+        }
+      },
+      "410": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "CHANGES_EXPIRED",
+          "title": "Gone",
+          "status": 410,
+          "detail": "the changes after since are no longer kept; list the users again and resume with since=-1"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-v1-users-lookup",
+    "method": "POST",
+    "path": "/v1/users/lookup",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/LookupUsersResponse.json",
+          "results": [
+            {
+              "found": true,
+              "id": "string",
+              "user": {
+                "$schema": "https://example.com/schemas/User.json",
+                "active": true,
+                "deleted_at": "2024-01-02T15:04:05.000Z",
+                "email": "rohan@example.com",
+                "email_raw": "Rohan+news@Example.com",
+                "id": "20240101120000",
+                "last_login_at": "2024-01-02T15:04:05.000Z",
+                "last_seen_at": "2024-01-02T15:04:05.000Z",
+                "metadata": {},
+                "name": "Rohan Chauhan",
+                "phone": "+436601234567",
+                "post_count": 1,
+                "posts": [
+                  {
+                    "$schema": "https://example.com/schemas/Post.json",
+                    "author_id": "20240101120000",
+                    "body": "My first post.",
+                    "comments": [
+                      {
+                        "$schema": "https://example.com/schemas/Comment.json",
+                        "author_id": "20240101120000",
+                        "body": "Nice post!",
+                        "created_at": "2024-01-02T15:04:05.000Z",
+                        "id": "20240101121000",
+                        "parent_id": "20240101120900",
+                        "post_id": "20240101120500",
+                        "reply_count": 1,
+                        "status": "published",
+                        "updated_at": "2024-01-02T15:04:05.000Z"
+                      }
+                    ],
+                    "created_at": "2024-01-02T15:04:05.000Z",
+                    "id": "20240101120500",
+                    "title": "Hello, world",
+                    "updated_at": "2024-01-02T15:04:05.000Z"
+                  }
+                ],
+                "status": "invited",
+                "tags": [
+                  "string"
+                ],
+                "username": "ro_chauhan"
+              }
+            }
+          ]
+        }
+      },
+      "400": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "BAD_REQUEST",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "",
+              "code": "malformed",
+              "message": "invalid character '}' looking for beginning of object key string",
+              "location": "body",
+              "value": "{\"name\":\"Rohan\",}"
+            }
+          ]
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-v1-users-search",
+    "method": "GET",
+    "path": "/v1/users/search",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/SearchUsersResponse.json",
+          "partial": true,
+          "results": [
+            {
+              "email": "string",
+              "id": "string",
+              "name": "string"
+            }
+          ]
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-v1-users-stream",
+    "method": "GET",
+    "path": "/v1/users/stream",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/x-ndjson",
+        "body": "string"
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "delete-v1-users-by-id",
+    "method": "DELETE",
+    "path": "/v1/users/{id}",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/DeleteUserResponse.json",
+          "deleted": true
+        }
+      },
+      "404": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/DeleteUserResponse.json",
+          "deleted": true
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-v1-users-by-id",
+    "method": "GET",
+    "path": "/v1/users/{id}",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/User.json",
+          "active": true,
+          "deleted_at": "2024-01-02T15:04:05.000Z",
+          "email": "rohan@example.com",
+          "email_raw": "Rohan+news@Example.com",
+          "id": "20240101120000",
+          "last_login_at": "2024-01-02T15:04:05.000Z",
+          "last_seen_at": "2024-01-02T15:04:05.000Z",
+          "metadata": {},
+          "name": "Rohan Chauhan",
+          "phone": "+436601234567",
+          "post_count": 1,
+          "posts": [
+            {
+              "$schema": "https://example.com/schemas/Post.json",
+              "author_id": "20240101120000",
+              "body": "My first post.",
+              "comments": [
+                {
+                  "$schema": "https://example.com/schemas/Comment.json",
+                  "author_id": "20240101120000",
+                  "body": "Nice post!",
+                  "created_at": "2024-01-02T15:04:05.000Z",
+                  "id": "20240101121000",
+                  "parent_id": "20240101120900",
+                  "post_id": "20240101120500",
+                  "reply_count": 1,
+                  "status": "published",
+                  "updated_at": "2024-01-02T15:04:05.000Z"
+                }
+              ],
+              "created_at": "2024-01-02T15:04:05.000Z",
+              "id": "20240101120500",
+              "title": "Hello, world",
+              "updated_at": "2024-01-02T15:04:05.000Z"
+            }
+          ],
+          "status": "invited",
+          "tags": [
+            "string"
+          ],
+          "username": "ro_chauhan"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "put-v1-users-by-id",
+    "method": "PUT",
+    "path": "/v1/users/{id}",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/User.json",
+          "active": true,
+          "deleted_at": "2024-01-02T15:04:05.000Z",
+          "email": "rohan@example.com",
+          "email_raw": "Rohan+news@Example.com",
+          "id": "20240101120000",
+          "last_login_at": "2024-01-02T15:04:05.000Z",
+          "last_seen_at": "2024-01-02T15:04:05.000Z",
+          "metadata": {},
+          "name": "Rohan Chauhan",
+          "phone": "+436601234567",
+          "post_count": 1,
+          "posts": [
+            {
+              "$schema": "https://example.com/schemas/Post.json",
+              "author_id": "20240101120000",
+              "body": "My first post.",
+              "comments": [
+                {
+                  "$schema": "https://example.com/schemas/Comment.json",
+                  "author_id": "20240101120000",
+                  "body": "Nice post!",
+                  "created_at": "2024-01-02T15:04:05.000Z",
+                  "id": "20240101121000",
+                  "parent_id": "20240101120900",
+                  "post_id": "20240101120500",
+                  "reply_count": 1,
+                  "status": "published",
+                  "updated_at": "2024-01-02T15:04:05.000Z"
+                }
+              ],
+              "created_at": "2024-01-02T15:04:05.000Z",
+              "id": "20240101120500",
+              "title": "Hello, world",
+              "updated_at": "2024-01-02T15:04:05.000Z"
+            }
+          ],
+          "status": "invited",
+          "tags": [
+            "string"
+          ],
+          "username": "ro_chauhan"
+        }
+      },
+      "400": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "BAD_REQUEST",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "",
+              "code": "malformed",
+              "message": "invalid character '}' looking for beginning of object key string",
+              "location": "body",
+              "value": "{\"name\":\"Rohan\",}"
+            }
+          ]
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "409": {
+        "contentType": "application/problem+json",
+        "body": {
+          "$schema": "https://example.com/schemas/ErrorModel.json",
+          "code": "BAD_REQUEST",
+          "detail": "Property foo is required but is missing.",
+          "errors": [
+            {
+              "code": "required",
+              "field": "tags[0]",
+              "location": "body.tags[0]",
+              "message": "string",
+              "value": null
+            }
+          ],
+          "instance": "https://example.com/error-log/abc123",
+          "status": 400,
+          "title": "Bad Request",
+          "type": "https://example.com/errors/example"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "$schema": "https://example.com/schemas/ErrorModel.json",
+          "code": "BAD_REQUEST",
+          "detail": "Property foo is required but is missing.",
+          "errors": [
+            {
+              "code": "required",
+              "field": "tags[0]",
+              "location": "body.tags[0]",
+              "message": "string",
+              "value": null
+            }
+          ],
+          "instance": "https://example.com/error-log/abc123",
+          "status": 400,
+          "title": "Bad Request",
+          "type": "https://example.com/errors/example"
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-v1-users-by-id-activate",
+    "method": "POST",
+    "path": "/v1/users/{id}/activate",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/User.json",
+          "active": true,
+          "deleted_at": "2024-01-02T15:04:05.000Z",
+          "email": "rohan@example.com",
+          "email_raw": "Rohan+news@Example.com",
+          "id": "20240101120000",
+          "last_login_at": "2024-01-02T15:04:05.000Z",
+          "last_seen_at": "2024-01-02T15:04:05.000Z",
+          "metadata": {},
+          "name": "Rohan Chauhan",
+          "phone": "+436601234567",
+          "post_count": 1,
+          "posts": [
+            {
+              "$schema": "https://example.com/schemas/Post.json",
+              "author_id": "20240101120000",
+              "body": "My first post.",
+              "comments": [
+                {
+                  "$schema": "https://example.com/schemas/Comment.json",
+                  "author_id": "20240101120000",
+                  "body": "Nice post!",
+                  "created_at": "2024-01-02T15:04:05.000Z",
+                  "id": "20240101121000",
+                  "parent_id": "20240101120900",
+                  "post_id": "20240101120500",
+                  "reply_count": 1,
+                  "status": "published",
+                  "updated_at": "2024-01-02T15:04:05.000Z"
+                }
+              ],
+              "created_at": "2024-01-02T15:04:05.000Z",
+              "id": "20240101120500",
+              "title": "Hello, world",
+              "updated_at": "2024-01-02T15:04:05.000Z"
+            }
+          ],
+          "status": "invited",
+          "tags": [
+            "string"
+          ],
+          "username": "ro_chauhan"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "409": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USERNAME_TAKEN",
+          "title": "Conflict",
+          "status": 409,
+          "detail": "username is already taken"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-v1-users-by-id-data-export",
+    "method": "GET",
+    "path": "/v1/users/{id}/data-export",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/UserDataExport.json",
+          "audit": [
+            {
+              "causation_id": "string",
+              "correlation_id": "string",
+              "data": null,
+              "event_id": "string",
+              "id": 1,
+              "subject": "string",
+              "time": "2024-01-02T15:04:05.000Z",
+              "type": "user.activated"
+            }
+          ],
+          "comments": [
+            {
+              "$schema": "https://example.com/schemas/Comment.json",
+              "author_id": "20240101120000",
+              "body": "Nice post!",
+              "created_at": "2024-01-02T15:04:05.000Z",
+              "id": "20240101121000",
+              "parent_id": "20240101120900",
+              "post_id": "20240101120500",
+              "reply_count": 1,
+              "status": "published",
+              "updated_at": "2024-01-02T15:04:05.000Z"
+            }
+          ],
+          "exported_at": "2024-01-02T15:04:05.000Z",
+          "posts": [
+            {
+              "$schema": "https://example.com/schemas/Post.json",
+              "author_id": "20240101120000",
+              "body": "My first post.",
+              "comments": [
+                {
+                  "$schema": "https://example.com/schemas/Comment.json",
+                  "author_id": "20240101120000",
+                  "body": "Nice post!",
+                  "created_at": "2024-01-02T15:04:05.000Z",
+                  "id": "20240101121000",
+                  "parent_id": "20240101120900",
+                  "post_id": "20240101120500",
+                  "reply_count": 1,
+                  "status": "published",
+                  "updated_at": "2024-01-02T15:04:05.000Z"
+                }
+              ],
+              "created_at": "2024-01-02T15:04:05.000Z",
+              "id": "20240101120500",
+              "title": "Hello, world",
+              "updated_at": "2024-01-02T15:04:05.000Z"
+            }
+          ],
+          "preferences": {
+            "$schema": "https://example.com/schemas/UserPreferences.json",
+            "locale": "de-AT",
+            "notifications": {
+              "digest": "weekly",
+              "email": true,
+              "in_app": true
+            },
+            "timezone": "Europe/Vienna"
+          },
+          "user": {
+            "$schema": "https://example.com/schemas/User.json",
+            "active": true,
+            "deleted_at": "2024-01-02T15:04:05.000Z",
+            "email": "rohan@example.com",
+            "email_raw": "Rohan+news@Example.com",
+            "id": "20240101120000",
+            "last_login_at": "2024-01-02T15:04:05.000Z",
+            "last_seen_at": "2024-01-02T15:04:05.000Z",
+            "metadata": {},
+            "name": "Rohan Chauhan",
+            "phone": "+436601234567",
+            "post_count": 1,
+            "posts": [
+              {
+                "$schema": "https://example.com/schemas/Post.json",
+                "author_id": "20240101120000",
+                "body": "My first post.",
+                "comments": [
+                  {
+                    "$schema": "https://example.com/schemas/Comment.json",
+                    "author_id": "20240101120000",
+                    "body": "Nice post!",
+                    "created_at": "2024-01-02T15:04:05.000Z",
+                    "id": "20240101121000",
+                    "parent_id": "20240101120900",
+                    "post_id": "20240101120500",
+                    "reply_count": 1,
+                    "status": "published",
+                    "updated_at": "2024-01-02T15:04:05.000Z"
+                  }
+                ],
+                "created_at": "2024-01-02T15:04:05.000Z",
+                "id": "20240101120500",
+                "title": "Hello, world",
+                "updated_at": "2024-01-02T15:04:05.000Z"
+              }
+            ],
+            "status": "invited",
+            "tags": [
+              "string"
+            ],
+            "username": "ro_chauhan"
+          }
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-v1-users-by-id-deactivate",
+    "method": "POST",
+    "path": "/v1/users/{id}/deactivate",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/User.json",
+          "active": true,
+          "deleted_at": "2024-01-02T15:04:05.000Z",
+          "email": "rohan@example.com",
+          "email_raw": "Rohan+news@Example.com",
+          "id": "20240101120000",
+          "last_login_at": "2024-01-02T15:04:05.000Z",
+          "last_seen_at": "2024-01-02T15:04:05.000Z",
+          "metadata": {},
+          "name": "Rohan Chauhan",
+          "phone": "+436601234567",
+          "post_count": 1,
+          "posts": [
+            {
+              "$schema": "https://example.com/schemas/Post.json",
+              "author_id": "20240101120000",
+              "body": "My first post.",
+              "comments": [
+                {
+                  "$schema": "https://example.com/schemas/Comment.json",
+                  "author_id": "20240101120000",
+                  "body": "Nice post!",
+                  "created_at": "2024-01-02T15:04:05.000Z",
+                  "id": "20240101121000",
+                  "parent_id": "20240101120900",
+                  "post_id": "20240101120500",
+                  "reply_count": 1,
+                  "status": "published",
+                  "updated_at": "2024-01-02T15:04:05.000Z"
+                }
+              ],
+              "created_at": "2024-01-02T15:04:05.000Z",
+              "id": "20240101120500",
+              "title": "Hello, world",
+              "updated_at": "2024-01-02T15:04:05.000Z"
+            }
+          ],
+          "status": "invited",
+          "tags": [
+            "string"
+          ],
+          "username": "ro_chauhan"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "409": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USERNAME_TAKEN",
+          "title": "Conflict",
+          "status": 409,
+          "detail": "username is already taken"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-v1-users-by-id-posts",
+    "method": "GET",
+    "path": "/v1/users/{id}/posts",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/PostsListResponse.json",
+          "has_more": true,
+          "next": {
+            "after": "20240101120000",
+            "href": "/v1/users?page=2\u0026per_page=100",
+            "page": 2,
+            "per_page": 100
+          },
+          "posts": [
+            {
+              "$schema": "https://example.com/schemas/Post.json",
+              "author_id": "20240101120000",
+              "body": "My first post.",
+              "comments": [
+                {
+                  "$schema": "https://example.com/schemas/Comment.json",
+                  "author_id": "20240101120000",
+                  "body": "Nice post!",
+                  "created_at": "2024-01-02T15:04:05.000Z",
+                  "id": "20240101121000",
+                  "parent_id": "20240101120900",
+                  "post_id": "20240101120500",
+                  "reply_count": 1,
+                  "status": "published",
+                  "updated_at": "2024-01-02T15:04:05.000Z"
+                }
+              ],
+              "created_at": "2024-01-02T15:04:05.000Z",
+              "id": "20240101120500",
+              "title": "Hello, world",
+              "updated_at": "2024-01-02T15:04:05.000Z"
+            }
+          ]
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-v1-users-by-id-posts",
+    "method": "POST",
+    "path": "/v1/users/{id}/posts",
+    "status": 201,
+    "responses": {
+      "201": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/Post.json",
+          "author_id": "20240101120000",
+          "body": "My first post.",
+          "comments": [
+            {
+              "$schema": "https://example.com/schemas/Comment.json",
+              "author_id": "20240101120000",
+              "body": "Nice post!",
+              "created_at": "2024-01-02T15:04:05.000Z",
+              "id": "20240101121000",
+              "parent_id": "20240101120900",
+              "post_id": "20240101120500",
+              "reply_count": 1,
+              "status": "published",
+              "updated_at": "2024-01-02T15:04:05.000Z"
+            }
+          ],
+          "created_at": "2024-01-02T15:04:05.000Z",
+          "id": "20240101120500",
+          "title": "Hello, world",
+          "updated_at": "2024-01-02T15:04:05.000Z"
+        }
+      },
+      "400": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "BAD_REQUEST",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "",
+              "code": "malformed",
+              "message": "invalid character '}' looking for beginning of object key string",
+              "location": "body",
+              "value": "{\"name\":\"Rohan\",}"
+            }
+          ]
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-v1-users-by-id-preferences",
+    "method": "GET",
+    "path": "/v1/users/{id}/preferences",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/UserPreferences.json",
+          "locale": "de-AT",
+          "notifications": {
+            "digest": "weekly",
+            "email": true,
+            "in_app": true
+          },
+          "timezone": "Europe/Vienna"
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "put-v1-users-by-id-preferences",
+    "method": "PUT",
+    "path": "/v1/users/{id}/preferences",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/UserPreferences.json",
+          "locale": "de-AT",
+          "notifications": {
+            "digest": "weekly",
+            "email": true,
+            "in_app": true
+          },
+          "timezone": "Europe/Vienna"
+        }
+      },
+      "400": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "BAD_REQUEST",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "",
+              "code": "malformed",
+              "message": "invalid character '}' looking for beginning of object key string",
+              "location": "body",
+              "value": "{\"name\":\"Rohan\",}"
+            }
+          ]
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "post-v1-users-by-id-status",
+    "method": "POST",
+    "path": "/v1/users/{id}/status",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/User.json",
+          "active": true,
+          "deleted_at": "2024-01-02T15:04:05.000Z",
+          "email": "rohan@example.com",
+          "email_raw": "Rohan+news@Example.com",
+          "id": "20240101120000",
+          "last_login_at": "2024-01-02T15:04:05.000Z",
+          "last_seen_at": "2024-01-02T15:04:05.000Z",
+          "metadata": {},
+          "name": "Rohan Chauhan",
+          "phone": "+436601234567",
+          "post_count": 1,
+          "posts": [
+            {
+              "$schema": "https://example.com/schemas/Post.json",
+              "author_id": "20240101120000",
+              "body": "My first post.",
+              "comments": [
+                {
+                  "$schema": "https://example.com/schemas/Comment.json",
+                  "author_id": "20240101120000",
+                  "body": "Nice post!",
+                  "created_at": "2024-01-02T15:04:05.000Z",
+                  "id": "20240101121000",
+                  "parent_id": "20240101120900",
+                  "post_id": "20240101120500",
+                  "reply_count": 1,
+                  "status": "published",
+                  "updated_at": "2024-01-02T15:04:05.000Z"
+                }
+              ],
+              "created_at": "2024-01-02T15:04:05.000Z",
+              "id": "20240101120500",
+              "title": "Hello, world",
+              "updated_at": "2024-01-02T15:04:05.000Z"
+            }
+          ],
+          "status": "invited",
+          "tags": [
+            "string"
+          ],
+          "username": "ro_chauhan"
+        }
+      },
+      "400": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "BAD_REQUEST",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "",
+              "code": "malformed",
+              "message": "invalid character '}' looking for beginning of object key string",
+              "location": "body",
+              "value": "{\"name\":\"Rohan\",}"
+            }
+          ]
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "409": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USERNAME_TAKEN",
+          "title": "Conflict",
+          "status": 409,
+          "detail": "username is already taken"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "put-v1-users-by-id-tags",
+    "method": "PUT",
+    "path": "/v1/users/{id}/tags",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/UserTags.json",
+          "tags": [
+            "beta"
+          ]
+        }
+      },
+      "400": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "BAD_REQUEST",
+          "title": "Bad Request",
+          "status": 400,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "",
+              "code": "malformed",
+              "message": "invalid character '}' looking for beginning of object key string",
+              "location": "body",
+              "value": "{\"name\":\"Rohan\",}"
+            }
+          ]
+        }
+      },
+      "404": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "USER_NOT_FOUND",
+          "title": "Not Found",
+          "status": 404,
+          "detail": "User not found"
+        }
+      },
+      "422": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "VALIDATION_FAILED",
+          "title": "Unprocessable Entity",
+          "status": 422,
+          "detail": "validation failed",
+          "errors": [
+            {
+              "field": "email",
+              "code": "format",
+              "message": "expected string to be RFC 5322 email: mail: missing '@' or angle-addr",
+              "location": "body.email",
+              "value": "rohan.example.com"
+            }
+          ]
+        }
+      },
+      "500": {
+        "contentType": "application/problem+json",
+        "body": {
+          "code": "INTERNAL_ERROR",
+          "title": "Internal Server Error",
+          "status": 500,
+          "detail": "unexpected error occurred"
+        }
+      }
+    }
+  },
+  {
+    "operationId": "get-version",
+    "method": "GET",
+    "path": "/version",
+    "status": 200,
+    "responses": {
+      "200": {
+        "contentType": "application/json",
+        "body": {
+          "$schema": "https://example.com/schemas/VersionResponse.json",
+          "commit": "string",
+          "date": "string",
+          "go_version": "string",
+          "version": "string"
+        }
+      }
+    }
+  }
+];
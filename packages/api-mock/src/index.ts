@@ -0,0 +1,191 @@
+import type { operations } from "@monorepo/api";
+import { fixtures } from "./fixtures";
+import type { Fixture, OperationId } from "./types";
+
+export type { Fixture, FixtureResponse, OperationId } from "./types";
+export { fixtures };
+
+/** Any body an operation documents, for any of its statuses. */
+export type ResponseBody<Id extends OperationId> = {
+  [S in keyof operations[Id]["responses"]]: operations[Id]["responses"][S] extends { content: infer C }
+    ? C[keyof C]
+    : never;
+}[keyof operations[Id]["responses"]];
+
+/** What to answer an operation with instead of its fixture. */
+export interface MockResponse<Id extends OperationId> {
+  /** The fixture's status if left out. */
+  status?: number;
+  /** Sent as JSON; no body if left out. */
+  body?: ResponseBody<Id>;
+  headers?: Record<string, string>;
+}
+
+/** A request the fake got. */
+export interface MockRequest {
+  operationId: OperationId;
+  method: string;
+  /** The path requested, below the base URL. */
+  path: string;
+  /** The path parameters, by the names the spec gives them. */
+  params: Record<string, string>;
+  query: URLSearchParams;
+  headers: Headers;
+  /** The body parsed as JSON, or as text if it isn't; undefined without one. */
+  body: unknown;
+}
+
+/** A fixed response, or a function of the request returning one. */
+export type MockHandler<Id extends OperationId> =
+  | MockResponse<Id>
+  | ((request: MockRequest) => MockResponse<Id> | Promise<MockResponse<Id>>);
+
+export interface FakeAPI {
+  /**
+   * A fetch answering every operation of the contract with its fixture, or
+   * what `respond` set. Send `Prefer: code=404` to get another documented
+   * response. Responses carry `X-Mock: true`.
+   */
+  fetch: typeof fetch;
+  /** Every request fetch got, oldest first. */
+  requests: MockRequest[];
+  /** Answers the operation with handler from now on, instead of its fixture. */
+  respond<Id extends OperationId>(operationId: Id, handler: MockHandler<Id>): void;
+  /** Drops what respond set and the requests seen, for the next test. */
+  reset(): void;
+}
+
+export interface FakeAPIOptions {
+  /** Where the app sends its requests; `http://localhost` by default. */
+  baseUrl?: string;
+}
+
+interface Route {
+  fixture: Fixture;
+  pattern: RegExp;
+  params: string[];
+}
+
+function compile(fixture: Fixture): Route {
+  const params: string[] = [];
+  const source = fixture.path
+    .split(/(\{[^}]+\})/)
+    .map((part) => {
+      if (part.startsWith("{") && part.endsWith("}")) {
+        params.push(part.slice(1, -1));
+        return "([^/]+)";
+      }
+      return part.replace(/[.*+?^$()|[\]\\]/g, "\\$&");
+    })
+    .join("");
+  return { fixture, pattern: new RegExp(`^${source}$`), params };
+}
+
+// Routes with fewer parameters first, so /v1/users/stream wins over
+// /v1/users/{id} as it does on the server.
+const routes = fixtures.map(compile).sort((a, b) => a.params.length - b.params.length);
+
+function preferredCode(headers: Headers): number | undefined {
+  for (const pref of (headers.get("Prefer") ?? "").split(",")) {
+    const match = /^code=(\d{3})$/.exec(pref.trim());
+    if (match) {
+      return Number(match[1]);
+    }
+  }
+  return undefined;
+}
+
+function parseBody(text: string): unknown {
+  if (text === "") {
+    return undefined;
+  }
+  try {
+    return JSON.parse(text);
+  } catch {
+    return text;
+  }
+}
+
+function reply(status: number, contentType: string | undefined, body: unknown, headers: Record<string, string> = {}): Response {
+  const h = new Headers({ "X-Mock": "true", ...headers });
+  // Responses of these statuses can't have a body.
+  if (body === undefined || status === 204 || status === 304) {
+    return new Response(null, { status, headers: h });
+  }
+  if (!h.has("Content-Type")) {
+    h.set("Content-Type", contentType ?? "application/json");
+  }
+  return new Response(JSON.stringify(body), { status, headers: h });
+}
+
+/**
+ * Creates a fake of the API for tests: pass its `fetch` to the client the
+ * app uses, or assign it to `globalThis.fetch`.
+ *
+ * ```ts
+ * const api = createFakeAPI();
+ * api.respond("get-v1-users-by-id", { status: 404 });
+ * render(<App fetch={api.fetch} />);
+ * expect(api.requests.map((r) => r.operationId)).toContain("get-v1-users-by-id");
+ * ```
+ */
+export function createFakeAPI(options: FakeAPIOptions = {}): FakeAPI {
+  const base = new URL(options.baseUrl ?? "http://localhost");
+  const basePath = base.pathname.replace(/\/$/, "");
+  const handlers = new Map<OperationId, MockHandler<OperationId>>();
+  const requests: MockRequest[] = [];
+
+  const fakeFetch = async (input: RequestInfo | URL, init?: RequestInit): Promise<Response> => {
+    const request = input instanceof Request ? input : undefined;
+    const url = new URL(request ? request.url : input.toString(), base);
+    const method = (init?.method ?? request?.method ?? "GET").toUpperCase();
+    const headers = new Headers(init?.headers ?? request?.headers);
+    const text = typeof init?.body === "string" ? init.body : request ? await request.text() : "";
+    const path = url.pathname.startsWith(basePath) ? url.pathname.slice(basePath.length) || "/" : url.pathname;
+
+    for (const route of routes) {
+      const match = route.fixture.method === method ? route.pattern.exec(path) : null;
+      if (!match) {
+        continue;
+      }
+      const { fixture } = route;
+      const params = Object.fromEntries(route.params.map((name, i) => [name, decodeURIComponent(match[i + 1])]));
+      const seen: MockRequest = {
+        operationId: fixture.operationId,
+        method,
+        path,
+        params,
+        query: url.searchParams,
+        headers,
+        body: parseBody(text),
+      };
+      requests.push(seen);
+
+      const preferred = preferredCode(headers);
+      const handler = handlers.get(fixture.operationId);
+      if (handler && preferred === undefined) {
+        const response = typeof handler === "function" ? await handler(seen) : handler;
+        const status = response.status ?? fixture.status;
+        return reply(status, fixture.responses[status]?.contentType, response.body, response.headers);
+      }
+      const status = preferred ?? fixture.status;
+      // A status the operation doesn't document is answered with just
+      // the status.
+      const canned = fixture.responses[status] ?? {};
+      return reply(status, canned.contentType, canned.body);
+    }
+    return new Response("mock: no operation matches the path", { status: 404, headers: { "Content-Type": "text/plain" } });
+  };
+
+  return {
+    fetch: fakeFetch as typeof fetch,
+    requests,
+    respond(operationId, handler) {
+      handlers.set(operationId, handler as MockHandler<OperationId>);
+    },
+    reset() {
+      handlers.clear();
+      requests.length = 0;
+    },
+  };
+}
@@ -0,0 +1,27 @@
+import type { operations } from "@monorepo/api";
+
+/** The ID of an operation of the API, as in `operations`. */
+export type OperationId = keyof operations;
+
+/** The response of a fixture with one status. */
+export interface FixtureResponse {
+  contentType?: string;
+  /** Sent encoded as JSON, whatever the content type, as `serve --mock` does. */
+  body?: unknown;
+}
+
+/**
+ * An operation's canned responses, generated by `api gen:mock` from the same
+ * spec as the contract. Typing `operationId` as an `OperationId` makes a
+ * fixture of an operation the contract no longer has fail to compile.
+ */
+export interface Fixture {
+  operationId: OperationId;
+  method: string;
+  /** The path template, as in the spec: `/v1/users/{id}`. */
+  path: string;
+  /** The status answered unless the request prefers another. */
+  status: number;
+  /** By status code. */
+  responses: Record<string, FixtureResponse>;
+}
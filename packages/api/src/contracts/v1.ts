@@ -5,123 +5,7051 @@
 
 
 export interface paths {
+  "/admin/backup": {
+    /**
+     * Back up the store
+     * @description Download every user, their preferences, password hashes, posts and comments as a gzipped JSON archive, which `post-admin-restore` accepts. Requires the admin token.
+     */
+    get: operations["get-admin-backup"];
+  };
+  "/admin/dead-letters": {
+    /**
+     * List dead letters
+     * @description List the background deliveries that failed every attempt, newest first: security events the SECURITY_EVENTS sink didn't take and purges the CDN refused. Each has what was to be delivered and every failed attempt with its error. The queue is kept in memory on this replica, holds up to 10000, and is lost on restart. Requires the admin token.
+     */
+    get: operations["get-admin-dead-letters"];
+  };
+  "/admin/dead-letters/{id}": {
+    /**
+     * Get a dead letter
+     * @description Get a dead letter with its payload and error history. Requires the admin token.
+     */
+    get: operations["get-admin-dead-letters-by-id"];
+    /**
+     * Discard a dead letter
+     * @description Drop a dead letter without delivering it. Requires the admin token.
+     */
+    delete: operations["delete-admin-dead-letters-by-id"];
+  };
+  "/admin/dead-letters/{id}/requeue": {
+    /**
+     * Requeue a dead letter
+     * @description Put a dead letter back on its queue, to be delivered again once. It is listed as `requeued` until it is delivered, when it leaves the queue, or fails again, when it is `dead` again with the new attempt in its history. Fails with 409 if it is already requeued. Requires the admin token.
+     */
+    post: operations["post-admin-dead-letters-by-id-requeue"];
+  };
+  "/admin/digest": {
+    /**
+     * Send the activity digests
+     * @description Send the activity digest email now to every active user whose preferences ask for the `period` digest, instead of waiting for DIGEST_AT. It summarizes the users created and changed in the last day or week, from the audit log, in each user's locale. With dry_run it only counts who would get one. Fails with 409 while the period's digests are being sent, on this or another replica. Requires the admin token.
+     */
+    post: operations["post-admin-digest"];
+  };
+  "/admin/events/replay": {
+    /**
+     * Replay events
+     * @description Deliver the events from `from_seq` to `to_seq` again, up to 1000 at a time, to the consumers outside the API that may have missed them during an outage: the security event stream, marked `replayed`, and the CDN purges. The receivers get the events' original IDs, to skip those they had. Fails with 409 if neither is configured. Requires the admin token.
+     */
+    post: operations["post-admin-events-replay"];
+  };
+  "/admin/faults": {
+    /**
+     * List the fault rules
+     * @description List the rules this replica injects faults with. Only available with FAULT_INJECTION set, or in dev mode. Requires the admin token.
+     */
+    get: operations["get-admin-faults"];
+    /**
+     * Set the fault rules
+     * @description Replace the rules this replica injects faults with, to exercise clients' timeouts and retries: each makes a share of a route's requests wait, fail with a 5xx `INJECTED_FAULT`, or lose their connection. The admin API and probes are never hit. An empty list turns faults off. Rules live in memory, per replica. Only available with FAULT_INJECTION set, or in dev mode. Requires the admin token.
+     */
+    put: operations["put-admin-faults"];
+  };
+  "/admin/health/history": {
+    /**
+     * Get the health history
+     * @description Report what this replica's health probes found: it probes its readiness, as /readyz answers it, every HEALTH_PROBE_INTERVAL (10s by default) and keeps the latest 8640 outcomes. `uptime_percent` is the share of those that found it up, and `transitions` are the latest 100 changes between up and down, newest first, with why it was down. The history is kept in memory and starts over on restart. Requires the admin token.
+     */
+    get: operations["get-admin-health-history"];
+  };
+  "/admin/impersonate/{userID}": {
+    /**
+     * Impersonate a user
+     * @description Issue a short-lived user token that acts as the user, with an `impersonated_by` claim naming `actor`, so support staff can reproduce what the user sees. Issuing it and every request made with it are recorded in the audit log; requests made with it don't count as the user being seen. Requires the admin token.
+     */
+    post: operations["post-admin-impersonate-by-user-id"];
+  };
+  "/admin/inflight": {
+    /**
+     * List in-flight requests
+     * @description List the requests this replica is serving, oldest first, with their route, client and how long they have been running, to find the one holding the server up. Requires the admin token.
+     */
+    get: operations["get-admin-inflight"];
+  };
+  "/admin/inflight/{id}/cancel": {
+    /**
+     * Cancel an in-flight request
+     * @description Cancel the context of the request with the X-Request-ID, e.g. a stuck export, so its handler gives up. A request canceled before it started its response gets a 503 `REQUEST_CANCELED`; one already streaming its response is cut short. The work a handler doesn't check its context for may still be done. Returns the canceled requests, more than one if clients sent the same ID. Requires the admin token.
+     */
+    post: operations["post-admin-inflight-by-id-cancel"];
+  };
+  "/admin/leader": {
+    /**
+     * Get the leader
+     * @description Report which replica leads, running the scheduled retention and digests, and whether the one answering does. The leader is the raft leader with RAFT_NODE_ID set, or else whoever holds the leader lock in REDIS_URLS; without either, every replica leads itself. Requires the admin token.
+     */
+    get: operations["get-admin-leader"];
+  };
+  "/admin/loglevel": {
+    /**
+     * Change the log level
+     * @description Change the minimum log level of the running server, optionally going back to the configured level after `revert_after_minutes`. Requires the admin token.
+     */
+    put: operations["put-admin-loglevel"];
+  };
+  "/admin/maintenance": {
+    /**
+     * Get the maintenance mode
+     * @description Report whether this replica is in maintenance mode, and since when. Requires the admin token.
+     */
+    get: operations["get-admin-maintenance"];
+    /**
+     * Switch maintenance mode
+     * @description Put this replica in maintenance mode, or take it out. `on` answers every request but health, metrics, version, the docs and the admin API with 503 `MAINTENANCE` and Retry-After; `read_only` still serves GET and HEAD. Background writes, like recording user activity and scheduled retention, pause too. Holds until changed again or MAINTENANCE_MODE changes on reload. Requires the admin token.
+     */
+    put: operations["put-admin-maintenance"];
+  };
+  "/admin/ratelimits": {
+    /**
+     * List the API key quotas
+     * @description List every API key with a quota or with requests counted on this replica, with its daily and monthly caps and the requests it made in the current UTC day and month. Requires the admin token.
+     */
+    get: operations["get-admin-ratelimits"];
+  };
+  "/admin/ratelimits/{keyID}": {
+    /**
+     * Get an API key's quota
+     * @description Get an API key's daily and monthly caps, zero if it has none, and the requests it made on this replica in the current UTC day and month. Requires the admin token.
+     */
+    get: operations["get-admin-ratelimits-by-key-id"];
+    /**
+     * Set an API key's quota
+     * @description Cap the requests an API key makes per UTC day and month. Once either is used up, its requests get 429 `QUOTA_EXCEEDED` with Retry-After until the period starts over; the admin API and probes are never refused. Lowering a cap below the requests already made takes effect at once. Quotas and counts live in memory, per replica. Requires the admin token.
+     */
+    put: operations["put-admin-ratelimits-by-key-id"];
+    /**
+     * Lift an API key's quota
+     * @description Remove an API key's caps. Its requests are still counted. Requires the admin token.
+     */
+    delete: operations["delete-admin-ratelimits-by-key-id"];
+  };
+  "/admin/reencrypt": {
+    /**
+     * Re-encrypt users' PII
+     * @description Rewrite every user's email and phone that is plaintext or encrypted under an older key under the primary key, so the older key can be removed. Fails with 409 if field encryption is off. Requires the admin token.
+     */
+    post: operations["post-admin-reencrypt"];
+  };
+  "/admin/requests": {
+    /**
+     * List recent requests
+     * @description List the latest requests this replica served, newest first, with their responses, for debugging clients. Headers, parameters and bodies are redacted like the logs, and bodies truncated to 4 KiB. Health checks and metrics scrapes are left out. Only available with CAPTURE_REQUESTS set, or in dev mode. Requires the admin token.
+     */
+    get: operations["get-admin-requests"];
+    /**
+     * Clear the recent requests
+     * @description Drop the requests GET /admin/requests lists, e.g. before reproducing a problem. Requires the admin token.
+     */
+    delete: operations["delete-admin-requests"];
+  };
+  "/admin/restore": {
+    /**
+     * Restore the store from a backup
+     * @description Replace every user, their preferences, posts and comments with the contents of an archive from `get-admin-backup`. Users not in the archive are deleted. Fails with 409 while another restore is in progress, on this or another replica. Requires the admin token.
+     */
+    post: operations["post-admin-restore"];
+  };
+  "/admin/retention": {
+    /**
+     * Apply the retention policy
+     * @description Run the configured retention rules now instead of waiting for the next scheduled run: purge users soft-deleted longer ago than RETENTION_DELETED_USERS and audit entries older than RETENTION_AUDIT. With dry_run it only reports what would be purged. Fails with 409 while another run is in progress, on this or another replica. Requires the admin token.
+     */
+    post: operations["post-admin-retention"];
+  };
+  "/admin/telemetry": {
+    /**
+     * Show the usage report
+     * @description Show whether this replica sends anonymous usage reports, which it only does with TELEMETRY=true, where and how often, and exactly what the next report would hold were it sent now: how many requests each operation got, the version, the platform and the store backend. Nothing about users, their data or addresses, or the configuration is reported. Requires the admin token.
+     */
+    get: operations["get-admin-telemetry"];
+  };
+  "/admin/traffic/blocks": {
+    /**
+     * List the clients the traffic analyzer acted on
+     * @description List the client addresses this replica's traffic analyzer has banned, or put to a CAPTCHA, and not yet let go, with why and until when. Requires the admin token.
+     */
+    get: operations["get-admin-traffic-blocks"];
+  };
+  "/admin/traffic/blocks/{ip}": {
+    /**
+     * Let a client go
+     * @description Lift a ban or CAPTCHA the traffic analyzer put on a client address on this replica before it runs out. Requires the admin token.
+     */
+    delete: operations["delete-admin-traffic-blocks-by-ip"];
+  };
+  "/admin/unlock/{userID}": {
+    /**
+     * Unlock a user's account
+     * @description Clear a user's failed logins, lifting a lockout from too many of them before LOGIN_LOCKOUT runs out. Failures counted against client addresses stay. The unlock is recorded in the audit log. Requires the admin token.
+     */
+    post: operations["post-admin-unlock-by-user-id"];
+  };
+  "/dev/emails/{name}": {
+    /**
+     * Preview an email
+     * @description Render one of the emails the API sends, with made-up data, as HTML for a browser or as the plain-text part. The subject comes in X-Email-Subject. Only available in dev mode.
+     */
+    get: operations["get-dev-emails-by-name"];
+  };
   "/health": {
-    /** Get health */
+    /**
+     * Get health
+     * @description Answer 200, or 503 while the server drains before shutting down. `format=plain` answers in a line of text and `format=prometheus` with a health_up gauge, like the probes.
+     */
     get: operations["get-health"];
   };
   "/hello": {
     /** Get hello */
     get: operations["get-hello"];
   };
+  "/livez": {
+    /**
+     * Liveness probe
+     * @description Answer 200 as long as the process serves requests, draining included, so the orchestrator only restarts a server that stopped responding. Takes `format` as get-health does.
+     */
+    get: operations["get-livez"];
+  };
+  "/readyz": {
+    /**
+     * Readiness probe
+     * @description Answer 200 while the server takes traffic, and 503 from the moment it gets SIGTERM, so the orchestrator takes it out of rotation during SHUTDOWN_DELAY. Listeners only open once every other component has started, so there is no warm-up to wait for, but a component with a readiness gate fails it while it can't serve, like a raft store without a leader. Every component is listed with its status. `format=plain` answers with a line per component, and `format=prometheus` with a health_up gauge and a health_component_ready gauge per component.
+     */
+    get: operations["get-readyz"];
+  };
+  "/v1/api-keys": {
+    /**
+     * List your API keys
+     * @description List the API keys of the user the token acts as, oldest first, with their scopes and when each was last used. Only the start of each key is shown. Needs a user token; API keys can't manage API keys.
+     */
+    get: operations["get-v1-api-keys"];
+    /**
+     * Create an API key
+     * @description Create an API key acting as you, for scripts and integrations, limited to the given scopes and optionally expiring. The key is only shown in this response; send it as `Authorization: Bearer <key>`. A user can have up to 20 keys. Keys stop working when the user is deactivated. Recorded in the audit log as `security.api_key_created`. Needs a user token.
+     */
+    post: operations["post-v1-api-keys"];
+  };
+  "/v1/api-keys/{id}": {
+    /**
+     * Revoke an API key
+     * @description Delete one of your API keys; it stops working at once. Recorded in the audit log as `security.api_key_revoked`. Needs a user token.
+     */
+    delete: operations["delete-v1-api-keys-by-id"];
+  };
+  "/v1/api-keys/{id}/rotate": {
+    /**
+     * Rotate an API key
+     * @description Replace the secret of one of your API keys, keeping its ID, name and scopes. The old key stops working at once; the new one is only shown in this response. Recorded in the audit log as `security.api_key_rotated`. Needs a user token.
+     */
+    post: operations["post-v1-api-keys-by-id-rotate"];
+  };
+  "/v1/auth/introspect": {
+    /**
+     * Introspect a token
+     * @description Say whether a user token or API key works, in the shape of an RFC 7662 introspection response, taking the token as JSON rather than a form: `active` is false, and nothing else is set, for a token that is malformed, forged, expired or revoked. For one that works it says who the token acts as, until when and, for an API key, with which scopes. Holding the token is all it takes.
+     */
+    post: operations["post-v1-auth-introspect"];
+  };
+  "/v1/auth/login": {
+    /**
+     * Log in
+     * @description Exchange an active user's username or email and password for a user token valid for an hour. After a few failed logins in a row, each further attempt has to wait, longer every time (429 `LOGIN_THROTTLED`); after LOGIN_MAX_FAILURES the account is locked for LOGIN_LOCKOUT (423 `ACCOUNT_LOCKED`), and an address with LOGIN_MAX_FAILURES_PER_IP failures is blocked as long. Both come with Retry-After. Failures, lockouts and blocks are recorded in the audit log. When the server has CAPTCHA_PROVIDER set, the request needs an `X-Captcha-Token`.
+     */
+    post: operations["post-v1-auth-login"];
+  };
+  "/v1/auth/revoke": {
+    /**
+     * Revoke a token
+     * @description Make a user token or API key stop working at once, before it expires, as in RFC 7009; holding the token is all it takes. A revoked user token is refused with 401 `INVALID_TOKEN`, and an API key is deleted, as `delete-v1-api-keys-by-id` does. A token that already doesn't work is accepted as revoked. Recorded in the audit log as `security.token_revoked` or `security.api_key_revoked`.
+     */
+    post: operations["post-v1-auth-revoke"];
+  };
+  "/v1/batch": {
+    /**
+     * Run operations atomically
+     * @description Run up to 100 create, update and delete operations in order, as one transaction: either all of them succeed and are committed, or none are. Each operation follows the rules of its single endpoint and sees the writes of the ones before it; `$N` as an `id` names what operation N created. `results` reports every operation's outcome, and the error of the one that failed the transaction. Nothing is locked while it runs: if a request changes a user the transaction read before it commits, it runs again on the new data, and fails with 409 if that keeps happening. When the server has CAPTCHA_PROVIDER set, a transaction that creates users needs an `X-Captcha-Token`. The body may be sent gzipped, with `Content-Encoding: gzip`.
+     */
+    post: operations["post-v1-batch"];
+  };
+  "/v1/changelog": {
+    /**
+     * Get the API changelog
+     * @description List the changes to the API per release, newest first, starting with the changes not released yet. Operations added and removed are listed even where the changelog doesn't mention them, from the operations each release served. Pass since to get only what changed after the release a client was built against.
+     */
+    get: operations["get-v1-changelog"];
+  };
+  "/v1/downloads/{kind}/{id}": {
+    /**
+     * Download a file through a signed link
+     * @description Download a file through a time-limited signed link another operation handed out, such as the `download_url` of get-v1-exports-by-id. The link's signature stands in for credentials, so it can be opened in a browser or passed to another service. A link that was altered or has expired gets 403 `INVALID_SIGNATURE`.
+     */
+    get: operations["get-v1-downloads-by-kind-by-id"];
+  };
+  "/v1/events": {
+    /**
+     * List the events
+     * @description List the domain events published on this replica from `from_seq` on, oldest first, as the audit log records them under sequence numbers; pass `next_seq` as `from_seq` to get the next page. The log is kept in memory, and with AUDIT_LOG_PATH in a file too, so it outlives restarts; `oldest_seq` tells whether events before it are gone. Requires the admin token.
+     */
+    get: operations["get-v1-events"];
+  };
+  "/v1/exports": {
+    /**
+     * Export users in the background
+     * @description Start exporting the users the filters select, which are those of get-v1-users, as NDJSON or CSV, in ID order. The export is built in the background, so it takes no longer than the request to start it; poll the `Location` it returns until `status` is `completed`, then fetch its `download_url`. Exports are kept in memory on the replica that built them for a day after they finish, and are lost on restart. Requires the admin token.
+     */
+    post: operations["post-v1-exports"];
+  };
+  "/v1/exports/{id}": {
+    /**
+     * Get an export
+     * @description Get the status of an export. Once it has completed, `download_url` is a link to the file that works for an hour without credentials; each request returns a fresh one. Requires the admin token.
+     */
+    get: operations["get-v1-exports-by-id"];
+  };
+  "/v1/invitations": {
+    /**
+     * List invitations
+     * @description List the invitations on this replica, newest first, optionally only those with a status. Requires the admin token.
+     */
+    get: operations["get-v1-invitations"];
+    /**
+     * Invite someone to create an account
+     * @description Create a pending invitation for an email no user has, valid for 7 days unless `expires_at` says otherwise. The token to accept it with is only shown in this response. With APP_URL set, it is also emailed as a link to `<APP_URL>/invitations/accept?token=...`, in the invitation's locale. An email can have one pending invitation at a time. Invitations are kept in memory on the replica that created them, so they are lost on restart. Requires the admin token.
+     */
+    post: operations["post-v1-invitations"];
+  };
+  "/v1/invitations/{id}": {
+    /**
+     * Revoke an invitation
+     * @description Revoke an invitation that hasn't been accepted; its token stops working at once. It is still listed, as revoked. Revoking it again is a no-op. Requires the admin token.
+     */
+    delete: operations["delete-v1-invitations-by-id"];
+  };
+  "/v1/invitations/{token}/accept": {
+    /**
+     * Accept an invitation
+     * @description Create the user a pending invitation invites, with its email and the password given, who can then log in. The name defaults to the invitation's. A token that is unknown or revoked gets 404 `INVITATION_NOT_FOUND`, an expired one 410 `INVITATION_EXPIRED`, and one already accepted 409.
+     */
+    post: operations["post-v1-invitations-by-token-accept"];
+  };
+  "/v1/me": {
+    /**
+     * Get the current user
+     * @description Get the user a user token or API key acts as and, for an impersonation token, who is acting as them, or for an API key, its scopes. An API key needs the `users:read` scope.
+     */
+    get: operations["get-v1-me"];
+  };
+  "/v1/notifications": {
+    /**
+     * List your notifications
+     * @description Get a page of the notifications of the user the token acts as, newest first, with how many are unread. Users are notified when their account is created, when someone comments on their post or replies to their comment, and when a post or comment @mentions their username. Notifications are kept in memory, so they start empty on every restart. An API key needs the `notifications:read` scope.
+     */
+    get: operations["get-v1-notifications"];
+  };
+  "/v1/notifications/{id}/read": {
+    /**
+     * Mark a notification read
+     * @description Mark one of your notifications read. Marking it again keeps the time it was first read. An API key needs the `notifications:write` scope.
+     */
+    post: operations["post-v1-notifications-by-id-read"];
+  };
+  "/v1/posts": {
+    /**
+     * List all posts
+     * @description Get a page of every user's posts, or with `author_id` of one user's, in ID order, which is oldest first unless ID_GENERATOR=uuidv4 makes IDs random. `X-Total-Count` and `Link` headers describe the other pages.
+     */
+    get: operations["get-v1-posts"];
+    /**
+     * Create a post
+     * @description Create a post with a title and body, written by the user `author_id` names. Posts belong to their author: deleting the user for good deletes their posts.
+     */
+    post: operations["post-v1-posts"];
+  };
+  "/v1/posts/{id}": {
+    /**
+     * Get post by ID
+     * @description Get a post by its ID.
+     */
+    get: operations["get-v1-posts-by-id"];
+    /**
+     * Update post by ID
+     * @description Change a post's title and/or body. Its author can't be changed.
+     */
+    put: operations["put-v1-posts-by-id"];
+    /**
+     * Delete post by ID
+     * @description Delete a post and the comments on it by its ID.
+     */
+    delete: operations["delete-v1-posts-by-id"];
+  };
+  "/v1/posts/{id}/comments": {
+    /**
+     * List a post's comments
+     * @description Get a page of the top-level comments on a post, or with `parent_id` of the replies to a comment, in ID order, which is oldest first unless ID_GENERATOR=uuidv4 makes IDs random. Each comes with its `reply_count`; walk a thread by listing the replies of those that have some. Comments hidden by moderation are left out unless `include_hidden=true`. `X-Total-Count` and `Link` headers describe the other pages.
+     */
+    get: operations["get-v1-posts-by-id-comments"];
+    /**
+     * Comment on a post
+     * @description Write a comment on a post as the user `author_id` names, or with `parent_id` a reply to another comment on the post. Comments are published straight away.
+     */
+    post: operations["post-v1-posts-by-id-comments"];
+  };
+  "/v1/posts/{id}/comments/{commentID}": {
+    /**
+     * Get a comment
+     * @description Get a comment on a post, with the number of its published replies.
+     */
+    get: operations["get-v1-posts-by-id-comments-by-comment-id"];
+    /**
+     * Edit a comment
+     * @description Replace the body of a comment on a post.
+     */
+    put: operations["put-v1-posts-by-id-comments-by-comment-id"];
+    /**
+     * Delete a comment
+     * @description Delete a comment on a post, along with the replies to it and theirs.
+     */
+    delete: operations["delete-v1-posts-by-id-comments-by-comment-id"];
+  };
+  "/v1/posts/{id}/comments/{commentID}/status": {
+    /**
+     * Moderate a comment
+     * @description Hide a comment from listings, or publish it again. Its replies stay listed under it. Moving a comment to the status it has is a no-op. Requires the admin token.
+     */
+    post: operations["post-v1-posts-by-id-comments-by-comment-id-status"];
+  };
+  "/v1/search": {
+    /**
+     * Search users and posts
+     * @description Full-text search over active users' names, usernames and emails and over posts' titles and bodies, best match first. Each result has the user or post and the fragments of its fields that matched, with the matched words highlighted.
+     */
+    get: operations["get-v1-search"];
+  };
+  "/v1/stats": {
+    /**
+     * Get aggregate counts
+     * @description Count the users, in total, created today and by status and tag, and the posts and comments. The store counts them in place rather than listing everything. With `STATS_CACHE_TTL` set, the counts are reused for that long; `computed_at` says when they were taken. Requires the admin token.
+     */
+    get: operations["get-v1-stats"];
+  };
+  "/v1/usernames/{name}/available": {
+    /**
+     * Check username availability
+     * @description Check whether a username can be registered, for validating signup forms as the user types.
+     */
+    get: operations["get-v1-usernames-by-name-available"];
+  };
   "/v1/users": {
-    /** Get v1 users */
+    /**
+     * List all users
+     * @description Get a page of active users, or of all users with `include_inactive=true`, in ID order, which is oldest first unless ID_GENERATOR=uuidv4 makes IDs random. `X-Total-Count` and `Link` headers describe the other pages. `include=post_count` adds how many posts each user wrote.
+     */
     get: operations["get-v1-users"];
-    /** Post v1 users */
+    /**
+     * Create a new user
+     * @description Create a new user with name and email. When the server has CAPTCHA_PROVIDER set, the request needs an `X-Captcha-Token`.
+     */
     post: operations["post-v1-users"];
   };
+  "/v1/users/batch": {
+    /**
+     * Update several users
+     * @description Apply up to 100 updates in order, each `changes` being what put-v1-users-by-id takes. Every entry is validated and applied on its own, and `results` reports each one's outcome with the status and error the single update would have answered, so one bad entry doesn't fail the rest. The body may be sent gzipped, with `Content-Encoding: gzip`.
+     */
+    patch: operations["patch-v1-users-batch"];
+  };
+  "/v1/users/changes": {
+    /**
+     * Poll for user changes
+     * @description A long-polling change feed for clients that can't use WebSockets or server-sent events. Returns the changes after `since` in order, waiting up to `wait` for one if there are none yet; pass `next_since` as `since` on the next call. Start with `since=-1`. A 410 means changes were missed, after a restart or the retention policy pruned them: list the users again and start over.
+     */
+    get: operations["get-v1-users-changes"];
+  };
+  "/v1/users/lookup": {
+    /**
+     * Get users by IDs
+     * @description Fetch up to 100 users in one call, in request order. IDs without a user come back with `found: false` instead of failing the request.
+     */
+    post: operations["post-v1-users-lookup"];
+  };
+  "/v1/users/search": {
+    /**
+     * Search users by prefix
+     * @description Typeahead search over active users' names and emails. Returns only the fields an autocomplete needs, best match first.
+     */
+    get: operations["get-v1-users-search"];
+  };
+  "/v1/users/stream": {
+    /**
+     * Stream all users
+     * @description Stream every user get-v1-users would list, in ID order, like its pages, and without them, as newline-delimited JSON: one user per line, as get-v1-users-by-id returns them, each flushed as it is written. For bulk consumers that would rather not page through millions of users or hold them all in memory. Takes the filters of get-v1-users, `metadata.<key>=<value>` included. Once the stream has started, a failure can only cut it short, so count the lines if completeness matters.
+     */
+    get: operations["get-v1-users-stream"];
+  };
   "/v1/users/{id}": {
-    /** Get v1 users by ID */
+    /**
+     * Get user by ID
+     * @description Get a user by their ID. `include=post_count` adds how many posts they wrote.
+     */
     get: operations["get-v1-users-by-id"];
-    /** Put v1 users by ID */
+    /**
+     * Update user by ID
+     * @description Update a user's username, name, email, phone and/or metadata by their ID. Fields left out keep their values; an empty phone clears it, and metadata replaces the stored metadata wholesale.
+     */
     put: operations["put-v1-users-by-id"];
-    /** Delete v1 users by ID */
+    /**
+     * Delete user by ID
+     * @description Delete a user, their preferences, posts and comments by their ID. With `mode=erase`, for GDPR erasure requests, the audit log entries about them are anonymized too, and the erasure is audited without naming them.
+     */
     delete: operations["delete-v1-users-by-id"];
   };
+  "/v1/users/{id}/activate": {
+    /**
+     * Activate user
+     * @description Activate an invited or suspended user. Activating an active user is a no-op.
+     */
+    post: operations["post-v1-users-by-id-activate"];
+  };
+  "/v1/users/{id}/data-export": {
+    /**
+     * Export a user's data
+     * @description Get everything stored about a user, for GDPR access requests: the user record, their saved preferences, their posts and comments and the audit log entries about them. The export itself is audited.
+     */
+    get: operations["get-v1-users-by-id-data-export"];
+  };
+  "/v1/users/{id}/deactivate": {
+    /**
+     * Deactivate user
+     * @description Suspend an active user, hiding them from the default listing. Deactivating a suspended user is a no-op.
+     */
+    post: operations["post-v1-users-by-id-deactivate"];
+  };
+  "/v1/users/{id}/posts": {
+    /**
+     * List a user's posts
+     * @description Get a page of the posts a user wrote, in ID order, which is oldest first unless ID_GENERATOR=uuidv4 makes IDs random. `X-Total-Count` and `Link` headers describe the other pages.
+     */
+    get: operations["get-v1-users-by-id-posts"];
+    /**
+     * Write a post for a user
+     * @description Create a post written by the user. The same as post-v1-posts with the user as `author_id`.
+     */
+    post: operations["post-v1-users-by-id-posts"];
+  };
+  "/v1/users/{id}/preferences": {
+    /**
+     * Get user preferences
+     * @description Get a user's preferences. Users who never saved any get the defaults.
+     */
+    get: operations["get-v1-users-by-id-preferences"];
+    /**
+     * Replace user preferences
+     * @description Replace a user's preferences. Omitted fields are reset to their defaults.
+     */
+    put: operations["put-v1-users-by-id-preferences"];
+  };
+  "/v1/users/{id}/status": {
+    /**
+     * Change user status
+     * @description Move a user to another lifecycle status. Invited users can be activated, active and suspended users can be moved between those two, and any user can be deleted; deleted is final.
+     */
+    post: operations["post-v1-users-by-id-status"];
+  };
+  "/v1/users/{id}/tags": {
+    /**
+     * Replace user tags
+     * @description Replace the set of tags on a user. An empty list removes all tags.
+     */
+    put: operations["put-v1-users-by-id-tags"];
+  };
+  "/version": {
+    /**
+     * Get build version
+     * @description Report the version, git commit and build date of the running server, and the Go version it was built with.
+     */
+    get: operations["get-version"];
+  };
 }
 
 export type webhooks = Record<string, never>;
 
 export interface components {
   schemas: {
-    CreateUserRequest: {
+    APIKey: {
+      /**
+       * Format: date-time
+       * @description When the key was created
+       */
+      created_at: string;
+      /**
+       * Format: date-time
+       * @description When the key stops working; absent if it doesn't
+       */
+      expires_at?: string;
+      /** @description API key ID */
+      id: string;
+      /**
+       * Format: date-time
+       * @description When the key was last used, to the minute; absent if it never was
+       */
+      last_used_at?: string;
+      /** @description What the key is for */
+      name: string;
+      /** @description The start of the key, which stays the same when it is rotated, to tell it apart from the others */
+      prefix: string;
+      /**
+       * Format: date-time
+       * @description When the key was last rotated, if it was
+       */
+      rotated_at?: string;
+      /** @description What the key may do */
+      scopes: string[];
+    };
+    APIKeysResponse: {
       /**
        * Format: uri
        * @description A URL to the JSON Schema for this object.
        */
       $schema?: string;
-      /** @description User's email */
-      email: string;
-      /** @description User's name */
-      name: string;
+      /** @description Your API keys, oldest first */
+      api_keys: components["schemas"]["APIKey"][];
     };
-    ErrorDetail: {
-      /** @description Where the error occurred, e.g. 'body.items[3].tags' or 'path.thing-id' */
-      location?: string;
-      /** @description Error message text */
-      message?: string;
-      /** @description The value at the given location */
-      value?: unknown;
+    AcceptInvitationRequest: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description User's name; the invitation's if omitted, which requires it has one */
+      name?: string;
+      /** @description Password for post-v1-auth-login */
+      password: string;
+      /** @description International phone number; stored in E.164 form */
+      phone?: string;
+      /** @description Unique handle: 3-32 lowercase letters, digits or underscores, starting with a letter */
+      username?: string;
     };
-    ErrorModel: {
+    AuditEntry: {
+      /** @description ID of what caused it: the X-Request-ID of a request, or the ID of another event */
+      causation_id?: string;
+      /** @description Correlation ID of the request that caused it, the X-Correlation-ID it was sent with or else its request ID */
+      correlation_id?: string;
+      /** @description Event-specific details */
+      data?: unknown;
+      /** @description ID of the event recorded */
+      event_id: string;
+      /**
+       * Format: int64
+       * @description Sequence number of the entry
+       */
+      id: number;
+      /** @description ID of the user it happened to, or an erased-… placeholder once the user is erased */
+      subject: string;
+      /**
+       * Format: date-time
+       * @description When it happened
+       */
+      time: string;
+      /** @description What happened */
+      type: string;
+    };
+    BatchUpdate: {
+      /** @description The fields to change, as in the body of put-v1-users-by-id (UpdateUserRequest). They are validated per entry, so an invalid entry fails on its own */
+      changes: {
+        [key: string]: unknown;
+      };
+      /** @description ID of the user to update */
+      id: string;
+    };
+    BatchUpdateRequest: {
       /**
        * Format: uri
        * @description A URL to the JSON Schema for this object.
        */
       $schema?: string;
-      /** @description A human-readable explanation specific to this occurrence of the problem. */
-      detail?: string;
-      /** @description Optional list of individual error details */
-      errors?: components["schemas"]["ErrorDetail"][] | null;
+      /** @description Updates to apply, in order */
+      updates: components["schemas"]["BatchUpdate"][];
+    };
+    BatchUpdateResponse: {
       /**
        * Format: uri
-       * @description A URI reference that identifies the specific occurrence of the problem.
+       * @description A URL to the JSON Schema for this object.
        */
-      instance?: string;
+      $schema?: string;
       /**
        * Format: int64
-       * @description HTTP status code
+       * @description How many entries failed
        */
-      status?: number;
-      /** @description A short, human-readable summary of the problem type. This value should not change between occurrences of the error. */
-      title?: string;
+      failed: number;
+      /** @description One per entry, in request order */
+      results: components["schemas"]["BatchUpdateResult"][];
+      /**
+       * Format: int64
+       * @description How many entries succeeded
+       */
+      updated: number;
+    };
+    BatchUpdateResult: {
+      /** @description Why the entry failed, as put-v1-users-by-id would have said */
+      error?: components["schemas"]["ErrorModel"];
+      /** @description ID of the user the entry was for */
+      id: string;
+      /**
+       * Format: int64
+       * @description What updating the user on its own would have answered: 200, or the status of Error
+       */
+      status: number;
+      /** @description The updated user, if the entry succeeded */
+      user?: components["schemas"]["User"];
+    };
+    CapturedList: {
       /**
        * Format: uri
-       * @description A URI reference to human-readable documentation for the error.
-       * @default about:blank
+       * @description A URL to the JSON Schema for this object.
        */
-      type?: string;
+      $schema?: string;
+      /**
+       * Format: int64
+       * @description How many requests the buffer keeps, CAPTURE_REQUESTS
+       */
+      capacity: number;
+      /** @description The captured requests, newest first */
+      requests: components["schemas"]["CapturedRequest"][];
     };
-    UpdateUserRequest: {
+    CapturedRequest: {
+      /** @description The client's address, as resolved through TRUSTED_PROXIES */
+      client_ip: string;
+      /**
+       * Format: double
+       * @description How long the request took to serve, in milliseconds
+       */
+      duration_ms: number;
+      /**
+       * Format: int64
+       * @description Sequence number of the request since the server started
+       */
+      id: number;
+      /** @description HTTP method */
+      method: string;
+      /** @description Route and query parameters, redacted */
+      params?: string;
+      /** @description Request path, without the query */
+      path: string;
+      /** @description Request body, redacted and truncated to 4 KiB */
+      request_body?: string;
+      /** @description Request headers, with credentials redacted */
+      request_headers?: {
+        [key: string]: string;
+      };
+      /** @description Response body, redacted and truncated to 4 KiB */
+      response_body?: string;
+      /** @description Response headers, with credentials redacted */
+      response_headers?: {
+        [key: string]: string;
+      };
+      /** @description The route that matched, if any */
+      route?: string;
+      /**
+       * Format: int64
+       * @description Response status
+       */
+      status: number;
+      /**
+       * Format: date-time
+       * @description When the request arrived
+       */
+      time: string;
+    };
+    Changelog: {
       /**
        * Format: uri
        * @description A URL to the JSON Schema for this object.
        */
       $schema?: string;
-      /** @description User's email */
-      email?: string;
-      /** @description User's name */
+      /** @description Newest first; the unreleased changes come first, if there are any */
+      releases: components["schemas"]["ChangelogRelease"][];
+    };
+    ChangelogChange: {
+      /** @description What changed */
+      description: string;
+      /**
+       * @description What kind of change it is
+       * @enum {string}
+       */
+      kind: "added" | "changed" | "deprecated" | "removed" | "fixed" | "security";
+      /** @description ID of the operation it changed, if it is about one */
+      operation?: string;
+    };
+    ChangelogRelease: {
+      /** @description Its changes: the changelog's, plus the operations added and removed that it doesn't mention */
+      changes: components["schemas"]["ChangelogChange"][];
+      /**
+       * Format: date
+       * @description When it was released; omitted for the unreleased changes
+       */
+      date?: string;
+      /** @description The release, or unreleased for the changes since the last one */
+      version: string;
+    };
+    ClearCapturedOutputBody: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /**
+       * Format: int64
+       * @description How many captured requests were dropped
+       */
+      cleared: number;
+    };
+    Comment: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description ID of the user who wrote the comment */
+      author_id: string;
+      /** @description Text of the comment */
+      body: string;
+      /**
+       * Format: date-time
+       * @description When the comment was written
+       */
+      created_at: string;
+      /** @description Comment ID */
+      id: string;
+      /** @description ID of the comment this one replies to; absent for a top-level comment */
+      parent_id?: string;
+      /** @description ID of the post the comment is on */
+      post_id: string;
+      /**
+       * Format: int64
+       * @description Number of direct replies, as the listing would show them
+       */
+      reply_count?: number;
+      /**
+       * @description Moderation status; hidden comments are left out of listings unless asked for
+       * @enum {string}
+       */
+      status: "published" | "hidden";
+      /**
+       * Format: date-time
+       * @description When the comment was last changed
+       */
+      updated_at: string;
+    };
+    CommentStatusRequest: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /**
+       * @description Status to move the comment to
+       * @enum {string}
+       */
+      status: "published" | "hidden";
+    };
+    CommentsListResponse: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description This page of comments, oldest first */
+      comments: components["schemas"]["Comment"][];
+      /** @description Whether there are pages after this one */
+      has_more: boolean;
+      /** @description The next page; left out on the last one */
+      next?: components["schemas"]["PageLink"];
+    };
+    ComponentStatus: {
+      /** @description Why it failed, or isn't ready */
+      error?: string;
+      /** @description The component */
+      name: string;
+      /** @description Whether it is running and its readiness gate, if it has one, passes */
+      ready: boolean;
+      /**
+       * @description Where it is in its lifecycle
+       * @enum {string}
+       */
+      status: "starting" | "running" | "stopping" | "stopped" | "failed";
+    };
+    CreateAPIKeyRequest: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /**
+       * Format: date-time
+       * @description When the key stops working; never if omitted
+       */
+      expires_at?: string;
+      /** @description What the key is for */
+      name: string;
+      /** @description What the key may do */
+      scopes: ("users:read" | "notifications:read" | "notifications:write")[];
+    };
+    CreateCommentRequest: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description ID of the user writing the comment */
+      author_id: string;
+      /** @description Text of the comment */
+      body: string;
+      /** @description ID of the comment on the same post to reply to */
+      parent_id?: string;
+    };
+    CreateExportRequest: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Which users to export; every active user if omitted */
+      filters?: components["schemas"]["ExportFilters"];
+      /**
+       * @description File format: ndjson, a user per line as get-v1-users-by-id returns them, or csv; ndjson if omitted
+       * @enum {string}
+       */
+      format?: "ndjson" | "csv";
+    };
+    CreateInvitationRequest: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /**
+       * Format: email
+       * @description Who to invite
+       */
+      email: string;
+      /**
+       * Format: date-time
+       * @description When the invitation stops working; in 7 days if omitted
+       */
+      expires_at?: string;
+      /** @description BCP 47 language tag of the invitation email; en if omitted */
+      locale?: string;
+      /** @description What to call them */
       name?: string;
     };
-    User: {
-      /** @description User's email */
+    CreatePostRequest: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description ID of the user writing the post */
+      author_id: string;
+      /** @description Text of the post */
+      body: string;
+      /** @description Title */
+      title: string;
+    };
+    CreateUserRequest: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /**
+       * Format: email
+       * @description User's email
+       */
       email: string;
-      /** @description User ID */
-      id: string;
+      /** @description Free-form attributes for integrations */
+      metadata?: {
+        [key: string]: unknown;
+      };
       /** @description User's name */
       name: string;
-      /** Format: int64 */
-      status?: number;
+      /** @description Password for post-v1-auth-login; without one the user can't log in */
+      password?: string;
+      /** @description International phone number; stored in E.164 form */
+      phone?: string;
+      /** @description Unique handle: 3-32 lowercase letters, digits or underscores, starting with a letter */
+      username?: string;
+    };
+    CurrentUser: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description The API key used, if it was one rather than a user token */
+      api_key_id?: string;
+      /** @description Staff member acting as the user, if this is an impersonation token */
+      impersonated_by?: string;
+      /** @description The identity provider that issued the token, if it isn't the API's own */
+      issuer?: string;
+      /** @description What the API key may do; absent for a user token, which may do anything */
+      scopes?: string[];
+      /**
+       * Format: date-time
+       * @description When the token stops working; absent for an API key that doesn't expire
+       */
+      token_expires_at?: string;
+      /** @description The user the token acts as */
+      user: components["schemas"]["User"];
+    };
+    DeadLetter: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Every failed attempt, oldest first, including those after requeueing */
+      attempts: components["schemas"]["DeadLetterAttempt"][];
+      /**
+       * Format: date-time
+       * @description When the delivery first ran out of attempts
+       */
+      created_at: string;
+      /** @description Dead letter ID */
+      id: string;
+      /**
+       * @description What failed to be delivered
+       * @enum {string}
+       */
+      kind: "security_event" | "cache_purge" | "email";
+      /** @description What was to be delivered: the security event, the surrogate keys to purge, or the email */
+      payload: unknown;
+      /**
+       * @description dead until requeued; requeued ones leave the queue once delivered, or are dead again
+       * @enum {string}
+       */
+      status: "dead" | "requeued";
+      /**
+       * Format: date-time
+       * @description When the status last changed
+       */
+      updated_at: string;
+    };
+    DeadLetterAttempt: {
+      /** @description Why it failed */
+      error: string;
+      /**
+       * Format: date-time
+       * @description When the attempt failed
+       */
+      time: string;
+    };
+    DeadLetterList: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description The dead letters, newest first */
+      dead_letters: components["schemas"]["DeadLetter"][];
+    };
+    DeleteUserResponse: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Whether there was a user to delete */
+      deleted: boolean;
+    };
+    DigestReport: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Whether the run only counted who would get one */
+      dry_run: boolean;
+      /**
+       * Format: int64
+       * @description Digests that couldn't be sent, or queued
+       */
+      failed: number;
+      /**
+       * Format: int64
+       * @description Users created since then
+       */
+      new_users: number;
+      /**
+       * @description Which users' digests were sent
+       * @enum {string}
+       */
+      period: "daily" | "weekly";
+      /**
+       * Format: int64
+       * @description Of those sent, the ones queued to be retried, as the mail server refused them or is refusing others
+       */
+      queued: number;
+      /**
+       * Format: int64
+       * @description Digests sent, or that would be on a dry run
+       */
+      sent: number;
+      /**
+       * Format: date-time
+       * @description Start of the activity summarized: a day or a week ago
+       */
+      since: string;
+      /**
+       * Format: int64
+       * @description Other users changed since then
+       */
+      updated_users: number;
+    };
+    /**
+     * @description Machine-readable error code. Branch on it rather than on the messages:
+     *
+     * - `BAD_REQUEST`: The request could not be read, e.g. its body is not valid JSON.
+     * - `VALIDATION_FAILED`: Parameters or body failed validation; `errors` lists every problem.
+     * - `UNAUTHORIZED`: The operation needs credentials that were missing or wrong.
+     * - `INVALID_TOKEN`: The user token is forged, malformed or expired.
+     * - `INVALID_CREDENTIALS`: The login or password is wrong, or the user can't log in.
+     * - `ACCOUNT_LOCKED`: Too many failed logins locked the account; retry after Retry-After or ask an admin to unlock it.
+     * - `LOGIN_THROTTLED`: Too many failed logins from the account or address; retry after Retry-After.
+     * - `REQUEST_CANCELED`: An operator canceled the request through the admin API before it was answered.
+     * - `CAPTCHA_FAILED`: The X-Captcha-Token header is missing, or the CAPTCHA provider rejected it. Clients the traffic analyzer challenges get it on every request until they send a solved CAPTCHA.
+     * - `CAPTCHA_UNAVAILABLE`: The CAPTCHA provider couldn't be reached to check the token.
+     * - `NOT_FOUND`: No route or resource matches the request.
+     * - `USER_NOT_FOUND`: The user does not exist.
+     * - `POST_NOT_FOUND`: The post does not exist.
+     * - `COMMENT_NOT_FOUND`: The comment does not exist, or is on another post.
+     * - `NOTIFICATION_NOT_FOUND`: The notification does not exist, or is another user's.
+     * - `METHOD_NOT_ALLOWED`: The route does not support the method.
+     * - `NOT_ACCEPTABLE`: No response format matches the Accept header.
+     * - `CONFLICT`: The request conflicts with the current state.
+     * - `USERNAME_TAKEN`: Another user has the username.
+     * - `PHONE_TAKEN`: Another user has the phone number, and USER_PHONE_UNIQUE is on.
+     * - `EMAIL_TAKEN`: Another user has the email, once folded as EMAIL_FOLDING says.
+     * - `INVALID_STATUS_TRANSITION`: The user's status can't move to the requested one.
+     * - `ENCRYPTION_NOT_CONFIGURED`: The operation needs field encryption, which is off.
+     * - `INVALID_BACKUP`: The uploaded archive is not a backup this server can restore.
+     * - `INVALID_LOG_LEVEL`: The log level is not one the server knows.
+     * - `PRECONDITION_FAILED`: An If-Match or If-Unmodified-Since precondition failed.
+     * - `REQUEST_TOO_LARGE`: The request body is over the limit.
+     * - `UNSUPPORTED_MEDIA_TYPE`: The body's Content-Type is not supported.
+     * - `INTERNAL_ERROR`: Something went wrong on the server.
+     * - `NO_LEADER`: The clustered store has no leader to take the write; retry after Retry-After.
+     * - `MAINTENANCE`: The API is down or read-only for maintenance; retry after Retry-After.
+     * - `OVERLOADED`: The server is serving as many requests as it can and shed this one; retry after Retry-After.
+     * - `IP_NOT_ALLOWED`: The client's address is not allowed to call the endpoint, per the IP allow and deny lists.
+     * - `CLIENT_BANNED`: The traffic analyzer banned the client's address for abusive traffic; retry after Retry-After.
+     * - `CHANGES_EXPIRED`: The change feed no longer has every change after since; list the users again and resume with since=-1.
+     * - `SEARCH_UNAVAILABLE`: The search index couldn't be queried; retry later.
+     * - `LEADER_UNKNOWN`: The locks that elect the leader couldn't be reached; retry later.
+     * - `INJECTED_FAULT`: A fault rule of PUT /admin/faults failed the request on purpose, to test the client.
+     * - `QUOTA_EXCEEDED`: The API key used up its daily or monthly quota; retry after Retry-After.
+     * - `INSUFFICIENT_SCOPE`: The API key doesn't have the scope the operation needs, or the operation needs a user token.
+     * - `API_KEY_NOT_FOUND`: The API key does not exist, or is another user's.
+     * - `ISSUER_UNAVAILABLE`: The keys of the identity provider that issued the token couldn't be fetched to check it.
+     * - `INVITATION_NOT_FOUND`: No invitation has the ID, or no pending one the token.
+     * - `INVITATION_EXPIRED`: The invitation is past its expiry; ask for a new one.
+     * - `EXPORT_NOT_FOUND`: The export does not exist, or was deleted after a day.
+     * - `INVALID_SIGNATURE`: The signed link was tampered with or has expired; get a new one.
+     * - `REPLAY_NOT_CONFIGURED`: There is nowhere to replay events to: neither the security event stream nor cache purging is configured.
+     * - `DEAD_LETTER_NOT_FOUND`: No dead letter has the ID on this replica; it was delivered, discarded or lost on restart.
+     * - `CONCURRENCY_LIMITED`: The route group of the endpoint is serving as many requests at once as CONCURRENCY_LIMITS lets it; retry after Retry-After.
+     * - `REQUEST_CANCELED`: An operator canceled the request through the admin API before it was answered.
+     * @enum {string}
+     */
+    ErrorCode: "BAD_REQUEST" | "VALIDATION_FAILED" | "UNAUTHORIZED" | "INVALID_TOKEN" | "INVALID_CREDENTIALS" | "ACCOUNT_LOCKED" | "LOGIN_THROTTLED" | "REQUEST_CANCELED" | "CAPTCHA_FAILED" | "CAPTCHA_UNAVAILABLE" | "NOT_FOUND" | "USER_NOT_FOUND" | "POST_NOT_FOUND" | "COMMENT_NOT_FOUND" | "NOTIFICATION_NOT_FOUND" | "METHOD_NOT_ALLOWED" | "NOT_ACCEPTABLE" | "CONFLICT" | "USERNAME_TAKEN" | "PHONE_TAKEN" | "EMAIL_TAKEN" | "INVALID_STATUS_TRANSITION" | "ENCRYPTION_NOT_CONFIGURED" | "INVALID_BACKUP" | "INVALID_LOG_LEVEL" | "PRECONDITION_FAILED" | "REQUEST_TOO_LARGE" | "UNSUPPORTED_MEDIA_TYPE" | "INTERNAL_ERROR" | "NO_LEADER" | "MAINTENANCE" | "OVERLOADED" | "IP_NOT_ALLOWED" | "CLIENT_BANNED" | "CHANGES_EXPIRED" | "SEARCH_UNAVAILABLE" | "LEADER_UNKNOWN" | "INJECTED_FAULT" | "QUOTA_EXCEEDED" | "INSUFFICIENT_SCOPE" | "API_KEY_NOT_FOUND" | "ISSUER_UNAVAILABLE" | "INVITATION_NOT_FOUND" | "INVITATION_EXPIRED" | "EXPORT_NOT_FOUND" | "INVALID_SIGNATURE" | "REPLAY_NOT_CONFIGURED" | "DEAD_LETTER_NOT_FOUND" | "CONCURRENCY_LIMITED";
+    ErrorDetail: {
+      /**
+       * @description What is wrong with the field
+       * @enum {string}
+       */
+      code: "required" | "unexpected_property" | "type" | "format" | "enum" | "pattern" | "minimum" | "maximum" | "multiple_of" | "min_length" | "max_length" | "min_items" | "max_items" | "unique_items" | "min_properties" | "max_properties" | "schema" | "max_size" | "max_depth" | "reserved" | "disposable" | "malformed" | "unsupported_media_type" | "invalid";
+      /** @description Path of the invalid field within its location, empty for the body as a whole */
+      field: string;
+      /** @description Where the error occurred, e.g. 'body.items[3].tags' or 'path.thing-id' */
+      location: string;
+      /** @description Error message text */
+      message: string;
+      /** @description The value at the given location */
+      value?: unknown;
+    };
+    ErrorModel: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      code: components["schemas"]["ErrorCode"];
+      /** @description A human-readable explanation specific to this occurrence of the problem. */
+      detail?: string;
+      /** @description Every problem found with the request, at most one per field */
+      errors?: components["schemas"]["ErrorDetail"][];
+      /**
+       * Format: uri
+       * @description A URI reference that identifies the specific occurrence of the problem.
+       */
+      instance?: string;
+      /**
+       * Format: int64
+       * @description HTTP status code
+       */
+      status?: number;
+      /** @description A short, human-readable summary of the problem type. This value should not change between occurrences of the error. */
+      title?: string;
+      /**
+       * Format: uri
+       * @description A URI reference to human-readable documentation for the error.
+       * @default about:blank
+       */
+      type?: string;
+    };
+    EventReplay: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /**
+       * Format: int64
+       * @description Purges queued for the CDN
+       */
+      cache_purges: number;
+      /**
+       * Format: int64
+       * @description Events in the range replayed
+       */
+      events: number;
+      /**
+       * Format: int64
+       * @description If the range holds more than 1000 events, the from_seq to replay the rest from
+       */
+      next_seq?: number;
+      /**
+       * Format: int64
+       * @description Events queued on the security event stream
+       */
+      security_events: number;
+    };
+    EventsPage: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description The events from from_seq on, oldest first */
+      events: components["schemas"]["AuditEntry"][];
+      /**
+       * Format: int64
+       * @description What to pass as from_seq to get the events after these
+       */
+      next_seq: number;
+      /**
+       * Format: int64
+       * @description Sequence number of the oldest event still kept. If it is past from_seq, the events before it were pruned by the retention policy, or lost on a restart without AUDIT_LOG_PATH
+       */
+      oldest_seq: number;
+    };
+    Export: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /**
+       * Format: date-time
+       * @description When the export completed or failed
+       */
+      completed_at?: string;
+      /**
+       * Format: date-time
+       * @description When the export was requested
+       */
+      created_at: string;
+      /**
+       * Format: date-time
+       * @description When download_url stops working; get the export again for a new one
+       */
+      download_expires_at?: string;
+      /** @description Signed link to the file, relative to the API; it needs no credentials, so treat it as a secret. Only once completed */
+      download_url?: string;
+      /** @description Why the export failed */
+      error?: string;
+      /**
+       * Format: date-time
+       * @description When the finished export is deleted
+       */
+      expires_at?: string;
+      /** @description Which users the export holds */
+      filters: components["schemas"]["ExportFilters"];
+      /**
+       * @description File format
+       * @enum {string}
+       */
+      format: "ndjson" | "csv";
+      /** @description Export ID */
+      id: string;
+      /**
+       * Format: int64
+       * @description Number of users exported, once completed
+       */
+      rows: number;
+      /**
+       * Format: int64
+       * @description Size of the file in bytes, once completed
+       */
+      size: number;
+      /**
+       * @description pending until it starts, then running until it completed or failed
+       * @enum {string}
+       */
+      status: "pending" | "running" | "completed" | "failed";
+      /** @description IANA time zone the timestamps of a CSV export are in: that of the tz query parameter, the user's preferences or the Time-Zone header when it was requested, else UTC */
+      timezone: string;
+    };
+    ExportFilters: {
+      /** @description Only export users not seen since this long ago (e.g. 30d, 2w, 12h) or since an RFC 3339 timestamp */
+      inactive_since?: string;
+      /** @description Also export users that are not active */
+      include_inactive?: boolean;
+      /** @description Only export users whose metadata has these values */
+      metadata?: {
+        [key: string]: string;
+      };
+      /** @description Only export users carrying every one of these tags */
+      tag?: string[];
+    };
+    FaultRule: {
+      /** @description Close the connection without answering */
+      drop?: boolean;
+      /**
+       * Format: int64
+       * @description Delay before the request is handled, failed or dropped
+       */
+      latency_ms?: number;
+      /**
+       * @description Only requests with this method; any if omitted
+       * @enum {string}
+       */
+      method?: "GET" | "HEAD" | "POST" | "PUT" | "PATCH" | "DELETE";
+      /**
+       * Format: double
+       * @description Share of the matching requests the fault hits
+       */
+      rate: number;
+      /** @description Route the rule applies to, with {param} for any one segment and a trailing /* for any rest, or * for every route; the admin API and probes are never hit */
+      route: string;
+      /**
+       * Format: int64
+       * @description Answer with this status, and code INJECTED_FAULT, instead of handling the request
+       */
+      status?: number;
+    };
+    FaultRules: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description The rules, tried in order; the first matching a request applies */
+      rules: components["schemas"]["FaultRule"][];
+    };
+    HealthHistory: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /**
+       * Format: int64
+       * @description Seconds between probes
+       */
+      interval_seconds: number;
+      /**
+       * Format: int64
+       * @description How many probes are kept: the latest, up to 8640
+       */
+      probes: number;
+      /**
+       * Format: date-time
+       * @description When the oldest probe kept ran
+       */
+      since?: string;
+      /**
+       * @description What the latest probe found; left out before the first
+       * @enum {string}
+       */
+      status?: "up" | "down";
+      /** @description The latest changes in status, newest first, up to 100; the first probe is one */
+      transitions: components["schemas"]["HealthTransition"][];
+      /**
+       * Format: double
+       * @description The share of the probes kept that found the server up, in percent; left out before the first
+       */
+      uptime_percent?: number;
+    };
+    HealthResponse: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /**
+       * Format: int64
+       * @description 200, or 503 while the server drains before shutting down
+       */
+      status: number;
+    };
+    HealthTransition: {
+      /** @description The components that weren't ready, if any */
+      components?: string[];
+      /**
+       * @description Why it was down, as /readyz gives it
+       * @enum {string}
+       */
+      reason?: "draining" | "not_ready";
+      /**
+       * @description What the server was from then on
+       * @enum {string}
+       */
+      status: "up" | "down";
+      /**
+       * Format: date-time
+       * @description When the probe that saw the change ran
+       */
+      time: string;
+    };
+    HelloResponse: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description A welcome message from the API */
+      message: string;
+    };
+    ImpersonateRequest: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Who will act as the user, recorded in the token and the audit log */
+      actor: string;
+      /** @description Why, for the audit log */
+      reason: string;
+      /**
+       * Format: int64
+       * @description How long the token works
+       * @default 15
+       */
+      ttl_minutes?: number;
+    };
+    ImpersonationToken: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /**
+       * Format: date-time
+       * @description When the token stops working
+       */
+      expires_at: string;
+      /** @description The actor, as recorded in the token's impersonated_by claim */
+      impersonated_by: string;
+      /** @description Bearer token acting as the user */
+      token: string;
+      /** @description Always Bearer */
+      token_type: string;
+      /** @description The user the token acts as */
+      user_id: string;
+    };
+    InFlightList: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description The requests, oldest first */
+      requests: components["schemas"]["InFlightRequest"][];
+    };
+    InFlightRequest: {
+      /**
+       * Format: double
+       * @description How long the request has been running, in milliseconds
+       */
+      age_ms: number;
+      /** @description true if it was canceled and its handler hasn't returned yet */
+      canceled?: boolean;
+      /** @description The client's address, as resolved through TRUSTED_PROXIES */
+      client_ip: string;
+      /** @description The request's X-Request-ID */
+      id: string;
+      /** @description HTTP method */
+      method: string;
+      /** @description Request path, without the query */
+      path: string;
+      /** @description The operation's route, once one matched */
+      route?: string;
+      /**
+       * Format: date-time
+       * @description When the request arrived
+       */
+      started: string;
+    };
+    Invitation: {
+      /**
+       * Format: date-time
+       * @description When the invitation was accepted
+       */
+      accepted_at?: string;
+      /**
+       * Format: date-time
+       * @description When the invitation was created
+       */
+      created_at: string;
+      /** @description Who is invited; the user's email once they accept */
+      email: string;
+      /**
+       * Format: date-time
+       * @description When the invitation stops working if not accepted
+       */
+      expires_at: string;
+      /** @description Invitation ID */
+      id: string;
+      /** @description Language of the invitation email */
+      locale: string;
+      /** @description The name they are greeted with and the user gets, unless they give another on accepting */
+      name?: string;
+      /**
+       * Format: date-time
+       * @description When the invitation was revoked
+       */
+      revoked_at?: string;
+      /**
+       * @description pending until accepted, revoked or past expires_at
+       * @enum {string}
+       */
+      status: "pending" | "accepted" | "revoked" | "expired";
+      /** @description The user the invitation became */
+      user_id?: string;
+    };
+    InvitationsResponse: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description The invitations, newest first */
+      invitations: components["schemas"]["Invitation"][];
+    };
+    LeaderStatus: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /**
+       * @description How the leader is elected: it is the raft leader, holds the leader lock in Redis, or, with neither, every replica leads itself
+       * @enum {string}
+       */
+      election: "raft" | "lock" | "local";
+      /** @description Whether the replica that answered leads */
+      is_leader: boolean;
+      /** @description ID of the replica that leads; omitted while none does, as after the leader died and before another takes over */
+      leader?: string;
+      /** @description ID of the replica that answered */
+      replica: string;
+    };
+    LogLevelRequest: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /**
+       * @description Minimum level to log
+       * @enum {string}
+       */
+      level: "debug" | "info" | "warn" | "error";
+      /**
+       * Format: int64
+       * @description Go back to the configured level after this many minutes
+       */
+      revert_after_minutes?: number;
+    };
+    LogLevelResponse: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Level now in effect */
+      level: string;
+      /** @description Level before this change */
+      previous: string;
+      /**
+       * Format: date-time
+       * @description When the configured level comes back, if a revert was requested
+       */
+      revert_at?: string;
+    };
+    LoginRequest: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Username or email */
+      login: string;
+      /** @description The user's password */
+      password: string;
+    };
+    LoginToken: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /**
+       * Format: date-time
+       * @description When the token stops working
+       */
+      expires_at: string;
+      /** @description Bearer token acting as the user */
+      token: string;
+      /** @description Always Bearer */
+      token_type: string;
+      /** @description The user the token acts as */
+      user_id: string;
+    };
+    LookupUsersRequest: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description User IDs to fetch, in the order results should be returned */
+      ids: string[];
+    };
+    LookupUsersResponse: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description One entry per requested ID, in request order */
+      results: components["schemas"]["UserLookupResult"][];
+    };
+    Maintenance: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Shown to clients in the 503's detail */
+      message?: string;
+      /**
+       * @description What is refused: nothing, writes, or everything but health, metrics, docs and the admin API
+       * @enum {string}
+       */
+      mode: "off" | "read_only" | "on";
+      /**
+       * Format: int64
+       * @description What the 503's Retry-After tells clients to wait
+       */
+      retry_after_seconds?: number;
+      /**
+       * Format: date-time
+       * @description When maintenance started
+       */
+      since?: string;
+    };
+    MaintenanceRequest: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Shown to clients in the 503's detail; translated only if it is the default */
+      message?: string;
+      /**
+       * @description What to refuse: nothing, writes, or everything but health, metrics, docs and the admin API
+       * @enum {string}
+       */
+      mode: "off" | "read_only" | "on";
+      /**
+       * Format: int64
+       * @description What the 503's Retry-After tells clients to wait; MAINTENANCE_RETRY_AFTER if omitted
+       */
+      retry_after_seconds?: number;
+    };
+    NewAPIKey: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /**
+       * Format: date-time
+       * @description When the key was created
+       */
+      created_at: string;
+      /**
+       * Format: date-time
+       * @description When the key stops working; absent if it doesn't
+       */
+      expires_at?: string;
+      /** @description API key ID */
+      id: string;
+      /** @description The key, to send as a bearer token; it can't be shown again */
+      key: string;
+      /**
+       * Format: date-time
+       * @description When the key was last used, to the minute; absent if it never was
+       */
+      last_used_at?: string;
+      /** @description What the key is for */
+      name: string;
+      /** @description The start of the key, which stays the same when it is rotated, to tell it apart from the others */
+      prefix: string;
+      /**
+       * Format: date-time
+       * @description When the key was last rotated, if it was
+       */
+      rotated_at?: string;
+      /** @description What the key may do */
+      scopes: string[];
+    };
+    NewInvitation: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /**
+       * Format: date-time
+       * @description When the invitation was accepted
+       */
+      accepted_at?: string;
+      /**
+       * Format: date-time
+       * @description When the invitation was created
+       */
+      created_at: string;
+      /** @description Who is invited; the user's email once they accept */
+      email: string;
+      /** @description Whether the invitation was emailed, which needs APP_URL for the link, or queued to be if the mail server is refusing emails */
+      emailed: boolean;
+      /**
+       * Format: date-time
+       * @description When the invitation stops working if not accepted
+       */
+      expires_at: string;
+      /** @description Invitation ID */
+      id: string;
+      /** @description Language of the invitation email */
+      locale: string;
+      /** @description The name they are greeted with and the user gets, unless they give another on accepting */
+      name?: string;
+      /**
+       * Format: date-time
+       * @description When the invitation was revoked
+       */
+      revoked_at?: string;
+      /**
+       * @description pending until accepted, revoked or past expires_at
+       * @enum {string}
+       */
+      status: "pending" | "accepted" | "revoked" | "expired";
+      /** @description The token post-v1-invitations-by-token-accept takes; it can't be shown again */
+      token: string;
+      /** @description The user the invitation became */
+      user_id?: string;
+    };
+    Notification: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description ID of the user whose action caused the notification */
+      actor_id?: string;
+      /** @description ID of the comment it is about */
+      comment_id?: string;
+      /**
+       * Format: date-time
+       * @description When it happened
+       */
+      created_at: string;
+      /** @description Notification ID */
+      id: string;
+      /** @description ID of the post it is about */
+      post_id?: string;
+      /**
+       * Format: date-time
+       * @description When the user marked it read; absent while unread
+       */
+      read_at?: string;
+      /**
+       * @description welcome when the account was created; comment on their post; reply to their comment; mention of their @username in a post or comment
+       * @enum {string}
+       */
+      type: "welcome" | "comment" | "reply" | "mention";
+      /** @description ID of the user notified */
+      user_id: string;
+    };
+    NotificationPreferences: {
+      /**
+       * @description How often to send the activity digest email
+       * @default weekly
+       * @enum {string}
+       */
+      digest?: "off" | "daily" | "weekly";
+      /**
+       * @description Send notifications by email
+       * @default true
+       */
+      email?: boolean | null;
+      /**
+       * @description Show notifications in the app
+       * @default true
+       */
+      in_app?: boolean | null;
+    };
+    NotificationsListResponse: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Whether there are pages after this one */
+      has_more: boolean;
+      /** @description The next page; left out on the last one */
+      next?: components["schemas"]["PageLink"];
+      /** @description This page of notifications, newest first */
+      notifications: components["schemas"]["Notification"][];
+      /**
+       * Format: int64
+       * @description Number of unread notifications, across all pages
+       */
+      unread_count: number;
+    };
+    PageLink: {
+      /** @description ID of the last entry of the page before, to pass as after to start this one; only on lists that take it */
+      after?: string;
+      /** @description URL of the page, keeping the filters of the request; it starts after the last entry of this one if the request passed after */
+      href: string;
+      /**
+       * Format: int64
+       * @description Page number; after a request that passed after, where the page falls as of now
+       */
+      page: number;
+      /**
+       * Format: int64
+       * @description Entries per page
+       */
+      per_page: number;
+    };
+    Post: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description ID of the user who wrote the post */
+      author_id: string;
+      /** @description Text of the post */
+      body: string;
+      /** @description The published comments on the post, replies included, oldest first; only with include=posts.comments on a user, and omitted if there are none */
+      comments?: readonly components["schemas"]["Comment"][];
+      /**
+       * Format: date-time
+       * @description When the post was created
+       */
+      created_at: string;
+      /** @description Post ID */
+      id: string;
+      /** @description Title */
+      title: string;
+      /**
+       * Format: date-time
+       * @description When the post was last changed
+       */
+      updated_at: string;
+    };
+    PostRequest: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Text of the post */
+      body: string;
+      /** @description Title */
+      title: string;
+    };
+    PostsListResponse: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Whether there are pages after this one */
+      has_more: boolean;
+      /** @description The next page; left out on the last one */
+      next?: components["schemas"]["PageLink"];
+      /** @description This page of posts */
+      posts: components["schemas"]["Post"][];
+    };
+    QuotaLimits: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /**
+       * Format: int64
+       * @description Requests allowed per UTC day; 0 for no cap
+       */
+      daily: number;
+      /**
+       * Format: int64
+       * @description Requests allowed per UTC calendar month; 0 for no cap
+       */
+      monthly: number;
+    };
+    QuotaUsage: {
+      /**
+       * Format: int64
+       * @description Requests made today
+       */
+      day: number;
+      /**
+       * Format: date-time
+       * @description When the daily count starts over
+       */
+      day_resets_at: string;
+      /**
+       * Format: int64
+       * @description Requests made this month
+       */
+      month: number;
+      /**
+       * Format: date-time
+       * @description When the monthly count starts over
+       */
+      month_resets_at: string;
+    };
+    RateLimit: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /**
+       * Format: int64
+       * @description Requests allowed per UTC day; 0 for no cap
+       */
+      daily: number;
+      /** @description ID of the API key */
+      key_id: string;
+      /**
+       * Format: int64
+       * @description Requests allowed per UTC calendar month; 0 for no cap
+       */
+      monthly: number;
+      /** @description Requests counted against the quota so far */
+      usage: components["schemas"]["QuotaUsage"];
+    };
+    RateLimits: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Every API key with a quota or requests counted, by ID */
+      rate_limits: components["schemas"]["RateLimit"][];
+    };
+    ReadinessResponse: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description The components of the server, in the order they start; empty when the API is embedded and not run on its own */
+      components?: components["schemas"]["ComponentStatus"][];
+      /**
+       * @description Why the server doesn't take traffic: it got SIGTERM and drains before shutting down, or a component isn't ready
+       * @enum {string}
+       */
+      reason?: "draining" | "not_ready";
+      /**
+       * Format: int64
+       * @description 200 while the server takes traffic, or 503
+       */
+      status: number;
+    };
+    ReencryptResponse: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /**
+       * Format: int64
+       * @description Users looked at
+       */
+      checked: number;
+      /** @description ID of the primary key */
+      key_id: string;
+      /**
+       * Format: int64
+       * @description Users whose fields were rewritten under the primary key
+       */
+      reencrypted: number;
+    };
+    ReplayEventsRequest: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /**
+       * Format: int64
+       * @description Sequence number of the first event to replay
+       */
+      from_seq: number;
+      /** @description Where to deliver the events again: the security event stream, the CDN purges or both. Every one that is configured if omitted */
+      targets?: ("security_events" | "cache_purge")[];
+      /**
+       * Format: int64
+       * @description Sequence number of the last event to replay; the latest if omitted
+       */
+      to_seq?: number;
+    };
+    RestoreResponse: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /**
+       * Format: int64
+       * @description Number of users restored
+       */
+      users: number;
+    };
+    RetentionReport: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Whether the run only reported what it would purge */
+      dry_run: boolean;
+      /** @description One entry per configured rule; rules without a retention period are skipped */
+      rules: components["schemas"]["RetentionRuleReport"][];
+    };
+    RetentionRuleReport: {
+      /**
+       * Format: date-time
+       * @description Records from before this were purged
+       */
+      cutoff: string;
+      /** @description IDs of the purged users, for the deleted_users rule */
+      ids?: string[];
+      /** @description How long records are kept */
+      max_age: string;
+      /**
+       * Format: int64
+       * @description Records purged, or that would be on a dry run
+       */
+      purged: number;
+      /**
+       * @description What the rule purges
+       * @enum {string}
+       */
+      rule: "deleted_users" | "audit";
+    };
+    SearchResponse: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description true if the time budget ran out before the search finished; the results are those found by then, which may be none */
+      partial?: boolean;
+      /** @description Matching resources, best match first */
+      results: components["schemas"]["SearchResult"][];
+    };
+    SearchResult: {
+      /** @description Fragments of the fields that matched, by field, HTML-escaped with the matched words in <mark> tags */
+      highlights: {
+        [key: string]: string[];
+      };
+      /** @description ID of the user or post */
+      id: string;
+      /** @description The post, for type post */
+      post?: components["schemas"]["Post"];
+      /**
+       * Format: double
+       * @description How well it matches, higher being better; only comparable within one response
+       */
+      score: number;
+      /**
+       * @description Kind of resource
+       * @enum {string}
+       */
+      type: "user" | "post";
+      /** @description The user, for type user */
+      user?: components["schemas"]["UserSuggestion"];
+    };
+    SearchUsersResponse: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description true if the time budget ran out before every user was looked at; the results are the best of those that were */
+      partial?: boolean;
+      /** @description Matching users, best match first */
+      results: components["schemas"]["UserSuggestion"][];
+    };
+    Stats: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Number of users with each status; statuses no user has are left out */
+      by_status: {
+        [key: string]: number;
+      };
+      /** @description Number of users carrying each tag */
+      by_tag: {
+        [key: string]: number;
+      };
+      /**
+       * Format: int64
+       * @description Number of comments, replies included
+       */
+      comments: number;
+      /**
+       * Format: date-time
+       * @description When the counts were taken; up to STATS_CACHE_TTL ago
+       */
+      computed_at: string;
+      /**
+       * Format: int64
+       * @description Number of users created since midnight, in the server's time zone; always 0 with ID_GENERATOR=uuidv4, as those IDs don't tell
+       */
+      created_today: number;
+      /**
+       * Format: int64
+       * @description Number of posts
+       */
+      posts: number;
+      /**
+       * Format: int64
+       * @description Number of users, whatever their status
+       */
+      users: number;
+    };
+    TelemetryReport: {
+      /** @description Go version the server was built with */
+      go_version: string;
+      /** @description Random ID of this process, new on every start, so reports can be told apart without saying whose they are */
+      instance_id: string;
+      /** @description Operating system and architecture */
+      platform: string;
+      /** @description Requests served in the period, by operation ID */
+      requests: {
+        [key: string]: number;
+      };
+      /**
+       * Format: date-time
+       * @description Start of the period the counts cover: the last report sent, or the server's start
+       */
+      since: string;
+      /**
+       * @description The store backend: memory, raft for the clustered mode, or custom for one an embedding program provides
+       * @enum {string}
+       */
+      store: "memory" | "raft" | "custom";
+      /**
+       * Format: int64
+       * @description How long the server has been running
+       */
+      uptime_seconds: number;
+      /** @description The server's version */
+      version: string;
+    };
+    TelemetryStatus: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Whether reports are sent; only with TELEMETRY=true */
+      enabled: boolean;
+      /**
+       * Format: int64
+       * @description How often reports are sent
+       */
+      interval_seconds: number;
+      /** @description Why the last report failed, if it did */
+      last_error?: string;
+      /**
+       * Format: date-time
+       * @description When the last report was accepted
+       */
+      last_sent?: string;
+      /** @description Exactly what the next report would hold, were it sent now */
+      report: components["schemas"]["TelemetryReport"];
+      /** @description Where reports are sent */
+      url?: string;
+    };
+    TokenIntrospection: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Whether the token works: it is well-formed, correctly signed, not expired and not revoked */
+      active: boolean;
+      /** @description The API key's ID */
+      api_key_id?: string;
+      /**
+       * Format: int64
+       * @description When the token stops working, in seconds since the Unix epoch; absent for an API key that doesn't expire
+       */
+      exp?: number;
+      /** @description The identity provider that issued the token, if it isn't the API's own */
+      iss?: string;
+      /** @description The user token's ID, as in audit entries */
+      jti?: string;
+      /** @description What the API key may do, space-separated; absent for a user token, which may do anything */
+      scope?: string;
+      /** @description ID of the user the token acts as */
+      sub?: string;
+      /**
+       * @description Bearer for a user token, api_key for an API key
+       * @enum {string}
+       */
+      token_type?: "Bearer" | "api_key";
+    };
+    TokenRequest: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description A user token or API key */
+      token: string;
+      /**
+       * @description What the token is, if known; the token is recognized either way
+       * @enum {string}
+       */
+      token_type_hint?: "access_token" | "api_key";
+    };
+    TrafficBlock: {
+      /**
+       * @description ban refuses its requests; captcha refuses them until one comes with a solved CAPTCHA
+       * @enum {string}
+       */
+      action: "ban" | "captcha";
+      /** @description The client's address */
+      client_ip: string;
+      /** @description Why the traffic analyzer acted */
+      reason: string;
+      /**
+       * Format: date-time
+       * @description When it started
+       */
+      since: string;
+      /**
+       * Format: date-time
+       * @description When it ends
+       */
+      until: string;
+    };
+    TrafficBlocks: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description The clients banned or put to a CAPTCHA, by address */
+      blocks: components["schemas"]["TrafficBlock"][];
+    };
+    TransactionOperation: {
+      /** @description For create, what post-v1-users takes (CreateUserRequest); for update, what put-v1-users-by-id takes (UpdateUserRequest) */
+      body?: {
+        [key: string]: unknown;
+      };
+      /** @description For update and delete, the resource's ID, or $N for the one operation N, counting from 0, created */
+      id?: string;
+      /**
+       * @description What to do
+       * @enum {string}
+       */
+      op: "create" | "update" | "delete";
+      /**
+       * @description The kind of resource to do it to
+       * @enum {string}
+       */
+      resource: "user";
+    };
+    TransactionRequest: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Operations to run, in order */
+      operations: components["schemas"]["TransactionOperation"][];
+    };
+    TransactionResponse: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Whether every operation succeeded and was committed. If not, none of them were */
+      committed: boolean;
+      /** @description One per operation, in request order */
+      results: components["schemas"]["TransactionResult"][];
+    };
+    TransactionResult: {
+      /** @description Why the operation failed */
+      error?: components["schemas"]["ErrorModel"];
+      /** @description ID of the resource; absent for a create that was rolled back */
+      id?: string;
+      /**
+       * @description committed if the transaction was; otherwise failed for the operation that failed, rolled_back for those before it and skipped for those after it
+       * @enum {string}
+       */
+      outcome: "committed" | "rolled_back" | "failed" | "skipped";
+      /**
+       * Format: int64
+       * @description What the operation answered, or would have on its own: 201 for a create, 200 for an update or delete, or the status of Error. Absent for skipped operations
+       */
+      status?: number;
+      /** @description The user as a committed create or update left them */
+      user?: components["schemas"]["User"];
+    };
+    UnlockResponse: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /**
+       * Format: int64
+       * @description Failed logins in a row that were cleared
+       */
+      failed_attempts: number;
+      /** @description The user whose failed logins were cleared */
+      user_id: string;
+      /** @description Whether the account was locked out */
+      was_locked: boolean;
+    };
+    UpdateCommentRequest: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Text of the comment */
+      body: string;
+    };
+    UpdatePostRequest: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Text of the post */
+      body?: string;
+      /** @description Title */
+      title?: string;
+    };
+    UpdateUserRequest: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /**
+       * Format: email
+       * @description User's email
+       */
+      email?: string;
+      /** @description Free-form attributes for integrations; replaces the existing metadata */
+      metadata?: {
+        [key: string]: unknown;
+      };
+      /** @description User's name */
+      name?: string;
+      /** @description International phone number; stored in E.164 form. Empty clears it */
+      phone?: string;
+      /** @description Unique handle: 3-32 lowercase letters, digits or underscores, starting with a letter */
+      username?: string;
+      [key: string]: unknown;
+    };
+    User: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Whether the user is active; inactive users are hidden from the default listing */
+      active: boolean;
+      /**
+       * Format: date-time
+       * @description When the user was soft-deleted; the retention policy purges them some time after
+       */
+      deleted_at?: string;
+      /**
+       * Format: email
+       * @description User's email, in its canonical form: the domain lowercased, and folded further as EMAIL_FOLDING says
+       */
+      email: string;
+      /** @description The email as it was given, if that differs from its canonical form */
+      email_raw?: string;
+      /** @description User ID */
+      id: string;
+      /**
+       * Format: date-time
+       * @description When the user last logged in
+       */
+      last_login_at?: string;
+      /**
+       * Format: date-time
+       * @description When the user last made an authenticated request
+       */
+      last_seen_at?: string;
+      /** @description Free-form attributes for integrations */
+      metadata?: {
+        [key: string]: unknown;
+      };
+      /** @description User's name */
+      name: string;
+      /**
+       * Format: e164
+       * @description Phone number in E.164 form
+       */
+      phone?: string;
+      /**
+       * Format: int64
+       * @description Number of posts the user wrote; only with include=post_count
+       */
+      post_count?: number;
+      /** @description The posts the user wrote, oldest first; only with include=posts or include=posts.comments, and omitted if there are none */
+      posts?: readonly components["schemas"]["Post"][];
+      /**
+       * @description Lifecycle status of the user
+       * @enum {string}
+       */
+      status: "invited" | "active" | "suspended" | "deleted";
+      /** @description Labels, managed through /v1/users/{id}/tags */
+      tags?: readonly string[];
+      /** @description Unique lowercase handle */
+      username?: string;
+      [key: string]: unknown;
+    };
+    UserChange: {
+      /** @description Correlation ID of the request that made the change, the X-Correlation-ID it was sent with or else its request ID */
+      correlation_id?: string;
+      /**
+       * Format: int64
+       * @description Sequence number of the change; the same as the audit entry's ID
+       */
+      seq: number;
+      /**
+       * Format: date-time
+       * @description When the change happened
+       */
+      time: string;
+      /** @description What happened */
+      type: string;
+      /** @description ID of the user, or an erased-… placeholder once the user is erased */
+      user_id: string;
+    };
+    UserChanges: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description The changes after since, oldest first; empty if the wait ran out first */
+      changes: components["schemas"]["UserChange"][];
+      /**
+       * Format: int64
+       * @description What to pass as since to get the changes after these
+       */
+      next_since: number;
+    };
+    UserDataExport: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Audit log entries about the user, oldest first */
+      audit: components["schemas"]["AuditEntry"][];
+      /** @description The comments the user wrote, on anyone's posts, oldest first */
+      comments: components["schemas"]["Comment"][];
+      /**
+       * Format: date-time
+       * @description When the export was generated
+       */
+      exported_at: string;
+      /** @description The user's posts, oldest first */
+      posts: components["schemas"]["Post"][];
+      /** @description Saved preferences, left out if the user never saved any */
+      preferences?: components["schemas"]["UserPreferences"];
+      /** @description The user record */
+      user: components["schemas"]["User"];
+    };
+    UserLookupResult: {
+      /** @description Whether a user with this ID exists */
+      found: boolean;
+      /** @description Requested user ID */
+      id: string;
+      /** @description The user, if found */
+      user?: components["schemas"]["User"];
+    };
+    UserPreferences: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /**
+       * @description BCP 47 language tag used for emails and formatted output
+       * @default en
+       */
+      locale?: string;
+      /** @description Which notifications the user receives */
+      notifications?: components["schemas"]["NotificationPreferences"];
+      /**
+       * @description IANA time zone name
+       * @default UTC
+       */
+      timezone?: string;
+    };
+    UserStatusRequest: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /**
+       * @description Status to move the user to
+       * @enum {string}
+       */
+      status: "invited" | "active" | "suspended" | "deleted";
+    };
+    UserSuggestion: {
+      /** @description User's email */
+      email: string;
+      /** @description User ID */
+      id: string;
+      /** @description User's name */
+      name: string;
+    };
+    UserTags: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Labels for the user. Tags are lowercased, deduplicated and sorted. */
+      tags: string[];
+    };
+    UsernameAvailability: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Whether the name can be registered */
+      available: boolean;
+      /**
+       * @description Why the name is unavailable
+       * @enum {string}
+       */
+      reason?: "invalid" | "reserved" | "taken";
+      /** @description The name that was checked, normalized to lowercase */
+      username: string;
+    };
+    UsersListResponse: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Whether there are pages after this one */
+      has_more: boolean;
+      /** @description The next page; left out on the last one */
+      next?: components["schemas"]["PageLink"];
+      /** @description true if the time budget ran out before every user was looked at; the users, tag counts and pages are then of those that were, oldest first */
+      partial?: boolean;
+      /**
+       * Format: int64
+       * @description Always 200; kept for older clients
+       */
+      status: number;
+      /** @description Number of listed users carrying each tag */
+      tag_counts: {
+        [key: string]: number;
+      };
+      /** @description This page of users */
+      users: components["schemas"]["User"][];
+    };
+    VersionResponse: {
+      /**
+       * Format: uri
+       * @description A URL to the JSON Schema for this object.
+       */
+      $schema?: string;
+      /** @description Git commit SHA the binary was built from */
+      commit: string;
+      /** @description Build time (RFC 3339), or the commit time if it wasn't set at build */
+      date: string;
+      /** @description Go runtime version */
+      go_version: string;
+      /** @description Release version, or dev for local builds */
+      version: string;
+    };
+  };
+  responses: never;
+  parameters: never;
+  requestBodies: never;
+  headers: never;
+  pathItems: never;
+}
+
+export type $defs = Record<string, never>;
+
+export type external = Record<string, never>;
+
+export interface operations {
+
+  /**
+   * Back up the store
+   * @description Download every user, their preferences, password hashes, posts and comments as a gzipped JSON archive, which `post-admin-restore` accepts. Requires the admin token.
+   */
+  "get-admin-backup": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    responses: {
+      /** @description Backup archive */
+      200: {
+        headers: {
+          "Content-Disposition"?: string;
+          "Content-Type"?: string;
+        };
+        content: {
+          "application/gzip": string;
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * List dead letters
+   * @description List the background deliveries that failed every attempt, newest first: security events the SECURITY_EVENTS sink didn't take and purges the CDN refused. Each has what was to be delivered and every failed attempt with its error. The queue is kept in memory on this replica, holds up to 10000, and is lost on restart. Requires the admin token.
+   */
+  "get-admin-dead-letters": {
+    parameters: {
+      query?: {
+        /** @description Only list dead letters of this kind */
+        kind?: "security_event" | "cache_purge" | "email";
+        /** @description Only list dead letters with this status */
+        status?: "dead" | "requeued";
+      };
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["DeadLetterList"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Get a dead letter
+   * @description Get a dead letter with its payload and error history. Requires the admin token.
+   */
+  "get-admin-dead-letters-by-id": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+      path: {
+        /**
+         * @description Dead letter ID
+         * @example dlq_3f9a1c2b7e4d
+         */
+        id: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["DeadLetter"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Discard a dead letter
+   * @description Drop a dead letter without delivering it. Requires the admin token.
+   */
+  "delete-admin-dead-letters-by-id": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+      path: {
+        /**
+         * @description Dead letter ID
+         * @example dlq_3f9a1c2b7e4d
+         */
+        id: string;
+      };
+    };
+    responses: {
+      /** @description No Content */
+      204: {
+        content: never;
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Requeue a dead letter
+   * @description Put a dead letter back on its queue, to be delivered again once. It is listed as `requeued` until it is delivered, when it leaves the queue, or fails again, when it is `dead` again with the new attempt in its history. Fails with 409 if it is already requeued. Requires the admin token.
+   */
+  "post-admin-dead-letters-by-id-requeue": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+      path: {
+        /**
+         * @description Dead letter ID
+         * @example dlq_3f9a1c2b7e4d
+         */
+        id: string;
+      };
+    };
+    responses: {
+      /** @description Accepted */
+      202: {
+        content: {
+          "application/json": components["schemas"]["DeadLetter"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Conflict */
+      409: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Send the activity digests
+   * @description Send the activity digest email now to every active user whose preferences ask for the `period` digest, instead of waiting for DIGEST_AT. It summarizes the users created and changed in the last day or week, from the audit log, in each user's locale. With dry_run it only counts who would get one. Fails with 409 while the period's digests are being sent, on this or another replica. Requires the admin token.
+   */
+  "post-admin-digest": {
+    parameters: {
+      query?: {
+        /** @description Send the digests of the users who get them this often */
+        period?: "daily" | "weekly";
+        /** @description Only count who would get one */
+        dry_run?: boolean;
+      };
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["DigestReport"];
+        };
+      };
+      /** @description Bad Request */
+      400: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Conflict */
+      409: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Replay events
+   * @description Deliver the events from `from_seq` to `to_seq` again, up to 1000 at a time, to the consumers outside the API that may have missed them during an outage: the security event stream, marked `replayed`, and the CDN purges. The receivers get the events' original IDs, to skip those they had. Fails with 409 if neither is configured. Requires the admin token.
+   */
+  "post-admin-events-replay": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["ReplayEventsRequest"];
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["EventReplay"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Conflict */
+      409: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * List the fault rules
+   * @description List the rules this replica injects faults with. Only available with FAULT_INJECTION set, or in dev mode. Requires the admin token.
+   */
+  "get-admin-faults": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["FaultRules"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Set the fault rules
+   * @description Replace the rules this replica injects faults with, to exercise clients' timeouts and retries: each makes a share of a route's requests wait, fail with a 5xx `INJECTED_FAULT`, or lose their connection. The admin API and probes are never hit. An empty list turns faults off. Rules live in memory, per replica. Only available with FAULT_INJECTION set, or in dev mode. Requires the admin token.
+   */
+  "put-admin-faults": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["FaultRules"];
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["FaultRules"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Get the health history
+   * @description Report what this replica's health probes found: it probes its readiness, as /readyz answers it, every HEALTH_PROBE_INTERVAL (10s by default) and keeps the latest 8640 outcomes. `uptime_percent` is the share of those that found it up, and `transitions` are the latest 100 changes between up and down, newest first, with why it was down. The history is kept in memory and starts over on restart. Requires the admin token.
+   */
+  "get-admin-health-history": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["HealthHistory"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Impersonate a user
+   * @description Issue a short-lived user token that acts as the user, with an `impersonated_by` claim naming `actor`, so support staff can reproduce what the user sees. Issuing it and every request made with it are recorded in the audit log; requests made with it don't count as the user being seen. Requires the admin token.
+   */
+  "post-admin-impersonate-by-user-id": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+      path: {
+        /** @description ID of the user to act as */
+        userID: string;
+      };
+    };
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["ImpersonateRequest"];
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["ImpersonationToken"];
+        };
+      };
+      /** @description Bad Request */
+      400: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * List in-flight requests
+   * @description List the requests this replica is serving, oldest first, with their route, client and how long they have been running, to find the one holding the server up. Requires the admin token.
+   */
+  "get-admin-inflight": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["InFlightList"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Cancel an in-flight request
+   * @description Cancel the context of the request with the X-Request-ID, e.g. a stuck export, so its handler gives up. A request canceled before it started its response gets a 503 `REQUEST_CANCELED`; one already streaming its response is cut short. The work a handler doesn't check its context for may still be done. Returns the canceled requests, more than one if clients sent the same ID. Requires the admin token.
+   */
+  "post-admin-inflight-by-id-cancel": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+      path: {
+        /**
+         * @description X-Request-ID of the request to cancel
+         * @example req_4bf92f3577b34da6a3ce929d0e0e4736
+         */
+        id: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["InFlightList"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Get the leader
+   * @description Report which replica leads, running the scheduled retention and digests, and whether the one answering does. The leader is the raft leader with RAFT_NODE_ID set, or else whoever holds the leader lock in REDIS_URLS; without either, every replica leads itself. Requires the admin token.
+   */
+  "get-admin-leader": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["LeaderStatus"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Service Unavailable */
+      503: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Change the log level
+   * @description Change the minimum log level of the running server, optionally going back to the configured level after `revert_after_minutes`. Requires the admin token.
+   */
+  "put-admin-loglevel": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["LogLevelRequest"];
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["LogLevelResponse"];
+        };
+      };
+      /** @description Bad Request */
+      400: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Get the maintenance mode
+   * @description Report whether this replica is in maintenance mode, and since when. Requires the admin token.
+   */
+  "get-admin-maintenance": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["Maintenance"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Switch maintenance mode
+   * @description Put this replica in maintenance mode, or take it out. `on` answers every request but health, metrics, version, the docs and the admin API with 503 `MAINTENANCE` and Retry-After; `read_only` still serves GET and HEAD. Background writes, like recording user activity and scheduled retention, pause too. Holds until changed again or MAINTENANCE_MODE changes on reload. Requires the admin token.
+   */
+  "put-admin-maintenance": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["MaintenanceRequest"];
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["Maintenance"];
+        };
+      };
+      /** @description Bad Request */
+      400: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * List the API key quotas
+   * @description List every API key with a quota or with requests counted on this replica, with its daily and monthly caps and the requests it made in the current UTC day and month. Requires the admin token.
+   */
+  "get-admin-ratelimits": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["RateLimits"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Get an API key's quota
+   * @description Get an API key's daily and monthly caps, zero if it has none, and the requests it made on this replica in the current UTC day and month. Requires the admin token.
+   */
+  "get-admin-ratelimits-by-key-id": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+      path: {
+        /** @description ID of the API key */
+        keyID: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["RateLimit"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Set an API key's quota
+   * @description Cap the requests an API key makes per UTC day and month. Once either is used up, its requests get 429 `QUOTA_EXCEEDED` with Retry-After until the period starts over; the admin API and probes are never refused. Lowering a cap below the requests already made takes effect at once. Quotas and counts live in memory, per replica. Requires the admin token.
+   */
+  "put-admin-ratelimits-by-key-id": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+      path: {
+        /** @description ID of the API key */
+        keyID: string;
+      };
+    };
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["QuotaLimits"];
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["RateLimit"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Lift an API key's quota
+   * @description Remove an API key's caps. Its requests are still counted. Requires the admin token.
+   */
+  "delete-admin-ratelimits-by-key-id": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+      path: {
+        /** @description ID of the API key */
+        keyID: string;
+      };
+    };
+    responses: {
+      /** @description No Content */
+      204: {
+        content: never;
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Re-encrypt users' PII
+   * @description Rewrite every user's email and phone that is plaintext or encrypted under an older key under the primary key, so the older key can be removed. Fails with 409 if field encryption is off. Requires the admin token.
+   */
+  "post-admin-reencrypt": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["ReencryptResponse"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Conflict */
+      409: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * List recent requests
+   * @description List the latest requests this replica served, newest first, with their responses, for debugging clients. Headers, parameters and bodies are redacted like the logs, and bodies truncated to 4 KiB. Health checks and metrics scrapes are left out. Only available with CAPTURE_REQUESTS set, or in dev mode. Requires the admin token.
+   */
+  "get-admin-requests": {
+    parameters: {
+      query?: {
+        /** @description How many requests to return, newest first */
+        limit?: number;
+        /** @description errors keeps only 4xx and 5xx responses */
+        status?: "all" | "errors";
+      };
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["CapturedList"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Clear the recent requests
+   * @description Drop the requests GET /admin/requests lists, e.g. before reproducing a problem. Requires the admin token.
+   */
+  "delete-admin-requests": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["ClearCapturedOutputBody"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Restore the store from a backup
+   * @description Replace every user, their preferences, posts and comments with the contents of an archive from `get-admin-backup`. Users not in the archive are deleted. Fails with 409 while another restore is in progress, on this or another replica. Requires the admin token.
+   */
+  "post-admin-restore": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    requestBody: {
+      content: {
+        "application/gzip": string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["RestoreResponse"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Conflict */
+      409: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Request Entity Too Large */
+      413: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Apply the retention policy
+   * @description Run the configured retention rules now instead of waiting for the next scheduled run: purge users soft-deleted longer ago than RETENTION_DELETED_USERS and audit entries older than RETENTION_AUDIT. With dry_run it only reports what would be purged. Fails with 409 while another run is in progress, on this or another replica. Requires the admin token.
+   */
+  "post-admin-retention": {
+    parameters: {
+      query?: {
+        /** @description Only report what would be purged */
+        dry_run?: boolean;
+      };
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["RetentionReport"];
+        };
+      };
+      /** @description Bad Request */
+      400: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Conflict */
+      409: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Show the usage report
+   * @description Show whether this replica sends anonymous usage reports, which it only does with TELEMETRY=true, where and how often, and exactly what the next report would hold were it sent now: how many requests each operation got, the version, the platform and the store backend. Nothing about users, their data or addresses, or the configuration is reported. Requires the admin token.
+   */
+  "get-admin-telemetry": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["TelemetryStatus"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * List the clients the traffic analyzer acted on
+   * @description List the client addresses this replica's traffic analyzer has banned, or put to a CAPTCHA, and not yet let go, with why and until when. Requires the admin token.
+   */
+  "get-admin-traffic-blocks": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["TrafficBlocks"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Let a client go
+   * @description Lift a ban or CAPTCHA the traffic analyzer put on a client address on this replica before it runs out. Requires the admin token.
+   */
+  "delete-admin-traffic-blocks-by-ip": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+      path: {
+        /**
+         * @description The client's address
+         * @example 203.0.113.9
+         */
+        ip: string;
+      };
+    };
+    responses: {
+      /** @description No Content */
+      204: {
+        content: never;
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Unlock a user's account
+   * @description Clear a user's failed logins, lifting a lockout from too many of them before LOGIN_LOCKOUT runs out. Failures counted against client addresses stay. The unlock is recorded in the audit log. Requires the admin token.
+   */
+  "post-admin-unlock-by-user-id": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+      path: {
+        /** @description ID of the user to unlock */
+        userID: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["UnlockResponse"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Preview an email
+   * @description Render one of the emails the API sends, with made-up data, as HTML for a browser or as the plain-text part. The subject comes in X-Email-Subject. Only available in dev mode.
+   */
+  "get-dev-emails-by-name": {
+    parameters: {
+      query?: {
+        /**
+         * @description Language to render it in; regional tags fall back to their base language, and missing variants to English
+         * @example de
+         */
+        lang?: string;
+        /** @description Which part of the email to show */
+        format?: "html" | "text";
+      };
+      path: {
+        /** @description Email to render */
+        name: "verification" | "reset" | "digest" | "invitation";
+      };
+    };
+    responses: {
+      /** @description Rendered email */
+      200: {
+        headers: {
+          "Content-Type"?: string;
+          "X-Email-Subject"?: string;
+        };
+        content: {
+          "text/html": string;
+          "text/plain": string;
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Get health
+   * @description Answer 200, or 503 while the server drains before shutting down. `format=plain` answers in a line of text and `format=prometheus` with a health_up gauge, like the probes.
+   */
+  "get-health": {
+    parameters: {
+      query?: {
+        /** @description json, plain for a line per component that people and shell scripts can read, or prometheus for gauges in the Prometheus text format */
+        format?: "json" | "plain" | "prometheus";
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        headers: {
+          "Content-Type"?: string;
+        };
+        content: {
+          "application/json": components["schemas"]["HealthResponse"];
+          "text/plain; charset=utf-8": string;
+          "text/plain; version=0.0.4; charset=utf-8": string;
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /** Get hello */
+  "get-hello": {
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["HelloResponse"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Liveness probe
+   * @description Answer 200 as long as the process serves requests, draining included, so the orchestrator only restarts a server that stopped responding. Takes `format` as get-health does.
+   */
+  "get-livez": {
+    parameters: {
+      query?: {
+        /** @description json, plain for a line per component that people and shell scripts can read, or prometheus for gauges in the Prometheus text format */
+        format?: "json" | "plain" | "prometheus";
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        headers: {
+          "Content-Type"?: string;
+        };
+        content: {
+          "application/json": components["schemas"]["HealthResponse"];
+          "text/plain; charset=utf-8": string;
+          "text/plain; version=0.0.4; charset=utf-8": string;
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Readiness probe
+   * @description Answer 200 while the server takes traffic, and 503 from the moment it gets SIGTERM, so the orchestrator takes it out of rotation during SHUTDOWN_DELAY. Listeners only open once every other component has started, so there is no warm-up to wait for, but a component with a readiness gate fails it while it can't serve, like a raft store without a leader. Every component is listed with its status. `format=plain` answers with a line per component, and `format=prometheus` with a health_up gauge and a health_component_ready gauge per component.
+   */
+  "get-readyz": {
+    parameters: {
+      query?: {
+        /** @description json, plain for a line per component that people and shell scripts can read, or prometheus for gauges in the Prometheus text format */
+        format?: "json" | "plain" | "prometheus";
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        headers: {
+          "Content-Type"?: string;
+        };
+        content: {
+          "application/json": components["schemas"]["ReadinessResponse"];
+          "text/plain; charset=utf-8": string;
+          "text/plain; version=0.0.4; charset=utf-8": string;
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * List your API keys
+   * @description List the API keys of the user the token acts as, oldest first, with their scopes and when each was last used. Only the start of each key is shown. Needs a user token; API keys can't manage API keys.
+   */
+  "get-v1-api-keys": {
+    parameters: {
+      header?: {
+        /** @description Bearer user token */
+        Authorization?: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["APIKeysResponse"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Forbidden */
+      403: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Create an API key
+   * @description Create an API key acting as you, for scripts and integrations, limited to the given scopes and optionally expiring. The key is only shown in this response; send it as `Authorization: Bearer <key>`. A user can have up to 20 keys. Keys stop working when the user is deactivated. Recorded in the audit log as `security.api_key_created`. Needs a user token.
+   */
+  "post-v1-api-keys": {
+    parameters: {
+      header?: {
+        /** @description Bearer user token */
+        Authorization?: string;
+      };
+    };
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["CreateAPIKeyRequest"];
+      };
+    };
+    responses: {
+      /** @description Created */
+      201: {
+        headers: {
+          /** @description true if the response is that of an identical request made just before, which this one duplicated */
+          "X-Deduplicated"?: "true";
+        };
+        content: {
+          "application/json": components["schemas"]["NewAPIKey"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Forbidden */
+      403: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Conflict */
+      409: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Revoke an API key
+   * @description Delete one of your API keys; it stops working at once. Recorded in the audit log as `security.api_key_revoked`. Needs a user token.
+   */
+  "delete-v1-api-keys-by-id": {
+    parameters: {
+      header?: {
+        /** @description Bearer user token */
+        Authorization?: string;
+      };
+      path: {
+        /** @description API key ID */
+        id: string;
+      };
+    };
+    responses: {
+      /** @description No Content */
+      204: {
+        content: never;
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Forbidden */
+      403: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Rotate an API key
+   * @description Replace the secret of one of your API keys, keeping its ID, name and scopes. The old key stops working at once; the new one is only shown in this response. Recorded in the audit log as `security.api_key_rotated`. Needs a user token.
+   */
+  "post-v1-api-keys-by-id-rotate": {
+    parameters: {
+      header?: {
+        /** @description Bearer user token */
+        Authorization?: string;
+      };
+      path: {
+        /** @description API key ID */
+        id: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["NewAPIKey"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Forbidden */
+      403: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Introspect a token
+   * @description Say whether a user token or API key works, in the shape of an RFC 7662 introspection response, taking the token as JSON rather than a form: `active` is false, and nothing else is set, for a token that is malformed, forged, expired or revoked. For one that works it says who the token acts as, until when and, for an API key, with which scopes. Holding the token is all it takes.
+   */
+  "post-v1-auth-introspect": {
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["TokenRequest"];
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["TokenIntrospection"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Service Unavailable */
+      503: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Log in
+   * @description Exchange an active user's username or email and password for a user token valid for an hour. After a few failed logins in a row, each further attempt has to wait, longer every time (429 `LOGIN_THROTTLED`); after LOGIN_MAX_FAILURES the account is locked for LOGIN_LOCKOUT (423 `ACCOUNT_LOCKED`), and an address with LOGIN_MAX_FAILURES_PER_IP failures is blocked as long. Both come with Retry-After. Failures, lockouts and blocks are recorded in the audit log. When the server has CAPTCHA_PROVIDER set, the request needs an `X-Captcha-Token`.
+   */
+  "post-v1-auth-login": {
+    parameters: {
+      header?: {
+        /** @description Token from solving the site's CAPTCHA; required when the server has CAPTCHA_PROVIDER set */
+        "X-Captcha-Token"?: string;
+      };
+    };
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["LoginRequest"];
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["LoginToken"];
+        };
+      };
+      /** @description Bad Request */
+      400: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Forbidden */
+      403: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Locked */
+      423: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Too Many Requests */
+      429: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Service Unavailable */
+      503: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Revoke a token
+   * @description Make a user token or API key stop working at once, before it expires, as in RFC 7009; holding the token is all it takes. A revoked user token is refused with 401 `INVALID_TOKEN`, and an API key is deleted, as `delete-v1-api-keys-by-id` does. A token that already doesn't work is accepted as revoked. Recorded in the audit log as `security.token_revoked` or `security.api_key_revoked`.
+   */
+  "post-v1-auth-revoke": {
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["TokenRequest"];
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: never;
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Service Unavailable */
+      503: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Run operations atomically
+   * @description Run up to 100 create, update and delete operations in order, as one transaction: either all of them succeed and are committed, or none are. Each operation follows the rules of its single endpoint and sees the writes of the ones before it; `$N` as an `id` names what operation N created. `results` reports every operation's outcome, and the error of the one that failed the transaction. Nothing is locked while it runs: if a request changes a user the transaction read before it commits, it runs again on the new data, and fails with 409 if that keeps happening. When the server has CAPTCHA_PROVIDER set, a transaction that creates users needs an `X-Captcha-Token`. The body may be sent gzipped, with `Content-Encoding: gzip`.
+   */
+  "post-v1-batch": {
+    parameters: {
+      header?: {
+        /** @description Token from solving the site's CAPTCHA; required when the server has CAPTCHA_PROVIDER set */
+        "X-Captcha-Token"?: string;
+      };
+    };
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["TransactionRequest"];
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["TransactionResponse"];
+        };
+      };
+      /** @description Bad Request */
+      400: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Forbidden */
+      403: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Conflict */
+      409: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Request Entity Too Large */
+      413: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unsupported Media Type */
+      415: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Service Unavailable */
+      503: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Get the API changelog
+   * @description List the changes to the API per release, newest first, starting with the changes not released yet. Operations added and removed are listed even where the changelog doesn't mention them, from the operations each release served. Pass since to get only what changed after the release a client was built against.
+   */
+  "get-v1-changelog": {
+    parameters: {
+      query?: {
+        /**
+         * @description Return only the releases after this one, and the unreleased changes
+         * @example 1.0.0
+         */
+        since?: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        headers: {
+          /**
+           * @description How long the response may be cached, and by whom
+           * @example public, max-age=300
+           */
+          "Cache-Control"?: string;
+        };
+        content: {
+          "application/json": components["schemas"]["Changelog"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Download a file through a signed link
+   * @description Download a file through a time-limited signed link another operation handed out, such as the `download_url` of get-v1-exports-by-id. The link's signature stands in for credentials, so it can be opened in a browser or passed to another service. A link that was altered or has expired gets 403 `INVALID_SIGNATURE`.
+   */
+  "get-v1-downloads-by-kind-by-id": {
+    parameters: {
+      query: {
+        /** @description When the link stops working, in Unix seconds */
+        expires: number;
+        /** @description Signature of the link */
+        signature: string;
+      };
+      path: {
+        /** @description Kind of download */
+        kind: "exports";
+        /**
+         * @description ID of the download within its kind
+         * @example exp_5b0e2a9c1d7f
+         */
+        id: string;
+      };
+    };
+    responses: {
+      /** @description The file */
+      200: {
+        headers: {
+          "Content-Disposition"?: string;
+          "Content-Type"?: string;
+        };
+        content: {
+          "application/x-ndjson": string;
+          "text/csv": string;
+        };
+      };
+      /** @description Forbidden */
+      403: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * List the events
+   * @description List the domain events published on this replica from `from_seq` on, oldest first, as the audit log records them under sequence numbers; pass `next_seq` as `from_seq` to get the next page. The log is kept in memory, and with AUDIT_LOG_PATH in a file too, so it outlives restarts; `oldest_seq` tells whether events before it are gone. Requires the admin token.
+   */
+  "get-v1-events": {
+    parameters: {
+      query?: {
+        /** @description Sequence number of the first event to return */
+        from_seq?: number;
+        /** @description Maximum number of events to return */
+        limit?: number;
+      };
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["EventsPage"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Export users in the background
+   * @description Start exporting the users the filters select, which are those of get-v1-users, as NDJSON or CSV, in ID order. The export is built in the background, so it takes no longer than the request to start it; poll the `Location` it returns until `status` is `completed`, then fetch its `download_url`. Exports are kept in memory on the replica that built them for a day after they finish, and are lost on restart. Requires the admin token.
+   */
+  "post-v1-exports": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["CreateExportRequest"];
+      };
+    };
+    responses: {
+      /** @description Accepted */
+      202: {
+        headers: {
+          Location?: string;
+          /** @description true if the response is that of an identical request made just before, which this one duplicated */
+          "X-Deduplicated"?: "true";
+        };
+        content: {
+          "application/json": components["schemas"]["Export"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Get an export
+   * @description Get the status of an export. Once it has completed, `download_url` is a link to the file that works for an hour without credentials; each request returns a fresh one. Requires the admin token.
+   */
+  "get-v1-exports-by-id": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+      path: {
+        /**
+         * @description Export ID
+         * @example exp_5b0e2a9c1d7f
+         */
+        id: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        headers: {
+          Location?: string;
+        };
+        content: {
+          "application/json": components["schemas"]["Export"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * List invitations
+   * @description List the invitations on this replica, newest first, optionally only those with a status. Requires the admin token.
+   */
+  "get-v1-invitations": {
+    parameters: {
+      query?: {
+        /** @description Only list the invitations with this status */
+        status?: "pending" | "accepted" | "revoked" | "expired";
+      };
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["InvitationsResponse"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Invite someone to create an account
+   * @description Create a pending invitation for an email no user has, valid for 7 days unless `expires_at` says otherwise. The token to accept it with is only shown in this response. With APP_URL set, it is also emailed as a link to `<APP_URL>/invitations/accept?token=...`, in the invitation's locale. An email can have one pending invitation at a time. Invitations are kept in memory on the replica that created them, so they are lost on restart. Requires the admin token.
+   */
+  "post-v1-invitations": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["CreateInvitationRequest"];
+      };
+    };
+    responses: {
+      /** @description Created */
+      201: {
+        headers: {
+          /** @description true if the response is that of an identical request made just before, which this one duplicated */
+          "X-Deduplicated"?: "true";
+        };
+        content: {
+          "application/json": components["schemas"]["NewInvitation"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Conflict */
+      409: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Revoke an invitation
+   * @description Revoke an invitation that hasn't been accepted; its token stops working at once. It is still listed, as revoked. Revoking it again is a no-op. Requires the admin token.
+   */
+  "delete-v1-invitations-by-id": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+      path: {
+        /**
+         * @description Invitation ID
+         * @example inv_3f9a1c2b7d4e
+         */
+        id: string;
+      };
+    };
+    responses: {
+      /** @description No Content */
+      204: {
+        content: never;
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Conflict */
+      409: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Accept an invitation
+   * @description Create the user a pending invitation invites, with its email and the password given, who can then log in. The name defaults to the invitation's. A token that is unknown or revoked gets 404 `INVITATION_NOT_FOUND`, an expired one 410 `INVITATION_EXPIRED`, and one already accepted 409.
+   */
+  "post-v1-invitations-by-token-accept": {
+    parameters: {
+      path: {
+        /** @description The invitation's token */
+        token: string;
+      };
+    };
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["AcceptInvitationRequest"];
+      };
+    };
+    responses: {
+      /** @description Created */
+      201: {
+        content: {
+          "application/json": components["schemas"]["User"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Conflict */
+      409: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Gone */
+      410: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Get the current user
+   * @description Get the user a user token or API key acts as and, for an impersonation token, who is acting as them, or for an API key, its scopes. An API key needs the `users:read` scope.
+   */
+  "get-v1-me": {
+    parameters: {
+      header?: {
+        /** @description Bearer user token */
+        Authorization?: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["CurrentUser"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Forbidden */
+      403: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * List your notifications
+   * @description Get a page of the notifications of the user the token acts as, newest first, with how many are unread. Users are notified when their account is created, when someone comments on their post or replies to their comment, and when a post or comment @mentions their username. Notifications are kept in memory, so they start empty on every restart. An API key needs the `notifications:read` scope.
+   */
+  "get-v1-notifications": {
+    parameters: {
+      query?: {
+        /** @description Page number, starting at 1 */
+        page?: number;
+        /** @description Entries per page */
+        per_page?: number;
+        /** @description Only list the unread notifications */
+        unread?: boolean;
+      };
+      header?: {
+        /** @description Bearer user token */
+        Authorization?: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        headers: {
+          Link?: string;
+          "X-Total-Count"?: number;
+        };
+        content: {
+          "application/json": components["schemas"]["NotificationsListResponse"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Forbidden */
+      403: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Mark a notification read
+   * @description Mark one of your notifications read. Marking it again keeps the time it was first read. An API key needs the `notifications:write` scope.
+   */
+  "post-v1-notifications-by-id-read": {
+    parameters: {
+      header?: {
+        /** @description Bearer user token */
+        Authorization?: string;
+      };
+      path: {
+        /**
+         * @description Notification ID
+         * @example 20240101121000
+         */
+        id: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["Notification"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Forbidden */
+      403: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * List all posts
+   * @description Get a page of every user's posts, or with `author_id` of one user's, in ID order, which is oldest first unless ID_GENERATOR=uuidv4 makes IDs random. `X-Total-Count` and `Link` headers describe the other pages.
+   */
+  "get-v1-posts": {
+    parameters: {
+      query?: {
+        /** @description Page number, starting at 1 */
+        page?: number;
+        /** @description Entries per page */
+        per_page?: number;
+        /**
+         * @description Start after the entry with this ID, the last of the previous page, instead of at page; unlike page numbers, it neither skips nor repeats entries when others are deleted or created between requests. next.after gives it
+         * @example 20240101120500
+         */
+        after?: string;
+        /**
+         * @description Only list the posts of this user
+         * @example 20240101120000
+         */
+        author_id?: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        headers: {
+          /**
+           * @description How long the response may be cached, and by whom
+           * @example public, max-age=30
+           */
+          "Cache-Control"?: string;
+          /**
+           * @description When what the response holds last changed
+           * @example Mon, 01 Jan 2024 12:05:00 GMT
+           */
+          "Last-Modified"?: string;
+          Link?: string;
+          /**
+           * @description Space-separated keys naming what the response holds, for a CDN to purge it by when that changes
+           * @example post:20240101120500 user:20240101120000
+           */
+          "Surrogate-Key"?: string;
+          "X-Total-Count"?: number;
+        };
+        content: {
+          "application/json": components["schemas"]["PostsListResponse"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Create a post
+   * @description Create a post with a title and body, written by the user `author_id` names. Posts belong to their author: deleting the user for good deletes their posts.
+   */
+  "post-v1-posts": {
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["CreatePostRequest"];
+      };
+    };
+    responses: {
+      /** @description Created */
+      201: {
+        headers: {
+          /** @description true if the response is that of an identical request made just before, which this one duplicated */
+          "X-Deduplicated"?: "true";
+        };
+        content: {
+          "application/json": components["schemas"]["Post"];
+        };
+      };
+      /** @description Bad Request */
+      400: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Get post by ID
+   * @description Get a post by its ID.
+   */
+  "get-v1-posts-by-id": {
+    parameters: {
+      path: {
+        /**
+         * @description Post ID
+         * @example 20240101120500
+         */
+        id: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        headers: {
+          /**
+           * @description How long the response may be cached, and by whom
+           * @example public, max-age=30
+           */
+          "Cache-Control"?: string;
+          /**
+           * @description When what the response holds last changed
+           * @example Mon, 01 Jan 2024 12:05:00 GMT
+           */
+          "Last-Modified"?: string;
+          /**
+           * @description Space-separated keys naming what the response holds, for a CDN to purge it by when that changes
+           * @example post:20240101120500 user:20240101120000
+           */
+          "Surrogate-Key"?: string;
+        };
+        content: {
+          "application/json": components["schemas"]["Post"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Update post by ID
+   * @description Change a post's title and/or body. Its author can't be changed.
+   */
+  "put-v1-posts-by-id": {
+    parameters: {
+      path: {
+        /**
+         * @description Post ID
+         * @example 20240101120500
+         */
+        id: string;
+      };
+    };
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["UpdatePostRequest"];
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["Post"];
+        };
+      };
+      /** @description Bad Request */
+      400: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Delete post by ID
+   * @description Delete a post and the comments on it by its ID.
+   */
+  "delete-v1-posts-by-id": {
+    parameters: {
+      path: {
+        /**
+         * @description Post ID
+         * @example 20240101120500
+         */
+        id: string;
+      };
+    };
+    responses: {
+      /** @description No Content */
+      204: {
+        content: never;
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * List a post's comments
+   * @description Get a page of the top-level comments on a post, or with `parent_id` of the replies to a comment, in ID order, which is oldest first unless ID_GENERATOR=uuidv4 makes IDs random. Each comes with its `reply_count`; walk a thread by listing the replies of those that have some. Comments hidden by moderation are left out unless `include_hidden=true`. `X-Total-Count` and `Link` headers describe the other pages.
+   */
+  "get-v1-posts-by-id-comments": {
+    parameters: {
+      query?: {
+        /** @description Page number, starting at 1 */
+        page?: number;
+        /** @description Entries per page */
+        per_page?: number;
+        /**
+         * @description Start after the entry with this ID, the last of the previous page, instead of at page; unlike page numbers, it neither skips nor repeats entries when others are deleted or created between requests. next.after gives it
+         * @example 20240101120500
+         */
+        after?: string;
+        /**
+         * @description List the replies to this comment instead of the top-level comments
+         * @example 20240101120900
+         */
+        parent_id?: string;
+        /** @description Also list, and count as replies, comments hidden by moderation */
+        include_hidden?: boolean;
+      };
+      path: {
+        /**
+         * @description Post ID
+         * @example 20240101120500
+         */
+        id: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        headers: {
+          /**
+           * @description How long the response may be cached, and by whom
+           * @example public, max-age=30
+           */
+          "Cache-Control"?: string;
+          /**
+           * @description When what the response holds last changed
+           * @example Mon, 01 Jan 2024 12:05:00 GMT
+           */
+          "Last-Modified"?: string;
+          Link?: string;
+          /**
+           * @description Space-separated keys naming what the response holds, for a CDN to purge it by when that changes
+           * @example post:20240101120500 user:20240101120000
+           */
+          "Surrogate-Key"?: string;
+          "X-Total-Count"?: number;
+        };
+        content: {
+          "application/json": components["schemas"]["CommentsListResponse"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Comment on a post
+   * @description Write a comment on a post as the user `author_id` names, or with `parent_id` a reply to another comment on the post. Comments are published straight away.
+   */
+  "post-v1-posts-by-id-comments": {
+    parameters: {
+      path: {
+        /**
+         * @description Post ID
+         * @example 20240101120500
+         */
+        id: string;
+      };
+    };
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["CreateCommentRequest"];
+      };
+    };
+    responses: {
+      /** @description Created */
+      201: {
+        headers: {
+          /** @description true if the response is that of an identical request made just before, which this one duplicated */
+          "X-Deduplicated"?: "true";
+        };
+        content: {
+          "application/json": components["schemas"]["Comment"];
+        };
+      };
+      /** @description Bad Request */
+      400: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Get a comment
+   * @description Get a comment on a post, with the number of its published replies.
+   */
+  "get-v1-posts-by-id-comments-by-comment-id": {
+    parameters: {
+      path: {
+        /**
+         * @description Post ID
+         * @example 20240101120500
+         */
+        id: string;
+        /**
+         * @description Comment ID
+         * @example 20240101121000
+         */
+        commentID: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        headers: {
+          /**
+           * @description How long the response may be cached, and by whom
+           * @example public, max-age=30
+           */
+          "Cache-Control"?: string;
+          /**
+           * @description When what the response holds last changed
+           * @example Mon, 01 Jan 2024 12:05:00 GMT
+           */
+          "Last-Modified"?: string;
+          /**
+           * @description Space-separated keys naming what the response holds, for a CDN to purge it by when that changes
+           * @example post:20240101120500 user:20240101120000
+           */
+          "Surrogate-Key"?: string;
+        };
+        content: {
+          "application/json": components["schemas"]["Comment"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Edit a comment
+   * @description Replace the body of a comment on a post.
+   */
+  "put-v1-posts-by-id-comments-by-comment-id": {
+    parameters: {
+      path: {
+        /**
+         * @description Post ID
+         * @example 20240101120500
+         */
+        id: string;
+        /**
+         * @description Comment ID
+         * @example 20240101121000
+         */
+        commentID: string;
+      };
+    };
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["UpdateCommentRequest"];
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["Comment"];
+        };
+      };
+      /** @description Bad Request */
+      400: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Delete a comment
+   * @description Delete a comment on a post, along with the replies to it and theirs.
+   */
+  "delete-v1-posts-by-id-comments-by-comment-id": {
+    parameters: {
+      path: {
+        /**
+         * @description Post ID
+         * @example 20240101120500
+         */
+        id: string;
+        /**
+         * @description Comment ID
+         * @example 20240101121000
+         */
+        commentID: string;
+      };
+    };
+    responses: {
+      /** @description No Content */
+      204: {
+        content: never;
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Moderate a comment
+   * @description Hide a comment from listings, or publish it again. Its replies stay listed under it. Moving a comment to the status it has is a no-op. Requires the admin token.
+   */
+  "post-v1-posts-by-id-comments-by-comment-id-status": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+      path: {
+        /**
+         * @description Post ID
+         * @example 20240101120500
+         */
+        id: string;
+        /**
+         * @description Comment ID
+         * @example 20240101121000
+         */
+        commentID: string;
+      };
+    };
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["CommentStatusRequest"];
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["Comment"];
+        };
+      };
+      /** @description Bad Request */
+      400: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Search users and posts
+   * @description Full-text search over active users' names, usernames and emails and over posts' titles and bodies, best match first. Each result has the user or post and the fragments of its fields that matched, with the matched words highlighted.
+   */
+  "get-v1-search": {
+    parameters: {
+      query: {
+        /**
+         * @description X-Time-Budget, for clients that can't set headers; the header wins if both are set
+         * @example 250
+         */
+        time_budget?: number;
+        /**
+         * @description Words to search for
+         * @example hello world
+         */
+        q: string;
+        /** @description Only search these kinds of resources, comma-separated; all of them by default */
+        type?: ("user" | "post")[];
+        /** @description Maximum number of results */
+        limit?: number;
+      };
+      header?: {
+        /**
+         * @description Milliseconds the request may take, counted from when the server got it. Once they are spent, the response has the results found so far, with partial set to true
+         * @example 250
+         */
+        "X-Time-Budget"?: number;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["SearchResponse"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Service Unavailable */
+      503: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Get aggregate counts
+   * @description Count the users, in total, created today and by status and tag, and the posts and comments. The store counts them in place rather than listing everything. With `STATS_CACHE_TTL` set, the counts are reused for that long; `computed_at` says when they were taken. Requires the admin token.
+   */
+  "get-v1-stats": {
+    parameters: {
+      header?: {
+        /** @description Bearer ADMIN_TOKEN */
+        Authorization?: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["Stats"];
+        };
+      };
+      /** @description Unauthorized */
+      401: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Check username availability
+   * @description Check whether a username can be registered, for validating signup forms as the user types.
+   */
+  "get-v1-usernames-by-name-available": {
+    parameters: {
+      path: {
+        /** @description Username to check */
+        name: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["UsernameAvailability"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * List all users
+   * @description Get a page of active users, or of all users with `include_inactive=true`, in ID order, which is oldest first unless ID_GENERATOR=uuidv4 makes IDs random. `X-Total-Count` and `Link` headers describe the other pages. `include=post_count` adds how many posts each user wrote.
+   */
+  "get-v1-users": {
+    parameters: {
+      query?: {
+        /**
+         * @description Extras to add to each user, comma-separated: post_count, the number of posts they wrote; posts, their posts, oldest first; posts.comments, their posts with the published comments on them
+         * @example [
+         *   "posts.comments"
+         * ]
+         */
+        include?: string[];
+        /**
+         * @description X-Time-Budget, for clients that can't set headers; the header wins if both are set
+         * @example 250
+         */
+        time_budget?: number;
+        /** @description Page number, starting at 1, of the users in ID order: oldest first, unless ID_GENERATOR=uuidv4 makes IDs random */
+        page?: number;
+        /** @description Users per page */
+        per_page?: number;
+        /**
+         * @description Start after the user with this ID, the last of the previous page, instead of at page; unlike page numbers, it neither skips nor repeats users when others are deleted or created between requests. next.after gives it
+         * @example 20240101120000
+         */
+        after?: string;
+        /** @description Also list users that are not active */
+        include_inactive?: boolean;
+        /**
+         * @description Only list users carrying this tag; repeat to require several
+         * @example [
+         *   "beta"
+         * ]
+         */
+        tag?: string[];
+        /**
+         * @description Only list users not seen since this long ago (e.g. 30d, 2w, 12h) or since an RFC 3339 timestamp
+         * @example 30d
+         */
+        inactive_since?: string;
+      };
+      header?: {
+        /**
+         * @description Milliseconds the request may take, counted from when the server got it. Once they are spent, the response has the results found so far, with partial set to true
+         * @example 250
+         */
+        "X-Time-Budget"?: number;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        headers: {
+          Link?: string;
+          "X-Total-Count"?: number;
+        };
+        content: {
+          "application/json": components["schemas"]["UsersListResponse"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Create a new user
+   * @description Create a new user with name and email. When the server has CAPTCHA_PROVIDER set, the request needs an `X-Captcha-Token`.
+   */
+  "post-v1-users": {
+    parameters: {
+      header?: {
+        /** @description Token from solving the site's CAPTCHA; required when the server has CAPTCHA_PROVIDER set */
+        "X-Captcha-Token"?: string;
+      };
+    };
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["CreateUserRequest"];
+      };
+    };
+    responses: {
+      /** @description Created */
+      201: {
+        headers: {
+          /** @description true if the response is that of an identical request made just before, which this one duplicated */
+          "X-Deduplicated"?: "true";
+        };
+        content: {
+          "application/json": components["schemas"]["User"];
+        };
+      };
+      /** @description Bad Request */
+      400: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Forbidden */
+      403: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Conflict */
+      409: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Service Unavailable */
+      503: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Update several users
+   * @description Apply up to 100 updates in order, each `changes` being what put-v1-users-by-id takes. Every entry is validated and applied on its own, and `results` reports each one's outcome with the status and error the single update would have answered, so one bad entry doesn't fail the rest. The body may be sent gzipped, with `Content-Encoding: gzip`.
+   */
+  "patch-v1-users-batch": {
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["BatchUpdateRequest"];
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["BatchUpdateResponse"];
+        };
+      };
+      /** @description Bad Request */
+      400: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Request Entity Too Large */
+      413: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unsupported Media Type */
+      415: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Poll for user changes
+   * @description A long-polling change feed for clients that can't use WebSockets or server-sent events. Returns the changes after `since` in order, waiting up to `wait` for one if there are none yet; pass `next_since` as `since` on the next call. Start with `since=-1`. A 410 means changes were missed, after a restart or the retention policy pruned them: list the users again and start over.
+   */
+  "get-v1-users-changes": {
+    parameters: {
+      query?: {
+        /** @description Sequence number of the last change seen; only later ones are returned. -1, the default, starts from the latest change without returning it */
+        since?: number;
+        /**
+         * @description How long to wait, up to 30s, for a change when there is none yet. Without it the request returns at once
+         * @example 30s
+         */
+        wait?: string;
+        /** @description Maximum number of changes to return */
+        limit?: number;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["UserChanges"];
+        };
+      };
+      /** @description Gone */
+      410: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Get users by IDs
+   * @description Fetch up to 100 users in one call, in request order. IDs without a user come back with `found: false` instead of failing the request.
+   */
+  "post-v1-users-lookup": {
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["LookupUsersRequest"];
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["LookupUsersResponse"];
+        };
+      };
+      /** @description Bad Request */
+      400: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Search users by prefix
+   * @description Typeahead search over active users' names and emails. Returns only the fields an autocomplete needs, best match first.
+   */
+  "get-v1-users-search": {
+    parameters: {
+      query: {
+        /**
+         * @description X-Time-Budget, for clients that can't set headers; the header wins if both are set
+         * @example 250
+         */
+        time_budget?: number;
+        /**
+         * @description Prefix to match against names and emails
+         * @example ro
+         */
+        q: string;
+        /** @description Maximum number of results */
+        limit?: number;
+      };
+      header?: {
+        /**
+         * @description Milliseconds the request may take, counted from when the server got it. Once they are spent, the response has the results found so far, with partial set to true
+         * @example 250
+         */
+        "X-Time-Budget"?: number;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["SearchUsersResponse"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Stream all users
+   * @description Stream every user get-v1-users would list, in ID order, like its pages, and without them, as newline-delimited JSON: one user per line, as get-v1-users-by-id returns them, each flushed as it is written. For bulk consumers that would rather not page through millions of users or hold them all in memory. Takes the filters of get-v1-users, `metadata.<key>=<value>` included. Once the stream has started, a failure can only cut it short, so count the lines if completeness matters.
+   */
+  "get-v1-users-stream": {
+    parameters: {
+      query?: {
+        /** @description Also stream users that are not active */
+        include_inactive?: boolean;
+        /**
+         * @description Only stream users carrying this tag; repeat to require several
+         * @example [
+         *   "beta"
+         * ]
+         */
+        tag?: string[];
+        /**
+         * @description Only stream users not seen since this long ago (e.g. 30d, 2w, 12h) or since an RFC 3339 timestamp
+         * @example 30d
+         */
+        inactive_since?: string;
+      };
+    };
+    responses: {
+      /** @description One user per line */
+      200: {
+        content: {
+          "application/x-ndjson": string;
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Get user by ID
+   * @description Get a user by their ID. `include=post_count` adds how many posts they wrote.
+   */
+  "get-v1-users-by-id": {
+    parameters: {
+      query?: {
+        /**
+         * @description Extras to add to each user, comma-separated: post_count, the number of posts they wrote; posts, their posts, oldest first; posts.comments, their posts with the published comments on them
+         * @example [
+         *   "posts.comments"
+         * ]
+         */
+        include?: string[];
+      };
+      path: {
+        /**
+         * @description User ID
+         * @example 20240101120000
+         */
+        id: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["User"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Update user by ID
+   * @description Update a user's username, name, email, phone and/or metadata by their ID. Fields left out keep their values; an empty phone clears it, and metadata replaces the stored metadata wholesale.
+   */
+  "put-v1-users-by-id": {
+    parameters: {
+      path: {
+        /**
+         * @description User ID
+         * @example 20240101120000
+         */
+        id: string;
+      };
+    };
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["UpdateUserRequest"];
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["User"];
+        };
+      };
+      /** @description Bad Request */
+      400: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Conflict */
+      409: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Delete user by ID
+   * @description Delete a user, their preferences, posts and comments by their ID. With `mode=erase`, for GDPR erasure requests, the audit log entries about them are anonymized too, and the erasure is audited without naming them.
+   */
+  "delete-v1-users-by-id": {
+    parameters: {
+      query?: {
+        /** @description erase also anonymizes the audit log entries about the user, for GDPR erasure requests */
+        mode?: "delete" | "erase";
+      };
+      path: {
+        /**
+         * @description User ID
+         * @example 20240101120000
+         */
+        id: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["DeleteUserResponse"];
+        };
+      };
+      /** @description User not found */
+      404: {
+        content: {
+          "application/json": components["schemas"]["DeleteUserResponse"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Activate user
+   * @description Activate an invited or suspended user. Activating an active user is a no-op.
+   */
+  "post-v1-users-by-id-activate": {
+    parameters: {
+      path: {
+        /**
+         * @description User ID
+         * @example 20240101120000
+         */
+        id: string;
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["User"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Conflict */
+      409: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
     };
   };
-  responses: never;
-  parameters: never;
-  requestBodies: never;
-  headers: never;
-  pathItems: never;
-}
-
-export type $defs = Record<string, never>;
-
-export type external = Record<string, never>;
-
-export interface operations {
-
-  /** Get health */
-  "get-health": {
+  /**
+   * Export a user's data
+   * @description Get everything stored about a user, for GDPR access requests: the user record, their saved preferences, their posts and comments and the audit log entries about them. The export itself is audited.
+   */
+  "get-v1-users-by-id-data-export": {
+    parameters: {
+      path: {
+        /**
+         * @description User ID
+         * @example 20240101120000
+         */
+        id: string;
+      };
+    };
     responses: {
-      /** @description No Content */
-      204: {
-        content: never;
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["UserDataExport"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
       };
       /** @description Error */
       default: {
@@ -131,15 +7059,50 @@ export interface operations {
       };
     };
   };
-  /** Get hello */
-  "get-hello": {
+  /**
+   * Deactivate user
+   * @description Suspend an active user, hiding them from the default listing. Deactivating a suspended user is a no-op.
+   */
+  "post-v1-users-by-id-deactivate": {
+    parameters: {
+      path: {
+        /**
+         * @description User ID
+         * @example 20240101120000
+         */
+        id: string;
+      };
+    };
     responses: {
-      /** @description No Content */
-      204: {
-        headers: {
-          Message?: string;
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["User"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Conflict */
+      409: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
         };
-        content: never;
       };
       /** @description Error */
       default: {
@@ -149,18 +7112,59 @@ export interface operations {
       };
     };
   };
-  /** Get v1 users */
-  "get-v1-users": {
+  /**
+   * List a user's posts
+   * @description Get a page of the posts a user wrote, in ID order, which is oldest first unless ID_GENERATOR=uuidv4 makes IDs random. `X-Total-Count` and `Link` headers describe the other pages.
+   */
+  "get-v1-users-by-id-posts": {
+    parameters: {
+      query?: {
+        /** @description Page number, starting at 1 */
+        page?: number;
+        /** @description Entries per page */
+        per_page?: number;
+        /**
+         * @description Start after the entry with this ID, the last of the previous page, instead of at page; unlike page numbers, it neither skips nor repeats entries when others are deleted or created between requests. next.after gives it
+         * @example 20240101120500
+         */
+        after?: string;
+      };
+      path: {
+        /**
+         * @description User ID
+         * @example 20240101120000
+         */
+        id: string;
+      };
+    };
     responses: {
-      /** @description No Content */
-      204: {
+      /** @description OK */
+      200: {
         headers: {
-          Email?: string;
-          ID?: string;
-          Name?: string;
-          Users?: components["schemas"]["User"];
+          Link?: string;
+          "X-Total-Count"?: number;
+        };
+        content: {
+          "application/json": components["schemas"]["PostsListResponse"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
         };
-        content: never;
       };
       /** @description Error */
       default: {
@@ -170,22 +7174,55 @@ export interface operations {
       };
     };
   };
-  /** Post v1 users */
-  "post-v1-users": {
+  /**
+   * Write a post for a user
+   * @description Create a post written by the user. The same as post-v1-posts with the user as `author_id`.
+   */
+  "post-v1-users-by-id-posts": {
+    parameters: {
+      path: {
+        /**
+         * @description User ID
+         * @example 20240101120000
+         */
+        id: string;
+      };
+    };
     requestBody: {
       content: {
-        "application/json": components["schemas"]["CreateUserRequest"];
+        "application/json": components["schemas"]["PostRequest"];
       };
     };
     responses: {
-      /** @description No Content */
-      204: {
-        headers: {
-          Email?: string;
-          ID?: string;
-          Name?: string;
+      /** @description Created */
+      201: {
+        content: {
+          "application/json": components["schemas"]["Post"];
+        };
+      };
+      /** @description Bad Request */
+      400: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
         };
-        content: never;
       };
       /** @description Error */
       default: {
@@ -195,22 +7232,44 @@ export interface operations {
       };
     };
   };
-  /** Get v1 users by ID */
-  "get-v1-users-by-id": {
+  /**
+   * Get user preferences
+   * @description Get a user's preferences. Users who never saved any get the defaults.
+   */
+  "get-v1-users-by-id-preferences": {
     parameters: {
       path: {
+        /**
+         * @description User ID
+         * @example 20240101120000
+         */
         id: string;
       };
     };
     responses: {
-      /** @description No Content */
-      204: {
-        headers: {
-          Email?: string;
-          ID?: string;
-          Name?: string;
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["UserPreferences"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
         };
-        content: never;
       };
       /** @description Error */
       default: {
@@ -220,27 +7279,55 @@ export interface operations {
       };
     };
   };
-  /** Put v1 users by ID */
-  "put-v1-users-by-id": {
+  /**
+   * Replace user preferences
+   * @description Replace a user's preferences. Omitted fields are reset to their defaults.
+   */
+  "put-v1-users-by-id-preferences": {
     parameters: {
       path: {
+        /**
+         * @description User ID
+         * @example 20240101120000
+         */
         id: string;
       };
     };
     requestBody: {
       content: {
-        "application/json": components["schemas"]["UpdateUserRequest"];
+        "application/json": components["schemas"]["UserPreferences"];
       };
     };
     responses: {
-      /** @description No Content */
-      204: {
-        headers: {
-          Email?: string;
-          ID?: string;
-          Name?: string;
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["UserPreferences"];
+        };
+      };
+      /** @description Bad Request */
+      400: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
         };
-        content: never;
       };
       /** @description Error */
       default: {
@@ -250,20 +7337,143 @@ export interface operations {
       };
     };
   };
-  /** Delete v1 users by ID */
-  "delete-v1-users-by-id": {
+  /**
+   * Change user status
+   * @description Move a user to another lifecycle status. Invited users can be activated, active and suspended users can be moved between those two, and any user can be deleted; deleted is final.
+   */
+  "post-v1-users-by-id-status": {
     parameters: {
       path: {
+        /**
+         * @description User ID
+         * @example 20240101120000
+         */
         id: string;
       };
     };
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["UserStatusRequest"];
+      };
+    };
     responses: {
-      /** @description No Content */
-      204: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["User"];
+        };
+      };
+      /** @description Bad Request */
+      400: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Conflict */
+      409: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Replace user tags
+   * @description Replace the set of tags on a user. An empty list removes all tags.
+   */
+  "put-v1-users-by-id-tags": {
+    parameters: {
+      path: {
+        /** @description User ID */
+        id: string;
+      };
+    };
+    requestBody: {
+      content: {
+        "application/json": components["schemas"]["UserTags"];
+      };
+    };
+    responses: {
+      /** @description OK */
+      200: {
+        content: {
+          "application/json": components["schemas"]["UserTags"];
+        };
+      };
+      /** @description Bad Request */
+      400: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Not Found */
+      404: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Unprocessable Entity */
+      422: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Internal Server Error */
+      500: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+      /** @description Error */
+      default: {
+        content: {
+          "application/problem+json": components["schemas"]["ErrorModel"];
+        };
+      };
+    };
+  };
+  /**
+   * Get build version
+   * @description Report the version, git commit and build date of the running server, and the Go version it was built with.
+   */
+  "get-version": {
+    responses: {
+      /** @description OK */
+      200: {
         headers: {
-          Deleted?: boolean;
+          /**
+           * @description How long the response may be cached, and by whom
+           * @example public, max-age=60
+           */
+          "Cache-Control"?: string;
+        };
+        content: {
+          "application/json": components["schemas"]["VersionResponse"];
         };
-        content: never;
       };
       /** @description Error */
       default: {
@@ -0,0 +1,45 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// ListAPIKeys calls GET /v1/api-keys. The client needs a user token; API
+// keys can't manage API keys.
+func (c *Client) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	var out struct {
+		APIKeys []APIKey `json:"api_keys"`
+	}
+	if _, err := c.do(ctx, http.MethodGet, "/v1/api-keys", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.APIKeys, nil
+}
+
+// CreateAPIKey calls POST /v1/api-keys. The client needs a user token.
+func (c *Client) CreateAPIKey(ctx context.Context, req CreateAPIKeyRequest) (*NewAPIKey, error) {
+	var out NewAPIKey
+	if _, err := c.do(ctx, http.MethodPost, "/v1/api-keys", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RotateAPIKey calls POST /v1/api-keys/{id}/rotate. The client needs a user
+// token.
+func (c *Client) RotateAPIKey(ctx context.Context, id string) (*NewAPIKey, error) {
+	var out NewAPIKey
+	if _, err := c.do(ctx, http.MethodPost, "/v1/api-keys/"+url.PathEscape(id)+"/rotate", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RevokeAPIKey calls DELETE /v1/api-keys/{id}. The client needs a user
+// token.
+func (c *Client) RevokeAPIKey(ctx context.Context, id string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/v1/api-keys/"+url.PathEscape(id), nil, nil, nil)
+	return err
+}
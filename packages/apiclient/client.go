@@ -0,0 +1,193 @@
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client calls the API. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	header     http.Header
+	retries    int
+	backoff    time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests. The default is a
+// client with a 30 second timeout.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithHeader adds a header, such as Authorization, to every request.
+func WithHeader(key, value string) Option {
+	return func(c *Client) { c.header.Add(key, value) }
+}
+
+// WithRetries sets how many times a request is retried after a network error,
+// a 429 or a 5xx, waiting about backoff, then twice that, and so on. Only
+// idempotent requests (GET, PUT, DELETE) are retried. The default is 2
+// retries starting at 100ms; 0 disables retrying.
+func WithRetries(n int, backoff time.Duration) Option {
+	return func(c *Client) { c.retries, c.backoff = n, backoff }
+}
+
+// New returns a client for the API at baseURL, e.g. "http://localhost:8080".
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		header:     http.Header{},
+		retries:    2,
+		backoff:    100 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// do sends a request with body encoded as JSON (if non-nil) and decodes a
+// successful response into out (if non-nil). Non-2xx responses become *Error.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) (*http.Response, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("apiclient: encode request: %w", err)
+		}
+	}
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	attempts := 1
+	if method == http.MethodGet || method == http.MethodPut || method == http.MethodDelete {
+		attempts += c.retries
+	}
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := c.wait(ctx, attempt, lastErr); err != nil {
+				return nil, err
+			}
+		}
+		resp, err := c.send(ctx, method, u, payload)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			defer resp.Body.Close()
+			if out != nil && resp.StatusCode != http.StatusNoContent {
+				if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+					return resp, fmt.Errorf("apiclient: decode %s %s response: %w", method, path, err)
+				}
+			}
+			return resp, nil
+		}
+		lastErr = newError(resp)
+		if !retryable(resp.StatusCode) {
+			return resp, lastErr
+		}
+	}
+	return nil, lastErr
+}
+
+type captchaTokenKey struct{}
+
+// WithCaptchaToken returns a context whose requests carry token, from the
+// site's CAPTCHA widget, as X-Captcha-Token. CreateUser and Login need one
+// when the server has CAPTCHA_PROVIDER set.
+func WithCaptchaToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, captchaTokenKey{}, token)
+}
+
+type correlationIDKey struct{}
+
+// WithCorrelationID returns a context whose requests carry id as
+// X-Correlation-ID, so the server's logs, audit entries and change feed tie
+// them, and the events they cause, to one action.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+func (c *Client) send(ctx context.Context, method, u string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, fmt.Errorf("apiclient: %w", err)
+	}
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+	if token, ok := ctx.Value(captchaTokenKey{}).(string); ok {
+		req.Header.Set("X-Captcha-Token", token)
+	}
+	if id, ok := ctx.Value(correlationIDKey{}).(string); ok {
+		req.Header.Set("X-Correlation-ID", id)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.httpClient.Do(req)
+}
+
+// wait sleeps before a retry, honoring a Retry-After from a 429 or 503 and
+// otherwise backing off exponentially with jitter.
+func (c *Client) wait(ctx context.Context, attempt int, lastErr error) error {
+	d := c.backoff << (attempt - 1)
+	d = d/2 + rand.N(d/2+1)
+	var apiErr *Error
+	if errors.As(lastErr, &apiErr) && apiErr.RetryAfter > 0 {
+		d = apiErr.RetryAfter
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+func retryAfter(h http.Header) time.Duration {
+	s := h.Get("Retry-After")
+	if s == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(s); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(s); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
@@ -0,0 +1,129 @@
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// serve answers the i-th request, counting from 0, with responses[i], and
+// the rest with the last of them. It returns a client for it that retries
+// quickly, and the number of requests it got.
+func serve(t *testing.T, responses ...func(w http.ResponseWriter)) (*Client, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := int(calls.Add(1)) - 1
+		responses[min(i, len(responses)-1)](w)
+	}))
+	t.Cleanup(srv.Close)
+	return New(srv.URL, WithRetries(2, time.Millisecond)), &calls
+}
+
+func status(code int, header ...string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		for i := 0; i+1 < len(header); i += 2 {
+			w.Header().Set(header[i], header[i+1])
+		}
+		w.WriteHeader(code)
+	}
+}
+
+func userJSON(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"id":"20240101120000","name":"Ada Lovelace"}`))
+}
+
+func TestRetriesIdempotentRequests(t *testing.T) {
+	c, calls := serve(t, status(http.StatusTooManyRequests), status(http.StatusServiceUnavailable), userJSON)
+	u, err := c.GetUser(context.Background(), "20240101120000")
+	if err != nil || u.Name != "Ada Lovelace" {
+		t.Fatalf("GetUser: %+v, %v", u, err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("%d requests, want 3", n)
+	}
+
+	// Past the retries, the last error is returned.
+	c, calls = serve(t, status(http.StatusBadGateway))
+	_, err = c.GetUser(context.Background(), "20240101120000")
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusBadGateway || calls.Load() != 3 {
+		t.Errorf("after %d requests: %v, want the 502 after 3", calls.Load(), err)
+	}
+
+	// A client error isn't retried.
+	c, calls = serve(t, status(http.StatusNotFound), userJSON)
+	if _, err := c.GetUser(context.Background(), "missing"); err == nil || calls.Load() != 1 {
+		t.Errorf("after %d requests: %v, want the 404 after 1", calls.Load(), err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	c, _ := serve(t, status(http.StatusTooManyRequests, "Retry-After", "1"), userJSON)
+	start := time.Now()
+	if _, err := c.GetUser(context.Background(), "20240101120000"); err != nil {
+		t.Fatal(err)
+	}
+	if took := time.Since(start); took < time.Second {
+		t.Errorf("retried after %s, want the second Retry-After asked for", took)
+	}
+
+	// A context ending during the wait ends it.
+	c, _ = serve(t, status(http.StatusServiceUnavailable, "Retry-After", "60"), userJSON)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetUser(ctx, "20240101120000"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetUser: %v, want the deadline", err)
+	}
+}
+
+func TestNoRetryForPost(t *testing.T) {
+	c, calls := serve(t, status(http.StatusServiceUnavailable), userJSON)
+	_, err := c.CreateUser(context.Background(), CreateUserRequest{Name: "Ada Lovelace", Email: "ada@example.com"})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusServiceUnavailable {
+		t.Errorf("CreateUser: %v, want the 503", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("%d requests, want the POST sent once", n)
+	}
+}
+
+func TestErrorDecoding(t *testing.T) {
+	c, _ := serve(t, func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"code":"VALIDATION_FAILED","status":422,"title":"Unprocessable Entity","detail":"validation failed","errors":[{"field":"email","code":"format","message":"expected an email","location":"body.email","value":"ada"}]}`))
+	})
+	_, err := c.CreateUser(context.Background(), CreateUserRequest{Name: "Ada Lovelace", Email: "ada"})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("CreateUser: %v, want an *Error", err)
+	}
+	if apiErr.Code != "VALIDATION_FAILED" || apiErr.Status != http.StatusUnprocessableEntity || len(apiErr.Errors) != 1 || apiErr.Errors[0].Field != "email" || apiErr.Errors[0].Code != "format" {
+		t.Errorf("error %+v, want the problem details", apiErr)
+	}
+	if want := "apiclient: 422 validation failed; body.email: expected an email"; err.Error() != want {
+		t.Errorf("message %q, want %q", err.Error(), want)
+	}
+
+	// A body that isn't a problem document is kept as it is.
+	c, _ = serve(t, func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("upstream unavailable"))
+	})
+	c.retries = 0
+	_, err = c.GetUser(context.Background(), "20240101120000")
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusBadGateway || string(apiErr.Body) != "upstream unavailable" {
+		t.Errorf("error %+v, want the 502 with its body", apiErr)
+	}
+	if !strings.Contains(err.Error(), "502 Bad Gateway") {
+		t.Errorf("message %q, want the status text", err.Error())
+	}
+}
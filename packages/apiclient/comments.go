@@ -0,0 +1,85 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+func commentsPath(postID string) string {
+	return "/v1/posts/" + url.PathEscape(postID) + "/comments"
+}
+
+// CreateComment calls POST /v1/posts/{id}/comments.
+func (c *Client) CreateComment(ctx context.Context, postID string, req CreateCommentRequest) (*Comment, error) {
+	var out Comment
+	if _, err := c.do(ctx, http.MethodPost, commentsPath(postID), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListComments calls GET /v1/posts/{id}/comments and returns one page,
+// oldest first.
+func (c *Client) ListComments(ctx context.Context, postID string, opts ListCommentsOptions) (*CommentsPage, error) {
+	q := url.Values{}
+	if opts.Page > 0 {
+		q.Set("page", strconv.Itoa(opts.Page))
+	}
+	if opts.PerPage > 0 {
+		q.Set("per_page", strconv.Itoa(opts.PerPage))
+	}
+	if opts.After != "" {
+		q.Set("after", opts.After)
+	}
+	if opts.ParentID != "" {
+		q.Set("parent_id", opts.ParentID)
+	}
+	if opts.IncludeHidden {
+		q.Set("include_hidden", "true")
+	}
+	var out CommentsPage
+	resp, err := c.do(ctx, http.MethodGet, commentsPath(postID), q, nil, &out)
+	if err != nil {
+		return nil, err
+	}
+	out.Total, _ = strconv.Atoi(resp.Header.Get("X-Total-Count"))
+	return &out, nil
+}
+
+// GetComment calls GET /v1/posts/{id}/comments/{commentID}.
+func (c *Client) GetComment(ctx context.Context, postID, id string) (*Comment, error) {
+	var out Comment
+	if _, err := c.do(ctx, http.MethodGet, commentsPath(postID)+"/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateComment calls PUT /v1/posts/{id}/comments/{commentID}.
+func (c *Client) UpdateComment(ctx context.Context, postID, id, body string) (*Comment, error) {
+	var out Comment
+	req := map[string]string{"body": body}
+	if _, err := c.do(ctx, http.MethodPut, commentsPath(postID)+"/"+url.PathEscape(id), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetCommentStatus calls POST /v1/posts/{id}/comments/{commentID}/status.
+// The client needs the admin token.
+func (c *Client) SetCommentStatus(ctx context.Context, postID, id string, status CommentStatus) (*Comment, error) {
+	var out Comment
+	req := map[string]CommentStatus{"status": status}
+	if _, err := c.do(ctx, http.MethodPost, commentsPath(postID)+"/"+url.PathEscape(id)+"/status", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteComment calls DELETE /v1/posts/{id}/comments/{commentID}.
+func (c *Client) DeleteComment(ctx context.Context, postID, id string) error {
+	_, err := c.do(ctx, http.MethodDelete, commentsPath(postID)+"/"+url.PathEscape(id), nil, nil, nil)
+	return err
+}
@@ -0,0 +1,14 @@
+// Package apiclient is a typed Go client for the Monorepo API.
+//
+// It is maintained by hand in lockstep with the OpenAPI contract in
+// packages/api/src/contracts/v1.json: every operation there has a method
+// here, and the types mirror the contract's schemas. When an operation is
+// added or changed in backend/api, update this package in the same change.
+//
+//	c := apiclient.New("http://localhost:8080")
+//	user, err := c.CreateUser(ctx, apiclient.CreateUserRequest{Name: "Ro", Email: "ro@example.com"})
+//	var apiErr *apiclient.Error
+//	if errors.As(err, &apiErr) && apiErr.Code == "USERNAME_TAKEN" {
+//		// ...
+//	}
+package apiclient
@@ -0,0 +1,70 @@
+package apiclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Error is a non-2xx response. The API reports problems as RFC 9457 problem
+// details, which are decoded into the fields below.
+type Error struct {
+	// Code is the stable, machine-readable error code, such as
+	// "USER_NOT_FOUND"; the contract's ErrorCode schema lists them all.
+	Code   string        `json:"code"`
+	Status int           `json:"status"`
+	Title  string        `json:"title"`
+	Detail string        `json:"detail"`
+	Errors []ErrorDetail `json:"errors"`
+
+	// RetryAfter is the server's Retry-After hint, if any.
+	RetryAfter time.Duration `json:"-"`
+	// Body holds the raw response when it wasn't a problem document.
+	Body []byte `json:"-"`
+}
+
+// ErrorDetail describes one problem, typically a validation failure of a
+// single field. A 422 lists every problem with the request, at most one per
+// field.
+type ErrorDetail struct {
+	// Field is the path of the field within its location, such as
+	// "tags[0]", and empty for the body as a whole.
+	Field string `json:"field"`
+	// Code says what is wrong with the field, such as "required" or
+	// "format"; unlike Message it is not translated.
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	Location string `json:"location"`
+	Value    any    `json:"value"`
+}
+
+func (e *Error) Error() string {
+	msg := e.Detail
+	if msg == "" {
+		msg = e.Title
+	}
+	if msg == "" {
+		msg = http.StatusText(e.Status)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "apiclient: %d %s", e.Status, msg)
+	for _, d := range e.Errors {
+		fmt.Fprintf(&b, "; %s: %s", d.Location, d.Message)
+	}
+	return b.String()
+}
+
+// newError reads and closes resp's body.
+func newError(resp *http.Response) *Error {
+	defer resp.Body.Close()
+	e := &Error{Status: resp.StatusCode, RetryAfter: retryAfter(resp.Header)}
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err := json.Unmarshal(b, e); err != nil || e.Status == 0 {
+		e.Status = resp.StatusCode
+		e.Body = b
+	}
+	return e
+}
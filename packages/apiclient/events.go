@@ -0,0 +1,70 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ListEvents calls GET /v1/events, returning up to limit events from
+// fromSeq on; zero limit leaves the server's default. The client needs the
+// admin token.
+func (c *Client) ListEvents(ctx context.Context, fromSeq int64, limit int) (*EventsPage, error) {
+	q := url.Values{"from_seq": {strconv.FormatInt(fromSeq, 10)}}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	var out EventsPage
+	if _, err := c.do(ctx, http.MethodGet, "/v1/events", q, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ReplayEvents calls POST /admin/events/replay. Without a consumer to
+// replay to, it returns an *Error with Code REPLAY_NOT_CONFIGURED. The
+// client needs the admin token.
+func (c *Client) ReplayEvents(ctx context.Context, req ReplayEventsRequest) (*EventReplay, error) {
+	var out EventReplay
+	if _, err := c.do(ctx, http.MethodPost, "/admin/events/replay", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListDeadLetters calls GET /admin/dead-letters, optionally only those of
+// kind and status. The client needs the admin token.
+func (c *Client) ListDeadLetters(ctx context.Context, kind, status string) ([]DeadLetter, error) {
+	q := url.Values{}
+	if kind != "" {
+		q.Set("kind", kind)
+	}
+	if status != "" {
+		q.Set("status", status)
+	}
+	var out struct {
+		DeadLetters []DeadLetter `json:"dead_letters"`
+	}
+	if _, err := c.do(ctx, http.MethodGet, "/admin/dead-letters", q, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.DeadLetters, nil
+}
+
+// RequeueDeadLetter calls POST /admin/dead-letters/{id}/requeue. The
+// client needs the admin token.
+func (c *Client) RequeueDeadLetter(ctx context.Context, id string) (*DeadLetter, error) {
+	var out DeadLetter
+	if _, err := c.do(ctx, http.MethodPost, "/admin/dead-letters/"+url.PathEscape(id)+"/requeue", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteDeadLetter calls DELETE /admin/dead-letters/{id}. The client needs
+// the admin token.
+func (c *Client) DeleteDeadLetter(ctx context.Context, id string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/admin/dead-letters/"+url.PathEscape(id), nil, nil, nil)
+	return err
+}
@@ -0,0 +1,46 @@
+package apiclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// CreateExport calls POST /v1/exports, which starts building the export in
+// the background; poll GetExport until it completed. The client needs the
+// admin token.
+func (c *Client) CreateExport(ctx context.Context, req CreateExportRequest) (*Export, error) {
+	var out Export
+	if _, err := c.do(ctx, http.MethodPost, "/v1/exports", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetExport calls GET /v1/exports/{id}. The client needs the admin token.
+func (c *Client) GetExport(ctx context.Context, id string) (*Export, error) {
+	var out Export
+	if _, err := c.do(ctx, http.MethodGet, "/v1/exports/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DownloadExport fetches the file behind a completed export's DownloadURL,
+// a signed link that needs no credentials. The caller must close it.
+func (c *Client) DownloadExport(ctx context.Context, exp *Export) (io.ReadCloser, error) {
+	if exp.DownloadURL == "" {
+		return nil, fmt.Errorf("apiclient: export %s is %s, not completed", exp.ID, exp.Status)
+	}
+	resp, err := c.send(ctx, http.MethodGet, c.baseURL+exp.DownloadURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, newError(resp)
+	}
+	return resp.Body, nil
+}
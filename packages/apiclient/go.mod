@@ -0,0 +1,3 @@
+module github.com/rohanchauhan02/monorepo-demo/packages/apiclient
+
+go 1.23.4
@@ -0,0 +1,50 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// CreateInvitation calls POST /v1/invitations. The client needs the admin
+// token.
+func (c *Client) CreateInvitation(ctx context.Context, req CreateInvitationRequest) (*NewInvitation, error) {
+	var out NewInvitation
+	if _, err := c.do(ctx, http.MethodPost, "/v1/invitations", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListInvitations calls GET /v1/invitations, listing only those with status
+// unless it is empty. The client needs the admin token.
+func (c *Client) ListInvitations(ctx context.Context, status InvitationStatus) ([]Invitation, error) {
+	q := url.Values{}
+	if status != "" {
+		q.Set("status", string(status))
+	}
+	var out struct {
+		Invitations []Invitation `json:"invitations"`
+	}
+	if _, err := c.do(ctx, http.MethodGet, "/v1/invitations", q, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Invitations, nil
+}
+
+// RevokeInvitation calls DELETE /v1/invitations/{id}. The client needs the
+// admin token.
+func (c *Client) RevokeInvitation(ctx context.Context, id string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/v1/invitations/"+url.PathEscape(id), nil, nil, nil)
+	return err
+}
+
+// AcceptInvitation calls POST /v1/invitations/{token}/accept and returns the
+// user it created.
+func (c *Client) AcceptInvitation(ctx context.Context, token string, req AcceptInvitationRequest) (*User, error) {
+	var out User
+	if _, err := c.do(ctx, http.MethodPost, "/v1/invitations/"+url.PathEscape(token)+"/accept", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
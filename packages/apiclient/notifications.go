@@ -0,0 +1,40 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ListNotifications calls GET /v1/notifications and returns one page,
+// newest first. The client needs a user token, as for Me.
+func (c *Client) ListNotifications(ctx context.Context, opts ListNotificationsOptions) (*NotificationsPage, error) {
+	q := url.Values{}
+	if opts.Page > 0 {
+		q.Set("page", strconv.Itoa(opts.Page))
+	}
+	if opts.PerPage > 0 {
+		q.Set("per_page", strconv.Itoa(opts.PerPage))
+	}
+	if opts.Unread {
+		q.Set("unread", "true")
+	}
+	var out NotificationsPage
+	resp, err := c.do(ctx, http.MethodGet, "/v1/notifications", q, nil, &out)
+	if err != nil {
+		return nil, err
+	}
+	out.Total, _ = strconv.Atoi(resp.Header.Get("X-Total-Count"))
+	return &out, nil
+}
+
+// MarkNotificationRead calls POST /v1/notifications/{id}/read. The client
+// needs a user token.
+func (c *Client) MarkNotificationRead(ctx context.Context, id string) (*Notification, error) {
+	var out Notification
+	if _, err := c.do(ctx, http.MethodPost, "/v1/notifications/"+url.PathEscape(id)+"/read", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
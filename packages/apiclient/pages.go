@@ -0,0 +1,93 @@
+package apiclient
+
+import (
+	"context"
+	"iter"
+)
+
+// each yields every entry of a list from page first on, fetching each
+// page with list as the previous one runs out: after the last entry of the
+// previous one on the lists that take it, so entries deleted or created
+// meanwhile don't make it skip or repeat any, and by number on the others.
+// An error ends the iteration after it is yielded.
+func each[T any](first PageLink, list func(next PageLink) ([]T, Pagination, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		next := first
+		next.Page = max(next.Page, 1)
+		for {
+			items, p, err := list(next)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if !p.HasMore || p.Next == nil {
+				return
+			}
+			next = *p.Next
+		}
+	}
+}
+
+// AllUsers calls GET /v1/users for as many pages as it takes to yield every
+// user opts lists, from opts.Page, or after opts.After, on.
+//
+//	for user, err := range c.AllUsers(ctx, apiclient.ListUsersOptions{Tags: []string{"beta"}}) {
+//		if err != nil {
+//			return err
+//		}
+//		// ...
+//	}
+func (c *Client) AllUsers(ctx context.Context, opts ListUsersOptions) iter.Seq2[*User, error] {
+	return each(PageLink{Page: opts.Page, After: opts.After}, func(next PageLink) ([]*User, Pagination, error) {
+		opts.Page, opts.After = next.Page, next.After
+		out, err := c.ListUsers(ctx, opts)
+		if err != nil {
+			return nil, Pagination{}, err
+		}
+		return out.Users, out.Pagination, nil
+	})
+}
+
+// AllPosts is ListPosts for as many pages as it takes, like AllUsers.
+func (c *Client) AllPosts(ctx context.Context, opts ListPostsOptions) iter.Seq2[*Post, error] {
+	return each(PageLink{Page: opts.Page, After: opts.After}, func(next PageLink) ([]*Post, Pagination, error) {
+		opts.Page, opts.After = next.Page, next.After
+		out, err := c.ListPosts(ctx, opts)
+		if err != nil {
+			return nil, Pagination{}, err
+		}
+		return out.Posts, out.Pagination, nil
+	})
+}
+
+// AllComments is ListComments for as many pages as it takes, like AllUsers.
+func (c *Client) AllComments(ctx context.Context, postID string, opts ListCommentsOptions) iter.Seq2[*Comment, error] {
+	return each(PageLink{Page: opts.Page, After: opts.After}, func(next PageLink) ([]*Comment, Pagination, error) {
+		opts.Page, opts.After = next.Page, next.After
+		out, err := c.ListComments(ctx, postID, opts)
+		if err != nil {
+			return nil, Pagination{}, err
+		}
+		return out.Comments, out.Pagination, nil
+	})
+}
+
+// AllNotifications is ListNotifications for as many pages as it takes, like
+// AllUsers. Notifications arriving meanwhile shift the pages, so one may be
+// yielded twice.
+func (c *Client) AllNotifications(ctx context.Context, opts ListNotificationsOptions) iter.Seq2[*Notification, error] {
+	return each(PageLink{Page: opts.Page}, func(next PageLink) ([]*Notification, Pagination, error) {
+		opts.Page = next.Page
+		out, err := c.ListNotifications(ctx, opts)
+		if err != nil {
+			return nil, Pagination{}, err
+		}
+		return out.Notifications, out.Pagination, nil
+	})
+}
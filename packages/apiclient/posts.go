@@ -0,0 +1,65 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// CreatePost calls POST /v1/posts.
+func (c *Client) CreatePost(ctx context.Context, req CreatePostRequest) (*Post, error) {
+	var out Post
+	if _, err := c.do(ctx, http.MethodPost, "/v1/posts", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListPosts calls GET /v1/posts and returns one page, in ID order.
+func (c *Client) ListPosts(ctx context.Context, opts ListPostsOptions) (*PostsPage, error) {
+	q := url.Values{}
+	if opts.Page > 0 {
+		q.Set("page", strconv.Itoa(opts.Page))
+	}
+	if opts.PerPage > 0 {
+		q.Set("per_page", strconv.Itoa(opts.PerPage))
+	}
+	if opts.After != "" {
+		q.Set("after", opts.After)
+	}
+	if opts.AuthorID != "" {
+		q.Set("author_id", opts.AuthorID)
+	}
+	var out PostsPage
+	resp, err := c.do(ctx, http.MethodGet, "/v1/posts", q, nil, &out)
+	if err != nil {
+		return nil, err
+	}
+	out.Total, _ = strconv.Atoi(resp.Header.Get("X-Total-Count"))
+	return &out, nil
+}
+
+// GetPost calls GET /v1/posts/{id}.
+func (c *Client) GetPost(ctx context.Context, id string) (*Post, error) {
+	var out Post
+	if _, err := c.do(ctx, http.MethodGet, "/v1/posts/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdatePost calls PUT /v1/posts/{id}.
+func (c *Client) UpdatePost(ctx context.Context, id string, req UpdatePostRequest) (*Post, error) {
+	var out Post
+	if _, err := c.do(ctx, http.MethodPut, "/v1/posts/"+url.PathEscape(id), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeletePost calls DELETE /v1/posts/{id}.
+func (c *Client) DeletePost(ctx context.Context, id string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/v1/posts/"+url.PathEscape(id), nil, nil, nil)
+	return err
+}
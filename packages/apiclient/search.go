@@ -0,0 +1,28 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Search calls GET /v1/search and returns the users and posts matching q,
+// best match first. A limit of 0 uses the server default.
+func (c *Client) Search(ctx context.Context, q string, opts SearchOptions) ([]SearchResult, error) {
+	query := url.Values{"q": {q}}
+	if len(opts.Types) > 0 {
+		query.Set("type", strings.Join(opts.Types, ","))
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	var out struct {
+		Results []SearchResult `json:"results"`
+	}
+	if _, err := c.do(ctx, http.MethodGet, "/v1/search", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Results, nil
+}
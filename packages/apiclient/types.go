@@ -0,0 +1,715 @@
+package apiclient
+
+import "time"
+
+// These types mirror the schemas in packages/api/src/contracts/v1.json.
+
+type UserStatus string
+
+const (
+	UserStatusInvited   UserStatus = "invited"
+	UserStatusActive    UserStatus = "active"
+	UserStatusSuspended UserStatus = "suspended"
+	UserStatusDeleted   UserStatus = "deleted"
+)
+
+type Version struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"go_version"`
+}
+
+type ChangelogRelease struct {
+	Version string            `json:"version"`
+	Date    string            `json:"date,omitempty"`
+	Changes []ChangelogChange `json:"changes"`
+}
+
+type ChangelogChange struct {
+	Kind        string `json:"kind"`
+	Operation   string `json:"operation,omitempty"`
+	Description string `json:"description"`
+}
+
+type User struct {
+	ID          string         `json:"id"`
+	Username    string         `json:"username,omitempty"`
+	Name        string         `json:"name"`
+	Email       string         `json:"email"`
+	EmailRaw    string         `json:"email_raw,omitempty"`
+	Phone       string         `json:"phone,omitempty"`
+	Status      UserStatus     `json:"status"`
+	Active      bool           `json:"active"`
+	LastLoginAt *time.Time     `json:"last_login_at,omitempty"`
+	LastSeenAt  *time.Time     `json:"last_seen_at,omitempty"`
+	DeletedAt   *time.Time     `json:"deleted_at,omitempty"`
+	Metadata    map[string]any `json:"metadata,omitempty"`
+	Tags        []string       `json:"tags,omitempty"`
+	// PostCount is only set when asked for, with ListUsersOptions.PostCount.
+	PostCount *int `json:"post_count,omitempty"`
+	// Posts is only set when asked for, with ListUsersOptions.Include.
+	Posts []*Post `json:"posts,omitempty"`
+}
+
+type CreateUserRequest struct {
+	Username string `json:"username,omitempty"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone,omitempty"`
+	// Password lets the user log in; without one they can't.
+	Password string         `json:"password,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// UpdateUserRequest changes only the fields that are set.
+type UpdateUserRequest struct {
+	Username *string        `json:"username,omitempty"`
+	Name     *string        `json:"name,omitempty"`
+	Email    *string        `json:"email,omitempty"`
+	Phone    *string        `json:"phone,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// BatchUpdate is one entry of BatchUpdateUsers.
+type BatchUpdate struct {
+	ID      string            `json:"id"`
+	Changes UpdateUserRequest `json:"changes"`
+}
+
+// BatchUpdateResult is the outcome of one BatchUpdate: User on success,
+// otherwise Error, with Status the status UpdateUser would have got.
+type BatchUpdateResult struct {
+	ID     string `json:"id"`
+	Status int    `json:"status"`
+	User   *User  `json:"user,omitempty"`
+	Error  *Error `json:"error,omitempty"`
+}
+
+type BatchUpdateResponse struct {
+	Results []BatchUpdateResult `json:"results"`
+	Updated int                 `json:"updated"`
+	Failed  int                 `json:"failed"`
+}
+
+// TransactionOperation is one step of Transact. Op is "create", "update" or
+// "delete" and Resource is "user". ID, for update and delete, may be "$N"
+// for what operation N created. Body is a CreateUserRequest or an
+// UpdateUserRequest.
+type TransactionOperation struct {
+	Op       string `json:"op"`
+	Resource string `json:"resource"`
+	ID       string `json:"id,omitempty"`
+	Body     any    `json:"body,omitempty"`
+}
+
+// TransactionResult is the outcome of one TransactionOperation: Outcome is
+// "committed", "rolled_back", "failed" or "skipped".
+type TransactionResult struct {
+	Outcome string `json:"outcome"`
+	Status  int    `json:"status,omitempty"`
+	ID      string `json:"id,omitempty"`
+	User    *User  `json:"user,omitempty"`
+	Error   *Error `json:"error,omitempty"`
+}
+
+type TransactionResponse struct {
+	Committed bool                `json:"committed"`
+	Results   []TransactionResult `json:"results"`
+}
+
+// ListUsersOptions filters and pages ListUsers. Zero values use the server
+// defaults.
+type ListUsersOptions struct {
+	Page            int
+	PerPage         int
+	IncludeInactive bool
+	InactiveSince   string
+	Tags            []string
+	// Metadata filters on metadata values by dot path, e.g. "plan": "pro".
+	Metadata map[string]string
+	// PostCount sets each user's PostCount.
+	PostCount bool
+	// Include embeds related resources in each user: "posts" sets Posts,
+	// and "posts.comments" the Comments of those too.
+	Include []string
+	// TimeBudget, in whole milliseconds, bounds how long the server looks
+	// for users; past it the page is of those it found, and Partial set.
+	TimeBudget time.Duration
+	// After starts the page after the user with this ID, instead of at
+	// Page, as PageLink.After gives it.
+	After string
+}
+
+// StreamUsersOptions filters StreamUsers like the ListUsersOptions of the
+// same names.
+type StreamUsersOptions struct {
+	IncludeInactive bool
+	InactiveSince   string
+	Tags            []string
+	Metadata        map[string]string
+}
+
+// Pagination says whether a list goes on past a page, and where.
+type Pagination struct {
+	HasMore bool `json:"has_more"`
+	// Next is the next page, nil on the last one.
+	Next *PageLink `json:"next,omitempty"`
+}
+
+type PageLink struct {
+	Href    string `json:"href"`
+	Page    int    `json:"page"`
+	PerPage int    `json:"per_page"`
+	// After, on the lists that take it, is the ID of the last entry of
+	// the page before, for starting this one after it: unlike Page, it
+	// doesn't shift as entries are deleted or created.
+	After string `json:"after,omitempty"`
+}
+
+type UsersPage struct {
+	Users     []*User        `json:"users"`
+	TagCounts map[string]int `json:"tag_counts"`
+	Pagination
+	// Partial is set if the time budget ran out before every user was
+	// looked at; Users, TagCounts and Total are then of those that were.
+	Partial bool `json:"partial"`
+	// Total is the number of matching users across all pages, from the
+	// X-Total-Count header.
+	Total int `json:"-"`
+}
+
+type Post struct {
+	ID        string    `json:"id"`
+	AuthorID  string    `json:"author_id"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Comments is only set on a user's Posts, with "posts.comments" in
+	// ListUsersOptions.Include.
+	Comments []*Comment `json:"comments,omitempty"`
+}
+
+type CreatePostRequest struct {
+	AuthorID string `json:"author_id"`
+	Title    string `json:"title"`
+	Body     string `json:"body"`
+}
+
+// UpdatePostRequest changes only the fields that are set.
+type UpdatePostRequest struct {
+	Title *string `json:"title,omitempty"`
+	Body  *string `json:"body,omitempty"`
+}
+
+// ListPostsOptions filters and pages ListPosts. Zero values use the server
+// defaults.
+type ListPostsOptions struct {
+	Page     int
+	PerPage  int
+	AuthorID string
+	// After is as for ListUsersOptions.
+	After string
+}
+
+type PostsPage struct {
+	Posts []*Post `json:"posts"`
+	Pagination
+	// Total is the number of matching posts across all pages, from the
+	// X-Total-Count header.
+	Total int `json:"-"`
+}
+
+type CommentStatus string
+
+const (
+	CommentPublished CommentStatus = "published"
+	CommentHidden    CommentStatus = "hidden"
+)
+
+type Comment struct {
+	ID       string `json:"id"`
+	PostID   string `json:"post_id"`
+	AuthorID string `json:"author_id"`
+	// ParentID is empty for a top-level comment.
+	ParentID  string        `json:"parent_id,omitempty"`
+	Body      string        `json:"body"`
+	Status    CommentStatus `json:"status"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	// ReplyCount is the number of direct replies, counting hidden ones only
+	// when they are listed.
+	ReplyCount *int `json:"reply_count,omitempty"`
+}
+
+type CreateCommentRequest struct {
+	AuthorID string `json:"author_id"`
+	ParentID string `json:"parent_id,omitempty"`
+	Body     string `json:"body"`
+}
+
+// ListCommentsOptions filters and pages ListComments. By default it lists
+// the published top-level comments.
+type ListCommentsOptions struct {
+	Page          int
+	PerPage       int
+	ParentID      string
+	IncludeHidden bool
+	// After is as for ListUsersOptions.
+	After string
+}
+
+type CommentsPage struct {
+	Comments []*Comment `json:"comments"`
+	Pagination
+	// Total is the number of matching comments across all pages, from the
+	// X-Total-Count header.
+	Total int `json:"-"`
+}
+
+// Notification types.
+const (
+	NotificationWelcome = "welcome"
+	NotificationComment = "comment"
+	NotificationReply   = "reply"
+	NotificationMention = "mention"
+)
+
+type Notification struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Type      string    `json:"type"`
+	ActorID   string    `json:"actor_id,omitempty"`
+	PostID    string    `json:"post_id,omitempty"`
+	CommentID string    `json:"comment_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// ReadAt is nil while the notification is unread.
+	ReadAt *time.Time `json:"read_at,omitempty"`
+}
+
+// ListNotificationsOptions pages ListNotifications; Unread leaves out the
+// read ones.
+type ListNotificationsOptions struct {
+	Page    int
+	PerPage int
+	Unread  bool
+}
+
+type NotificationsPage struct {
+	Notifications []*Notification `json:"notifications"`
+	UnreadCount   int             `json:"unread_count"`
+	Pagination
+	// Total is the number of matching notifications across all pages, from
+	// the X-Total-Count header.
+	Total int `json:"-"`
+}
+
+type UserSuggestion struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Kinds of resources Search finds.
+const (
+	SearchUser = "user"
+	SearchPost = "post"
+)
+
+type SearchOptions struct {
+	// Types limits the search to these kinds of resources; empty searches
+	// all of them.
+	Types []string
+	Limit int
+}
+
+// SearchResult is one entry of Search: User is set for SearchUser results,
+// Post for SearchPost ones. Highlights are HTML, with the matched words in
+// <mark> tags.
+type SearchResult struct {
+	Type       string              `json:"type"`
+	ID         string              `json:"id"`
+	Score      float64             `json:"score"`
+	Highlights map[string][]string `json:"highlights"`
+	User       *UserSuggestion     `json:"user,omitempty"`
+	Post       *Post               `json:"post,omitempty"`
+}
+
+// UserLookupResult is one entry of LookupUsers; User is nil when not Found.
+type UserLookupResult struct {
+	ID    string `json:"id"`
+	Found bool   `json:"found"`
+	User  *User  `json:"user,omitempty"`
+}
+
+// UserChange is one entry of UserChanges: which user changed and how.
+type UserChange struct {
+	Seq    int64     `json:"seq"`
+	Time   time.Time `json:"time"`
+	Type   string    `json:"type"`
+	UserID string    `json:"user_id"`
+
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+type UserChanges struct {
+	Changes   []UserChange `json:"changes"`
+	NextSince int64        `json:"next_since"`
+}
+
+type UsernameAvailability struct {
+	Username  string `json:"username"`
+	Available bool   `json:"available"`
+	// Reason is "invalid", "reserved" or "taken" when not Available.
+	Reason string `json:"reason,omitempty"`
+}
+
+type UserPreferences struct {
+	Locale        string                  `json:"locale,omitempty"`
+	Timezone      string                  `json:"timezone,omitempty"`
+	Notifications NotificationPreferences `json:"notifications"`
+}
+
+type UserDataExport struct {
+	ExportedAt time.Time `json:"exported_at"`
+	User       User      `json:"user"`
+	// Preferences is nil if the user never saved any.
+	Preferences *UserPreferences `json:"preferences"`
+	Posts       []*Post          `json:"posts"`
+	Comments    []*Comment       `json:"comments"`
+	Audit       []AuditEntry     `json:"audit"`
+}
+
+type AuditEntry struct {
+	ID      int64     `json:"id"`
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Subject string    `json:"subject"`
+	Data    any       `json:"data,omitempty"`
+	EventID string    `json:"event_id"`
+
+	CorrelationID string `json:"correlation_id,omitempty"`
+	CausationID   string `json:"causation_id,omitempty"`
+}
+
+// EventsPage is a page of GetEvents. Events are audit entries, numbered by
+// their ID.
+type EventsPage struct {
+	Events  []AuditEntry `json:"events"`
+	NextSeq int64        `json:"next_seq"`
+	// OldestSeq is the oldest event still kept; events before it are gone.
+	OldestSeq int64 `json:"oldest_seq"`
+}
+
+// ReplayEventsRequest is the range ReplayEvents delivers again. ToSeq zero
+// means the latest event, and no Targets every one configured.
+type ReplayEventsRequest struct {
+	FromSeq int64    `json:"from_seq"`
+	ToSeq   int64    `json:"to_seq,omitempty"`
+	Targets []string `json:"targets,omitempty"`
+}
+
+type EventReplay struct {
+	Events         int `json:"events"`
+	SecurityEvents int `json:"security_events"`
+	CachePurges    int `json:"cache_purges"`
+	// NextSeq, if set, is where to replay the rest of the range from.
+	NextSeq int64 `json:"next_seq,omitempty"`
+}
+
+// DeadLetter is a background delivery that failed every attempt. Kind is
+// security_event, cache_purge or email, and Status dead or requeued.
+type DeadLetter struct {
+	ID        string              `json:"id"`
+	Kind      string              `json:"kind"`
+	Status    string              `json:"status"`
+	Payload   any                 `json:"payload"`
+	Attempts  []DeadLetterAttempt `json:"attempts"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+type DeadLetterAttempt struct {
+	Time  time.Time `json:"time"`
+	Error string    `json:"error"`
+}
+
+// HealthHistory is what a replica's latest health probes found. Status is
+// up or down, and like UptimePercent and Since empty before the first
+// probe.
+type HealthHistory struct {
+	Status          string             `json:"status,omitempty"`
+	UptimePercent   *float64           `json:"uptime_percent,omitempty"`
+	Probes          int                `json:"probes"`
+	Since           *time.Time         `json:"since,omitempty"`
+	IntervalSeconds int                `json:"interval_seconds"`
+	Transitions     []HealthTransition `json:"transitions"` // newest first
+}
+
+// HealthTransition is a change in what the health probes found. Reason and
+// Components say why the replica was down.
+type HealthTransition struct {
+	Time       time.Time `json:"time"`
+	Status     string    `json:"status"`
+	Reason     string    `json:"reason,omitempty"`
+	Components []string  `json:"components,omitempty"`
+}
+
+type NotificationPreferences struct {
+	Email  *bool  `json:"email,omitempty"`
+	InApp  *bool  `json:"in_app,omitempty"`
+	Digest string `json:"digest,omitempty"`
+}
+
+type LogLevelRequest struct {
+	// Level is "debug", "info", "warn" or "error".
+	Level              string `json:"level"`
+	RevertAfterMinutes int    `json:"revert_after_minutes,omitempty"`
+}
+
+type LogLevelResponse struct {
+	Level    string     `json:"level"`
+	Previous string     `json:"previous"`
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+}
+
+type ImpersonateRequest struct {
+	// Actor names who will act as the user, e.g. a support engineer's email.
+	Actor      string `json:"actor"`
+	Reason     string `json:"reason"`
+	TTLMinutes int    `json:"ttl_minutes,omitempty"`
+}
+
+type ImpersonationToken struct {
+	Token          string    `json:"token"`
+	TokenType      string    `json:"token_type"`
+	UserID         string    `json:"user_id"`
+	ImpersonatedBy string    `json:"impersonated_by"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+type CurrentUser struct {
+	User           *User  `json:"user"`
+	ImpersonatedBy string `json:"impersonated_by,omitempty"`
+	// Issuer is set for a token from another identity provider.
+	Issuer string `json:"issuer,omitempty"`
+	// APIKeyID and Scopes are set when the client uses an API key.
+	APIKeyID string   `json:"api_key_id,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
+	// TokenExpiresAt is nil for an API key that doesn't expire.
+	TokenExpiresAt *time.Time `json:"token_expires_at,omitempty"`
+}
+
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RotatedAt  *time.Time `json:"rotated_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// NewAPIKey is what creating or rotating a key returns; Key is the only
+// time the key is shown.
+type NewAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name string `json:"name"`
+	// Scopes are any of users:read, notifications:read and
+	// notifications:write.
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type ExportFormat string
+
+const (
+	ExportNDJSON ExportFormat = "ndjson"
+	ExportCSV    ExportFormat = "csv"
+)
+
+type ExportStatus string
+
+const (
+	ExportPending   ExportStatus = "pending"
+	ExportRunning   ExportStatus = "running"
+	ExportCompleted ExportStatus = "completed"
+	ExportFailed    ExportStatus = "failed"
+)
+
+// ExportFilters are those of ListUsers.
+type ExportFilters struct {
+	IncludeInactive bool              `json:"include_inactive,omitempty"`
+	Tag             []string          `json:"tag,omitempty"`
+	InactiveSince   string            `json:"inactive_since,omitempty"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+}
+
+type CreateExportRequest struct {
+	// Format defaults to ExportNDJSON.
+	Format  ExportFormat  `json:"format,omitempty"`
+	Filters ExportFilters `json:"filters,omitempty"`
+}
+
+type Export struct {
+	ID          string        `json:"id"`
+	Format      ExportFormat  `json:"format"`
+	Filters     ExportFilters `json:"filters"`
+	Status      ExportStatus  `json:"status"`
+	Error       string        `json:"error,omitempty"`
+	Rows        int           `json:"rows"`
+	Size        int           `json:"size"`
+	CreatedAt   time.Time     `json:"created_at"`
+	CompletedAt *time.Time    `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time    `json:"expires_at,omitempty"`
+	// Timezone is the IANA time zone of a CSV export's timestamps.
+	Timezone string `json:"timezone"`
+	// DownloadURL, relative to the API, is set once the export completed
+	// and works without credentials until DownloadExpiresAt.
+	DownloadURL       string     `json:"download_url,omitempty"`
+	DownloadExpiresAt *time.Time `json:"download_expires_at,omitempty"`
+}
+
+type InvitationStatus string
+
+const (
+	InvitationPending  InvitationStatus = "pending"
+	InvitationAccepted InvitationStatus = "accepted"
+	InvitationRevoked  InvitationStatus = "revoked"
+	InvitationExpired  InvitationStatus = "expired"
+)
+
+type Invitation struct {
+	ID         string           `json:"id"`
+	Email      string           `json:"email"`
+	Name       string           `json:"name,omitempty"`
+	Locale     string           `json:"locale"`
+	Status     InvitationStatus `json:"status"`
+	CreatedAt  time.Time        `json:"created_at"`
+	ExpiresAt  time.Time        `json:"expires_at"`
+	AcceptedAt *time.Time       `json:"accepted_at,omitempty"`
+	// UserID is the user an accepted invitation became.
+	UserID    string     `json:"user_id,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// NewInvitation is what creating an invitation returns; Token is the only
+// time the token is shown.
+type NewInvitation struct {
+	Invitation
+	Token string `json:"token"`
+	// Emailed reports whether the server emailed the invitation, which it
+	// does with APP_URL set.
+	Emailed bool `json:"emailed"`
+}
+
+type CreateInvitationRequest struct {
+	Email  string `json:"email"`
+	Name   string `json:"name,omitempty"`
+	Locale string `json:"locale,omitempty"`
+	// ExpiresAt defaults to 7 days from now.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type AcceptInvitationRequest struct {
+	Username string `json:"username,omitempty"`
+	// Name defaults to the invitation's.
+	Name     string `json:"name,omitempty"`
+	Phone    string `json:"phone,omitempty"`
+	Password string `json:"password"`
+}
+
+type LoginRequest struct {
+	// Login is the username or email.
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+type LoginToken struct {
+	Token     string    `json:"token"`
+	TokenType string    `json:"token_type"`
+	UserID    string    `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type TokenRequest struct {
+	// Token is a user token or API key.
+	Token string `json:"token"`
+	// TokenTypeHint is "access_token" or "api_key", if known.
+	TokenTypeHint string `json:"token_type_hint,omitempty"`
+}
+
+// TokenIntrospection is shaped like an RFC 7662 introspection response.
+// Only Active is set for a token that doesn't work.
+type TokenIntrospection struct {
+	Active    bool   `json:"active"`
+	TokenType string `json:"token_type,omitempty"` // Bearer or api_key
+	Subject   string `json:"sub,omitempty"`
+	TokenID   string `json:"jti,omitempty"`
+	Issuer    string `json:"iss,omitempty"`
+	APIKeyID  string `json:"api_key_id,omitempty"`
+	// Scope is what an API key may do, space-separated.
+	Scope string `json:"scope,omitempty"`
+	// ExpiresAt is in seconds since the Unix epoch; zero for an API key
+	// that doesn't expire.
+	ExpiresAt int64 `json:"exp,omitempty"`
+}
+
+type UnlockResponse struct {
+	UserID         string `json:"user_id"`
+	WasLocked      bool   `json:"was_locked"`
+	FailedAttempts int    `json:"failed_attempts"`
+}
+
+type LeaderStatus struct {
+	// Leader is empty while no replica leads.
+	Leader   string `json:"leader,omitempty"`
+	Replica  string `json:"replica"`
+	IsLeader bool   `json:"is_leader"`
+	// Election is raft, lock or local.
+	Election string `json:"election"`
+}
+
+// FaultRule makes a share of a route's requests wait, fail with Status or
+// lose their connection.
+type FaultRule struct {
+	// Route is a pattern like /v1/users/{id}, /v1/* or *.
+	Route     string  `json:"route"`
+	Method    string  `json:"method,omitempty"`
+	Rate      float64 `json:"rate"`
+	LatencyMS int     `json:"latency_ms,omitempty"`
+	Status    int     `json:"status,omitempty"`
+	Drop      bool    `json:"drop,omitempty"`
+}
+
+type MaintenanceRequest struct {
+	// Mode is off, read_only or on.
+	Mode              string `json:"mode"`
+	Message           string `json:"message,omitempty"`
+	RetryAfterSeconds int    `json:"retry_after_seconds,omitempty"`
+}
+
+type Maintenance struct {
+	Mode              string     `json:"mode"`
+	Message           string     `json:"message,omitempty"`
+	RetryAfterSeconds int        `json:"retry_after_seconds,omitempty"`
+	Since             *time.Time `json:"since,omitempty"`
+}
+
+// Stats are aggregate counts; ComputedAt is when they were taken, which is
+// up to the server's STATS_CACHE_TTL ago.
+type Stats struct {
+	Users        int            `json:"users"`
+	CreatedToday int            `json:"created_today"`
+	ByStatus     map[string]int `json:"by_status"`
+	ByTag        map[string]int `json:"by_tag"`
+	Posts        int            `json:"posts"`
+	Comments     int            `json:"comments"`
+	ComputedAt   time.Time      `json:"computed_at"`
+}
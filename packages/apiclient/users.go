@@ -0,0 +1,461 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Hello calls GET /hello.
+func (c *Client) Hello(ctx context.Context) (string, error) {
+	var out struct {
+		Message string `json:"message"`
+	}
+	_, err := c.do(ctx, http.MethodGet, "/hello", nil, nil, &out)
+	return out.Message, err
+}
+
+// Health calls GET /health and returns nil if the API is up.
+func (c *Client) Health(ctx context.Context) error {
+	_, err := c.do(ctx, http.MethodGet, "/health", nil, nil, nil)
+	return err
+}
+
+// HealthHistory calls GET /admin/health/history, for the replica that
+// answers. The client needs the admin token.
+func (c *Client) HealthHistory(ctx context.Context) (*HealthHistory, error) {
+	var out HealthHistory
+	if _, err := c.do(ctx, http.MethodGet, "/admin/health/history", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Version calls GET /version.
+func (c *Client) Version(ctx context.Context) (*Version, error) {
+	var out Version
+	if _, err := c.do(ctx, http.MethodGet, "/version", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Changelog calls GET /v1/changelog, returning the releases after since,
+// or all of them if since is "".
+func (c *Client) Changelog(ctx context.Context, since string) ([]ChangelogRelease, error) {
+	query := url.Values{}
+	if since != "" {
+		query.Set("since", since)
+	}
+	var out struct {
+		Releases []ChangelogRelease `json:"releases"`
+	}
+	if _, err := c.do(ctx, http.MethodGet, "/v1/changelog", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Releases, nil
+}
+
+// CreateUser calls POST /v1/users.
+func (c *Client) CreateUser(ctx context.Context, req CreateUserRequest) (*User, error) {
+	var out User
+	if _, err := c.do(ctx, http.MethodPost, "/v1/users", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListUsers calls GET /v1/users and returns one page.
+func (c *Client) ListUsers(ctx context.Context, opts ListUsersOptions) (*UsersPage, error) {
+	q := url.Values{}
+	if opts.Page > 0 {
+		q.Set("page", strconv.Itoa(opts.Page))
+	}
+	if opts.PerPage > 0 {
+		q.Set("per_page", strconv.Itoa(opts.PerPage))
+	}
+	if opts.After != "" {
+		q.Set("after", opts.After)
+	}
+	if opts.IncludeInactive {
+		q.Set("include_inactive", "true")
+	}
+	if opts.InactiveSince != "" {
+		q.Set("inactive_since", opts.InactiveSince)
+	}
+	for _, tag := range opts.Tags {
+		q.Add("tag", tag)
+	}
+	for k, v := range opts.Metadata {
+		q.Set("metadata."+k, v)
+	}
+	include := opts.Include
+	if opts.PostCount {
+		include = append(slices.Clone(include), "post_count")
+	}
+	if len(include) > 0 {
+		q.Set("include", strings.Join(include, ","))
+	}
+	if opts.TimeBudget > 0 {
+		q.Set("time_budget", strconv.FormatInt(opts.TimeBudget.Milliseconds(), 10))
+	}
+	var out UsersPage
+	resp, err := c.do(ctx, http.MethodGet, "/v1/users", q, nil, &out)
+	if err != nil {
+		return nil, err
+	}
+	out.Total, _ = strconv.Atoi(resp.Header.Get("X-Total-Count"))
+	return &out, nil
+}
+
+// StreamUsers calls GET /v1/users/stream and yields the users opts selects
+// as they arrive, in ID order, without paging or holding them all. A
+// stream cut short ends with an error. Unlike other reads it isn't retried,
+// since a stream can't pick up where it broke off.
+func (c *Client) StreamUsers(ctx context.Context, opts StreamUsersOptions) iter.Seq2[*User, error] {
+	return func(yield func(*User, error) bool) {
+		q := url.Values{}
+		if opts.IncludeInactive {
+			q.Set("include_inactive", "true")
+		}
+		if opts.InactiveSince != "" {
+			q.Set("inactive_since", opts.InactiveSince)
+		}
+		for _, tag := range opts.Tags {
+			q.Add("tag", tag)
+		}
+		for k, v := range opts.Metadata {
+			q.Set("metadata."+k, v)
+		}
+		u := c.baseURL + "/v1/users/stream"
+		if len(q) > 0 {
+			u += "?" + q.Encode()
+		}
+		resp, err := c.send(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			yield(nil, newError(resp))
+			return
+		}
+		dec := json.NewDecoder(resp.Body)
+		for {
+			var user User
+			if err := dec.Decode(&user); err == io.EOF {
+				return
+			} else if err != nil {
+				yield(nil, fmt.Errorf("apiclient: decode GET /v1/users/stream response: %w", err))
+				return
+			}
+			if !yield(&user, nil) {
+				return
+			}
+		}
+	}
+}
+
+// SearchUsers calls GET /v1/users/search. A limit of 0 uses the server
+// default.
+func (c *Client) SearchUsers(ctx context.Context, q string, limit int) ([]UserSuggestion, error) {
+	query := url.Values{"q": {q}}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var out struct {
+		Results []UserSuggestion `json:"results"`
+	}
+	if _, err := c.do(ctx, http.MethodGet, "/v1/users/search", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Results, nil
+}
+
+// LookupUsers calls POST /v1/users/lookup. Results are in the order of ids.
+func (c *Client) LookupUsers(ctx context.Context, ids []string) ([]UserLookupResult, error) {
+	var out struct {
+		Results []UserLookupResult `json:"results"`
+	}
+	body := struct {
+		IDs []string `json:"ids"`
+	}{ids}
+	if _, err := c.do(ctx, http.MethodPost, "/v1/users/lookup", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return out.Results, nil
+}
+
+// UserChanges calls GET /v1/users/changes, waiting up to wait for a change
+// when there is none after since. Start with since -1, then pass the
+// returned NextSince. Keep wait below the HTTP client's timeout. A 410
+// *Error means changes were missed: list the users again and start over.
+func (c *Client) UserChanges(ctx context.Context, since int64, wait time.Duration) (*UserChanges, error) {
+	query := url.Values{"since": {strconv.FormatInt(since, 10)}}
+	if wait > 0 {
+		query.Set("wait", wait.String())
+	}
+	var out UserChanges
+	if _, err := c.do(ctx, http.MethodGet, "/v1/users/changes", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetUser calls GET /v1/users/{id}.
+func (c *Client) GetUser(ctx context.Context, id string) (*User, error) {
+	var out User
+	if _, err := c.do(ctx, http.MethodGet, "/v1/users/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateUser calls PUT /v1/users/{id}.
+func (c *Client) UpdateUser(ctx context.Context, id string, req UpdateUserRequest) (*User, error) {
+	var out User
+	if _, err := c.do(ctx, http.MethodPut, "/v1/users/"+url.PathEscape(id), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// BatchUpdateUsers calls PATCH /v1/users/batch. Each update succeeds or
+// fails on its own; a result's Error says why it failed.
+func (c *Client) BatchUpdateUsers(ctx context.Context, updates []BatchUpdate) (*BatchUpdateResponse, error) {
+	body := struct {
+		Updates []BatchUpdate `json:"updates"`
+	}{updates}
+	var out BatchUpdateResponse
+	if _, err := c.do(ctx, http.MethodPatch, "/v1/users/batch", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Transact calls POST /v1/batch, running ops as one transaction. A
+// transaction that fails isn't an error: check the response's Committed,
+// and the failed operation's result for why.
+func (c *Client) Transact(ctx context.Context, ops []TransactionOperation) (*TransactionResponse, error) {
+	body := struct {
+		Operations []TransactionOperation `json:"operations"`
+	}{ops}
+	var out TransactionResponse
+	if _, err := c.do(ctx, http.MethodPost, "/v1/batch", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteUser calls DELETE /v1/users/{id}.
+func (c *Client) DeleteUser(ctx context.Context, id string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/v1/users/"+url.PathEscape(id), nil, nil, nil)
+	return err
+}
+
+// EraseUser calls DELETE /v1/users/{id}?mode=erase, which also anonymizes
+// the audit log entries about the user.
+func (c *Client) EraseUser(ctx context.Context, id string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/v1/users/"+url.PathEscape(id), url.Values{"mode": {"erase"}}, nil, nil)
+	return err
+}
+
+// ExportUserData calls GET /v1/users/{id}/data-export.
+func (c *Client) ExportUserData(ctx context.Context, id string) (*UserDataExport, error) {
+	var out UserDataExport
+	if _, err := c.do(ctx, http.MethodGet, "/v1/users/"+url.PathEscape(id)+"/data-export", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetUserStatus calls POST /v1/users/{id}/status.
+func (c *Client) SetUserStatus(ctx context.Context, id string, status UserStatus) (*User, error) {
+	body := struct {
+		Status UserStatus `json:"status"`
+	}{status}
+	return c.userAction(ctx, id, "status", body)
+}
+
+// ActivateUser calls POST /v1/users/{id}/activate.
+func (c *Client) ActivateUser(ctx context.Context, id string) (*User, error) {
+	return c.userAction(ctx, id, "activate", nil)
+}
+
+// DeactivateUser calls POST /v1/users/{id}/deactivate.
+func (c *Client) DeactivateUser(ctx context.Context, id string) (*User, error) {
+	return c.userAction(ctx, id, "deactivate", nil)
+}
+
+func (c *Client) userAction(ctx context.Context, id, action string, body any) (*User, error) {
+	var out User
+	if _, err := c.do(ctx, http.MethodPost, "/v1/users/"+url.PathEscape(id)+"/"+action, nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetUserTags calls PUT /v1/users/{id}/tags and returns the normalized tags.
+func (c *Client) SetUserTags(ctx context.Context, id string, tags []string) ([]string, error) {
+	if tags == nil {
+		tags = []string{}
+	}
+	body := struct {
+		Tags []string `json:"tags"`
+	}{tags}
+	var out struct {
+		Tags []string `json:"tags"`
+	}
+	if _, err := c.do(ctx, http.MethodPut, "/v1/users/"+url.PathEscape(id)+"/tags", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return out.Tags, nil
+}
+
+// GetUserPreferences calls GET /v1/users/{id}/preferences.
+func (c *Client) GetUserPreferences(ctx context.Context, id string) (*UserPreferences, error) {
+	var out UserPreferences
+	if _, err := c.do(ctx, http.MethodGet, "/v1/users/"+url.PathEscape(id)+"/preferences", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetUserPreferences calls PUT /v1/users/{id}/preferences. Omitted fields are
+// reset to their defaults.
+func (c *Client) SetUserPreferences(ctx context.Context, id string, prefs UserPreferences) (*UserPreferences, error) {
+	var out UserPreferences
+	if _, err := c.do(ctx, http.MethodPut, "/v1/users/"+url.PathEscape(id)+"/preferences", nil, prefs, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CheckUsername calls GET /v1/usernames/{name}/available.
+func (c *Client) CheckUsername(ctx context.Context, name string) (*UsernameAvailability, error) {
+	var out UsernameAvailability
+	if _, err := c.do(ctx, http.MethodGet, "/v1/usernames/"+url.PathEscape(name)+"/available", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetLogLevel calls PUT /admin/loglevel. The client needs the admin token,
+// e.g. WithHeader("Authorization", "Bearer "+token).
+func (c *Client) SetLogLevel(ctx context.Context, req LogLevelRequest) (*LogLevelResponse, error) {
+	var out LogLevelResponse
+	if _, err := c.do(ctx, http.MethodPut, "/admin/loglevel", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Login calls POST /v1/auth/login. After too many failures it returns an
+// *Error with Code ACCOUNT_LOCKED or LOGIN_THROTTLED.
+func (c *Client) Login(ctx context.Context, req LoginRequest) (*LoginToken, error) {
+	var out LoginToken
+	if _, err := c.do(ctx, http.MethodPost, "/v1/auth/login", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// IntrospectToken calls POST /v1/auth/introspect.
+func (c *Client) IntrospectToken(ctx context.Context, req TokenRequest) (*TokenIntrospection, error) {
+	var out TokenIntrospection
+	if _, err := c.do(ctx, http.MethodPost, "/v1/auth/introspect", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RevokeToken calls POST /v1/auth/revoke. It succeeds for a token that
+// already doesn't work too.
+func (c *Client) RevokeToken(ctx context.Context, req TokenRequest) error {
+	_, err := c.do(ctx, http.MethodPost, "/v1/auth/revoke", nil, req, nil)
+	return err
+}
+
+// Me calls GET /v1/me. The client needs a user token, e.g.
+// WithHeader("Authorization", "Bearer "+token.Token), or an API key with
+// the users:read scope, sent the same way.
+func (c *Client) Me(ctx context.Context) (*CurrentUser, error) {
+	var out CurrentUser
+	if _, err := c.do(ctx, http.MethodGet, "/v1/me", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Impersonate calls POST /admin/impersonate/{userID}. The client needs the
+// admin token.
+func (c *Client) Impersonate(ctx context.Context, userID string, req ImpersonateRequest) (*ImpersonationToken, error) {
+	var out ImpersonationToken
+	if _, err := c.do(ctx, http.MethodPost, "/admin/impersonate/"+url.PathEscape(userID), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Unlock calls POST /admin/unlock/{userID}. The client needs the admin
+// token.
+func (c *Client) Unlock(ctx context.Context, userID string) (*UnlockResponse, error) {
+	var out UnlockResponse
+	if _, err := c.do(ctx, http.MethodPost, "/admin/unlock/"+url.PathEscape(userID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Leader calls GET /admin/leader. The client needs the admin token.
+func (c *Client) Leader(ctx context.Context) (*LeaderStatus, error) {
+	var out LeaderStatus
+	if _, err := c.do(ctx, http.MethodGet, "/admin/leader", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetFaults calls PUT /admin/faults, replacing the fault rules; nil turns
+// faults off. The client needs the admin token, and the server
+// FAULT_INJECTION.
+func (c *Client) SetFaults(ctx context.Context, rules []FaultRule) ([]FaultRule, error) {
+	if rules == nil {
+		rules = []FaultRule{}
+	}
+	var out struct {
+		Rules []FaultRule `json:"rules"`
+	}
+	if _, err := c.do(ctx, http.MethodPut, "/admin/faults", nil, map[string]any{"rules": rules}, &out); err != nil {
+		return nil, err
+	}
+	return out.Rules, nil
+}
+
+// SetMaintenance calls PUT /admin/maintenance. The client needs the admin
+// token.
+func (c *Client) SetMaintenance(ctx context.Context, req MaintenanceRequest) (*Maintenance, error) {
+	var out Maintenance
+	if _, err := c.do(ctx, http.MethodPut, "/admin/maintenance", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Stats calls GET /v1/stats. The client needs the admin token.
+func (c *Client) Stats(ctx context.Context) (*Stats, error) {
+	var out Stats
+	if _, err := c.do(ctx, http.MethodGet, "/v1/stats", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}